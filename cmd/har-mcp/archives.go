@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// unsafeArchiveNameChars matches characters replaced when deriving archive names from sources
+var unsafeArchiveNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// archive is a loaded HAR file, tools select it by name
+type archive struct {
+	name   string
+	source string
+	// harData is the parsed HAR without the excluded entries, parsed the whole of it, and
+	// parseReport describes how it was parsed
+	harData     *har.HAR
+	parsed      *har.HAR
+	parseReport *harParser.ParseReport
+	// excluded holds the request IDs left out of the analysis by exclude_requests, and
+	// exclusionKey identifies them in analysis keys
+	excluded     map[string]bool
+	exclusionKey string
+	// entityIndex is built by build_entity_index, or by find_entity on first use
+	entityIndex *harParser.EntityIndex
+}
+
+// archiveSummary describes a loaded archive in list_archives
+type archiveSummary struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Entries int    `json:"entries"`
+	// Current is set on the archive tools use when no archive is given
+	Current bool `json:"current"`
+}
+
+// archiveProperty describes the archive parameter of the tools reading a loaded HAR
+func archiveProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Name of the loaded HAR to use, as returned by load_har (defaults to the last loaded one)",
+	}
+}
+
+// withArchive adds the archive parameter to the given tool input properties
+func withArchive(properties map[string]interface{}) map[string]interface{} {
+	properties["archive"] = archiveProperty()
+	return properties
+}
+
+// pageProperty describes the page parameter of the tools listing entries
+func pageProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Only consider the entries of this page load, by page ID as returned by list_pages",
+	}
+}
+
+// withPage adds the page parameter to the given tool input properties
+func withPage(properties map[string]interface{}) map[string]interface{} {
+	properties["page"] = pageProperty()
+	return properties
+}
+
+// scopeToPage returns the HAR of the archive restricted to the entries of a page, request IDs
+// still referring to the whole file, or the whole HAR when page is empty
+func (a *archive) scopeToPage(page string) (*har.HAR, error) {
+	if page == "" {
+		return a.harData, nil
+	}
+	return a.parseReport.PageIndex().Scope(a.harData, page)
+}
+
+// lookupArchive returns the archive with the given name, or the current archive when name is empty
+func (h *HARServer) lookupArchive(name string) (*archive, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.archives) == 0 {
+		return nil, errors.New(h.localize("No HAR file loaded. Please load a HAR file first using load_har."))
+	}
+	if name == "" {
+		name = h.current
+	}
+	loaded, ok := h.archives[name]
+	if !ok {
+		return nil, errors.New(h.localize("unknown archive %q, loaded archives: %s", name, strings.Join(h.archiveNames(), ", ")))
+	}
+	return loaded, nil
+}
+
+// entityIndex returns the entity index of the archive, building it when missing or when rebuild is set
+func (h *HARServer) entityIndex(loaded *archive, rebuild bool) *harParser.EntityIndex {
+	h.mu.RLock()
+	index := loaded.entityIndex
+	h.mu.RUnlock()
+	if index != nil && !rebuild {
+		return index
+	}
+
+	index = h.parser.BuildEntityIndex(loaded.harData)
+	h.mu.Lock()
+	loaded.entityIndex = index
+	h.mu.Unlock()
+	return index
+}
+
+// beacons returns the beacon index of the archive, left out of error rates, nil when include is
+// set so that beacons are counted like other requests
+func (h *HARServer) beacons(loaded *archive, include bool) *harParser.BeaconIndex {
+	if include {
+		return nil
+	}
+	return h.parser.FindBeacons(loaded.harData, loaded.parseReport.CustomFieldIndex(), loaded.parseReport.PageIndex())
+}
+
+// setExclusions replaces the archive with a copy leaving the given request IDs out of the
+// analysis, so that calls in flight keep using the archive they looked up
+func (h *HARServer) setExclusions(loaded *archive, excluded map[string]bool) (*archive, error) {
+	requestIDs := make([]string, 0, len(excluded))
+	for requestID := range excluded {
+		requestIDs = append(requestIDs, requestID)
+	}
+	sort.Strings(requestIDs)
+	harData, err := h.parser.Exclude(loaded.parsed, requestIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request IDs are taken back from the entries left out, so that each is spelled once
+	requestIDs = h.parser.GetHARInfo(harData, nil).ExcludedRequestIDs
+	updated := *loaded
+	updated.harData = harData
+	updated.excluded = make(map[string]bool, len(requestIDs))
+	for _, requestID := range requestIDs {
+		updated.excluded[requestID] = true
+	}
+	updated.exclusionKey = ""
+	if len(requestIDs) > 0 {
+		sum := sha256.Sum256([]byte(strings.Join(requestIDs, ",")))
+		updated.exclusionKey = hex.EncodeToString(sum[:8])
+	}
+	updated.entityIndex = nil
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.archives[loaded.name] != loaded {
+		return nil, errors.New(h.localize("archive %q changed while updating its exclusions, please retry", loaded.name))
+	}
+	h.archives[loaded.name] = &updated
+
+	if len(requestIDs) == 0 {
+		delete(h.workspace.state.Exclusions, loaded.name)
+	} else {
+		if h.workspace.state.Exclusions == nil {
+			h.workspace.state.Exclusions = make(map[string][]string)
+		}
+		h.workspace.state.Exclusions[loaded.name] = requestIDs
+	}
+	if err := h.workspace.save(); err != nil {
+		log.Printf("Failed to persist workspace: %v", err)
+	}
+	return &updated, nil
+}
+
+// listArchives summarizes the loaded archives, by name
+func (h *HARServer) listArchives() []archiveSummary {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	summaries := []archiveSummary{}
+	for _, name := range h.archiveNames() {
+		loaded := h.archives[name]
+		summaries = append(summaries, archiveSummary{
+			Name:    name,
+			Source:  loaded.source,
+			Entries: harParser.AnalyzedEntries(loaded.harData),
+			Current: name == h.current,
+		})
+	}
+	return summaries
+}
+
+// archiveNames returns the sorted names of the loaded archives, the caller must hold mu
+func (h *HARServer) archiveNames() []string {
+	names := make([]string, 0, len(h.archives))
+	for name := range h.archives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// archiveName returns the name a source is loaded under when none is given: the name it is
+// already loaded under, or one derived from its file name. The caller must hold mu.
+func (h *HARServer) archiveName(source string) string {
+	for name, loaded := range h.archives {
+		if loaded.source == source {
+			return name
+		}
+	}
+
+	base := path.Base(strings.SplitN(source, "?", 2)[0])
+	base = strings.TrimSuffix(base, path.Ext(base))
+	base = strings.Trim(unsafeArchiveNameChars.ReplaceAllString(base, "-"), "-")
+	if base == "" {
+		base = "har"
+	}
+
+	name := base
+	for i := 2; h.archives[name] != nil; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// archiveTools lists the tools loading, listing and comparing archives
+func (h *HARServer) archiveTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "list_archives",
+				Description: "List the loaded HAR files with their names, sources and entry counts, and which one tools use when no archive is given",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]interface{}{},
+				},
+			},
+			Handler: h.handleListArchives,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "compare_archives",
+				Description: "Diff two loaded archives, such as captures taken before and after a deploy, by endpoint (same method and URL template): endpoints requested in only one of them, and for the others status code changes, average response size changes above 10% and median latency regressions above a threshold",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive_a": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the archive to compare from, e.g. the capture before the deploy, as returned by load_har",
+						},
+						"archive_b": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the archive to compare to, e.g. the capture after the deploy (defaults to the last loaded one)",
+						},
+						"threshold_ms": map[string]interface{}{
+							"type":        "number",
+							"description": fmt.Sprintf("Increase of the median duration of an endpoint in milliseconds above which it is reported as a regression (defaults to %d)", harParser.DefaultLatencyThresholdMS),
+						},
+					},
+					Required: []string{"archive_a"},
+				},
+			},
+			Handler: h.handleCompareArchives,
+		},
+	}
+}
+
+// handleListArchives handles the list_archives tool call
+func (h *HARServer) handleListArchives(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(h.listArchives(), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal archives: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleCompareArchives handles the compare_archives tool call
+func (h *HARServer) handleCompareArchives(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		ArchiveA    string  `json:"archive_a"`
+		ArchiveB    string  `json:"archive_b"`
+		ThresholdMS float64 `json:"threshold_ms"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+	if args.ArchiveA == "" {
+		return mcp.NewToolResultError(h.localize("archive_a is required")), nil
+	}
+
+	loadedA, err := h.lookupArchive(args.ArchiveA)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	loadedB, err := h.lookupArchive(args.ArchiveB)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	comparison := h.parser.CompareArchives(loadedA.harData, loadedA.parseReport.TimingIndex(), loadedB.harData, loadedB.parseReport.TimingIndex(), args.ThresholdMS)
+	result := struct {
+		ArchiveA string `json:"archive_a"`
+		ArchiveB string `json:"archive_b"`
+		*harParser.ArchiveComparison
+	}{loadedA.name, loadedB.name, comparison}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal archive comparison: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
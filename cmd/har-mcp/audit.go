@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditRecord is one line of the audit log.
+// Arguments are only recorded as a digest so the log never holds the extracted data itself.
+type auditRecord struct {
+	Timestamp       time.Time `json:"timestamp"`
+	Session         string    `json:"session,omitempty"`
+	Tool            string    `json:"tool"`
+	ArgumentsSHA256 string    `json:"arguments_sha256"`
+	ResultBytes     int       `json:"result_bytes"`
+	IsError         bool      `json:"is_error"`
+	DurationMS      int64     `json:"duration_ms"`
+}
+
+// auditLogger appends a JSON line per tool call
+type auditLogger struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// openAuditLog opens the audit log at path in append-only mode
+func openAuditLog(path string) (*auditLogger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLogger{out: file}, nil
+}
+
+// middleware records every tool call once its handler returned
+func (a *auditLogger) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+
+		record := auditRecord{
+			Timestamp:       start.UTC(),
+			Tool:            request.Params.Name,
+			ArgumentsSHA256: digestArguments(request.Params.Arguments),
+			IsError:         err != nil || (result != nil && result.IsError),
+			DurationMS:      time.Since(start).Milliseconds(),
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			record.Session = session.SessionID()
+		}
+		record.ResultBytes = resultSize(result)
+		a.write(record)
+
+		return result, err
+	}
+}
+
+// write appends the record to the log, failures are reported but never fail the tool call
+func (a *auditLogger) write(record auditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Failed to marshal audit record: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.out.Write(append(line, '\n')); err != nil {
+		log.Printf("Failed to write audit record: %v", err)
+	}
+}
+
+// digestArguments hashes the arguments, relying on encoding/json sorting map keys for a stable digest
+func digestArguments(arguments any) string {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resultSize returns the size of the content of a tool result once serialized, as counted by the
+// audit log, the rate limits and the usage statistics
+func resultSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	content, err := json.Marshal(result.Content)
+	if err != nil {
+		return 0
+	}
+	return len(content)
+}
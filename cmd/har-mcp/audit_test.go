@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestToolRequest returns a call of the named tool with the given arguments
+func newTestToolRequest(name string, arguments map[string]any) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	return request
+}
+
+// callThroughAudit calls a handler returning result through the audit middleware and returns the record written
+func callThroughAudit(t *testing.T, request mcp.CallToolRequest, result *mcp.CallToolResult) auditRecord {
+	var out bytes.Buffer
+	handler := (&auditLogger{out: &out}).middleware(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return result, nil
+	})
+
+	_, err := handler(context.Background(), request)
+	require.NoError(t, err)
+
+	var record auditRecord
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	return record
+}
+
+func TestAuditRecordsToolCall(t *testing.T) {
+	result := mcp.NewToolResultText("secret response")
+	record := callThroughAudit(t, newTestToolRequest("get_response_body", map[string]any{"request_id": "request_1"}), result)
+
+	content, err := json.Marshal(result.Content)
+	require.NoError(t, err)
+	assert.Equal(t, "get_response_body", record.Tool)
+	assert.Equal(t, len(content), record.ResultBytes)
+	assert.False(t, record.IsError)
+	assert.Len(t, record.ArgumentsSHA256, 64)
+}
+
+func TestAuditNeverRecordsArguments(t *testing.T) {
+	var out bytes.Buffer
+	handler := (&auditLogger{out: &out}).middleware(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	_, err := handler(context.Background(), newTestToolRequest("search_entries", map[string]any{"query": "hunter2"}))
+
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), "hunter2")
+}
+
+func TestAuditRecordsToolErrors(t *testing.T) {
+	record := callThroughAudit(t, newTestToolRequest("har_info", nil), mcp.NewToolResultError("No HAR file loaded"))
+
+	assert.True(t, record.IsError)
+}
+
+func TestDigestArgumentsIgnoresKeyOrder(t *testing.T) {
+	first := digestArguments(map[string]any{"a": 1, "b": "x"})
+	second := digestArguments(map[string]any{"b": "x", "a": 1})
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, digestArguments(map[string]any{"a": 2, "b": "x"}))
+}
+
+func TestOpenAuditLogAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		logger, err := openAuditLog(path)
+		require.NoError(t, err)
+		logger.write(auditRecord{Tool: "har_info"})
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxCachedAnalyses caps the number of analyzer results kept in memory
+const maxCachedAnalyses = 128
+
+// analysisCache memoizes analyzer results per HAR content, so that repeated tool calls in a
+// conversation do not rescan every entry. Results are keyed by the content hash of the archive,
+// so reloading an unchanged file keeps them and reloading a changed file misses them.
+type analysisCache struct {
+	mu      sync.Mutex
+	results map[string]interface{}
+	// order lists the keys from the least to the most recently stored, for eviction
+	order []string
+}
+
+// newAnalysisCache creates an empty analysis cache
+func newAnalysisCache() *analysisCache {
+	return &analysisCache{results: make(map[string]interface{})}
+}
+
+// get returns the cached result of the analysis of the content, computing and storing it on a
+// miss. Concurrent misses may compute the same result twice, only one is kept.
+func (c *analysisCache) get(contentHash, analysis string, compute func() interface{}) interface{} {
+	if contentHash == "" {
+		return compute()
+	}
+	key := contentHash + "\x00" + analysis
+
+	c.mu.Lock()
+	result, ok := c.results[key]
+	c.mu.Unlock()
+	if ok {
+		return result
+	}
+
+	result = compute()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.results[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.results[key] = result
+	for len(c.order) > maxCachedAnalyses {
+		delete(c.results, c.order[0])
+		c.order = c.order[1:]
+	}
+	return result
+}
+
+// invalidate drops the results of the analyses of the content
+func (c *analysisCache) invalidate(contentHash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := contentHash + "\x00"
+	order := c.order[:0]
+	for _, key := range c.order {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.results, key)
+			continue
+		}
+		order = append(order, key)
+	}
+	c.order = order
+}
+
+// clear drops every result, e.g. when the redaction policy they were computed with changes
+func (c *analysisCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results = make(map[string]interface{})
+	c.order = nil
+}
+
+// analysis returns the result of an analyzer on the archive, computed once per content and
+// analysis key, the key holding the tool name and the arguments the result depends on, and the
+// entries excluded from the analysis
+func (h *HARServer) analysis(loaded *archive, key string, compute func() interface{}) interface{} {
+	contentHash := ""
+	if loaded.parseReport != nil {
+		contentHash = loaded.parseReport.SHA256
+	}
+	// Results computed without the excluded entries are kept apart
+	if loaded.exclusionKey != "" {
+		key += "\x00excluded=" + loaded.exclusionKey
+	}
+	return h.analyses.get(contentHash, key, compute)
+}
+
+// contentInUse reports whether a loaded archive has the given content hash. The caller holds h.mu.
+func (h *HARServer) contentInUse(contentHash string) bool {
+	for _, loaded := range h.archives {
+		if loaded.parseReport != nil && loaded.parseReport.SHA256 == contentHash {
+			return true
+		}
+	}
+	return false
+}
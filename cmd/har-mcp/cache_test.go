@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// computeCounter counts the computations of cached analyses
+type computeCounter struct {
+	calls int
+}
+
+// compute returns a computation of the given result, counted by the counter
+func (c *computeCounter) compute(result string) func() interface{} {
+	return func() interface{} {
+		c.calls++
+		return result
+	}
+}
+
+func TestAnalysisCacheComputesOncePerContent(t *testing.T) {
+	cache := newAnalysisCache()
+	counter := &computeCounter{}
+
+	cache.get("hash", "get_har_stats", counter.compute("stats"))
+	result := cache.get("hash", "get_har_stats", counter.compute("other"))
+
+	assert.Equal(t, "stats", result)
+	assert.Equal(t, 1, counter.calls)
+}
+
+func TestAnalysisCacheKeepsAnalysesApart(t *testing.T) {
+	cache := newAnalysisCache()
+	counter := &computeCounter{}
+
+	cache.get("hash", "get_har_stats|5", counter.compute("five"))
+	result := cache.get("hash", "get_har_stats|10", counter.compute("ten"))
+
+	assert.Equal(t, "ten", result)
+	assert.Equal(t, 2, counter.calls)
+}
+
+func TestAnalysisCacheBypassesContentWithoutHash(t *testing.T) {
+	cache := newAnalysisCache()
+	counter := &computeCounter{}
+
+	cache.get("", "get_har_stats", counter.compute("stats"))
+	cache.get("", "get_har_stats", counter.compute("stats"))
+
+	assert.Equal(t, 2, counter.calls)
+	assert.Empty(t, cache.results)
+}
+
+func TestAnalysisCacheEvictsTheOldestResults(t *testing.T) {
+	cache := newAnalysisCache()
+	counter := &computeCounter{}
+	for i := 0; i <= maxCachedAnalyses; i++ {
+		cache.get("hash", fmt.Sprintf("analysis-%d", i), counter.compute("result"))
+	}
+
+	assert.Len(t, cache.results, maxCachedAnalyses)
+	assert.NotContains(t, cache.results, "hash\x00analysis-0")
+	assert.Contains(t, cache.results, fmt.Sprintf("hash\x00analysis-%d", maxCachedAnalyses))
+}
+
+func TestAnalysisCacheInvalidateDropsTheResultsOfTheContent(t *testing.T) {
+	cache := newAnalysisCache()
+	counter := &computeCounter{}
+	cache.get("replaced", "get_har_stats", counter.compute("stats"))
+	cache.get("kept", "get_har_stats", counter.compute("stats"))
+
+	cache.invalidate("replaced")
+
+	assert.Equal(t, []string{"kept\x00get_har_stats"}, cache.order)
+	assert.NotContains(t, cache.results, "replaced\x00get_har_stats")
+}
+
+func TestAnalysisCacheClearDropsEveryResult(t *testing.T) {
+	cache := newAnalysisCache()
+	counter := &computeCounter{}
+	cache.get("hash", "get_har_stats", counter.compute("stats"))
+
+	cache.clear()
+	cache.get("hash", "get_har_stats", counter.compute("stats"))
+
+	assert.Equal(t, 2, counter.calls)
+}
+
+func TestAddArchiveInvalidatesReplacedContent(t *testing.T) {
+	h := newTestServer(t)
+	counter := &computeCounter{}
+	loaded := h.addArchive("capture", "capture.har", newTestArchive("https://example.com/"), &harParser.ParseReport{SHA256: "before"})
+	h.analysis(loaded, "get_har_stats", counter.compute("stats"))
+
+	h.addArchive("capture", "capture.har", newTestArchive("https://example.com/"), &harParser.ParseReport{SHA256: "after"})
+
+	assert.Empty(t, h.analyses.results)
+}
+
+func TestAddArchiveKeepsContentStillLoaded(t *testing.T) {
+	h := newTestServer(t)
+	counter := &computeCounter{}
+	loaded := h.addArchive("capture", "capture.har", newTestArchive("https://example.com/"), &harParser.ParseReport{SHA256: "shared"})
+	h.addArchive("copy", "copy.har", newTestArchive("https://example.com/"), &harParser.ParseReport{SHA256: "shared"})
+	h.analysis(loaded, "get_har_stats", counter.compute("stats"))
+
+	h.addArchive("capture", "capture.har", newTestArchive("https://example.com/"), &harParser.ParseReport{SHA256: "after"})
+
+	assert.Contains(t, h.analyses.results, "shared\x00get_har_stats")
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// Config holds the server settings provided on the command line or in a configuration file
+type Config struct {
+	// ReadOnly disables every tool with side effects so the server can be exposed to untrusted agents
+	ReadOnly bool `json:"read_only"`
+	// EnableReplay registers replay_request, read-only servers leave it out anyway
+	EnableReplay bool        `json:"enable_replay"`
+	Tools        ToolsConfig `json:"tools"`
+	// AuditLog is the path of the JSONL file recording every tool call, empty disables auditing
+	AuditLog string       `json:"audit_log"`
+	Limits   LimitsConfig `json:"limits"`
+	// Source is a HAR file path, HTTP URL or object storage URI loaded at startup, empty starts without archive
+	Source string `json:"source"`
+	// Fetch authenticates the requests fetching HAR files from HTTP URLs
+	Fetch harParser.FetchOptions `json:"fetch"`
+	// FetchLimits bound the fetches of remote HAR files, so a slow or huge file cannot hang the server
+	FetchLimits harParser.FetchLimits `json:"fetch_limits"`
+	// StateFile persists the workspace across restarts, empty disables persistence
+	StateFile string `json:"state_file"`
+	// GoldenDir stores the fixtures of register_golden and check_against_golden
+	GoldenDir string `json:"golden_dir"`
+	// OutputDir confines the files written by the export tools, empty allows writing anywhere
+	OutputDir string `json:"output_dir"`
+	// TimeZone renders every timestamp in this IANA time zone, empty keeps the offsets of the capture
+	TimeZone string `json:"time_zone"`
+	// Lenient recovers malformed HAR files instead of failing
+	Lenient bool `json:"lenient"`
+	// Redaction configures what is redacted from the contents exposed by the tools
+	Redaction harParser.RedactionPolicy `json:"redaction"`
+	// Scoring adjusts the severities and ranking of the findings to the priorities of the team
+	Scoring harParser.ScoringPolicy `json:"scoring"`
+	// AllowSensitive allows disabling redaction, at startup or with configure_redaction
+	AllowSensitive bool `json:"allow_sensitive"`
+	// Language of the tool descriptions and messages, such as fr or ja, English by default
+	Language string `json:"language"`
+}
+
+// stringList is a command-line flag that can be repeated
+type stringList []string
+
+// String implements flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseHeaders parses the headers given as "Name: value" on the command line
+func parseHeaders(values []string) (map[string]string, error) {
+	headers := make(map[string]string, len(values))
+	for _, value := range values {
+		name, headerValue, ok := strings.Cut(value, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected Name: value", value)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+	}
+	return headers, nil
+}
+
+// ToolsConfig selects the tools the server registers.
+// When Enabled is not empty only the listed tools are registered, Disabled always wins.
+type ToolsConfig struct {
+	Enabled  []string `json:"enabled"`
+	Disabled []string `json:"disabled"`
+}
+
+// loadConfig reads the JSON configuration file at path, an empty path yields the default configuration
+func loadConfig(path string) (Config, error) {
+	var config Config
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse configuration file: %w", err)
+	}
+
+	return config, nil
+}
+
+// isEnabled reports whether the configuration allows registering the named tool
+func (c ToolsConfig) isEnabled(name string) bool {
+	for _, disabled := range c.Disabled {
+		if disabled == name {
+			return false
+		}
+	}
+	if len(c.Enabled) == 0 {
+		return true
+	}
+	for _, enabled := range c.Enabled {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRedaction rejects a policy disabling redaction unless sensitive values are explicitly allowed
+func (c Config) validateRedaction(policy harParser.RedactionPolicy) error {
+	if policy.Disabled && !c.AllowSensitive {
+		return fmt.Errorf("redaction can only be disabled when the server runs with --allow-sensitive")
+	}
+	return nil
+}
+
+// outputPath returns the absolute path of a file written by a tool, inside the output directory when one is configured
+func (c Config) outputPath(path string) (string, error) {
+	if c.OutputDir == "" {
+		return filepath.Abs(path)
+	}
+	return harParser.ResolveOutputPath(c.OutputDir, path)
+}
+
+// validateToolsConfig rejects unknown tool names so a typo cannot silently widen or narrow the exposed surface
+func (h *HARServer) validateToolsConfig() error {
+	known := make(map[string]bool)
+	for _, tool := range h.allTools() {
+		known[tool.Tool.Name] = true
+	}
+
+	for _, names := range [][]string{h.config.Tools.Enabled, h.config.Tools.Disabled} {
+		for _, name := range names {
+			if !known[name] {
+				return fmt.Errorf("unknown tool %q in tools configuration", name)
+			}
+		}
+	}
+
+	return nil
+}
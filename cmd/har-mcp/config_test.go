@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolNames returns the names of the tools the server registers
+func toolNames(h *HARServer) []string {
+	var names []string
+	for _, tool := range h.createTools() {
+		names = append(names, tool.Tool.Name)
+	}
+	return names
+}
+
+func TestToolsConfigEnablesEveryToolByDefault(t *testing.T) {
+	config := ToolsConfig{}
+
+	assert.True(t, config.isEnabled("har_info"))
+	assert.True(t, config.isEnabled("export_subset"))
+}
+
+func TestToolsConfigOnlyEnablesListedTools(t *testing.T) {
+	config := ToolsConfig{Enabled: []string{"har_info", "filter_entries"}}
+
+	assert.True(t, config.isEnabled("har_info"))
+	assert.False(t, config.isEnabled("get_response_body"))
+}
+
+func TestToolsConfigDisabledWinsOverEnabled(t *testing.T) {
+	config := ToolsConfig{Enabled: []string{"har_info", "filter_entries"}, Disabled: []string{"har_info"}}
+
+	assert.False(t, config.isEnabled("har_info"))
+	assert.True(t, config.isEnabled("filter_entries"))
+}
+
+func TestCreateToolsLeavesOutDisabledTools(t *testing.T) {
+	h := NewHARServer(Config{Tools: ToolsConfig{Enabled: []string{"har_info", "load_har"}, Disabled: []string{"load_har"}}})
+
+	assert.Equal(t, []string{"har_info"}, toolNames(h))
+}
+
+func TestValidateToolsConfigRejectsUnknownTools(t *testing.T) {
+	h := NewHARServer(Config{Tools: ToolsConfig{Disabled: []string{"har_inf"}}})
+
+	assert.EqualError(t, h.validateToolsConfig(), `unknown tool "har_inf" in tools configuration`)
+}
+
+func TestValidateToolsConfigAcceptsKnownTools(t *testing.T) {
+	h := NewHARServer(Config{Tools: ToolsConfig{Enabled: []string{"har_info"}, Disabled: []string{"replay_request"}}})
+
+	assert.NoError(t, h.validateToolsConfig())
+}
+
+func TestLoadConfigReadsToolLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tools": {"enabled": ["har_info"], "disabled": ["load_har"]}}`), 0o600))
+
+	config, err := loadConfig(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, ToolsConfig{Enabled: []string{"har_info"}, Disabled: []string{"load_har"}}, config.Tools)
+}
+
+func TestReadOnlyModeLeavesOutToolsWithSideEffects(t *testing.T) {
+	h := NewHARServer(Config{ReadOnly: true, EnableReplay: true})
+
+	names := toolNames(h)
+
+	for _, name := range []string{"save_query", "register_golden", "configure_redaction", "replay_request", "export_redacted_har", "export_subset", "exclude_requests", "include_requests"} {
+		assert.NotContains(t, names, name)
+	}
+	assert.Contains(t, names, "har_info")
+	assert.Contains(t, names, "search_entries")
+	assert.Len(t, names, len(toolNames(NewHARServer(Config{EnableReplay: true})))-8)
+}
+
+func TestReadOnlyModeKeepsOnlyToolsWithoutSideEffects(t *testing.T) {
+	h := NewHARServer(Config{ReadOnly: true, EnableReplay: true})
+
+	for _, tool := range h.createTools() {
+		assert.False(t, hasSideEffects(tool.Tool), tool.Tool.Name)
+	}
+}
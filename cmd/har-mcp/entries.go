@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// entryTools lists the tools inspecting single entries and their contents
+func (h *HARServer) entryTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "har_info",
+				Description: "Get an overview of the loaded HAR file: detected HAR version, creator, entry count, capture time span, the entries excluded from the analysis with exclude_requests and the deviations from the HAR specification tolerated while parsing it",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleHARInfo,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "diff_requests",
+				Description: "Compare the request side (headers, query parameters and body) of two entries, e.g. to explain why a call succeeded once and failed later (authentication headers will be redacted)",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_id_a": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID of the first entry",
+						},
+						"request_id_b": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID of the second entry",
+						},
+					},
+					Required: []string{"request_id_a", "request_id_b"},
+				},
+			},
+			Handler: h.handleDiffRequests,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "header_values",
+				Description: "Tabulate the distinct values of a header and their frequency across all requests or responses, with example request IDs per value (authentication headers will be redacted)",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "The header name (case insensitive), e.g. Content-Type or User-Agent",
+						},
+						"side": map[string]interface{}{
+							"type":        "string",
+							"description": "Whether to inspect request or response headers (defaults to request)",
+							"enum":        []string{"request", "response"},
+						},
+					},
+					Required: []string{"name"},
+				},
+			},
+			Handler: h.handleHeaderValues,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "decode_embedded",
+				Description: "Find base64-looking string fields in JSON request and response bodies, decode them and identify the decoded type (png, pdf, json, jwt, binary...), optionally with decoded previews",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Only scan this request (defaults to every entry)",
+						},
+						"min_length": map[string]interface{}{
+							"type":        "integer",
+							"description": "Minimum length of the strings to consider (defaults to 64)",
+						},
+						"include_previews": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Include a preview of the decoded content, as text or hex",
+						},
+					},
+				},
+			},
+			Handler: h.handleDecodeEmbedded,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "get_response_body",
+				Description: "Read the response body of a request page by page instead of inline, reporting its total size and MIME type. Base64 encoded bodies are decoded, binary bodies are replaced by a marker and pages end on whole characters; use next_offset to read the next page",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID whose response body to read",
+						},
+						"offset": map[string]interface{}{
+							"type":        "integer",
+							"description": "Byte offset to start reading from (defaults to 0)",
+						},
+						"length": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of bytes to return (defaults to %d, at most %d)", harParser.DefaultBodyPageSize, harParser.MaxBodyPageSize),
+						},
+					},
+					Required: []string{"request_id"},
+				},
+			},
+			Handler: h.handleGetResponseBody,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "query_response_body",
+				Description: "Extract values from the JSON response body of a request with a JSONPath expression, instead of reading the whole payload, e.g. $.data.users[0].id, $..email or $.items[?(@.price > 10)].name. Returns each match with its normalized path. The body is decoded and redacted first",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withArchive(map[string]interface{}{
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID whose response body to query",
+						},
+						"expression": map[string]interface{}{
+							"type":        "string",
+							"description": "JSONPath expression: $ root, .name or ['name'] members, [n] items, [start:end:step] slices, [a,b] unions, * wildcards, .. recursive descent, [?(@.member op value)] filters with ==, !=, <, <=, >, >= and [?(@.member)] existence tests",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of matches to return (defaults to %d, at most %d)", harParser.DefaultQueryMatches, harParser.MaxQueryMatches),
+						},
+					}),
+					Required: []string{"request_id", "expression"},
+				},
+			},
+			Handler: h.handleQueryResponseBody,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "get_raw_http",
+				Description: "Render a request and its response as raw HTTP/1.1 text: request line, headers and body, then status line, headers and body, the wire format engineers and external tools reason best over. Sensitive values are redacted, binary bodies replaced by a marker and long bodies cut",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withArchive(map[string]interface{}{
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID to render",
+						},
+						"max_body_bytes": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of bytes of each body to render (defaults to %d)", harParser.DefaultRawBodySize),
+						},
+					}),
+					Required: []string{"request_id"},
+				},
+			},
+			Handler: h.handleGetRawHTTP,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "get_websocket_messages",
+				Description: "Read the WebSocket frames Chrome records on websocket requests (_webSocketMessages), page by page: direction, opcode, timestamp and payload. Text payloads are redacted and truncated, binary frames are replaced by a marker; use next_offset to read the next page",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID of the WebSocket connection (e.g. request_0)",
+						},
+						"direction": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{harParser.WebSocketSend, harParser.WebSocketReceive},
+							"description": "Only return the frames sent or received by the client (defaults to both)",
+						},
+						"offset": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of frames to skip (defaults to 0)",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of frames to return (defaults to %d, at most %d)", harParser.DefaultWebSocketMessages, harParser.MaxWebSocketMessages),
+						},
+					},
+					Required: []string{"request_id"},
+				},
+			},
+			Handler: h.handleGetWebSocketMessages,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "list_cookies",
+				Description: "List every cookie set by Set-Cookie response headers or sent in requests, grouped by domain, with its path, expiry, Secure, HttpOnly and SameSite attributes, how many responses set it and requests sent it, and the security rules it breaks (Secure missing over HTTPS, HttpOnly or SameSite missing). Values are redacted unless allowed by the redaction policy",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleListCookies,
+		},
+	}
+}
+
+// handleHARInfo handles the har_info tool call
+func (h *HARServer) handleHARInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	info := h.parser.GetHARInfo(loaded.harData, loaded.parseReport)
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal HAR info: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleDiffRequests handles the diff_requests tool call
+func (h *HARServer) handleDiffRequests(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		RequestIDA string `json:"request_id_a"`
+		RequestIDB string `json:"request_id_b"`
+		Archive    string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	diff, err := h.parser.DiffRequests(loaded.harData, args.RequestIDA, args.RequestIDB)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error comparing requests: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal request diff: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleHeaderValues handles the header_values tool call
+func (h *HARServer) handleHeaderValues(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Name    string `json:"name"`
+		Side    string `json:"side"`
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	frequency, err := h.parser.GetHeaderValues(loaded.harData, args.Name, args.Side)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error analyzing header values: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(frequency, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal header values: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleDecodeEmbedded handles the decode_embedded tool call
+func (h *HARServer) handleDecodeEmbedded(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		RequestID       string `json:"request_id"`
+		MinLength       int    `json:"min_length"`
+		IncludePreviews bool   `json:"include_previews"`
+		Archive         string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	payloads, err := h.parser.FindEmbeddedBase64(loaded.harData, args.RequestID, args.MinLength, args.IncludePreviews)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error decoding embedded payloads: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(payloads, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal embedded payloads: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleGetResponseBody handles the get_response_body tool call
+func (h *HARServer) handleGetResponseBody(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive   string `json:"archive"`
+		RequestID string `json:"request_id"`
+		Offset    int    `json:"offset"`
+		Length    int    `json:"length"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	body, err := h.parser.GetResponseBody(loaded.harData, args.RequestID, args.Offset, args.Length)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to read response body: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal response body: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleQueryResponseBody handles the query_response_body tool call
+func (h *HARServer) handleQueryResponseBody(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive    string `json:"archive"`
+		RequestID  string `json:"request_id"`
+		Expression string `json:"expression"`
+		Limit      int    `json:"limit"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	query, err := h.parser.QueryResponseBody(loaded.harData, args.RequestID, args.Expression, args.Limit)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error querying response body: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(query, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal query result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleGetRawHTTP handles the get_raw_http tool call
+func (h *HARServer) handleGetRawHTTP(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive      string `json:"archive"`
+		RequestID    string `json:"request_id"`
+		MaxBodyBytes int    `json:"max_body_bytes"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	raw, err := h.parser.GetRawHTTP(loaded.harData, args.RequestID, args.MaxBodyBytes)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error rendering request: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(raw), nil
+}
+
+// handleGetWebSocketMessages handles the get_websocket_messages tool call
+func (h *HARServer) handleGetWebSocketMessages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive   string `json:"archive"`
+		RequestID string `json:"request_id"`
+		Direction string `json:"direction"`
+		Offset    int    `json:"offset"`
+		Limit     int    `json:"limit"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	messages, err := h.parser.GetWebSocketMessages(loaded.harData, loaded.parseReport.WebSocketIndex(), args.RequestID, args.Direction, args.Offset, args.Limit)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error getting WebSocket messages: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal WebSocket messages: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleListCookies handles the list_cookies tool call
+func (h *HARServer) handleListCookies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ListCookies(harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal cookies: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errorTools lists the tools reporting failed requests
+func (h *HARServer) errorTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "error_summary",
+				Description: "Count the failed entries and group them by endpoint and failure kind: network (no response), http_4xx, http_5xx, graphql and jsonrpc for GraphQL and JSON-RPC responses reporting errors despite a 200 status. Each group lists its statuses, error messages and example request IDs. Beacons (sendBeacon and ping), analytics hits and keepalive requests, often cut short at unload, are summarized apart",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"include_beacons": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Count beacons, analytics hits and keepalive requests like other requests instead of summarizing them apart (defaults to false)",
+						},
+					},
+				},
+			},
+			Handler: h.handleErrorSummary,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "list_errors",
+				Description: "List the 4xx and 5xx responses grouped by status code and URL pattern, the dominant failure first. Each group gives its count, example request IDs, a snippet of the first response body and the request headers correlating with the failure compared with the successful requests to the same endpoint, or else host: headers all failures sent with a value no success did, and headers every success sent and no failure did. Header values and snippets are redacted",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withPage(withArchive(map[string]interface{}{
+						"include_beacons": map[string]interface{}{
+							"type":        "boolean",
+							"description": "List beacons, analytics hits and keepalive requests like other requests (defaults to false)",
+						},
+					})),
+				},
+			},
+			Handler: h.handleListErrors,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "soft_error_report",
+				Description: "Flag 2xx responses whose body is shaped like an error, which status-based error summaries miss: JSON objects with an error or errors member, success or ok set to false, a status of error or failed, a 4xx or 5xx code member, and stack traces (Java, .NET, Python, Node.js, PHP, Ruby, Go) in any textual body, with the error message found",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleSoftErrorReport,
+		},
+	}
+}
+
+// handleErrorSummary handles the error_summary tool call
+func (h *HARServer) handleErrorSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive        string `json:"archive"`
+		IncludeBeacons bool   `json:"include_beacons"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	analysis := "error_summary"
+	if args.IncludeBeacons {
+		analysis = "error_summary_with_beacons"
+	}
+	summary := h.analysis(loaded, analysis, func() interface{} {
+		return h.parser.GetErrorSummary(loaded.harData, h.beacons(loaded, args.IncludeBeacons))
+	})
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal error summary: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleListErrors handles the list_errors tool call
+func (h *HARServer) handleListErrors(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive        string `json:"archive"`
+		Page           string `json:"page"`
+		IncludeBeacons bool   `json:"include_beacons"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	list := h.parser.ListErrors(harData, h.beacons(loaded, args.IncludeBeacons))
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal error list: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleSoftErrorReport handles the soft_error_report tool call
+func (h *HARServer) handleSoftErrorReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetSoftErrors(harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal soft error report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/martian/har"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// exclusionTools lists the tools excluding entries from the analysis and bringing them back
+func (h *HARServer) exclusionTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "exclude_requests",
+				Description: "Exclude entries from the analysis for the rest of the session, e.g. to drop analytics noise: the entries with the given request IDs, those matching the filter and the requests to the given domain are left out of every subsequent list, statistic and report, request IDs still referring to the whole file. Returns the entries excluded by the call and every excluded request ID, also shown by har_info",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withArchive(exclusionProperties("Exclude")),
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleExcludeRequests,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "include_requests",
+				Description: "Bring entries excluded with exclude_requests back into the analysis: the entries with the given request IDs, those matching the filter and the requests to the given domain, or every excluded entry with all. Returns the entries included by the call and the request IDs still excluded",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withArchive(includeProperties()),
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleIncludeRequests,
+		},
+	}
+}
+
+// exclusionProperties describes the parameters selecting the entries of exclude_requests, with
+// the given action starting their descriptions
+func exclusionProperties(action string) map[string]interface{} {
+	return map[string]interface{}{
+		"request_ids": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": action + " the entries with these request IDs",
+		},
+		"filter": map[string]interface{}{
+			"type":        "object",
+			"description": action + " the entries matching this filter, every criterion is optional and all of them must match",
+			"properties":  entryFilterProperties(),
+		},
+		"domain": map[string]interface{}{
+			"type":        "string",
+			"description": action + " the requests to every host of this registrable domain (e.g. google-analytics.com)",
+		},
+	}
+}
+
+// includeProperties describes the parameters of include_requests
+func includeProperties() map[string]interface{} {
+	properties := exclusionProperties("Include")
+	properties["all"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Include every excluded entry (defaults to false)",
+	}
+	return properties
+}
+
+// exclusionSelector holds the arguments selecting the entries of exclude_requests and include_requests
+type exclusionSelector struct {
+	RequestIDs []string               `json:"request_ids"`
+	Filter     *harParser.EntryFilter `json:"filter"`
+	Domain     string                 `json:"domain"`
+	Archive    string                 `json:"archive"`
+}
+
+// exclusionResult reports the entries an exclude_requests or include_requests call changed
+type exclusionResult struct {
+	// RequestIDs are the entries the call excluded or included
+	RequestIDs         []string `json:"request_ids"`
+	Excluded           int      `json:"excluded"`
+	ExcludedRequestIDs []string `json:"excluded_request_ids"`
+}
+
+// selectRequestIDs returns the request IDs of the entries of the HAR the selector designates
+func (h *HARServer) selectRequestIDs(loaded *archive, harData *har.HAR, selector exclusionSelector) ([]string, error) {
+	requestIDs := append([]string{}, selector.RequestIDs...)
+	var filters []harParser.EntryFilter
+	if selector.Filter != nil {
+		filters = append(filters, *selector.Filter)
+	}
+	if selector.Domain != "" {
+		filters = append(filters, harParser.EntryFilter{Host: "site:" + selector.Domain})
+	}
+	for _, filter := range filters {
+		matches, err := h.parser.FilterEntries(harData, loaded.parseReport.TimingIndex(), loaded.parseReport.LookupIndex(), loaded.parseReport.CustomFieldIndex(), filter)
+		if err != nil {
+			return nil, errors.New(h.localize("Invalid filter: %v", err))
+		}
+		for _, match := range matches {
+			requestIDs = append(requestIDs, match.RequestID)
+		}
+	}
+	return requestIDs, nil
+}
+
+// exclusionResponse marshals the result of an exclude_requests or include_requests call, the
+// request IDs excluded by one archive and not the other being the ones the call changed
+func (h *HARServer) exclusionResponse(previous, updated *archive) (*mcp.CallToolResult, error) {
+	info := h.parser.GetHARInfo(updated.harData, nil)
+	result := exclusionResult{RequestIDs: []string{}, Excluded: info.Excluded, ExcludedRequestIDs: info.ExcludedRequestIDs}
+	for _, requestID := range info.ExcludedRequestIDs {
+		if !previous.excluded[requestID] {
+			result.RequestIDs = append(result.RequestIDs, requestID)
+		}
+	}
+	for _, requestID := range h.parser.GetHARInfo(previous.harData, nil).ExcludedRequestIDs {
+		if !updated.excluded[requestID] {
+			result.RequestIDs = append(result.RequestIDs, requestID)
+		}
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal exclusions: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleExcludeRequests handles the exclude_requests tool call
+func (h *HARServer) handleExcludeRequests(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args exclusionSelector
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+	if len(args.RequestIDs) == 0 && args.Filter == nil && args.Domain == "" {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: request_ids, filter or domain is required")), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	requestIDs, err := h.selectRequestIDs(loaded, loaded.harData, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	excluded := make(map[string]bool, len(loaded.excluded)+len(requestIDs))
+	for requestID := range loaded.excluded {
+		excluded[requestID] = true
+	}
+	for _, requestID := range requestIDs {
+		excluded[requestID] = true
+	}
+	updated, err := h.setExclusions(loaded, excluded)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error excluding requests: %v", err)), nil
+	}
+
+	return h.exclusionResponse(loaded, updated)
+}
+
+// handleIncludeRequests handles the include_requests tool call
+func (h *HARServer) handleIncludeRequests(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		exclusionSelector
+		All bool `json:"all"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+	if len(args.RequestIDs) == 0 && args.Filter == nil && args.Domain == "" && !args.All {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: request_ids, filter, domain or all is required")), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	requestIDs, err := h.selectRequestIDs(loaded, loaded.parsed, args.exclusionSelector)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// Request IDs are resolved against the whole file, the entries they designate being spelled
+	// as the excluded ones
+	selected, err := h.parser.Exclude(loaded.parsed, requestIDs)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error including requests: %v", err)), nil
+	}
+
+	excluded := make(map[string]bool, len(loaded.excluded))
+	if !args.All {
+		for requestID := range loaded.excluded {
+			excluded[requestID] = true
+		}
+		for _, requestID := range h.parser.GetHARInfo(selected, nil).ExcludedRequestIDs {
+			delete(excluded, requestID)
+		}
+	}
+	updated, err := h.setExclusions(loaded, excluded)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error including requests: %v", err)), nil
+	}
+
+	return h.exclusionResponse(loaded, updated)
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+	"github.com/tjamet/har-mcp/pkg/openapi"
+)
+
+// exportTools lists the tools exporting entries to other formats or files
+func (h *HARServer) exportTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "export_llm_bundle",
+				Description: "Package entries into a single compact text document under a byte or token budget, with noisy headers dropped, secrets redacted and bodies truncated fairly, optimized for pasting into a model prompt",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_ids": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "The request IDs to include (defaults to every entry)",
+						},
+						"max_bytes": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum size of the document in bytes (defaults to 32000)",
+						},
+						"max_tokens": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum size of the document in tokens, approximated as 4 bytes per token",
+						},
+					},
+				},
+			},
+			Handler: h.handleExportLLMBundle,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "export_as_curl",
+				Description: "Reconstruct an equivalent curl command for a request, with its method, URL and query string, headers and body, to replay a captured call from the terminal. Sensitive values are redacted and must be filled in before replaying",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID to export",
+						},
+					},
+					Required: []string{"request_id"},
+				},
+			},
+			Handler: h.handleExportAsCurl,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "export_sarif",
+				Description: "Scan the HAR file for security issues (secrets in headers, query strings and bodies, cookies missing the Secure, HttpOnly or SameSite attributes, plain HTTP and mixed content requests, credentials sent over HTTP, permissive CORS responses) and return them as a SARIF 2.1.0 log ready to upload to code-scanning dashboards. Results are located in the HAR file and in the requests providing evidence, and never include the detected secret values",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleExportSARIF,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "export_openapi",
+				Description: "Generate an OpenAPI 3 skeleton of the APIs called in the HAR file. Requests exchanging JSON are grouped by host and templated path, numeric, UUID and hash segments becoming path parameters, and request and response schemas are inferred from the observed JSON bodies. Pages and assets are left out, and schemas hold property names and types, never observed values",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"host": map[string]interface{}{
+							"type":        "string",
+							"description": "Only describe the requests to the hosts matching this host name, glob (e.g. *.example.com) or site:example.co.uk registrable domain (defaults to every API host, paths listing their servers)",
+						},
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "Title of the document",
+						},
+					},
+				},
+			},
+			Handler: h.handleExportOpenAPI,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "export_redacted_har",
+				Description: "Write the loaded HAR file, or a page load of it, to disk as a HAR 1.2 file with the redaction policy applied to headers, cookies, query parameters, URLs, bodies and WebSocket frames, so a sanitized capture can be shared. Returns the path, size, entry and page counts of the file; with dry_run nothing is written",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"page":    pageProperty(),
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path of the HAR file to write, relative to the output directory when the server confines writes to one",
+						},
+						"overwrite": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Replace the file when it already exists (defaults to false)",
+						},
+						"dry_run": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Describe the file that would be written, with its size and whether it replaces an existing one, without writing it",
+						},
+					},
+					Required: []string{"path"},
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleExportRedactedHAR,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "export_subset",
+				Description: "Write a HAR 1.2 file holding only the selected entries of the loaded HAR file, e.g. just the failing requests to share with a vendor: the entries with the given request IDs and those matching the filter. The creator and the pages of the selected entries are kept and the redaction policy is applied. Returns the path, size, entry and page counts of the file; with dry_run nothing is written",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path of the HAR file to write, relative to the output directory when the server confines writes to one",
+						},
+						"request_ids": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "The request IDs of the entries to write",
+						},
+						"filter": map[string]interface{}{
+							"type":        "object",
+							"description": "Also write the entries matching this filter, every criterion is optional and all of them must match",
+							"properties":  entryFilterProperties(),
+						},
+						"overwrite": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Replace the file when it already exists (defaults to false)",
+						},
+						"dry_run": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Describe the file that would be written, with its size and whether it replaces an existing one, without writing it",
+						},
+					},
+					Required: []string{"path"},
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleExportSubset,
+		},
+	}
+}
+
+// handleExportLLMBundle handles the export_llm_bundle tool call
+func (h *HARServer) handleExportLLMBundle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		RequestIDs []string `json:"request_ids"`
+		MaxBytes   int      `json:"max_bytes"`
+		MaxTokens  int      `json:"max_tokens"`
+		Archive    string   `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	maxBytes := args.MaxBytes
+	if tokenBytes := args.MaxTokens * harParser.BytesPerToken; tokenBytes > 0 && (maxBytes <= 0 || tokenBytes < maxBytes) {
+		maxBytes = tokenBytes
+	}
+
+	bundle, err := h.parser.ExportLLMBundle(loaded.harData, loaded.parseReport.TimingIndex(), args.RequestIDs, maxBytes)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error exporting bundle: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(bundle), nil
+}
+
+// handleExportAsCurl handles the export_as_curl tool call
+func (h *HARServer) handleExportAsCurl(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive   string `json:"archive"`
+		RequestID string `json:"request_id"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	command, err := h.parser.ExportAsCurl(loaded.harData, args.RequestID)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error exporting request: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(command), nil
+}
+
+// handleExportSARIF handles the export_sarif tool call
+func (h *HARServer) handleExportSARIF(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	log := h.parser.ExportSARIF(loaded.harData, loaded.source)
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal SARIF log: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleExportOpenAPI handles the export_openapi tool call
+func (h *HARServer) handleExportOpenAPI(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Host    string `json:"host"`
+		Title   string `json:"title"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := harParser.ValidateHostPattern(args.Host); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	doc := h.analysis(loaded, fmt.Sprintf("export_openapi|%s|%s", args.Host, args.Title), func() interface{} {
+		return openapi.Generate(loaded.harData, openapi.Options{Title: args.Title, Host: args.Host})
+	})
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal OpenAPI document: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleExportRedactedHAR handles the export_redacted_har tool call
+func (h *HARServer) handleExportRedactedHAR(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Path      string `json:"path"`
+		Overwrite bool   `json:"overwrite"`
+		DryRun    bool   `json:"dry_run"`
+		Archive   string `json:"archive"`
+		Page      string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+	if args.Path == "" {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: path is required")), nil
+	}
+	path, err := h.config.outputPath(args.Path)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	export, err := h.parser.ExportHAR(harData, loaded.parseReport, path, harParser.HARExportOptions{DryRun: args.DryRun, Overwrite: args.Overwrite})
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error exporting HAR file: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal HAR export: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleExportSubset handles the export_subset tool call
+func (h *HARServer) handleExportSubset(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Path       string                 `json:"path"`
+		RequestIDs []string               `json:"request_ids"`
+		Filter     *harParser.EntryFilter `json:"filter"`
+		Overwrite  bool                   `json:"overwrite"`
+		DryRun     bool                   `json:"dry_run"`
+		Archive    string                 `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+	if args.Path == "" {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: path is required")), nil
+	}
+	if len(args.RequestIDs) == 0 && args.Filter == nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: request_ids or filter is required")), nil
+	}
+	path, err := h.config.outputPath(args.Path)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	requestIDs := args.RequestIDs
+	if args.Filter != nil {
+		matches, err := h.parser.FilterEntries(loaded.harData, loaded.parseReport.TimingIndex(), loaded.parseReport.LookupIndex(), loaded.parseReport.CustomFieldIndex(), *args.Filter)
+		if err != nil {
+			return mcp.NewToolResultError(h.localize("Invalid filter: %v", err)), nil
+		}
+		for _, match := range matches {
+			requestIDs = append(requestIDs, match.RequestID)
+		}
+	}
+	if len(requestIDs) == 0 {
+		return mcp.NewToolResultError(h.localize("No entry matches the filter")), nil
+	}
+
+	subset, err := h.parser.Subset(loaded.harData, requestIDs)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error exporting HAR file: %v", err)), nil
+	}
+	export, err := h.parser.ExportHAR(subset, loaded.parseReport, path, harParser.HARExportOptions{DryRun: args.DryRun, Overwrite: args.Overwrite})
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error exporting HAR file: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal HAR export: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
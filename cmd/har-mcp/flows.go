@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// flowTools lists the tools following data and calls across requests
+func (h *HARServer) flowTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "data_flow_graph",
+				Description: "Infer dependencies between requests by data flow: values carried by a response (JSON body strings, headers, cookies) and reused by a later request in its URL, headers, cookies or body, as is, base64-encoded or hashed (md5, sha1, sha256). Returns a dependency graph of the API conversation for reverse engineering; values reused as credentials are redacted",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"min_length": map[string]interface{}{
+							"type":        "integer",
+							"description": "Minimum length of the tracked values, shorter values match by chance (defaults to 8)",
+						},
+					},
+				},
+			},
+			Handler: h.handleDataFlowGraph,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "find_callbacks",
+				Description: "Detect the requests back to the first-party site completing a round trip through a third party, such as payment providers redirecting to the merchant in checkout flows: redirects from the provider, return URLs handed to it, and requests from provider pages carrying signed or provider-specific parameters. Each callback reports the provider, the outbound and callback request IDs, the time spent at the provider and the parameter names, never their values",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"first_party": map[string]interface{}{
+							"type":        "string",
+							"description": "Host of the first-party site (defaults to the host of the first HTML document)",
+						},
+					},
+				},
+			},
+			Handler: h.handleFindCallbacks,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "summarize_flow",
+				Description: "Summarize a user flow in a single call: one representative request per method and URL template, the most recent one or the one closest to the median latency, with its status, MIME type, duration and excerpts of its bodies, next to the number of calls, status counts and median latency of the endpoint. The flow is a page load, the entries matching a saved query, or both; the whole HAR file when neither is given",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withArchive(map[string]interface{}{
+						"page": pageProperty(),
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the saved query selecting the entries of the flow",
+						},
+						"pick": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{harParser.FlowPickRecent, harParser.FlowPickMedian},
+							"description": "Request representing each endpoint: the most recent one or the one closest to the median latency (defaults to recent)",
+						},
+						"max_body_bytes": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of bytes of each body excerpt (defaults to %d)", harParser.DefaultFlowBodyBytes),
+						},
+					}),
+				},
+			},
+			Handler: h.handleSummarizeFlow,
+		},
+	}
+}
+
+// handleDataFlowGraph handles the data_flow_graph tool call
+func (h *HARServer) handleDataFlowGraph(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		MinLength int    `json:"min_length"`
+		Archive   string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	graph := h.parser.GetDataFlowGraph(loaded.harData, args.MinLength)
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal data flow graph: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleFindCallbacks handles the find_callbacks tool call
+func (h *HARServer) handleFindCallbacks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive    string `json:"archive"`
+		FirstParty string `json:"first_party"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.FindCallbacks(loaded.harData, args.FirstParty)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal callbacks: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleSummarizeFlow handles the summarize_flow tool call
+func (h *HARServer) handleSummarizeFlow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive      string `json:"archive"`
+		Page         string `json:"page"`
+		Query        string `json:"query"`
+		Pick         string `json:"pick"`
+		MaxBodyBytes int    `json:"max_body_bytes"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if args.Query != "" {
+		filter, err := h.savedQuery(args.Query)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		matches, err := h.parser.FilterEntries(harData, loaded.parseReport.TimingIndex(), loaded.parseReport.LookupIndex(), loaded.parseReport.CustomFieldIndex(), filter)
+		if err != nil {
+			return mcp.NewToolResultError(h.localize("Error running query: %v", err)), nil
+		}
+		requestIDs := make([]string, 0, len(matches))
+		for _, match := range matches {
+			requestIDs = append(requestIDs, match.RequestID)
+		}
+		if harData, err = h.parser.Subset(harData, requestIDs); err != nil {
+			return mcp.NewToolResultError(h.localize("Error summarizing flow: %v", err)), nil
+		}
+	}
+
+	summary, err := h.parser.SummarizeFlow(harData, loaded.parseReport.TimingIndex(), args.Pick, args.MaxBodyBytes)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error summarizing flow: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal flow summary: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
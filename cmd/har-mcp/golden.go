@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// goldenTools lists the tools recording golden fixtures and checking captures against them
+func (h *HARServer) goldenTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "register_golden",
+				Description: "Store the responses of the given requests as golden fixtures on disk, one per endpoint (method and URL template), replacing previous fixtures of the same endpoints",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"request_ids": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "The request IDs whose responses become golden fixtures",
+						},
+						"dry_run": map[string]interface{}{
+							"type":        "boolean",
+							"description": "List the fixture files that would be written, with their sizes and whether they replace existing ones, without writing them",
+						},
+					},
+					Required: []string{"request_ids"},
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleRegisterGolden,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "check_against_golden",
+				Description: "Compare the responses of the loaded HAR file against the golden fixtures of their endpoints and report drift: status, MIME type and JSON structure changes",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleCheckAgainstGolden,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "export_junit",
+				Description: "Render the checks run against the HAR file as a JUnit XML report for CI test reporting: contract checks against the golden fixtures when a golden directory is configured, one test case per security scan rule, and one per OAuth audit check when the capture holds an OAuth flow. Failed test cases list the requests providing evidence",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleExportJUnit,
+		},
+	}
+}
+
+// handleRegisterGolden handles the register_golden tool call
+func (h *HARServer) handleRegisterGolden(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if h.config.GoldenDir == "" {
+		return mcp.NewToolResultError(h.localize("No golden directory configured. Start the server with --golden-dir.")), nil
+	}
+
+	var args struct {
+		RequestIDs []string `json:"request_ids"`
+		Archive    string   `json:"archive"`
+		DryRun     bool     `json:"dry_run"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if args.DryRun {
+		var (
+			lines   []string
+			planned = make(map[string]bool)
+		)
+		for _, requestID := range args.RequestIDs {
+			fixture, err := h.parser.NewGoldenFixture(loaded.harData, requestID)
+			if err != nil {
+				return mcp.NewToolResultError(h.localize("Error recording golden fixture: %v", err)), nil
+			}
+			preview, err := harParser.PreviewGoldenFixture(h.config.GoldenDir, fixture)
+			if err != nil {
+				return mcp.NewToolResultError(h.localize("Error saving golden fixture: %v", err)), nil
+			}
+			if preview.Replaces || planned[preview.Path] {
+				lines = append(lines, h.localize("%s (%d bytes, replacing the existing fixture)", preview.Path, preview.Bytes))
+			} else {
+				lines = append(lines, h.localize("%s (%d bytes)", preview.Path, preview.Bytes))
+			}
+			planned[preview.Path] = true
+		}
+		return mcp.NewToolResultText(h.localize("Dry run, would register %d golden fixtures:\n%s", len(lines), strings.Join(lines, "\n"))), nil
+	}
+
+	var paths []string
+	for _, requestID := range args.RequestIDs {
+		fixture, err := h.parser.NewGoldenFixture(loaded.harData, requestID)
+		if err != nil {
+			return mcp.NewToolResultError(h.localize("Error recording golden fixture: %v", err)), nil
+		}
+		path, err := harParser.SaveGoldenFixture(h.config.GoldenDir, fixture)
+		if err != nil {
+			return mcp.NewToolResultError(h.localize("Error saving golden fixture: %v", err)), nil
+		}
+		paths = append(paths, path)
+	}
+
+	return mcp.NewToolResultText(h.localize("Registered %d golden fixtures:\n%s", len(paths), strings.Join(paths, "\n"))), nil
+}
+
+// handleCheckAgainstGolden handles the check_against_golden tool call
+func (h *HARServer) handleCheckAgainstGolden(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if h.config.GoldenDir == "" {
+		return mcp.NewToolResultError(h.localize("No golden directory configured. Start the server with --golden-dir.")), nil
+	}
+
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	fixtures, err := harParser.LoadGoldenFixtures(h.config.GoldenDir)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error loading golden fixtures: %v", err)), nil
+	}
+
+	report := h.parser.CheckAgainstGolden(loaded.harData, fixtures)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal golden report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleExportJUnit handles the export_junit tool call
+func (h *HARServer) handleExportJUnit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var fixtures []harParser.GoldenFixture
+	if h.config.GoldenDir != "" {
+		fixtures, err = harParser.LoadGoldenFixtures(h.config.GoldenDir)
+		if err != nil {
+			return mcp.NewToolResultError(h.localize("Error loading golden fixtures: %v", err)), nil
+		}
+	}
+
+	report := h.parser.ExportJUnit(loaded.harData, fixtures)
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal JUnit report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(xml.Header + string(data)), nil
+}
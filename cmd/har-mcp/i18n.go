@@ -0,0 +1,112 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultLanguage is the language the tool descriptions and messages are written in
+const defaultLanguage = "en"
+
+// localeFiles holds a translation catalog per language other than English
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalog holds the translations of a language, English being used for the missing ones
+type catalog struct {
+	// Tools are the descriptions of the tools, by tool name
+	Tools map[string]string `json:"tools"`
+	// Parameters are the descriptions of the tool parameters, keyed by tool and parameter name
+	// such as load_har.source, or by parameter name alone for the parameters shared by the tools
+	Parameters map[string]string `json:"parameters"`
+	// Messages are the format strings of the messages, keyed by their English format
+	Messages map[string]string `json:"messages"`
+}
+
+// languages returns the supported languages, English first
+func languages() []string {
+	supported := []string{defaultLanguage}
+	entries, _ := localeFiles.ReadDir("locales")
+	for _, entry := range entries {
+		supported = append(supported, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+	return supported
+}
+
+// loadCatalog returns the translations of the language, empty for English
+func loadCatalog(language string) (*catalog, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" || language == defaultLanguage {
+		return &catalog{}, nil
+	}
+	if !slices.Contains(languages(), language) {
+		return nil, fmt.Errorf("unsupported language %q, expected one of %s", language, strings.Join(languages(), ", "))
+	}
+
+	data, err := localeFiles.ReadFile("locales/" + language + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s translations: %w", language, err)
+	}
+	translations := &catalog{}
+	if err := json.Unmarshal(data, translations); err != nil {
+		return nil, fmt.Errorf("failed to parse %s translations: %w", language, err)
+	}
+	return translations, nil
+}
+
+// message returns the translation of the format string, the format itself when it has none
+func (c *catalog) message(format string) string {
+	if translated, ok := c.Messages[format]; ok {
+		return translated
+	}
+	return format
+}
+
+// localizeTool returns a copy of the tool whose description and parameter descriptions are translated
+func (c *catalog) localizeTool(tool mcp.Tool) mcp.Tool {
+	if description, ok := c.Tools[tool.Name]; ok {
+		tool.Description = description
+	}
+	tool.InputSchema.Properties = c.localizeProperties(tool.Name, tool.InputSchema.Properties)
+	return tool
+}
+
+// localizeProperties returns a copy of the properties of a tool input schema whose descriptions
+// are translated, along with the ones of nested objects
+func (c *catalog) localizeProperties(toolName string, properties map[string]interface{}) map[string]interface{} {
+	if len(c.Parameters) == 0 || properties == nil {
+		return properties
+	}
+	localized := make(map[string]interface{}, len(properties))
+	for name, property := range properties {
+		schema, ok := property.(map[string]interface{})
+		if !ok {
+			localized[name] = property
+			continue
+		}
+		schema = maps.Clone(schema)
+		if description, ok := c.Parameters[toolName+"."+name]; ok {
+			schema["description"] = description
+		} else if description, ok := c.Parameters[name]; ok {
+			schema["description"] = description
+		}
+		if nested, ok := schema["properties"].(map[string]interface{}); ok {
+			schema["properties"] = c.localizeProperties(toolName, nested)
+		}
+		localized[name] = schema
+	}
+	return localized
+}
+
+// localize formats a message in the language of the server
+func (h *HARServer) localize(format string, args ...interface{}) string {
+	return fmt.Sprintf(h.catalog.message(format), args...)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatVerb matches the verbs of a format string
+var formatVerb = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+
+// sampleArguments returns arguments fitting the verbs of an English format string
+func sampleArguments(format string) []interface{} {
+	var args []interface{}
+	for _, verb := range formatVerb.FindAllString(format, -1) {
+		switch verb[len(verb)-1] {
+		case '%':
+		case 'd':
+			args = append(args, 1)
+		default:
+			args = append(args, "x")
+		}
+	}
+	return args
+}
+
+// assertMessagesKeepTheirArguments checks every message of the language formats the arguments of its English format
+func assertMessagesKeepTheirArguments(t *testing.T, language string) {
+	translations, err := loadCatalog(language)
+	require.NoError(t, err)
+	require.NotEmpty(t, translations.Messages)
+
+	for format, translated := range translations.Messages {
+		assert.NotContains(t, fmt.Sprintf(translated, sampleArguments(format)...), "%!", "translation of %q", format)
+	}
+}
+
+// parameterKeys returns the keys a parameter translation may use: tool and parameter names, or parameter names alone
+func parameterKeys(h *HARServer) map[string]bool {
+	keys := make(map[string]bool)
+	var add func(toolName string, properties map[string]interface{})
+	add = func(toolName string, properties map[string]interface{}) {
+		for name, property := range properties {
+			keys[toolName+"."+name] = true
+			keys[name] = true
+			if schema, ok := property.(map[string]interface{}); ok {
+				if nested, ok := schema["properties"].(map[string]interface{}); ok {
+					add(toolName, nested)
+				}
+			}
+		}
+	}
+	for _, tool := range h.createTools() {
+		add(tool.Tool.Name, tool.Tool.InputSchema.Properties)
+	}
+	return keys
+}
+
+// assertParametersExist checks every parameter translation of the language applies to a tool parameter
+func assertParametersExist(t *testing.T, language string) {
+	translations, err := loadCatalog(language)
+	require.NoError(t, err)
+	keys := parameterKeys(NewHARServer(Config{EnableReplay: true}))
+
+	for key := range translations.Parameters {
+		assert.True(t, keys[key], "parameter translation %q matches no tool parameter", key)
+	}
+}
+
+func TestFrenchMessagesKeepTheirArguments(t *testing.T) {
+	assertMessagesKeepTheirArguments(t, "fr")
+}
+
+func TestJapaneseMessagesKeepTheirArguments(t *testing.T) {
+	assertMessagesKeepTheirArguments(t, "ja")
+}
+
+func TestFrenchParametersExist(t *testing.T) {
+	assertParametersExist(t, "fr")
+}
+
+func TestJapaneseParametersExist(t *testing.T) {
+	assertParametersExist(t, "ja")
+}
+
+func TestLoadCatalogRejectsUnsupportedLanguages(t *testing.T) {
+	_, err := loadCatalog("xx")
+
+	assert.EqualError(t, err, `unsupported language "xx", expected one of en, fr, ja`)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// rateWindow is the sliding window over which calls and returned bytes are counted
+const rateWindow = time.Minute
+
+// LimitsConfig caps the work a single client session can ask for, zero disables a limit.
+// There is no concurrency limit: the stdio transport serves the calls of its single session one
+// at a time.
+type LimitsConfig struct {
+	MaxCallsPerMinute int `json:"max_calls_per_minute"`
+	MaxBytesPerMinute int `json:"max_bytes_per_minute"`
+}
+
+// enabled reports whether any limit is configured
+func (c LimitsConfig) enabled() bool {
+	return c.MaxCallsPerMinute > 0 || c.MaxBytesPerMinute > 0
+}
+
+// usageEvent is a past tool call with the number of bytes it returned
+type usageEvent struct {
+	at    time.Time
+	bytes int
+}
+
+// sessionUsage tracks the recent activity of one client session
+type sessionUsage struct {
+	// running counts the calls in progress, which count against the call rate
+	running int
+	events  []usageEvent
+}
+
+// forget drops the events out of the window, returning the bytes returned by the remaining ones
+func (u *sessionUsage) forget(now time.Time) int {
+	recent := u.events[:0]
+	bytes := 0
+	for _, event := range u.events {
+		if now.Sub(event.at) < rateWindow {
+			recent = append(recent, event)
+			bytes += event.bytes
+		}
+	}
+	u.events = recent
+	return bytes
+}
+
+// rateLimiter enforces LimitsConfig per client session
+type rateLimiter struct {
+	config LimitsConfig
+	// catalog translates the rejections
+	catalog  *catalog
+	mu       sync.Mutex
+	sessions map[string]*sessionUsage
+}
+
+// newRateLimiter creates a rate limiter enforcing the given limits
+func newRateLimiter(config LimitsConfig, catalog *catalog) *rateLimiter {
+	return &rateLimiter{
+		config:   config,
+		catalog:  catalog,
+		sessions: make(map[string]*sessionUsage),
+	}
+}
+
+// middleware rejects tool calls exceeding the limits of the calling session
+func (r *rateLimiter) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session := ""
+		if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+			session = clientSession.SessionID()
+		}
+
+		if err := r.acquire(session); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf(r.catalog.message("Rate limit exceeded: %v"), err)), nil
+		}
+
+		result, err := next(ctx, request)
+		r.release(session, resultSize(result))
+
+		return result, err
+	}
+}
+
+// acquire registers the start of a call, or explains which limit it would exceed
+func (r *rateLimiter) acquire(session string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.prune(session, now)
+	usage, ok := r.sessions[session]
+	if !ok {
+		usage = &sessionUsage{}
+		r.sessions[session] = usage
+	}
+	bytes := usage.forget(now)
+
+	switch {
+	case r.config.MaxCallsPerMinute > 0 && len(usage.events)+usage.running >= r.config.MaxCallsPerMinute:
+		return fmt.Errorf(r.catalog.message("%d tool calls in the last minute, the maximum is %d"), len(usage.events)+usage.running, r.config.MaxCallsPerMinute)
+	case r.config.MaxBytesPerMinute > 0 && bytes >= r.config.MaxBytesPerMinute:
+		return fmt.Errorf(r.catalog.message("%d bytes returned in the last minute, the maximum is %d"), bytes, r.config.MaxBytesPerMinute)
+	}
+
+	usage.running++
+	return nil
+}
+
+// prune drops the sessions other than the given one without call in progress nor in the
+// window, so that sessions of departed clients do not accumulate. The caller holds r.mu.
+func (r *rateLimiter) prune(session string, now time.Time) {
+	for name, usage := range r.sessions {
+		if name == session || usage.running > 0 {
+			continue
+		}
+		if usage.forget(now); len(usage.events) == 0 {
+			delete(r.sessions, name)
+		}
+	}
+}
+
+// release registers the end of a call and the size of its result
+func (r *rateLimiter) release(session string, size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := r.sessions[session]
+	usage.running--
+	usage.events = append(usage.events, usageEvent{at: time.Now(), bytes: size})
+}
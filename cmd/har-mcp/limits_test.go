@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callThroughLimiter calls a handler returning text through the rate limiter middleware
+func callThroughLimiter(t *testing.T, limiter *rateLimiter, text string) *mcp.CallToolResult {
+	handler := limiter.middleware(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(text), nil
+	})
+	result, err := handler(context.Background(), newTestToolRequest("har_info", nil))
+	require.NoError(t, err)
+	return result
+}
+
+func TestRateLimiterRejectsCallsOverTheCallRate(t *testing.T) {
+	limiter := newRateLimiter(LimitsConfig{MaxCallsPerMinute: 2}, &catalog{})
+
+	assert.False(t, callThroughLimiter(t, limiter, "ok").IsError)
+	assert.False(t, callThroughLimiter(t, limiter, "ok").IsError)
+	result := callThroughLimiter(t, limiter, "ok")
+
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "2 tool calls in the last minute, the maximum is 2")
+}
+
+func TestRateLimiterRejectsCallsOverTheByteRate(t *testing.T) {
+	limiter := newRateLimiter(LimitsConfig{MaxBytesPerMinute: 100}, &catalog{})
+
+	assert.False(t, callThroughLimiter(t, limiter, strings.Repeat("x", 200)).IsError)
+	result := callThroughLimiter(t, limiter, "ok")
+
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "bytes returned in the last minute, the maximum is 100")
+}
+
+func TestRateLimiterForgetsCallsOutOfTheWindow(t *testing.T) {
+	limiter := newRateLimiter(LimitsConfig{MaxCallsPerMinute: 1}, &catalog{})
+	limiter.sessions[""] = &sessionUsage{events: []usageEvent{{at: time.Now().Add(-2 * rateWindow)}}}
+
+	assert.NoError(t, limiter.acquire(""))
+}
+
+func TestRateLimiterCountsSessionsApart(t *testing.T) {
+	limiter := newRateLimiter(LimitsConfig{MaxCallsPerMinute: 1}, &catalog{})
+
+	require.NoError(t, limiter.acquire("first"))
+	limiter.release("first", 0)
+
+	assert.NoError(t, limiter.acquire("second"))
+	assert.Error(t, limiter.acquire("first"))
+}
+
+func TestRateLimiterDropsIdleSessions(t *testing.T) {
+	limiter := newRateLimiter(LimitsConfig{MaxCallsPerMinute: 10}, &catalog{})
+	limiter.sessions["departed"] = &sessionUsage{events: []usageEvent{{at: time.Now().Add(-2 * rateWindow)}}}
+	require.NoError(t, limiter.acquire("running"))
+	require.NoError(t, limiter.acquire("recent"))
+	limiter.release("recent", 0)
+
+	require.NoError(t, limiter.acquire("current"))
+
+	assert.NotContains(t, limiter.sessions, "departed")
+	assert.Contains(t, limiter.sessions, "running")
+	assert.Contains(t, limiter.sessions, "recent")
+}
+
+func TestRateLimiterLocalizesRejections(t *testing.T) {
+	translations, err := loadCatalog("fr")
+	require.NoError(t, err)
+	limiter := newRateLimiter(LimitsConfig{MaxCallsPerMinute: 1}, translations)
+
+	require.False(t, callThroughLimiter(t, limiter, "ok").IsError)
+	result := callThroughLimiter(t, limiter, "ok")
+
+	assert.Equal(t, "Limite de débit dépassée : 1 appels d'outils au cours de la dernière minute, le maximum est 1", result.Content[0].(mcp.TextContent).Text)
+}
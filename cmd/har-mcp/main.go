@@ -3,9 +3,19 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	// Embed the time zone database so --time-zone works on minimal images
+	_ "time/tzdata"
 
 	"github.com/google/martian/har"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,42 +23,280 @@ import (
 	harParser "github.com/tjamet/har-mcp/pkg/har"
 )
 
+// sourceEnv names the environment variable holding the HAR file loaded at startup when --har is not set
+const sourceEnv = "HAR_MCP_SOURCE"
+
+// Environment variables holding the credentials fetching HAR files from URLs, kept out of the
+// command line where other users could read them
+const (
+	fetchTokenEnv    = "HAR_MCP_FETCH_TOKEN"
+	fetchUsernameEnv = "HAR_MCP_FETCH_USERNAME"
+	fetchPasswordEnv = "HAR_MCP_FETCH_PASSWORD"
+)
+
 // HARServer implements the MCP server for HAR file analysis
 type HARServer struct {
-	parser  *harParser.Parser
-	harData *har.HAR
+	config Config
+	parser *harParser.Parser
+	// mu guards archives, current and the workspace state. The stdio transport serves tool calls
+	// one at a time, but the handlers stay safe for transports serving them concurrently.
+	mu sync.RWMutex
+	// archives are the loaded HAR files by name, current is the one used by default
+	archives map[string]*archive
+	current  string
+	// workspace holds the working context, persisted across restarts when a state file is set
+	workspace *workspace
+	// analyses caches the results of the heavier analyzers per archive content
+	analyses *analysisCache
+	// catalog translates the tool descriptions and messages to the configured language
+	catalog *catalog
+	// resources publishes the loaded archives as MCP resources once the server is created
+	resources *server.MCPServer
+	// stats counts the tool calls served, for server_stats
+	stats *usageStats
 }
 
 // NewHARServer creates a new HAR MCP server
-func NewHARServer() *HARServer {
+func NewHARServer(config Config) *HARServer {
 	return &HARServer{
-		parser: harParser.NewParser(),
+		config:    config,
+		parser:    harParser.NewParser(),
+		archives:  make(map[string]*archive),
+		workspace: &workspace{},
+		analyses:  newAnalysisCache(),
+		catalog:   &catalog{},
+		stats:     newUsageStats(),
+	}
+}
+
+// loadHAR loads a HAR file from the given source under the given name, deriving a name from
+// the source when empty, and makes it the current archive. URLs are fetched with the given
+// options over the configured ones, which are not persisted in the workspace, and abandoned when
+// the context is done.
+func (h *HARServer) loadHAR(ctx context.Context, name, source string, options harParser.FetchOptions) (*archive, error) {
+	harData, parseReport, err := h.parser.ParseSourceWithOptions(ctx, source, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load HAR: %w", err)
+	}
+
+	loaded := h.addArchive(name, source, harData, parseReport)
+	h.publishArchive(loaded)
+	return loaded, nil
+}
+
+// addArchive stores a parsed HAR file under the given name, deriving a name from the source when
+// empty, makes it the current archive and persists the workspace
+func (h *HARServer) addArchive(name, source string, harData *har.HAR, parseReport *harParser.ParseReport) *archive {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	source = absoluteSource(source)
+	if name == "" {
+		name = h.archiveName(source)
+	}
+	loaded := &archive{name: name, source: source, harData: harData, parsed: harData, parseReport: parseReport}
+	previous := h.archives[name]
+	h.archives[name] = loaded
+	h.current = name
+	// Drop the results of a replaced archive whose content no archive holds anymore
+	if previous != nil && previous.parseReport != nil && !h.contentInUse(previous.parseReport.SHA256) {
+		h.analyses.invalidate(previous.parseReport.SHA256)
+	}
+
+	if h.workspace.state.Archives == nil {
+		h.workspace.state.Archives = make(map[string]string)
+	}
+	h.workspace.state.Archives[name] = source
+	h.workspace.state.Current = name
+	// A loaded file starts with every entry in the analysis
+	delete(h.workspace.state.Exclusions, name)
+	if err := h.workspace.save(); err != nil {
+		log.Printf("Failed to persist workspace: %v", err)
 	}
+	return loaded
 }
 
-// loadHAR loads a HAR file from the given source
-func (h *HARServer) loadHAR(source string) error {
-	harData, err := h.parser.ParseSource(source)
+// restoreWorkspace reloads the HAR files referenced by the persisted workspace, if any
+func (h *HARServer) restoreWorkspace() {
+	state := h.workspace.state
+	// Loading an archive drops its exclusions from the state, they are set again once it is loaded
+	exclusions := maps.Clone(state.Exclusions)
+	if state.Redaction != nil {
+		if err := h.applyRedaction(*state.Redaction); err != nil {
+			log.Printf("Failed to restore redaction policy: %v", err)
+		}
+	}
+	names := make([]string, 0, len(state.Archives))
+	for name := range state.Archives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		loaded, err := h.loadHAR(context.Background(), name, state.Archives[name], harParser.FetchOptions{})
+		if err != nil {
+			log.Printf("Failed to restore archive %s: %v", name, err)
+			continue
+		}
+		if len(exclusions[name]) == 0 {
+			continue
+		}
+		excluded := make(map[string]bool, len(exclusions[name]))
+		for _, requestID := range exclusions[name] {
+			excluded[requestID] = true
+		}
+		if _, err := h.setExclusions(loaded, excluded); err != nil {
+			log.Printf("Failed to restore the exclusions of archive %s: %v", name, err)
+		}
+	}
+	if state.Source != "" && len(state.Archives) == 0 {
+		// State files written before named archives hold a single source
+		h.workspace.state.Source = ""
+		if _, err := h.loadHAR(context.Background(), "", state.Source, harParser.FetchOptions{}); err != nil {
+			log.Printf("Failed to restore workspace: %v", err)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.archives[state.Current] != nil {
+		h.current = state.Current
+		h.workspace.state.Current = state.Current
+	}
+}
+
+// loadStartupArchives restores the workspace of the state file, then loads the HAR file of the
+// configuration, if any, and makes it the current archive. The server must not start when it fails.
+func (h *HARServer) loadStartupArchives(ctx context.Context) error {
+	if h.config.StateFile != "" {
+		w, err := openWorkspace(h.config.StateFile)
+		if err != nil {
+			return err
+		}
+		h.workspace = w
+		h.restoreWorkspace()
+	}
+	if h.config.Source == "" {
+		return nil
+	}
+
+	loaded, err := h.loadHAR(ctx, "", h.config.Source, harParser.FetchOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to load HAR: %w", err)
+		return fmt.Errorf("%s: %w", h.config.Source, err)
 	}
-	h.harData = harData
+	log.Printf("Loaded %s with %d entries", loaded.source, harParser.AnalyzedEntries(loaded.harData))
 	return nil
 }
 
-// createTools creates the server tools with their handlers
+// instructions describes the archives loaded at startup to the clients, so they know whether
+// load_har must be called first
+func (h *HARServer) instructions() string {
+	archives := h.listArchives()
+	if len(archives) == 0 {
+		return h.localize("No HAR file is loaded yet, call load_har with a file path or HTTP URL before using the other tools.")
+	}
+
+	lines := []string{h.localize("HAR files loaded at startup:")}
+	for _, summary := range archives {
+		line := h.localize("- %s (%s): %d entries", summary.Name, summary.Source, summary.Entries)
+		if summary.Current {
+			line += h.localize(", used when no archive is given")
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, h.localize("Call load_har to analyze another HAR file."))
+	return strings.Join(lines, "\n")
+}
+
+// createTools creates the server tools with their handlers, leaving out the ones disabled by the configuration
 func (h *HARServer) createTools() []server.ServerTool {
+	var enabled []server.ServerTool
+	for _, tool := range h.allTools() {
+		if h.config.ReadOnly && hasSideEffects(tool.Tool) {
+			continue
+		}
+		if tool.Tool.Name == "replay_request" && !h.config.EnableReplay {
+			continue
+		}
+		if !h.config.Tools.isEnabled(tool.Tool.Name) {
+			continue
+		}
+		tool.Tool = h.catalog.localizeTool(tool.Tool)
+		enabled = append(enabled, tool)
+	}
+	return enabled
+}
+
+// hasSideEffects reports whether the tool writes files or reaches out to other systems.
+// Such tools must declare it with a false ReadOnlyHint annotation.
+func hasSideEffects(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint != nil && !*tool.Annotations.ReadOnlyHint
+}
+
+// allTools lists every tool the server provides, the ones of each feature next to their handlers
+func (h *HARServer) allTools() []server.ServerTool {
+	return slices.Concat(
+		h.coreTools(),
+		h.archiveTools(),
+		h.entryTools(),
+		h.searchTools(),
+		h.workspaceTools(),
+		h.exclusionTools(),
+		h.redactionTools(),
+		h.performanceTools(),
+		h.errorTools(),
+		h.securityTools(),
+		h.flowTools(),
+		h.exportTools(),
+		h.goldenTools(),
+		h.replayTools(),
+		h.outputSchemaTools(),
+		h.statsTools(),
+	)
+}
+
+// coreTools lists the tools loading a HAR file and looking up its requests
+func (h *HARServer) coreTools() []server.ServerTool {
 	return []server.ServerTool{
 		{
 			Tool: mcp.Tool{
 				Name:        "load_har",
-				Description: "Load a HAR file from a file path or HTTP URL",
+				Description: "Load a HAR file, possibly gzip, zstd or zip compressed, from a file path, an HTTP URL or an s3://, gs:// or az:// object storage URI under a name other tools select it by, alongside the HAR files already loaded, and report how it was parsed: format, HAR version, parse mode (strict, flexible or recovered), size, parse time, dropped entries and warnings",
 				InputSchema: mcp.ToolInputSchema{
 					Type: "object",
 					Properties: map[string]interface{}{
 						"source": map[string]interface{}{
 							"type":        "string",
-							"description": "File path or HTTP URL to the HAR file",
+							"description": "File path, HTTP URL or s3://bucket/key, gs://bucket/object or az://account/container/blob object storage URI of the HAR file",
+						},
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Name selecting this HAR in the other tools, replacing the HAR loaded under the same name (defaults to a name derived from the file name)",
+						},
+						"bearer_token": map[string]interface{}{
+							"type":        "string",
+							"description": "Bearer token sent in the Authorization header when fetching a URL (defaults to the configured one)",
+						},
+						"username": map[string]interface{}{
+							"type":        "string",
+							"description": "User name sent with HTTP basic authentication when fetching a URL",
+						},
+						"password": map[string]interface{}{
+							"type":        "string",
+							"description": "Password sent with HTTP basic authentication when fetching a URL",
+						},
+						"headers": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "string"},
+							"description":          "Headers sent when fetching a URL, such as the API key header of an artifact store, added to the configured ones",
+						},
+						"client_cert_file": map[string]interface{}{
+							"type":        "string",
+							"description": "Path of the PEM file of the TLS client certificate presented when fetching a URL",
+						},
+						"client_key_file": map[string]interface{}{
+							"type":        "string",
+							"description": "Path of the PEM file of the key of the TLS client certificate",
 						},
 					},
 					Required: []string{"source"},
@@ -59,10 +307,17 @@ func (h *HARServer) createTools() []server.ServerTool {
 		{
 			Tool: mcp.Tool{
 				Name:        "list_urls_methods",
-				Description: "List all accessed URLs and their HTTP methods from the loaded HAR file",
+				Description: "List all accessed URLs and their HTTP methods from the loaded HAR file, or with group_by_pattern the URL patterns with variable path segments (numeric IDs, UUIDs, hashes) replaced by {id}, with request counts and example URLs",
 				InputSchema: mcp.ToolInputSchema{
-					Type:       "object",
-					Properties: map[string]interface{}{},
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"page":    pageProperty(),
+						"group_by_pattern": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Group the URLs by pattern, such as /users/{id} for /users/123 and /users/456, instead of listing every URL",
+						},
+					},
 				},
 			},
 			Handler: h.handleListURLsMethods,
@@ -74,6 +329,8 @@ func (h *HARServer) createTools() []server.ServerTool {
 				InputSchema: mcp.ToolInputSchema{
 					Type: "object",
 					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"page":    pageProperty(),
 						"url": map[string]interface{}{
 							"type":        "string",
 							"description": "The URL to filter by",
@@ -91,10 +348,11 @@ func (h *HARServer) createTools() []server.ServerTool {
 		{
 			Tool: mcp.Tool{
 				Name:        "get_request_details",
-				Description: "Get full request details by request ID (authentication headers will be redacted)",
+				Description: "Get full request details by request ID (authentication headers will be redacted), with the resource type, priority and initiator stack recorded by Chrome DevTools",
 				InputSchema: mcp.ToolInputSchema{
 					Type: "object",
 					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
 						"request_id": map[string]interface{}{
 							"type":        "string",
 							"description": "The request ID to retrieve details for",
@@ -108,32 +366,80 @@ func (h *HARServer) createTools() []server.ServerTool {
 	}
 }
 
+// loadResult is the load report returned by load_har
+type loadResult struct {
+	// Archive is the name selecting the loaded HAR in the other tools
+	Archive string `json:"archive"`
+	Source  string `json:"source"`
+	Entries int    `json:"entries"`
+	*harParser.ParseReport
+}
+
 // handleLoadHAR handles the load_har tool call
 func (h *HARServer) handleLoadHAR(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args struct {
 		Source string `json:"source"`
+		Name   string `json:"name"`
+		harParser.FetchOptions
 	}
 	if err := request.BindArguments(&args); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
 	}
 
-	if err := h.loadHAR(args.Source); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error loading HAR file: %v", err)), nil
+	loaded, err := h.loadHAR(ctx, args.Name, args.Source, args.FetchOptions)
+	if err != nil {
+		result := mcp.NewToolResultError(h.localize("Error loading HAR file: %v", err))
+		// Exceeded fetch limits are also returned as JSON, for clients to tell them apart
+		var limitErr *harParser.FetchLimitError
+		if errors.As(err, &limitErr) {
+			if data, err := json.Marshal(limitErr); err == nil {
+				result.Content = append(result.Content, mcp.NewTextContent(string(data)))
+			}
+		}
+		return result, nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Successfully loaded HAR file with %d entries", len(h.harData.Log.Entries))), nil
+	result := loadResult{
+		Archive:     loaded.name,
+		Source:      args.Source,
+		Entries:     len(loaded.harData.Log.Entries),
+		ParseReport: loaded.parseReport,
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal load report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
 }
 
 // handleListURLsMethods handles the list_urls_methods tool call
 func (h *HARServer) handleListURLsMethods(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if h.harData == nil {
-		return mcp.NewToolResultError("No HAR file loaded. Please load a HAR file first using load_har."), nil
+	var args struct {
+		Archive        string `json:"archive"`
+		Page           string `json:"page"`
+		GroupByPattern bool   `json:"group_by_pattern"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	entries := h.parser.GetURLsAndMethods(h.harData)
+	var entries interface{} = h.parser.GetURLsAndMethods(harData, loaded.parseReport.LookupIndex())
+	if args.GroupByPattern {
+		entries = h.parser.GetURLPatterns(harData)
+	}
 	data, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal URLs and methods: %v", err)), nil
+		return mcp.NewToolResultError(h.localize("Failed to marshal URLs and methods: %v", err)), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
@@ -141,22 +447,29 @@ func (h *HARServer) handleListURLsMethods(ctx context.Context, request mcp.CallT
 
 // handleGetRequestIDs handles the get_request_ids tool call
 func (h *HARServer) handleGetRequestIDs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if h.harData == nil {
-		return mcp.NewToolResultError("No HAR file loaded. Please load a HAR file first using load_har."), nil
-	}
-
 	var args struct {
-		URL    string `json:"url"`
-		Method string `json:"method"`
+		URL     string `json:"url"`
+		Method  string `json:"method"`
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
 	}
 	if err := request.BindArguments(&args); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	requestIDs := h.parser.GetRequestIDsForURLMethod(h.harData, args.URL, args.Method)
+	requestIDs := h.parser.GetRequestIDsForURLMethod(harData, loaded.parseReport.LookupIndex(), args.URL, args.Method)
 	data, err := json.MarshalIndent(requestIDs, "", "  ")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal request IDs: %v", err)), nil
+		return mcp.NewToolResultError(h.localize("Failed to marshal request IDs: %v", err)), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
@@ -164,43 +477,206 @@ func (h *HARServer) handleGetRequestIDs(ctx context.Context, request mcp.CallToo
 
 // handleGetRequestDetails handles the get_request_details tool call
 func (h *HARServer) handleGetRequestDetails(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if h.harData == nil {
-		return mcp.NewToolResultError("No HAR file loaded. Please load a HAR file first using load_har."), nil
-	}
-
 	var args struct {
 		RequestID string `json:"request_id"`
+		Archive   string `json:"archive"`
 	}
 	if err := request.BindArguments(&args); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
 	}
 
-	details, err := h.parser.GetRequestDetails(h.harData, args.RequestID)
+	loaded, err := h.lookupArchive(args.Archive)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error getting request details: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	details, err := h.parser.GetRequestDetails(loaded.harData, loaded.parseReport.TimingIndex(), loaded.parseReport.CustomFieldIndex(), args.RequestID)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error getting request details: %v", err)), nil
 	}
 
 	data, err := json.MarshalIndent(details, "", "  ")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal request details: %v", err)), nil
+		return mcp.NewToolResultError(h.localize("Failed to marshal request details: %v", err)), nil
 	}
 
 	return mcp.NewToolResultText(string(data)), nil
 }
 
 func main() {
+	configPath := flag.String("config", "", "path to a JSON configuration file")
+	readOnly := flag.Bool("read-only", false, "disable tools with side effects (replay, save, export to disk, redaction and exclusion changes)")
+	enableReplay := flag.Bool("enable-replay", false, "register replay_request, which sends captured requests with their credentials to live endpoints")
+	auditLog := flag.String("audit-log", "", "path of a JSONL file recording every tool call")
+	goldenDir := flag.String("golden-dir", "", "directory storing golden response fixtures")
+	outputDir := flag.String("output-dir", "", "directory the export tools must write their files in (defaults to anywhere)")
+	lenient := flag.Bool("lenient", false, "recover malformed HAR files (trailing commas, NaN, concatenated documents) instead of failing")
+	timeZone := flag.String("time-zone", "", "time zone rendering every timestamp, e.g. UTC or Europe/Paris (defaults to the offsets of the capture)")
+	source := flag.String("har", "", "HAR file path, HTTP URL or object storage URI to load at startup (defaults to $"+sourceEnv+")")
+	fetchToken := flag.String("fetch-token", "", "bearer token sent when fetching HAR files from URLs (defaults to $"+fetchTokenEnv+")")
+	fetchUsername := flag.String("fetch-username", "", "user name sent with basic authentication when fetching HAR files from URLs (defaults to $"+fetchUsernameEnv+", the password being read from $"+fetchPasswordEnv+")")
+	fetchClientCert := flag.String("fetch-client-cert", "", "PEM file of the TLS client certificate presented when fetching HAR files from URLs")
+	fetchClientKey := flag.String("fetch-client-key", "", "PEM file of the key of the TLS client certificate")
+	fetchTimeout := flag.Int("fetch-timeout", 0, "seconds after which fetching a HAR file from a URL is abandoned (defaults to 120, negative for unlimited)")
+	fetchMaxBytes := flag.Int64("fetch-max-bytes", 0, "maximum size in bytes of a HAR file fetched from a URL, before and after decompression (defaults to 512 MiB, negative for unlimited)")
+	stateFile := flag.String("state-file", "", "path of a file persisting the loaded HAR across restarts")
+	maxCallsPerMinute := flag.Int("max-calls-per-minute", 0, "maximum number of tool calls per minute and client session (0 for unlimited)")
+	maxBytesPerMinute := flag.Int("max-bytes-per-minute", 0, "maximum number of bytes returned per minute and client session (0 for unlimited)")
+	var redactHeaders, redactPatterns, allowCookies, fetchHeaders stringList
+	flag.Var(&fetchHeaders, "fetch-header", "header sent when fetching HAR files from URLs, as \"Name: value\" (repeatable)")
+	flag.Var(&redactHeaders, "redact-header", "additional header name to redact (repeatable)")
+	flag.Var(&redactPatterns, "redact-pattern", "regular expression whose matches are redacted in header values, query parameters and bodies (repeatable)")
+	flag.Var(&allowCookies, "allow-cookie", "name of a cookie whose value is not redacted (repeatable)")
+	allowSensitive := flag.Bool("allow-sensitive", false, "allow disabling redaction from the configuration file or with configure_redaction")
+	language := flag.String("language", "", "language of the tool descriptions and messages: "+strings.Join(languages(), ", ")+" (defaults to en)")
+	flag.Parse()
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	if *readOnly {
+		config.ReadOnly = true
+	}
+	if *enableReplay {
+		config.EnableReplay = true
+	}
+	if *auditLog != "" {
+		config.AuditLog = *auditLog
+	}
+	if *goldenDir != "" {
+		config.GoldenDir = *goldenDir
+	}
+	if *outputDir != "" {
+		config.OutputDir = *outputDir
+	}
+	if *lenient {
+		config.Lenient = true
+	}
+	if *timeZone != "" {
+		config.TimeZone = *timeZone
+	}
+	if *source != "" {
+		config.Source = *source
+	} else if env := os.Getenv(sourceEnv); env != "" {
+		config.Source = env
+	}
+	if *fetchToken != "" {
+		config.Fetch.BearerToken = *fetchToken
+	} else if env := os.Getenv(fetchTokenEnv); env != "" {
+		config.Fetch.BearerToken = env
+	}
+	if *fetchUsername != "" {
+		config.Fetch.Username = *fetchUsername
+	} else if env := os.Getenv(fetchUsernameEnv); env != "" {
+		config.Fetch.Username = env
+	}
+	if env := os.Getenv(fetchPasswordEnv); env != "" {
+		config.Fetch.Password = env
+	}
+	if *fetchClientCert != "" {
+		config.Fetch.ClientCertFile = *fetchClientCert
+	}
+	if *fetchClientKey != "" {
+		config.Fetch.ClientKeyFile = *fetchClientKey
+	}
+	headers, err := parseHeaders(fetchHeaders)
+	if err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	if len(headers) > 0 && config.Fetch.Headers == nil {
+		config.Fetch.Headers = make(map[string]string, len(headers))
+	}
+	maps.Copy(config.Fetch.Headers, headers)
+	if *fetchTimeout != 0 {
+		config.FetchLimits.TimeoutSeconds = *fetchTimeout
+	}
+	if *fetchMaxBytes != 0 {
+		config.FetchLimits.MaxBytes = *fetchMaxBytes
+	}
+	if *stateFile != "" {
+		config.StateFile = *stateFile
+	}
+	if *maxCallsPerMinute > 0 {
+		config.Limits.MaxCallsPerMinute = *maxCallsPerMinute
+	}
+	if *maxBytesPerMinute > 0 {
+		config.Limits.MaxBytesPerMinute = *maxBytesPerMinute
+	}
+	config.Redaction.Headers = append(config.Redaction.Headers, redactHeaders...)
+	config.Redaction.ValuePatterns = append(config.Redaction.ValuePatterns, redactPatterns...)
+	config.Redaction.AllowedCookies = append(config.Redaction.AllowedCookies, allowCookies...)
+	if *allowSensitive {
+		config.AllowSensitive = true
+	}
+	if *language != "" {
+		config.Language = *language
+	}
+
 	// Create the HAR server
-	harServer := NewHARServer()
+	harServer := NewHARServer(config)
+	if err := harServer.validateToolsConfig(); err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	if harServer.catalog, err = loadCatalog(config.Language); err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	harServer.parser.SetMessages(harServer.catalog.message)
+	harServer.parser.SetLenient(config.Lenient)
+	if err := harServer.parser.SetFetchOptions(config.Fetch); err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	harServer.parser.SetFetchLimits(config.FetchLimits)
+	if err := config.validateRedaction(config.Redaction); err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	if err := harServer.parser.SetRedactionPolicy(config.Redaction); err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	if err := harServer.parser.SetScoringPolicy(config.Scoring); err != nil {
+		log.Fatal("Configuration error:", err)
+	}
+	if config.TimeZone != "" {
+		location, err := time.LoadLocation(config.TimeZone)
+		if err != nil {
+			log.Fatal("Configuration error:", fmt.Errorf("invalid time zone: %w", err))
+		}
+		harServer.parser.SetTimeZone(location)
+	}
+	if err := harServer.loadStartupArchives(context.Background()); err != nil {
+		log.Fatal("Startup error:", err)
+	}
+
+	serverOptions := []server.ServerOption{
+		server.WithInstructions(harServer.instructions()),
+		server.WithToolHandlerMiddleware(schemaVersionMiddleware),
+		server.WithToolHandlerMiddleware(harServer.stats.middleware),
+		server.WithResourceCapabilities(false, true),
+	}
+	if config.AuditLog != "" {
+		auditor, err := openAuditLog(config.AuditLog)
+		if err != nil {
+			log.Fatal("Configuration error:", err)
+		}
+		serverOptions = append(serverOptions, server.WithToolHandlerMiddleware(auditor.middleware))
+	}
+	if config.Limits.enabled() {
+		serverOptions = append(serverOptions, server.WithToolHandlerMiddleware(newRateLimiter(config.Limits, harServer.catalog).middleware))
+	}
 
 	// Create MCP server
 	mcpServer := server.NewMCPServer(
 		"har-mcp",
 		"1.0.0",
+		serverOptions...,
 	)
 
 	// Add tools
 	mcpServer.AddTools(harServer.createTools()...)
 
+	// Add resources addressing the entries of the loaded archives
+	harServer.registerResources(mcpServer)
+
 	// Create and start stdio server
 	stdioServer := server.NewStdioServer(mcpServer)
 
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// newTestServer returns a server persisting its workspace to a temporary state file
+func newTestServer(t *testing.T) *HARServer {
+	h := NewHARServer(Config{})
+	h.workspace = &workspace{path: filepath.Join(t.TempDir(), "state.json")}
+	return h
+}
+
+// newTestArchive returns a HAR file holding a GET request to each of the given URLs
+func newTestArchive(urls ...string) *har.HAR {
+	harData := &har.HAR{Log: &har.Log{Version: "1.2", Creator: &har.Creator{Name: "test", Version: "1"}}}
+	for _, u := range urls {
+		harData.Log.Entries = append(harData.Log.Entries, &har.Entry{
+			Request:  &har.Request{Method: "GET", URL: u, HTTPVersion: "HTTP/1.1"},
+			Response: &har.Response{Status: 200, StatusText: "OK", HTTPVersion: "HTTP/1.1", Content: &har.Content{MimeType: "text/plain"}},
+		})
+	}
+	return harData
+}
+
+func TestSaveQueryIsFoundBySavedQuery(t *testing.T) {
+	h := newTestServer(t)
+
+	result, err := h.handleSaveQuery(context.Background(), newTestToolRequest("save_query", map[string]any{"name": "errors", "filter": map[string]any{"status": "5xx"}}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	filter, err := h.savedQuery("errors")
+	require.NoError(t, err)
+	assert.Equal(t, "5xx", filter.Status)
+	_, err = h.savedQuery("missing")
+	assert.EqualError(t, err, `Unknown query "missing", saved queries: errors`)
+}
+
+func TestSaveQueryWhileLoadingArchives(t *testing.T) {
+	h := newTestServer(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h.addArchive(fmt.Sprintf("archive-%d", i), "https://example.com/capture.har", newTestArchive("https://example.com/"), &harParser.ParseReport{})
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := h.handleSaveQuery(context.Background(), newTestToolRequest("save_query", map[string]any{"name": fmt.Sprintf("query-%d", i), "filter": map[string]any{}}))
+			assert.NoError(t, err)
+			_, err = h.savedQuery(fmt.Sprintf("query-%d", i))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, h.workspace.state.Queries, 10)
+	assert.Len(t, h.workspace.state.Archives, 10)
+}
+
+// loadTestArchive parses a HAR file holding a GET request to each of the given URLs and loads it as the current archive
+func loadTestArchive(t *testing.T, h *HARServer, urls ...string) *archive {
+	data, err := json.Marshal(newTestArchive(urls...))
+	require.NoError(t, err)
+	harData, parseReport, err := h.parser.ParseWithReport(bytes.NewReader(data))
+	require.NoError(t, err)
+	return h.addArchive("capture", "capture.har", harData, parseReport)
+}
+
+// callExclusionTool calls exclude_requests or include_requests and decodes its result
+func callExclusionTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), arguments map[string]any) exclusionResult {
+	result, err := handler(context.Background(), newTestToolRequest("exclude_requests", arguments))
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content)
+
+	var exclusions exclusionResult
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &exclusions))
+	return exclusions
+}
+
+func TestExcludeRequestsByRequestID(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://example.com/app.js")
+
+	result := callExclusionTool(t, h.handleExcludeRequests, map[string]any{"request_ids": []any{"request_1"}})
+
+	assert.Equal(t, exclusionResult{RequestIDs: []string{"request_1"}, Excluded: 1, ExcludedRequestIDs: []string{"request_1"}}, result)
+}
+
+func TestExcludeRequestsByDomain(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://cdn.tracker.net/pixel.gif", "https://tracker.net/collect")
+
+	result := callExclusionTool(t, h.handleExcludeRequests, map[string]any{"domain": "tracker.net"})
+
+	assert.Equal(t, []string{"request_1", "request_2"}, result.RequestIDs)
+}
+
+func TestExcludeRequestsByFilter(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://example.com/health")
+
+	result := callExclusionTool(t, h.handleExcludeRequests, map[string]any{"filter": map[string]any{"url_contains": "health"}})
+
+	assert.Equal(t, []string{"request_1"}, result.RequestIDs)
+}
+
+func TestExcludeRequestsRequiresASelection(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/")
+
+	result, err := h.handleExcludeRequests(context.Background(), newTestToolRequest("exclude_requests", map[string]any{}))
+
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestExcludedRequestsAreLeftOutOfTheArchive(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://example.com/health")
+
+	callExclusionTool(t, h.handleExcludeRequests, map[string]any{"request_ids": []any{"request_1"}})
+
+	loaded, err := h.lookupArchive("")
+	require.NoError(t, err)
+	info := h.parser.GetHARInfo(loaded.harData, nil)
+	assert.Equal(t, 1, info.Excluded)
+	assert.Equal(t, []string{"request_1"}, info.ExcludedRequestIDs)
+}
+
+func TestIncludeRequestsBringsEntriesBack(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://example.com/a", "https://example.com/b")
+	callExclusionTool(t, h.handleExcludeRequests, map[string]any{"request_ids": []any{"request_1", "request_2"}})
+
+	result := callExclusionTool(t, h.handleIncludeRequests, map[string]any{"request_ids": []any{"request_2"}})
+
+	assert.Equal(t, exclusionResult{RequestIDs: []string{"request_2"}, Excluded: 1, ExcludedRequestIDs: []string{"request_1"}}, result)
+}
+
+func TestIncludeRequestsAllClearsExclusions(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://example.com/a", "https://example.com/b")
+	callExclusionTool(t, h.handleExcludeRequests, map[string]any{"request_ids": []any{"request_1", "request_2"}})
+
+	result := callExclusionTool(t, h.handleIncludeRequests, map[string]any{"all": true})
+
+	assert.Equal(t, exclusionResult{RequestIDs: []string{"request_1", "request_2"}, Excluded: 0, ExcludedRequestIDs: []string{}}, result)
+}
+
+func TestExclusionsKeepAnalysesApart(t *testing.T) {
+	h := newTestServer(t)
+	loaded := loadTestArchive(t, h, "https://example.com/", "https://example.com/a")
+	counter := &computeCounter{}
+	h.analysis(loaded, "get_har_stats", counter.compute("whole"))
+
+	updated, err := h.setExclusions(loaded, map[string]bool{"request_1": true})
+	require.NoError(t, err)
+	result := h.analysis(updated, "get_har_stats", counter.compute("excluded"))
+
+	assert.Equal(t, "excluded", result)
+	assert.Equal(t, 2, counter.calls)
+}
+
+func TestListArchivesLeavesOutExcludedEntries(t *testing.T) {
+	h := newTestServer(t)
+	loadTestArchive(t, h, "https://example.com/", "https://tracker.net/pixel")
+
+	callExclusionTool(t, h.handleExcludeRequests, map[string]any{"domain": "tracker.net"})
+
+	assert.Equal(t, 1, h.listArchives()[0].Entries)
+}
+
+func TestLoadStartupArchivesMakesTheConfiguredHARCurrent(t *testing.T) {
+	path := writeTestArchive(t, "https://example.com/", "https://example.com/app.js")
+	h := NewHARServer(Config{Source: path})
+
+	require.NoError(t, h.loadStartupArchives(context.Background()))
+
+	assert.Equal(t, []archiveSummary{{Name: "capture", Source: path, Entries: 2, Current: true}}, h.listArchives())
+}
+
+func TestLoadStartupArchivesPrefersTheConfiguredHARToTheRestoredOnes(t *testing.T) {
+	previous := newTestServer(t)
+	_, err := previous.loadHAR(context.Background(), "restored", writeTestArchive(t, "https://example.com/"), harParser.FetchOptions{})
+	require.NoError(t, err)
+	h := NewHARServer(Config{StateFile: previous.workspace.path, Source: writeTestArchive(t, "https://example.com/")})
+
+	require.NoError(t, h.loadStartupArchives(context.Background()))
+
+	loaded, err := h.lookupArchive("")
+	require.NoError(t, err)
+	assert.Equal(t, "capture", loaded.name)
+	assert.Len(t, h.listArchives(), 2)
+}
+
+func TestLoadStartupArchivesReportsFilesThatFailToLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.har")
+	require.NoError(t, os.WriteFile(path, []byte(`{"log": `), 0o600))
+	h := NewHARServer(Config{Source: path})
+
+	err := h.loadStartupArchives(context.Background())
+
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), path+": failed to load HAR: "), err.Error())
+	assert.Empty(t, h.listArchives())
+}
+
+func TestLoadStartupArchivesReportsCorruptStateFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"archives": `), 0o600))
+	h := NewHARServer(Config{StateFile: path, Source: writeTestArchive(t, "https://example.com/")})
+
+	err := h.loadStartupArchives(context.Background())
+
+	assert.ErrorContains(t, err, "failed to parse state file")
+	assert.Empty(t, h.listArchives())
+}
+
+func TestInstructionsListTheLoadedArchives(t *testing.T) {
+	path := writeTestArchive(t, "https://example.com/", "https://example.com/app.js")
+	h := NewHARServer(Config{Source: path})
+	require.NoError(t, h.loadStartupArchives(context.Background()))
+
+	assert.Equal(t, "HAR files loaded at startup:\n- capture ("+path+"): 2 entries, used when no archive is given\nCall load_har to analyze another HAR file.", h.instructions())
+}
+
+func TestInstructionsAskToLoadAHARFirst(t *testing.T) {
+	h := NewHARServer(Config{})
+
+	assert.Equal(t, "No HAR file is loaded yet, call load_har with a file path or HTTP URL before using the other tools.", h.instructions())
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+	"github.com/tjamet/har-mcp/pkg/openapi"
+)
+
+// Formats of the tool outputs
+const (
+	formatJSON  = "application/json"
+	formatText  = "text/plain"
+	formatXML   = "application/xml"
+	formatHTTP  = "message/http"
+	formatShell = "application/x-sh"
+)
+
+// toolOutput describes the text content a tool returns
+type toolOutput struct {
+	format string
+	// value is a value of the type encoded by the tools returning JSON
+	value interface{}
+	// note tells when the tool returns another format than its usual one
+	note string
+}
+
+// toolOutputs describes the output of every tool, the schemas of get_output_schemas being
+// derived from the types encoded
+var toolOutputs = map[string]toolOutput{
+	"load_har":                     {format: formatJSON, value: loadResult{}},
+	"list_archives":                {format: formatJSON, value: []archiveSummary{}},
+	"list_urls_methods":            {format: formatJSON, value: []harParser.URLMethodEntry{}},
+	"get_request_ids":              {format: formatJSON, value: []string{}},
+	"get_request_details":          {format: formatJSON, value: &harParser.RequestDetails{}},
+	"diff_requests":                {format: formatJSON, value: &harParser.RequestDiff{}},
+	"header_values":                {format: formatJSON, value: &harParser.HeaderValueFrequency{}},
+	"mime_mismatch_report":         {format: formatJSON, value: &harParser.MimeMismatchReport{}},
+	"cache_buster_report":          {format: formatJSON, value: &harParser.CacheBusterReport{}},
+	"retry_storm_report":           {format: formatJSON, value: &harParser.RetryStormReport{}},
+	"compare_page_loads":           {format: formatJSON, value: &harParser.PageLoadComparison{}},
+	"decode_embedded":              {format: formatJSON, value: []harParser.EmbeddedPayload{}},
+	"export_llm_bundle":            {format: formatText},
+	"save_query":                   {format: formatText},
+	"run_saved_query":              {format: formatJSON, value: []harParser.FilteredEntry{}},
+	"register_golden":              {format: formatText},
+	"check_against_golden":         {format: formatJSON, value: &harParser.GoldenReport{}},
+	"har_info":                     {format: formatJSON, value: &harParser.HARInfo{}},
+	"find_at_time":                 {format: formatJSON, value: &harParser.InFlightReport{}},
+	"data_flow_graph":              {format: formatJSON, value: &harParser.DataFlowGraph{}},
+	"search_entries":               {format: formatJSON, value: &harParser.SearchResult{}},
+	"build_entity_index":           {format: formatJSON, value: &harParser.EntityIndexSummary{}},
+	"find_entity":                  {format: formatJSON, value: &harParser.Entity{}, note: "A text message is returned when no request mentions the value"},
+	"configure_redaction":          {format: formatJSON, value: harParser.RedactionPolicy{}},
+	"error_summary":                {format: formatJSON, value: &harParser.ErrorSummary{}},
+	"operation_stats":              {format: formatJSON, value: []harParser.OperationStats{}},
+	"get_response_body":            {format: formatJSON, value: &harParser.ResponseBody{}},
+	"query_parameters":             {format: formatJSON, value: []harParser.EndpointParameters{}},
+	"token_expiry_report":          {format: formatJSON, value: &harParser.TokenExpiryReport{}},
+	"get_har_stats":                {format: formatJSON, value: &harParser.HARStats{}},
+	"filter_entries":               {format: formatJSON, value: []harParser.FilteredEntry{}},
+	"oauth_audit":                  {format: formatJSON, value: &harParser.OAuthAudit{}},
+	"export_as_curl":               {format: formatShell},
+	"find_callbacks":               {format: formatJSON, value: &harParser.CallbackReport{}},
+	"export_sarif":                 {format: formatJSON, value: &harParser.SARIFLog{}},
+	"export_openapi":               {format: formatJSON, value: &openapi.Document{}},
+	"export_junit":                 {format: formatXML},
+	"list_pages":                   {format: formatJSON, value: &harParser.PageList{}},
+	"all_findings":                 {format: formatJSON, value: &harParser.AllFindings{}},
+	"get_websocket_messages":       {format: formatJSON, value: &harParser.WebSocketMessages{}},
+	"get_timings":                  {format: formatJSON, value: &harParser.TimingReport{}},
+	"freshness_report":             {format: formatJSON, value: &harParser.FreshnessReport{}},
+	"header_conflicts":             {format: formatJSON, value: &harParser.HeaderConflictReport{}},
+	"compare_archives":             {format: formatJSON, value: &harParser.ArchiveComparison{}},
+	"list_cookies":                 {format: formatJSON, value: &harParser.CookieReport{}},
+	"get_raw_http":                 {format: formatHTTP},
+	"lint_http":                    {format: formatJSON, value: &harParser.HTTPLintReport{}},
+	"soft_error_report":            {format: formatJSON, value: &harParser.SoftErrorReport{}},
+	"replay_request":               {format: formatJSON, value: &harParser.ReplayResult{}},
+	"debug_leak_report":            {format: formatJSON, value: &harParser.DebugLeakReport{}},
+	"query_response_body":          {format: formatJSON, value: &harParser.ResponseBodyQuery{}},
+	"get_output_schemas":           {format: formatJSON, value: &outputSchemas{}},
+	"export_redacted_har":          {format: formatJSON, value: &harParser.HARExport{}},
+	"export_subset":                {format: formatJSON, value: &harParser.HARExport{}},
+	"summarize_flow":               {format: formatJSON, value: &harParser.FlowSummary{}},
+	"scan_secrets":                 {format: formatJSON, value: &harParser.SecretScanReport{}},
+	"detect_interception":          {format: formatJSON, value: &harParser.InterceptionReport{}},
+	"http2_streams":                {format: formatJSON, value: &harParser.HTTP2StreamsReport{}},
+	"performance_report":           {format: formatJSON, value: &harParser.PerformanceReport{}},
+	"alt_svc_report":               {format: formatJSON, value: &harParser.AltSvcReport{}},
+	"service_worker_report":        {format: formatJSON, value: &harParser.ServiceWorkerReport{}},
+	"resource_hints_effectiveness": {format: formatJSON, value: &harParser.ResourceHintsReport{}},
+	"font_report":                  {format: formatJSON, value: &harParser.FontReport{}},
+	"media_report":                 {format: formatJSON, value: &harParser.MediaReport{}},
+	"duplicate_tracking_report":    {format: formatJSON, value: &harParser.DuplicateTrackingReport{}},
+	"list_errors":                  {format: formatJSON, value: &harParser.ErrorList{}},
+	"server_stats":                 {format: formatJSON, value: serverStats{}},
+	"analyze_auth_flow":            {format: formatJSON, value: &harParser.AuthFlow{}},
+	"exclude_requests":             {format: formatJSON, value: exclusionResult{}},
+	"include_requests":             {format: formatJSON, value: exclusionResult{}},
+}
+
+// outputSchemas is the output contract returned by get_output_schemas
+type outputSchemas struct {
+	// SchemaVersion is bumped whenever a field is removed, renamed or changes type
+	SchemaVersion int                         `json:"schema_version"`
+	Tools         map[string]toolOutputSchema `json:"tools"`
+}
+
+// toolOutputSchema describes the text content a tool returns
+type toolOutputSchema struct {
+	// Format is the media type of the text content
+	Format string `json:"format"`
+	// Schema is the JSON Schema of the tools returning JSON
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	Note   string                 `json:"note,omitempty"`
+}
+
+// outputSchemas describes the output of the enabled tools, or of a single one when named
+func (h *HARServer) outputSchemas(name string) (*outputSchemas, error) {
+	schemas := &outputSchemas{SchemaVersion: harParser.OutputSchemaVersion, Tools: make(map[string]toolOutputSchema)}
+	for _, tool := range h.createTools() {
+		if name != "" && tool.Tool.Name != name {
+			continue
+		}
+		output, ok := toolOutputs[tool.Tool.Name]
+		if !ok {
+			output = toolOutput{format: formatText}
+		}
+		schema := toolOutputSchema{Format: output.format, Note: output.note}
+		if output.value != nil {
+			schema.Schema = harParser.JSONSchema(output.value)
+		}
+		schemas.Tools[tool.Tool.Name] = schema
+	}
+	if name != "" && len(schemas.Tools) == 0 {
+		return nil, errors.New(h.localize("unknown or disabled tool %q", name))
+	}
+	return schemas, nil
+}
+
+// schemaVersionMiddleware stamps the version of the output schemas in the metadata of the
+// successful results of the tools returning JSON, so automations detect contract changes
+func schemaVersionMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError || toolOutputs[request.Params.Name].format != formatJSON {
+			return result, err
+		}
+		if result.Meta == nil {
+			result.Meta = make(map[string]interface{})
+		}
+		result.Meta["schema_version"] = harParser.OutputSchemaVersion
+		return result, err
+	}
+}
+
+// handleGetOutputSchemas handles the get_output_schemas tool call
+func (h *HARServer) handleGetOutputSchemas(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Tool string `json:"tool"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	schemas, err := h.outputSchemas(args.Tool)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	data, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal output schemas: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// outputSchemaTools lists the tool describing the output of the other tools
+func (h *HARServer) outputSchemaTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "get_output_schemas",
+				Description: "Return the output contract of the tools: the media type of their text content and, for the tools returning JSON, its JSON Schema, along with the schema_version stamped in the _meta of their results. The version is bumped when a field is removed, renamed or changes type, added fields do not bump it",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"tool": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the tool whose output to describe, every enabled tool being described when empty",
+						},
+					},
+				},
+			},
+			Handler: h.handleGetOutputSchemas,
+		},
+	}
+}
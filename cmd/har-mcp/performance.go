@@ -0,0 +1,675 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// performanceTools lists the tools reporting on timings, performance and caching
+func (h *HARServer) performanceTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "get_timings",
+				Description: "Get the timing breakdown (blocked, dns, connect, ssl, send, wait, receive in milliseconds, ssl being part of connect) of a request, or of the entries matching the filter criteria, slowest first, with the total of each phase over the matching entries to tell whether time goes to connection setup, server wait or download",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(timingsProperties())),
+				},
+			},
+			Handler: h.handleGetTimings,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "get_har_stats",
+				Description: "Get aggregate statistics for a loaded HAR, a cheap overview before drilling into individual requests: entry count, hosts, method and status distributions, bytes transferred, capture time span and the slowest requests",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"slowest": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Number of slowest requests to list (defaults to %d)", harParser.DefaultSlowestRequests),
+						},
+					},
+				},
+			},
+			Handler: h.handleGetHARStats,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "operation_stats",
+				Description: "Group the GraphQL and JSON-RPC requests by logical operation (GraphQL operation name or JSON-RPC method) rather than by URL, for apps sending every call to a single endpoint. Batched requests count once per operation. Each operation lists its calls, failures, average and max duration and request IDs",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleOperationStats,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "query_parameters",
+				Description: "Group the requests carrying query parameters by endpoint template (IDs and OData keys replaced by {id}) and tabulate each parameter: requests using it, distinct and most frequent values. OData options ($filter, $expand, $select, $orderby...) and JSON:API families (filter[...], page[...]) are recognized and the fields they reference are listed, so query-heavy APIs read as a few operations rather than thousands of unique URLs",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleQueryParameters,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "performance_report",
+				Description: "Aggregate the requests per host and per templated path (e.g. /users/{id}) into a compact table for performance triage: request counts, errors (no response, 4xx and 5xx) and error rate, p50, p90 and p99 latencies and transferred bytes. Rows are ordered by requests, p90, errors or bytes, largest first. Beacons (sendBeacon and ping), analytics hits and keepalive requests, often cut short at unload, are summarized apart",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withPage(withArchive(map[string]interface{}{
+						"order_by": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{harParser.PerformanceByRequests, harParser.PerformanceByP90, harParser.PerformanceByErrors, harParser.PerformanceByBytes},
+							"description": "Column the rows are ordered by, largest first (defaults to requests)",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of path rows to return (defaults to %d)", harParser.DefaultPerformancePaths),
+						},
+						"include_beacons": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Count beacons, analytics hits and keepalive requests like other requests instead of summarizing them apart (defaults to false)",
+						},
+					})),
+				},
+			},
+			Handler: h.handlePerformanceReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "compare_page_loads",
+				Description: "Align the requests of two page loads by resource (same method and URL template) and report per-resource start-offset and duration deltas, to explain why one navigation was slower than the other. A page load is identified by the request ID of its HTML document and spans every request until the next navigation",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"page_a": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID of the HTML document of the first page load",
+						},
+						"page_b": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID of the HTML document of the second page load",
+						},
+					},
+					Required: []string{"page_a", "page_b"},
+				},
+			},
+			Handler: h.handleComparePageLoads,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "retry_storm_report",
+				Description: "Detect bursts of identical requests fired within milliseconds after a 5xx or failed response (missing exponential backoff), with a timeline per endpoint",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"window_ms": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum delay in milliseconds between a failure and the next identical request (defaults to 1000)",
+						},
+						"min_attempts": map[string]interface{}{
+							"type":        "integer",
+							"description": "Minimum number of identical requests forming a burst (defaults to 3)",
+						},
+					},
+				},
+			},
+			Handler: h.handleRetryStormReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "http2_streams",
+				Description: "Report how the HTTP/2 requests were multiplexed: requests are grouped by the connection ID recorded by the exporter, or by host otherwise, and each connection gives its streams, their stream IDs when recorded, the largest number of streams in flight at once and whether multiplexing went unused. Head-of-line blocking is inferred from the timings: streams queued while others were in flight, and streams waiting for their first byte several times longer than their siblings while another response was received",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleHTTP2Streams,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "alt_svc_report",
+				Description: "Show per host the alternative services advertised by Alt-Svc response headers (h3, h3-29, ...) and the protocols the requests used, to tell whether the client upgraded to HTTP/3 during the session; for hosts switching over, compare the median latency of the requests before and after the switch",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleAltSvcReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "cache_buster_report",
+				Description: "Detect cache-busting query parameters (e.g. _=1699999, cb=, random UUIDs on static assets) and count how many cacheable requests they defeated",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleCacheBusterReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "freshness_report",
+				Description: "Report how old each response was when received, from its Date, Age, Cache-Control, Expires and Last-Modified headers, oldest first, flagging responses served past their freshness lifetime and API responses served stale by a CDN or cache",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withPage(withArchive(map[string]interface{}{
+						"stale_after_seconds": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Age in seconds from which API responses without a freshness lifetime are reported stale (defaults to %d)", int(harParser.DefaultStaleAfter.Seconds())),
+						},
+						"only_stale": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Only return the stale responses",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of responses to return (defaults to %d, at most %d)", harParser.DefaultFreshnessEntries, harParser.MaxFreshnessEntries),
+						},
+					})),
+				},
+			},
+			Handler: h.handleFreshnessReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "service_worker_report",
+				Description: "Tell which requests a service worker handled, which aggregate statistics count as plain network requests: responses it served from Cache Storage or its own code, responses it passed through to the network along with the duplicate fetch it sent, its own background requests attributed to no page, and fetches of its script",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withArchive(map[string]interface{}{}),
+				},
+			},
+			Handler: h.handleServiceWorkerReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "resource_hints_effectiveness",
+				Description: "Tell whether the preconnect and dns-prefetch hints found in the link tags of the captured HTML documents and in Link headers saved time: for each hint, whether the first request to the hinted host found its connection (or DNS lookup) already set up, paid the setup anyway, came before the hint or never happened, with the time saved estimated from the setup paid by unhinted hosts, and the unhinted hosts that paid the longest setups",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleResourceHintsEffectiveness,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "font_report",
+				Description: "Summarize the web font downloads: their formats, sizes and families (read from the @font-face rules of the captured stylesheets or guessed from the URLs), whether each was downloaded before the first paint of its page, still downloading then and so blocking the rendering of its text, or requested after it, the first paint being approximated by DOMContentLoaded or the end of the HTML document; and the font families downloaded from several origins",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleFontReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "media_report",
+				Description: "Analyze video and audio streaming sessions: detect HLS playlists and DASH manifests with the renditions they declare, and the segment requests of each stream; reconstruct the bitrate ladder and the switches up or down it over time from the manifests or the segment URLs and sizes; and simulate the playback buffer to list the segments arriving with little or no media left to play, i.e. the stall risks",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleMediaReport,
+		},
+	}
+}
+
+// timingsProperties describes the input of get_timings: a request ID or entry filter criteria
+func timingsProperties() map[string]interface{} {
+	properties := entryFilterProperties()
+	properties["request_id"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Only return the timings of this request (e.g. request_0), the filter criteria are ignored",
+	}
+	properties["limit"] = map[string]interface{}{
+		"type":        "integer",
+		"description": fmt.Sprintf("Maximum number of entries to return (defaults to %d, at most %d)", harParser.DefaultTimingEntries, harParser.MaxTimingEntries),
+	}
+	return properties
+}
+
+// handleGetTimings handles the get_timings tool call
+func (h *HARServer) handleGetTimings(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		harParser.EntryFilter
+		Archive   string `json:"archive"`
+		Page      string `json:"page"`
+		RequestID string `json:"request_id"`
+		Limit     int    `json:"limit"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	timings, err := h.parser.GetTimings(harData, loaded.parseReport.TimingIndex(), loaded.parseReport.LookupIndex(), loaded.parseReport.CustomFieldIndex(), args.RequestID, args.EntryFilter, args.Limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	timings.Caveats = h.interception(loaded).Caveats
+
+	data, err := json.MarshalIndent(timings, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal timings: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleGetHARStats handles the get_har_stats tool call
+func (h *HARServer) handleGetHARStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Slowest int    `json:"slowest"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	stats := h.analysis(loaded, fmt.Sprintf("get_har_stats|%d", args.Slowest), func() interface{} {
+		return h.parser.GetHARStats(loaded.harData, loaded.parseReport.TimingIndex(), args.Slowest)
+	})
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal HAR stats: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleOperationStats handles the operation_stats tool call
+func (h *HARServer) handleOperationStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	stats := h.parser.GetOperationStats(loaded.harData, loaded.parseReport.TimingIndex())
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal operation stats: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleQueryParameters handles the query_parameters tool call
+func (h *HARServer) handleQueryParameters(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	stats := h.analysis(loaded, "query_parameters", func() interface{} {
+		return h.parser.GetQueryParameterStats(loaded.harData)
+	})
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal query parameters: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handlePerformanceReport handles the performance_report tool call
+func (h *HARServer) handlePerformanceReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+		OrderBy string `json:"order_by"`
+		Limit   int    `json:"limit"`
+		// IncludeBeacons counts the beacons in the rows rather than apart
+		IncludeBeacons bool `json:"include_beacons"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report, err := h.parser.GetPerformanceReport(harData, loaded.parseReport.TimingIndex(), h.beacons(loaded, args.IncludeBeacons), args.OrderBy, args.Limit)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error building performance report: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal performance report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleComparePageLoads handles the compare_page_loads tool call
+func (h *HARServer) handleComparePageLoads(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		PageA   string `json:"page_a"`
+		PageB   string `json:"page_b"`
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	comparison, err := h.parser.ComparePageLoads(loaded.harData, loaded.parseReport.TimingIndex(), args.PageA, args.PageB)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error comparing page loads: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(comparison, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal page load comparison: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleRetryStormReport handles the retry_storm_report tool call
+func (h *HARServer) handleRetryStormReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		WindowMS    int64  `json:"window_ms"`
+		MinAttempts int    `json:"min_attempts"`
+		Archive     string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetRetryStorms(loaded.harData, loaded.parseReport.TimingIndex(), time.Duration(args.WindowMS)*time.Millisecond, args.MinAttempts)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal retry storm report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleHTTP2Streams handles the http2_streams tool call
+func (h *HARServer) handleHTTP2Streams(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ReportHTTP2Streams(harData, loaded.parseReport.TimingIndex(), loaded.parseReport.ConnectionIndex())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal HTTP/2 stream report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleAltSvcReport handles the alt_svc_report tool call
+func (h *HARServer) handleAltSvcReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetAltSvcReport(harData, loaded.parseReport.TimingIndex())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal Alt-Svc report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleCacheBusterReport handles the cache_buster_report tool call
+func (h *HARServer) handleCacheBusterReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetCacheBusters(loaded.harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal cache buster report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleFreshnessReport handles the freshness_report tool call
+func (h *HARServer) handleFreshnessReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive           string `json:"archive"`
+		Page              string `json:"page"`
+		StaleAfterSeconds int    `json:"stale_after_seconds"`
+		OnlyStale         bool   `json:"only_stale"`
+		Limit             int    `json:"limit"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	staleAfter := time.Duration(args.StaleAfterSeconds) * time.Second
+	report := h.parser.GetFreshness(harData, loaded.parseReport.TimingIndex(), staleAfter, args.OnlyStale, args.Limit)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal freshness report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleServiceWorkerReport handles the service_worker_report tool call
+func (h *HARServer) handleServiceWorkerReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ReportServiceWorker(loaded.harData, loaded.parseReport.CustomFieldIndex(), loaded.parseReport.PageIndex(), loaded.parseReport.TimingIndex())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal service worker report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleResourceHintsEffectiveness handles the resource_hints_effectiveness tool call
+func (h *HARServer) handleResourceHintsEffectiveness(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ReportResourceHints(harData, loaded.parseReport.TimingIndex())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal resource hints report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleFontReport handles the font_report tool call
+func (h *HARServer) handleFontReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ReportFonts(harData, loaded.parseReport.PageIndex(), loaded.parseReport.TimingIndex())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal font report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleMediaReport handles the media_report tool call
+func (h *HARServer) handleMediaReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ReportMedia(harData, loaded.parseReport.TimingIndex())
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal media report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// redactionTools lists the tools changing the redaction policy
+func (h *HARServer) redactionTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "configure_redaction",
+				Description: "Adjust what is redacted from the contents returned by every tool: additional header names, regular expressions whose matches are redacted in header values, query parameters and bodies (e.g. JWTs, API keys), and cookies whose values are shown. Omitted parameters keep their current value. Returns the redaction policy in effect",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"headers": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "Header names to redact in addition to the credential headers (Authorization, Cookie, X-API-Key...)",
+						},
+						"value_patterns": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "Regular expressions whose matches are redacted",
+						},
+						"allowed_cookies": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "Names of the cookies whose values are shown, every other cookie value is redacted",
+						},
+						"disabled": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Turn redaction off entirely, only allowed when the server runs with --allow-sensitive",
+						},
+					},
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleConfigureRedaction,
+		},
+	}
+}
+
+// applyRedaction replaces the redaction policy, provided the configuration allows it
+func (h *HARServer) applyRedaction(policy harParser.RedactionPolicy) error {
+	if err := h.config.validateRedaction(policy); err != nil {
+		return err
+	}
+	if err := h.parser.SetRedactionPolicy(policy); err != nil {
+		return err
+	}
+	// Cached results were redacted with the previous policy
+	h.analyses.clear()
+	return nil
+}
+
+// handleConfigureRedaction handles the configure_redaction tool call
+func (h *HARServer) handleConfigureRedaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Headers        *[]string `json:"headers"`
+		ValuePatterns  *[]string `json:"value_patterns"`
+		AllowedCookies *[]string `json:"allowed_cookies"`
+		Disabled       *bool     `json:"disabled"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	policy := h.parser.RedactionPolicy()
+	if args.Headers != nil {
+		policy.Headers = *args.Headers
+	}
+	if args.ValuePatterns != nil {
+		policy.ValuePatterns = *args.ValuePatterns
+	}
+	if args.AllowedCookies != nil {
+		policy.AllowedCookies = *args.AllowedCookies
+	}
+	if args.Disabled != nil {
+		policy.Disabled = *args.Disabled
+	}
+
+	if err := h.applyRedaction(policy); err != nil {
+		return mcp.NewToolResultError(h.localize("Error configuring redaction: %v", err)), nil
+	}
+
+	h.mu.Lock()
+	h.workspace.state.Redaction = &policy
+	if err := h.workspace.save(); err != nil {
+		log.Printf("Failed to persist workspace: %v", err)
+	}
+	h.mu.Unlock()
+
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal redaction policy: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
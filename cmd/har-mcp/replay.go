@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// replayTools lists the tool replaying captured requests
+func (h *HARServer) replayTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "replay_request",
+				Description: "Send a captured request again to a live endpoint, optionally to another base URL and with header overrides, and return the live response next to the recorded one with whether the status and body changed. The request is sent with its unredacted headers and body, redirects are not followed. Only available when the server runs with --enable-replay",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: withArchive(map[string]interface{}{
+						"request_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The request ID to replay",
+						},
+						"base_url": map[string]interface{}{
+							"type":        "string",
+							"description": "Scheme and host replacing those of the captured URL, e.g. http://localhost:8080, its path prefixing the captured path",
+						},
+						"headers": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "string"},
+							"description":          "Headers set on the replayed request, replacing the captured ones, an empty value removes the header",
+						},
+						"max_body_bytes": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Maximum number of bytes of each body to return (defaults to %d)", harParser.DefaultRawBodySize),
+						},
+					}),
+					Required: []string{"request_id"},
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleReplayRequest,
+		},
+	}
+}
+
+// replayClient sends the requests of replay_request, returning redirects instead of following them
+var replayClient = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// handleReplayRequest handles the replay_request tool call
+func (h *HARServer) handleReplayRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive      string            `json:"archive"`
+		RequestID    string            `json:"request_id"`
+		BaseURL      string            `json:"base_url"`
+		Headers      map[string]string `json:"headers"`
+		MaxBodyBytes int               `json:"max_body_bytes"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result, err := h.parser.ReplayRequest(ctx, replayClient, loaded.harData, args.RequestID, harParser.ReplayOptions{
+		BaseURL:     args.BaseURL,
+		Headers:     args.Headers,
+		MaxBodySize: args.MaxBodyBytes,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error replaying request: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal replay result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
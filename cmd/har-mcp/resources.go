@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// archiveURIPrefix starts the URIs of the resources exposing the loaded archives
+const archiveURIPrefix = "har://archive/"
+
+// URI templates of the resources exposing the entries of the loaded archives
+const (
+	entryURITemplate        = archiveURIPrefix + "{name}/entry/{id}"
+	responseBodyURITemplate = archiveURIPrefix + "{name}/entry/{id}/response-body"
+)
+
+// archiveResource describes a loaded archive read as a resource
+type archiveResource struct {
+	archiveSummary
+	// EntryURITemplate and ResponseBodyURITemplate address the entries of the archive by request ID
+	EntryURITemplate        string `json:"entry_uri_template"`
+	ResponseBodyURITemplate string `json:"response_body_uri_template"`
+}
+
+// archiveURI returns the URI of the resource of the archive with the given name
+func archiveURI(name string) string {
+	return archiveURIPrefix + url.PathEscape(name)
+}
+
+// registerResources exposes the entries of the loaded archives as MCP resources, and publishes
+// an archive resource for every archive loaded from now on so clients are notified of new ones
+func (h *HARServer) registerResources(mcpServer *server.MCPServer) {
+	mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(entryURITemplate, "HAR entry",
+			mcp.WithTemplateDescription("Details of a request of a loaded HAR file, as returned by get_request_details, by archive name and request ID"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		h.readEntryResource,
+	)
+	mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(responseBodyURITemplate, "HAR response body",
+			mcp.WithTemplateDescription("Redacted and decoded response body of a request of a loaded HAR file, up to the largest page get_response_body returns, by archive name and request ID"),
+		),
+		h.readResponseBodyResource,
+	)
+
+	h.mu.Lock()
+	h.resources = mcpServer
+	archives := make([]*archive, 0, len(h.archives))
+	for _, name := range h.archiveNames() {
+		archives = append(archives, h.archives[name])
+	}
+	h.mu.Unlock()
+	for _, loaded := range archives {
+		h.publishArchive(loaded)
+	}
+}
+
+// publishArchive adds the resource of a loaded archive, replacing the one of an archive loaded
+// under the same name, which notifies the clients the resource list changed
+func (h *HARServer) publishArchive(loaded *archive) {
+	h.mu.RLock()
+	resources := h.resources
+	h.mu.RUnlock()
+	if resources == nil {
+		return
+	}
+
+	name := loaded.name
+	resources.AddResource(
+		mcp.NewResource(archiveURI(name), name,
+			mcp.WithResourceDescription(fmt.Sprintf("HAR file loaded from %s, with %d entries", loaded.source, harParser.AnalyzedEntries(loaded.harData))),
+			mcp.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return h.readArchiveResource(name, request.Params.URI)
+		},
+	)
+}
+
+// readArchiveResource describes the archive with the given name and how to address its entries
+func (h *HARServer) readArchiveResource(name, uri string) ([]mcp.ResourceContents, error) {
+	loaded, err := h.lookupArchive(name)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.RLock()
+	current := h.current
+	h.mu.RUnlock()
+	prefix := archiveURI(name) + "/entry/"
+	data, err := json.MarshalIndent(archiveResource{
+		archiveSummary: archiveSummary{
+			Name:    name,
+			Source:  loaded.source,
+			Entries: harParser.AnalyzedEntries(loaded.harData),
+			Current: name == current,
+		},
+		EntryURITemplate:        prefix + "{id}",
+		ResponseBodyURITemplate: prefix + "{id}/response-body",
+	}, "", "  ")
+	if err != nil {
+		return nil, errors.New(h.localize("Failed to marshal archive: %v", err))
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+// readEntryResource reads the details of the entry addressed by a URI of the entry template
+func (h *HARServer) readEntryResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	loaded, requestID, err := h.resourceEntry(request)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := h.parser.GetRequestDetails(loaded.harData, loaded.parseReport.TimingIndex(), loaded.parseReport.CustomFieldIndex(), requestID)
+	if err != nil {
+		return nil, errors.New(h.localize("Error getting request details: %v", err))
+	}
+	data, err := json.MarshalIndent(details, "", "  ")
+	if err != nil {
+		return nil, errors.New(h.localize("Failed to marshal request details: %v", err))
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+// readResponseBodyResource reads the response body of the entry addressed by a URI of the
+// response body template. Binary bodies are replaced by a marker, as get_response_body does.
+func (h *HARServer) readResponseBodyResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	loaded, requestID, err := h.resourceEntry(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := h.parser.GetResponseBody(loaded.harData, requestID, 0, harParser.MaxBodyPageSize)
+	if err != nil {
+		return nil, errors.New(h.localize("Failed to read response body: %v", err))
+	}
+	mimeType := body.MimeType
+	if body.Binary {
+		mimeType = "text/plain"
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: mimeType, Text: body.Text}}, nil
+}
+
+// resourceEntry returns the archive and the request ID addressed by the variables of an entry URI
+func (h *HARServer) resourceEntry(request mcp.ReadResourceRequest) (*archive, string, error) {
+	name := resourceVariable(request, "name")
+	requestID := resourceVariable(request, "id")
+	if name == "" || requestID == "" {
+		return nil, "", errors.New(h.localize("invalid resource URI %q", request.Params.URI))
+	}
+	loaded, err := h.lookupArchive(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return loaded, requestID, nil
+}
+
+// resourceVariable returns a variable matched in the URI of a resource template, empty when missing.
+// The template matcher unescapes the variables, so the archive names escaped by archiveURI come
+// back as they were and must not be unescaped again.
+func resourceVariable(request mcp.ReadResourceRequest, name string) string {
+	switch value := request.Params.Arguments[name].(type) {
+	case string:
+		return value
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestResourceServer returns an MCP server exposing the resources of the given server
+func newTestResourceServer(h *HARServer) *server.MCPServer {
+	mcpServer := server.NewMCPServer("har-mcp", "test", server.WithResourceCapabilities(false, true))
+	h.registerResources(mcpServer)
+	return mcpServer
+}
+
+// addTestArchive parses a HAR file holding a GET request to each of the given URLs and loads it
+// under the given name, publishing its resource
+func addTestArchive(t *testing.T, h *HARServer, name string, urls ...string) {
+	data, err := json.Marshal(newTestArchive(urls...))
+	require.NoError(t, err)
+	harData, parseReport, err := h.parser.ParseWithReport(bytes.NewReader(data))
+	require.NoError(t, err)
+	h.publishArchive(h.addArchive(name, name+".har", harData, parseReport))
+}
+
+// callResourceMethod sends a resources request to the MCP server and decodes its result into result
+func callResourceMethod(t *testing.T, mcpServer *server.MCPServer, method string, params any, result any) {
+	message, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	require.NoError(t, err)
+
+	response := mcpServer.HandleMessage(context.Background(), message)
+	require.IsType(t, mcp.JSONRPCResponse{}, response, response)
+	data, err := json.Marshal(response.(mcp.JSONRPCResponse).Result)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, result))
+}
+
+// readTestResource reads the resource at uri and returns its text
+func readTestResource(t *testing.T, mcpServer *server.MCPServer, uri string) string {
+	var result struct {
+		Contents []struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	callResourceMethod(t, mcpServer, "resources/read", map[string]any{"uri": uri}, &result)
+	require.Len(t, result.Contents, 1)
+	assert.Equal(t, uri, result.Contents[0].URI)
+	return result.Contents[0].Text
+}
+
+func TestResourcesListLoadedArchives(t *testing.T) {
+	h := newTestServer(t)
+	mcpServer := newTestResourceServer(h)
+	addTestArchive(t, h, "capture", "https://example.com/", "https://example.com/app.js")
+
+	var result mcp.ListResourcesResult
+	callResourceMethod(t, mcpServer, "resources/list", map[string]any{}, &result)
+
+	require.Len(t, result.Resources, 1)
+	assert.Equal(t, "har://archive/capture", result.Resources[0].URI)
+	assert.Equal(t, "capture", result.Resources[0].Name)
+	assert.Equal(t, "HAR file loaded from "+absoluteSource("capture.har")+", with 2 entries", result.Resources[0].Description)
+}
+
+func TestResourcesReadArchive(t *testing.T) {
+	h := newTestServer(t)
+	mcpServer := newTestResourceServer(h)
+	addTestArchive(t, h, "capture", "https://example.com/")
+
+	var resource archiveResource
+	require.NoError(t, json.Unmarshal([]byte(readTestResource(t, mcpServer, "har://archive/capture")), &resource))
+
+	assert.Equal(t, archiveResource{
+		archiveSummary:          archiveSummary{Name: "capture", Source: absoluteSource("capture.har"), Entries: 1, Current: true},
+		EntryURITemplate:        "har://archive/capture/entry/{id}",
+		ResponseBodyURITemplate: "har://archive/capture/entry/{id}/response-body",
+	}, resource)
+}
+
+func TestResourcesReadEntry(t *testing.T) {
+	h := newTestServer(t)
+	mcpServer := newTestResourceServer(h)
+	addTestArchive(t, h, "capture", "https://example.com/", "https://example.com/app.js")
+
+	var details struct {
+		Request struct {
+			URL string `json:"url"`
+		} `json:"request"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(readTestResource(t, mcpServer, "har://archive/capture/entry/request_1")), &details))
+
+	assert.Equal(t, "https://example.com/app.js", details.Request.URL)
+}
+
+func TestResourcesReadArchivesWithEscapedNames(t *testing.T) {
+	h := newTestServer(t)
+	mcpServer := newTestResourceServer(h)
+	addTestArchive(t, h, "prod/eu capture", "https://example.com/", "https://example.com/app.js")
+
+	var result mcp.ListResourcesResult
+	callResourceMethod(t, mcpServer, "resources/list", map[string]any{}, &result)
+	require.Len(t, result.Resources, 1)
+	uri := result.Resources[0].URI
+	assert.Equal(t, "har://archive/prod%2Feu%20capture", uri)
+
+	var resource archiveResource
+	require.NoError(t, json.Unmarshal([]byte(readTestResource(t, mcpServer, uri)), &resource))
+	assert.Equal(t, "prod/eu capture", resource.Name)
+
+	var details struct {
+		Request struct {
+			URL string `json:"url"`
+		} `json:"request"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(readTestResource(t, mcpServer, uri+"/entry/request_1")), &details))
+	assert.Equal(t, "https://example.com/app.js", details.Request.URL)
+}
+
+func TestResourcesReadArchivesWithPercentSigns(t *testing.T) {
+	h := newTestServer(t)
+	mcpServer := newTestResourceServer(h)
+	addTestArchive(t, h, "100%20off", "https://example.com/")
+
+	var resource archiveResource
+	require.NoError(t, json.Unmarshal([]byte(readTestResource(t, mcpServer, archiveURI("100%20off"))), &resource))
+	assert.Equal(t, "100%20off", resource.Name)
+	assert.Contains(t, readTestResource(t, mcpServer, archiveURI("100%20off")+"/entry/request_0"), "https://example.com/")
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// searchTools lists the tools finding entries by criteria, content, entity, time or page
+func (h *HARServer) searchTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "filter_entries",
+				Description: "List the entries matching every given criterion, with their request ID, status, MIME type and duration: status codes, classes or ranges, host globs, URL path prefixes, MIME types, minimum duration and start time range. Use it instead of list_urls_methods on large captures",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(entryFilterProperties())),
+				},
+			},
+			Handler: h.handleFilterEntries,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "search_entries",
+				Description: "Search the URLs, headers, query strings and request and response bodies of every entry for a substring or regular expression, e.g. to find all requests containing a UUID. Returns the matching request IDs with the field and excerpts around each occurrence with their byte offsets",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"page":    pageProperty(),
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "The substring or regular expression to search for",
+						},
+						"fields": map[string]interface{}{
+							"type": "array",
+							"items": map[string]interface{}{
+								"type": "string",
+								"enum": []string{"url", "request_headers", "response_headers", "query_string", "request_body", "response_body"},
+							},
+							"description": "Fields to search (defaults to every field)",
+						},
+						"regex": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Interpret the query as a regular expression (defaults to false)",
+						},
+						"case_sensitive": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Match case (defaults to false)",
+						},
+						"max_results": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum number of matches returned (defaults to 100)",
+						},
+						"window": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of bytes kept on each side of an occurrence in its excerpt (defaults to 40)",
+						},
+						"max_excerpts": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum number of excerpts returned per matching field (defaults to 10)",
+						},
+					},
+					Required: []string{"query"},
+				},
+			},
+			Handler: h.handleSearchEntries,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "build_entity_index",
+				Description: "Index the business entities (IDs, emails, UUIDs, SKUs) appearing in request URLs and JSON bodies, and summarize them by kind with the most referenced ones. Use find_entity to locate every request mentioning one of them",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"top": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of most referenced entities listed (defaults to 20)",
+						},
+					},
+				},
+			},
+			Handler: h.handleBuildEntityIndex,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "find_entity",
+				Description: "Find every request and response mentioning an entity such as an order ID, email, UUID or SKU, e.g. every call that touched order 8842, with the URL or JSONPath of each mention",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"value": map[string]interface{}{
+							"type":        "string",
+							"description": "The entity value, matched ignoring case",
+						},
+					},
+					Required: []string{"value"},
+				},
+			},
+			Handler: h.handleFindEntity,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "find_at_time",
+				Description: "Find the requests in flight (started but not finished) at a point in time, to answer what the application was waiting on at that moment. Requests are sorted by how long they had been running",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"at": map[string]interface{}{
+							"type":        "string",
+							"description": "An RFC 3339 timestamp, or an offset from the start of the capture such as 00:01:23.400, 1:23.4 or 83.4s",
+						},
+					},
+					Required: []string{"at"},
+				},
+			},
+			Handler: h.handleFindAtTime,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "list_pages",
+				Description: "List the page loads of a browser capture with their title, start time, DOMContentLoaded and load timings, and the number, size and errors of their entries. Pass a page ID as the page parameter of list_urls_methods, get_request_ids, filter_entries or search_entries to analyze a single page load",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleListPages,
+		},
+	}
+}
+
+// entryFilterProperties describes the harParser.EntryFilter criteria in tool input schemas
+func entryFilterProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"method": map[string]interface{}{
+			"type":        "string",
+			"description": "HTTP method",
+		},
+		"host": map[string]interface{}{
+			"type":        "string",
+			"description": "Host name of the request URL, a glob (e.g. *.example.com for every subdomain) or site: followed by a domain for every host of its registrable domain (e.g. site:example.co.uk matches api.eu.example.co.uk)",
+		},
+		"url_contains": map[string]interface{}{
+			"type":        "string",
+			"description": "Substring of the request URL",
+		},
+		"path_prefix": map[string]interface{}{
+			"type":        "string",
+			"description": "Prefix of the request URL path (e.g. /api/v2/)",
+		},
+		"status": map[string]interface{}{
+			"type":        "string",
+			"description": "Comma separated response status codes (e.g. 404), classes (e.g. 5xx) or ranges (e.g. 400-499)",
+		},
+		"mime_type": map[string]interface{}{
+			"type":        "string",
+			"description": "Response MIME type (e.g. application/json) or content family (e.g. json)",
+		},
+		"min_duration_ms": map[string]interface{}{
+			"type":        "number",
+			"description": "Minimum total duration in milliseconds",
+		},
+		"started_after": map[string]interface{}{
+			"type":        "string",
+			"description": "Earliest startedDateTime, as an RFC 3339 time (e.g. 2023-01-01T10:00:00Z)",
+		},
+		"started_before": map[string]interface{}{
+			"type":        "string",
+			"description": "Latest startedDateTime, excluded, as an RFC 3339 time",
+		},
+		"resource_type": map[string]interface{}{
+			"type":        "string",
+			"description": "Comma separated resource types recorded by Chrome DevTools (e.g. xhr,fetch or document)",
+		},
+	}
+}
+
+// handleFilterEntries handles the filter_entries tool call
+func (h *HARServer) handleFilterEntries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		harParser.EntryFilter
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	matches, err := h.parser.FilterEntries(harData, loaded.parseReport.TimingIndex(), loaded.parseReport.LookupIndex(), loaded.parseReport.CustomFieldIndex(), args.EntryFilter)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid filter: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal matching entries: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleSearchEntries handles the search_entries tool call
+func (h *HARServer) handleSearchEntries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		harParser.SearchQuery
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result, err := h.parser.SearchEntries(harData, args.SearchQuery)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error searching entries: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal search results: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleBuildEntityIndex handles the build_entity_index tool call
+func (h *HARServer) handleBuildEntityIndex(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Top     int    `json:"top"`
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	summary := h.entityIndex(loaded, true).Summary(args.Top)
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal entity index: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleFindEntity handles the find_entity tool call
+func (h *HARServer) handleFindEntity(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Value   string `json:"value"`
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	entity := h.entityIndex(loaded, false).Find(args.Value)
+	if entity == nil {
+		return mcp.NewToolResultText(h.localize("No request mentions %q", args.Value)), nil
+	}
+
+	data, err := json.MarshalIndent(entity, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal entity: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleFindAtTime handles the find_at_time tool call
+func (h *HARServer) handleFindAtTime(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		At      string `json:"at"`
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report, err := h.parser.FindAtTime(loaded.harData, loaded.parseReport.TimingIndex(), args.At)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error finding requests in flight: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal requests in flight: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleListPages handles the list_pages tool call
+func (h *HARServer) handleListPages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	pages := h.parser.ListPages(loaded.harData, loaded.parseReport.PageIndex())
+	data, err := json.MarshalIndent(pages, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal pages: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
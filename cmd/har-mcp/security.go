@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// securityTools lists the tools auditing the security, privacy and protocol compliance of a capture
+func (h *HARServer) securityTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "all_findings",
+				Description: "Run every analyzer (error summary, security scan, OAuth audit, token expiry, retry storms, slow requests, cache busters, MIME mismatches) and return a single deduplicated list of findings ranked by score, the severity weighted by the scoring policy of the server configuration, then by the number of requests involved. Each finding names the analyzer tool giving its details and lists the request IDs providing evidence, to review a capture in one call",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleAllFindings,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "oauth_audit",
+				Description: "Audit the captured OAuth and OpenID Connect authorize and token exchanges against basic best practices: PKCE present with S256 and its code_verifier sent, state present and echoed back, no implicit flow, no tokens in URLs, HTTPS or loopback redirect URIs matching between authorization and token requests. Findings are sorted by severity with evidence request IDs",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleOAuthAudit,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "analyze_auth_flow",
+				Description: "Reconstruct the authentication sequence of the session with timestamps: OpenID Connect discovery, OAuth authorization requests and their callbacks, credential submissions to login endpoints, token exchanges and refreshes with their grant type, logouts and revocations, cookies set by authentication paths, and the first request sending each Authorization header value, linked to the response that issued the token. Tokens are only identified by fingerprints and secret URL parameters are redacted",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleAnalyzeAuthFlow,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "token_expiry_report",
+				Description: "Find the JWTs sent in request headers, cookies and query parameters, decode their exp claims and correlate them with 401 responses, to diagnose refresh-logic bugs: tokens still sent after expiring, 401s despite unexpired tokens, 401s without any token. Tokens are identified by a fingerprint, never exposed",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleTokenExpiryReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "scan_secrets",
+				Description: "Scan the URLs, headers and bodies of the whole HAR file for secrets before sharing it: AWS access keys, GitHub, Slack and Stripe tokens, Google API keys, private keys, JWTs, credentials embedded in URLs (user:password@host) and API keys passed as query parameters. Each finding gives the kind of secret, its location (e.g. request.query.api_key or response.body), the request IDs carrying it and a fingerprint telling secrets apart; secret values are never returned. Headers meant to carry credentials, such as Authorization and Cookie, are left out",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleScanSecrets,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "debug_leak_report",
+				Description: "Scan response headers and bodies for debug information leaked by servers, a quick win for security reviews of staging traffic: stack traces, internal hostnames (.internal, .corp, .local, ...) and private IP addresses never requested in the capture, absolute paths to source files, error pages of frameworks in debug mode (Django, Werkzeug, Laravel, Rails, Spring Boot, ASP.NET, Symfony, phpinfo) and headers exposing debug tokens or software versions",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleDebugLeakReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "detect_interception",
+				Description: "Tell whether the HAR file was recorded behind a proxy, possibly inspecting TLS, so latency and TLS findings can be caveated: Via headers or vendor headers naming inspection products (Zscaler, Blue Coat, Netskope, Fortinet, ...) or debugging proxies (mitmproxy, Charles, Fiddler, Burp), certificates issued by such products, Proxy-Connection request headers, and unrelated sites sharing one server IP address, one certificate issuer or one Via header. Returns the signals with the request IDs showing them, a confidence level and the caveats applying to the analysis of the capture",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleDetectInterception,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "header_conflicts",
+				Description: "Flag responses with conflicting headers, which browsers resolve silently and inconsistently: single-valued headers such as Content-Type or Location repeated with different values, contradictory Cache-Control directives (no-store with max-age, public with private) and cookies set several times in a response for different domains or overriding each other",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleHeaderConflicts,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "lint_http",
+				Description: "Check the captured exchanges against HTTP semantics (RFC 9110 and 9112) and report violations per entry: GET, HEAD, DELETE or OPTIONS requests with a body, 1xx, 204, 304 or HEAD responses with content, Content-Length along with Transfer-Encoding or differing from the body received, redirects without Location, 405 without Allow and 401 without WWW-Authenticate",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleLintHTTP,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "mime_mismatch_report",
+				Description: "Flag responses whose Content-Type disagrees with the URL extension or with the sniffed body content (e.g. JavaScript served as text/html, JSON as text/plain)",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+					},
+				},
+			},
+			Handler: h.handleMimeMismatchReport,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "duplicate_tracking_report",
+				Description: "Flag the identical analytics events sent to several vendors: events of the analytics hits and pings carrying the same user identifier, event name and page, matched by hash whatever the field names each vendor uses, with the vendors receiving them and the redundant bytes sent beyond the first one, for privacy and performance reviews. User identifiers are never reported, only the fingerprints of the events",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: withPage(withArchive(map[string]interface{}{})),
+				},
+			},
+			Handler: h.handleDuplicateTrackingReport,
+		},
+	}
+}
+
+// interception returns the signs that the archive was recorded behind a proxy
+func (h *HARServer) interception(loaded *archive) *harParser.InterceptionReport {
+	return h.analysis(loaded, "detect_interception", func() interface{} {
+		return h.parser.DetectInterception(loaded.harData, loaded.parseReport.ConnectionIndex())
+	}).(*harParser.InterceptionReport)
+}
+
+// handleAllFindings handles the all_findings tool call
+func (h *HARServer) handleAllFindings(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	findings := h.analysis(loaded, "all_findings", func() interface{} {
+		findings := h.parser.GetAllFindings(loaded.harData)
+		findings.Caveats = h.interception(loaded).Caveats
+		return findings
+	})
+	data, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal findings: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleOAuthAudit handles the oauth_audit tool call
+func (h *HARServer) handleOAuthAudit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	audit := h.parser.AuditOAuth(loaded.harData)
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal OAuth audit: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleAnalyzeAuthFlow handles the analyze_auth_flow tool call
+func (h *HARServer) handleAnalyzeAuthFlow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	flow := h.parser.AnalyzeAuthFlow(harData)
+	data, err := json.MarshalIndent(flow, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal authentication flow: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleTokenExpiryReport handles the token_expiry_report tool call
+func (h *HARServer) handleTokenExpiryReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetTokenExpiryReport(loaded.harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal token expiry report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleScanSecrets handles the scan_secrets tool call
+func (h *HARServer) handleScanSecrets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ScanSecrets(harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal secret scan: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleDebugLeakReport handles the debug_leak_report tool call
+func (h *HARServer) handleDebugLeakReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetDebugLeaks(harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal debug leak report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleDetectInterception handles the detect_interception tool call
+func (h *HARServer) handleDetectInterception(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	data, err := json.MarshalIndent(h.interception(loaded), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal interception report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleHeaderConflicts handles the header_conflicts tool call
+func (h *HARServer) handleHeaderConflicts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetHeaderConflicts(harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal header conflicts: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleLintHTTP handles the lint_http tool call
+func (h *HARServer) handleLintHTTP(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.LintHTTP(harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal HTTP lint report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleMimeMismatchReport handles the mime_mismatch_report tool call
+func (h *HARServer) handleMimeMismatchReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.GetMimeMismatches(loaded.harData)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal MIME mismatch report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleDuplicateTrackingReport handles the duplicate_tracking_report tool call
+func (h *HARServer) handleDuplicateTrackingReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Archive string `json:"archive"`
+		Page    string `json:"page"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	harData, err := loaded.scopeToPage(args.Page)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	report := h.parser.ReportDuplicateTracking(harData, h.beacons(loaded, false))
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal duplicate tracking report: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolCounters accumulates the calls of one tool
+type toolCounters struct {
+	calls       int
+	errors      int
+	resultBytes int64
+	duration    time.Duration
+	maxDuration time.Duration
+}
+
+// toolUsage reports the calls of one tool
+type toolUsage struct {
+	Tool   string `json:"tool"`
+	Calls  int    `json:"calls"`
+	Errors int    `json:"errors"`
+	// ResultBytes is the size of the content returned, as for rate limits
+	ResultBytes     int64   `json:"result_bytes"`
+	AverageBytes    int64   `json:"average_bytes"`
+	AverageMS       float64 `json:"average_ms"`
+	MaxMS           float64 `json:"max_ms"`
+	TotalDurationMS float64 `json:"total_duration_ms"`
+}
+
+// serverStats reports the tool calls served since the server started
+type serverStats struct {
+	StartedAt     string  `json:"started_at"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Calls         int     `json:"calls"`
+	Errors        int     `json:"errors"`
+	ResultBytes   int64   `json:"result_bytes"`
+	// Tools lists the tools called, the ones returning the most bytes first
+	Tools []toolUsage `json:"tools"`
+}
+
+// usageStats counts the calls, returned bytes and handler latency of every tool
+type usageStats struct {
+	started time.Time
+	mu      sync.Mutex
+	tools   map[string]*toolCounters
+}
+
+// newUsageStats creates empty usage statistics starting now
+func newUsageStats() *usageStats {
+	return &usageStats{started: time.Now(), tools: make(map[string]*toolCounters)}
+}
+
+// middleware times the handler of every tool call and counts its result towards the statistics of the tool
+func (s *usageStats) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+		elapsed := time.Since(start)
+
+		s.record(request.Params.Name, elapsed, resultSize(result), err != nil || (result != nil && result.IsError))
+
+		return result, err
+	}
+}
+
+// record adds a call of the tool
+func (s *usageStats) record(tool string, elapsed time.Duration, size int, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counters, ok := s.tools[tool]
+	if !ok {
+		counters = &toolCounters{}
+		s.tools[tool] = counters
+	}
+	counters.calls++
+	if failed {
+		counters.errors++
+	}
+	counters.resultBytes += int64(size)
+	counters.duration += elapsed
+	counters.maxDuration = max(counters.maxDuration, elapsed)
+}
+
+// snapshot returns the statistics of the calls recorded so far
+func (s *usageStats) snapshot() serverStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := serverStats{
+		StartedAt:     s.started.UTC().Format(time.RFC3339),
+		UptimeSeconds: time.Since(s.started).Round(time.Second).Seconds(),
+		Tools:         []toolUsage{},
+	}
+	for tool, counters := range s.tools {
+		stats.Calls += counters.calls
+		stats.Errors += counters.errors
+		stats.ResultBytes += counters.resultBytes
+		stats.Tools = append(stats.Tools, toolUsage{
+			Tool:            tool,
+			Calls:           counters.calls,
+			Errors:          counters.errors,
+			ResultBytes:     counters.resultBytes,
+			AverageBytes:    counters.resultBytes / int64(counters.calls),
+			AverageMS:       durationMS(counters.duration / time.Duration(counters.calls)),
+			MaxMS:           durationMS(counters.maxDuration),
+			TotalDurationMS: durationMS(counters.duration),
+		})
+	}
+	sort.Slice(stats.Tools, func(i, j int) bool {
+		if stats.Tools[i].ResultBytes != stats.Tools[j].ResultBytes {
+			return stats.Tools[i].ResultBytes > stats.Tools[j].ResultBytes
+		}
+		return stats.Tools[i].Tool < stats.Tools[j].Tool
+	})
+	return stats
+}
+
+// durationMS converts a duration to milliseconds, rounded to the microsecond
+func durationMS(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+// handleServerStats handles the server_stats tool call
+func (h *HARServer) handleServerStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(h.stats.snapshot(), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal server statistics: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// statsTools lists the tool reporting the usage statistics of the server
+func (h *HARServer) statsTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "server_stats",
+				Description: "Report the tool calls served since the server started: per tool, the number of calls and errors, the bytes returned in total and on average, and the average and maximum handler latency, the tools returning the most bytes first, to tune heavy workflows and spot the noisiest tools",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]interface{}{},
+				},
+			},
+			Handler: h.handleServerStats,
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callThroughStats calls a handler returning result through the usage statistics middleware
+func callThroughStats(t *testing.T, stats *usageStats, tool string, result *mcp.CallToolResult) {
+	handler := stats.middleware(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return result, nil
+	})
+	_, err := handler(context.Background(), newTestToolRequest(tool, nil))
+	require.NoError(t, err)
+}
+
+func TestUsageStatsCountCallsPerTool(t *testing.T) {
+	stats := newUsageStats()
+	result := mcp.NewToolResultText("ok")
+
+	callThroughStats(t, stats, "har_info", result)
+	callThroughStats(t, stats, "har_info", result)
+	callThroughStats(t, stats, "list_urls", result)
+
+	snapshot := stats.snapshot()
+	assert.Equal(t, 3, snapshot.Calls)
+	require.Len(t, snapshot.Tools, 2)
+	assert.Equal(t, "har_info", snapshot.Tools[0].Tool)
+	assert.Equal(t, 2, snapshot.Tools[0].Calls)
+}
+
+func TestUsageStatsCountErrors(t *testing.T) {
+	stats := newUsageStats()
+
+	callThroughStats(t, stats, "har_info", mcp.NewToolResultError("No HAR file loaded"))
+
+	assert.Equal(t, 1, stats.snapshot().Errors)
+}
+
+func TestUsageStatsCountResultBytes(t *testing.T) {
+	stats := newUsageStats()
+	result := mcp.NewToolResultText("some content")
+
+	callThroughStats(t, stats, "har_info", result)
+
+	assert.Equal(t, int64(resultSize(result)), stats.snapshot().Tools[0].ResultBytes)
+}
+
+func TestUsageStatsListToolsReturningTheMostBytesFirst(t *testing.T) {
+	stats := newUsageStats()
+
+	callThroughStats(t, stats, "har_info", mcp.NewToolResultText("short"))
+	callThroughStats(t, stats, "get_response_body", mcp.NewToolResultText("a much longer response body"))
+
+	assert.Equal(t, "get_response_body", stats.snapshot().Tools[0].Tool)
+}
+
+func TestUsageStatsAverageDurations(t *testing.T) {
+	stats := newUsageStats()
+
+	stats.record("har_info", 10*time.Millisecond, 0, false)
+	stats.record("har_info", 30*time.Millisecond, 0, false)
+
+	usage := stats.snapshot().Tools[0]
+	assert.Equal(t, 20.0, usage.AverageMS)
+	assert.Equal(t, 30.0, usage.MaxMS)
+	assert.Equal(t, 40.0, usage.TotalDurationMS)
+}
+
+func TestResultSizeCountsTheSerializedContent(t *testing.T) {
+	result := mcp.NewToolResultText("content")
+	content, err := json.Marshal(result.Content)
+	require.NoError(t, err)
+
+	assert.Equal(t, len(content), resultSize(result))
+	assert.Equal(t, 0, resultSize(nil))
+}
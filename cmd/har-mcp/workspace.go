@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// workspaceState is the analyst's working context persisted across server restarts
+type workspaceState struct {
+	// Archives are the file paths or URLs of the loaded HAR files, by archive name
+	Archives map[string]string `json:"archives,omitempty"`
+	// Current is the name of the archive tools use by default
+	Current string `json:"current,omitempty"`
+	// Source is the single loaded HAR of state files written before named archives
+	Source string `json:"source,omitempty"`
+	// Queries are the saved entry filters, by name
+	Queries map[string]harParser.EntryFilter `json:"queries,omitempty"`
+	// Exclusions are the request IDs left out of the analysis with exclude_requests, by archive name
+	Exclusions map[string][]string `json:"exclusions,omitempty"`
+	// Redaction is the redaction policy set with configure_redaction, nil keeping the configured one
+	Redaction *harParser.RedactionPolicy `json:"redaction,omitempty"`
+}
+
+// workspace persists the working context to a state file, or keeps it in memory when path is empty
+type workspace struct {
+	path  string
+	state workspaceState
+}
+
+// absoluteSource makes file paths absolute so the workspace survives a change of working
+// directory, URLs and object storage URIs being kept as is
+func absoluteSource(source string) string {
+	if u, err := url.Parse(source); err == nil && slices.Contains([]string{"http", "https", "s3", "gs", "az"}, u.Scheme) {
+		return source
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		return abs
+	}
+	return source
+}
+
+// openWorkspace reads the state file at path, a missing file yields an empty workspace
+func openWorkspace(path string) (*workspace, error) {
+	w := &workspace{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &w.state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	return w, nil
+}
+
+// save writes the state file atomically so a crash never leaves a truncated workspace behind
+func (w *workspace) save() error {
+	if w.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal workspace: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), filepath.Base(w.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
+
+// workspaceTools lists the tools saving and running queries in the workspace
+func (h *HARServer) workspaceTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "save_query",
+				Description: "Save an entry filter under a name in the workspace so it can be rerun later with run_saved_query, replacing any query of the same name",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the query, e.g. prod-5xx-json-over-1s",
+						},
+						"filter": map[string]interface{}{
+							"type":        "object",
+							"description": "The entry filter, every criterion is optional and all of them must match",
+							"properties":  entryFilterProperties(),
+						},
+					},
+					Required: []string{"name", "filter"},
+				},
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(false)},
+			},
+			Handler: h.handleSaveQuery,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "run_saved_query",
+				Description: "Run a query saved with save_query against the loaded HAR file and list the matching entries",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"archive": archiveProperty(),
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the saved query",
+						},
+					},
+					Required: []string{"name"},
+				},
+			},
+			Handler: h.handleRunSavedQuery,
+		},
+	}
+}
+
+// saveQuery saves the filter under name in the workspace and persists it
+func (h *HARServer) saveQuery(name string, filter harParser.EntryFilter) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.workspace.state.Queries == nil {
+		h.workspace.state.Queries = make(map[string]harParser.EntryFilter)
+	}
+	h.workspace.state.Queries[name] = filter
+	return h.workspace.save()
+}
+
+// savedQuery returns the filter saved under name, the error listing the saved queries otherwise
+func (h *HARServer) savedQuery(name string) (harParser.EntryFilter, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	filter, ok := h.workspace.state.Queries[name]
+	if !ok {
+		names := make([]string, 0, len(h.workspace.state.Queries))
+		for name := range h.workspace.state.Queries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return filter, errors.New(h.localize("Unknown query %q, saved queries: %s", name, strings.Join(names, ", ")))
+	}
+	return filter, nil
+}
+
+// handleSaveQuery handles the save_query tool call
+func (h *HARServer) handleSaveQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Name   string                `json:"name"`
+		Filter harParser.EntryFilter `json:"filter"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+	if args.Name == "" {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: name is required")), nil
+	}
+	if err := args.Filter.Validate(); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid filter: %v", err)), nil
+	}
+
+	if err := h.saveQuery(args.Name, args.Filter); err != nil {
+		return mcp.NewToolResultError(h.localize("Error saving query: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(h.localize("Saved query %q", args.Name)), nil
+}
+
+// handleRunSavedQuery handles the run_saved_query tool call
+func (h *HARServer) handleRunSavedQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Name    string `json:"name"`
+		Archive string `json:"archive"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError(h.localize("Invalid arguments: %v", err)), nil
+	}
+
+	loaded, err := h.lookupArchive(args.Archive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	filter, err := h.savedQuery(args.Name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	matches, err := h.parser.FilterEntries(loaded.harData, loaded.parseReport.TimingIndex(), loaded.parseReport.LookupIndex(), loaded.parseReport.CustomFieldIndex(), filter)
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Error running query: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(h.localize("Failed to marshal matching entries: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// writeTestArchive writes a HAR file holding a GET request to each of the given URLs and returns its path
+func writeTestArchive(t *testing.T, urls ...string) string {
+	data, err := json.Marshal(newTestArchive(urls...))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "capture.har")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// restartTestServer returns a new server restoring the workspace the given server persisted
+func restartTestServer(t *testing.T, h *HARServer) *HARServer {
+	restarted := NewHARServer(Config{})
+	var err error
+	restarted.workspace, err = openWorkspace(h.workspace.path)
+	require.NoError(t, err)
+	restarted.restoreWorkspace()
+	return restarted
+}
+
+func TestWorkspaceRestoresArchives(t *testing.T) {
+	h := newTestServer(t)
+	path := writeTestArchive(t, "https://example.com/", "https://example.com/app.js")
+	_, err := h.loadHAR(context.Background(), "first", path, harParser.FetchOptions{})
+	require.NoError(t, err)
+	_, err = h.loadHAR(context.Background(), "second", path, harParser.FetchOptions{})
+	require.NoError(t, err)
+	h.current = "first"
+	h.workspace.state.Current = "first"
+	require.NoError(t, h.workspace.save())
+
+	restarted := restartTestServer(t, h)
+
+	assert.Equal(t, []archiveSummary{
+		{Name: "first", Source: path, Entries: 2, Current: true},
+		{Name: "second", Source: path, Entries: 2},
+	}, restarted.listArchives())
+}
+
+func TestWorkspaceRestoresExclusions(t *testing.T) {
+	h := newTestServer(t)
+	_, err := h.loadHAR(context.Background(), "capture", writeTestArchive(t, "https://example.com/", "https://tracker.net/pixel"), harParser.FetchOptions{})
+	require.NoError(t, err)
+	callExclusionTool(t, h.handleExcludeRequests, map[string]any{"request_ids": []any{"request_1"}})
+
+	restarted := restartTestServer(t, h)
+
+	loaded, err := restarted.lookupArchive("capture")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"request_1": true}, loaded.excluded)
+	assert.Equal(t, 1, harParser.AnalyzedEntries(loaded.harData))
+}
+
+func TestWorkspaceForgetsExclusionsOfReloadedArchives(t *testing.T) {
+	h := newTestServer(t)
+	path := writeTestArchive(t, "https://example.com/", "https://tracker.net/pixel")
+	_, err := h.loadHAR(context.Background(), "capture", path, harParser.FetchOptions{})
+	require.NoError(t, err)
+	callExclusionTool(t, h.handleExcludeRequests, map[string]any{"request_ids": []any{"request_1"}})
+	_, err = h.loadHAR(context.Background(), "capture", path, harParser.FetchOptions{})
+	require.NoError(t, err)
+
+	restarted := restartTestServer(t, h)
+
+	loaded, err := restarted.lookupArchive("capture")
+	require.NoError(t, err)
+	assert.Empty(t, loaded.excluded)
+}
+
+func TestWorkspaceRestoresSavedQueries(t *testing.T) {
+	h := newTestServer(t)
+	result, err := h.handleSaveQuery(context.Background(), newTestToolRequest("save_query", map[string]any{"name": "errors", "filter": map[string]any{"status": "5xx"}}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	restarted := restartTestServer(t, h)
+
+	filter, err := restarted.savedQuery("errors")
+	require.NoError(t, err)
+	assert.Equal(t, "5xx", filter.Status)
+}
+
+func TestWorkspaceRestoresRedactionPolicy(t *testing.T) {
+	h := newTestServer(t)
+	result, err := h.handleConfigureRedaction(context.Background(), newTestToolRequest("configure_redaction", map[string]any{"value_patterns": []any{`sk_live_\w+`}}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	restarted := restartTestServer(t, h)
+
+	assert.Equal(t, []string{`sk_live_\w+`}, restarted.parser.RedactionPolicy().ValuePatterns)
+}
+
+func TestWorkspaceKeepsRedactionWithoutAllowSensitive(t *testing.T) {
+	h := newTestServer(t)
+	h.config.AllowSensitive = true
+	result, err := h.handleConfigureRedaction(context.Background(), newTestToolRequest("configure_redaction", map[string]any{"disabled": true}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	restarted := restartTestServer(t, h)
+
+	assert.False(t, restarted.parser.RedactionPolicy().Disabled)
+}
+
+func TestWorkspaceSkipsArchivesThatFailToLoad(t *testing.T) {
+	h := newTestServer(t)
+	path := writeTestArchive(t, "https://example.com/")
+	_, err := h.loadHAR(context.Background(), "gone", path, harParser.FetchOptions{})
+	require.NoError(t, err)
+	_, err = h.loadHAR(context.Background(), "kept", writeTestArchive(t, "https://example.com/"), harParser.FetchOptions{})
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(path))
+
+	restarted := restartTestServer(t, h)
+
+	archives := restarted.listArchives()
+	require.Len(t, archives, 1)
+	assert.Equal(t, "kept", archives[0].Name)
+}
+
+func TestOpenWorkspaceStartsEmptyWithoutStateFile(t *testing.T) {
+	w, err := openWorkspace(filepath.Join(t.TempDir(), "state.json"))
+
+	require.NoError(t, err)
+	assert.Equal(t, workspaceState{}, w.state)
+}
+
+func TestOpenWorkspaceRejectsCorruptStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"archives": `), 0o600))
+
+	_, err := openWorkspace(path)
+
+	assert.ErrorContains(t, err, "failed to parse state file")
+}
+
+func TestOpenWorkspaceReportsUnreadableStateFile(t *testing.T) {
+	_, err := openWorkspace(t.TempDir())
+
+	assert.ErrorContains(t, err, "failed to read state file")
+}
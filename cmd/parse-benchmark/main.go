@@ -0,0 +1,114 @@
+// Command parse-benchmark measures how long the HAR parser takes on a capture, either a
+// HAR file or URL given as argument or a generated one, to compare parser changes.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+	"github.com/tjamet/har-mcp/pkg/har/hartest"
+)
+
+func main() {
+	entries := flag.Int("entries", 1000, "Number of entries of the generated capture")
+	styleName := flag.String("style", string(hartest.Strict), "Style of the generated capture: strict, flexible or malformed")
+	iterations := flag.Int("iterations", 10, "Number of times the capture is parsed")
+	output := flag.String("output", "", "Write the generated capture to this file instead of parsing it")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [har-file-or-url]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *iterations < 1 {
+		log.Fatal("iterations must be at least 1")
+	}
+
+	source, data, err := capture(flag.Arg(0), *entries, *styleName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output != "" {
+		if err := os.WriteFile(*output, data, 0o644); err != nil {
+			log.Fatalf("failed to write capture: %v", err)
+		}
+		fmt.Printf("wrote %s (%d bytes) to %s\n", source, len(data), *output)
+		return
+	}
+
+	parser := harParser.NewParser()
+	parser.SetLenient(true)
+
+	var total, fastest, slowest time.Duration
+	var report *harParser.ParseReport
+	entryCount := 0
+	for i := 0; i < *iterations; i++ {
+		started := time.Now()
+		harData, parseReport, err := parser.ParseWithReport(bytes.NewReader(data))
+		elapsed := time.Since(started)
+		if err != nil {
+			log.Fatal(err)
+		}
+		report, entryCount = parseReport, len(harData.Log.Entries)
+
+		total += elapsed
+		if i == 0 || elapsed < fastest {
+			fastest = elapsed
+		}
+		if elapsed > slowest {
+			slowest = elapsed
+		}
+	}
+
+	mean := total / time.Duration(*iterations)
+	fmt.Printf("source:     %s\n", source)
+	fmt.Printf("size:       %d bytes\n", len(data))
+	fmt.Printf("mode:       %s\n", report.Mode)
+	fmt.Printf("entries:    %d\n", entryCount)
+	fmt.Printf("warnings:   %d\n", len(report.Warnings))
+	fmt.Printf("iterations: %d\n", *iterations)
+	fmt.Printf("time:       min %v, mean %v, max %v\n", fastest, mean, slowest)
+	fmt.Printf("throughput: %.2f MB/s\n", float64(len(data))/mean.Seconds()/1e6)
+}
+
+// capture returns the bytes of the HAR file or URL, or of a generated capture when path is empty
+func capture(path string, entries int, styleName string) (string, []byte, error) {
+	if path == "" {
+		style, err := hartest.ParseStyle(styleName)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("generated %s capture of %d entries", style, entries), hartest.Generate(entries, style), nil
+	}
+
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		resp, err := http.Get(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to fetch HAR file: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", nil, fmt.Errorf("failed to fetch HAR file: HTTP %d", resp.StatusCode)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read HAR file: %w", err)
+		}
+		return path, data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+	return path, data, nil
+}
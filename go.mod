@@ -4,6 +4,7 @@ go 1.24.3
 
 require (
 	github.com/google/martian v2.1.0+incompatible
+	github.com/klauspost/compress v1.18.0
 	github.com/mark3labs/mcp-go v0.31.0
 	github.com/stretchr/testify v1.10.0
 )
@@ -0,0 +1,207 @@
+package har
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Protocols reported by the Alt-Svc report
+const (
+	ProtocolHTTP1 = "http/1.1"
+	ProtocolH2    = "h2"
+	ProtocolH3    = "h3"
+)
+
+// AltSvcService is an alternative service advertised by an Alt-Svc header (RFC 7838)
+type AltSvcService struct {
+	// Protocol is the ALPN protocol ID, such as h3 or h3-29
+	Protocol  string `json:"protocol"`
+	Authority string `json:"authority"`
+	// MaxAgeSeconds is the ma parameter, 0 when omitted, in which case it defaults to 24 hours
+	MaxAgeSeconds int `json:"max_age_seconds,omitempty"`
+}
+
+// AltSvcHost describes the protocols a host advertised and the client used over the session
+type AltSvcHost struct {
+	Host string `json:"host"`
+	// Advertised are the alternative services of the Alt-Svc headers of the host, first advertised first
+	Advertised []AltSvcService `json:"advertised"`
+	// AdvertisedBy is the first request whose response advertised HTTP/3
+	AdvertisedBy string `json:"advertised_by,omitempty"`
+	// Cleared is set when the host withdrew its alternative services with Alt-Svc: clear
+	Cleared bool `json:"cleared,omitempty"`
+	// Protocols counts the requests by protocol
+	Protocols map[string]int `json:"protocols"`
+	// Upgraded is set when the client switched to HTTP/3 during the session, SwitchedAt being the
+	// first HTTP/3 request following other protocols
+	Upgraded   bool   `json:"upgraded"`
+	SwitchedAt string `json:"switched_at,omitempty"`
+	// MedianBeforeMS and MedianAfterMS are the median durations of the requests before and after the switch
+	MedianBeforeMS float64 `json:"median_before_ms,omitempty"`
+	MedianAfterMS  float64 `json:"median_after_ms,omitempty"`
+	DeltaMS        float64 `json:"delta_ms,omitempty"`
+}
+
+// AltSvcReport tells which hosts advertised HTTP/3 and whether the client upgraded to it
+type AltSvcReport struct {
+	// HostsAdvertisingH3 counts the hosts advertising HTTP/3, HostsUpgraded those the client
+	// switched to HTTP/3 for and HostsOnH3 those using HTTP/3 from their first request
+	HostsAdvertisingH3 int          `json:"hosts_advertising_h3"`
+	HostsUpgraded      int          `json:"hosts_upgraded"`
+	HostsOnH3          int          `json:"hosts_on_h3"`
+	Hosts              []AltSvcHost `json:"hosts"`
+}
+
+// altSvcHostState accumulates the requests of a host
+type altSvcHostState struct {
+	host      AltSvcHost
+	before    []float64
+	after     []float64
+	firstH3   int
+	otherSeen bool
+}
+
+// GetAltSvcReport reads the Alt-Svc response headers and the protocol versions of the entries to
+// show, per host, the alternative services advertised and whether the client upgraded to HTTP/3
+// during the session. For hosts switching over, the median durations of the requests before and
+// after the switch are compared. Hosts are listed in the order they were first requested;
+// durations are read from the timing index.
+func (p *Parser) GetAltSvcReport(harData *har.HAR, index *TimingIndex) *AltSvcReport {
+	hosts := make(map[string]*altSvcHostState)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		state, ok := hosts[u.Host]
+		if !ok {
+			state = &altSvcHostState{host: AltSvcHost{Host: u.Host, Advertised: []AltSvcService{}, Protocols: make(map[string]int)}, firstH3: -1}
+			hosts[u.Host] = state
+			order = append(order, u.Host)
+		}
+
+		protocol := entryProtocol(entry)
+		state.host.Protocols[protocol]++
+		duration := index.Time(entry)
+		switch {
+		case protocol == ProtocolH3 && state.otherSeen:
+			if state.firstH3 < 0 {
+				state.firstH3 = i
+			}
+			state.after = append(state.after, duration)
+		case protocol == ProtocolH3:
+			if state.firstH3 < 0 {
+				state.firstH3 = i
+			}
+		case state.firstH3 < 0:
+			state.otherSeen = true
+			state.before = append(state.before, duration)
+		}
+
+		if entry.Response == nil {
+			continue
+		}
+		for _, header := range entry.Response.Headers {
+			if !strings.EqualFold(header.Name, "Alt-Svc") {
+				continue
+			}
+			services, cleared := parseAltSvc(header.Value)
+			state.host.Cleared = state.host.Cleared || cleared
+			for _, service := range services {
+				if !containsService(state.host.Advertised, service) {
+					state.host.Advertised = append(state.host.Advertised, service)
+				}
+				if isH3(service.Protocol) && state.host.AdvertisedBy == "" {
+					state.host.AdvertisedBy = formatRequestID(i)
+				}
+			}
+		}
+	}
+
+	report := &AltSvcReport{Hosts: make([]AltSvcHost, 0, len(order))}
+	for _, name := range order {
+		state := hosts[name]
+		host := state.host
+		if host.AdvertisedBy != "" {
+			report.HostsAdvertisingH3++
+		}
+		if len(state.after) > 0 {
+			host.Upgraded = true
+			host.SwitchedAt = formatRequestID(state.firstH3)
+			host.MedianBeforeMS = median(state.before)
+			host.MedianAfterMS = median(state.after)
+			host.DeltaMS = host.MedianAfterMS - host.MedianBeforeMS
+			report.HostsUpgraded++
+		} else if state.firstH3 >= 0 {
+			report.HostsOnH3++
+		}
+		report.Hosts = append(report.Hosts, host)
+	}
+	return report
+}
+
+// parseAltSvc parses the alternative services of an Alt-Svc header value, reporting whether it
+// clears them instead
+func parseAltSvc(value string) ([]AltSvcService, bool) {
+	if strings.EqualFold(strings.TrimSpace(value), "clear") {
+		return nil, true
+	}
+	var services []AltSvcService
+	for _, alternative := range strings.Split(value, ",") {
+		parameters := strings.Split(alternative, ";")
+		protocol, authority, ok := strings.Cut(strings.TrimSpace(parameters[0]), "=")
+		if !ok || protocol == "" {
+			continue
+		}
+		service := AltSvcService{Protocol: protocol, Authority: strings.Trim(authority, `"`)}
+		for _, parameter := range parameters[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+			if strings.EqualFold(name, "ma") {
+				service.MaxAgeSeconds, _ = strconv.Atoi(strings.Trim(value, `"`))
+			}
+		}
+		services = append(services, service)
+	}
+	return services, false
+}
+
+// containsService reports whether the service is already listed
+func containsService(services []AltSvcService, service AltSvcService) bool {
+	for _, listed := range services {
+		if listed.Protocol == service.Protocol && listed.Authority == service.Authority {
+			return true
+		}
+	}
+	return false
+}
+
+// isH3 reports whether an ALPN protocol ID is HTTP/3, final (h3) or a draft (h3-29)
+func isH3(protocol string) bool {
+	protocol = strings.ToLower(protocol)
+	return protocol == "h3" || strings.HasPrefix(protocol, "h3-") || strings.HasPrefix(protocol, "h3q")
+}
+
+// entryProtocol returns the protocol the entry was exchanged over, read from the version of its
+// response or of its request, http/1.1 when unknown
+func entryProtocol(entry *har.Entry) string {
+	version := entry.Request.HTTPVersion
+	if entry.Response != nil && entry.Response.HTTPVersion != "" {
+		version = entry.Response.HTTPVersion
+	}
+	version = strings.ToLower(strings.TrimSpace(version))
+	switch {
+	case isH3(version) || version == "http/3" || version == "http/3.0" || version == "quic":
+		return ProtocolH3
+	case version == "h2" || version == "h2c" || version == "http/2" || version == "http/2.0":
+		return ProtocolH2
+	}
+	return ProtocolHTTP1
+}
@@ -0,0 +1,61 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProtocolEntry builds a request to the URL over the protocol lasting ms milliseconds
+func newTestProtocolEntry(url, protocol string, ms int64, headers ...har.Header) *har.Entry {
+	entry := newTestEntry("GET", url)
+	entry.Request.HTTPVersion = protocol
+	entry.Response.HTTPVersion = protocol
+	entry.Response.Headers = headers
+	entry.Time = ms
+	return entry
+}
+
+func TestGetAltSvcReportFindsUpgrades(t *testing.T) {
+	altSvc := har.Header{Name: "alt-svc", Value: `h3=":443"; ma=86400, h3-29=":443"; ma=86400`}
+	archive := newTestHAR(
+		newTestProtocolEntry("https://example.com/", "h2", 120, altSvc),
+		newTestProtocolEntry("https://example.com/app.js", "h2", 80),
+		newTestProtocolEntry("https://example.com/api", "h3", 60),
+		newTestProtocolEntry("https://example.com/api", "h3", 40),
+		newTestProtocolEntry("https://cdn.example.net/lib.js", "http/2.0", 30, har.Header{Name: "Alt-Svc", Value: "clear"}),
+	)
+
+	report := NewParser().GetAltSvcReport(archive, nil)
+
+	assert.Equal(t, 1, report.HostsAdvertisingH3)
+	assert.Equal(t, 1, report.HostsUpgraded)
+	require.Len(t, report.Hosts, 2)
+	host := report.Hosts[0]
+	assert.Equal(t, []AltSvcService{
+		{Protocol: "h3", Authority: ":443", MaxAgeSeconds: 86400},
+		{Protocol: "h3-29", Authority: ":443", MaxAgeSeconds: 86400},
+	}, host.Advertised)
+	assert.Equal(t, "request_0", host.AdvertisedBy)
+	assert.Equal(t, map[string]int{ProtocolH2: 2, ProtocolH3: 2}, host.Protocols)
+	assert.True(t, host.Upgraded)
+	assert.Equal(t, "request_2", host.SwitchedAt)
+	assert.Equal(t, 100.0, host.MedianBeforeMS)
+	assert.Equal(t, 50.0, host.MedianAfterMS)
+	assert.Equal(t, -50.0, host.DeltaMS)
+
+	assert.True(t, report.Hosts[1].Cleared)
+	assert.False(t, report.Hosts[1].Upgraded)
+}
+
+func TestGetAltSvcReportCountsHostsOnH3FromTheStart(t *testing.T) {
+	archive := newTestHAR(newTestProtocolEntry("https://example.com/", "HTTP/3", 20))
+
+	report := NewParser().GetAltSvcReport(archive, nil)
+
+	assert.Equal(t, 1, report.HostsOnH3)
+	assert.Zero(t, report.HostsUpgraded)
+	assert.Equal(t, map[string]int{ProtocolH3: 1}, report.Hosts[0].Protocols)
+}
@@ -0,0 +1,215 @@
+package har
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultLatencyThresholdMS is the increase of the median duration of an endpoint above
+	// which it is reported as a regression when no threshold is given
+	DefaultLatencyThresholdMS = 100
+	// sizeChangeRatio is the relative change of the average response size of an endpoint
+	// from which it is reported as changed
+	sizeChangeRatio = 0.1
+)
+
+// ArchiveEndpoint is an endpoint requested in a single one of the compared archives
+type ArchiveEndpoint struct {
+	Method    string `json:"method"`
+	Template  string `json:"template"`
+	Requests  int    `json:"requests"`
+	RequestID string `json:"request_id"`
+}
+
+// EndpointDelta compares an endpoint requested in both archives. Durations are the median
+// durations of its requests and sizes the average sizes of its response bodies.
+type EndpointDelta struct {
+	Method           string  `json:"method"`
+	Template         string  `json:"template"`
+	RequestsA        int     `json:"requests_a"`
+	RequestsB        int     `json:"requests_b"`
+	RequestIDA       string  `json:"request_id_a"`
+	RequestIDB       string  `json:"request_id_b"`
+	StatusesA        []int   `json:"statuses_a"`
+	StatusesB        []int   `json:"statuses_b"`
+	StatusChanged    bool    `json:"status_changed"`
+	SizeA            int64   `json:"size_a"`
+	SizeB            int64   `json:"size_b"`
+	SizeDelta        int64   `json:"size_delta"`
+	SizeChanged      bool    `json:"size_changed"`
+	MedianMSA        float64 `json:"median_ms_a"`
+	MedianMSB        float64 `json:"median_ms_b"`
+	LatencyDeltaMS   float64 `json:"latency_delta_ms"`
+	LatencyRegressed bool    `json:"latency_regressed"`
+}
+
+// ArchiveComparison diffs the endpoints requested in two archives
+type ArchiveComparison struct {
+	EntriesA           int     `json:"entries_a"`
+	EntriesB           int     `json:"entries_b"`
+	LatencyThresholdMS float64 `json:"latency_threshold_ms"`
+	// Common counts the endpoints requested in both archives, Changed lists those among them
+	// whose statuses, response size or latency changed, status changes and regressions first
+	Common             int               `json:"common"`
+	StatusChanges      int               `json:"status_changes"`
+	SizeChanges        int               `json:"size_changes"`
+	LatencyRegressions int               `json:"latency_regressions"`
+	Changed            []EndpointDelta   `json:"changed"`
+	OnlyInA            []ArchiveEndpoint `json:"only_in_a"`
+	OnlyInB            []ArchiveEndpoint `json:"only_in_b"`
+}
+
+// archiveEndpoint gathers the requests of an endpoint in an archive
+type archiveEndpoint struct {
+	method    string
+	template  string
+	requestID string
+	statuses  []int
+	durations []float64
+	size      int64
+}
+
+// CompareArchives diffs two archives, such as captures taken before and after a deploy, by
+// endpoint (same method and URL template): the endpoints requested in a single archive, and for
+// the others the changes of response statuses, of average response size by more than 10% and
+// the increases of the median duration above thresholdMS. Durations are read from the timing
+// index of each archive.
+func (p *Parser) CompareArchives(harA *har.HAR, indexA *TimingIndex, harB *har.HAR, indexB *TimingIndex, thresholdMS float64) *ArchiveComparison {
+	if thresholdMS <= 0 {
+		thresholdMS = DefaultLatencyThresholdMS
+	}
+	redaction := p.redaction()
+	endpointsA, orderA := groupArchiveEndpoints(harA, indexA)
+	endpointsB, orderB := groupArchiveEndpoints(harB, indexB)
+
+	comparison := &ArchiveComparison{
+		EntriesA:           AnalyzedEntries(harA),
+		EntriesB:           AnalyzedEntries(harB),
+		LatencyThresholdMS: thresholdMS,
+		Changed:            []EndpointDelta{},
+		OnlyInA:            []ArchiveEndpoint{},
+		OnlyInB:            []ArchiveEndpoint{},
+	}
+	for _, key := range orderA {
+		a := endpointsA[key]
+		b, ok := endpointsB[key]
+		if !ok {
+			comparison.OnlyInA = append(comparison.OnlyInA, a.summary(redaction))
+			continue
+		}
+		comparison.Common++
+
+		delta := EndpointDelta{
+			Method:     a.method,
+			Template:   redaction.text(a.template),
+			RequestsA:  len(a.durations),
+			RequestsB:  len(b.durations),
+			RequestIDA: a.requestID,
+			RequestIDB: b.requestID,
+			StatusesA:  distinctStatuses(a.statuses),
+			StatusesB:  distinctStatuses(b.statuses),
+			SizeA:      a.size / int64(len(a.durations)),
+			SizeB:      b.size / int64(len(b.durations)),
+			MedianMSA:  median(a.durations),
+			MedianMSB:  median(b.durations),
+		}
+		delta.StatusChanged = !slices.Equal(delta.StatusesA, delta.StatusesB)
+		delta.SizeDelta = delta.SizeB - delta.SizeA
+		delta.SizeChanged = float64(abs(delta.SizeDelta)) > sizeChangeRatio*float64(max(delta.SizeA, 1))
+		delta.LatencyDeltaMS = delta.MedianMSB - delta.MedianMSA
+		delta.LatencyRegressed = delta.LatencyDeltaMS > thresholdMS
+		if !delta.StatusChanged && !delta.SizeChanged && !delta.LatencyRegressed {
+			continue
+		}
+		if delta.StatusChanged {
+			comparison.StatusChanges++
+		}
+		if delta.SizeChanged {
+			comparison.SizeChanges++
+		}
+		if delta.LatencyRegressed {
+			comparison.LatencyRegressions++
+		}
+		comparison.Changed = append(comparison.Changed, delta)
+	}
+	for _, key := range orderB {
+		if _, ok := endpointsA[key]; !ok {
+			comparison.OnlyInB = append(comparison.OnlyInB, endpointsB[key].summary(redaction))
+		}
+	}
+
+	sort.SliceStable(comparison.Changed, func(i, j int) bool {
+		deltaI, deltaJ := comparison.Changed[i], comparison.Changed[j]
+		if deltaI.StatusChanged != deltaJ.StatusChanged {
+			return deltaI.StatusChanged
+		}
+		if deltaI.LatencyRegressed != deltaJ.LatencyRegressed {
+			return deltaI.LatencyRegressed
+		}
+		return deltaI.LatencyDeltaMS > deltaJ.LatencyDeltaMS
+	})
+	return comparison
+}
+
+// groupArchiveEndpoints groups the entries of an archive by endpoint, in order of first request
+func groupArchiveEndpoints(harData *har.HAR, index *TimingIndex) (map[string]*archiveEndpoint, []string) {
+	endpoints := make(map[string]*archiveEndpoint)
+	var order []string
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		key := resourceKey(entry)
+		endpoint, ok := endpoints[key]
+		if !ok {
+			endpoint = &archiveEndpoint{method: entry.Request.Method, template: templateURL(entry.Request.URL), requestID: formatRequestID(i)}
+			endpoints[key] = endpoint
+			order = append(order, key)
+		}
+		endpoint.durations = append(endpoint.durations, index.Time(entry))
+		if entry.Response != nil {
+			endpoint.statuses = append(endpoint.statuses, entry.Response.Status)
+			endpoint.size += responseBodySize(entry.Response)
+		} else {
+			endpoint.statuses = append(endpoint.statuses, 0)
+		}
+	}
+	return endpoints, order
+}
+
+// summary describes an endpoint requested in a single archive
+func (e *archiveEndpoint) summary(redaction *redactor) ArchiveEndpoint {
+	return ArchiveEndpoint{Method: e.method, Template: redaction.text(e.template), Requests: len(e.durations), RequestID: e.requestID}
+}
+
+// distinctStatuses returns the distinct statuses in increasing order
+func distinctStatuses(statuses []int) []int {
+	distinct := slices.Clone(statuses)
+	slices.Sort(distinct)
+	return slices.Compact(distinct)
+}
+
+// median returns the median of the values, the mean of the two middle ones for an even count
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// abs returns the absolute value of n
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
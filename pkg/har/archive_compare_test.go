@@ -0,0 +1,83 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareArchives(t *testing.T) {
+	parser := NewParser()
+	before := newTestHAR(
+		newTestFilterEntry("https://example.com/", 200, "text/html", 100),
+		newTestFilterEntry("https://example.com/api/users/1", 200, "application/json", 50),
+		newTestFilterEntry("https://example.com/api/users/2", 200, "application/json", 70),
+		newTestFilterEntry("https://example.com/api/legacy", 200, "application/json", 20),
+		newTestFilterEntry("https://example.com/app.js", 200, "application/javascript", 30),
+	)
+	before.Log.Entries[4].Response.BodySize = 1000
+	after := newTestHAR(
+		newTestFilterEntry("https://example.com/", 200, "text/html", 120),
+		newTestFilterEntry("https://example.com/api/users/7", 500, "application/json", 400),
+		newTestFilterEntry("https://example.com/app.js", 200, "application/javascript", 35),
+		newTestFilterEntry("https://example.com/api/feature-flags", 200, "application/json", 10),
+	)
+	after.Log.Entries[2].Response.BodySize = 1500
+
+	comparison := parser.CompareArchives(before, nil, after, nil, 0)
+
+	assert.Equal(t, 5, comparison.EntriesA)
+	assert.Equal(t, 4, comparison.EntriesB)
+	assert.Equal(t, float64(DefaultLatencyThresholdMS), comparison.LatencyThresholdMS)
+	assert.Equal(t, 3, comparison.Common)
+	assert.Equal(t, 1, comparison.StatusChanges)
+	assert.Equal(t, 1, comparison.SizeChanges)
+	assert.Equal(t, 1, comparison.LatencyRegressions)
+
+	require.Len(t, comparison.Changed, 2)
+	users := comparison.Changed[0]
+	assert.Equal(t, "https://example.com/api/users/{id}", users.Template)
+	assert.Equal(t, []int{200}, users.StatusesA)
+	assert.Equal(t, []int{500}, users.StatusesB)
+	assert.Equal(t, 60.0, users.MedianMSA)
+	assert.Equal(t, 340.0, users.LatencyDeltaMS)
+	assert.True(t, users.LatencyRegressed)
+	assert.Equal(t, "request_1", users.RequestIDA)
+	assert.Equal(t, "request_1", users.RequestIDB)
+
+	script := comparison.Changed[1]
+	assert.Equal(t, int64(500), script.SizeDelta)
+	assert.True(t, script.SizeChanged)
+	assert.False(t, script.StatusChanged)
+
+	require.Len(t, comparison.OnlyInA, 1)
+	assert.Equal(t, "https://example.com/api/legacy", comparison.OnlyInA[0].Template)
+	require.Len(t, comparison.OnlyInB, 1)
+	assert.Equal(t, "https://example.com/api/feature-flags", comparison.OnlyInB[0].Template)
+	assert.Equal(t, "request_3", comparison.OnlyInB[0].RequestID)
+}
+
+func TestCompareArchivesThreshold(t *testing.T) {
+	parser := NewParser()
+	before := newTestHAR(newTestFilterEntry("https://example.com/api", 200, "application/json", 100))
+	after := newTestHAR(newTestFilterEntry("https://example.com/api", 200, "application/json", 150))
+
+	assert.Empty(t, parser.CompareArchives(before, nil, after, nil, 0).Changed)
+	assert.Len(t, parser.CompareArchives(before, nil, after, nil, 25).Changed, 1)
+}
+
+func TestCompareArchivesIgnoresExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	before, err := parser.Exclude(newTestHAR(
+		newTestFilterEntry("https://example.com/", 200, "text/html", 100),
+		newTestFilterEntry("https://tracker.net/pixel", 200, "image/gif", 10),
+	), []string{"request_1"})
+	require.NoError(t, err)
+	after := newTestHAR(newTestFilterEntry("https://example.com/", 200, "text/html", 100))
+
+	comparison := parser.CompareArchives(before, nil, after, nil, 0)
+
+	assert.Equal(t, 1, comparison.EntriesA)
+	assert.Empty(t, comparison.OnlyInA)
+}
@@ -0,0 +1,137 @@
+package har
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// InFlightEntry is a request started but not finished at a given time
+type InFlightEntry struct {
+	RequestID       string  `json:"request_id"`
+	Method          string  `json:"method"`
+	URL             string  `json:"url"`
+	Status          int     `json:"status"`
+	StartedDateTime string  `json:"started_datetime"`
+	DurationMS      float64 `json:"duration_ms"`
+	// ElapsedMS is the time since the request started, RemainingMS the time until it finished
+	ElapsedMS   float64 `json:"elapsed_ms"`
+	RemainingMS float64 `json:"remaining_ms"`
+}
+
+// InFlightReport lists the requests in flight at a point in time
+type InFlightReport struct {
+	At string `json:"at"`
+	// OffsetMS is the time relative to the start of the first request of the capture
+	OffsetMS float64         `json:"offset_ms"`
+	InFlight []InFlightEntry `json:"in_flight"`
+}
+
+// FindAtTime returns the requests in flight at the given time, either an RFC 3339 timestamp
+// or an offset from the start of the capture such as 00:01:23.400, 1:23.4 or 83.4s. A request
+// is in flight from its start time (inclusive) to its start time plus its duration (exclusive),
+// read from the timing index.
+func (p *Parser) FindAtTime(harData *har.HAR, index *TimingIndex, at string) (*InFlightReport, error) {
+	var captureStart time.Time
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.StartedDateTime.IsZero() {
+			continue
+		}
+		if captureStart.IsZero() || entry.StartedDateTime.Before(captureStart) {
+			captureStart = entry.StartedDateTime
+		}
+	}
+
+	instant, err := parseInstant(at, captureStart)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &InFlightReport{
+		At:       p.formatTime(instant, time.RFC3339Nano),
+		OffsetMS: milliseconds(instant.Sub(captureStart)),
+		InFlight: []InFlightEntry{},
+	}
+	redaction := p.redaction()
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		end := index.End(entry)
+		if instant.Before(entry.StartedDateTime) || !instant.Before(end) {
+			continue
+		}
+		inFlight := InFlightEntry{
+			RequestID:       formatRequestID(i),
+			Method:          entry.Request.Method,
+			URL:             redaction.text(entry.Request.URL),
+			StartedDateTime: p.formatTime(entry.StartedDateTime, time.RFC3339Nano),
+			DurationMS:      index.Time(entry),
+			ElapsedMS:       milliseconds(instant.Sub(entry.StartedDateTime)),
+			RemainingMS:     milliseconds(end.Sub(instant)),
+		}
+		if entry.Response != nil {
+			inFlight.Status = entry.Response.Status
+		}
+		report.InFlight = append(report.InFlight, inFlight)
+	}
+
+	sort.SliceStable(report.InFlight, func(i, j int) bool {
+		return report.InFlight[i].ElapsedMS > report.InFlight[j].ElapsedMS
+	})
+
+	return report, nil
+}
+
+// parseInstant parses an RFC 3339 timestamp or an offset from captureStart
+func parseInstant(at string, captureStart time.Time) (time.Time, error) {
+	at = strings.TrimSpace(at)
+	if instant, err := time.Parse(time.RFC3339Nano, at); err == nil {
+		return instant, nil
+	}
+	if captureStart.IsZero() {
+		return time.Time{}, fmt.Errorf("cannot resolve offset %q: the capture has no entries", at)
+	}
+
+	offset, err := parseOffset(at)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return captureStart.Add(offset), nil
+}
+
+// parseOffset parses a clock offset (HH:MM:SS.mmm or MM:SS.mmm) or a Go duration (83.4s)
+func parseOffset(offset string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid time %q: expected an RFC 3339 timestamp, an offset such as 00:01:23.400 or a duration such as 83.4s", offset)
+
+	if !strings.Contains(offset, ":") {
+		duration, err := time.ParseDuration(offset)
+		if err != nil || duration < 0 {
+			return 0, invalid
+		}
+		return duration, nil
+	}
+
+	parts := strings.Split(offset, ":")
+	if len(parts) > 3 {
+		return 0, invalid
+	}
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || seconds < 0 || seconds >= 60 {
+		return 0, invalid
+	}
+	duration := time.Duration(math.Round(seconds * float64(time.Second)))
+	for i, unit := range []time.Duration{time.Minute, time.Hour}[:len(parts)-1] {
+		value, err := strconv.Atoi(parts[len(parts)-2-i])
+		if err != nil || value < 0 {
+			return 0, invalid
+		}
+		duration += time.Duration(value) * unit
+	}
+	return duration, nil
+}
@@ -0,0 +1,89 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAtTimeReturnsRequestsInFlight(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/", 0, 200, 100),
+		newTestAttempt("https://example.com/app.js", 83*time.Second, 200, 1000),
+		newTestAttempt("https://example.com/api", 83300*time.Millisecond, 200, 500),
+		newTestAttempt("https://example.com/done", 83*time.Second, 200, 400),
+		newTestAttempt("https://example.com/later", 83500*time.Millisecond, 200, 100),
+	)
+
+	report, err := parser.FindAtTime(archive, nil, "00:01:23.400")
+	require.NoError(t, err)
+
+	assert.Equal(t, "2023-01-01T00:01:23.4Z", report.At)
+	assert.Equal(t, float64(83400), report.OffsetMS)
+	require.Len(t, report.InFlight, 2)
+	assert.Equal(t, "request_1", report.InFlight[0].RequestID)
+	assert.Equal(t, float64(400), report.InFlight[0].ElapsedMS)
+	assert.Equal(t, float64(600), report.InFlight[0].RemainingMS)
+	assert.Equal(t, "request_2", report.InFlight[1].RequestID)
+	assert.Equal(t, float64(100), report.InFlight[1].ElapsedMS)
+}
+
+func TestFindAtTimeAcceptsTimestampsAndDurations(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/", 0, 200, 100),
+		newTestAttempt("https://example.com/api", 83*time.Second, 200, 1000),
+	)
+
+	for _, at := range []string{"2023-01-01T00:01:23.400Z", "2023-01-01T01:01:23.4+01:00", "1:23.4", "83.4s"} {
+		report, err := parser.FindAtTime(archive, nil, at)
+		require.NoError(t, err, at)
+		assert.Equal(t, float64(83400), report.OffsetMS, at)
+		assert.Len(t, report.InFlight, 1, at)
+	}
+}
+
+func TestFindAtTimeRejectsInvalidTimes(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestAttempt("https://example.com/", 0, 200, 100))
+
+	for _, at := range []string{"noon", "1:2:3:4", "00:00:75", "-5s"} {
+		_, err := parser.FindAtTime(archive, nil, at)
+		assert.Error(t, err, at)
+	}
+
+	_, err := parser.FindAtTime(newTestHAR(), nil, "1s")
+	assert.Error(t, err)
+}
+
+func TestFindAtTimeIgnoresExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	archive, err := parser.Exclude(newTestHAR(
+		newTestAttempt("https://example.com/", 0, 200, 100),
+		newTestAttempt("https://tracker.net/pixel", 60*time.Millisecond, 200, 10),
+		newTestAttempt("https://example.com/api", 500*time.Millisecond, 200, 100),
+	), []string{"request_1"})
+	require.NoError(t, err)
+
+	report, err := parser.FindAtTime(archive, nil, "0.5s")
+	require.NoError(t, err)
+
+	assert.Equal(t, "2023-01-01T00:00:00.5Z", report.At)
+	require.Len(t, report.InFlight, 1)
+	assert.Equal(t, "request_2", report.InFlight[0].RequestID)
+}
+
+func TestFindAtTimeRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(newTestAttempt("https://example.com/api?key=sk_live_abc", 0, 200, 100))
+
+	report, err := parser.FindAtTime(archive, nil, "0.05s")
+	require.NoError(t, err)
+
+	require.Len(t, report.InFlight, 1)
+	assert.Equal(t, "https://example.com/api?key=[REDACTED]", report.InFlight[0].URL)
+}
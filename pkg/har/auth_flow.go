@@ -0,0 +1,295 @@
+package har
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Kinds of the steps of an authentication flow
+const (
+	// AuthStepDiscovery is a request for OpenID Connect or OAuth metadata or signing keys
+	AuthStepDiscovery = "discovery"
+	// AuthStepAuthorize is an OAuth or OpenID Connect authorization request
+	AuthStepAuthorize = "authorize"
+	// AuthStepCallback is the redirect back to the client with the authorization code or error
+	AuthStepCallback = "callback"
+	// AuthStepLogin is the submission of credentials to a login endpoint
+	AuthStepLogin = "login"
+	// AuthStepTokenExchange is a token request other than a refresh
+	AuthStepTokenExchange = "token_exchange"
+	// AuthStepRefresh is a refresh token grant or a request to a refresh endpoint
+	AuthStepRefresh = "refresh"
+	// AuthStepLogout ends the session or revokes a token
+	AuthStepLogout = "logout"
+	// AuthStepSessionCookie is a response of an authentication path setting cookies
+	AuthStepSessionCookie = "session_cookie"
+	// AuthStepCredentialFirstUse is the first request sending a given Authorization header value
+	AuthStepCredentialFirstUse = "credential_first_use"
+)
+
+// maxAuthFlowSteps caps the steps listed by the authentication flow
+const maxAuthFlowSteps = 200
+
+var (
+	// authPathSegments are the path segments of authentication endpoints
+	authPathSegments = []string{
+		"login", "signin", "sign-in", "sign_in", "log-in", "logon", "auth", "authenticate", "authentication",
+		"session", "sessions", "sso", "saml", "oauth", "oauth2", "oidc", "token", "refresh", "callback",
+		"logout", "signout", "sign-out", "log-out",
+	}
+	// logoutPathSegments are the path segments of logout and revocation endpoints
+	logoutPathSegments = []string{"logout", "signout", "sign-out", "log-out", "revoke", "end_session", "endsession"}
+	// loginPathSegments are the path segments of login endpoints
+	loginPathSegments = []string{"login", "signin", "sign-in", "sign_in", "log-in", "logon", "authenticate", "session", "sessions"}
+	// authSecretParameters are the query parameters carrying codes, tokens and secrets, redacted
+	// from the URLs of the steps
+	authSecretParameters = []string{
+		"code", "access_token", "id_token", "refresh_token", "token", "id_token_hint", "client_secret",
+		"code_verifier", "password", "assertion", "samlresponse", "samlrequest",
+	}
+	// issuedTokenFields are the response body fields returning tokens
+	issuedTokenFields = []string{"access_token", "id_token", "refresh_token", "accessToken", "idToken", "refreshToken", "token"}
+)
+
+// IssuedToken is a token returned by a response, identified by its fingerprint only
+type IssuedToken struct {
+	Field       string `json:"field"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// AuthCredential is an Authorization header value sent for the first time
+type AuthCredential struct {
+	Scheme      string `json:"scheme"`
+	Fingerprint string `json:"fingerprint"`
+	// IssuedBy is the request whose response returned the token, when captured
+	IssuedBy string `json:"issued_by,omitempty"`
+}
+
+// AuthFlowStep is a request taking part in the authentication of the session
+type AuthFlowStep struct {
+	RequestID string `json:"request_id"`
+	StartedAt string `json:"started_at"`
+	// OffsetMS is the time from the first step of the flow
+	OffsetMS float64 `json:"offset_ms"`
+	Kind     string  `json:"kind"`
+	Method   string  `json:"method"`
+	URL      string  `json:"url"`
+	Status   int     `json:"status"`
+	// GrantType is the grant of token requests
+	GrantType string `json:"grant_type,omitempty"`
+	// SetCookies lists the names of the cookies the response set
+	SetCookies   []string      `json:"set_cookies,omitempty"`
+	IssuedTokens []IssuedToken `json:"issued_tokens,omitempty"`
+	// Credential is the Authorization header value the request was the first to send
+	Credential *AuthCredential `json:"credential,omitempty"`
+}
+
+// AuthFlow is the authentication sequence of a capture
+type AuthFlow struct {
+	ByKind    map[string]int `json:"by_kind"`
+	Steps     []AuthFlowStep `json:"steps"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+// AnalyzeAuthFlow reconstructs the authentication sequence of a capture: OpenID Connect
+// discovery, authorization requests and their callbacks, credential submissions to login
+// endpoints, token exchanges and refreshes, logouts, cookies set by authentication paths, and
+// the first request sending each Authorization header value, linked to the response that issued
+// the token when captured. Steps are listed in capture order with their timestamps. Tokens and
+// credentials are never exposed, only fingerprints matching those of the token expiry report for
+// JWTs, and the secret parameters of URLs are redacted.
+func (p *Parser) AnalyzeAuthFlow(harData *har.HAR) *AuthFlow {
+	flow := &AuthFlow{ByKind: make(map[string]int), Steps: []AuthFlowStep{}}
+	redaction := p.redaction()
+
+	// issuers maps the fingerprints of the issued tokens to the request issuing them
+	issuers := make(map[string]string)
+	usedCredentials := make(map[string]bool)
+	authorized := false
+	var first time.Time
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		requestID := formatRequestID(i)
+		step := AuthFlowStep{
+			RequestID: requestID,
+			Method:    entry.Request.Method,
+			URL:       redaction.text(redactAuthSecrets(u, redaction)),
+			Status:    responseStatus(entry),
+		}
+		step.Kind, step.GrantType = authStepKind(entry.Request, u, authorized)
+		if step.Kind == AuthStepAuthorize {
+			authorized = true
+		}
+
+		if entry.Response != nil {
+			if step.Kind != "" || isAuthPath(u.Path) {
+				for _, cookie := range responseCookies(entry.Response) {
+					if !slices.Contains(step.SetCookies, cookie.Name) {
+						step.SetCookies = append(step.SetCookies, cookie.Name)
+					}
+				}
+				if step.Kind == "" && len(step.SetCookies) > 0 {
+					step.Kind = AuthStepSessionCookie
+				}
+			}
+			if step.Kind != "" {
+				step.IssuedTokens = issuedTokens(entry.Response)
+				for _, token := range step.IssuedTokens {
+					if _, ok := issuers[token.Fingerprint]; !ok {
+						issuers[token.Fingerprint] = requestID
+					}
+				}
+			}
+		}
+
+		if scheme, credential, ok := strings.Cut(strings.TrimSpace(headerValue(entry.Request.Headers, "Authorization")), " "); ok {
+			fingerprint := tokenFingerprint(strings.TrimSpace(credential))
+			if !usedCredentials[fingerprint] {
+				usedCredentials[fingerprint] = true
+				step.Credential = &AuthCredential{Scheme: scheme, Fingerprint: fingerprint, IssuedBy: issuers[fingerprint]}
+				if step.Kind == "" {
+					step.Kind = AuthStepCredentialFirstUse
+				}
+			}
+		}
+
+		if step.Kind == "" {
+			continue
+		}
+		if len(flow.Steps) == 0 {
+			first = entry.StartedDateTime
+		}
+		step.StartedAt = p.formatTime(entry.StartedDateTime, time.RFC3339Nano)
+		step.OffsetMS = milliseconds(entry.StartedDateTime.Sub(first))
+		flow.ByKind[step.Kind]++
+		if len(flow.Steps) < maxAuthFlowSteps {
+			flow.Steps = append(flow.Steps, step)
+		} else {
+			flow.Truncated = true
+		}
+	}
+	return flow
+}
+
+// authStepKind returns the kind of authentication step of a request, empty when it is none, with
+// the grant type of token requests. Callbacks are only recognized after an authorization request.
+func authStepKind(request *har.Request, u *url.URL, authorized bool) (string, string) {
+	query := u.Query()
+	segments := strings.Split(strings.ToLower(strings.Trim(u.Path, "/")), "/")
+	last := segments[len(segments)-1]
+	post := strings.EqualFold(request.Method, "POST")
+	switch {
+	case strings.Contains(u.Path, "/.well-known/openid-configuration") || strings.Contains(u.Path, "/.well-known/oauth-authorization-server") || last == "jwks" || last == "jwks.json":
+		return AuthStepDiscovery, ""
+	case query.Get("response_type") != "" && query.Get("client_id") != "":
+		return AuthStepAuthorize, ""
+	case isTokenRequest(request):
+		grantType := requestForm(request).Get("grant_type")
+		if grantType == "refresh_token" {
+			return AuthStepRefresh, grantType
+		}
+		return AuthStepTokenExchange, grantType
+	case authorized && (query.Get("code") != "" || query.Get("error") != "") && query.Get("state") != "":
+		return AuthStepCallback, ""
+	case slices.ContainsFunc(segments, func(segment string) bool { return slices.Contains(logoutPathSegments, segment) }):
+		return AuthStepLogout, ""
+	case post && slices.ContainsFunc(segments, func(segment string) bool { return strings.Contains(segment, "refresh") }):
+		return AuthStepRefresh, ""
+	case post && (sendsPassword(request) || slices.ContainsFunc(segments, func(segment string) bool { return slices.Contains(loginPathSegments, segment) })):
+		return AuthStepLogin, ""
+	}
+	return "", ""
+}
+
+// isAuthPath reports whether a URL path has a segment of authentication endpoints
+func isAuthPath(urlPath string) bool {
+	for _, segment := range strings.Split(strings.ToLower(urlPath), "/") {
+		if slices.Contains(authPathSegments, strings.TrimSuffix(segment, path.Ext(segment))) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendsPassword reports whether the form or JSON body of a request has a password field
+func sendsPassword(request *har.Request) bool {
+	for name := range requestForm(request) {
+		if strings.Contains(strings.ToLower(name), "password") {
+			return true
+		}
+	}
+	if request.PostData == nil {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(request.PostData.Text), &fields); err != nil {
+		return false
+	}
+	for name := range fields {
+		if strings.Contains(strings.ToLower(name), "password") {
+			return true
+		}
+	}
+	return false
+}
+
+// issuedTokens returns the tokens the JSON body of a response returns
+func issuedTokens(response *har.Response) []IssuedToken {
+	decoded, _ := decodedResponse(response)
+	if decoded == nil || decoded.Content == nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(decoded.Content.Text, &fields); err != nil {
+		return nil
+	}
+	var tokens []IssuedToken
+	for _, field := range issuedTokenFields {
+		if token, ok := fields[field].(string); ok && token != "" {
+			tokens = append(tokens, IssuedToken{Field: field, Fingerprint: tokenFingerprint(token)})
+		}
+	}
+	return tokens
+}
+
+// tokenFingerprint identifies a token or credential without exposing it, JWTs getting the
+// fingerprint the token expiry report gives them
+func tokenFingerprint(token string) string {
+	if _, ok := decodeJWTClaims(token); ok {
+		return jwtFingerprint(token)
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "tok_" + hex.EncodeToString(sum[:6])
+}
+
+// redactAuthSecrets returns the URL with the values of its secret query parameters redacted,
+// unless redaction is disabled
+func redactAuthSecrets(u *url.URL, redaction *redactor) string {
+	if redaction.policy.Disabled || u.RawQuery == "" {
+		return u.String()
+	}
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil && slices.Contains(authSecretParameters, strings.ToLower(unescaped)) {
+			pairs[i] = name + "=" + redactedValue
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = strings.Join(pairs, "&")
+	return redacted.String()
+}
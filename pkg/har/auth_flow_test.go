@@ -0,0 +1,95 @@
+package har
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAuthEntry returns a request started the given milliseconds after the start of the
+// capture, answered with the status and JSON body
+func newTestAuthEntry(method, url string, offset, status int, body string, headers ...har.Header) *har.Entry {
+	entry := newTestEntry(method, url, headers...)
+	entry.StartedDateTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(offset) * time.Millisecond)
+	entry.Response.Status = status
+	entry.Response.Content.MimeType = "application/json"
+	entry.Response.Content.Text = []byte(body)
+	return entry
+}
+
+// newTestAuthForm sets the form body of a token request
+func newTestAuthForm(entry *har.Entry, form string) *har.Entry {
+	entry.Request.PostData = &har.PostData{MimeType: "application/x-www-form-urlencoded", Text: form}
+	return entry
+}
+
+func TestAnalyzeAuthFlow(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256"}`))
+	accessToken := header + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"42","exp":1672531500}`)) + ".sig"
+	refreshedToken := header + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"42","exp":1672535100}`)) + ".sig"
+
+	login := newTestAuthEntry("POST", "https://app.example.com/api/session", 1500, 200, `{}`)
+	login.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"email":"jane@example.com","password":"hunter2"}`}
+	login.Response.Headers = append(login.Response.Headers, har.Header{Name: "Set-Cookie", Value: "sid=abc; HttpOnly; Secure"})
+
+	harData := newTestHAR(
+		newTestAuthEntry("GET", "https://app.example.com/", 0, 200, ""),
+		newTestAuthEntry("GET", "https://id.example.com/.well-known/openid-configuration", 100, 200, `{}`),
+		newTestAuthEntry("GET", "https://id.example.com/authorize?response_type=code&client_id=app&state=xyz&code_challenge=c", 200, 302, ""),
+		login,
+		newTestAuthEntry("GET", "https://app.example.com/callback?code=secret-code&state=xyz", 2000, 200, ""),
+		newTestAuthForm(newTestAuthEntry("POST", "https://id.example.com/oauth/token", 2100, 200, `{"access_token":"`+accessToken+`","refresh_token":"rt-1","token_type":"Bearer"}`), "grant_type=authorization_code&code=secret-code"),
+		newTestAuthEntry("GET", "https://api.example.com/me", 2200, 200, `{}`, har.Header{Name: "Authorization", Value: "Bearer " + accessToken}),
+		// The same token again is not a new step
+		newTestAuthEntry("GET", "https://api.example.com/orders", 2300, 200, `{}`, har.Header{Name: "Authorization", Value: "Bearer " + accessToken}),
+		newTestAuthForm(newTestAuthEntry("POST", "https://id.example.com/oauth/token", 300000, 200, `{"access_token":"`+refreshedToken+`"}`), "grant_type=refresh_token&refresh_token=rt-1"),
+		newTestAuthEntry("GET", "https://api.example.com/orders", 300100, 200, `{}`, har.Header{Name: "Authorization", Value: "Bearer " + refreshedToken}),
+		newTestAuthEntry("POST", "https://app.example.com/logout", 400000, 204, ""),
+	)
+
+	flow := NewParser().AnalyzeAuthFlow(harData)
+	kinds := make([]string, len(flow.Steps))
+	for i, step := range flow.Steps {
+		kinds[i] = step.Kind
+	}
+	assert.Equal(t, []string{
+		AuthStepDiscovery, AuthStepAuthorize, AuthStepLogin, AuthStepCallback, AuthStepTokenExchange,
+		AuthStepCredentialFirstUse, AuthStepRefresh, AuthStepCredentialFirstUse, AuthStepLogout,
+	}, kinds)
+	assert.Equal(t, 2, flow.ByKind[AuthStepCredentialFirstUse])
+
+	assert.Equal(t, 0.0, flow.Steps[0].OffsetMS)
+	assert.Equal(t, "2023-01-01T00:00:00.1Z", flow.Steps[0].StartedAt)
+	assert.Equal(t, []string{"sid"}, flow.Steps[2].SetCookies)
+	assert.Equal(t, "https://app.example.com/callback?code=[REDACTED]&state=xyz", flow.Steps[3].URL)
+
+	exchange := flow.Steps[4]
+	assert.Equal(t, "authorization_code", exchange.GrantType)
+	require.Len(t, exchange.IssuedTokens, 2)
+	assert.Equal(t, IssuedToken{Field: "access_token", Fingerprint: jwtFingerprint(accessToken)}, exchange.IssuedTokens[0])
+	assert.Equal(t, "refresh_token", exchange.IssuedTokens[1].Field)
+	assert.NotContains(t, exchange.IssuedTokens[1].Fingerprint, "rt-1")
+
+	assert.Equal(t, &AuthCredential{Scheme: "Bearer", Fingerprint: jwtFingerprint(accessToken), IssuedBy: "request_5"}, flow.Steps[5].Credential)
+	assert.Equal(t, "request_9", flow.Steps[7].RequestID)
+	assert.Equal(t, "request_8", flow.Steps[7].Credential.IssuedBy)
+	assert.Equal(t, 300000.0, flow.Steps[7].OffsetMS)
+}
+
+func TestAnalyzeAuthFlowSessionCookie(t *testing.T) {
+	sso := newTestAuthEntry("GET", "https://app.example.com/sso/complete", 0, 302, "")
+	sso.Response.Headers = append(sso.Response.Headers, har.Header{Name: "Set-Cookie", Value: "session=abc"})
+	tracking := newTestAuthEntry("GET", "https://app.example.com/pixel.gif", 10, 200, "")
+	tracking.Response.Headers = append(tracking.Response.Headers, har.Header{Name: "Set-Cookie", Value: "visitor=1"})
+	// A code parameter without prior authorization request is no callback
+	search := newTestAuthEntry("GET", "https://app.example.com/search?code=42&state=CA", 20, 200, "")
+
+	flow := NewParser().AnalyzeAuthFlow(newTestHAR(sso, tracking, search))
+	require.Len(t, flow.Steps, 1)
+	assert.Equal(t, AuthStepSessionCookie, flow.Steps[0].Kind)
+	assert.Equal(t, []string{"session"}, flow.Steps[0].SetCookies)
+}
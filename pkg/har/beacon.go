@@ -0,0 +1,143 @@
+package har
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Kinds of fire-and-forget requests told apart by the beacon index
+const (
+	// BeaconPing is a navigator.sendBeacon call or a hyperlink auditing ping
+	BeaconPing = "ping"
+	// BeaconAnalytics is a request to a known analytics or monitoring collector
+	BeaconAnalytics = "analytics"
+	// BeaconKeepalive is a POST left without response at the end of its page, as fetch keepalive
+	// requests sent at unload often are
+	BeaconKeepalive = "keepalive"
+)
+
+// maxBeaconExamples caps the request IDs listed in a beacon summary
+const maxBeaconExamples = 10
+
+// analyticsHosts are the hosts, or parents of the hosts, of common analytics and monitoring collectors
+var analyticsHosts = []string{
+	"google-analytics.com", "analytics.google.com", "stats.g.doubleclick.net", "segment.io", "segment.com",
+	"mixpanel.com", "amplitude.com", "heapanalytics.com", "plausible.io", "bat.bing.com",
+	"px.ads.linkedin.com", "clarity.ms", "hotjar.com", "hotjar.io", "fullstory.com", "nr-data.net",
+	"browser-intake-datadoghq.com", "browser-intake-datadoghq.eu", "posthog.com", "matomo.cloud",
+}
+
+// analyticsPaths are the last path segments of common collector endpoints
+var analyticsPaths = []string{"collect", "beacon", "rum", "tr"}
+
+// BeaconIndex tells which entries are beacons, analytics hits and keepalive requests: requests
+// the page does not wait for, often cut short at unload, which would otherwise inflate error rates
+type BeaconIndex struct {
+	kinds map[*har.Entry]string
+}
+
+// Kind returns the beacon kind of the entry, empty when it is a regular request
+func (idx *BeaconIndex) Kind(entry *har.Entry) string {
+	if idx == nil {
+		return ""
+	}
+	return idx.kinds[entry]
+}
+
+// BeaconSummary counts the beacons left out of error rates
+type BeaconSummary struct {
+	Requests int `json:"requests"`
+	// Failed counts the beacons without response or answered with a 4xx or 5xx status
+	Failed int            `json:"failed"`
+	ByKind map[string]int `json:"by_kind"`
+	// RequestIDs lists the first beacons
+	RequestIDs []string `json:"request_ids"`
+}
+
+// add records a beacon of the given kind
+func (s *BeaconSummary) add(kind string, entry *har.Entry, requestID string) {
+	s.Requests++
+	s.ByKind[kind]++
+	if status := responseStatus(entry); status == 0 || status >= 400 {
+		s.Failed++
+	}
+	if len(s.RequestIDs) < maxBeaconExamples {
+		s.RequestIDs = append(s.RequestIDs, requestID)
+	}
+}
+
+// newBeaconSummary creates an empty beacon summary
+func newBeaconSummary() *BeaconSummary {
+	return &BeaconSummary{ByKind: make(map[string]int), RequestIDs: []string{}}
+}
+
+// FindBeacons classifies the fire-and-forget requests of the capture: pings, recognized by the
+// ping resource type Chrome records for sendBeacon, a text/ping body or hyperlink auditing
+// headers, hits to known analytics collectors, and keepalive requests, POSTs left without
+// response that no answered request of their page started after. Resource types and page
+// references are read from the indexes, which may be nil.
+func (p *Parser) FindBeacons(harData *har.HAR, fields *CustomFieldIndex, pages *PageIndex) *BeaconIndex {
+	index := &BeaconIndex{kinds: make(map[*har.Entry]string)}
+	// lastAnswered is the start of the last answered request of each page
+	lastAnswered := make(map[string]time.Time)
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || responseStatus(entry) == 0 {
+			continue
+		}
+		page := pages.PageRef(entry)
+		if entry.StartedDateTime.After(lastAnswered[page]) {
+			lastAnswered[page] = entry.StartedDateTime
+		}
+	}
+
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		switch {
+		case isPing(entry, fields):
+			index.kinds[entry] = BeaconPing
+		case isAnalytics(entry.Request.URL):
+			index.kinds[entry] = BeaconAnalytics
+		case entry.Request.Method == "POST" && responseStatus(entry) == 0 && !entry.StartedDateTime.Before(lastAnswered[pages.PageRef(entry)]):
+			index.kinds[entry] = BeaconKeepalive
+		}
+	}
+	return index
+}
+
+// isPing reports whether the entry is a sendBeacon call or a hyperlink auditing ping
+func isPing(entry *har.Entry, fields *CustomFieldIndex) bool {
+	if strings.EqualFold(fields.ResourceType(entry), "ping") {
+		return true
+	}
+	if headerValue(entry.Request.Headers, "Ping-From") != "" || headerValue(entry.Request.Headers, "Ping-To") != "" {
+		return true
+	}
+	return entry.Request.PostData != nil && strings.HasPrefix(strings.ToLower(entry.Request.PostData.MimeType), "text/ping")
+}
+
+// isAnalytics reports whether the URL is the endpoint of a known analytics or monitoring collector
+func isAnalytics(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, analytics := range analyticsHosts {
+		if host == analytics || strings.HasSuffix(host, "."+analytics) {
+			return true
+		}
+	}
+	last := path.Base(u.Path)
+	for _, analytics := range analyticsPaths {
+		if last == analytics {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBeaconHAR returns a page load ending with a failed API call and beacons cut short at
+// unload: a ping, an analytics hit and a keepalive POST
+func newTestBeaconHAR() *har.HAR {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := newTestEntry("GET", "https://example.com/")
+	page.StartedDateTime = start
+	failed := newTestEntry("GET", "https://example.com/api/cart")
+	failed.StartedDateTime = start.Add(time.Second)
+	failed.Response.Status = 500
+	// A POST abandoned before later requests were answered is not sent at unload
+	abandoned := newTestEntry("POST", "https://example.com/api/upload")
+	abandoned.StartedDateTime = start.Add(time.Second)
+	abandoned.Response.Status = 0
+	ping := newTestEntry("POST", "https://example.com/audit", har.Header{Name: "Ping-From", Value: "https://example.com/"})
+	ping.StartedDateTime = start.Add(2 * time.Second)
+	analytics := newTestEntry("GET", "https://www.google-analytics.com/g/collect?v=2")
+	analytics.StartedDateTime = start.Add(2 * time.Second)
+	analytics.Response.Status = 204
+	keepalive := newTestEntry("POST", "https://example.com/api/session/end")
+	keepalive.StartedDateTime = start.Add(3 * time.Second)
+	keepalive.Response.Status = 0
+	return newTestHAR(page, failed, abandoned, ping, analytics, keepalive)
+}
+
+func TestFindBeacons(t *testing.T) {
+	harData := newTestBeaconHAR()
+	beacons := NewParser().FindBeacons(harData, nil, nil)
+
+	kinds := make([]string, len(harData.Log.Entries))
+	for i, entry := range harData.Log.Entries {
+		kinds[i] = beacons.Kind(entry)
+	}
+	assert.Equal(t, []string{"", "", "", BeaconPing, BeaconAnalytics, BeaconKeepalive}, kinds)
+}
+
+func TestGetErrorSummaryLeavesBeaconsOut(t *testing.T) {
+	parser := NewParser()
+	harData := newTestBeaconHAR()
+
+	summary := parser.GetErrorSummary(harData, parser.FindBeacons(harData, nil, nil))
+	assert.Equal(t, 3, summary.Entries)
+	assert.Equal(t, 2, summary.Failed)
+	require.NotNil(t, summary.Beacons)
+	assert.Equal(t, &BeaconSummary{
+		Requests:   3,
+		Failed:     1,
+		ByKind:     map[string]int{BeaconPing: 1, BeaconAnalytics: 1, BeaconKeepalive: 1},
+		RequestIDs: []string{"request_3", "request_4", "request_5"},
+	}, summary.Beacons)
+
+	summary = parser.GetErrorSummary(harData, nil)
+	assert.Equal(t, 6, summary.Entries)
+	assert.Equal(t, 3, summary.Failed)
+	assert.Nil(t, summary.Beacons)
+}
+
+func TestGetPerformanceReportLeavesBeaconsOut(t *testing.T) {
+	parser := NewParser()
+	harData := newTestBeaconHAR()
+
+	report, err := parser.GetPerformanceReport(harData, nil, parser.FindBeacons(harData, nil, nil), "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Requests)
+	require.Len(t, report.Hosts, 1)
+	assert.Equal(t, 2, report.Hosts[0].Errors)
+	assert.Equal(t, 3, report.Beacons.Requests)
+}
@@ -0,0 +1,101 @@
+package har
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tjamet/har-mcp/pkg/har/hartest"
+)
+
+// benchmarkSizes are the entry counts of the generated captures
+var benchmarkSizes = []int{100, 1000, 10000}
+
+func TestGeneratedFixturesExerciseEveryParseMode(t *testing.T) {
+	expected := map[hartest.Style]string{
+		hartest.Strict:    ParseModeStrict,
+		hartest.Flexible:  ParseModeFlexible,
+		hartest.Malformed: ParseModeRecovered,
+	}
+
+	parser := NewParser()
+	parser.SetLenient(true)
+	for _, style := range hartest.Styles {
+		harData, report, err := parser.ParseWithReport(bytes.NewReader(hartest.Generate(25, style)))
+		require.NoError(t, err, style)
+		assert.Equal(t, expected[style], report.Mode, style)
+		assert.Len(t, harData.Log.Entries, 25, style)
+	}
+}
+
+func BenchmarkParseStrict(b *testing.B) {
+	benchmarkParse(b, hartest.Strict)
+}
+
+func BenchmarkParseFlexible(b *testing.B) {
+	benchmarkParse(b, hartest.Flexible)
+}
+
+func BenchmarkParseRecovered(b *testing.B) {
+	benchmarkParse(b, hartest.Malformed)
+}
+
+// benchmarkParse measures parsing generated captures of the given style at every benchmark size
+func benchmarkParse(b *testing.B, style hartest.Style) {
+	parser := NewParser()
+	parser.SetLenient(true)
+	for _, size := range benchmarkSizes {
+		data := hartest.Generate(size, style)
+		b.Run(fmt.Sprintf("entries=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := parser.ParseWithReport(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// lookupBenchmarkSize is the entry count of the capture the lookups are measured on
+const lookupBenchmarkSize = 50000
+
+// BenchmarkLookup compares the lookups of the tools selecting entries with and without the
+// lookup index of the parse report
+func BenchmarkLookup(b *testing.B) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(bytes.NewReader(hartest.Generate(lookupBenchmarkSize, hartest.Strict)))
+	require.NoError(b, err)
+	target := harData.Log.Entries[lookupBenchmarkSize/2].Request.URL
+	filter := EntryFilter{Host: "www.example.com", Status: "200"}
+
+	for _, indexed := range []bool{false, true} {
+		var index *LookupIndex
+		if indexed {
+			index = report.LookupIndex()
+		}
+		b.Run(fmt.Sprintf("request_ids/indexed=%t", indexed), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if ids := parser.GetRequestIDsForURLMethod(harData, index, target, "GET"); len(ids) != 1 {
+					b.Fatalf("expected a single request, found %d", len(ids))
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("filter/indexed=%t", indexed), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.FilterEntries(harData, report.TimingIndex(), index, nil, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	b.Run("build", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewLookupIndex(harData)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package har
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/google/martian/har"
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxDecodedBodySize caps the size of a decompressed body, protecting against compression bombs
+const maxDecodedBodySize = 64 * 1024 * 1024
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// windows1252 maps the bytes 0x80 to 0x9f of Windows-1252 to their runes, the other bytes
+// mapping to the same code point as in ISO-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// DecodedBody is a body decoded to UTF-8 text
+type DecodedBody struct {
+	Text []byte
+	// ContentEncodings are the content codings removed, in the order they were removed
+	ContentEncodings []string
+	// Charset is the character set the body was converted from
+	Charset string
+	// Error tells why the body could not be fully decoded, the body being kept as far as it was decoded
+	Error string
+}
+
+// DecodeBody decodes a body to readable text. Browsers usually record decoded bodies while
+// keeping the Content-Encoding header, other tools store the compressed bytes, base64 encoded.
+// The gzip, deflate and zstd content codings of the Content-Encoding headers are removed from
+// bodies still compressed, and bodies in another character set than UTF-8, declared by the
+// charset parameter of the MIME type or by a byte order mark, are converted. Brotli is not
+// supported. Already decoded bodies are returned as is, without their UTF-8 byte order mark.
+func DecodeBody(body []byte, headers []har.Header, mimeType string) DecodedBody {
+	decoded := DecodedBody{Text: body}
+	codings := contentCodings(headers)
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := codings[i]
+		if !stillEncoded(decoded.Text, coding) {
+			break
+		}
+		text, err := decodeContentCoding(decoded.Text, coding)
+		if err != nil {
+			decoded.Error = fmt.Sprintf("failed to decode %s content: %v", coding, err)
+			return decoded
+		}
+		decoded.Text = text
+		decoded.ContentEncodings = append(decoded.ContentEncodings, coding)
+	}
+
+	if mimeType == "" {
+		mimeType = headerValue(headers, "Content-Type")
+	}
+	text, charset, err := decodeCharset(decoded.Text, mimeType)
+	if err != nil {
+		decoded.Error = err.Error()
+		return decoded
+	}
+	decoded.Text = text
+	decoded.Charset = charset
+	return decoded
+}
+
+// contentCodings returns the content codings of the Content-Encoding headers in the order they
+// were applied, leaving out identity
+func contentCodings(headers []har.Header) []string {
+	var codings []string
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, "Content-Encoding") {
+			continue
+		}
+		for _, coding := range strings.Split(header.Value, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	return codings
+}
+
+// stillEncoded reports whether a body is still encoded with the content coding, sniffing the
+// magic bytes of the formats that have some
+func stillEncoded(body []byte, coding string) bool {
+	switch coding {
+	case "gzip", "x-gzip":
+		return bytes.HasPrefix(body, gzipMagic)
+	case "zstd":
+		return bytes.HasPrefix(body, zstdMagic)
+	}
+	return len(body) > 0 && !isPrintableText(body)
+}
+
+// decodeContentCoding removes a content coding from a body
+func decodeContentCoding(body []byte, coding string) ([]byte, error) {
+	var reader io.Reader
+	switch coding {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close() //nolint:errcheck
+		reader = gzipReader
+	case "deflate":
+		// Servers send either zlib streams, as specified, or raw deflate streams
+		zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return readDecoded(flate.NewReader(bytes.NewReader(body)))
+		}
+		defer zlibReader.Close() //nolint:errcheck
+		reader = zlibReader
+	case "zstd":
+		decoder, err := zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	case "br":
+		return nil, fmt.Errorf("brotli is not supported")
+	default:
+		return nil, fmt.Errorf("unknown content coding")
+	}
+	return readDecoded(reader)
+}
+
+// readDecoded reads a decompressed body, failing beyond maxDecodedBodySize
+func readDecoded(reader io.Reader) ([]byte, error) {
+	text, err := io.ReadAll(io.LimitReader(reader, maxDecodedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(text) > maxDecodedBodySize {
+		return nil, fmt.Errorf("decoded content exceeds %d bytes", maxDecodedBodySize)
+	}
+	return text, nil
+}
+
+// decodeCharset converts a body to UTF-8, returning the character set converted from.
+// Bodies already valid UTF-8 are kept, as browsers record bodies converted to UTF-8.
+func decodeCharset(body []byte, mimeType string) ([]byte, string, error) {
+	charset := ""
+	if _, params, err := mime.ParseMediaType(mimeType); err == nil {
+		charset = strings.ToLower(strings.TrimSpace(params["charset"]))
+	}
+	switch {
+	case bytes.HasPrefix(body, utf16LEBOM):
+		return decodeUTF16(body[len(utf16LEBOM):], false), "utf-16le", nil
+	case bytes.HasPrefix(body, utf16BEBOM):
+		return decodeUTF16(body[len(utf16BEBOM):], true), "utf-16be", nil
+	case utf8.Valid(body):
+		return bytes.TrimPrefix(body, utf8BOM), "", nil
+	}
+
+	switch charset {
+	case "", "utf-8", "utf8":
+		return body, "", nil
+	case "iso-8859-1", "iso8859-1", "latin1", "l1", "us-ascii", "ascii", "windows-1252", "cp1252", "x-cp1252":
+		// WHATWG decodes Latin-1 and ASCII labels as Windows-1252
+		return decodeWindows1252(body), charset, nil
+	case "utf-16le":
+		return decodeUTF16(body, false), charset, nil
+	case "utf-16", "utf-16be":
+		return decodeUTF16(body, true), charset, nil
+	}
+	return body, "", fmt.Errorf("charset %s is not supported", charset)
+}
+
+// decodeWindows1252 converts a Windows-1252 body to UTF-8
+func decodeWindows1252(body []byte) []byte {
+	text := make([]byte, 0, len(body)+len(body)/2)
+	for _, b := range body {
+		r := rune(b)
+		if b >= 0x80 && b < 0xa0 {
+			r = windows1252[b-0x80]
+		}
+		text = utf8.AppendRune(text, r)
+	}
+	return text
+}
+
+// decodeUTF16 converts a UTF-16 body without byte order mark to UTF-8
+func decodeUTF16(body []byte, bigEndian bool) []byte {
+	units := make([]uint16, 0, len(body)/2)
+	for i := 0; i+1 < len(body); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(body[i])<<8|uint16(body[i+1]))
+		} else {
+			units = append(units, uint16(body[i+1])<<8|uint16(body[i]))
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// decodedResponse returns a copy of the response whose content is decoded by DecodeBody, along
+// with the decoding applied
+func decodedResponse(response *har.Response) (*har.Response, DecodedBody) {
+	if response.Content == nil || len(response.Content.Text) == 0 {
+		return response, DecodedBody{}
+	}
+	decoded := DecodeBody(response.Content.Text, response.Headers, response.Content.MimeType)
+	copied := *response
+	content := *response.Content
+	content.Text = decoded.Text
+	copied.Content = &content
+	return &copied, decoded
+}
+
+// newResponseInfo converts a redacted response whose content was decoded to a ResponseInfo
+func newResponseInfo(response *har.Response, decoded DecodedBody) *ResponseInfo {
+	info := &ResponseInfo{
+		Status:      response.Status,
+		StatusText:  response.StatusText,
+		HTTPVersion: response.HTTPVersion,
+		Cookies:     response.Cookies,
+		Headers:     response.Headers,
+		RedirectURL: response.RedirectURL,
+		HeadersSize: response.HeadersSize,
+		BodySize:    response.BodySize,
+	}
+	if response.Content == nil {
+		return info
+	}
+	info.Content = &ContentInfo{
+		Size:                    response.Content.Size,
+		MimeType:                response.Content.MimeType,
+		DecodedContentEncodings: decoded.ContentEncodings,
+		DecodedCharset:          decoded.Charset,
+		DecodeError:             decoded.Error,
+	}
+	if text := response.Content.Text; utf8.Valid(text) {
+		info.Content.Text = string(text)
+	} else {
+		info.Content.Text = base64.StdEncoding.EncodeToString(text)
+		info.Content.Encoding = "base64"
+	}
+	return info
+}
@@ -0,0 +1,128 @@
+package har
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDecodedBody is the body the decoding tests encode
+const testDecodedBody = `{"user": "alice"}`
+
+// assertDecodesBody checks that a body sent with the given Content-Encoding decodes to testDecodedBody
+// after removing the given codings, outermost first
+func assertDecodesBody(t *testing.T, body []byte, encoding string, codings []string) {
+	t.Helper()
+	decoded := DecodeBody(body, []har.Header{{Name: "Content-Encoding", Value: encoding}}, "application/json")
+	assert.Empty(t, decoded.Error)
+	assert.Equal(t, testDecodedBody, string(decoded.Text))
+	assert.Equal(t, codings, decoded.ContentEncodings)
+}
+
+// zlibData compresses data in the zlib format of the deflate coding
+func zlibData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeBodyGzip(t *testing.T) {
+	assertDecodesBody(t, gzipData(t, []byte(testDecodedBody)), "gzip", []string{"gzip"})
+}
+
+func TestDecodeBodyZlibDeflate(t *testing.T) {
+	assertDecodesBody(t, zlibData(t, []byte(testDecodedBody)), "deflate", []string{"deflate"})
+}
+
+func TestDecodeBodyRawDeflate(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte(testDecodedBody))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assertDecodesBody(t, buf.Bytes(), "deflate", []string{"deflate"})
+}
+
+func TestDecodeBodyZstd(t *testing.T) {
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	body := encoder.EncodeAll([]byte(testDecodedBody), nil)
+	require.NoError(t, encoder.Close())
+
+	assertDecodesBody(t, body, "zstd", []string{"zstd"})
+}
+
+func TestDecodeBodyStackedCodings(t *testing.T) {
+	assertDecodesBody(t, gzipData(t, zlibData(t, []byte(testDecodedBody))), "deflate, gzip", []string{"gzip", "deflate"})
+}
+
+func TestDecodeBodyAlreadyDecodedByTheBrowser(t *testing.T) {
+	assertDecodesBody(t, []byte(testDecodedBody), "gzip", nil)
+}
+
+func TestDecodeBodyReportsUnsupportedCodings(t *testing.T) {
+	compressed := []byte{0x1b, 0x0b, 0x00, 0xf8, 0x05}
+	decoded := DecodeBody(compressed, []har.Header{{Name: "Content-Encoding", Value: "br"}}, "text/plain")
+
+	assert.Equal(t, compressed, decoded.Text)
+	assert.Contains(t, decoded.Error, "brotli is not supported")
+}
+
+// assertDecodesCharset checks that a body of the given MIME type decodes to "café €" from the given charset
+func assertDecodesCharset(t *testing.T, body, mimeType, charset string) {
+	t.Helper()
+	decoded := DecodeBody([]byte(body), nil, mimeType)
+	assert.Empty(t, decoded.Error)
+	assert.Equal(t, "café €", string(decoded.Text))
+	assert.Equal(t, charset, decoded.Charset)
+}
+
+func TestDecodeBodyLatin1(t *testing.T) {
+	assertDecodesCharset(t, "caf\xe9 \x80", "text/plain; charset=ISO-8859-1", "iso-8859-1")
+}
+
+func TestDecodeBodyUTF16WithByteOrderMark(t *testing.T) {
+	assertDecodesCharset(t, "\xff\xfec\x00a\x00f\x00\xe9\x00 \x00\xac\x20", "text/plain", "utf-16le")
+}
+
+func TestDecodeBodyUTF16BigEndian(t *testing.T) {
+	assertDecodesCharset(t, "\x00c\x00a\x00f\x00\xe9\x00 \x20\xac", "text/plain; charset=utf-16be", "utf-16be")
+}
+
+func TestDecodeBodyUTF8WithByteOrderMark(t *testing.T) {
+	assertDecodesCharset(t, "\xef\xbb\xbfcafé €", "text/plain", "")
+}
+
+func TestDecodeBodyUTF8RecordedForLatin1Response(t *testing.T) {
+	assertDecodesCharset(t, "café €", "text/plain; charset=iso-8859-1", "")
+}
+
+func TestGetRequestDetailsDecodesCompressedBodies(t *testing.T) {
+	parser := NewParser()
+	entry := newTestResponseEntry("https://example.com/api/users", "application/json", "")
+	entry.Response.Headers = append(entry.Response.Headers, har.Header{Name: "Content-Encoding", Value: "gzip"})
+	entry.Response.Content.Text = gzipData(t, []byte(`{"user": "alice"}`))
+	image := newTestResponseEntry("https://example.com/logo.png", "image/png", "\x89PNG\r\n\x1a\n\x00")
+
+	details, err := parser.GetRequestDetails(newTestHAR(entry, image), nil, nil, "request_0")
+	require.NoError(t, err)
+	assert.Equal(t, `{"user": "alice"}`, details.Response.Content.Text)
+	assert.Equal(t, []string{"gzip"}, details.Response.Content.DecodedContentEncodings)
+	assert.Empty(t, details.Response.Content.Encoding)
+
+	details, err = parser.GetRequestDetails(newTestHAR(entry, image), nil, nil, "request_1")
+	require.NoError(t, err)
+	assert.Equal(t, "base64", details.Response.Content.Encoding)
+	assert.Equal(t, "iVBORw0KGgoA", details.Response.Content.Text)
+}
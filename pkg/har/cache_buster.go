@@ -0,0 +1,220 @@
+package har
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// maxCacheBusterExamples caps the number of example values and request IDs per parameter
+const maxCacheBusterExamples = 3
+
+// cacheBusterNames are query parameter names conventionally used to defeat caches
+var cacheBusterNames = map[string]bool{
+	"_":            true,
+	"cb":           true,
+	"cachebuster":  true,
+	"cache_buster": true,
+	"cachebust":    true,
+	"nocache":      true,
+	"no_cache":     true,
+	"rnd":          true,
+	"rand":         true,
+	"random":       true,
+	"ts":           true,
+	"timestamp":    true,
+}
+
+var (
+	// unixTimestampPattern matches Unix timestamps in seconds or milliseconds
+	unixTimestampPattern = regexp.MustCompile(`^1[0-9]{9}([0-9]{3})?$`)
+	uuidPattern          = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	randomTokenPattern   = regexp.MustCompile(`^(0\.[0-9]{8,}|[0-9a-fA-F]{16,})$`)
+)
+
+// staticAssetFamilies are the content families of resources expected to be served from cache
+var staticAssetFamilies = map[string]bool{
+	familyJavaScript: true,
+	familyCSS:        true,
+	familyPNG:        true,
+	familyJPEG:       true,
+	familyGIF:        true,
+	familyWebP:       true,
+	familySVG:        true,
+	familyWOFF:       true,
+	familyWOFF2:      true,
+	familyWASM:       true,
+}
+
+// CacheBusterParam describes a query parameter that makes otherwise identical URLs unique
+type CacheBusterParam struct {
+	Endpoint          string   `json:"endpoint"`
+	Name              string   `json:"name"`
+	Requests          int      `json:"requests"`
+	DistinctValues    int      `json:"distinct_values"`
+	CacheableRequests int      `json:"cacheable_requests"`
+	Reasons           []string `json:"reasons"`
+	ExampleValues     []string `json:"example_values"`
+	RequestIDs        []string `json:"example_request_ids"`
+}
+
+// CacheBusterReport lists cache-busting query parameters found in the HAR
+type CacheBusterReport struct {
+	TotalRequests int `json:"total_requests"`
+	// CacheableDefeated counts cacheable requests carrying at least one cache buster
+	CacheableDefeated int                `json:"cacheable_requests_defeated"`
+	Parameters        []CacheBusterParam `json:"parameters"`
+}
+
+// cacheBusterCandidate accumulates the values of one parameter on one endpoint
+type cacheBusterCandidate struct {
+	param          CacheBusterParam
+	values         map[string]bool
+	looksRandom    int
+	cacheableIndex []int
+}
+
+// GetCacheBusters detects query parameters such as _=1699999, cb= or random UUIDs
+// whose value changes on every request to the same endpoint, and counts how many
+// cacheable requests they defeated.
+func (p *Parser) GetCacheBusters(harData *har.HAR) *CacheBusterReport {
+	report := &CacheBusterReport{Parameters: []CacheBusterParam{}}
+	candidates := make(map[string]*cacheBusterCandidate)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil || entry.Request.Method != "GET" {
+			continue
+		}
+		report.TotalRequests++
+
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.RawQuery == "" {
+			continue
+		}
+		endpoint := fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, u.Path)
+		cacheable := isCacheable(entry)
+
+		query := u.Query()
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			values := query[name]
+			key := endpoint + "?" + name
+			candidate, ok := candidates[key]
+			if !ok {
+				candidate = &cacheBusterCandidate{
+					param:  CacheBusterParam{Endpoint: endpoint, Name: name},
+					values: make(map[string]bool),
+				}
+				candidates[key] = candidate
+				order = append(order, key)
+			}
+			for _, value := range values {
+				candidate.param.Requests++
+				if !candidate.values[value] && len(candidate.param.ExampleValues) < maxCacheBusterExamples {
+					candidate.param.ExampleValues = append(candidate.param.ExampleValues, value)
+				}
+				candidate.values[value] = true
+				if looksRandom(value) {
+					candidate.looksRandom++
+				}
+			}
+			if len(candidate.param.RequestIDs) < maxCacheBusterExamples {
+				candidate.param.RequestIDs = append(candidate.param.RequestIDs, formatRequestID(i))
+			}
+			if cacheable {
+				candidate.cacheableIndex = append(candidate.cacheableIndex, i)
+			}
+		}
+	}
+
+	defeated := make(map[int]bool)
+	for _, key := range order {
+		candidate := candidates[key]
+		candidate.param.DistinctValues = len(candidate.values)
+		candidate.param.Reasons = cacheBusterReasons(candidate)
+		if len(candidate.param.Reasons) == 0 {
+			continue
+		}
+		candidate.param.CacheableRequests = len(candidate.cacheableIndex)
+		for _, index := range candidate.cacheableIndex {
+			defeated[index] = true
+		}
+		report.Parameters = append(report.Parameters, candidate.param)
+	}
+	report.CacheableDefeated = len(defeated)
+
+	sort.SliceStable(report.Parameters, func(i, j int) bool {
+		return report.Parameters[i].CacheableRequests > report.Parameters[j].CacheableRequests
+	})
+
+	return report
+}
+
+// cacheBusterReasons explains why a parameter is considered a cache buster, if it is one
+func cacheBusterReasons(candidate *cacheBusterCandidate) []string {
+	param := candidate.param
+	unique := param.Requests > 1 && param.DistinctValues == param.Requests
+	// A conventional name or random-looking value that repeats is a version pin, not a buster
+	if param.DistinctValues < param.Requests {
+		return nil
+	}
+
+	var reasons []string
+	if cacheBusterNames[strings.ToLower(param.Name)] {
+		reasons = append(reasons, "conventional cache-busting parameter name")
+	}
+	if candidate.looksRandom == param.Requests {
+		reasons = append(reasons, "values look like timestamps or random tokens")
+	}
+	// Varying values alone are only suspicious on resources that should come from cache
+	if len(reasons) == 0 && !(unique && len(candidate.cacheableIndex) == param.Requests) {
+		return nil
+	}
+	if unique {
+		reasons = append(reasons, fmt.Sprintf("value is unique on each of the %d requests", param.Requests))
+	}
+	return reasons
+}
+
+// looksRandom reports whether a query value looks like a timestamp, a UUID or a random token
+func looksRandom(value string) bool {
+	return unixTimestampPattern.MatchString(value) || uuidPattern.MatchString(value) || randomTokenPattern.MatchString(value)
+}
+
+// isCacheable reports whether the response could have been served from a cache
+func isCacheable(entry *har.Entry) bool {
+	if entry.Request == nil || entry.Response == nil || entry.Response.Status != 200 {
+		return false
+	}
+
+	for _, header := range entry.Response.Headers {
+		if !strings.EqualFold(header.Name, "Cache-Control") {
+			continue
+		}
+		directives := strings.ToLower(header.Value)
+		if strings.Contains(directives, "no-store") || strings.Contains(directives, "private") {
+			return false
+		}
+		if strings.Contains(directives, "max-age") || strings.Contains(directives, "public") || strings.Contains(directives, "immutable") {
+			return true
+		}
+	}
+
+	for _, header := range entry.Response.Headers {
+		if strings.EqualFold(header.Name, "ETag") || strings.EqualFold(header.Name, "Last-Modified") {
+			return true
+		}
+	}
+
+	return staticAssetFamilies[extensionFamily(entry.Request.URL)] || staticAssetFamilies[mimeTypeFamily(responseMimeType(entry.Response))]
+}
@@ -0,0 +1,67 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCacheBustersTimestamp(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://cdn.example.com/app.js?_=1699999000001"),
+		newTestEntry("GET", "https://cdn.example.com/app.js?_=1699999000002"),
+		newTestEntry("GET", "https://cdn.example.com/app.js?_=1699999000003"),
+	)
+
+	report := parser.GetCacheBusters(archive)
+
+	require.Len(t, report.Parameters, 1)
+	param := report.Parameters[0]
+	assert.Equal(t, "https://cdn.example.com/app.js", param.Endpoint)
+	assert.Equal(t, "_", param.Name)
+	assert.Equal(t, 3, param.Requests)
+	assert.Equal(t, 3, param.DistinctValues)
+	assert.Equal(t, 3, param.CacheableRequests)
+	assert.Equal(t, 3, report.CacheableDefeated)
+	assert.Len(t, param.Reasons, 3)
+}
+
+func TestGetCacheBustersIgnoresVersionPins(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://cdn.example.com/app.js?v=0123456789abcdef"),
+		newTestEntry("GET", "https://cdn.example.com/app.js?v=0123456789abcdef"),
+	)
+
+	report := parser.GetCacheBusters(archive)
+
+	assert.Empty(t, report.Parameters)
+	assert.Equal(t, 0, report.CacheableDefeated)
+}
+
+func TestGetCacheBustersIgnoresPaginationOnAPIs(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/api/items?page=1"),
+		newTestEntry("GET", "https://example.com/api/items?page=2"),
+	)
+
+	report := parser.GetCacheBusters(archive)
+
+	assert.Empty(t, report.Parameters)
+}
+
+func TestGetCacheBustersRandomUUID(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/api/config?id=6fa459ea-ee8a-3ca4-894e-db77e160355e"),
+	)
+
+	report := parser.GetCacheBusters(archive)
+
+	require.Len(t, report.Parameters, 1)
+	assert.Equal(t, []string{"values look like timestamps or random tokens"}, report.Parameters[0].Reasons)
+	assert.Equal(t, 0, report.Parameters[0].CacheableRequests)
+}
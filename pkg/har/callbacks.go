@@ -0,0 +1,250 @@
+package har
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+var (
+	// signedParameterPattern matches the names of parameters carrying a signature
+	signedParameterPattern = regexp.MustCompile(`(?i)(signature|hmac|checksum|digest|^sig$|^mac$|^hash$|[_.-](sig|mac|hash)$)`)
+	// returnParameterPattern matches the names of parameters telling a third party where to send the user back
+	returnParameterPattern = regexp.MustCompile(`(?i)^(return|success|cancel|failure|callback|notify|redirect)[_-]?(ur[il]|to)?$`)
+	// callbackParameters are parameters payment and identity providers send back to the merchant
+	callbackParameters = []string{
+		"payment_intent", "payment_intent_client_secret", "redirect_status", "session_id",
+		"paymentid", "payerid", "token", "transaction_id", "order_id", "status",
+		"pares", "md", "cres", "threedssessiondata",
+	}
+)
+
+// CallbackRoundTrip is a request back to the first party completing a detour through a third party,
+// such as a payment provider redirecting to the merchant after checkout
+type CallbackRoundTrip struct {
+	Provider string `json:"provider"`
+	// OutboundRequestID is the first request of the detour to the provider
+	OutboundRequestID string `json:"outbound_request_id,omitempty"`
+	CallbackRequestID string `json:"callback_request_id"`
+	Method            string `json:"method"`
+	URL               string `json:"url"`
+	Status            int    `json:"status"`
+	// ElapsedMS is the time spent between the outbound request and the callback
+	ElapsedMS int64 `json:"elapsed_ms"`
+	// Parameters are the names of the query and form parameters of the callback, values are never exposed
+	Parameters       []string `json:"parameters"`
+	SignedParameters []string `json:"signed_parameters,omitempty"`
+	Evidence         []string `json:"evidence"`
+}
+
+// CallbackReport lists the callbacks from third parties to the first party
+type CallbackReport struct {
+	FirstParty string              `json:"first_party"`
+	Callbacks  []CallbackRoundTrip `json:"callbacks"`
+}
+
+// providerVisit is the first request of a detour through a third party
+type providerVisit struct {
+	index     int
+	requestID string
+}
+
+// providerHint is a hint that a first-party URL is reached back from a provider
+type providerHint struct {
+	provider  string
+	requestID string
+	evidence  string
+}
+
+// FindCallbacks detects the requests to the first party completing a round trip through a third
+// party, as payment providers do in checkout flows: the first party is redirected to by the
+// provider, was given as the provider's return URL, or is requested from a provider page with
+// signed or provider-specific parameters. The first party is the site of the given host, or of
+// the first HTML document of the capture when empty.
+func (p *Parser) FindCallbacks(harData *har.HAR, firstParty string) *CallbackReport {
+	if firstParty == "" {
+		firstParty = firstPartyHost(harData)
+	}
+	report := &CallbackReport{FirstParty: firstParty, Callbacks: []CallbackRoundTrip{}}
+	site := RegistrableDomain(firstParty)
+	if site == "" {
+		return report
+	}
+
+	redaction := p.redaction()
+	visits := make(map[string]providerVisit)
+	// hints are the first-party URLs, without query, providers redirect or were told to return to
+	hints := make(map[string][]providerHint)
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		requestID := formatRequestID(i)
+
+		if RegistrableDomain(u.Hostname()) != site {
+			provider := u.Hostname()
+			if _, ok := visits[provider]; !ok {
+				visits[provider] = providerVisit{index: i, requestID: requestID}
+			}
+			if location := redirectLocation(entry.Response, u); location != nil && RegistrableDomain(location.Hostname()) == site {
+				hints[urlWithoutQuery(location)] = append(hints[urlWithoutQuery(location)], providerHint{
+					provider: provider, requestID: requestID,
+					evidence: fmt.Sprintf("redirected by %s from %s", requestID, provider),
+				})
+			}
+			continue
+		}
+
+		// Return URLs handed to a provider, e.g. success_url, are hints for the callback
+		parameters := requestParameters(entry.Request, u)
+		for name, values := range parameters {
+			if !returnParameterPattern.MatchString(name) {
+				continue
+			}
+			for _, value := range values {
+				target, err := url.Parse(value)
+				if err != nil || target.Hostname() == "" || RegistrableDomain(target.Hostname()) != site {
+					continue
+				}
+				hints[urlWithoutQuery(target)] = append(hints[urlWithoutQuery(target)], providerHint{
+					requestID: requestID,
+					evidence:  fmt.Sprintf("return URL %s passed in %s", name, requestID),
+				})
+			}
+		}
+
+		callback := CallbackRoundTrip{
+			CallbackRequestID: requestID,
+			Method:            entry.Request.Method,
+			URL:               redaction.text(urlWithoutQuery(u)),
+			Parameters:        []string{},
+		}
+		providerSpecific := false
+		for name := range parameters {
+			callback.Parameters = append(callback.Parameters, name)
+			if signedParameterPattern.MatchString(name) {
+				callback.SignedParameters = append(callback.SignedParameters, name)
+			}
+			if slices.Contains(callbackParameters, strings.ToLower(name)) {
+				providerSpecific = true
+			}
+		}
+		sort.Strings(callback.Parameters)
+		sort.Strings(callback.SignedParameters)
+
+		for _, hint := range hints[urlWithoutQuery(u)] {
+			if hint.provider != "" {
+				callback.Provider = hint.provider
+			}
+			callback.Evidence = append(callback.Evidence, hint.evidence)
+		}
+		if referer := requestReferer(entry.Request); referer != nil && referer.Hostname() != "" && RegistrableDomain(referer.Hostname()) != site {
+			if callback.Provider == "" {
+				callback.Provider = referer.Hostname()
+			}
+			// Any request of a third-party page has such a referer, it takes callback parameters to count
+			if len(callback.Evidence) > 0 || len(callback.SignedParameters) > 0 || providerSpecific {
+				callback.Evidence = append(callback.Evidence, "referer from "+referer.Hostname())
+			}
+		}
+		if len(callback.Evidence) == 0 {
+			continue
+		}
+		if len(callback.Parameters) == 0 && !strings.EqualFold(callback.Method, "POST") {
+			// A plain return to a page, without any data from the provider
+			continue
+		}
+		delete(hints, urlWithoutQuery(u))
+
+		if entry.Response != nil {
+			callback.Status = entry.Response.Status
+		}
+		if visit, ok := visits[callback.Provider]; ok {
+			callback.OutboundRequestID = visit.requestID
+			outbound := harData.Log.Entries[visit.index]
+			callback.ElapsedMS = entry.StartedDateTime.Sub(outbound.StartedDateTime).Milliseconds()
+			delete(visits, callback.Provider)
+		}
+		report.Callbacks = append(report.Callbacks, callback)
+	}
+
+	return report
+}
+
+// firstPartyHost returns the host of the first HTML document of the capture, or of its first request
+func firstPartyHost(harData *har.HAR) string {
+	host := ""
+	for _, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		if host == "" {
+			host = u.Hostname()
+		}
+		if mimeTypeFamily(responseMimeType(entry.Response)) == familyHTML {
+			return u.Hostname()
+		}
+	}
+	return host
+}
+
+// redirectLocation returns the target of a redirect response, resolved against the request URL
+func redirectLocation(response *har.Response, base *url.URL) *url.URL {
+	if response == nil || response.Status < 300 || response.Status >= 400 {
+		return nil
+	}
+	location := response.RedirectURL
+	for _, header := range response.Headers {
+		if strings.EqualFold(header.Name, "Location") {
+			location = header.Value
+		}
+	}
+	if location == "" {
+		return nil
+	}
+	target, err := base.Parse(location)
+	if err != nil {
+		return nil
+	}
+	return target
+}
+
+// requestReferer returns the URL of the Referer header, if any
+func requestReferer(request *har.Request) *url.URL {
+	for _, header := range request.Headers {
+		if strings.EqualFold(header.Name, "Referer") {
+			if referer, err := url.Parse(header.Value); err == nil {
+				return referer
+			}
+		}
+	}
+	return nil
+}
+
+// requestParameters returns the query and form parameters of a request
+func requestParameters(request *har.Request, u *url.URL) url.Values {
+	parameters := u.Query()
+	for name, values := range requestForm(request) {
+		parameters[name] = append(parameters[name], values...)
+	}
+	return parameters
+}
+
+// urlWithoutQuery returns the URL without its query string and fragment
+func urlWithoutQuery(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.Path
+}
@@ -0,0 +1,66 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCallbacksFollowsPaymentRedirects(t *testing.T) {
+	parser := NewParser()
+	checkout := newTestFilterEntry("https://www.shop.example/checkout", 200, "text/html", 50)
+	session := newTestAttempt("https://api.shop.example/create-session", time.Second, 200, 80)
+	session.Request.Method = "POST"
+	session.Request.PostData = &har.PostData{
+		MimeType: "application/x-www-form-urlencoded",
+		Text:     "success_url=https%3A%2F%2Fwww.shop.example%2Forder%2Fcomplete&cancel_url=https%3A%2F%2Fwww.shop.example%2Fcart",
+	}
+	provider := newTestAttempt("https://checkout.payments.example/pay/cs_123", 2*time.Second, 200, 100)
+	confirm := newTestAttempt("https://checkout.payments.example/confirm", 30*time.Second, 303, 100)
+	confirm.Response.Headers = []har.Header{{Name: "Location", Value: "https://www.shop.example/order/complete?session_id=cs_123&signature=abc"}}
+	complete := newTestAttempt("https://www.shop.example/order/complete?session_id=cs_123&signature=abc", 31*time.Second, 200, 40)
+	complete.Request.Headers = []har.Header{{Name: "Referer", Value: "https://checkout.payments.example/"}}
+	// Assets of the provider page are requested with the same referer but carry no callback data
+	asset := newTestAttempt("https://www.shop.example/logo.png", 3*time.Second, 200, 5)
+	asset.Request.Headers = []har.Header{{Name: "Referer", Value: "https://checkout.payments.example/"}}
+
+	report := parser.FindCallbacks(newTestHAR(checkout, session, provider, asset, confirm, complete), "")
+
+	assert.Equal(t, "www.shop.example", report.FirstParty)
+	require.Len(t, report.Callbacks, 1)
+	assert.Equal(t, CallbackRoundTrip{
+		Provider:          "checkout.payments.example",
+		OutboundRequestID: "request_2",
+		CallbackRequestID: "request_5",
+		Method:            "GET",
+		URL:               "https://www.shop.example/order/complete",
+		Status:            200,
+		ElapsedMS:         29000,
+		Parameters:        []string{"session_id", "signature"},
+		SignedParameters:  []string{"signature"},
+		Evidence: []string{
+			"return URL success_url passed in request_1",
+			"redirected by request_4 from checkout.payments.example",
+			"referer from checkout.payments.example",
+		},
+	}, report.Callbacks[0])
+}
+
+func TestFindCallbacksDetectsFormPostsFromProviders(t *testing.T) {
+	parser := NewParser()
+	challenge := newTestAttempt("https://acs.bank.example/challenge", 0, 200, 100)
+	post := newTestAttempt("https://shop.example.co.uk/3ds/return", time.Second, 302, 20)
+	post.Request.Method = "POST"
+	post.Request.Headers = []har.Header{{Name: "Referer", Value: "https://acs.bank.example/challenge"}}
+	post.Request.PostData = &har.PostData{MimeType: "application/x-www-form-urlencoded", Params: []har.Param{{Name: "PaRes", Value: "eJzV"}, {Name: "MD", Value: "42"}}}
+
+	report := parser.FindCallbacks(newTestHAR(challenge, post), "www.shop.example.co.uk")
+
+	require.Len(t, report.Callbacks, 1)
+	assert.Equal(t, "acs.bank.example", report.Callbacks[0].Provider)
+	assert.Equal(t, []string{"MD", "PaRes"}, report.Callbacks[0].Parameters)
+	assert.Equal(t, []string{"referer from acs.bank.example"}, report.Callbacks[0].Evidence)
+}
@@ -0,0 +1,143 @@
+package har
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Containers a HAR file can be wrapped in
+const (
+	ContainerGzip = "gzip"
+	ContainerZstd = "zstd"
+	ContainerZip  = "zip"
+)
+
+// maxContainerDepth caps the nesting of containers, e.g. a zip holding a .har.gz
+const maxContainerDepth = 3
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// layeredReader reads the innermost of nested containers and closes them all
+type layeredReader struct {
+	io.Reader
+	closers []func()
+}
+
+// Close implements io.Closer
+func (l *layeredReader) Close() error {
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		l.closers[i]()
+	}
+	return nil
+}
+
+// decompress sniffs the gzip, zstd and zip containers wrapping the data and unwraps them as
+// streams, returning a reader of the HAR document and the containers found from the outermost one.
+// Zip archives need random access and are buffered, compressed, in memory.
+func decompress(r io.Reader) (io.ReadCloser, []string, error) {
+	layers := &layeredReader{}
+	var containers []string
+	buffered := bufio.NewReader(r)
+	for range maxContainerDepth {
+		magic, err := buffered.Peek(len(zipMagic))
+		if err != nil && err != io.EOF {
+			layers.Close() //nolint:errcheck
+			return nil, nil, fmt.Errorf("failed to read HAR data: %w", err)
+		}
+
+		var container string
+		var reader io.Reader
+		switch {
+		case bytes.HasPrefix(magic, gzipMagic):
+			container = ContainerGzip
+			reader, err = gunzip(buffered, layers)
+		case bytes.HasPrefix(magic, zstdMagic):
+			container = ContainerZstd
+			reader, err = unzstd(buffered, layers)
+		case bytes.HasPrefix(magic, zipMagic):
+			container = ContainerZip
+			reader, err = unzip(buffered, layers)
+		default:
+			layers.Reader = buffered
+			return layers, containers, nil
+		}
+		if err != nil {
+			layers.Close() //nolint:errcheck
+			return nil, nil, fmt.Errorf("failed to decompress %s HAR data: %w", container, err)
+		}
+		containers = append(containers, container)
+		buffered = bufio.NewReader(reader)
+	}
+	layers.Reader = buffered
+	return layers, containers, nil
+}
+
+// gunzip decompresses a gzip stream
+func gunzip(r io.Reader, layers *layeredReader) (io.Reader, error) {
+	reader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	layers.closers = append(layers.closers, func() { reader.Close() }) //nolint:errcheck
+	return reader, nil
+}
+
+// unzstd decompresses a zstd stream
+func unzstd(r io.Reader, layers *layeredReader) (io.Reader, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	layers.closers = append(layers.closers, decoder.Close)
+	return decoder, nil
+}
+
+// unzip extracts the HAR file of a zip archive: its only .har file, possibly compressed,
+// or its only file when none is named .har
+func unzip(r io.Reader, layers *layeredReader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files, harFiles []*zip.File
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(path.Base(file.Name), ".") {
+			continue
+		}
+		files = append(files, file)
+		name := strings.ToLower(file.Name)
+		if strings.HasSuffix(name, ".har") || strings.HasSuffix(name, ".har.gz") || strings.HasSuffix(name, ".har.zst") {
+			harFiles = append(harFiles, file)
+		}
+	}
+	if len(harFiles) == 0 {
+		harFiles = files
+	}
+	if len(harFiles) != 1 {
+		return nil, fmt.Errorf("expected a single HAR file in the zip archive, found %d", len(harFiles))
+	}
+
+	file, err := harFiles[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	layers.closers = append(layers.closers, func() { file.Close() }) //nolint:errcheck
+	return file, nil
+}
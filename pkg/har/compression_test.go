@@ -0,0 +1,99 @@
+package har
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gzipData compresses data with gzip
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+// zipData builds a zip archive holding the given files
+func zipData(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, data := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestParseGzipCompressedHAR(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(bytes.NewReader(gzipData(t, []byte(createMultipleEntriesHAR()))))
+	require.NoError(t, err)
+
+	assert.Len(t, harData.Log.Entries, 3)
+	assert.Equal(t, []string{ContainerGzip}, report.Containers)
+	assert.Equal(t, len(createMultipleEntriesHAR()), report.Bytes)
+}
+
+func TestParseZstdCompressedHAR(t *testing.T) {
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	compressed := encoder.EncodeAll([]byte(createMultipleEntriesHAR()), nil)
+	require.NoError(t, encoder.Close())
+
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(bytes.NewReader(compressed))
+	require.NoError(t, err)
+
+	assert.Len(t, harData.Log.Entries, 3)
+	assert.Equal(t, []string{ContainerZstd}, report.Containers)
+}
+
+func TestParseZipArchivedHAR(t *testing.T) {
+	archive := zipData(t, map[string][]byte{
+		"README.txt":       []byte("exported by the browser"),
+		"capture.har":      []byte(createMultipleEntriesHAR()),
+		"__MACOSX/._x.har": []byte("resource fork"),
+	})
+
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(bytes.NewReader(archive))
+	require.NoError(t, err)
+
+	assert.Len(t, harData.Log.Entries, 3)
+	assert.Equal(t, []string{ContainerZip}, report.Containers)
+}
+
+func TestParseZipArchivedCompressedHAR(t *testing.T) {
+	archive := zipData(t, map[string][]byte{"capture.har.gz": gzipData(t, []byte(createMultipleEntriesHAR()))})
+
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(bytes.NewReader(archive))
+	require.NoError(t, err)
+
+	assert.Len(t, harData.Log.Entries, 3)
+	assert.Equal(t, []string{ContainerZip, ContainerGzip}, report.Containers)
+}
+
+func TestParseZipWithSeveralHARsFails(t *testing.T) {
+	archive := zipData(t, map[string][]byte{
+		"first.har":  []byte(createMultipleEntriesHAR()),
+		"second.har": []byte(createMultipleEntriesHAR()),
+	})
+
+	_, err := NewParser().Parse(bytes.NewReader(archive))
+
+	assert.ErrorContains(t, err, "expected a single HAR file in the zip archive, found 2")
+}
@@ -0,0 +1,313 @@
+package har
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// CookieSummary aggregates the occurrences of a cookie, identified by its name, domain and path.
+// Attributes are those of the last Set-Cookie seen, cookies only sent by the client having none.
+type CookieSummary struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Path   string `json:"path,omitempty"`
+	// HostOnly is set when the cookie was set without Domain attribute, so it is only sent to
+	// the host that set it
+	HostOnly bool   `json:"host_only,omitempty"`
+	Value    string `json:"value"`
+	// DistinctValues counts the values set or sent, telling rotated cookies apart
+	DistinctValues int    `json:"distinct_values"`
+	Secure         bool   `json:"secure"`
+	HttpOnly       bool   `json:"http_only"`
+	SameSite       string `json:"same_site,omitempty"`
+	// Expires is when the cookie expires, from its Max-Age or Expires attribute, empty for
+	// session cookies
+	Expires string `json:"expires,omitempty"`
+	Session bool   `json:"session"`
+	Deleted bool   `json:"deleted,omitempty"`
+	// Set counts the responses setting the cookie, Sent the requests sending it
+	Set  int `json:"set"`
+	Sent int `json:"sent"`
+	// SetBy is the first request whose response set the cookie, SentBy the first request sending it
+	SetBy  string `json:"set_by,omitempty"`
+	SentBy string `json:"sent_by,omitempty"`
+	// Issues are the security rules of export_sarif the cookie breaks, such as cookie-missing-secure
+	Issues []string `json:"issues"`
+}
+
+// CookieDomain lists the cookies of a domain
+type CookieDomain struct {
+	Domain  string          `json:"domain"`
+	Cookies []CookieSummary `json:"cookies"`
+}
+
+// CookieReport lists the cookies seen in the capture by domain
+type CookieReport struct {
+	Cookies int `json:"cookies"`
+	// Flagged counts the cookies breaking at least one security rule
+	Flagged int            `json:"flagged"`
+	Domains []CookieDomain `json:"domains"`
+}
+
+// cookieAggregate accumulates the occurrences of a cookie
+type cookieAggregate struct {
+	summary CookieSummary
+	values  map[string]bool
+	// expires is the expiry of the last Set-Cookie
+	expires time.Time
+}
+
+// ListCookies aggregates the cookies set by Set-Cookie response headers and sent in requests,
+// grouped by domain, with their attributes and the security rules they break: Secure missing on
+// cookies set over HTTPS, HttpOnly or SameSite missing. Cookies sent to a host are matched with
+// the cookies set for that host or a parent domain. Values are redacted by the redaction policy.
+func (p *Parser) ListCookies(harData *har.HAR) *CookieReport {
+	redaction := p.redaction()
+	cookies := make(map[string]*cookieAggregate)
+	var order []string
+	lookup := func(name, domain, path string) *cookieAggregate {
+		key := strings.Join([]string{name, domain, path}, "\x00")
+		aggregate, ok := cookies[key]
+		if !ok {
+			aggregate = &cookieAggregate{summary: CookieSummary{Name: name, Domain: domain, Path: path, Issues: []string{}}, values: make(map[string]bool)}
+			cookies[key] = aggregate
+			order = append(order, key)
+		}
+		return aggregate
+	}
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		requestID := formatRequestID(i)
+		host := strings.ToLower(u.Hostname())
+
+		for _, cookie := range requestCookies(entry.Request) {
+			aggregate := sentCookie(cookies, order, cookie.Name, host, u.Path)
+			if aggregate == nil {
+				// Cookies set before the capture started have unknown attributes
+				aggregate = lookup(cookie.Name, host, "")
+			}
+			aggregate.sent(cookie.Value, requestID, redaction)
+		}
+
+		if entry.Response == nil {
+			continue
+		}
+		for _, cookie := range responseCookies(entry.Response) {
+			domain, hostOnly := strings.TrimPrefix(strings.ToLower(cookie.Domain), "."), false
+			if domain == "" {
+				domain, hostOnly = host, true
+			}
+			path := cookie.Path
+			if path == "" {
+				path = defaultCookiePath(u.Path)
+			}
+			aggregate := lookup(cookie.Name, domain, path)
+			aggregate.summary.HostOnly = hostOnly
+			aggregate.set(cookie, u, requestID, entry.StartedDateTime, redaction)
+		}
+	}
+
+	report := &CookieReport{Domains: []CookieDomain{}}
+	domains := make(map[string]*CookieDomain)
+	var domainOrder []string
+	for _, key := range order {
+		aggregate := cookies[key]
+		summary := aggregate.summary
+		summary.DistinctValues = len(aggregate.values)
+		if !aggregate.expires.IsZero() {
+			summary.Expires = p.formatTime(aggregate.expires, time.RFC3339)
+		}
+		report.Cookies++
+		if len(summary.Issues) > 0 {
+			report.Flagged++
+		}
+		domain, ok := domains[summary.Domain]
+		if !ok {
+			domain = &CookieDomain{Domain: summary.Domain}
+			domains[summary.Domain] = domain
+			domainOrder = append(domainOrder, summary.Domain)
+		}
+		domain.Cookies = append(domain.Cookies, summary)
+	}
+	sort.Strings(domainOrder)
+	for _, name := range domainOrder {
+		domain := domains[name]
+		sort.SliceStable(domain.Cookies, func(i, j int) bool { return domain.Cookies[i].Name < domain.Cookies[j].Name })
+		report.Domains = append(report.Domains, *domain)
+	}
+	return report
+}
+
+// sentCookie returns the cookie set earlier that a request to the host and path sends, the most
+// specific path winning, nil when no such cookie was set
+func sentCookie(cookies map[string]*cookieAggregate, order []string, name, host, path string) *cookieAggregate {
+	var match *cookieAggregate
+	for _, key := range order {
+		aggregate := cookies[key]
+		summary := aggregate.summary
+		if summary.Name != name || summary.Set == 0 {
+			continue
+		}
+		if summary.HostOnly && summary.Domain != host {
+			continue
+		}
+		if !summary.HostOnly && host != summary.Domain && !strings.HasSuffix(host, "."+summary.Domain) {
+			continue
+		}
+		if !cookiePathMatches(summary.Path, path) {
+			continue
+		}
+		if match == nil || len(summary.Path) > len(match.summary.Path) {
+			match = aggregate
+		}
+	}
+	return match
+}
+
+// set records a Set-Cookie of the cookie by the response to the URL
+func (c *cookieAggregate) set(cookie *http.Cookie, u *url.URL, requestID string, received time.Time, redaction *redactor) {
+	c.summary.Set++
+	if c.summary.SetBy == "" {
+		c.summary.SetBy = requestID
+	}
+	c.summary.Secure = cookie.Secure
+	c.summary.HttpOnly = cookie.HttpOnly
+	c.summary.SameSite = sameSiteName(cookie.SameSite)
+
+	c.expires = time.Time{}
+	switch {
+	case cookie.MaxAge > 0:
+		c.expires = received.Add(time.Duration(cookie.MaxAge) * time.Second)
+	case !cookie.Expires.IsZero():
+		c.expires = cookie.Expires
+	}
+	c.summary.Session = c.expires.IsZero() && cookie.MaxAge == 0
+	// Deletions expire the cookie, their attributes don't matter
+	c.summary.Deleted = cookie.MaxAge < 0 || cookie.Value == "" || (!c.expires.IsZero() && !c.expires.After(received))
+	c.summary.Issues = []string{}
+	if !c.summary.Deleted {
+		c.summary.Issues = append(c.summary.Issues, cookieIssues(cookie, u)...)
+	}
+	c.record(cookie.Value, redaction)
+}
+
+// sent records the cookie sent by a request
+func (c *cookieAggregate) sent(value, requestID string, redaction *redactor) {
+	c.summary.Sent++
+	if c.summary.SentBy == "" {
+		c.summary.SentBy = requestID
+	}
+	c.record(value, redaction)
+}
+
+// record records a value of the cookie, the last one being reported redacted
+func (c *cookieAggregate) record(value string, redaction *redactor) {
+	if value == "" {
+		return
+	}
+	c.values[value] = true
+	if redaction.sensitiveCookie(c.summary.Name) {
+		c.summary.Value = redactedValue
+	} else {
+		c.summary.Value = redaction.text(value)
+	}
+}
+
+// requestCookies returns the cookies sent by a request, parsed from its Cookie headers when
+// the HAR does not list them
+func requestCookies(request *har.Request) []har.Cookie {
+	if len(request.Cookies) > 0 {
+		return request.Cookies
+	}
+	var cookies []har.Cookie
+	for _, header := range request.Headers {
+		if !strings.EqualFold(header.Name, "Cookie") {
+			continue
+		}
+		parsed, err := http.ParseCookie(header.Value)
+		if err != nil {
+			continue
+		}
+		for _, cookie := range parsed {
+			cookies = append(cookies, har.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	return cookies
+}
+
+// responseCookies returns the cookies set by a response, parsed from its Set-Cookie headers,
+// or from the cookies the HAR lists when it has no such header
+func responseCookies(response *har.Response) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, header := range response.Headers {
+		if !strings.EqualFold(header.Name, "Set-Cookie") {
+			continue
+		}
+		if cookie, err := http.ParseSetCookie(header.Value); err == nil {
+			cookies = append(cookies, cookie)
+		}
+	}
+	if len(cookies) > 0 {
+		return cookies
+	}
+	for _, cookie := range response.Cookies {
+		cookies = append(cookies, &http.Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HTTPOnly,
+		})
+	}
+	return cookies
+}
+
+// defaultCookiePath returns the path of a cookie set without Path attribute: the directory of
+// the request path
+func defaultCookiePath(requestPath string) string {
+	if !strings.HasPrefix(requestPath, "/") || strings.Count(requestPath, "/") == 1 {
+		return "/"
+	}
+	return requestPath[:strings.LastIndex(requestPath, "/")]
+}
+
+// cookiePathMatches reports whether a request path is within the path of a cookie
+func cookiePathMatches(cookiePath, requestPath string) bool {
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	if cookiePath == "" || cookiePath == requestPath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+// sameSiteName returns the SameSite attribute value, empty when not set
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
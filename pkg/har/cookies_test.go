@@ -0,0 +1,98 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCookiesAggregatesByDomain(t *testing.T) {
+	parser := NewParser()
+	login := newTestEntry("POST", "https://app.example.com/login")
+	login.StartedDateTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	login.Response.Headers = []har.Header{
+		{Name: "Set-Cookie", Value: "session=abc; Domain=.example.com; Path=/; Secure; HttpOnly; SameSite=Lax; Max-Age=3600"},
+		{Name: "Set-Cookie", Value: "theme=dark; Path=/"},
+	}
+	api := newTestEntry("GET", "https://api.example.com/me", har.Header{Name: "Cookie", Value: "session=abc; legacy=1"})
+	other := newTestEntry("GET", "http://tracker.test/pixel")
+	other.Response.Headers = []har.Header{{Name: "Set-Cookie", Value: "uid=42; Expires=Fri, 01 Mar 2030 00:00:00 GMT"}}
+
+	report := parser.ListCookies(newTestHAR(login, api, other))
+
+	assert.Equal(t, 4, report.Cookies)
+	assert.Equal(t, 2, report.Flagged)
+	require.Len(t, report.Domains, 4)
+	assert.Equal(t, "api.example.com", report.Domains[0].Domain)
+	assert.Equal(t, "legacy", report.Domains[0].Cookies[0].Name)
+	assert.Equal(t, 1, report.Domains[0].Cookies[0].Sent)
+	assert.Equal(t, 0, report.Domains[0].Cookies[0].Set)
+
+	assert.Equal(t, "app.example.com", report.Domains[1].Domain)
+	theme := report.Domains[1].Cookies[0]
+	assert.True(t, theme.HostOnly)
+	assert.True(t, theme.Session)
+	assert.Equal(t, []string{RuleCookieMissingSecure, RuleCookieMissingHTTPOnly, RuleCookieMissingSameSite}, theme.Issues)
+
+	assert.Equal(t, "example.com", report.Domains[2].Domain)
+	session := report.Domains[2].Cookies[0]
+	assert.Equal(t, "session", session.Name)
+	assert.Equal(t, "[REDACTED]", session.Value)
+	assert.Equal(t, 1, session.Set)
+	assert.Equal(t, 1, session.Sent)
+	assert.Equal(t, "request_0", session.SetBy)
+	assert.Equal(t, "request_1", session.SentBy)
+	assert.Equal(t, 1, session.DistinctValues)
+	assert.Equal(t, "Lax", session.SameSite)
+	assert.Equal(t, "2024-03-01T13:00:00Z", session.Expires)
+	assert.False(t, session.Session)
+	assert.Empty(t, session.Issues)
+
+	uid := report.Domains[3].Cookies[0]
+	assert.Equal(t, "tracker.test", report.Domains[3].Domain)
+	assert.Equal(t, "2030-03-01T00:00:00Z", uid.Expires)
+	// Secure is only expected on cookies set over HTTPS
+	assert.Equal(t, []string{RuleCookieMissingHTTPOnly, RuleCookieMissingSameSite}, uid.Issues)
+}
+
+func TestListCookiesShowsAllowedValues(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{AllowedCookies: []string{"theme"}}))
+	first := newTestEntry("GET", "https://example.com/")
+	first.Response.Headers = []har.Header{{Name: "Set-Cookie", Value: "theme=dark; Path=/; Secure; HttpOnly; SameSite=Strict"}}
+	second := newTestEntry("GET", "https://example.com/settings")
+	second.Response.Headers = []har.Header{{Name: "Set-Cookie", Value: "theme=light; Path=/; Secure; HttpOnly; SameSite=Strict"}}
+
+	report := parser.ListCookies(newTestHAR(first, second))
+
+	require.Len(t, report.Domains, 1)
+	require.Len(t, report.Domains[0].Cookies, 1)
+	theme := report.Domains[0].Cookies[0]
+	assert.Equal(t, "light", theme.Value)
+	assert.Equal(t, 2, theme.Set)
+	assert.Equal(t, 2, theme.DistinctValues)
+}
+
+func TestListCookiesDeletion(t *testing.T) {
+	parser := NewParser()
+	logout := newTestEntry("GET", "https://example.com/logout")
+	logout.Response.Headers = []har.Header{{Name: "Set-Cookie", Value: "session=; Path=/; Max-Age=0"}}
+
+	report := parser.ListCookies(newTestHAR(logout))
+
+	require.Len(t, report.Domains, 1)
+	assert.True(t, report.Domains[0].Cookies[0].Deleted)
+	assert.Empty(t, report.Domains[0].Cookies[0].Issues)
+	assert.Equal(t, 0, report.Flagged)
+}
+
+func TestCookiePathMatches(t *testing.T) {
+	assert.True(t, cookiePathMatches("/", "/api"))
+	assert.True(t, cookiePathMatches("/api", "/api/users"))
+	assert.False(t, cookiePathMatches("/api", "/apiary"))
+	assert.Equal(t, "/api", defaultCookiePath("/api/login"))
+	assert.Equal(t, "/", defaultCookiePath("/login"))
+}
@@ -0,0 +1,64 @@
+package har
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// ExportAsCurl reconstructs a curl command replaying a request: method, URL with its query
+// string, headers and body. Sensitive values are redacted as in GetRequestDetails, so
+// redacted credentials must be filled in before replaying.
+func (p *Parser) ExportAsCurl(harData *har.HAR, requestID string) (string, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return "", err
+	}
+
+	redaction := p.redaction()
+	request := entry.Request
+	body := curlBody(redaction.postData(request.PostData))
+
+	// Each option goes on its own line with its value
+	args := []string{"curl"}
+	if method := strings.ToUpper(request.Method); method != "GET" || body != "" {
+		args = append(args, "-X "+shellQuote(method))
+	}
+	args = append(args, shellQuote(redaction.text(request.URL)))
+	for _, header := range redaction.headerList(request.Headers) {
+		// HTTP/2 pseudo headers and the length are derived by curl
+		if strings.HasPrefix(header.Name, ":") || strings.EqualFold(header.Name, "Content-Length") {
+			continue
+		}
+		args = append(args, "-H "+shellQuote(header.Name+": "+header.Value))
+	}
+	if body != "" {
+		args = append(args, "--data-raw "+shellQuote(body))
+	}
+	if strings.HasPrefix(request.HTTPVersion, "HTTP/2") || strings.EqualFold(request.HTTPVersion, "h2") {
+		args = append(args, "--http2")
+	}
+
+	return strings.Join(args, " \\\n  "), nil
+}
+
+// curlBody returns the request body, form encoding the parameters of bodies recorded without text
+func curlBody(postData *har.PostData) string {
+	if postData == nil {
+		return ""
+	}
+	if postData.Text != "" || len(postData.Params) == 0 {
+		return postData.Text
+	}
+	form := url.Values{}
+	for _, param := range postData.Params {
+		form.Add(param.Name, param.Value)
+	}
+	return form.Encode()
+}
+
+// shellQuote quotes a value for POSIX shells
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
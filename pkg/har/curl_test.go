@@ -0,0 +1,51 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAsCurl(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("POST", "https://api.example.com/orders?dry_run=true",
+		har.Header{Name: ":authority", Value: "api.example.com"},
+		har.Header{Name: "Authorization", Value: "Bearer secret"},
+		har.Header{Name: "Content-Type", Value: "application/json"},
+		har.Header{Name: "Content-Length", Value: "27"},
+	)
+	entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"note": "it's urgent"}`}
+
+	command, err := parser.ExportAsCurl(newTestHAR(entry), "request_0")
+	require.NoError(t, err)
+
+	assert.Equal(t, `curl \
+  -X 'POST' \
+  'https://api.example.com/orders?dry_run=true' \
+  -H 'Authorization: [REDACTED]' \
+  -H 'Content-Type: application/json' \
+  --data-raw '{"note": "it'\''s urgent"}'`, command)
+}
+
+func TestExportAsCurlEncodesFormParams(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("POST", "https://example.com/login")
+	entry.Request.PostData = &har.PostData{
+		MimeType: "application/x-www-form-urlencoded",
+		Params:   []har.Param{{Name: "user", Value: "ada lovelace"}},
+	}
+	get := newTestEntry("GET", "https://example.com/")
+
+	command, err := parser.ExportAsCurl(newTestHAR(entry, get), "request_0")
+	require.NoError(t, err)
+	assert.Contains(t, command, `--data-raw 'user=ada+lovelace'`)
+
+	command, err = parser.ExportAsCurl(newTestHAR(entry, get), "request_1")
+	require.NoError(t, err)
+	assert.Equal(t, "curl \\\n  'https://example.com/'", command)
+
+	_, err = parser.ExportAsCurl(newTestHAR(entry), "request_9")
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// maxInitiatorFrames caps the call frames returned for the initiator of a request
+const maxInitiatorFrames = 30
+
+// CustomFieldIndex holds the custom fields Chrome DevTools adds to the entries (_initiator,
+// _priority, _resourceType, _fromCache and the service worker fields), which martian's model
+// leaves out. It is built when parsing and available from the parse report.
+type CustomFieldIndex struct {
+	fields map[*har.Entry]*CustomFields
+}
+
+// CustomFields are the custom fields Chrome DevTools records on an entry
+type CustomFields struct {
+	// ResourceType is the kind of resource, such as document, script, xhr or fetch
+	ResourceType string `json:"resource_type,omitempty"`
+	// Priority is the fetch priority the browser gave the request, such as VeryHigh or Low
+	Priority string `json:"priority,omitempty"`
+	// FromCache tells the cache the response was served from, disk or memory
+	FromCache string `json:"from_cache,omitempty"`
+	// FromServiceWorker is set when a service worker answered the request, ServiceWorkerSource
+	// telling where it got the response from: cache-storage, http-cache, network or fallback-code
+	FromServiceWorker   bool       `json:"from_service_worker,omitempty"`
+	ServiceWorkerSource string     `json:"service_worker_source,omitempty"`
+	Initiator           *Initiator `json:"initiator,omitempty"`
+}
+
+// Initiator tells what triggered a request: the parser of a document, a script or a preload
+type Initiator struct {
+	Type string `json:"type"`
+	// URL and LineNumber locate the markup or script that sent the request, when recorded
+	URL        string `json:"url,omitempty"`
+	LineNumber *int   `json:"line_number,omitempty"`
+	// Stack is the JavaScript call stack of script initiators, innermost frame first, async
+	// parents following their children
+	Stack []InitiatorFrame `json:"stack,omitempty"`
+	// Truncated is set when only the innermost frames are returned
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// InitiatorFrame is a frame of the call stack of an initiator. Line and column numbers are
+// zero-based, as recorded by Chrome.
+type InitiatorFrame struct {
+	FunctionName string `json:"function_name,omitempty"`
+	URL          string `json:"url"`
+	LineNumber   int    `json:"line_number"`
+	ColumnNumber int    `json:"column_number"`
+	// Async names the asynchronous boundary crossed to reach the frame, such as Promise.then
+	Async string `json:"async,omitempty"`
+}
+
+// Fields returns the custom fields of the entry, nil when it has none
+func (idx *CustomFieldIndex) Fields(entry *har.Entry) *CustomFields {
+	if idx == nil {
+		return nil
+	}
+	return idx.fields[entry]
+}
+
+// ResourceType returns the resource type recorded on the entry, empty when unknown
+func (idx *CustomFieldIndex) ResourceType(entry *har.Entry) string {
+	if fields := idx.Fields(entry); fields != nil {
+		return fields.ResourceType
+	}
+	return ""
+}
+
+// redacted returns a copy of the fields with the URLs of the initiator redacted
+func (f *CustomFields) redacted(redaction *redactor) *CustomFields {
+	if f == nil || f.Initiator == nil {
+		return f
+	}
+	fields := *f
+	initiator := *f.Initiator
+	initiator.URL = redaction.text(initiator.URL)
+	initiator.Stack = make([]InitiatorFrame, len(f.Initiator.Stack))
+	for i, frame := range f.Initiator.Stack {
+		frame.URL = redaction.text(frame.URL)
+		initiator.Stack[i] = frame
+	}
+	fields.Initiator = &initiator
+	return &fields
+}
+
+// rawInitiator is the _initiator field as written by Chrome DevTools
+type rawInitiator struct {
+	Type       string        `json:"type"`
+	URL        string        `json:"url"`
+	LineNumber *float64      `json:"lineNumber"`
+	Stack      *rawCallStack `json:"stack"`
+}
+
+// rawCallStack is a JavaScript call stack, chained to the stack of the asynchronous caller
+type rawCallStack struct {
+	Description string `json:"description"`
+	CallFrames  []struct {
+		FunctionName string  `json:"functionName"`
+		URL          string  `json:"url"`
+		LineNumber   float64 `json:"lineNumber"`
+		ColumnNumber float64 `json:"columnNumber"`
+	} `json:"callFrames"`
+	Parent *rawCallStack `json:"parent"`
+}
+
+// decodeCustomFields decodes the custom fields of an entry, nil when it has none. Malformed
+// fields are left out with a warning.
+func (d *documentDecoder) decodeCustomFields(extensions *entryExtensions, index int) *CustomFields {
+	fields := &CustomFields{
+		ResourceType:      extensions.ResourceType,
+		Priority:          extensions.Priority,
+		FromServiceWorker: extensions.FromServiceWorker,
+	}
+	if extensions.Response != nil {
+		fields.FromServiceWorker = fields.FromServiceWorker || extensions.Response.FetchedViaServiceWorker
+		fields.ServiceWorkerSource = extensions.Response.ServiceWorkerResponseSource
+	}
+	switch fromCache := extensions.FromCache.(type) {
+	case string:
+		fields.FromCache = fromCache
+	case bool:
+		if fromCache {
+			fields.FromCache = "cache"
+		}
+	}
+	if len(extensions.Initiator) > 0 {
+		var initiator *rawInitiator
+		if err := json.Unmarshal(extensions.Initiator, &initiator); err != nil {
+			d.report.warn(formatRequestID(index), fmt.Sprintf("ignored _initiator: %v", err))
+		} else if initiator != nil {
+			fields.Initiator = initiator.toInitiator()
+		}
+	}
+	if *fields == (CustomFields{}) {
+		return nil
+	}
+	return fields
+}
+
+// toInitiator flattens the call stack of the initiator, async parents included
+func (r *rawInitiator) toInitiator() *Initiator {
+	initiator := &Initiator{Type: r.Type, URL: r.URL}
+	if r.LineNumber != nil {
+		line := int(*r.LineNumber)
+		initiator.LineNumber = &line
+	}
+	async := ""
+	for stack := r.Stack; stack != nil; stack = stack.Parent {
+		for _, frame := range stack.CallFrames {
+			if len(initiator.Stack) == maxInitiatorFrames {
+				initiator.Truncated = true
+				return initiator
+			}
+			initiator.Stack = append(initiator.Stack, InitiatorFrame{
+				FunctionName: frame.FunctionName,
+				URL:          frame.URL,
+				LineNumber:   int(frame.LineNumber),
+				ColumnNumber: int(frame.ColumnNumber),
+				Async:        async,
+			})
+			async = ""
+		}
+		// The description of the parent names the boundary between the stacks
+		if stack.Parent != nil {
+			async = stack.Parent.Description
+		}
+	}
+	return initiator
+}
+
+// matchResourceType reports whether the resource type is one of the comma separated types, the
+// comparison ignoring case
+func matchResourceType(types, resourceType string) bool {
+	if resourceType == "" {
+		return false
+	}
+	for _, wanted := range strings.Split(types, ",") {
+		if strings.EqualFold(strings.TrimSpace(wanted), resourceType) {
+			return true
+		}
+	}
+	return false
+}
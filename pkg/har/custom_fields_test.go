@@ -0,0 +1,71 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createChromeHAR returns a capture exported by Chrome DevTools: a document and a fetch sent by
+// a script after an awaited promise
+func createChromeHAR() string {
+	return `{"log": {"version": "1.2", "creator": {"name": "WebInspector", "version": "537.36"}, "entries": [
+		{"startedDateTime": "2023-01-01T00:00:00Z", "time": 10, "_resourceType": "document", "_priority": "VeryHigh", "_initiator": {"type": "other"},
+		 "request": {"method": "GET", "url": "https://example.com/", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+		 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/html"}, "redirectURL": "", "headersSize": -1, "bodySize": 0}},
+		{"startedDateTime": "2023-01-01T00:00:01Z", "time": 10, "_resourceType": "fetch", "_priority": "High", "_fromCache": "disk",
+		 "_initiator": {"type": "script", "stack": {"callFrames": [{"functionName": "loadUser", "url": "https://example.com/app.js?token=s3cr3t", "lineNumber": 41, "columnNumber": 7}],
+		   "parent": {"description": "await", "callFrames": [{"functionName": "main", "url": "https://example.com/app.js", "lineNumber": 3, "columnNumber": 1}]}}},
+		 "request": {"method": "GET", "url": "https://example.com/api/user", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+		 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "application/json"}, "redirectURL": "", "headersSize": -1, "bodySize": 0}}
+	]}}`
+}
+
+func TestParseKeepsChromeCustomFields(t *testing.T) {
+	harData, report, err := NewParser().ParseWithReport(strings.NewReader(createChromeHAR()))
+	require.NoError(t, err)
+
+	fields := report.CustomFieldIndex().Fields(harData.Log.Entries[1])
+	require.NotNil(t, fields)
+	assert.Equal(t, "fetch", fields.ResourceType)
+	assert.Equal(t, "High", fields.Priority)
+	assert.Equal(t, "disk", fields.FromCache)
+	require.NotNil(t, fields.Initiator)
+	assert.Equal(t, "script", fields.Initiator.Type)
+	assert.Equal(t, []InitiatorFrame{
+		{FunctionName: "loadUser", URL: "https://example.com/app.js?token=s3cr3t", LineNumber: 41, ColumnNumber: 7},
+		{FunctionName: "main", URL: "https://example.com/app.js", LineNumber: 3, ColumnNumber: 1, Async: "await"},
+	}, fields.Initiator.Stack)
+}
+
+func TestFilterEntriesByResourceType(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(createChromeHAR()))
+	require.NoError(t, err)
+
+	matches, err := parser.FilterEntries(harData, nil, nil, report.CustomFieldIndex(), EntryFilter{ResourceType: "XHR, fetch"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "request_1", matches[0].RequestID)
+
+	matches, err = parser.FilterEntries(harData, nil, nil, nil, EntryFilter{ResourceType: "document"})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestGetRequestDetailsShowsInitiator(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`s3cr3t`}}))
+	harData, report, err := parser.ParseWithReport(strings.NewReader(createChromeHAR()))
+	require.NoError(t, err)
+
+	details, err := parser.GetRequestDetails(harData, nil, report.CustomFieldIndex(), "request_1")
+	require.NoError(t, err)
+
+	require.NotNil(t, details.CustomFields)
+	assert.Equal(t, "fetch", details.CustomFields.ResourceType)
+	assert.NotContains(t, details.CustomFields.Initiator.Stack[0].URL, "s3cr3t")
+	assert.Contains(t, report.CustomFieldIndex().Fields(harData.Log.Entries[1]).Initiator.Stack[0].URL, "s3cr3t")
+}
@@ -1,8 +1,12 @@
 package har
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/martian/har"
@@ -15,27 +19,69 @@ type FlexibleHAR struct {
 
 // FlexibleLog represents the HAR log with flexible parsing
 type FlexibleLog struct {
-	Version string          `json:"version"`
-	Creator *har.Creator    `json:"creator"`
-	Entries []FlexibleEntry `json:"entries"`
+	Version string           `json:"version"`
+	Creator *FlexibleCreator `json:"creator"`
+	Entries []FlexibleEntry  `json:"entries"`
 	// Additional fields that might be in HAR files but not in martian/har
 	Browser interface{} `json:"browser,omitempty"`
-	Pages   interface{} `json:"pages,omitempty"`
+	Pages   []Page      `json:"pages,omitempty"`
 	Comment string      `json:"comment,omitempty"`
 }
 
+// FlexibleCreator accepts the creator block as an object, as a bare name, or in any other shape minimal exports produce
+type FlexibleCreator struct {
+	har.Creator
+}
+
+// UnmarshalJSON implements custom unmarshaling for FlexibleCreator
+func (fc *FlexibleCreator) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		fc.Name = name
+		return nil
+	}
+	// Malformed creators are left empty so they get defaulted like missing ones
+	var creator har.Creator
+	if err := json.Unmarshal(data, &creator); err == nil {
+		fc.Creator = creator
+	}
+	return nil
+}
+
+// ToStandardCreator converts FlexibleCreator to standard har.Creator
+func (fc *FlexibleCreator) ToStandardCreator() *har.Creator {
+	if fc == nil {
+		return nil
+	}
+	creator := fc.Creator
+	return &creator
+}
+
 // FlexibleEntry allows time to be parsed as either int or float
 type FlexibleEntry struct {
 	ID              string            `json:"_id,omitempty"`
+	PageRef         string            `json:"pageref,omitempty"`
 	StartedDateTime time.Time         `json:"startedDateTime"`
-	Time            FlexibleTime      `json:"time"`
-	Request         *har.Request      `json:"request"`
+	Time            FlexibleFloat     `json:"time"`
+	Request         *FlexibleRequest  `json:"request"`
 	Response        *FlexibleResponse `json:"response,omitempty"`
 	Cache           *har.Cache        `json:"cache,omitempty"`
 	Timings         *FlexibleTimings  `json:"timings,omitempty"`
 	ServerIPAddress string            `json:"serverIPAddress,omitempty"`
-	Connection      string            `json:"connection,omitempty"`
-	Comment         string            `json:"comment,omitempty"`
+	// Connection is usually the client port, which some exporters write as a number
+	Connection FlexibleString `json:"connection,omitempty"`
+	Comment    string         `json:"comment,omitempty"`
+	// WebSocketMessages are the frames Chrome records on websocket entries
+	WebSocketMessages []FlexibleWebSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+// FlexibleWebSocketMessage is a WebSocket frame, timestamped in seconds since the epoch
+type FlexibleWebSocketMessage struct {
+	// Type is send or receive
+	Type   string         `json:"type"`
+	Time   FlexibleFloat  `json:"time"`
+	Opcode FlexibleInt    `json:"opcode"`
+	Data   FlexibleString `json:"data"`
 }
 
 // FlexibleTime handles both int and float JSON values
@@ -43,28 +89,124 @@ type FlexibleTime int64
 
 // UnmarshalJSON implements custom unmarshaling for FlexibleTime
 func (ft *FlexibleTime) UnmarshalJSON(data []byte) error {
+	f, err := unmarshalFlexibleNumber(data)
+	if err != nil {
+		return err
+	}
+	*ft = FlexibleTime(int64(f))
+	return nil
+}
+
+// FlexibleFloat handles numbers written as JSON numbers or as numeric strings
+type FlexibleFloat float64
+
+// UnmarshalJSON implements custom unmarshaling for FlexibleFloat
+func (ff *FlexibleFloat) UnmarshalJSON(data []byte) error {
+	f, err := unmarshalFlexibleNumber(data)
+	if err != nil {
+		return err
+	}
+	*ff = FlexibleFloat(f)
+	return nil
+}
+
+// FlexibleInt handles integers written as JSON numbers or as numeric strings, such as "status": "200"
+type FlexibleInt int64
+
+// UnmarshalJSON implements custom unmarshaling for FlexibleInt
+func (fi *FlexibleInt) UnmarshalJSON(data []byte) error {
+	f, err := unmarshalFlexibleNumber(data)
+	if err != nil {
+		return err
+	}
+	*fi = FlexibleInt(int64(f))
+	return nil
+}
+
+// unmarshalFlexibleNumber decodes a JSON number, or a string holding one
+func unmarshalFlexibleNumber(data []byte) (float64, error) {
 	// Try to unmarshal as float64 first (handles both int and float)
 	var f float64
-	if err := json.Unmarshal(data, &f); err != nil {
+	if err := json.Unmarshal(data, &f); err == nil {
+		return f, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid numeric value %q: %w", s, err)
+	}
+	return f, nil
+}
+
+// FlexibleString handles strings that some exporters write as JSON numbers
+type FlexibleString string
+
+// UnmarshalJSON implements custom unmarshaling for FlexibleString
+func (fs *FlexibleString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*fs = FlexibleString(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
 		return err
 	}
-	*ft = FlexibleTime(int64(f))
+	*fs = FlexibleString(n.String())
 	return nil
 }
 
+// FlexibleRequest allows sizes, query string values and post parameters in non-standard shapes
+type FlexibleRequest struct {
+	Method      string                `json:"method"`
+	URL         string                `json:"url"`
+	HTTPVersion string                `json:"httpVersion"`
+	Cookies     []har.Cookie          `json:"cookies"`
+	Headers     []har.Header          `json:"headers"`
+	QueryString []FlexibleQueryString `json:"queryString"`
+	PostData    *FlexiblePostData     `json:"postData,omitempty"`
+	HeadersSize FlexibleInt           `json:"headersSize"`
+	BodySize    FlexibleInt           `json:"bodySize"`
+}
+
+// ToStandardRequest converts FlexibleRequest to standard har.Request
+func (fr *FlexibleRequest) ToStandardRequest() *har.Request {
+	if fr == nil {
+		return nil
+	}
+
+	return &har.Request{
+		Method:      fr.Method,
+		URL:         fr.URL,
+		HTTPVersion: fr.HTTPVersion,
+		Cookies:     fr.Cookies,
+		Headers:     fr.Headers,
+		QueryString: fr.standardQueryString(),
+		PostData:    fr.PostData.ToStandardPostData(),
+		HeadersSize: int64(fr.HeadersSize),
+		BodySize:    int64(fr.BodySize),
+	}
+}
+
 // FlexibleTimings allows timing fields to be parsed as either int or float
 type FlexibleTimings struct {
-	Send    FlexibleTime `json:"send"`
-	Wait    FlexibleTime `json:"wait"`
-	Receive FlexibleTime `json:"receive"`
-	// Additional timing fields that might be in HAR files
-	Blocked FlexibleTime `json:"blocked,omitempty"`
-	DNS     FlexibleTime `json:"dns,omitempty"`
-	Connect FlexibleTime `json:"connect,omitempty"`
-	SSL     FlexibleTime `json:"ssl,omitempty"`
+	Send    FlexibleFloat `json:"send"`
+	Wait    FlexibleFloat `json:"wait"`
+	Receive FlexibleFloat `json:"receive"`
+	// Optional phases, -1 or missing when they do not apply
+	Blocked *FlexibleFloat `json:"blocked,omitempty"`
+	DNS     *FlexibleFloat `json:"dns,omitempty"`
+	Connect *FlexibleFloat `json:"connect,omitempty"`
+	SSL     *FlexibleFloat `json:"ssl,omitempty"`
 }
 
-// ToStandardTimings converts FlexibleTimings to standard har.Timings
+// ToStandardTimings converts FlexibleTimings to standard har.Timings, which only has room for
+// the send, wait and receive milliseconds. ToTimings keeps every phase.
 func (ft *FlexibleTimings) ToStandardTimings() *har.Timings {
 	if ft == nil {
 		return nil
@@ -76,22 +218,171 @@ func (ft *FlexibleTimings) ToStandardTimings() *har.Timings {
 	}
 }
 
+// ToTimings converts FlexibleTimings to Timings, keeping every phase and fractional milliseconds
+func (ft *FlexibleTimings) ToTimings() *Timings {
+	if ft == nil {
+		return nil
+	}
+	return &Timings{
+		Blocked: optionalPhase(ft.Blocked),
+		DNS:     optionalPhase(ft.DNS),
+		Connect: optionalPhase(ft.Connect),
+		SSL:     optionalPhase(ft.SSL),
+		Send:    phase(float64(ft.Send)),
+		Wait:    phase(float64(ft.Wait)),
+		Receive: phase(float64(ft.Receive)),
+	}
+}
+
+// standardQueryString converts the query string parameters to standard har.QueryString
+func (fr *FlexibleRequest) standardQueryString() []har.QueryString {
+	if fr.QueryString == nil {
+		return nil
+	}
+	queryString := make([]har.QueryString, len(fr.QueryString))
+	for i, param := range fr.QueryString {
+		queryString[i] = har.QueryString{Name: param.Name.Value, Value: param.Value.Value}
+	}
+	return queryString
+}
+
+// coercions counts the query string values and post parameters that had to be coerced
+func (fr *FlexibleRequest) coercions() (queryString, params int) {
+	for _, param := range fr.QueryString {
+		if param.Name.coerced || param.Value.coerced {
+			queryString++
+		}
+	}
+	if fr.PostData != nil {
+		for _, param := range fr.PostData.Params {
+			if param.coerced {
+				params++
+			}
+		}
+	}
+	return queryString, params
+}
+
+// FlexibleScalar handles string fields written as JSON numbers or booleans, null is read as empty
+type FlexibleScalar struct {
+	Value string
+	// coerced is set when the value was not a JSON string
+	coerced bool
+}
+
+// UnmarshalJSON implements custom unmarshaling for FlexibleScalar
+func (fs *FlexibleScalar) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &fs.Value); err == nil {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value.(type) {
+	case float64, bool:
+		fs.Value = string(bytes.TrimSpace(data))
+	default:
+		return fmt.Errorf("expected a string, got %s", data)
+	}
+	fs.coerced = true
+	return nil
+}
+
+// FlexibleQueryString is a query string parameter whose value may not be a string
+type FlexibleQueryString struct {
+	Name  FlexibleScalar `json:"name"`
+	Value FlexibleScalar `json:"value"`
+}
+
+// FlexiblePostData allows post parameters in non-standard shapes
+type FlexiblePostData struct {
+	MimeType string          `json:"mimeType"`
+	Params   []FlexibleParam `json:"params"`
+	Text     string          `json:"text"`
+	Encoding string          `json:"encoding,omitempty"`
+}
+
+// ToStandardPostData converts FlexiblePostData to standard har.PostData
+func (fp *FlexiblePostData) ToStandardPostData() *har.PostData {
+	if fp == nil {
+		return nil
+	}
+
+	postData := &har.PostData{
+		MimeType: fp.MimeType,
+		Text:     fp.Text,
+	}
+	// Binary bodies are base64-encoded, as in martian's PostData
+	if fp.Encoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(fp.Text); err == nil {
+			postData.Text = string(decoded)
+		}
+	}
+	if fp.Params != nil {
+		postData.Params = make([]har.Param, len(fp.Params))
+		for i, param := range fp.Params {
+			postData.Params[i] = param.Param
+		}
+	}
+
+	return postData
+}
+
+// FlexibleParam handles post parameters written as objects with non-string values,
+// or as bare "name=value" strings
+type FlexibleParam struct {
+	har.Param
+	// coerced is set when the parameter was not an object of strings
+	coerced bool
+}
+
+// UnmarshalJSON implements custom unmarshaling for FlexibleParam
+func (fp *FlexibleParam) UnmarshalJSON(data []byte) error {
+	var object struct {
+		Name        FlexibleScalar `json:"name"`
+		Value       FlexibleScalar `json:"value"`
+		FileName    string         `json:"fileName"`
+		ContentType string         `json:"contentType"`
+	}
+	if err := json.Unmarshal(data, &object); err == nil {
+		fp.Param = har.Param{
+			Name:        object.Name.Value,
+			Value:       object.Value.Value,
+			Filename:    object.FileName,
+			ContentType: object.ContentType,
+		}
+		fp.coerced = object.Name.coerced || object.Value.coerced
+		return nil
+	}
+
+	var scalar FlexibleScalar
+	if err := scalar.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("expected a parameter object or string, got %s", data)
+	}
+	name, value, _ := strings.Cut(scalar.Value, "=")
+	fp.Param = har.Param{Name: name, Value: value}
+	fp.coerced = true
+	return nil
+}
+
 // FlexibleResponse allows content.text to be parsed as either string or base64
 type FlexibleResponse struct {
-	Status      int              `json:"status"`
+	Status      FlexibleInt      `json:"status"`
 	StatusText  string           `json:"statusText"`
 	HTTPVersion string           `json:"httpVersion"`
 	Cookies     []har.Cookie     `json:"cookies,omitempty"`
 	Headers     []har.Header     `json:"headers,omitempty"`
 	Content     *FlexibleContent `json:"content"`
 	RedirectURL string           `json:"redirectURL"`
-	HeadersSize int64            `json:"headersSize"`
-	BodySize    int64            `json:"bodySize"`
+	HeadersSize FlexibleInt      `json:"headersSize"`
+	BodySize    FlexibleInt      `json:"bodySize"`
 }
 
 // FlexibleContent handles text field that can be either plain text or base64
 type FlexibleContent struct {
-	Size     int64           `json:"size"`
+	Size     FlexibleInt     `json:"size"`
 	MimeType string          `json:"mimeType"`
 	Text     json.RawMessage `json:"text,omitempty"`
 	Encoding string          `json:"encoding,omitempty"`
@@ -104,7 +395,7 @@ func (fc *FlexibleContent) ToStandardContent() *har.Content {
 	}
 
 	content := &har.Content{
-		Size:     fc.Size,
+		Size:     int64(fc.Size),
 		MimeType: fc.MimeType,
 		Encoding: fc.Encoding,
 	}
@@ -147,40 +438,90 @@ func (fr *FlexibleResponse) ToStandardResponse() *har.Response {
 	}
 
 	return &har.Response{
-		Status:      fr.Status,
+		Status:      int(fr.Status),
 		StatusText:  fr.StatusText,
 		HTTPVersion: fr.HTTPVersion,
 		Cookies:     fr.Cookies,
 		Headers:     fr.Headers,
 		Content:     fr.Content.ToStandardContent(),
 		RedirectURL: fr.RedirectURL,
-		HeadersSize: fr.HeadersSize,
-		BodySize:    fr.BodySize,
+		HeadersSize: int64(fr.HeadersSize),
+		BodySize:    int64(fr.BodySize),
+	}
+}
+
+// Warnings lists, per entry, the values that had to be coerced into the standard model
+func (fh *FlexibleHAR) Warnings() []ParseWarning {
+	var warnings []ParseWarning
+	for i := range fh.Log.Entries {
+		warnings = append(warnings, fh.Log.Entries[i].Warnings(formatRequestID(i))...)
+	}
+	return warnings
+}
+
+// Warnings lists the values of the entry that had to be coerced into the standard model
+func (fe *FlexibleEntry) Warnings(requestID string) []ParseWarning {
+	if fe.Request == nil {
+		return nil
+	}
+	var warnings []ParseWarning
+	queryString, params := fe.Request.coercions()
+	if queryString > 0 {
+		warnings = append(warnings, ParseWarning{
+			RequestID: requestID,
+			Message:   fmt.Sprintf("coerced %d non-string queryString values to strings", queryString),
+		})
+	}
+	if params > 0 {
+		warnings = append(warnings, ParseWarning{
+			RequestID: requestID,
+			Message:   fmt.Sprintf("coerced %d non-standard postData.params to name/value strings", params),
+		})
 	}
+	return warnings
 }
 
-// ToStandardHAR converts FlexibleHAR to standard har.HAR
+// ToStandardHAR converts FlexibleHAR to standard har.HAR, which has no room for pages
 func (fh *FlexibleHAR) ToStandardHAR() *har.HAR {
+	standardHAR, _ := fh.ToStandardHARWithPages()
+	return standardHAR
+}
+
+// ToStandardHARWithPages converts FlexibleHAR to standard har.HAR along with its pages and
+// the page of each entry
+func (fh *FlexibleHAR) ToStandardHARWithPages() (*har.HAR, *PageIndex) {
 	standardHAR := &har.HAR{
 		Log: &har.Log{
 			Version: fh.Log.Version,
-			Creator: fh.Log.Creator,
+			Creator: fh.Log.Creator.ToStandardCreator(),
 		},
 	}
 
 	// Convert flexible entries to standard entries
 	standardHAR.Log.Entries = make([]*har.Entry, len(fh.Log.Entries))
-	for i, flexEntry := range fh.Log.Entries {
-		standardHAR.Log.Entries[i] = &har.Entry{
-			ID:              flexEntry.ID,
-			StartedDateTime: flexEntry.StartedDateTime,
-			Time:            int64(flexEntry.Time),
-			Request:         flexEntry.Request,
-			Response:        flexEntry.Response.ToStandardResponse(),
-			Cache:           flexEntry.Cache,
-			Timings:         flexEntry.Timings.ToStandardTimings(),
+	index := &PageIndex{pages: fh.Log.Pages, refs: make(map[*har.Entry]string)}
+	for i := range fh.Log.Entries {
+		standardHAR.Log.Entries[i] = fh.Log.Entries[i].ToStandardEntry()
+		if fh.Log.Entries[i].PageRef != "" {
+			index.refs[standardHAR.Log.Entries[i]] = fh.Log.Entries[i].PageRef
 		}
 	}
+	for i := range index.pages {
+		normalizePageTimings(index.pages[i].PageTimings)
+	}
 
-	return standardHAR
+	return standardHAR, index
+}
+
+// ToStandardEntry converts FlexibleEntry to standard har.Entry
+func (fe *FlexibleEntry) ToStandardEntry() *har.Entry {
+	return &har.Entry{
+		ID:              fe.ID,
+		StartedDateTime: fe.StartedDateTime,
+		Time:            int64(fe.Time),
+		Request:         fe.Request.ToStandardRequest(),
+		Response:        fe.Response.ToStandardResponse(),
+		Cache:           fe.Cache,
+		Timings:         fe.Timings.ToStandardTimings(),
+	}
 }
@@ -0,0 +1,271 @@
+package har
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// defaultDataFlowMinLength is the shortest value tracked, shorter values match by chance
+	defaultDataFlowMinLength = 8
+	// dataFlowMaxLength is the longest value tracked, longer values are documents, not tokens
+	dataFlowMaxLength = 2048
+	// dataFlowValuePreview caps the length of the values reported on edges
+	dataFlowValuePreview = 64
+)
+
+// dataFlowIgnoredHeaders are response headers whose values are not application data
+var dataFlowIgnoredHeaders = map[string]bool{
+	"date":             true,
+	"expires":          true,
+	"last-modified":    true,
+	"content-type":     true,
+	"content-length":   true,
+	"content-encoding": true,
+	"cache-control":    true,
+	"server":           true,
+	"vary":             true,
+	"connection":       true,
+	"set-cookie":       true,
+}
+
+// DataFlowNode is a request taking part in a data flow
+type DataFlowNode struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+}
+
+// DataFlowEdge is a value produced by a response and reused by a later request
+type DataFlowEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Source is where the response carried the value: a JSONPath in the body, a header or a cookie
+	Source string `json:"source"`
+	// Target is where the request reused it: the URL, a header, a cookie or the body
+	Target string `json:"target"`
+	// Match is exact, or the transformation applied before reuse (base64, md5, sha1 or sha256)
+	Match string `json:"match"`
+	Value string `json:"value"`
+}
+
+// DataFlowGraph is the dependency graph of the requests of a capture
+type DataFlowGraph struct {
+	Nodes []DataFlowNode `json:"nodes"`
+	Edges []DataFlowEdge `json:"edges"`
+}
+
+// producedValue is a value first seen in a response
+type producedValue struct {
+	requestID string
+	source    string
+	value     string
+	// forms are the encodings the value can be reused as, by match name
+	forms [][2]string
+}
+
+// requestTarget is a place of a request where a produced value can be reused
+type requestTarget struct {
+	name  string
+	value string
+}
+
+// GetDataFlowGraph detects values of at least minLength characters that a response carries
+// (JSON body strings, headers and cookies) and a later request reuses in its URL, headers,
+// cookies or body, either as is or encoded (base64) or hashed (md5, sha1, sha256). Each
+// value is attributed to the first response carrying it, unless the request of that same
+// entry already sent it. Values reused in redacted headers or cookies are redacted.
+func (p *Parser) GetDataFlowGraph(harData *har.HAR, minLength int) *DataFlowGraph {
+	if minLength <= 0 {
+		minLength = defaultDataFlowMinLength
+	}
+
+	redaction := p.redaction()
+	graph := &DataFlowGraph{Nodes: []DataFlowNode{}, Edges: []DataFlowEdge{}}
+	nodes := make(map[string]bool)
+	addNode := func(index int) {
+		id := formatRequestID(index)
+		if nodes[id] {
+			return
+		}
+		nodes[id] = true
+		request := harData.Log.Entries[index].Request
+		graph.Nodes = append(graph.Nodes, DataFlowNode{RequestID: id, Method: request.Method, URL: request.URL})
+	}
+
+	seen := make(map[string]bool)
+	var produced []producedValue
+	producers := make(map[string]int)
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+
+		targets := requestTargets(entry.Request)
+		edges := make(map[DataFlowEdge]bool)
+		for _, value := range produced {
+			for _, target := range targets {
+				match, ok := value.matchIn(target.value)
+				if !ok {
+					continue
+				}
+				edge := DataFlowEdge{
+					From:   value.requestID,
+					To:     formatRequestID(i),
+					Source: value.source,
+					Target: target.name,
+					Match:  match,
+					Value:  redaction.text(previewFlowValue(value.value)),
+				}
+				if redaction.sensitiveTarget(target.name) || redaction.sensitiveTarget(value.source) {
+					edge.Value = redactedValue
+				}
+				if edges[edge] {
+					continue
+				}
+				edges[edge] = true
+				addNode(producers[value.requestID])
+				addNode(i)
+				graph.Edges = append(graph.Edges, edge)
+			}
+		}
+
+		for _, value := range responseValues(entry.Response, minLength) {
+			if seen[value.value] || targetsContain(targets, value.value) {
+				seen[value.value] = true
+				continue
+			}
+			seen[value.value] = true
+			value.requestID = formatRequestID(i)
+			value.forms = flowForms(value.value)
+			producers[value.requestID] = i
+			produced = append(produced, value)
+		}
+	}
+
+	return graph
+}
+
+// responseValues lists the values a response carries, in a stable order
+func responseValues(response *har.Response, minLength int) []producedValue {
+	if response == nil {
+		return nil
+	}
+
+	var values []producedValue
+	add := func(source, value string) {
+		if len(value) >= minLength && len(value) <= dataFlowMaxLength {
+			values = append(values, producedValue{source: source, value: value})
+		}
+	}
+
+	for _, header := range response.Headers {
+		if !dataFlowIgnoredHeaders[strings.ToLower(header.Name)] {
+			add("header "+header.Name, header.Value)
+		}
+	}
+	for _, cookie := range response.Cookies {
+		add("cookie "+cookie.Name, cookie.Value)
+	}
+
+	if response.Content != nil {
+		var document interface{}
+		decoder := json.NewDecoder(bytes.NewReader(response.Content.Text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err == nil {
+			walkJSONStrings(document, "$", func(path, value string) {
+				add("body "+path, value)
+			})
+		}
+	}
+
+	return values
+}
+
+// requestTargets lists the places of a request where a value can be reused
+func requestTargets(request *har.Request) []requestTarget {
+	targets := []requestTarget{{name: "url", value: request.URL}}
+	if decoded, err := url.QueryUnescape(request.URL); err == nil && decoded != request.URL {
+		targets = append(targets, requestTarget{name: "url", value: decoded})
+	}
+	for _, header := range request.Headers {
+		if !strings.EqualFold(header.Name, "cookie") {
+			targets = append(targets, requestTarget{name: "header " + header.Name, value: header.Value})
+		}
+	}
+	for _, cookie := range request.Cookies {
+		targets = append(targets, requestTarget{name: "cookie " + cookie.Name, value: cookie.Value})
+	}
+	if request.PostData != nil {
+		targets = append(targets, requestTarget{name: "body", value: request.PostData.Text})
+		for _, param := range request.PostData.Params {
+			targets = append(targets, requestTarget{name: "body", value: param.Value})
+		}
+	}
+	return targets
+}
+
+// targetsContain reports whether any target contains the value as is
+func targetsContain(targets []requestTarget, value string) bool {
+	for _, target := range targets {
+		if strings.Contains(target.value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// flowForms lists the encodings under which a value is looked for, exact first
+func flowForms(value string) [][2]string {
+	md5Sum := md5.Sum([]byte(value))
+	sha1Sum := sha1.Sum([]byte(value))
+	sha256Sum := sha256.Sum256([]byte(value))
+	forms := [][2]string{
+		{"exact", value},
+		{"base64", base64.StdEncoding.EncodeToString([]byte(value))},
+		{"base64", base64.RawURLEncoding.EncodeToString([]byte(value))},
+		{"sha256", base64.StdEncoding.EncodeToString(sha256Sum[:])},
+	}
+	for _, digest := range [][2]string{
+		{"md5", hex.EncodeToString(md5Sum[:])},
+		{"sha1", hex.EncodeToString(sha1Sum[:])},
+		{"sha256", hex.EncodeToString(sha256Sum[:])},
+	} {
+		forms = append(forms, digest, [2]string{digest[0], strings.ToUpper(digest[1])})
+	}
+	return forms
+}
+
+// matchIn returns how the value appears in text, if it does
+func (v producedValue) matchIn(text string) (string, bool) {
+	for _, form := range v.forms {
+		if strings.Contains(text, form[1]) {
+			return form[0], true
+		}
+	}
+	return "", false
+}
+
+// sensitiveTarget reports whether a source or target is a redacted header or cookie
+func (r *redactor) sensitiveTarget(name string) bool {
+	kind, key, _ := strings.Cut(name, " ")
+	return (kind == "cookie" && r.sensitiveCookie(key)) || (kind == "header" && r.sensitiveHeader(key))
+}
+
+// previewFlowValue shortens long values
+func previewFlowValue(value string) string {
+	if len(value) <= dataFlowValuePreview {
+		return value
+	}
+	return fmt.Sprintf("%s... (%d characters)", value[:dataFlowValuePreview], len(value))
+}
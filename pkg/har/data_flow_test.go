@@ -0,0 +1,48 @@
+package har
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDataFlowGraphLinksReusedValues(t *testing.T) {
+	parser := NewParser()
+	login := newTestResponseEntry("https://api.example.com/login", "application/json",
+		`{"token": "tok-abcdef123456", "user": {"id": "user-98765432", "name": "al"}}`)
+	login.Response.Headers = append(login.Response.Headers, har.Header{Name: "X-Trace-Id", Value: "trace-00112233"})
+	login.Response.Headers = append(login.Response.Headers, har.Header{Name: "Date", Value: "Mon, 15 Jan 2024 10:30:00 GMT"})
+
+	profile := newTestEntry("GET", "https://api.example.com/users/user-98765432",
+		har.Header{Name: "Authorization", Value: "Bearer tok-abcdef123456"})
+	digest := sha256.Sum256([]byte("trace-00112233"))
+	signed := newTestEntry("POST", "https://api.example.com/events")
+	signed.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"sig": "` + hex.EncodeToString(digest[:]) + `"}`}
+	unrelated := newTestEntry("GET", "https://api.example.com/status?since=Mon, 15 Jan 2024 10:30:00 GMT")
+
+	graph := parser.GetDataFlowGraph(newTestHAR(login, profile, signed, unrelated), 0)
+
+	assert.Equal(t, []DataFlowEdge{
+		{From: "request_0", To: "request_1", Source: "body $.token", Target: "header Authorization", Match: "exact", Value: redactedValue},
+		{From: "request_0", To: "request_1", Source: "body $.user.id", Target: "url", Match: "exact", Value: "user-98765432"},
+		{From: "request_0", To: "request_2", Source: "header X-Trace-Id", Target: "body", Match: "sha256", Value: "trace-00112233"},
+	}, graph.Edges)
+	require.Len(t, graph.Nodes, 3)
+	assert.Equal(t, DataFlowNode{RequestID: "request_0", Method: "GET", URL: "https://api.example.com/login"}, graph.Nodes[0])
+}
+
+func TestGetDataFlowGraphIgnoresEchoedAndShortValues(t *testing.T) {
+	parser := NewParser()
+	search := newTestResponseEntry("https://api.example.com/search?q=needle-in-haystack", "application/json",
+		`{"query": "needle-in-haystack", "page": "short"}`)
+	next := newTestEntry("GET", "https://api.example.com/search?q=needle-in-haystack&page=short")
+
+	graph := parser.GetDataFlowGraph(newTestHAR(search, next), 0)
+
+	assert.Empty(t, graph.Edges)
+	assert.Empty(t, graph.Nodes)
+}
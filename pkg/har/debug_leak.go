@@ -0,0 +1,223 @@
+package har
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Debug information leak kinds
+const (
+	// LeakStackTrace is a stack trace of a server runtime
+	LeakStackTrace = "stack_trace"
+	// LeakInternalHost is a private IP address or a hostname of an internal domain such as .internal
+	// or .corp, that the capture never requests
+	LeakInternalHost = "internal_host"
+	// LeakFilePath is an absolute path to a source file on the server
+	LeakFilePath = "file_path"
+	// LeakDebugPage is the error page of a framework running in debug mode
+	LeakDebugPage = "debug_page"
+	// LeakDebugHeader is a header exposing debug tokens or the versions of the server software
+	LeakDebugHeader = "debug_header"
+)
+
+const (
+	// maxDebugLeakScan caps the number of bytes of each body scanned
+	maxDebugLeakScan = 1024 * 1024
+	// maxDebugLeakEvidence caps the length of the evidence reported
+	maxDebugLeakEvidence = 120
+)
+
+var (
+	// internalHostPattern matches the hostnames of internal domains and private IPv4 addresses
+	internalHostPattern = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:internal|local|localdomain|corp|lan|intranet|intra|private)\b|\b(?:10\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])|192\.168)\.\d{1,3}\.\d{1,3}\b`)
+	// filePathPattern matches absolute paths to source files, not preceded by a character that
+	// would make them part of a URL
+	filePathPattern = regexp.MustCompile(`(?:^|[\s"'(=\[>])((?:/(?:home|root|var/www|usr/src|usr/local|opt|srv|app|Users|tmp)(?:/[\w.@-]+)+|[A-Za-z]:(?:\\{1,2}[\w .@-]+)+)\.(?:php|py|rb|java|kt|scala|js|mjs|ts|go|cs|vb|jsp|aspx?|pl|exs?|rs))\b`)
+	// versionPattern matches a product version such as nginx/1.18.0
+	versionPattern = regexp.MustCompile(`\d+\.\d+`)
+)
+
+// debugPageMarkers identify the error pages of frameworks running in debug mode
+var debugPageMarkers = []struct {
+	framework string
+	marker    string
+}{
+	{"Django", "You're seeing this error because you have"},
+	{"Werkzeug", "Werkzeug Debugger"},
+	{"Laravel", "Whoops! There was an error."},
+	{"Laravel", `Illuminate\`},
+	{"Rails", "Action Controller: Exception caught"},
+	{"Spring Boot", "Whitelabel Error Page"},
+	{"ASP.NET", "<title>Runtime Error</title>"},
+	{"ASP.NET", "Server Error in '/' Application"},
+	{"Symfony", "sf-toolbar"},
+	{"Symfony", `Symfony\Component\`},
+	{"PHP", "<title>phpinfo()</title>"},
+	{"Tomcat", "Exception Report"},
+}
+
+// debugHeaders are the response headers reported whenever present
+var debugHeaders = []string{
+	"X-Debug-Token",
+	"X-Debug-Token-Link",
+	"X-AspNet-Version",
+	"X-AspNetMvc-Version",
+	"X-SourceFiles",
+	"X-Powered-By",
+}
+
+// versionedHeaders are the response headers reported when they give a version
+var versionedHeaders = []string{"Server", "X-Generator", "X-Runtime-Version"}
+
+// DebugLeak is debug information a response exposes
+type DebugLeak struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Status    int    `json:"status"`
+	Kind      string `json:"kind"`
+	// Location is response.body or response.headers.<name>
+	Location string `json:"location"`
+	// Detail names the runtime, framework or header, or gives the host or path leaked
+	Detail string `json:"detail"`
+	// Evidence is the redacted text found, cut to 120 bytes
+	Evidence string `json:"evidence"`
+}
+
+// DebugLeakReport lists the debug information exposed by the responses
+type DebugLeakReport struct {
+	Checked int            `json:"checked"`
+	ByKind  map[string]int `json:"by_kind"`
+	Leaks   []DebugLeak    `json:"leaks"`
+}
+
+// GetDebugLeaks scans the response headers and bodies for debug information: stack traces,
+// internal hostnames and private IP addresses, paths to source files, error pages of frameworks
+// running in debug mode and headers exposing debug tokens or software versions. Hosts requested
+// in the capture are not reported as internal. Bodies are decoded first and only the first MiB
+// of each is scanned, JavaScript and CSS assets being left out. Each kind is reported at most
+// once per location of an entry.
+func (p *Parser) GetDebugLeaks(harData *har.HAR) *DebugLeakReport {
+	redaction := p.redaction()
+	requested := make(map[string]bool)
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		if u, err := url.Parse(entry.Request.URL); err == nil {
+			requested[strings.ToLower(u.Hostname())] = true
+		}
+	}
+
+	report := &DebugLeakReport{ByKind: make(map[string]int), Leaks: []DebugLeak{}}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil || entry.Response.Status == 0 {
+			continue
+		}
+		report.Checked++
+		for _, leak := range debugLeaks(entry.Response, requested) {
+			leak.RequestID = formatRequestID(i)
+			leak.Method = entry.Request.Method
+			leak.URL = redaction.text(entry.Request.URL)
+			leak.Status = entry.Response.Status
+			leak.Detail = redaction.text(leak.Detail)
+			leak.Evidence = truncateBody(redaction.text(leak.Evidence), maxDebugLeakEvidence)
+			report.ByKind[leak.Kind]++
+			report.Leaks = append(report.Leaks, leak)
+		}
+	}
+	return report
+}
+
+// debugLeaks returns the debug information exposed by the headers and body of a response
+func debugLeaks(response *har.Response, requested map[string]bool) []DebugLeak {
+	var leaks []DebugLeak
+	for _, name := range debugHeaders {
+		if value := headerValue(response.Headers, name); value != "" {
+			leaks = append(leaks, DebugLeak{Kind: LeakDebugHeader, Location: "response.headers." + name, Detail: name, Evidence: name + ": " + value})
+		}
+	}
+	for _, name := range versionedHeaders {
+		if value := headerValue(response.Headers, name); versionPattern.MatchString(value) {
+			leaks = append(leaks, DebugLeak{Kind: LeakDebugHeader, Location: "response.headers." + name, Detail: name, Evidence: name + ": " + value})
+		}
+	}
+	for _, header := range response.Headers {
+		// Hosts in these headers point at where the client goes, not at the infrastructure
+		switch strings.ToLower(header.Name) {
+		case "location", "content-location", "link", "content-security-policy", "set-cookie":
+			continue
+		}
+		location := "response.headers." + header.Name
+		if host := internalHost(header.Value, requested); host != "" {
+			leaks = append(leaks, DebugLeak{Kind: LeakInternalHost, Location: location, Detail: host, Evidence: header.Name + ": " + header.Value})
+		}
+		if match := filePathPattern.FindStringSubmatch(header.Value); match != nil {
+			leaks = append(leaks, DebugLeak{Kind: LeakFilePath, Location: location, Detail: match[1], Evidence: header.Name + ": " + header.Value})
+		}
+	}
+
+	if response.Content == nil || len(response.Content.Text) == 0 {
+		return leaks
+	}
+	switch mimeTypeFamily(responseMimeType(response)) {
+	case familyJavaScript, familyCSS:
+		return leaks
+	}
+	body := DecodeBody(response.Content.Text, response.Headers, response.Content.MimeType).Text
+	body = body[:runeEnd(body, min(len(body), maxDebugLeakScan))]
+	if !isPrintableText(body) {
+		return leaks
+	}
+	text := string(body)
+	for _, stackTrace := range stackTracePatterns {
+		if location := stackTrace.pattern.FindStringIndex(text); location != nil {
+			leaks = append(leaks, DebugLeak{Kind: LeakStackTrace, Location: "response.body", Detail: stackTrace.runtime, Evidence: text[location[0]:location[1]]})
+			break
+		}
+	}
+	if host := internalHost(text, requested); host != "" {
+		leaks = append(leaks, DebugLeak{Kind: LeakInternalHost, Location: "response.body", Detail: host, Evidence: excerptAround(text, host)})
+	}
+	if match := filePathPattern.FindStringSubmatch(text); match != nil {
+		leaks = append(leaks, DebugLeak{Kind: LeakFilePath, Location: "response.body", Detail: match[1], Evidence: excerptAround(text, match[1])})
+	}
+	for _, page := range debugPageMarkers {
+		if strings.Contains(text, page.marker) {
+			leaks = append(leaks, DebugLeak{Kind: LeakDebugPage, Location: "response.body", Detail: page.framework, Evidence: page.marker})
+			break
+		}
+	}
+	return leaks
+}
+
+// internalHost returns the first internal host found in the text that the capture never requests
+func internalHost(text string, requested map[string]bool) string {
+	for _, host := range internalHostPattern.FindAllString(text, -1) {
+		if !requested[strings.ToLower(host)] {
+			return host
+		}
+	}
+	return ""
+}
+
+// excerptAround returns the line of the text holding the first occurrence of the value
+func excerptAround(text, value string) string {
+	index := strings.Index(text, value)
+	if index < 0 {
+		return value
+	}
+	start := strings.LastIndexByte(text[:index], '\n') + 1
+	end := len(text)
+	if newline := strings.IndexByte(text[index:], '\n'); newline >= 0 {
+		end = index + newline
+	}
+	// Keep the value in the evidence when the line is long
+	if index-start > maxDebugLeakEvidence/2 {
+		start = runeStart([]byte(text), index-maxDebugLeakEvidence/2)
+	}
+	return strings.TrimSpace(text[start:end])
+}
@@ -0,0 +1,91 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDebugLeaksScansBodies(t *testing.T) {
+	parser := NewParser()
+	traceback := newTestResponseEntry("https://staging.example.com/api/orders", "text/plain",
+		"Traceback (most recent call last):\n  File \"/app/orders/views.py\", line 42, in create\nConnectionError: db-primary.corp:5432 refused")
+	traceback.Response.Status = 500
+	django := newTestResponseEntry("https://staging.example.com/admin", "text/html",
+		"<html><body>You're seeing this error because you have <code>DEBUG = True</code></body></html>")
+	archive := newTestHAR(
+		traceback,
+		django,
+		newTestResponseEntry("https://staging.example.com/api/config", "application/json", `{"upstream": "http://staging.example.com/api", "cache": "10.0.3.17"}`),
+		newTestResponseEntry("https://staging.example.com/app.js", "application/javascript", `chrome.storage.local.get("/app/main.js")`),
+		newTestResponseEntry("https://staging.example.com/page", "text/html", `<a href="https://cdn.example.com/app/main.js">main</a>`),
+	)
+
+	report := parser.GetDebugLeaks(archive)
+
+	assert.Equal(t, 5, report.Checked)
+	require.Len(t, report.Leaks, 5)
+	assert.Equal(t, DebugLeak{
+		RequestID: "request_0",
+		Method:    "GET",
+		URL:       "https://staging.example.com/api/orders",
+		Status:    500,
+		Kind:      LeakStackTrace,
+		Location:  "response.body",
+		Detail:    "Python",
+		Evidence:  "Traceback (most recent call last)",
+	}, report.Leaks[0])
+	assert.Equal(t, LeakInternalHost, report.Leaks[1].Kind)
+	assert.Equal(t, "db-primary.corp", report.Leaks[1].Detail)
+	assert.Equal(t, "ConnectionError: db-primary.corp:5432 refused", report.Leaks[1].Evidence)
+	assert.Equal(t, LeakFilePath, report.Leaks[2].Kind)
+	assert.Equal(t, "/app/orders/views.py", report.Leaks[2].Detail)
+	assert.Equal(t, LeakDebugPage, report.Leaks[3].Kind)
+	assert.Equal(t, "Django", report.Leaks[3].Detail)
+	assert.Equal(t, "request_2", report.Leaks[4].RequestID)
+	assert.Equal(t, "10.0.3.17", report.Leaks[4].Detail)
+	assert.Equal(t, map[string]int{LeakStackTrace: 1, LeakInternalHost: 2, LeakFilePath: 1, LeakDebugPage: 1}, report.ByKind)
+}
+
+func TestGetDebugLeaksScansHeaders(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("GET", "https://example.com/")
+	entry.Response.Headers = []har.Header{
+		{Name: "Server", Value: "nginx/1.18.0 (Ubuntu)"},
+		{Name: "X-Powered-By", Value: "PHP/7.4.3"},
+		{Name: "X-Debug-Token", Value: "a1b2c3"},
+		{Name: "X-Backend", Value: "ip-10-0-1-12.ec2.internal"},
+		{Name: "Location", Value: "http://intranet.corp/login"},
+	}
+	quiet := newTestEntry("GET", "https://example.com/quiet")
+	quiet.Response.Headers = []har.Header{{Name: "Server", Value: "cloudflare"}}
+	archive := newTestHAR(entry, quiet)
+
+	report := parser.GetDebugLeaks(archive)
+
+	var details []string
+	for _, leak := range report.Leaks {
+		assert.Equal(t, "request_0", leak.RequestID)
+		details = append(details, leak.Kind+" "+leak.Location+" "+leak.Detail)
+	}
+	assert.Equal(t, []string{
+		"debug_header response.headers.X-Debug-Token X-Debug-Token",
+		"debug_header response.headers.X-Powered-By X-Powered-By",
+		"debug_header response.headers.Server Server",
+		"internal_host response.headers.X-Backend ip-10-0-1-12.ec2.internal",
+	}, details)
+	assert.Equal(t, "Server: nginx/1.18.0 (Ubuntu)", report.Leaks[2].Evidence)
+}
+
+func TestGetDebugLeaksIgnoresRequestedHosts(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestResponseEntry("http://api.staging.internal/users", "application/json", `{"next": "http://api.staging.internal/users?page=2"}`),
+	)
+
+	report := parser.GetDebugLeaks(archive)
+
+	assert.Empty(t, report.Leaks)
+}
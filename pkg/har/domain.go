@@ -0,0 +1,123 @@
+package har
+
+import (
+	_ "embed"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"sync"
+)
+
+// SiteHostPrefix prefixes host patterns matching every host of a registrable domain,
+// e.g. site:example.co.uk matching api.eu.example.co.uk
+const SiteHostPrefix = "site:"
+
+// publicSuffixData is the bundled public suffix list
+//
+//go:embed public_suffix_list.dat
+var publicSuffixData string
+
+// publicSuffixRules holds the rules of the public suffix list, parsed on first use
+var publicSuffixRules = sync.OnceValue(func() *suffixRules {
+	return parseSuffixRules(publicSuffixData)
+})
+
+// suffixRules are the rules of a public suffix list, by suffix
+type suffixRules struct {
+	suffixes   map[string]bool
+	wildcards  map[string]bool
+	exceptions map[string]bool
+}
+
+// parseSuffixRules parses a list in the format of the Public Suffix List
+func parseSuffixRules(data string) *suffixRules {
+	rules := &suffixRules{suffixes: map[string]bool{}, wildcards: map[string]bool{}, exceptions: map[string]bool{}}
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		rule := strings.ToLower(fields[0])
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			rules.exceptions[rule[1:]] = true
+		case strings.HasPrefix(rule, "*."):
+			rules.wildcards[rule[2:]] = true
+		default:
+			rules.suffixes[rule] = true
+		}
+	}
+	return rules
+}
+
+// publicSuffix returns the number of trailing labels forming the public suffix, the longest
+// matching rule winning and exceptions taking precedence
+func (r *suffixRules) publicSuffix(labels []string) int {
+	for i := range labels {
+		candidate := strings.Join(labels[i:], ".")
+		if r.exceptions[candidate] {
+			return len(labels) - i - 1
+		}
+		if r.suffixes[candidate] {
+			return len(labels) - i
+		}
+		if i+1 < len(labels) && r.wildcards[strings.Join(labels[i+1:], ".")] {
+			return len(labels) - i
+		}
+	}
+	return 1
+}
+
+// hostLabels returns the lowercased labels of a host name
+func hostLabels(host string) []string {
+	return strings.Split(strings.TrimSuffix(strings.ToLower(host), "."), ".")
+}
+
+// PublicSuffix returns the public suffix of a host according to the bundled public suffix list,
+// such as co.uk for api.example.co.uk
+func PublicSuffix(host string) string {
+	labels := hostLabels(host)
+	return strings.Join(labels[len(labels)-publicSuffixRules().publicSuffix(labels):], ".")
+}
+
+// RegistrableDomain returns the registrable domain of a host, its public suffix and the label
+// before it, such as example.co.uk for api.eu.example.co.uk. IP addresses and hosts that are
+// public suffixes themselves, such as localhost, are returned as is.
+func RegistrableDomain(host string) string {
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return strings.ToLower(host)
+	}
+	labels := hostLabels(host)
+	keep := publicSuffixRules().publicSuffix(labels) + 1
+	if len(labels) <= keep {
+		return strings.Join(labels, ".")
+	}
+	return strings.Join(labels[len(labels)-keep:], ".")
+}
+
+// ValidateHostPattern checks that a host pattern is a host name, a glob or a site: pattern
+func ValidateHostPattern(pattern string) error {
+	if site, ok := strings.CutPrefix(pattern, SiteHostPrefix); ok {
+		if strings.TrimSpace(site) == "" {
+			return fmt.Errorf("invalid host pattern %q: missing domain after %s", pattern, SiteHostPrefix)
+		}
+		return nil
+	}
+	if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+		return fmt.Errorf("invalid host glob %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// MatchHost reports whether the host name matches the pattern, ignoring case: a host name,
+// a glob such as *.example.com matching the subdomains at any depth, or site: followed by a
+// domain matching every host of its registrable domain, so site:example.co.uk matches
+// api.eu.example.co.uk but not other.co.uk
+func MatchHost(pattern, host string) bool {
+	if site, ok := strings.CutPrefix(pattern, SiteHostPrefix); ok {
+		return RegistrableDomain(host) == RegistrableDomain(strings.TrimSpace(site))
+	}
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(strings.TrimSuffix(host, ".")))
+	return matched
+}
@@ -0,0 +1,60 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrableDomain(t *testing.T) {
+	assert.Equal(t, "example.com", RegistrableDomain("api.eu.example.com"))
+	assert.Equal(t, "example.co.uk", RegistrableDomain("api.eu.example.co.uk"))
+	assert.Equal(t, "shop.co.uk", RegistrableDomain("WWW.Shop.co.uk."))
+	assert.Equal(t, "alice.github.io", RegistrableDomain("docs.alice.github.io"))
+	assert.Equal(t, "localhost", RegistrableDomain("localhost"))
+	assert.Equal(t, "co.uk", RegistrableDomain("co.uk"))
+	assert.Equal(t, "192.168.1.10", RegistrableDomain("192.168.1.10"))
+	assert.Equal(t, "[::1]", RegistrableDomain("[::1]"))
+}
+
+func TestPublicSuffixWildcardsAndExceptions(t *testing.T) {
+	assert.Equal(t, "co.uk", PublicSuffix("api.example.co.uk"))
+	assert.Equal(t, "example", PublicSuffix("acs.bank.example"))
+	assert.Equal(t, "shop.ck", PublicSuffix("www.shop.ck"))
+	assert.Equal(t, "ck", PublicSuffix("www.ck"))
+	assert.Equal(t, "www.ck", RegistrableDomain("www.ck"))
+	assert.Equal(t, "kawasaki.jp", PublicSuffix("city.kawasaki.jp"))
+	assert.Equal(t, "foo.kawasaki.jp", PublicSuffix("bar.foo.kawasaki.jp"))
+}
+
+func TestParseSuffixRules(t *testing.T) {
+	rules := parseSuffixRules("// comment\n\nuk\nco.uk  trailing text\n*.ck\n!www.ck\n")
+
+	assert.Equal(t, map[string]bool{"uk": true, "co.uk": true}, rules.suffixes)
+	assert.Equal(t, map[string]bool{"ck": true}, rules.wildcards)
+	assert.Equal(t, map[string]bool{"www.ck": true}, rules.exceptions)
+}
+
+func TestMatchHost(t *testing.T) {
+	assert.True(t, MatchHost("api.example.com", "API.example.com"))
+	assert.False(t, MatchHost("api.example.com", "example.com"))
+
+	assert.True(t, MatchHost("*.example.com", "api.example.com"))
+	assert.True(t, MatchHost("*.example.com", "api.eu.example.com"))
+	assert.False(t, MatchHost("*.example.com", "example.com"))
+	assert.False(t, MatchHost("*.example.com", "example.com.evil.net"))
+
+	assert.True(t, MatchHost("site:example.co.uk", "api.eu.example.co.uk"))
+	assert.True(t, MatchHost("site:example.co.uk", "example.co.uk"))
+	assert.True(t, MatchHost("site:www.example.co.uk", "cdn.example.co.uk"))
+	assert.False(t, MatchHost("site:example.co.uk", "other.co.uk"))
+	assert.False(t, MatchHost("site:alice.github.io", "bob.github.io"))
+}
+
+func TestValidateHostPattern(t *testing.T) {
+	require.NoError(t, ValidateHostPattern("*.example.com"))
+	require.NoError(t, ValidateHostPattern("site:example.co.uk"))
+	assert.Error(t, ValidateHostPattern("site:"))
+	assert.Error(t, ValidateHostPattern("[example.com"))
+}
@@ -0,0 +1,393 @@
+package har
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// maxDuplicateTrackingEvents caps the duplicated events listed in the duplicate tracking report
+const maxDuplicateTrackingEvents = 50
+
+var (
+	// trackingUserFields are the normalized names of the fields identifying the user of a tracking
+	// event, the application's own identifiers first, then the anonymous ones vendors assign
+	trackingUserFields = []string{
+		"userid", "uid", "distinctid", "email", "useremail", "externalid", "customerid",
+		"anonymousid", "clientid", "cid", "deviceid",
+	}
+	// trackingEventFields are the normalized names of the fields naming a tracking event, hit types last
+	trackingEventFields = []string{"event", "eventname", "en", "ea", "eventaction", "eventtype", "action", "t", "type"}
+	// trackingLocationFields are the normalized names of the fields giving the page a tracking event occurred on
+	trackingLocationFields = []string{"dl", "pagelocation", "pageurl", "currenturl", "url", "location", "href", "dp", "pagepath", "path"}
+)
+
+// TrackingVendor sums the tracking requests sent to an analytics vendor
+type TrackingVendor struct {
+	// Vendor is the registrable domain of the collector
+	Vendor   string `json:"vendor"`
+	Requests int    `json:"requests"`
+	// Events counts the events identifying both their user and their name
+	Events int   `json:"events"`
+	Bytes  int64 `json:"bytes"`
+}
+
+// DuplicateTrackingEvent is an event sent to several analytics vendors
+type DuplicateTrackingEvent struct {
+	// Fingerprint is the truncated SHA-256 of the user, event name and page of the event, the user
+	// identifier never being reported as is
+	Fingerprint string `json:"fingerprint"`
+	Event       string `json:"event"`
+	Location    string `json:"location,omitempty"`
+	// Vendors lists the vendors receiving the event, in the order they first did
+	Vendors     []string `json:"vendors"`
+	Occurrences int      `json:"occurrences"`
+	RequestIDs  []string `json:"request_ids"`
+	Bytes       int64    `json:"bytes"`
+	// RedundantBytes are the bytes sent to the vendors but the first one
+	RedundantBytes int64 `json:"redundant_bytes"`
+}
+
+// DuplicateTrackingReport lists the tracking events sent to more than one analytics vendor
+type DuplicateTrackingReport struct {
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+	// Events counts the events identifying both their user and their name
+	Events  int              `json:"events"`
+	Vendors []TrackingVendor `json:"vendors"`
+	// DuplicatedEvents counts the events sent to more than one vendor, listed in Duplicates by
+	// decreasing redundant bytes
+	DuplicatedEvents int                      `json:"duplicated_events"`
+	RedundantBytes   int64                    `json:"redundant_bytes"`
+	Duplicates       []DuplicateTrackingEvent `json:"duplicates"`
+	Truncated        bool                     `json:"truncated,omitempty"`
+}
+
+// trackingEvent groups the occurrences of an event fingerprint
+type trackingEvent struct {
+	DuplicateTrackingEvent
+	position      int
+	firstVendor   string
+	vendorsBytes  map[string]int64
+	requestsAdded map[string]bool
+}
+
+// ReportDuplicateTracking flags the identical events sent to several analytics vendors. Tracking
+// requests are the analytics hits and pings of the beacon index, or the requests to known
+// analytics collectors when it is nil, their vendor being the registrable domain of their host.
+// Their events are read from the query string and the form, JSON or line separated body,
+// batched JSON events and base64 encoded JSON parameters included. Fields are matched on their
+// name, regardless of case and separators, and events hashed on their user identifier, event
+// name and page, so that the same event reaches the same fingerprint whatever the vendor's
+// naming. The bytes of a request, its URL and body, are shared among its events, and the bytes
+// sent to vendors beyond the first one receiving an event are reported as redundant.
+func (p *Parser) ReportDuplicateTracking(harData *har.HAR, beacons *BeaconIndex) *DuplicateTrackingReport {
+	report := &DuplicateTrackingReport{Vendors: []TrackingVendor{}, Duplicates: []DuplicateTrackingEvent{}}
+	redaction := p.redaction()
+
+	vendors := make(map[string]*TrackingVendor)
+	var vendorOrder []string
+	events := make(map[string]*trackingEvent)
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || !isTracking(entry, beacons) {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		name := RegistrableDomain(u.Hostname())
+		vendor, ok := vendors[name]
+		if !ok {
+			vendor = &TrackingVendor{Vendor: name}
+			vendors[name] = vendor
+			vendorOrder = append(vendorOrder, name)
+		}
+		size := int64(len(entry.Request.URL)) + requestBodySize(entry.Request)
+		vendor.Requests++
+		vendor.Bytes += size
+		report.Requests++
+		report.Bytes += size
+
+		records := trackingRecords(entry.Request, u)
+		var identified []map[string]string
+		for _, record := range records {
+			if firstField(record, trackingUserFields) != "" && firstField(record, trackingEventFields) != "" {
+				identified = append(identified, record)
+			}
+		}
+		requestID := formatRequestID(i)
+		for _, record := range identified {
+			eventName := firstField(record, trackingEventFields)
+			location := trackingLocation(firstField(record, trackingLocationFields))
+			hash := sha256.Sum256([]byte(firstField(record, trackingUserFields) + "\x00" + trackingEventKey(eventName) + "\x00" + location))
+			fingerprint := hex.EncodeToString(hash[:6])
+			event, ok := events[fingerprint]
+			if !ok {
+				event = &trackingEvent{
+					DuplicateTrackingEvent: DuplicateTrackingEvent{Fingerprint: fingerprint, Event: redaction.text(eventName), Location: redaction.text(location)},
+					position:               i,
+					firstVendor:            name,
+					vendorsBytes:           make(map[string]int64),
+					requestsAdded:          make(map[string]bool),
+				}
+				events[fingerprint] = event
+			}
+			if _, ok := event.vendorsBytes[name]; !ok {
+				event.Vendors = append(event.Vendors, name)
+			}
+			share := size / int64(len(identified))
+			event.vendorsBytes[name] += share
+			event.Bytes += share
+			event.Occurrences++
+			if !event.requestsAdded[requestID] {
+				event.requestsAdded[requestID] = true
+				event.RequestIDs = append(event.RequestIDs, requestID)
+			}
+			vendor.Events++
+			report.Events++
+		}
+	}
+
+	for _, name := range vendorOrder {
+		report.Vendors = append(report.Vendors, *vendors[name])
+	}
+	var duplicates []*trackingEvent
+	for _, event := range events {
+		if len(event.Vendors) > 1 {
+			event.RedundantBytes = event.Bytes - event.vendorsBytes[event.firstVendor]
+			duplicates = append(duplicates, event)
+			report.DuplicatedEvents++
+			report.RedundantBytes += event.RedundantBytes
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].RedundantBytes != duplicates[j].RedundantBytes {
+			return duplicates[i].RedundantBytes > duplicates[j].RedundantBytes
+		}
+		if duplicates[i].position != duplicates[j].position {
+			return duplicates[i].position < duplicates[j].position
+		}
+		return duplicates[i].Fingerprint < duplicates[j].Fingerprint
+	})
+	for _, event := range duplicates {
+		if len(report.Duplicates) == maxDuplicateTrackingEvents {
+			report.Truncated = true
+			break
+		}
+		report.Duplicates = append(report.Duplicates, event.DuplicateTrackingEvent)
+	}
+	return report
+}
+
+// isTracking reports whether the entry is an analytics hit or a ping, from the beacon index or,
+// when nil, from the collector its URL targets
+func isTracking(entry *har.Entry, beacons *BeaconIndex) bool {
+	if beacons == nil {
+		return isAnalytics(entry.Request.URL)
+	}
+	kind := beacons.Kind(entry)
+	return kind == BeaconAnalytics || kind == BeaconPing
+}
+
+// trackingRecords returns the events a tracking request carries, as maps of their normalized
+// field names to their values. Query parameters apply to every event of the body.
+func trackingRecords(request *har.Request, u *url.URL) []map[string]string {
+	base := make(map[string]string)
+	var documents []interface{}
+	form := requestForm(request)
+	for _, values := range []url.Values{u.Query(), form} {
+		for name, value := range values {
+			if len(value) == 0 {
+				continue
+			}
+			if document, ok := trackingJSON(value[0]); ok {
+				documents = append(documents, document)
+			} else {
+				setTrackingField(base, name, value[0])
+			}
+		}
+	}
+
+	var records []map[string]string
+	for _, document := range documents {
+		records = append(records, jsonTrackingRecords(document, base)...)
+	}
+	if request.PostData != nil && len(form) == 0 {
+		text := strings.TrimSpace(request.PostData.Text)
+		if document, ok := trackingJSON(text); ok {
+			records = append(records, jsonTrackingRecords(document, base)...)
+		} else if text != "" && !strings.ContainsAny(text, " \t") {
+			// Batched hits of measurement protocols send one query string per line
+			for _, line := range strings.Split(text, "\n") {
+				values, err := url.ParseQuery(strings.TrimSpace(line))
+				if err != nil || len(values) == 0 {
+					continue
+				}
+				record := copyTrackingFields(base)
+				for name, value := range values {
+					setTrackingField(record, name, value[0])
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	if len(records) == 0 {
+		records = append(records, base)
+	}
+	return records
+}
+
+// trackingJSON decodes a JSON object or array, possibly base64 encoded as some vendors send them
+func trackingJSON(value string) (interface{}, bool) {
+	data := []byte(strings.TrimSpace(value))
+	if len(data) == 0 {
+		return nil, false
+	}
+	if data[0] != '{' && data[0] != '[' {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			if decoded, err = base64.URLEncoding.DecodeString(string(data)); err != nil {
+				return nil, false
+			}
+		}
+		data = bytes.TrimSpace(decoded)
+		if len(data) == 0 || data[0] != '{' && data[0] != '[' {
+			return nil, false
+		}
+	}
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false
+	}
+	return document, true
+}
+
+// jsonTrackingRecords returns the events of a JSON document: the items of an array, the objects
+// of the first array of objects a batch holds, along the other fields of the batch, or else the
+// object itself
+func jsonTrackingRecords(document interface{}, base map[string]string) []map[string]string {
+	switch typed := document.(type) {
+	case []interface{}:
+		var records []map[string]string
+		for _, item := range typed {
+			records = append(records, jsonTrackingRecords(item, base)...)
+		}
+		return records
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			items, ok := typed[key].([]interface{})
+			if !ok || len(items) == 0 {
+				continue
+			}
+			if _, ok := items[0].(map[string]interface{}); !ok {
+				continue
+			}
+			batch := copyTrackingFields(base)
+			flattenTrackingFields(batch, typed, key)
+			var records []map[string]string
+			for _, item := range items {
+				records = append(records, jsonTrackingRecords(item, batch)...)
+			}
+			return records
+		}
+		record := copyTrackingFields(base)
+		flattenTrackingFields(record, typed, "")
+		return []map[string]string{record}
+	}
+	return nil
+}
+
+// flattenTrackingFields sets the scalar fields of a JSON object and of its nested objects, by
+// their own name, shallower fields first, skipping the member named skip
+func flattenTrackingFields(record map[string]string, object map[string]interface{}, skip string) {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var nested []map[string]interface{}
+	for _, key := range keys {
+		switch value := object[key].(type) {
+		case string:
+			setTrackingField(record, key, value)
+		case json.Number:
+			setTrackingField(record, key, value.String())
+		case map[string]interface{}:
+			if key != skip {
+				nested = append(nested, value)
+			}
+		}
+	}
+	for _, value := range nested {
+		flattenTrackingFields(record, value, "")
+	}
+}
+
+// setTrackingField sets a field by its normalized name, unless already set or empty
+func setTrackingField(record map[string]string, name, value string) {
+	name = trackingFieldName(name)
+	if _, ok := record[name]; !ok && value != "" {
+		record[name] = value
+	}
+}
+
+// copyTrackingFields returns a copy of the fields of an event
+func copyTrackingFields(record map[string]string) map[string]string {
+	fields := make(map[string]string, len(record))
+	for name, value := range record {
+		fields[name] = value
+	}
+	return fields
+}
+
+// trackingFieldName normalizes a field name so that userId, user_id and user-id match
+func trackingFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == '.' || r == '$' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// firstField returns the value of the first of the fields the event sets
+func firstField(record map[string]string, names []string) string {
+	for _, name := range names {
+		if value := record[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// trackingEventKey normalizes an event name so that "Page View", "page_view" and "pageview" match
+func trackingEventKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(name))
+}
+
+// trackingLocation returns the page of an event without its query string and fragment, which
+// vendors tag differently
+func trackingLocation(location string) string {
+	location, _, _ = strings.Cut(withoutFragment(location), "?")
+	return strings.TrimSuffix(location, "/")
+}
@@ -0,0 +1,89 @@
+package har
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTrackingEntry returns a POST of the given body and MIME type to an analytics collector
+func newTestTrackingEntry(url, mimeType, body string) *har.Entry {
+	entry := newTestEntry("POST", url)
+	entry.Request.PostData = &har.PostData{MimeType: mimeType, Text: body}
+	entry.Request.BodySize = int64(len(body))
+	return entry
+}
+
+func TestReportDuplicateTracking(t *testing.T) {
+	mixpanel := base64.StdEncoding.EncodeToString([]byte(`{"event":"Sign Up","properties":{"distinct_id":"user-42","$current_url":"https://shop.example.com/signup"}}`))
+	harData := newTestHAR(
+		// Segment batch carrying a signup and a page view
+		newTestTrackingEntry("https://api.segment.io/v1/batch", "application/json", `{"batch":[{"type":"track","event":"sign_up","userId":"user-42","context":{"page":{"url":"https://shop.example.com/signup?utm_source=ads"}}},{"type":"page","userId":"user-42"}]}`),
+		// GA4 hit naming the event and the user its own way
+		newTestEntry("POST", "https://www.google-analytics.com/g/collect?v=2&en=sign_up&uid=user-42&dl=https%3A%2F%2Fshop.example.com%2Fsignup"),
+		newTestTrackingEntry("https://api-js.mixpanel.com/track/", "application/x-www-form-urlencoded", "data="+mixpanel),
+		// Another user signing up is not a duplicate
+		newTestEntry("GET", "https://www.google-analytics.com/g/collect?v=2&en=sign_up&uid=user-7"),
+		newTestEntry("GET", "https://example.com/api/signup?userId=user-42&event=sign_up"),
+	)
+
+	report := NewParser().ReportDuplicateTracking(harData, nil)
+	assert.Equal(t, 4, report.Requests)
+	assert.Equal(t, 5, report.Events)
+	assert.Equal(t, []string{"segment.io", "google-analytics.com", "mixpanel.com"}, []string{report.Vendors[0].Vendor, report.Vendors[1].Vendor, report.Vendors[2].Vendor})
+	assert.Equal(t, 2, report.Vendors[1].Events)
+
+	require.Len(t, report.Duplicates, 1)
+	assert.Equal(t, 1, report.DuplicatedEvents)
+	duplicate := report.Duplicates[0]
+	assert.Len(t, duplicate.Fingerprint, 12)
+	assert.Equal(t, "sign_up", duplicate.Event)
+	assert.Equal(t, "https://shop.example.com/signup", duplicate.Location)
+	assert.Equal(t, []string{"segment.io", "google-analytics.com", "mixpanel.com"}, duplicate.Vendors)
+	assert.Equal(t, []string{"request_0", "request_1", "request_2"}, duplicate.RequestIDs)
+	assert.Equal(t, 3, duplicate.Occurrences)
+
+	// The Segment batch is shared between its two events
+	segment := report.Vendors[0].Bytes / 2
+	assert.Equal(t, duplicate.Bytes-segment, duplicate.RedundantBytes)
+	assert.Equal(t, int64(len(harData.Log.Entries[1].Request.URL))+report.Vendors[2].Bytes, duplicate.RedundantBytes)
+	assert.Equal(t, duplicate.RedundantBytes, report.RedundantBytes)
+}
+
+func TestReportDuplicateTrackingWithBeacons(t *testing.T) {
+	ping := newTestEntry("POST", "https://example.com/audit", har.Header{Name: "Ping-From", Value: "https://example.com/"})
+	ping.Request.URL += "?uid=user-42&event=click"
+	harData := newTestHAR(
+		ping,
+		newTestEntry("GET", "https://www.google-analytics.com/g/collect?en=click&uid=user-42"),
+	)
+	parser := NewParser()
+
+	report := parser.ReportDuplicateTracking(harData, parser.FindBeacons(harData, nil, nil))
+	assert.Equal(t, 2, report.Requests)
+	require.Len(t, report.Duplicates, 1)
+	assert.Equal(t, []string{"example.com", "google-analytics.com"}, report.Duplicates[0].Vendors)
+
+	// Without beacon index, only known analytics collectors are tracking requests
+	report = parser.ReportDuplicateTracking(harData, nil)
+	assert.Equal(t, 1, report.Requests)
+	assert.Empty(t, report.Duplicates)
+}
+
+func TestReportDuplicateTrackingRedactsEventsAndLocations(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	harData := newTestHAR(
+		newTestEntry("GET", "https://www.google-analytics.com/g/collect?v=2&en=sk_live_abc&uid=user-42&dl=https%3A%2F%2Fshop.example.com%2F%3Fkey%3Dsk_live_abc"),
+		newTestEntry("GET", "https://api.segment.io/v1/t?event=sk_live_abc&userId=user-42&url=https%3A%2F%2Fshop.example.com%2F%3Fkey%3Dsk_live_abc"),
+	)
+
+	report := parser.ReportDuplicateTracking(harData, nil)
+
+	require.Len(t, report.Duplicates, 1)
+	assert.Equal(t, "[REDACTED]", report.Duplicates[0].Event)
+	assert.NotContains(t, report.Duplicates[0].Location, "sk_live_abc")
+}
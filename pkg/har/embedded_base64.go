@@ -0,0 +1,205 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// defaultEmbeddedMinLength is the shortest string considered as a base64 payload
+	defaultEmbeddedMinLength = 64
+	// embeddedPreviewBytes caps the size of decoded previews
+	embeddedPreviewBytes = 256
+)
+
+var (
+	base64Pattern    = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+	base64URLPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+={0,2}$`)
+	hexStringPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	jwtPattern       = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+)
+
+// EmbeddedPayload is a base64-encoded value found inside a JSON body
+type EmbeddedPayload struct {
+	RequestID     string `json:"request_id"`
+	Location      string `json:"location"`
+	Path          string `json:"path"`
+	EncodedLength int    `json:"encoded_length"`
+	DecodedLength int    `json:"decoded_length"`
+	DecodedType   string `json:"decoded_type"`
+	Preview       string `json:"preview,omitempty"`
+}
+
+// FindEmbeddedBase64 finds base64-looking string fields of at least minLength
+// characters in the JSON request and response bodies, decodes them and identifies
+// the decoded type. JWTs are reported with their decoded header and claims, never
+// their signature. When requestID is empty every entry is scanned.
+func (p *Parser) FindEmbeddedBase64(harData *har.HAR, requestID string, minLength int, previews bool) ([]EmbeddedPayload, error) {
+	if minLength <= 0 {
+		minLength = defaultEmbeddedMinLength
+	}
+
+	indices := make([]int, 0, len(harData.Log.Entries))
+	if requestID != "" {
+		index, err := p.getEntryIndex(harData, requestID)
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, index)
+	} else {
+		for i := range harData.Log.Entries {
+			indices = append(indices, i)
+		}
+	}
+
+	redaction := p.redaction()
+	payloads := []EmbeddedPayload{}
+	for _, index := range indices {
+		entry := harData.Log.Entries[index]
+		id := formatRequestID(index)
+		if entry.Request != nil && entry.Request.PostData != nil {
+			payloads = append(payloads, findEmbeddedInBody(id, "request", []byte(entry.Request.PostData.Text), minLength, previews, redaction)...)
+		}
+		if entry.Response != nil && entry.Response.Content != nil {
+			payloads = append(payloads, findEmbeddedInBody(id, "response", entry.Response.Content.Text, minLength, previews, redaction)...)
+		}
+	}
+
+	return payloads, nil
+}
+
+// findEmbeddedInBody walks a JSON body looking for base64 strings, redacting the previews of
+// their decoded values
+func findEmbeddedInBody(requestID, location string, body []byte, minLength int, previews bool, redaction *redactor) []EmbeddedPayload {
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil
+	}
+
+	var payloads []EmbeddedPayload
+	walkJSONStrings(document, "$", func(path, value string) {
+		if len(value) < minLength {
+			return
+		}
+		decoded, decodedType, ok := decodeEmbedded(value)
+		if !ok {
+			return
+		}
+		payload := EmbeddedPayload{
+			RequestID:     requestID,
+			Location:      location,
+			Path:          path,
+			EncodedLength: len(value),
+			DecodedLength: len(decoded),
+			DecodedType:   decodedType,
+		}
+		if previews {
+			payload.Preview = redaction.text(previewDecoded(decoded))
+		}
+		payloads = append(payloads, payload)
+	})
+
+	return payloads
+}
+
+// walkJSONStrings calls visit with the JSONPath of every string in the document, in a stable order
+func walkJSONStrings(value interface{}, path string, visit func(path, value string)) {
+	switch typed := value.(type) {
+	case string:
+		visit(path, typed)
+	case []interface{}:
+		for i, item := range typed {
+			walkJSONStrings(item, fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkJSONStrings(typed[key], path+"."+key, visit)
+		}
+	}
+}
+
+// decodeEmbedded decodes a base64 string or a JWT and identifies the decoded content
+func decodeEmbedded(value string) ([]byte, string, bool) {
+	if jwtPattern.MatchString(value) {
+		if claims, ok := decodeJWT(value); ok {
+			return claims, "jwt", true
+		}
+	}
+
+	// Hex digests are valid base64 by accident
+	if hexStringPattern.MatchString(value) {
+		return nil, "", false
+	}
+
+	var decoded []byte
+	var err error
+	switch {
+	case base64Pattern.MatchString(value):
+		decoded, err = base64.StdEncoding.DecodeString(padBase64(value))
+	case base64URLPattern.MatchString(value):
+		decoded, err = base64.URLEncoding.DecodeString(padBase64(value))
+	default:
+		return nil, "", false
+	}
+	if err != nil || len(decoded) == 0 {
+		return nil, "", false
+	}
+
+	if family := ClassifyContent("", decoded); family != "" {
+		return decoded, family, true
+	}
+	if !isPrintableText(decoded) {
+		return decoded, "binary", true
+	}
+	// Plain words and identifiers also decode as base64, only keep text that is clearly structured
+	if family := sniffFamily(decoded); family != "" {
+		return decoded, family, true
+	}
+	return nil, "", false
+}
+
+// decodeJWT returns the decoded header and claims of a JWT, leaving the signature out
+func decodeJWT(token string) ([]byte, bool) {
+	parts := strings.Split(token, ".")
+	var decodedParts []string
+	for _, part := range parts[:2] {
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+		if err != nil || !json.Valid(decoded) {
+			return nil, false
+		}
+		decodedParts = append(decodedParts, string(decoded))
+	}
+	return []byte(strings.Join(decodedParts, ".")), true
+}
+
+// padBase64 adds the padding some encoders omit
+func padBase64(value string) string {
+	if missing := len(value) % 4; missing != 0 {
+		return value + strings.Repeat("=", 4-missing)
+	}
+	return value
+}
+
+// previewDecoded renders the beginning of a decoded payload as text, or as hex when binary
+func previewDecoded(decoded []byte) string {
+	preview := decoded[:runeEnd(decoded, min(len(decoded), embeddedPreviewBytes))]
+	if isPrintableText(preview) {
+		return string(preview)
+	}
+	return hex.EncodeToString(preview[:min(len(preview), embeddedPreviewBytes/8)])
+}
@@ -0,0 +1,89 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestJSONResponseEntry builds an entry whose response is the given JSON body.
+func newTestJSONResponseEntry(body string) *har.Entry {
+	entry := newTestEntry("GET", "https://example.com/api")
+	entry.Response.Content = &har.Content{MimeType: "application/json", Text: []byte(body)}
+	return entry
+}
+
+func TestFindEmbeddedBase64DetectsFile(t *testing.T) {
+	parser := NewParser()
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.7 " + strings.Repeat("x", 100)))
+	archive := newTestHAR(newTestJSONResponseEntry(fmt.Sprintf(`{"documents": [{"name": "invoice", "content": %q}]}`, pdf)))
+
+	payloads, err := parser.FindEmbeddedBase64(archive, "", 0, false)
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "request_0", payloads[0].RequestID)
+	assert.Equal(t, "response", payloads[0].Location)
+	assert.Equal(t, "$.documents[0].content", payloads[0].Path)
+	assert.Equal(t, "pdf", payloads[0].DecodedType)
+	assert.Equal(t, 109, payloads[0].DecodedLength)
+	assert.Empty(t, payloads[0].Preview)
+}
+
+func TestFindEmbeddedBase64DecodesNestedJSONWithPreview(t *testing.T) {
+	parser := NewParser()
+	nested := base64.StdEncoding.EncodeToString([]byte(`{"user": "alice", "roles": ["admin", "billing", "support"]}`))
+	archive := newTestHAR(newTestJSONResponseEntry(fmt.Sprintf(`{"state": %q}`, nested)))
+
+	payloads, err := parser.FindEmbeddedBase64(archive, "request_0", 16, true)
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "json", payloads[0].DecodedType)
+	assert.Contains(t, payloads[0].Preview, `"user": "alice"`)
+}
+
+func TestFindEmbeddedBase64DecodesJWTClaims(t *testing.T) {
+	parser := NewParser()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1234567890","name":"John Doe"}`))
+	token := header + "." + claims + ".c2lnbmF0dXJlc2lnbmF0dXJl"
+	archive := newTestHAR(newTestJSONResponseEntry(fmt.Sprintf(`{"id_token": %q}`, token)))
+
+	payloads, err := parser.FindEmbeddedBase64(archive, "", 0, true)
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "jwt", payloads[0].DecodedType)
+	assert.Contains(t, payloads[0].Preview, `"name":"John Doe"`)
+	assert.NotContains(t, payloads[0].Preview, "signature")
+}
+
+func TestFindEmbeddedBase64IgnoresHexAndShortStrings(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestJSONResponseEntry(`{"sha": "` + strings.Repeat("ab12", 16) + `", "name": "aGVsbG8="}`))
+
+	payloads, err := parser.FindEmbeddedBase64(archive, "", 0, false)
+	require.NoError(t, err)
+
+	assert.Empty(t, payloads)
+}
+
+func TestFindEmbeddedBase64RedactsPreviews(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	nested := base64.StdEncoding.EncodeToString([]byte(`{"user": "alice", "api_key": "sk_live_abc"}`))
+	archive := newTestHAR(newTestJSONResponseEntry(fmt.Sprintf(`{"state": %q}`, nested)))
+
+	payloads, err := parser.FindEmbeddedBase64(archive, "", 16, true)
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 1)
+	assert.Contains(t, payloads[0].Preview, `"api_key": "[REDACTED]"`)
+	assert.NotContains(t, payloads[0].Preview, "sk_live_abc")
+}
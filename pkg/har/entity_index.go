@@ -0,0 +1,235 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Entity kinds recognized by the entity index
+const (
+	EntityKindID    = "id"
+	EntityKindEmail = "email"
+	EntityKindUUID  = "uuid"
+	EntityKindSKU   = "sku"
+)
+
+// defaultEntityIndexTop is the number of most referenced entities summarized
+const defaultEntityIndexTop = 20
+
+// emailPattern matches email addresses
+var emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+
+// EntityOccurrence is a place of an entry mentioning an entity
+type EntityOccurrence struct {
+	RequestID string `json:"request_id"`
+	// Side is request or response
+	Side string `json:"side"`
+	// Location is url, a query parameter or the JSONPath of the value in the body
+	Location string `json:"location"`
+}
+
+// Entity is a business value (ID, email, UUID, SKU) and every place mentioning it
+type Entity struct {
+	Value       string             `json:"value"`
+	Kind        string             `json:"kind"`
+	Requests    []string           `json:"requests"`
+	Occurrences []EntityOccurrence `json:"occurrences"`
+}
+
+// EntitySummary counts the requests mentioning an entity
+type EntitySummary struct {
+	Value    string `json:"value"`
+	Kind     string `json:"kind"`
+	Requests int    `json:"requests"`
+}
+
+// EntityIndexSummary describes an entity index
+type EntityIndexSummary struct {
+	Entities int            `json:"entities"`
+	ByKind   map[string]int `json:"by_kind"`
+	// Top lists the entities mentioned by the most requests
+	Top []EntitySummary `json:"top"`
+}
+
+// EntityIndex maps the entities found in a capture to the entries mentioning them
+type EntityIndex struct {
+	// entities are indexed by normalized value
+	entities map[string]*Entity
+}
+
+// BuildEntityIndex indexes the IDs, emails, UUIDs and SKUs found in request URLs and in
+// JSON request and response bodies. IDs and SKUs are recognized by their field or query
+// parameter name (id, order_id, orderId, sku) or, in URL paths, as numeric segments.
+func (p *Parser) BuildEntityIndex(harData *har.HAR) *EntityIndex {
+	index := &EntityIndex{entities: make(map[string]*Entity)}
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		requestID := formatRequestID(i)
+		index.addURL(requestID, entry.Request.URL)
+		if entry.Request.PostData != nil {
+			index.addJSON(requestID, "request", []byte(entry.Request.PostData.Text))
+		}
+		if entry.Response != nil && entry.Response.Content != nil {
+			index.addJSON(requestID, "response", entry.Response.Content.Text)
+		}
+	}
+	return index
+}
+
+// Find returns the entity with the given value, nil when no entry mentions it
+func (idx *EntityIndex) Find(value string) *Entity {
+	return idx.entities[normalizeEntity(value)]
+}
+
+// Summary counts the entities by kind and lists the top most referenced ones, top
+// defaulting to 20 when zero or negative
+func (idx *EntityIndex) Summary(top int) *EntityIndexSummary {
+	if top <= 0 {
+		top = defaultEntityIndexTop
+	}
+
+	summary := &EntityIndexSummary{
+		Entities: len(idx.entities),
+		ByKind:   make(map[string]int),
+		Top:      []EntitySummary{},
+	}
+	for _, entity := range idx.entities {
+		summary.ByKind[entity.Kind]++
+		summary.Top = append(summary.Top, EntitySummary{Value: entity.Value, Kind: entity.Kind, Requests: len(entity.Requests)})
+	}
+	sort.Slice(summary.Top, func(i, j int) bool {
+		if summary.Top[i].Requests != summary.Top[j].Requests {
+			return summary.Top[i].Requests > summary.Top[j].Requests
+		}
+		return summary.Top[i].Value < summary.Top[j].Value
+	})
+	if len(summary.Top) > top {
+		summary.Top = summary.Top[:top]
+	}
+	return summary
+}
+
+// add records an occurrence of value if it is an entity
+func (idx *EntityIndex) add(requestID, side, location, key, value string) {
+	kind := entityKind(key, value)
+	if kind == "" {
+		return
+	}
+
+	normalized := normalizeEntity(value)
+	entity, ok := idx.entities[normalized]
+	if !ok {
+		entity = &Entity{Value: value, Kind: kind, Requests: []string{}, Occurrences: []EntityOccurrence{}}
+		idx.entities[normalized] = entity
+	}
+	if len(entity.Requests) == 0 || entity.Requests[len(entity.Requests)-1] != requestID {
+		entity.Requests = append(entity.Requests, requestID)
+	}
+	entity.Occurrences = append(entity.Occurrences, EntityOccurrence{RequestID: requestID, Side: side, Location: location})
+}
+
+// addURL indexes the path segments and query parameters of a request URL
+func (idx *EntityIndex) addURL(requestID, rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if numericSegmentPattern.MatchString(segment) {
+			idx.add(requestID, "request", "url", "id", segment)
+		} else {
+			idx.add(requestID, "request", "url", "", segment)
+		}
+	}
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range query[name] {
+			idx.add(requestID, "request", fmt.Sprintf("query %s", name), name, value)
+		}
+	}
+}
+
+// addJSON indexes the scalar values of a JSON body
+func (idx *EntityIndex) addJSON(requestID, side string, body []byte) {
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return
+	}
+
+	var walk func(value interface{}, path, key string)
+	walk = func(value interface{}, path, key string) {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(typed))
+			for k := range typed {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(typed[k], path+"."+k, k)
+			}
+		case []interface{}:
+			// Items of an array of IDs (order_ids) are IDs too
+			for i, item := range typed {
+				walk(item, fmt.Sprintf("%s[%d]", path, i), strings.TrimSuffix(key, "s"))
+			}
+		case string:
+			idx.add(requestID, side, path, key, typed)
+		case json.Number:
+			idx.add(requestID, side, path, key, typed.String())
+		}
+	}
+	walk(document, "$", "")
+}
+
+// entityKind classifies a value by its shape, then by the name of its field
+func entityKind(key, value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "" || len(value) > 256:
+		return ""
+	case emailPattern.MatchString(value):
+		return EntityKindEmail
+	case uuidPattern.MatchString(value):
+		return EntityKindUUID
+	case isSKUKey(key):
+		return EntityKindSKU
+	case isIDKey(key) && !strings.ContainsAny(value, " \t\n") && value != "0":
+		return EntityKindID
+	default:
+		return ""
+	}
+}
+
+// isIDKey reports whether a field name denotes an identifier: id, order_id, orderId, orderID
+func isIDKey(key string) bool {
+	lower := strings.ToLower(key)
+	return lower == "id" || strings.HasSuffix(lower, "_id") || strings.HasSuffix(lower, "-id") ||
+		strings.HasSuffix(key, "Id") || strings.HasSuffix(key, "ID")
+}
+
+// isSKUKey reports whether a field name denotes a stock keeping unit
+func isSKUKey(key string) bool {
+	return strings.Contains(strings.ToLower(key), "sku")
+}
+
+// normalizeEntity makes lookups insensitive to case and surrounding spaces
+func normalizeEntity(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,65 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEntityHAR builds a capture where order 8842 is created, fetched and listed
+func newTestEntityHAR() *har.HAR {
+	created := newTestResponseEntry("https://shop.example.com/api/orders", "application/json",
+		`{"orderId": 8842, "customer": {"email": "Alice@Example.com"}, "lines": [{"sku": "SKU-001", "qty": 2}]}`)
+	created.Request.Method = "POST"
+	created.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"cart_id": "c-77", "note": "leave at door"}`}
+
+	fetched := newTestResponseEntry("https://shop.example.com/api/orders/8842?customer_id=c-77", "application/json",
+		`{"id": "8842", "status": "paid"}`)
+	listed := newTestResponseEntry("https://shop.example.com/api/orders", "application/json",
+		`{"order_ids": [8842, 9001], "trace": "9b2f8c1e-9a4d-4e7b-b5f6-0c1d2e3f4a5b"}`)
+
+	return newTestHAR(created, fetched, listed)
+}
+
+func TestEntityIndexFindsEveryMention(t *testing.T) {
+	index := NewParser().BuildEntityIndex(newTestEntityHAR())
+
+	order := index.Find("8842")
+	require.NotNil(t, order)
+	assert.Equal(t, EntityKindID, order.Kind)
+	assert.Equal(t, []string{"request_0", "request_1", "request_2"}, order.Requests)
+	assert.Equal(t, []EntityOccurrence{
+		{RequestID: "request_0", Side: "response", Location: "$.orderId"},
+		{RequestID: "request_1", Side: "request", Location: "url"},
+		{RequestID: "request_1", Side: "response", Location: "$.id"},
+		{RequestID: "request_2", Side: "response", Location: "$.order_ids[0]"},
+	}, order.Occurrences)
+
+	cart := index.Find("c-77")
+	require.NotNil(t, cart)
+	assert.Equal(t, []string{"request_0", "request_1"}, cart.Requests)
+	assert.Equal(t, "query customer_id", cart.Occurrences[1].Location)
+
+	email := index.Find("alice@example.com")
+	require.NotNil(t, email)
+	assert.Equal(t, EntityKindEmail, email.Kind)
+	assert.Equal(t, "Alice@Example.com", email.Value)
+
+	assert.Nil(t, index.Find("leave at door"))
+	assert.Nil(t, index.Find("2"))
+}
+
+func TestEntityIndexSummary(t *testing.T) {
+	index := NewParser().BuildEntityIndex(newTestEntityHAR())
+
+	summary := index.Summary(2)
+
+	assert.Equal(t, 6, summary.Entities)
+	assert.Equal(t, map[string]int{EntityKindID: 3, EntityKindEmail: 1, EntityKindSKU: 1, EntityKindUUID: 1}, summary.ByKind)
+	assert.Equal(t, []EntitySummary{
+		{Value: "8842", Kind: EntityKindID, Requests: 3},
+		{Value: "c-77", Kind: EntityKindID, Requests: 2},
+	}, summary.Top)
+}
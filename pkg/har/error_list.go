@@ -0,0 +1,256 @@
+package har
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/martian/har"
+)
+
+// Baselines the request headers of failures are compared against
+const (
+	// ErrorBaselineEndpoint compares with the successful requests to the same endpoint
+	ErrorBaselineEndpoint = "endpoint"
+	// ErrorBaselineHost compares with the successful requests to the same host, when the endpoint
+	// never succeeded
+	ErrorBaselineHost = "host"
+)
+
+const (
+	// maxErrorListGroups caps the groups listed by the error list
+	maxErrorListGroups = 50
+	// maxErrorBodySnippet caps the bytes of the response body snippet of an error group
+	maxErrorBodySnippet = 300
+)
+
+// volatileRequestHeaders are request headers whose values change with every request, which
+// cannot correlate with failures
+var volatileRequestHeaders = map[string]bool{
+	"content-length": true, "cookie": true, "date": true, "traceparent": true, "tracestate": true,
+	"baggage": true, "sentry-trace": true, "x-request-id": true, "x-correlation-id": true,
+	"x-amzn-trace-id": true, "x-cloud-trace-context": true, "x-b3-traceid": true, "x-b3-spanid": true,
+	"x-b3-parentspanid": true, "x-datadog-trace-id": true, "x-datadog-parent-id": true,
+	"if-none-match": true, "if-modified-since": true,
+}
+
+// CorrelatedHeader is a request header telling the failures of a group apart from the successful
+// requests of their baseline
+type CorrelatedHeader struct {
+	Name string `json:"name"`
+	// Value is the value every failure of the group sent, redacted
+	Value string `json:"value,omitempty"`
+	// Missing is set when every successful request of the baseline sent the header and no failure did
+	Missing bool `json:"missing,omitempty"`
+}
+
+// ErrorListGroup gathers the responses of one status on one URL pattern
+type ErrorListGroup struct {
+	Status     int    `json:"status"`
+	StatusText string `json:"status_text"`
+	Method     string `json:"method"`
+	// Endpoint is the URL with its variable path segments replaced by placeholders
+	Endpoint   string   `json:"endpoint"`
+	Count      int      `json:"count"`
+	RequestIDs []string `json:"request_ids"`
+	// BodySnippet is the start of the response body of the first failure, on a single line and redacted
+	BodySnippet  string `json:"body_snippet,omitempty"`
+	BodyMimeType string `json:"body_mime_type,omitempty"`
+	// Baseline tells which successful requests the headers were compared against, empty when
+	// neither the endpoint nor its host ever succeeded
+	Baseline          string             `json:"baseline,omitempty"`
+	BaselineRequests  int                `json:"baseline_requests,omitempty"`
+	CorrelatedHeaders []CorrelatedHeader `json:"correlated_headers"`
+}
+
+// ErrorList lists the 4xx and 5xx responses of a capture, grouped by status and URL pattern
+type ErrorList struct {
+	Failed int `json:"failed"`
+	// Groups are sorted by decreasing count, the dominant failure first
+	Groups    []ErrorListGroup `json:"groups"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// errorListGroup is an error group with its failed entries
+type errorListGroup struct {
+	ErrorListGroup
+	entries []*har.Entry
+	host    string
+}
+
+// ListErrors groups the responses with a 4xx or 5xx status by status and URL pattern, the dominant
+// failure first, with a snippet of the first response body. The request headers of the failures
+// are compared with those of the successful requests to the same endpoint, or else to the same
+// host: headers every failure sent with the same value and no successful request did, and headers
+// every successful request sent and no failure did, are reported as correlated. Header values and
+// snippets are redacted. The entries of the beacon index are left out, every entry is listed
+// when it is nil.
+func (p *Parser) ListErrors(harData *har.HAR, beacons *BeaconIndex) *ErrorList {
+	list := &ErrorList{Groups: []ErrorListGroup{}}
+	redaction := p.redaction()
+
+	groups := make(map[string]*errorListGroup)
+	var order []*errorListGroup
+	// endpointSuccesses and hostSuccesses are the successful requests, by endpoint and by host
+	endpointSuccesses := make(map[string][]*har.Entry)
+	hostSuccesses := make(map[string][]*har.Entry)
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil || beacons.Kind(entry) != "" {
+			continue
+		}
+		status := entry.Response.Status
+		if status > 0 && status < 400 {
+			endpointSuccesses[resourceKey(entry)] = append(endpointSuccesses[resourceKey(entry)], entry)
+			hostSuccesses[requestHost(entry)] = append(hostSuccesses[requestHost(entry)], entry)
+			continue
+		}
+		if status < 400 {
+			continue
+		}
+
+		list.Failed++
+		key := strconv.Itoa(status) + " " + resourceKey(entry)
+		group, ok := groups[key]
+		if !ok {
+			group = &errorListGroup{
+				ErrorListGroup: ErrorListGroup{
+					Status:     status,
+					StatusText: entry.Response.StatusText,
+					Method:     entry.Request.Method,
+					Endpoint:   templateURL(entry.Request.URL),
+					RequestIDs: []string{},
+				},
+				host: requestHost(entry),
+			}
+			if group.StatusText == "" {
+				group.StatusText = http.StatusText(status)
+			}
+			group.BodySnippet, group.BodyMimeType = errorBodySnippet(entry.Response)
+			group.BodySnippet = redaction.text(group.BodySnippet)
+			groups[key] = group
+			order = append(order, group)
+		}
+		group.Count++
+		group.entries = append(group.entries, entry)
+		if len(group.RequestIDs) < maxErrorGroupExamples {
+			group.RequestIDs = append(group.RequestIDs, formatRequestID(i))
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].Count > order[j].Count
+	})
+	for _, group := range order {
+		if len(list.Groups) == maxErrorListGroups {
+			list.Truncated = true
+			break
+		}
+		baseline := endpointSuccesses[resourceKey(group.entries[0])]
+		group.Baseline = ErrorBaselineEndpoint
+		if len(baseline) == 0 {
+			baseline = hostSuccesses[group.host]
+			group.Baseline = ErrorBaselineHost
+		}
+		if len(baseline) == 0 {
+			group.Baseline = ""
+		}
+		group.BaselineRequests = len(baseline)
+		group.CorrelatedHeaders = correlatedHeaders(group.entries, baseline, redaction)
+		list.Groups = append(list.Groups, group.ErrorListGroup)
+	}
+	return list
+}
+
+// correlatedHeaders returns the request headers telling the failures apart from the baseline:
+// those all failures sent with the same value that no baseline request sent with it, then those
+// all baseline requests sent and no failure did, by name. Without baseline, nothing correlates.
+func correlatedHeaders(failures, baseline []*har.Entry, redaction *redactor) []CorrelatedHeader {
+	headers := []CorrelatedHeader{}
+	if len(baseline) == 0 {
+		return headers
+	}
+
+	shared := requestHeaderValues(failures[0])
+	for _, entry := range failures[1:] {
+		values := requestHeaderValues(entry)
+		for name, value := range shared {
+			if values[name] != value {
+				delete(shared, name)
+			}
+		}
+	}
+	sentByFailures := make(map[string]bool)
+	for _, entry := range failures {
+		for name := range requestHeaderValues(entry) {
+			sentByFailures[name] = true
+		}
+	}
+
+	baselineValues := make([]map[string]string, len(baseline))
+	for i, entry := range baseline {
+		baselineValues[i] = requestHeaderValues(entry)
+	}
+	for _, name := range sortedKeys(shared) {
+		correlated := true
+		for _, values := range baselineValues {
+			if values[name] == shared[name] {
+				correlated = false
+				break
+			}
+		}
+		if correlated {
+			headers = append(headers, CorrelatedHeader{Name: name, Value: redaction.header(name, shared[name])})
+		}
+	}
+	for _, name := range sortedKeys(baselineValues[0]) {
+		missing := !sentByFailures[name]
+		for _, values := range baselineValues[1:] {
+			if _, ok := values[name]; !ok {
+				missing = false
+				break
+			}
+		}
+		if missing {
+			headers = append(headers, CorrelatedHeader{Name: name, Missing: true})
+		}
+	}
+	return headers
+}
+
+// requestHeaderValues maps the lowercased names of the request headers that can correlate with
+// failures to their values, HTTP/2 pseudo-headers and volatile headers left out
+func requestHeaderValues(entry *har.Entry) map[string]string {
+	values := make(map[string]string)
+	for _, header := range entry.Request.Headers {
+		name := strings.ToLower(header.Name)
+		if strings.HasPrefix(name, ":") || volatileRequestHeaders[name] {
+			continue
+		}
+		if value, ok := values[name]; ok {
+			values[name] = value + ", " + header.Value
+		} else {
+			values[name] = header.Value
+		}
+	}
+	return values
+}
+
+// errorBodySnippet returns the start of a textual response body on a single line, with its MIME type
+func errorBodySnippet(response *har.Response) (string, string) {
+	decoded, _ := decodedResponse(response)
+	if decoded == nil || decoded.Content == nil || len(decoded.Content.Text) == 0 || !isPrintableText(decoded.Content.Text) {
+		return "", ""
+	}
+	text := string(decoded.Content.Text)
+	suffix := ""
+	if len(text) > maxErrorBodySnippet {
+		end := maxErrorBodySnippet
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text, suffix = text[:end], "..."
+	}
+	return strings.Join(strings.Fields(text), " ") + suffix, responseMimeType(response)
+}
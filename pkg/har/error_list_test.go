@@ -0,0 +1,70 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestErrorEntry returns a request with the given headers answered with the status and body
+func newTestErrorEntry(url string, status int, body string, headers ...har.Header) *har.Entry {
+	entry := newTestEntry("GET", url, headers...)
+	entry.Response.Status = status
+	entry.Response.StatusText = ""
+	entry.Response.Content.MimeType = "application/json"
+	entry.Response.Content.Text = []byte(body)
+	return entry
+}
+
+func TestListErrors(t *testing.T) {
+	accept := har.Header{Name: "Accept", Value: "application/json"}
+	harData := newTestHAR(
+		newTestErrorEntry("https://api.example.com/orders/1", 200, `{}`, accept, har.Header{Name: "Authorization", Value: "Bearer fresh"}, har.Header{Name: "X-Tenant", Value: "acme"}),
+		// Orders fail with an expired token and without tenant
+		newTestErrorEntry("https://api.example.com/orders/2", 401, "{\n  \"error\": \"token expired\"\n}", accept, har.Header{Name: "Authorization", Value: "Bearer stale"}, har.Header{Name: "X-Request-Id", Value: "a"}),
+		newTestErrorEntry("https://api.example.com/orders/3", 401, `{"error": "token expired"}`, accept, har.Header{Name: "Authorization", Value: "Bearer stale"}, har.Header{Name: "X-Request-Id", Value: "b"}),
+		newTestErrorEntry("https://api.example.com/orders/4", 500, `<html>boom</html>`, accept),
+		newTestErrorEntry("https://cdn.example.com/app.js", 404, ""),
+	)
+
+	list := NewParser().ListErrors(harData, nil)
+	assert.Equal(t, 4, list.Failed)
+	require.Len(t, list.Groups, 3)
+
+	dominant := list.Groups[0]
+	assert.Equal(t, 401, dominant.Status)
+	assert.Equal(t, "Unauthorized", dominant.StatusText)
+	assert.Equal(t, "https://api.example.com/orders/{id}", dominant.Endpoint)
+	assert.Equal(t, 2, dominant.Count)
+	assert.Equal(t, []string{"request_1", "request_2"}, dominant.RequestIDs)
+	assert.Equal(t, `{ "error": "token expired" }`, dominant.BodySnippet)
+	assert.Equal(t, ErrorBaselineEndpoint, dominant.Baseline)
+	assert.Equal(t, 1, dominant.BaselineRequests)
+	// The request ID changes with every request and the shared Accept header also succeeded
+	assert.Equal(t, []CorrelatedHeader{
+		{Name: "authorization", Value: redactedValue},
+		{Name: "x-tenant", Missing: true},
+	}, dominant.CorrelatedHeaders)
+
+	assert.Equal(t, 500, list.Groups[1].Status)
+	assert.Equal(t, []CorrelatedHeader{{Name: "authorization", Missing: true}, {Name: "x-tenant", Missing: true}}, list.Groups[1].CorrelatedHeaders)
+
+	// Neither the script nor its host ever succeeded
+	assert.Equal(t, 404, list.Groups[2].Status)
+	assert.Empty(t, list.Groups[2].Baseline)
+	assert.Empty(t, list.Groups[2].BodySnippet)
+	assert.Empty(t, list.Groups[2].CorrelatedHeaders)
+}
+
+func TestListErrorsTruncatesBodySnippet(t *testing.T) {
+	body := make([]byte, maxErrorBodySnippet+10)
+	for i := range body {
+		body[i] = 'a'
+	}
+	list := NewParser().ListErrors(newTestHAR(newTestErrorEntry("https://example.com/", 503, string(body))), nil)
+	require.Len(t, list.Groups, 1)
+	assert.Len(t, list.Groups[0].BodySnippet, maxErrorBodySnippet+3)
+	assert.Equal(t, "application/json", list.Groups[0].BodyMimeType)
+}
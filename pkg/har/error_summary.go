@@ -0,0 +1,146 @@
+package har
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Failure kinds reported by the error summary
+const (
+	// FailureNetwork is a request that got no response (status 0)
+	FailureNetwork = "network"
+	FailureClient  = "http_4xx"
+	FailureServer  = "http_5xx"
+	// FailureGraphQL is a GraphQL response reporting errors, usually with a 200 status
+	FailureGraphQL = ProtocolGraphQL
+	// FailureJSONRPC is a JSON-RPC response reporting errors, usually with a 200 status
+	FailureJSONRPC = ProtocolJSONRPC
+)
+
+const (
+	// maxErrorGroupMessages caps the distinct error messages listed per group
+	maxErrorGroupMessages = 5
+	// maxErrorGroupExamples caps the request IDs listed per group
+	maxErrorGroupExamples = 10
+)
+
+// ErrorGroup gathers the failures of one kind on one endpoint
+type ErrorGroup struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	// Operation is the GraphQL operation name or JSON-RPC method, if any
+	Operation  string   `json:"operation,omitempty"`
+	Kind       string   `json:"kind"`
+	Count      int      `json:"count"`
+	Statuses   []int    `json:"statuses"`
+	Messages   []string `json:"messages,omitempty"`
+	RequestIDs []string `json:"request_ids"`
+}
+
+// ErrorSummary counts the failed entries of a capture and groups them by endpoint
+type ErrorSummary struct {
+	Entries int            `json:"entries"`
+	Failed  int            `json:"failed"`
+	ByKind  map[string]int `json:"by_kind"`
+	Groups  []ErrorGroup   `json:"groups"`
+	// Beacons summarizes the beacons left out of the counts, when they are
+	Beacons *BeaconSummary `json:"beacons,omitempty"`
+}
+
+// GetErrorSummary counts the entries that failed: no response, 4xx and 5xx statuses, and
+// GraphQL or JSON-RPC responses reporting errors despite a successful status, so RPC-heavy
+// captures don't look deceptively healthy. Groups are sorted by decreasing count. The entries of
+// the beacon index, often cut short at unload, are left out and summarized apart; every entry is
+// counted when the index is nil.
+func (p *Parser) GetErrorSummary(harData *har.HAR, beacons *BeaconIndex) *ErrorSummary {
+	summary := &ErrorSummary{ByKind: make(map[string]int), Groups: []ErrorGroup{}}
+	if beacons != nil {
+		summary.Beacons = newBeaconSummary()
+	}
+	groups := make(map[string]*ErrorGroup)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		if kind := beacons.Kind(entry); kind != "" {
+			summary.Beacons.add(kind, entry, formatRequestID(i))
+			continue
+		}
+		summary.Entries++
+
+		kind, messages := classifyFailure(entry)
+		if kind == "" {
+			continue
+		}
+		summary.Failed++
+		summary.ByKind[kind]++
+
+		status := 0
+		if entry.Response != nil {
+			status = entry.Response.Status
+		}
+		_, operations := requestOperations(entry.Request)
+		operation := strings.Join(operations, ",")
+
+		key := strings.Join([]string{resourceKey(entry), operation, kind}, " ")
+		group, ok := groups[key]
+		if !ok {
+			group = &ErrorGroup{
+				Method:    entry.Request.Method,
+				Endpoint:  templateURL(entry.Request.URL),
+				Operation: operation,
+				Kind:      kind,
+			}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.Count++
+		if !slices.Contains(group.Statuses, status) {
+			group.Statuses = append(group.Statuses, status)
+		}
+		for _, message := range messages {
+			if len(group.Messages) < maxErrorGroupMessages && !slices.Contains(group.Messages, message) {
+				group.Messages = append(group.Messages, message)
+			}
+		}
+		if len(group.RequestIDs) < maxErrorGroupExamples {
+			group.RequestIDs = append(group.RequestIDs, formatRequestID(i))
+		}
+	}
+
+	for _, key := range order {
+		summary.Groups = append(summary.Groups, *groups[key])
+	}
+	sort.SliceStable(summary.Groups, func(i, j int) bool {
+		return summary.Groups[i].Count > summary.Groups[j].Count
+	})
+
+	return summary
+}
+
+// classifyFailure returns the failure kind of an entry, empty for successful entries,
+// with the error messages reported by GraphQL and JSON-RPC responses
+func classifyFailure(entry *har.Entry) (string, []string) {
+	if entry.Response == nil || entry.Response.Status == 0 {
+		return FailureNetwork, nil
+	}
+
+	protocol, _ := requestOperations(entry.Request)
+	messages := operationErrors(protocol, entry.Response)
+	switch {
+	case entry.Response.Status >= 500:
+		return FailureServer, messages
+	case entry.Response.Status >= 400:
+		return FailureClient, messages
+	case len(messages) > 0:
+		// RPC protocols report errors in the body of successful responses
+		return protocol, messages
+	default:
+		return "", nil
+	}
+}
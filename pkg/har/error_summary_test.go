@@ -0,0 +1,65 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGraphQLEntry builds a GraphQL call answered with a 200 status
+func newTestGraphQLEntry(requestBody, responseBody string) *har.Entry {
+	entry := newTestResponseEntry("https://api.example.com/graphql", "application/json", responseBody)
+	entry.Request.Method = "POST"
+	entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: requestBody}
+	return entry
+}
+
+func TestGetErrorSummaryCountsGraphQLErrorsAsFailures(t *testing.T) {
+	parser := NewParser()
+	failed := newTestGraphQLEntry(`{"query": "query GetCart { cart { id } }"}`,
+		`{"data": null, "errors": [{"message": "Cart not found"}]}`)
+	failedAgain := newTestGraphQLEntry(`{"query": "query GetCart { cart { id } }"}`,
+		`{"errors": [{"message": "Cart not found"}, {"message": "Timeout"}]}`)
+	succeeded := newTestGraphQLEntry(`{"operationName": "GetCart", "query": "query GetCart { cart { id } }"}`,
+		`{"data": {"cart": {"id": 1}}}`)
+	batched := newTestGraphQLEntry(`[{"query": "mutation { logout }"}, {"operationName": "Me", "query": "{ me { id } }"}]`,
+		`[{"data": {"logout": true}}, {"errors": [{"message": "Unauthenticated"}]}]`)
+	// Only GraphQL requests are checked for errors fields
+	rest := newTestResponseEntry("https://api.example.com/report", "application/json", `{"errors": []}`)
+	missing := newTestResponseEntry("https://api.example.com/users/42", "application/json", `{}`)
+	missing.Response.Status = 404
+	aborted := newTestEntry("GET", "https://api.example.com/stream")
+	aborted.Response.Status = 0
+
+	summary := parser.GetErrorSummary(newTestHAR(failed, failedAgain, succeeded, batched, rest, missing, aborted), nil)
+
+	assert.Equal(t, 7, summary.Entries)
+	assert.Equal(t, 5, summary.Failed)
+	assert.Equal(t, map[string]int{FailureGraphQL: 3, FailureClient: 1, FailureNetwork: 1}, summary.ByKind)
+	require.Len(t, summary.Groups, 4)
+	assert.Equal(t, ErrorGroup{
+		Method:     "POST",
+		Endpoint:   "https://api.example.com/graphql",
+		Operation:  "GetCart",
+		Kind:       FailureGraphQL,
+		Count:      2,
+		Statuses:   []int{200},
+		Messages:   []string{"Cart not found", "Timeout"},
+		RequestIDs: []string{"request_0", "request_1"},
+	}, summary.Groups[0])
+	assert.Equal(t, "mutation,Me", summary.Groups[1].Operation)
+	assert.Equal(t, "https://api.example.com/users/{id}", summary.Groups[2].Endpoint)
+	assert.Equal(t, []int{0}, summary.Groups[3].Statuses)
+}
+
+func TestGetRequestDetailsReportsGraphQLErrors(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestGraphQLEntry(`{"query": "{ me { id } }"}`, `{"errors": [{"message": "Unauthenticated"}]}`))
+
+	details, err := parser.GetRequestDetails(archive, nil, nil, "request_0")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Unauthenticated"}, details.OperationErrors)
+}
@@ -0,0 +1,99 @@
+package har
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultExcerptWindow is the number of bytes of context kept on each side of a match
+	DefaultExcerptWindow = 80
+	// DefaultMaxExcerpts caps the number of excerpts returned for a single body
+	DefaultMaxExcerpts = 10
+)
+
+// Excerpt is a window of a body around a match.
+// Offsets are byte offsets into the full body so callers can fetch more context.
+type Excerpt struct {
+	MatchOffset int    `json:"match_offset"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Text        string `json:"text"`
+	Truncated   bool   `json:"truncated,omitempty"`
+}
+
+// ExcerptMatches returns a window of at most window bytes of context around each
+// occurrence of query in body, rather than the whole body, so that matches in
+// multi-megabyte payloads stay consumable. Overlapping windows are merged and at
+// most maxExcerpts are returned. Non-positive limits fall back to the defaults.
+func ExcerptMatches(body []byte, query string, window, maxExcerpts int) []Excerpt {
+	if query == "" {
+		return nil
+	}
+	needle := []byte(query)
+	return excerptsAround(body, func(from int) (int, int, bool) {
+		index := bytes.Index(body[from:], needle)
+		if index < 0 {
+			return 0, 0, false
+		}
+		return from + index, from + index + len(needle), true
+	}, window, maxExcerpts)
+}
+
+// excerptsAround returns the excerpts of body around the matches that find locates from an
+// offset, as ExcerptMatches does
+func excerptsAround(body []byte, find func(from int) (int, int, bool), window, maxExcerpts int) []Excerpt {
+	if window <= 0 {
+		window = DefaultExcerptWindow
+	}
+	if maxExcerpts <= 0 {
+		maxExcerpts = DefaultMaxExcerpts
+	}
+
+	var excerpts []Excerpt
+	for offset := 0; offset <= len(body); {
+		match, matchEnd, ok := find(offset)
+		if !ok {
+			break
+		}
+		// Empty matches, such as those of a* regular expressions, still move forward
+		offset = max(matchEnd, match+1)
+
+		start := runeStart(body, max(0, match-window))
+		end := runeEnd(body, min(len(body), matchEnd+window))
+
+		if last := len(excerpts) - 1; last >= 0 && start <= excerpts[last].End {
+			excerpts[last].End = end
+			excerpts[last].Text = string(body[excerpts[last].Start:end])
+			continue
+		}
+		if len(excerpts) == maxExcerpts {
+			excerpts[len(excerpts)-1].Truncated = true
+			break
+		}
+		excerpts = append(excerpts, Excerpt{
+			MatchOffset: match,
+			Start:       start,
+			End:         end,
+			Text:        string(body[start:end]),
+		})
+	}
+
+	return excerpts
+}
+
+// runeStart moves offset forward to the start of a UTF-8 sequence
+func runeStart(body []byte, offset int) int {
+	for offset < len(body) && !utf8.RuneStart(body[offset]) {
+		offset++
+	}
+	return offset
+}
+
+// runeEnd moves offset backward so the window does not cut a UTF-8 sequence in half
+func runeEnd(body []byte, offset int) int {
+	for offset < len(body) && offset > 0 && !utf8.RuneStart(body[offset]) {
+		offset--
+	}
+	return offset
+}
@@ -0,0 +1,49 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcerptMatchesWindow(t *testing.T) {
+	body := []byte(strings.Repeat("a", 100) + "needle" + strings.Repeat("b", 100))
+
+	excerpts := ExcerptMatches(body, "needle", 5, 0)
+
+	require.Len(t, excerpts, 1)
+	assert.Equal(t, 100, excerpts[0].MatchOffset)
+	assert.Equal(t, 95, excerpts[0].Start)
+	assert.Equal(t, 111, excerpts[0].End)
+	assert.Equal(t, "aaaaaneedlebbbbb", excerpts[0].Text)
+}
+
+func TestExcerptMatchesMergesOverlappingWindows(t *testing.T) {
+	body := []byte("xx needle yy needle zz")
+
+	excerpts := ExcerptMatches(body, "needle", 5, 0)
+
+	require.Len(t, excerpts, 1)
+	assert.Equal(t, string(body), excerpts[0].Text)
+}
+
+func TestExcerptMatchesLimit(t *testing.T) {
+	body := []byte(strings.Repeat("needle"+strings.Repeat(" ", 50), 5))
+
+	excerpts := ExcerptMatches(body, "needle", 2, 2)
+
+	require.Len(t, excerpts, 2)
+	assert.True(t, excerpts[1].Truncated)
+}
+
+func TestExcerptMatchesKeepsRunesWhole(t *testing.T) {
+	body := []byte("ééé needle ééé")
+
+	// A 4 byte window would cut the second é in half on both sides
+	excerpts := ExcerptMatches(body, "needle", 4, 0)
+
+	require.Len(t, excerpts, 1)
+	assert.Equal(t, "é needle é", excerpts[0].Text)
+}
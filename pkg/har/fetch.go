@@ -0,0 +1,268 @@
+package har
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Defaults of the fetch limits, so that a slow or huge remote file cannot hang or exhaust the server
+const (
+	DefaultFetchTimeoutSeconds = 120
+	DefaultFetchMaxBytes       = 512 << 20
+)
+
+// maxFetchRedirects caps the redirects followed when fetching a HAR file, as the default client does
+const maxFetchRedirects = 10
+
+// Limits reported by FetchLimitError
+const (
+	FetchLimitTimeout  = "timeout"
+	FetchLimitMaxBytes = "max_bytes"
+)
+
+// FetchOptions authenticate the requests fetching HAR files from HTTP URLs, for captures stored
+// behind single sign-on or in artifact stores
+type FetchOptions struct {
+	// BearerToken is sent in an Authorization: Bearer header
+	BearerToken string `json:"bearer_token,omitempty"`
+	// Username and Password are sent with HTTP basic authentication
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Headers are added to the requests, such as the API key header of an artifact store
+	Headers map[string]string `json:"headers,omitempty"`
+	// ClientCertFile and ClientKeyFile are the PEM files of the TLS client certificate presented
+	// to servers requiring mutual TLS
+	ClientCertFile string `json:"client_cert_file,omitempty"`
+	ClientKeyFile  string `json:"client_key_file,omitempty"`
+}
+
+// SetFetchOptions sets the options authenticating the requests fetching HAR files from HTTP
+// URLs, options given when parsing a source taking precedence. The client certificate is loaded
+// so that a misconfiguration is reported right away.
+func (p *Parser) SetFetchOptions(options FetchOptions) error {
+	if _, err := options.client(); err != nil {
+		return err
+	}
+	p.fetch = options
+	return nil
+}
+
+// FetchLimits bound the fetches of HAR files from HTTP URLs and object storage, reading the body
+// included. Zero values apply the defaults and negative values disable a limit.
+type FetchLimits struct {
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// MaxBytes caps the size of the fetched file, both before and after decompression so that
+	// compression bombs cannot exhaust memory
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// FetchLimitError reports a remote HAR file whose fetch exceeded the timeout or the size limit
+type FetchLimitError struct {
+	// Limit is the limit exceeded, FetchLimitTimeout or FetchLimitMaxBytes
+	Limit          string `json:"limit"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	MaxBytes       int64  `json:"max_bytes,omitempty"`
+	// Bytes is the size announced by the server, or the bytes read when giving up
+	Bytes int64 `json:"bytes,omitempty"`
+}
+
+// Error implements error
+func (e *FetchLimitError) Error() string {
+	if e.Limit == FetchLimitMaxBytes {
+		return fmt.Sprintf("HAR file exceeds the maximum fetch size of %d bytes", e.MaxBytes)
+	}
+	return fmt.Sprintf("HAR fetch exceeded the timeout of %d seconds", e.TimeoutSeconds)
+}
+
+// SetFetchLimits sets the timeout and size limit of the fetches of HAR files from HTTP URLs and
+// object storage
+func (p *Parser) SetFetchLimits(limits FetchLimits) {
+	p.fetchLimits = limits
+}
+
+// withDefaults returns the limits with the defaults applied to the zero values
+func (l FetchLimits) withDefaults() FetchLimits {
+	if l.TimeoutSeconds == 0 {
+		l.TimeoutSeconds = DefaultFetchTimeoutSeconds
+	}
+	if l.MaxBytes == 0 {
+		l.MaxBytes = DefaultFetchMaxBytes
+	}
+	return l
+}
+
+// context returns the context of a fetch, done once the timeout elapsed with a FetchLimitError as cause
+func (l FetchLimits) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.TimeoutSeconds < 0 {
+		return context.WithCancel(ctx)
+	}
+	cause := &FetchLimitError{Limit: FetchLimitTimeout, TimeoutSeconds: l.TimeoutSeconds}
+	return context.WithTimeoutCause(ctx, time.Duration(l.TimeoutSeconds)*time.Second, cause)
+}
+
+// parseRemote parses a fetched HAR file, its decompressed data being capped by the size limit
+func (p *Parser) parseRemote(body io.Reader) (*har.HAR, *ParseReport, error) {
+	return p.parse(body, "", p.fetchLimits.withDefaults().MaxBytes)
+}
+
+// merge returns the options with the fields set in override replacing theirs, headers being
+// merged. Credentials of one kind replace those of the other.
+func (o FetchOptions) merge(override FetchOptions) FetchOptions {
+	merged := o
+	if override.BearerToken != "" {
+		merged.BearerToken = override.BearerToken
+		merged.Username, merged.Password = "", ""
+	}
+	if override.Username != "" {
+		merged.Username, merged.Password = override.Username, override.Password
+		merged.BearerToken = ""
+	}
+	if len(override.Headers) > 0 {
+		merged.Headers = maps.Clone(o.Headers)
+		if merged.Headers == nil {
+			merged.Headers = make(map[string]string, len(override.Headers))
+		}
+		maps.Copy(merged.Headers, override.Headers)
+	}
+	if override.ClientCertFile != "" {
+		merged.ClientCertFile, merged.ClientKeyFile = override.ClientCertFile, override.ClientKeyFile
+	}
+	return merged
+}
+
+// client returns the HTTP client presenting the client certificate, if any
+func (o FetchOptions) client() (*http.Client, error) {
+	if o.BearerToken != "" && o.Username != "" {
+		return nil, errors.New("invalid fetch options: a bearer token and basic authentication cannot be used together")
+	}
+	if o.ClientCertFile == "" && o.ClientKeyFile == "" {
+		return &http.Client{CheckRedirect: o.checkRedirect}, nil
+	}
+	if o.ClientCertFile == "" || o.ClientKeyFile == "" {
+		return nil, errors.New("invalid fetch options: a client certificate needs both a certificate and a key file")
+	}
+	certificate, err := tls.LoadX509KeyPair(o.ClientCertFile, o.ClientKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fetch options: failed to load client certificate: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport, CheckRedirect: o.checkRedirect}, nil
+}
+
+// checkRedirect drops the headers of the options and the credentials from redirects to another
+// host than the one first requested, which Go only does for the Authorization and Cookie headers
+func (o FetchOptions) checkRedirect(request *http.Request, via []*http.Request) error {
+	if len(via) >= maxFetchRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+	}
+	if request.URL.Host != via[0].URL.Host {
+		for name := range o.Headers {
+			request.Header.Del(name)
+		}
+		request.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// openURL fetches a HAR file over HTTP with the given options and returns its body. The
+// Authorization header and the headers of the options are not forwarded when redirected to another host.
+func openURL(ctx context.Context, harURL string, options FetchOptions, limits FetchLimits) (*limitedBody, error) {
+	request, err := newFetchRequest(ctx, harURL, options)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case options.BearerToken != "":
+		request.Header.Set("Authorization", "Bearer "+options.BearerToken)
+	case options.Username != "":
+		request.SetBasicAuth(options.Username, options.Password)
+	}
+	return send(request, options, limits)
+}
+
+// newFetchRequest returns the request fetching a HAR file from the URL, with the headers of the options
+func newFetchRequest(ctx context.Context, harURL string, options FetchOptions) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, harURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch HAR from URL: %w", err)
+	}
+	for name, value := range options.Headers {
+		request.Header.Set(name, value)
+	}
+	return request, nil
+}
+
+// send sends the request with the client of the options and returns the body of the response,
+// failing when it is not a 200 or announces a body larger than the limit. Reading the body fails
+// once it exceeds the limit or the context of the request is done.
+func send(request *http.Request, options FetchOptions, limits FetchLimits) (*limitedBody, error) {
+	client, err := options.client()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		if request.Context().Err() != nil {
+			err = context.Cause(request.Context())
+		}
+		return nil, fmt.Errorf("failed to fetch HAR from URL: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to fetch HAR: HTTP %d", resp.StatusCode)
+	}
+	if limits.MaxBytes > 0 && resp.ContentLength > limits.MaxBytes {
+		resp.Body.Close() //nolint:errcheck
+		return nil, &FetchLimitError{Limit: FetchLimitMaxBytes, MaxBytes: limits.MaxBytes, Bytes: resp.ContentLength}
+	}
+
+	return &limitedBody{ctx: request.Context(), body: resp.Body, maxBytes: limits.MaxBytes}, nil
+}
+
+// limitedBody reads the body of a fetched HAR file, failing with a FetchLimitError once it
+// exceeds the size limit or the timeout elapsed
+type limitedBody struct {
+	ctx  context.Context
+	body io.ReadCloser
+	// maxBytes is the size limit, disabled when not positive
+	maxBytes int64
+	read     int64
+	// cancel releases the context of the fetch when the body is closed
+	cancel context.CancelFunc
+}
+
+// Read implements io.Reader
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.maxBytes > 0 && b.read+int64(n) > b.maxBytes {
+		// Hold back the bytes past the limit so the file cannot parse successfully
+		n = int(b.maxBytes - b.read)
+		b.read = b.maxBytes
+		return n, &FetchLimitError{Limit: FetchLimitMaxBytes, MaxBytes: b.maxBytes, Bytes: b.read}
+	}
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		return n, context.Cause(b.ctx)
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *limitedBody) Close() error {
+	err := b.body.Close()
+	if b.cancel != nil {
+		b.cancel()
+	}
+	return err
+}
@@ -0,0 +1,179 @@
+package har
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthenticatedHARServer serves the test HAR to requests carrying the expected Authorization
+// and X-Api-Key headers, answering 401 otherwise
+func newAuthenticatedHARServer(t *testing.T, authorization, apiKey string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization || r.Header.Get("X-Api-Key") != apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(createTestHAR()))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseSourceWithBearerToken(t *testing.T) {
+	server := newAuthenticatedHARServer(t, "Bearer s3cr3t", "")
+	parser := NewParser()
+
+	_, _, err := parser.ParseSourceWithReport(server.URL)
+	assert.ErrorContains(t, err, "HTTP 401")
+
+	harData, _, err := parser.ParseSourceWithOptions(context.Background(), server.URL, FetchOptions{BearerToken: "s3cr3t"})
+	require.NoError(t, err)
+	assert.Len(t, harData.Log.Entries, 1)
+}
+
+func TestParseSourceMergesFetchOptions(t *testing.T) {
+	server := newAuthenticatedHARServer(t, "Basic dXNlcjpwYXNz", "key")
+	parser := NewParser()
+	require.NoError(t, parser.SetFetchOptions(FetchOptions{BearerToken: "default", Headers: map[string]string{"X-Api-Key": "key"}}))
+
+	_, _, err := parser.ParseSourceWithReport(server.URL)
+	assert.ErrorContains(t, err, "HTTP 401")
+
+	// Basic authentication given when parsing replaces the default bearer token
+	_, _, err = parser.ParseSourceWithOptions(context.Background(), server.URL, FetchOptions{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+}
+
+func TestSetFetchOptionsRejectsInvalidOptions(t *testing.T) {
+	parser := NewParser()
+	assert.ErrorContains(t, parser.SetFetchOptions(FetchOptions{BearerToken: "token", Username: "user"}), "cannot be used together")
+	assert.ErrorContains(t, parser.SetFetchOptions(FetchOptions{ClientCertFile: "client.pem"}), "both a certificate and a key file")
+	assert.ErrorContains(t, parser.SetFetchOptions(FetchOptions{ClientCertFile: "missing.pem", ClientKeyFile: "missing.key"}), "failed to load client certificate")
+}
+
+func TestParseSourceEnforcesMaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// Flushing before writing the body leaves its size unannounced
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(createTestHAR()))
+	}))
+	t.Cleanup(server.Close)
+	parser := NewParser()
+	parser.SetFetchLimits(FetchLimits{MaxBytes: 100})
+
+	var limitErr *FetchLimitError
+	_, _, err := parser.ParseSourceWithReport(server.URL + "/announced")
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, FetchLimitMaxBytes, limitErr.Limit)
+	assert.Equal(t, int64(len(createTestHAR())), limitErr.Bytes)
+
+	_, _, err = parser.ParseSourceWithReport(server.URL + "/chunked")
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, int64(100), limitErr.MaxBytes)
+
+	parser.SetFetchLimits(FetchLimits{MaxBytes: -1})
+	_, _, err = parser.ParseSourceWithReport(server.URL + "/chunked")
+	require.NoError(t, err)
+}
+
+func TestParseSourceEnforcesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send the start of the file, then stall until the client gives up
+		_, _ = w.Write([]byte(`{"log": {"version": "1.2", "entries": [`))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	parser := NewParser()
+	parser.SetFetchLimits(FetchLimits{TimeoutSeconds: 1})
+
+	var limitErr *FetchLimitError
+	_, _, err := parser.ParseSourceWithReport(server.URL)
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, &FetchLimitError{Limit: FetchLimitTimeout, TimeoutSeconds: 1}, limitErr)
+}
+
+func TestParseSourceStopsWithContext(t *testing.T) {
+	server := newAuthenticatedHARServer(t, "", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := NewParser().ParseSourceWithOptions(ctx, server.URL, FetchOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParseSourceEnforcesMaxBytesOnceDecompressed(t *testing.T) {
+	// A small gzip file expanding far beyond the limit
+	bomb := gzipData(t, []byte(`{"log": {"version": "1.2", "comment": "`+strings.Repeat("x", 1<<20)+`", "entries": []}}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bomb)
+	}))
+	t.Cleanup(server.Close)
+	parser := NewParser()
+	parser.SetFetchLimits(FetchLimits{MaxBytes: 64 << 10})
+
+	var limitErr *FetchLimitError
+	_, _, err := parser.ParseSourceWithReport(server.URL)
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, &FetchLimitError{Limit: FetchLimitMaxBytes, MaxBytes: 64 << 10, Bytes: 64 << 10}, limitErr)
+}
+
+func TestParseSourceContextStopsWithContext(t *testing.T) {
+	server := newAuthenticatedHARServer(t, "", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewParser().ParseSourceContext(ctx, server.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParseFromURLContextStopsWithContext(t *testing.T) {
+	server := newAuthenticatedHARServer(t, "", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewParser().ParseFromURLContext(ctx, server.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParseSourceDropsHeadersOnRedirectsToAnotherHost(t *testing.T) {
+	var leaked []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("X-Api-Key"), r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(createTestHAR()))
+	}))
+	t.Cleanup(target.Close)
+	origin := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	t.Cleanup(origin.Close)
+
+	_, _, err := NewParser().ParseSourceWithOptions(context.Background(), origin.URL, FetchOptions{BearerToken: "s3cr3t", Headers: map[string]string{"X-Api-Key": "key"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", ""}, leaked)
+}
+
+func TestParseSourceKeepsHeadersOnRedirectsToTheSameHost(t *testing.T) {
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/capture.har", http.StatusFound)
+			return
+		}
+		apiKey = r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte(createTestHAR()))
+	}))
+	t.Cleanup(server.Close)
+
+	_, _, err := NewParser().ParseSourceWithOptions(context.Background(), server.URL+"/moved", FetchOptions{Headers: map[string]string{"X-Api-Key": "key"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "key", apiKey)
+}
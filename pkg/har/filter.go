@@ -0,0 +1,227 @@
+package har
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// EntryFilter selects entries, empty criteria match every entry
+type EntryFilter struct {
+	Method string `json:"method,omitempty"`
+	// Host is a host name, a glob such as *.example.com or a registrable domain such as
+	// site:example.co.uk, see MatchHost
+	Host        string `json:"host,omitempty"`
+	URLContains string `json:"url_contains,omitempty"`
+	PathPrefix  string `json:"path_prefix,omitempty"`
+	// Status is a comma separated list of status codes such as 404, classes such as 5xx
+	// or ranges such as 400-499
+	Status string `json:"status,omitempty"`
+	// MimeType is a MIME type such as application/json or a content family such as json
+	MimeType      string  `json:"mime_type,omitempty"`
+	MinDurationMS float64 `json:"min_duration_ms,omitempty"`
+	// StartedAfter and StartedBefore bound the startedDateTime, as RFC 3339 times
+	StartedAfter  string `json:"started_after,omitempty"`
+	StartedBefore string `json:"started_before,omitempty"`
+	// ResourceType is a comma separated list of the resource types Chrome DevTools records, such
+	// as xhr, fetch or document
+	ResourceType string `json:"resource_type,omitempty"`
+}
+
+// compiledFilter holds the parsed criteria of an EntryFilter
+type compiledFilter struct {
+	EntryFilter
+	matchStatus   func(int) bool
+	startedAfter  time.Time
+	startedBefore time.Time
+}
+
+// FilteredEntry summarizes an entry matching a filter
+type FilteredEntry struct {
+	RequestID  string  `json:"request_id"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Status     int     `json:"status"`
+	MimeType   string  `json:"mime_type"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Validate checks that the filter criteria are well-formed
+func (f EntryFilter) Validate() error {
+	_, err := f.compile()
+	return err
+}
+
+// compile parses the criteria of the filter
+func (f EntryFilter) compile() (*compiledFilter, error) {
+	compiled := &compiledFilter{EntryFilter: f}
+	var err error
+	if compiled.matchStatus, err = parseStatusFilter(f.Status); err != nil {
+		return nil, err
+	}
+	if err := ValidateHostPattern(f.Host); err != nil {
+		return nil, err
+	}
+	if compiled.startedAfter, err = parseFilterTime("started_after", f.StartedAfter); err != nil {
+		return nil, err
+	}
+	if compiled.startedBefore, err = parseFilterTime("started_before", f.StartedBefore); err != nil {
+		return nil, err
+	}
+	return compiled, nil
+}
+
+// parseFilterTime parses an RFC 3339 time bound, the zero time when empty
+func parseFilterTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: expected an RFC 3339 time such as 2023-01-01T10:00:00Z", name, value)
+	}
+	return parsed, nil
+}
+
+// FilterEntries returns the entries matching every criterion of the filter, in capture order.
+// Durations are read from the timing index, keeping fractional milliseconds, and the lookup
+// index narrows the entries checked on their method, exact host or status.
+func (p *Parser) FilterEntries(harData *har.HAR, index *TimingIndex, lookup *LookupIndex, fields *CustomFieldIndex, filter EntryFilter) ([]FilteredEntry, error) {
+	compiled, err := filter.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	redaction := p.redaction()
+	matches := []FilteredEntry{}
+	for _, i := range compiled.positions(harData, lookup) {
+		entry := harData.Log.Entries[i]
+		if entry == nil || entry.Request == nil || !compiled.matches(entry, index, fields) {
+			continue
+		}
+		matched := FilteredEntry{
+			RequestID:  formatRequestID(i),
+			Method:     entry.Request.Method,
+			URL:        redaction.text(entry.Request.URL),
+			MimeType:   responseMimeType(entry.Response),
+			DurationMS: index.Time(entry),
+		}
+		if entry.Response != nil {
+			matched.Status = entry.Response.Status
+		}
+		matches = append(matches, matched)
+	}
+
+	return matches, nil
+}
+
+// matches reports whether the entry satisfies every criterion of the filter
+func (f *compiledFilter) matches(entry *har.Entry, index *TimingIndex, fields *CustomFieldIndex) bool {
+	if f.Method != "" && !strings.EqualFold(entry.Request.Method, f.Method) {
+		return false
+	}
+	if f.Host != "" || f.PathPrefix != "" {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return false
+		}
+		if f.Host != "" && !MatchHost(f.Host, u.Hostname()) {
+			return false
+		}
+		if !strings.HasPrefix(u.Path, f.PathPrefix) {
+			return false
+		}
+	}
+	if f.URLContains != "" && !strings.Contains(entry.Request.URL, f.URLContains) {
+		return false
+	}
+	if !f.startedAfter.IsZero() && entry.StartedDateTime.Before(f.startedAfter) {
+		return false
+	}
+	if !f.startedBefore.IsZero() && !entry.StartedDateTime.Before(f.startedBefore) {
+		return false
+	}
+
+	status := 0
+	if entry.Response != nil {
+		status = entry.Response.Status
+	}
+	if !f.matchStatus(status) {
+		return false
+	}
+
+	if f.MimeType != "" {
+		mimeType := responseMimeType(entry.Response)
+		wanted := normalizeMimeType(f.MimeType)
+		if normalizeMimeType(mimeType) != wanted && mimeTypeFamily(mimeType) != wanted {
+			return false
+		}
+	}
+
+	if f.ResourceType != "" && !matchResourceType(f.ResourceType, fields.ResourceType(entry)) {
+		return false
+	}
+
+	return index.Time(entry) >= f.MinDurationMS
+}
+
+// parseStatusFilter parses a comma separated list of exact status codes (404),
+// status classes (5xx) and status ranges (400-499)
+func parseStatusFilter(spec string) (func(int) bool, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" {
+		return func(int) bool { return true }, nil
+	}
+
+	var ranges [][2]int
+	for _, part := range strings.Split(spec, ",") {
+		low, high, err := parseStatusRange(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status filter %q: expected status codes such as 404, classes such as 5xx or ranges such as 400-499", spec)
+		}
+		ranges = append(ranges, [2]int{low, high})
+	}
+	return func(status int) bool {
+		for _, r := range ranges {
+			if status >= r[0] && status <= r[1] {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// parseStatusRange parses a status code, class or range into its bounds
+func parseStatusRange(spec string) (int, int, error) {
+	if len(spec) == 3 && strings.HasSuffix(spec, "xx") && spec[0] >= '1' && spec[0] <= '5' {
+		class := int(spec[0]-'0') * 100
+		return class, class + 99, nil
+	}
+
+	lowSpec, highSpec, isRange := strings.Cut(spec, "-")
+	if !isRange {
+		highSpec = lowSpec
+	}
+	low, err := parseStatusCode(lowSpec)
+	if err != nil {
+		return 0, 0, err
+	}
+	high, err := parseStatusCode(highSpec)
+	if err != nil || high < low {
+		return 0, 0, fmt.Errorf("invalid status range %q", spec)
+	}
+	return low, high, nil
+}
+
+// parseStatusCode parses a status code between 100 and 599
+func parseStatusCode(spec string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(spec))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code %q", spec)
+	}
+	return code, nil
+}
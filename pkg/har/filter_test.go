@@ -0,0 +1,145 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFilterEntry builds an entry with the given status, content type and duration.
+func newTestFilterEntry(url string, status int, contentType string, durationMS int64) *har.Entry {
+	entry := newTestResponseEntry(url, contentType, "")
+	entry.Response.Status = status
+	entry.Time = durationMS
+	return entry
+}
+
+func TestFilterEntriesCombinesCriteria(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestFilterEntry("https://api.example.com/orders", 503, "application/json; charset=utf-8", 1500),
+		newTestFilterEntry("https://api.example.com/orders", 503, "application/json", 200),
+		newTestFilterEntry("https://api.example.com/orders", 200, "application/json", 1500),
+		newTestFilterEntry("https://staging.example.com/orders", 500, "application/json", 1500),
+		newTestFilterEntry("https://api.example.com/index", 502, "text/html", 1500),
+	)
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{
+		Host:          "API.example.com",
+		Status:        "5xx",
+		MimeType:      "json",
+		MinDurationMS: 1000,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, "request_0", matches[0].RequestID)
+	assert.Equal(t, 503, matches[0].Status)
+	assert.Equal(t, float64(1500), matches[0].DurationMS)
+}
+
+func TestFilterEntriesMatchesExactStatusAndMimeType(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestFilterEntry("https://example.com/a", 404, "text/html", 10),
+		newTestFilterEntry("https://example.com/b", 410, "text/html", 10),
+		newTestFilterEntry("https://example.com/c", 404, "application/json", 10),
+	)
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{Status: "404", MimeType: "text/html"})
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, "request_0", matches[0].RequestID)
+}
+
+func TestFilterEntriesEmptyFilterMatchesEverything(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestEntry("GET", "https://example.com/a"), newTestEntry("POST", "https://example.com/b"))
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{})
+	require.NoError(t, err)
+
+	assert.Len(t, matches, 2)
+}
+
+func TestFilterEntriesRejectsInvalidStatus(t *testing.T) {
+	parser := NewParser()
+
+	_, err := parser.FilterEntries(newTestHAR(), nil, nil, nil, EntryFilter{Status: "6xx"})
+	assert.Error(t, err)
+
+	assert.Error(t, EntryFilter{Status: "teapot"}.Validate())
+	assert.NoError(t, EntryFilter{Status: "2XX"}.Validate())
+}
+
+func TestFilterEntriesMatchesStatusRangesHostGlobsAndPathPrefixes(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestFilterEntry("https://eu.api.example.com/v2/orders", 404, "application/json", 10),
+		newTestFilterEntry("https://us.api.example.com/v2/orders/42", 503, "application/json", 10),
+		newTestFilterEntry("https://us.api.example.com/v1/orders", 503, "application/json", 10),
+		newTestFilterEntry("https://api.example.com/v2/orders", 500, "application/json", 10),
+		newTestFilterEntry("https://eu.api.example.com/v2/orders", 302, "application/json", 10),
+	)
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{Host: "*.api.example.com", PathPrefix: "/v2/", Status: "400-499, 5xx"})
+	require.NoError(t, err)
+
+	require.Len(t, matches, 2)
+	assert.Equal(t, "request_0", matches[0].RequestID)
+	assert.Equal(t, "request_1", matches[1].RequestID)
+}
+
+func TestFilterEntriesMatchesRegistrableDomains(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestFilterEntry("https://api.eu.example.co.uk/orders", 200, "application/json", 10),
+		newTestFilterEntry("https://other.co.uk/orders", 200, "application/json", 10),
+		newTestFilterEntry("https://example.co.uk/", 200, "text/html", 10),
+	)
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{Host: "site:example.co.uk"})
+	require.NoError(t, err)
+
+	require.Len(t, matches, 2)
+	assert.Equal(t, "request_0", matches[0].RequestID)
+	assert.Equal(t, "request_2", matches[1].RequestID)
+}
+
+func TestFilterEntriesMatchesStartedDateTimeRange(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/a", 0, 200, 10),
+		newTestAttempt("https://example.com/b", time.Second, 200, 10),
+		newTestAttempt("https://example.com/c", 2*time.Second, 200, 10),
+	)
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{StartedAfter: "2023-01-01T00:00:00.5Z", StartedBefore: "2023-01-01T00:00:02Z"})
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, "request_1", matches[0].RequestID)
+}
+
+func TestFilterEntriesRejectsInvalidCriteria(t *testing.T) {
+	assert.Error(t, EntryFilter{Status: "499-400"}.Validate())
+	assert.Error(t, EntryFilter{Host: "[api"}.Validate())
+	assert.Error(t, EntryFilter{StartedAfter: "yesterday"}.Validate())
+	assert.NoError(t, EntryFilter{Status: "404,5xx,300-399", StartedBefore: "2023-01-01T10:00:00+02:00"}.Validate())
+}
+
+func TestFilterEntriesRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(newTestFilterEntry("https://api.example.com/orders?key=sk_live_abc", 200, "application/json", 10))
+
+	matches, err := parser.FilterEntries(archive, nil, nil, nil, EntryFilter{})
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, "https://api.example.com/orders?key=[REDACTED]", matches[0].URL)
+}
@@ -0,0 +1,274 @@
+package har
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Categories of the composite findings
+const (
+	FindingErrors      = "errors"
+	FindingSecurity    = "security"
+	FindingPerformance = "performance"
+	FindingCaching     = "caching"
+	FindingContent     = "content"
+)
+
+const (
+	// maxFindingEvidence caps the request IDs listed per finding
+	maxFindingEvidence = 10
+	// slowRequestThresholdMS is the duration above which requests are reported as slow
+	slowRequestThresholdMS = 3000
+)
+
+// Finding is an issue reported by one of the analyzers, with the requests providing evidence
+type Finding struct {
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+	// Analyzer is the tool reporting the details of the finding, Check its rule in the analyzer
+	Analyzer string `json:"analyzer"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+	// Score ranks the finding, see ScoringPolicy
+	Score float64 `json:"score"`
+	// Occurrences counts the requests involved, when analyzers only list examples of them, and
+	// RequestIDs lists the first of them
+	Occurrences int      `json:"occurrences"`
+	RequestIDs  []string `json:"request_ids"`
+}
+
+// AllFindings is the severity-ranked list of findings of every analyzer
+type AllFindings struct {
+	Findings   []Finding      `json:"findings"`
+	BySeverity map[string]int `json:"by_severity"`
+	ByCategory map[string]int `json:"by_category"`
+	// Caveats qualify the performance and security findings of captures recorded behind a proxy,
+	// see DetectInterception
+	Caveats []string `json:"caveats,omitempty"`
+}
+
+// findingCollector deduplicates findings by check and message, merging their evidence
+type findingCollector struct {
+	findings map[string]*Finding
+	// requests holds every request ID of each finding, before capping the evidence
+	requests map[string][]string
+}
+
+// add records a finding, merging it with an identical finding of the same check
+func (c *findingCollector) add(finding Finding, requestIDs []string) {
+	key := strings.Join([]string{finding.Category, finding.Analyzer, finding.Check, finding.Message}, "\x00")
+	existing, ok := c.findings[key]
+	if !ok {
+		existing = &finding
+		c.findings[key] = existing
+	}
+	if severityRank(finding.Severity) < severityRank(existing.Severity) {
+		existing.Severity = finding.Severity
+	}
+	existing.Occurrences = max(existing.Occurrences, finding.Occurrences)
+	for _, requestID := range requestIDs {
+		if !slices.Contains(c.requests[key], requestID) {
+			c.requests[key] = append(c.requests[key], requestID)
+		}
+	}
+}
+
+// GetAllFindings runs the error, security, OAuth, token, performance, caching and content
+// analyzers and returns their findings in a single list, deduplicated and ranked by score, that
+// is by severity unless the scoring policy weighs checks or categories, then by the number of
+// requests involved. Each finding names the analyzer whose tool gives the details, and lists
+// the first requests providing evidence. Findings weighted 0 by the scoring policy are left out.
+func (p *Parser) GetAllFindings(harData *har.HAR) *AllFindings {
+	c := &findingCollector{findings: make(map[string]*Finding), requests: make(map[string][]string)}
+	p.collectErrorFindings(c, harData)
+	p.collectSecurityFindings(c, harData)
+	p.collectPerformanceFindings(c, harData)
+	p.collectCachingFindings(c, harData)
+	p.collectContentFindings(c, harData)
+
+	result := &AllFindings{Findings: []Finding{}, BySeverity: make(map[string]int), ByCategory: make(map[string]int)}
+	for key, finding := range c.findings {
+		weight := p.scoring.weight(finding.Category, finding.Check)
+		if weight == 0 {
+			continue
+		}
+		finding.Severity = p.scoring.severity(finding.Check, finding.Severity)
+		finding.Score = severityScores[finding.Severity] * weight
+		finding.Occurrences = max(finding.Occurrences, len(c.requests[key]), 1)
+		finding.RequestIDs = c.requests[key][:min(len(c.requests[key]), maxFindingEvidence)]
+		if finding.RequestIDs == nil {
+			finding.RequestIDs = []string{}
+		}
+		result.Findings = append(result.Findings, *finding)
+		result.BySeverity[finding.Severity]++
+		result.ByCategory[finding.Category]++
+	}
+	sort.Slice(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		if a.Occurrences != b.Occurrences {
+			return a.Occurrences > b.Occurrences
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Check != b.Check {
+			return a.Check < b.Check
+		}
+		return a.Message < b.Message
+	})
+	return result
+}
+
+// collectErrorFindings reports the failure groups of the error summary, server and network
+// failures being the most severe, and the successful responses carrying error payloads
+func (p *Parser) collectErrorFindings(c *findingCollector, harData *har.HAR) {
+	for _, group := range p.GetErrorSummary(harData, p.FindBeacons(harData, nil, nil)).Groups {
+		severity := SeverityMedium
+		if group.Kind == FailureServer || group.Kind == FailureNetwork {
+			severity = SeverityHigh
+		}
+		endpoint := group.Method + " " + group.Endpoint
+		if group.Operation != "" {
+			endpoint += " " + group.Operation
+		}
+		message := p.sprintf("%d %s failures on %s", group.Count, group.Kind, endpoint)
+		if len(group.Messages) > 0 {
+			message += ": " + group.Messages[0]
+		}
+		c.add(Finding{Severity: severity, Category: FindingErrors, Analyzer: "error_summary", Check: group.Kind, Message: message, Occurrences: group.Count}, group.RequestIDs)
+	}
+
+	for _, softError := range p.GetSoftErrors(harData).SoftErrors {
+		message := p.sprintf("%s %s returns %d with an error payload", softError.Method, templateURL(softError.URL), softError.Status)
+		c.add(Finding{Severity: SeverityMedium, Category: FindingErrors, Analyzer: "soft_error_report", Check: "soft_error", Message: message}, []string{softError.RequestID})
+	}
+}
+
+// collectSecurityFindings reports the security scan, the OAuth audit, the debug information
+// leaked and the tokens used after expiring
+func (p *Parser) collectSecurityFindings(c *findingCollector, harData *har.HAR) {
+	for _, finding := range p.ScanSecurity(harData).Findings {
+		c.add(Finding{Severity: finding.Severity, Category: FindingSecurity, Analyzer: "export_sarif", Check: finding.RuleID, Message: finding.Message}, finding.RequestIDs)
+	}
+	for _, finding := range p.AuditOAuth(harData).Findings {
+		c.add(Finding{Severity: finding.Severity, Category: FindingSecurity, Analyzer: "oauth_audit", Check: finding.Check, Message: finding.Message}, finding.RequestIDs)
+	}
+	for _, leak := range p.GetDebugLeaks(harData).Leaks {
+		endpoint := leak.Method + " " + templateURL(leak.URL)
+		severity, message := SeverityLow, ""
+		switch leak.Kind {
+		case LeakDebugPage:
+			severity, message = SeverityHigh, p.sprintf("%s serves a %s debug page", endpoint, leak.Detail)
+		case LeakStackTrace:
+			severity, message = SeverityMedium, p.sprintf("%s exposes a %s stack trace", endpoint, leak.Detail)
+		case LeakFilePath:
+			message = p.sprintf("%s exposes server file paths", endpoint)
+		case LeakInternalHost:
+			message = p.sprintf("responses expose the internal host %s", leak.Detail)
+		case LeakDebugHeader:
+			message = p.sprintf("responses expose the %s header", leak.Detail)
+		}
+		c.add(Finding{Severity: severity, Category: FindingSecurity, Analyzer: "debug_leak_report", Check: leak.Kind, Message: message}, []string{leak.RequestID})
+	}
+	for _, token := range p.GetTokenExpiryReport(harData).Tokens {
+		if token.ExpiredRequests == 0 {
+			continue
+		}
+		message := p.sprintf("token %s (%s) was sent after expiring at %s", token.Fingerprint, token.Location, token.ExpiresAt)
+		c.add(Finding{Severity: SeverityMedium, Category: FindingSecurity, Analyzer: "token_expiry_report", Check: "expired_token", Message: message, Occurrences: token.ExpiredRequests}, token.ExpiredRequestIDs)
+	}
+}
+
+// collectPerformanceFindings reports retry storms and slow requests
+func (p *Parser) collectPerformanceFindings(c *findingCollector, harData *har.HAR) {
+	for _, endpoint := range p.GetRetryStorms(harData, nil, 0, 0).Endpoints {
+		for _, burst := range endpoint.Bursts {
+			severity := SeverityMedium
+			if !burst.BackoffDetected && !burst.Recovered {
+				severity = SeverityHigh
+			}
+			format := "%d attempts of %s %s within %s ms"
+			if !burst.BackoffDetected {
+				format = "%d attempts of %s %s within %s ms without backoff"
+			}
+			message := p.sprintf(format, burst.Attempts, endpoint.Method, endpoint.URL, formatMilliseconds(burst.DurationMS))
+			requestIDs := make([]string, 0, len(burst.Timeline))
+			for _, attempt := range burst.Timeline {
+				requestIDs = append(requestIDs, attempt.RequestID)
+			}
+			c.add(Finding{Severity: severity, Category: FindingPerformance, Analyzer: "retry_storm_report", Check: "retry_storm", Message: message}, requestIDs)
+		}
+	}
+
+	var slow []string
+	for i, entry := range harData.Log.Entries {
+		if entry.Request != nil && entry.Time > slowRequestThresholdMS {
+			slow = append(slow, formatRequestID(i))
+		}
+	}
+	if len(slow) > 0 {
+		message := p.sprintf("requests taking longer than %d ms", slowRequestThresholdMS)
+		c.add(Finding{Severity: SeverityLow, Category: FindingPerformance, Analyzer: "get_har_stats", Check: "slow_request", Message: message}, slow)
+	}
+}
+
+// collectCachingFindings reports the query parameters defeating caches
+func (p *Parser) collectCachingFindings(c *findingCollector, harData *har.HAR) {
+	for _, param := range p.GetCacheBusters(harData).Parameters {
+		severity := SeverityLow
+		if param.CacheableRequests > 0 {
+			severity = SeverityMedium
+		}
+		message := p.sprintf("parameter %s of %s takes %d distinct values over %d requests, defeating caches", param.Name, param.Endpoint, param.DistinctValues, param.Requests)
+		c.add(Finding{Severity: severity, Category: FindingCaching, Analyzer: "cache_buster_report", Check: "cache_buster", Message: message, Occurrences: param.Requests}, param.RequestIDs)
+	}
+
+	for _, resource := range p.GetFreshness(harData, nil, 0, true, MaxFreshnessEntries).Resources {
+		severity, message := SeverityLow, p.message("responses served older than their freshness lifetime")
+		if isAPIMimeType(resource.MimeType) {
+			severity, message = SeverityMedium, p.message("API responses served stale by a cache or CDN")
+		}
+		c.add(Finding{Severity: severity, Category: FindingCaching, Analyzer: "freshness_report", Check: "stale_response", Message: message}, []string{resource.RequestID})
+	}
+}
+
+// collectContentFindings reports the responses whose declared MIME type contradicts their content,
+// the responses with conflicting headers and the exchanges breaking HTTP semantics
+func (p *Parser) collectContentFindings(c *findingCollector, harData *har.HAR) {
+	for _, mismatch := range p.GetMimeMismatches(harData).Mismatches {
+		message := p.sprintf("%s is declared as %s: %s", mismatch.URL, mismatch.DeclaredMimeType, strings.Join(mismatch.Reasons, "; "))
+		c.add(Finding{Severity: SeverityLow, Category: FindingContent, Analyzer: "mime_mismatch_report", Check: "mime_mismatch", Message: message}, []string{mismatch.RequestID})
+	}
+
+	for _, conflict := range p.GetHeaderConflicts(harData).Conflicts {
+		severity, category := SeverityLow, FindingContent
+		switch conflict.Check {
+		case ConflictCacheControl:
+			category = FindingCaching
+		case ConflictCookieDomain:
+			severity = SeverityMedium
+		}
+		c.add(Finding{Severity: severity, Category: category, Analyzer: "header_conflicts", Check: conflict.Check, Message: conflict.Message}, []string{conflict.RequestID})
+	}
+
+	descriptions := make(map[string]string, len(LintRules))
+	for _, rule := range LintRules {
+		descriptions[rule.ID] = rule.Description
+	}
+	for _, violation := range p.LintHTTP(harData).Violations {
+		severity := SeverityLow
+		if violation.Rule == LintLengthWithTransferCoding {
+			severity = SeverityMedium
+		}
+		c.add(Finding{Severity: severity, Category: FindingContent, Analyzer: "lint_http", Check: violation.Rule, Message: descriptions[violation.Rule]}, []string{violation.RequestID})
+	}
+}
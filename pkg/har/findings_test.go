@@ -0,0 +1,104 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAllFindingsRanksEveryAnalyzer(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api", 0, 503, 10),
+		newTestAttempt("https://example.com/api", 15*time.Millisecond, 503, 10),
+		newTestAttempt("https://example.com/api", 30*time.Millisecond, 503, 10),
+		newTestAttempt("http://example.com/report", time.Second, 200, 4500),
+	)
+
+	result := parser.GetAllFindings(archive)
+
+	require.Len(t, result.Findings, 4)
+	assert.Equal(t, Finding{
+		Severity:    SeverityHigh,
+		Category:    FindingErrors,
+		Analyzer:    "error_summary",
+		Check:       FailureServer,
+		Message:     "3 http_5xx failures on GET https://example.com/api",
+		Score:       9,
+		Occurrences: 3,
+		RequestIDs:  []string{"request_0", "request_1", "request_2"},
+	}, result.Findings[0])
+	assert.Equal(t, "retry_storm", result.Findings[1].Check)
+	assert.Equal(t, SeverityHigh, result.Findings[1].Severity)
+	assert.Equal(t, "3 attempts of GET https://example.com/api within 40 ms without backoff", result.Findings[1].Message)
+	assert.Equal(t, RuleInsecureTransport, result.Findings[2].Check)
+	assert.Equal(t, FindingSecurity, result.Findings[2].Category)
+	assert.Equal(t, Finding{
+		Severity:    SeverityLow,
+		Category:    FindingPerformance,
+		Analyzer:    "get_har_stats",
+		Check:       "slow_request",
+		Message:     "requests taking longer than 3000 ms",
+		Score:       1,
+		Occurrences: 1,
+		RequestIDs:  []string{"request_3"},
+	}, result.Findings[3])
+	assert.Equal(t, map[string]int{SeverityHigh: 2, SeverityMedium: 1, SeverityLow: 1}, result.BySeverity)
+	assert.Equal(t, map[string]int{FindingErrors: 1, FindingPerformance: 2, FindingSecurity: 1}, result.ByCategory)
+}
+
+func TestFindingCollectorMergesDuplicates(t *testing.T) {
+	c := &findingCollector{findings: make(map[string]*Finding), requests: make(map[string][]string)}
+	c.add(Finding{Severity: SeverityLow, Category: FindingContent, Analyzer: "mime_mismatch_report", Check: "mime_mismatch", Message: "same"}, []string{"request_0"})
+	c.add(Finding{Severity: SeverityMedium, Category: FindingContent, Analyzer: "mime_mismatch_report", Check: "mime_mismatch", Message: "same"}, []string{"request_0", "request_4"})
+
+	require.Len(t, c.findings, 1)
+	for key, finding := range c.findings {
+		assert.Equal(t, SeverityMedium, finding.Severity)
+		assert.Equal(t, []string{"request_0", "request_4"}, c.requests[key])
+	}
+}
+
+func TestGetAllFindingsOnCleanCapture(t *testing.T) {
+	parser := NewParser()
+
+	result := parser.GetAllFindings(newTestHAR(newTestEntry("GET", "https://example.com/")))
+
+	assert.Empty(t, result.Findings)
+	assert.NotNil(t, result.Findings)
+}
+
+func TestGetAllFindingsReportsStaleAPIResponses(t *testing.T) {
+	parser := NewParser()
+	stale := newTestFreshnessEntry("https://api.example.com/prices", "application/json",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 10:00:00 GMT"})
+
+	result := parser.GetAllFindings(newTestHAR(stale))
+
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, SeverityMedium, result.Findings[0].Severity)
+	assert.Equal(t, "freshness_report", result.Findings[0].Analyzer)
+	assert.Equal(t, []string{"request_0"}, result.Findings[0].RequestIDs)
+}
+
+func TestGetAllFindingsReportsDebugPages(t *testing.T) {
+	parser := NewParser()
+	page := newTestResponseEntry("https://staging.example.com/orders/42", "text/html", "<h1>Whitelabel Error Page</h1>")
+	page.Response.Status = 404
+
+	result := parser.GetAllFindings(newTestHAR(page))
+
+	var debugPages []Finding
+	for _, finding := range result.Findings {
+		if finding.Analyzer == "debug_leak_report" {
+			debugPages = append(debugPages, finding)
+		}
+	}
+	require.Len(t, debugPages, 1)
+	assert.Equal(t, SeverityHigh, debugPages[0].Severity)
+	assert.Equal(t, "GET https://staging.example.com/orders/{id} serves a Spring Boot debug page", debugPages[0].Message)
+}
@@ -0,0 +1,146 @@
+package har
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Representatives picked by SummarizeFlow for each endpoint of a flow
+const (
+	FlowPickRecent = "recent"
+	FlowPickMedian = "median"
+)
+
+// DefaultFlowBodyBytes caps the body excerpts of the representative requests
+const DefaultFlowBodyBytes = 300
+
+// FlowRequest condenses the details of the request representing an endpoint
+type FlowRequest struct {
+	RequestID       string  `json:"request_id"`
+	URL             string  `json:"url"`
+	Status          int     `json:"status"`
+	MimeType        string  `json:"mime_type,omitempty"`
+	StartedDateTime string  `json:"started_date_time"`
+	DurationMS      float64 `json:"duration_ms"`
+	// RequestBody and ResponseBody are excerpts of the bodies with secrets redacted
+	RequestBody  string `json:"request_body,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+// FlowEndpoint groups the requests of a flow sharing a method and URL template
+type FlowEndpoint struct {
+	Method   string `json:"method"`
+	Template string `json:"template"`
+	Calls    int    `json:"calls"`
+	// Statuses counts the responses by status code, 0 standing for requests without response
+	Statuses       map[int]int `json:"statuses"`
+	MedianMS       float64     `json:"median_ms"`
+	Representative FlowRequest `json:"representative"`
+}
+
+// FlowSummary is a compact picture of a flow, one representative request per endpoint
+type FlowSummary struct {
+	Requests int    `json:"requests"`
+	Pick     string `json:"pick"`
+	// Endpoints are in the order the flow first calls them
+	Endpoints []FlowEndpoint `json:"endpoints"`
+}
+
+// SummarizeFlow returns one representative request per method and URL template of the entries,
+// with condensed details, so agents get a faithful picture of a flow in a single call. pick
+// selects the most recent request of each endpoint or the one closest to its median duration.
+// Entries without request, such as those left out of a page or a subset, are skipped. Durations
+// are read from the timing index and bodies are cut to bodyBytes.
+func (p *Parser) SummarizeFlow(harData *har.HAR, index *TimingIndex, pick string, bodyBytes int) (*FlowSummary, error) {
+	if pick == "" {
+		pick = FlowPickRecent
+	}
+	if pick != FlowPickRecent && pick != FlowPickMedian {
+		return nil, fmt.Errorf("invalid pick %q, expected %s or %s", pick, FlowPickRecent, FlowPickMedian)
+	}
+	if bodyBytes <= 0 {
+		bodyBytes = DefaultFlowBodyBytes
+	}
+
+	positions := make(map[string][]int)
+	var order []string
+	summary := &FlowSummary{Pick: pick, Endpoints: []FlowEndpoint{}}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		key := entry.Request.Method + " " + templateURL(entry.Request.URL)
+		if _, ok := positions[key]; !ok {
+			order = append(order, key)
+		}
+		positions[key] = append(positions[key], i)
+		summary.Requests++
+	}
+
+	redaction := p.redaction()
+	for _, key := range order {
+		endpoint := FlowEndpoint{Statuses: make(map[int]int)}
+		durations := make([]float64, 0, len(positions[key]))
+		for _, i := range positions[key] {
+			entry := harData.Log.Entries[i]
+			endpoint.Calls++
+			endpoint.Statuses[responseStatus(entry)]++
+			durations = append(durations, index.Time(entry))
+		}
+		endpoint.MedianMS = median(durations)
+
+		representative := positions[key][0]
+		for n, i := range positions[key] {
+			entry := harData.Log.Entries[i]
+			chosen := harData.Log.Entries[representative]
+			switch pick {
+			case FlowPickRecent:
+				if !entry.StartedDateTime.Before(chosen.StartedDateTime) {
+					representative = i
+				}
+			case FlowPickMedian:
+				if math.Abs(durations[n]-endpoint.MedianMS) < math.Abs(index.Time(chosen)-endpoint.MedianMS) {
+					representative = i
+				}
+			}
+		}
+
+		entry := harData.Log.Entries[representative]
+		endpoint.Method = entry.Request.Method
+		endpoint.Template = templateURL(entry.Request.URL)
+		endpoint.Representative = p.newFlowRequest(representative, entry, index, redaction, bodyBytes)
+		summary.Endpoints = append(summary.Endpoints, endpoint)
+	}
+
+	return summary, nil
+}
+
+// responseStatus returns the status of the response of the entry, 0 when it has none
+func responseStatus(entry *har.Entry) int {
+	if entry.Response == nil {
+		return 0
+	}
+	return entry.Response.Status
+}
+
+// newFlowRequest condenses the entry at position i, redacting secrets and cutting bodies
+func (p *Parser) newFlowRequest(i int, entry *har.Entry, index *TimingIndex, redaction *redactor, bodyBytes int) FlowRequest {
+	request := FlowRequest{
+		RequestID:       formatRequestID(i),
+		URL:             redaction.text(entry.Request.URL),
+		Status:          responseStatus(entry),
+		MimeType:        responseMimeType(entry.Response),
+		StartedDateTime: p.formatTime(entry.StartedDateTime, time.RFC3339Nano),
+		DurationMS:      index.Time(entry),
+	}
+	if postData := entry.Request.PostData; postData != nil && postData.Text != "" {
+		request.RequestBody = truncateBody(compactBody([]byte(postData.Text), redaction), bodyBytes)
+	}
+	if entry.Response != nil && entry.Response.Content != nil && isPrintableText(entry.Response.Content.Text) {
+		request.ResponseBody = truncateBody(compactBody(entry.Response.Content.Text, redaction), bodyBytes)
+	}
+	return request
+}
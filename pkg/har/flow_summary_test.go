@@ -0,0 +1,76 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFlowHAR builds a flow calling a user endpoint three times and a login endpoint once
+func newTestFlowHAR() *har.HAR {
+	login := newTestAttempt("https://example.com/login", 0, 200, 40)
+	login.Request.Method = "POST"
+	login.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"user": "alice", "password": "hunter2"}`}
+	return newTestHAR(
+		login,
+		newTestAttempt("https://example.com/users/1", time.Second, 200, 10),
+		newTestAttempt("https://example.com/users/2", 2*time.Second, 200, 500),
+		newTestAttempt("https://example.com/users/3", 3*time.Second, 404, 30),
+	)
+}
+
+func TestSummarizeFlowGroupsByTemplate(t *testing.T) {
+	summary, err := NewParser().SummarizeFlow(newTestFlowHAR(), nil, "", 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, summary.Requests)
+	assert.Equal(t, FlowPickRecent, summary.Pick)
+	require.Len(t, summary.Endpoints, 2)
+	assert.Equal(t, "POST", summary.Endpoints[0].Method)
+	users := summary.Endpoints[1]
+	assert.Equal(t, "https://example.com/users/{id}", users.Template)
+	assert.Equal(t, 3, users.Calls)
+	assert.Equal(t, map[int]int{200: 2, 404: 1}, users.Statuses)
+	assert.Equal(t, float64(30), users.MedianMS)
+	assert.Equal(t, "request_3", users.Representative.RequestID, "the most recent request represents the endpoint")
+}
+
+func TestSummarizeFlowPicksMedianLatency(t *testing.T) {
+	summary, err := NewParser().SummarizeFlow(newTestFlowHAR(), nil, FlowPickMedian, 0)
+	require.NoError(t, err)
+
+	require.Len(t, summary.Endpoints, 2)
+	assert.Equal(t, "request_3", summary.Endpoints[1].Representative.RequestID)
+	assert.Equal(t, float64(30), summary.Endpoints[1].Representative.DurationMS)
+}
+
+func TestSummarizeFlowRedactsAndCutsBodies(t *testing.T) {
+	summary, err := NewParser().SummarizeFlow(newTestFlowHAR(), nil, "", 30)
+	require.NoError(t, err)
+
+	body := summary.Endpoints[0].Representative.RequestBody
+	assert.NotContains(t, body, "hunter2")
+	assert.LessOrEqual(t, len(body), 30)
+}
+
+func TestSummarizeFlowSkipsEntriesOutOfScope(t *testing.T) {
+	parser := NewParser()
+	subset, err := parser.Subset(newTestFlowHAR(), []string{"request_2"})
+	require.NoError(t, err)
+
+	summary, err := parser.SummarizeFlow(subset, nil, "", 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, summary.Requests)
+	require.Len(t, summary.Endpoints, 1)
+	assert.Equal(t, "request_2", summary.Endpoints[0].Representative.RequestID)
+}
+
+func TestSummarizeFlowRejectsUnknownPick(t *testing.T) {
+	_, err := NewParser().SummarizeFlow(newTestFlowHAR(), nil, "fastest", 0)
+
+	assert.ErrorContains(t, err, "invalid pick")
+}
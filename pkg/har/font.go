@@ -0,0 +1,283 @@
+package har
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Timings of the font downloads relative to the first paint of their page
+const (
+	// FontBeforePaint is a font downloaded before the first paint
+	FontBeforePaint = "before_paint"
+	// FontBlockingPaint is a font requested before the first paint and still downloading then,
+	// the text using it being rendered invisible or with a fallback font
+	FontBlockingPaint = "blocking_paint"
+	// FontAfterPaint is a font requested after the first paint, as lazily loaded fonts are
+	FontAfterPaint = "after_paint"
+)
+
+// maxFontRequests caps the font requests listed in the font report
+const maxFontRequests = 50
+
+// fontFormats maps font MIME types and URL extensions to their format
+var fontFormats = map[string]string{
+	"font/woff2":                    "woff2",
+	"font/woff":                     "woff",
+	"application/font-woff":         "woff",
+	"application/font-woff2":        "woff2",
+	"font/ttf":                      "ttf",
+	"font/sfnt":                     "ttf",
+	"application/x-font-ttf":        "ttf",
+	"application/x-font-truetype":   "ttf",
+	"font/otf":                      "otf",
+	"application/x-font-opentype":   "otf",
+	"application/vnd.ms-fontobject": "eot",
+	".woff2":                        "woff2",
+	".woff":                         "woff",
+	".ttf":                          "ttf",
+	".otf":                          "otf",
+	".eot":                          "eot",
+}
+
+var (
+	// fontFacePattern matches the @font-face rules of a stylesheet
+	fontFacePattern = regexp.MustCompile(`(?is)@font-face\s*\{([^}]*)\}`)
+	// fontFamilyPattern matches the font-family descriptor of a @font-face rule
+	fontFamilyPattern = regexp.MustCompile(`(?is)font-family\s*:\s*([^;]+)`)
+	// cssURLPattern matches the url() references of a CSS declaration
+	cssURLPattern = regexp.MustCompile(`(?is)url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+)
+
+// FontRequest is a web font download
+type FontRequest struct {
+	RequestID string `json:"request_id"`
+	URL       string `json:"url"`
+	Host      string `json:"host"`
+	// Family is the font family the file belongs to, FamilySource telling whether it was read from
+	// a @font-face rule of a captured stylesheet ("css") or guessed from the URL ("url")
+	Family       string `json:"family"`
+	FamilySource string `json:"family_source"`
+	Format       string `json:"format"`
+	Status       int    `json:"status"`
+	Bytes        int64  `json:"bytes"`
+	// StartMS and EndMS are the milliseconds from the first paint surrogate of the page to the start
+	// and the end of the download, negative before it
+	StartMS    *float64 `json:"start_ms,omitempty"`
+	EndMS      *float64 `json:"end_ms,omitempty"`
+	DurationMS float64  `json:"duration_ms"`
+	// Timing places the download relative to the first paint surrogate, empty when the page has none
+	Timing string `json:"timing,omitempty"`
+}
+
+// DuplicateFontFamily is a font family downloaded from several origins
+type DuplicateFontFamily struct {
+	Family     string   `json:"family"`
+	Hosts      []string `json:"hosts"`
+	RequestIDs []string `json:"request_ids"`
+	Bytes      int64    `json:"bytes"`
+}
+
+// FontReport summarizes the web fonts of a capture and whether they delayed text rendering
+type FontReport struct {
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+	// Formats counts the font requests per format
+	Formats     map[string]int `json:"formats"`
+	BeforePaint int            `json:"before_paint"`
+	// BlockingPaint counts the fonts still downloading at the first paint of their page
+	BlockingPaint int `json:"blocking_paint"`
+	AfterPaint    int `json:"after_paint"`
+	// Duplicates lists the font families downloaded from more than one origin
+	Duplicates []DuplicateFontFamily `json:"duplicates"`
+	// Fonts lists the first font requests, in capture order
+	Fonts     []FontRequest `json:"fonts"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// ReportFonts summarizes the web font downloads: their formats, sizes and families, read from
+// the @font-face rules of the captured stylesheets or else guessed from the URLs. HAR files do
+// not record paints, the first paint of a page is approximated by its DOMContentLoaded event or,
+// when the page timings are missing, by the end of its first HTML document. Fonts still
+// downloading then were blocking the rendering of the text using them. Families downloaded from
+// more than one origin, as when self-hosting a font also loaded from a font CDN, are reported as
+// duplicates. Page references and timings are read from the indexes.
+func (p *Parser) ReportFonts(harData *har.HAR, pages *PageIndex, timings *TimingIndex) *FontReport {
+	report := &FontReport{Formats: make(map[string]int), Duplicates: []DuplicateFontFamily{}, Fonts: []FontRequest{}}
+	redaction := p.redaction()
+	families := fontFaceFamilies(harData)
+
+	paints := make(map[string]time.Time)
+	for _, page := range pages.Pages() {
+		if page.PageTimings != nil && page.PageTimings.OnContentLoad != nil {
+			paints[page.ID] = page.StartedDateTime.Add(durationOf(*page.PageTimings.OnContentLoad))
+		}
+	}
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		ref := pages.PageRef(entry)
+		if _, ok := paints[ref]; !ok && mimeTypeFamily(responseMimeType(entry.Response)) == familyHTML {
+			paints[ref] = timings.End(entry)
+		}
+	}
+
+	var duplicates []*DuplicateFontFamily
+	byFamily := make(map[string]*DuplicateFontFamily)
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		format := fontFormat(entry)
+		if format == "" {
+			continue
+		}
+		font := FontRequest{
+			RequestID:    formatRequestID(i),
+			URL:          redaction.text(entry.Request.URL),
+			Host:         requestHost(entry),
+			Family:       families[withoutFragment(entry.Request.URL)],
+			FamilySource: "css",
+			Format:       format,
+			Status:       entry.Response.Status,
+			Bytes:        responseBodySize(entry.Response),
+			DurationMS:   timings.Time(entry),
+		}
+		if font.Family == "" {
+			font.Family, font.FamilySource = fontFamilyFromURL(entry.Request.URL), "url"
+		}
+		if paint, ok := paints[pages.PageRef(entry)]; ok {
+			start, end := milliseconds(entry.StartedDateTime.Sub(paint)), milliseconds(timings.End(entry).Sub(paint))
+			font.StartMS, font.EndMS = &start, &end
+			switch {
+			case end <= 0:
+				font.Timing = FontBeforePaint
+				report.BeforePaint++
+			case start < 0:
+				font.Timing = FontBlockingPaint
+				report.BlockingPaint++
+			default:
+				font.Timing = FontAfterPaint
+				report.AfterPaint++
+			}
+		}
+
+		report.Requests++
+		report.Bytes += font.Bytes
+		report.Formats[format]++
+		if len(report.Fonts) < maxFontRequests {
+			report.Fonts = append(report.Fonts, font)
+		} else {
+			report.Truncated = true
+		}
+
+		if font.Family == "" {
+			continue
+		}
+		key := fontFamilyKey(font.Family)
+		family, ok := byFamily[key]
+		if !ok {
+			family = &DuplicateFontFamily{Family: font.Family}
+			byFamily[key] = family
+			duplicates = append(duplicates, family)
+		}
+		if !slices.Contains(family.Hosts, font.Host) {
+			family.Hosts = append(family.Hosts, font.Host)
+		}
+		family.RequestIDs = append(family.RequestIDs, font.RequestID)
+		family.Bytes += font.Bytes
+	}
+
+	for _, family := range duplicates {
+		if len(family.Hosts) > 1 {
+			report.Duplicates = append(report.Duplicates, *family)
+		}
+	}
+	return report
+}
+
+// fontFormat returns the format of the font the entry downloaded, from its MIME type or else its
+// URL extension, empty when it is not a font
+func fontFormat(entry *har.Entry) string {
+	if format, ok := fontFormats[normalizeMimeType(responseMimeType(entry.Response))]; ok {
+		return format
+	}
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return ""
+	}
+	return fontFormats[strings.ToLower(path.Ext(u.Path))]
+}
+
+// fontFaceFamilies maps the URLs of the font files referenced by the @font-face rules of the
+// captured stylesheets to their font family
+func fontFaceFamilies(harData *har.HAR) map[string]string {
+	families := make(map[string]string)
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil || entry.Response.Content == nil {
+			continue
+		}
+		if mimeTypeFamily(responseMimeType(entry.Response)) != familyCSS && extensionFamily(entry.Request.URL) != familyCSS {
+			continue
+		}
+		base, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		decoded, _ := decodedResponse(entry.Response)
+		for _, rule := range fontFacePattern.FindAllStringSubmatch(string(decoded.Content.Text), -1) {
+			family := fontFamilyPattern.FindStringSubmatch(rule[1])
+			if family == nil {
+				continue
+			}
+			name := strings.Trim(strings.TrimSpace(family[1]), `"'`)
+			for _, reference := range cssURLPattern.FindAllStringSubmatch(rule[1], -1) {
+				if target, err := base.Parse(strings.TrimSpace(reference[1])); err == nil {
+					families[withoutFragment(target.String())] = name
+				}
+			}
+		}
+	}
+	return families
+}
+
+// withoutFragment returns the URL without its fragment, which @font-face rules use to select a
+// font in SVG and EOT files and the server never sees
+func withoutFragment(rawURL string) string {
+	key, _, _ := strings.Cut(rawURL, "#")
+	return key
+}
+
+// fontFamilyFromURL guesses the font family from the URL of a font file: the family directory
+// of Google Fonts URLs, or else the file name up to its first separator, as in Roboto-Bold.woff2
+func fontFamilyFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) > 2 && segments[0] == "s" && strings.HasSuffix(u.Host, "gstatic.com") {
+		return segments[1]
+	}
+	name := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	if i := strings.IndexAny(name, "-_."); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// fontFamilyKey normalizes a family name so that "Open Sans", "open-sans" and "OpenSans" match
+func fontFamilyKey(family string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(family))
+}
@@ -0,0 +1,76 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFontHAR returns a page declaring Open Sans in its stylesheet, downloading it from its own
+// origin before the document ends and again from Google Fonts, then an icon font after it
+func newTestFontHAR() *har.HAR {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(url, mimeType string, offset, elapsed int, size int64) *har.Entry {
+		e := newTestEntry("GET", url)
+		e.StartedDateTime = start.Add(time.Duration(offset) * time.Millisecond)
+		e.Time = int64(elapsed)
+		e.Response.Content.MimeType = mimeType
+		e.Response.BodySize = size
+		return e
+	}
+	stylesheet := entry("https://example.com/app.css", "text/css", 50, 20, 200)
+	stylesheet.Response.Content.Text = []byte(`@font-face { font-family: "Open Sans"; src: url(/fonts/open-sans.woff2) format("woff2"), url('/fonts/open-sans.woff') format("woff"); }`)
+	return newTestHAR(
+		entry("https://example.com/", "text/html", 0, 100, 5000),
+		stylesheet,
+		entry("https://example.com/fonts/open-sans.woff2", "font/woff2", 60, 30, 1000),
+		entry("https://fonts.gstatic.com/s/opensans/v34/memSYaGs126MiZpBA.woff2", "font/woff2", 80, 100, 2000),
+		entry("https://example.com/static/Icons-Regular.ttf", "application/octet-stream", 300, 10, 500),
+	)
+}
+
+func TestReportFonts(t *testing.T) {
+	report := NewParser().ReportFonts(newTestFontHAR(), nil, nil)
+
+	assert.Equal(t, 3, report.Requests)
+	assert.Equal(t, int64(3500), report.Bytes)
+	assert.Equal(t, map[string]int{"woff2": 2, "ttf": 1}, report.Formats)
+	assert.Equal(t, 1, report.BeforePaint)
+	assert.Equal(t, 1, report.BlockingPaint)
+	assert.Equal(t, 1, report.AfterPaint)
+
+	require.Len(t, report.Fonts, 3)
+	assert.Equal(t, []string{"Open Sans", "opensans", "Icons"}, []string{report.Fonts[0].Family, report.Fonts[1].Family, report.Fonts[2].Family})
+	assert.Equal(t, []string{"css", "url", "url"}, []string{report.Fonts[0].FamilySource, report.Fonts[1].FamilySource, report.Fonts[2].FamilySource})
+	assert.Equal(t, []string{FontBeforePaint, FontBlockingPaint, FontAfterPaint}, []string{report.Fonts[0].Timing, report.Fonts[1].Timing, report.Fonts[2].Timing})
+	require.NotNil(t, report.Fonts[1].StartMS)
+	assert.Equal(t, -20.0, *report.Fonts[1].StartMS)
+	assert.Equal(t, 80.0, *report.Fonts[1].EndMS)
+
+	assert.Equal(t, []DuplicateFontFamily{{
+		Family:     "Open Sans",
+		Hosts:      []string{"example.com", "fonts.gstatic.com"},
+		RequestIDs: []string{"request_2", "request_3"},
+		Bytes:      3000,
+	}}, report.Duplicates)
+}
+
+func TestReportFontsUsesDOMContentLoaded(t *testing.T) {
+	harData := newTestFontHAR()
+	onContentLoad := 200.0
+	pages := &PageIndex{
+		pages: []Page{{ID: "page_1", StartedDateTime: harData.Log.Entries[0].StartedDateTime, PageTimings: &PageTimings{OnContentLoad: &onContentLoad}}},
+		refs:  make(map[*har.Entry]string),
+	}
+	for _, entry := range harData.Log.Entries {
+		pages.refs[entry] = "page_1"
+	}
+
+	report := NewParser().ReportFonts(harData, pages, nil)
+	assert.Equal(t, 2, report.BeforePaint)
+	assert.Equal(t, 0, report.BlockingPaint)
+	assert.Equal(t, 1, report.AfterPaint)
+}
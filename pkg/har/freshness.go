@@ -0,0 +1,211 @@
+package har
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultStaleAfter is the age from which API responses without a freshness lifetime are
+	// reported stale when no threshold is given
+	DefaultStaleAfter = 10 * time.Minute
+	// DefaultFreshnessEntries is the number of responses returned when no limit is given
+	DefaultFreshnessEntries = 50
+	// MaxFreshnessEntries caps the number of responses returned at once
+	MaxFreshnessEntries = 1000
+)
+
+// cacheStatusHeaders are the headers CDNs and caching proxies report hits and misses with
+var cacheStatusHeaders = []string{"CF-Cache-Status", "X-Cache", "X-Cache-Status", "X-Proxy-Cache", "Cache-Status"}
+
+// ResourceFreshness describes how old a response was when it was received
+type ResourceFreshness struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Status    int    `json:"status"`
+	MimeType  string `json:"mime_type"`
+	Date      string `json:"date,omitempty"`
+	// AgeSeconds is the age of the response when received: the Age header of caches, or the
+	// time since its Date when older, as computed by HTTP caches
+	AgeSeconds int64 `json:"age_seconds"`
+	// LifetimeSeconds is the freshness lifetime granted by s-maxage, max-age or Expires
+	LifetimeSeconds *int64 `json:"lifetime_seconds,omitempty"`
+	LastModified    string `json:"last_modified,omitempty"`
+	// ContentAgeSeconds is the time between the last modification of the content and its Date
+	ContentAgeSeconds *int64 `json:"content_age_seconds,omitempty"`
+	// CacheStatus is the hit or miss reported by a CDN, such as the CF-Cache-Status header
+	CacheStatus string `json:"cache_status,omitempty"`
+	Stale       bool   `json:"stale"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// FreshnessReport lists how stale the responses were when received, oldest first
+type FreshnessReport struct {
+	StaleAfterSeconds int64 `json:"stale_after_seconds"`
+	// Dated counts the responses with a Date or Age header, the others having no known age
+	Dated int `json:"dated"`
+	Stale int `json:"stale"`
+	// Truncated is set when only the oldest responses are returned
+	Truncated bool                `json:"truncated,omitempty"`
+	Resources []ResourceFreshness `json:"resources"`
+}
+
+// GetFreshness reports the age of the responses when received, from their Date, Age, Expires,
+// Cache-Control and Last-Modified headers, to catch CDNs and caches serving outdated content.
+// A response is stale when older than its freshness lifetime or, for API responses without
+// one, than staleAfter. Only stale responses are listed when onlyStale is set.
+func (p *Parser) GetFreshness(harData *har.HAR, index *TimingIndex, staleAfter time.Duration, onlyStale bool, limit int) *FreshnessReport {
+	if staleAfter <= 0 {
+		staleAfter = DefaultStaleAfter
+	}
+	if limit <= 0 {
+		limit = DefaultFreshnessEntries
+	}
+	limit = min(limit, MaxFreshnessEntries)
+
+	redaction := p.redaction()
+	report := &FreshnessReport{StaleAfterSeconds: int64(staleAfter.Seconds()), Resources: []ResourceFreshness{}}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil || entry.Response.Status == 0 {
+			continue
+		}
+		freshness, ok := responseFreshness(entry, index, staleAfter)
+		if !ok {
+			continue
+		}
+		report.Dated++
+		if freshness.Stale {
+			report.Stale++
+		} else if onlyStale {
+			continue
+		}
+		freshness.RequestID = formatRequestID(i)
+		freshness.URL = redaction.text(freshness.URL)
+		report.Resources = append(report.Resources, freshness)
+	}
+
+	sort.SliceStable(report.Resources, func(i, j int) bool {
+		return report.Resources[i].AgeSeconds > report.Resources[j].AgeSeconds
+	})
+	if len(report.Resources) > limit {
+		report.Resources = report.Resources[:limit]
+		report.Truncated = true
+	}
+	return report
+}
+
+// responseFreshness computes the age of the response of the entry, false when it has neither
+// Date nor Age header
+func responseFreshness(entry *har.Entry, index *TimingIndex, staleAfter time.Duration) (ResourceFreshness, bool) {
+	headers := entry.Response.Headers
+	freshness := ResourceFreshness{
+		Method:   entry.Request.Method,
+		URL:      entry.Request.URL,
+		Status:   entry.Response.Status,
+		MimeType: responseMimeType(entry.Response),
+	}
+
+	date, dated := parseHTTPDate(headerValue(headers, "Date"))
+	ageHeader, aged := parseDeltaSeconds(headerValue(headers, "Age"))
+	if !dated && !aged {
+		return freshness, false
+	}
+	if dated {
+		freshness.Date = headerValue(headers, "Date")
+		// The apparent age is negative when the server clock runs ahead, caches clamp it to 0
+		freshness.AgeSeconds = max(0, int64(index.End(entry).Sub(date).Seconds()))
+	}
+	if aged {
+		freshness.AgeSeconds = max(freshness.AgeSeconds, ageHeader)
+	}
+
+	if lastModified, ok := parseHTTPDate(headerValue(headers, "Last-Modified")); ok && dated {
+		freshness.LastModified = headerValue(headers, "Last-Modified")
+		contentAge := max(0, int64(date.Sub(lastModified).Seconds()))
+		freshness.ContentAgeSeconds = &contentAge
+	}
+	for _, name := range cacheStatusHeaders {
+		if value := headerValue(headers, name); value != "" {
+			freshness.CacheStatus = value
+			break
+		}
+	}
+
+	freshness.LifetimeSeconds = freshnessLifetime(headers, date, dated)
+	age := time.Duration(freshness.AgeSeconds) * time.Second
+	switch {
+	case freshness.LifetimeSeconds != nil && freshness.AgeSeconds > *freshness.LifetimeSeconds:
+		freshness.Stale = true
+		freshness.Reason = "older than its freshness lifetime of " + strconv.FormatInt(*freshness.LifetimeSeconds, 10) + "s"
+	case freshness.LifetimeSeconds == nil && isAPIMimeType(freshness.MimeType) && age > staleAfter:
+		freshness.Stale = true
+		freshness.Reason = "API response older than " + staleAfter.String()
+	}
+	return freshness, true
+}
+
+// freshnessLifetime returns the freshness lifetime granted to the response: s-maxage, max-age,
+// or the time between Date and Expires, nil when the response grants none
+func freshnessLifetime(headers []har.Header, date time.Time, dated bool) *int64 {
+	var maxAge *int64
+	for _, directive := range strings.Split(headerValue(headers, "Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		seconds, ok := parseDeltaSeconds(strings.Trim(value, `"`))
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "s-maxage":
+			return &seconds
+		case "max-age":
+			maxAge = &seconds
+		}
+	}
+	if maxAge != nil {
+		return maxAge
+	}
+
+	// Invalid Expires values such as 0 mean already expired
+	expiresHeader := headerValue(headers, "Expires")
+	if expiresHeader == "" || !dated {
+		return nil
+	}
+	lifetime := int64(0)
+	if expires, ok := parseHTTPDate(expiresHeader); ok {
+		lifetime = max(0, int64(expires.Sub(date).Seconds()))
+	}
+	return &lifetime
+}
+
+// isAPIMimeType reports whether a MIME type carries data for scripts rather than a page or an asset
+func isAPIMimeType(mimeType string) bool {
+	switch mimeTypeFamily(mimeType) {
+	case familyJSON, familyXML:
+		return true
+	}
+	return false
+}
+
+// parseHTTPDate parses an HTTP date such as Sun, 06 Nov 1994 08:49:37 GMT
+func parseHTTPDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	date, err := http.ParseTime(strings.TrimSpace(value))
+	return date, err == nil
+}
+
+// parseDeltaSeconds parses a non-negative number of seconds such as the Age header
+func parseDeltaSeconds(value string) (int64, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return seconds, true
+}
@@ -0,0 +1,106 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFreshnessStart is when the test requests are sent
+var testFreshnessStart = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestFreshnessEntry(url, contentType string, headers ...har.Header) *har.Entry {
+	entry := newTestResponseEntry(url, contentType, "")
+	entry.StartedDateTime = testFreshnessStart
+	entry.Time = 200
+	entry.Response.Headers = append(entry.Response.Headers, headers...)
+	return entry
+}
+
+func TestGetFreshnessReportsAges(t *testing.T) {
+	parser := NewParser()
+	// A CDN serving a JSON response cached two hours ago without a lifetime
+	cached := newTestFreshnessEntry("https://api.example.com/prices", "application/json",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 10:00:00 GMT"},
+		har.Header{Name: "Last-Modified", Value: "Fri, 01 Mar 2024 09:00:00 GMT"},
+		har.Header{Name: "CF-Cache-Status", Value: "HIT"})
+	// An image within its one day lifetime, its age reported by the cache
+	image := newTestFreshnessEntry("https://cdn.example.com/logo.png", "image/png",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 12:00:00 GMT"},
+		har.Header{Name: "Age", Value: "3600"},
+		har.Header{Name: "Cache-Control", Value: "public, max-age=86400"})
+	// A stylesheet past its Expires
+	expired := newTestFreshnessEntry("https://cdn.example.com/site.css", "text/css",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 10:30:00 GMT"},
+		har.Header{Name: "Expires", Value: "Fri, 01 Mar 2024 11:00:00 GMT"})
+	undated := newTestFreshnessEntry("https://example.com/", "text/html")
+
+	report := parser.GetFreshness(newTestHAR(cached, image, expired, undated), nil, 0, false, 0)
+
+	assert.Equal(t, int64(600), report.StaleAfterSeconds)
+	assert.Equal(t, 3, report.Dated)
+	assert.Equal(t, 2, report.Stale)
+	require.Len(t, report.Resources, 3)
+
+	assert.Equal(t, "request_0", report.Resources[0].RequestID)
+	assert.Equal(t, int64(7200), report.Resources[0].AgeSeconds)
+	assert.Nil(t, report.Resources[0].LifetimeSeconds)
+	require.NotNil(t, report.Resources[0].ContentAgeSeconds)
+	assert.Equal(t, int64(3600), *report.Resources[0].ContentAgeSeconds)
+	assert.Equal(t, "HIT", report.Resources[0].CacheStatus)
+	assert.True(t, report.Resources[0].Stale)
+	assert.Equal(t, "API response older than 10m0s", report.Resources[0].Reason)
+
+	assert.Equal(t, "request_2", report.Resources[1].RequestID)
+	assert.Equal(t, int64(5400), report.Resources[1].AgeSeconds)
+	require.NotNil(t, report.Resources[1].LifetimeSeconds)
+	assert.Equal(t, int64(1800), *report.Resources[1].LifetimeSeconds)
+	assert.True(t, report.Resources[1].Stale)
+
+	assert.Equal(t, "request_1", report.Resources[2].RequestID)
+	assert.Equal(t, int64(3600), report.Resources[2].AgeSeconds)
+	assert.Equal(t, int64(86400), *report.Resources[2].LifetimeSeconds)
+	assert.False(t, report.Resources[2].Stale)
+}
+
+func TestGetFreshnessOnlyStaleAndLimit(t *testing.T) {
+	parser := NewParser()
+	fresh := newTestFreshnessEntry("https://api.example.com/me", "application/json",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 11:59:00 GMT"})
+	stale := newTestFreshnessEntry("https://api.example.com/feed", "application/json",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 11:00:00 GMT"},
+		har.Header{Name: "Cache-Control", Value: "max-age=60, s-maxage=600"})
+	staler := newTestFreshnessEntry("https://api.example.com/news", "application/json",
+		har.Header{Name: "Age", Value: "5000"})
+	archive := newTestHAR(fresh, stale, staler)
+
+	report := parser.GetFreshness(archive, nil, 30*time.Second, true, 0)
+
+	assert.Equal(t, 3, report.Dated)
+	assert.Equal(t, 3, report.Stale)
+	require.Len(t, report.Resources, 3)
+	assert.Equal(t, "request_2", report.Resources[0].RequestID)
+	assert.Equal(t, int64(600), *report.Resources[1].LifetimeSeconds)
+
+	report = parser.GetFreshness(archive, nil, 0, true, 1)
+
+	assert.Equal(t, 2, report.Stale)
+	assert.True(t, report.Truncated)
+	require.Len(t, report.Resources, 1)
+	assert.Equal(t, "request_2", report.Resources[0].RequestID)
+}
+
+func TestGetFreshnessClampsServerClockSkew(t *testing.T) {
+	parser := NewParser()
+	ahead := newTestFreshnessEntry("https://api.example.com/me", "application/json",
+		har.Header{Name: "Date", Value: "Fri, 01 Mar 2024 12:05:00 GMT"})
+
+	report := parser.GetFreshness(newTestHAR(ahead), nil, 0, false, 0)
+
+	require.Len(t, report.Resources, 1)
+	assert.Equal(t, int64(0), report.Resources[0].AgeSeconds)
+	assert.False(t, report.Resources[0].Stale)
+}
@@ -0,0 +1,77 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/tjamet/har-mcp/pkg/har/hartest"
+)
+
+// fuzzSeeds are HAR documents shaped like browser exports, including the quirks of the flexible path
+var fuzzSeeds = []string{
+	// Firefox exports fractional times and timings
+	`{"log": {"version": "1.2", "creator": {"name": "Firefox", "version": "121.0"}, "entries": [{"startedDateTime": "2024-01-15T10:30:00.000+01:00", "time": 12.5, "request": {"method": "GET", "url": "https://example.com/", "httpVersion": "HTTP/2", "headers": [], "queryString": [], "cookies": [], "headersSize": 320, "bodySize": 0}, "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/2", "headers": [], "cookies": [], "content": {"size": 5, "mimeType": "text/plain", "text": "hello"}, "redirectURL": "", "headersSize": 120, "bodySize": 5}, "cache": {}, "timings": {"blocked": 0.25, "dns": -1, "connect": -1, "ssl": -1, "send": 0.1, "wait": 10.4, "receive": 1.75}}]}}`,
+	// Safari exports stringified numbers and a creator string
+	`{"log": {"version": "1.2", "creator": "WebKit Web Inspector", "entries": [{"startedDateTime": "2024-01-15T10:30:00.000Z", "time": "42", "request": {"method": "POST", "url": "https://example.com/api?page=2", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [{"name": "page", "value": 2}], "postData": {"mimeType": "application/x-www-form-urlencoded", "params": ["a=1", {"name": "b", "value": true}], "text": "a=1&b=true"}, "headersSize": "-1", "bodySize": "10"}, "response": {"status": "201", "statusText": "Created", "httpVersion": "HTTP/1.1", "headers": [], "content": {"size": "4", "mimeType": "application/json", "text": "e30K", "encoding": "base64"}, "redirectURL": "", "headersSize": -1, "bodySize": "4"}, "timings": {"send": 1, "wait": 40, "receive": 1}, "connection": 443}]}}`,
+	// Malformed exports recovered in lenient mode
+	`{"log": {"version": "1.2", "creator": {"name": "tool", "version": "1"}, "entries": [{"startedDateTime": "2024-01-15T10:30:00Z", "time": NaN, "request": {"method": "GET", "url": "https://example.com/",}, "response": {"status": 200, "content": {"size": Infinity, "mimeType": "text/html"},},},]}}`,
+	`{"log": {"entries": [null, {}, {"request": null}]}}`,
+	`{"log": null}`,
+	`[]`,
+	``,
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	if example, err := os.ReadFile("../../example.har"); err == nil {
+		f.Add(example)
+	}
+	for _, style := range hartest.Styles {
+		f.Add(hartest.Generate(3, style))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, lenient := range []bool{false, true} {
+			parser := NewParser()
+			parser.SetLenient(lenient)
+			harData, report, err := parser.ParseWithReport(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			if harData == nil || harData.Log == nil || report == nil {
+				t.Fatalf("parsing succeeded without a log (lenient: %v)", lenient)
+			}
+
+			// Walk the parsed document the way the tools do
+			parser.GetURLsAndMethods(harData, nil)
+			parser.GetHARInfo(harData, report)
+			for i := range harData.Log.Entries {
+				if _, err := parser.GetRequestDetails(harData, nil, nil, formatRequestID(i)); err != nil {
+					t.Fatalf("parsed entry %d has no details: %v", i, err)
+				}
+			}
+		}
+	})
+}
+
+func FuzzFlexibleContentToStandardContent(f *testing.F) {
+	f.Add([]byte(`{"size": 5, "mimeType": "text/plain", "text": "hello"}`))
+	f.Add([]byte(`{"size": "4", "mimeType": "application/json", "text": "e30K", "encoding": "base64"}`))
+	f.Add([]byte(`{"size": 3, "mimeType": "image/png", "text": "not base64!", "encoding": "base64"}`))
+	f.Add([]byte(`{"size": 2.5, "text": 42}`))
+	f.Add([]byte(`{"text": null, "encoding": "gzip"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var content FlexibleContent
+		if err := json.Unmarshal(data, &content); err != nil {
+			return
+		}
+		if content.ToStandardContent() == nil {
+			t.Fatal("decoded content converted to nil")
+		}
+	})
+}
@@ -0,0 +1,332 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// JSON value kinds recorded by jsonShape besides scalar types
+const (
+	kindNull       = "null"
+	kindObject     = "object"
+	kindArray      = "array"
+	kindEmptyArray = "empty array"
+)
+
+// unsafeFileNameChars matches characters replaced when deriving fixture file names
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// GoldenFixture is a reference response for an endpoint, identified by method and URL template
+type GoldenFixture struct {
+	Method   string `json:"method"`
+	Template string `json:"template"`
+	Status   int    `json:"status"`
+	MimeType string `json:"mime_type"`
+	Body     string `json:"body,omitempty"`
+	// RecordedFrom is the URL of the request the fixture was recorded from
+	RecordedFrom string `json:"recorded_from"`
+}
+
+// GoldenDrift lists how an entry differs from the golden fixture of its endpoint
+type GoldenDrift struct {
+	RequestID string   `json:"request_id"`
+	Method    string   `json:"method"`
+	Template  string   `json:"template"`
+	Changes   []string `json:"changes"`
+}
+
+// GoldenReport is the result of comparing a capture against golden fixtures
+type GoldenReport struct {
+	Fixtures int `json:"fixtures"`
+	Checked  int `json:"checked"`
+	Matching int `json:"matching"`
+	// Drifts lists the entries that differ from their fixture
+	Drifts []GoldenDrift `json:"drifts"`
+	// Untested lists the fixtures no entry of the capture exercised
+	Untested []string `json:"untested"`
+}
+
+// NewGoldenFixture records the response of an entry as the golden fixture of its endpoint, with
+// the redaction policy applied to its body and URL since fixtures are written to disk
+func (p *Parser) NewGoldenFixture(harData *har.HAR, requestID string) (*GoldenFixture, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Request == nil || entry.Response == nil {
+		return nil, fmt.Errorf("request %s has no response", requestID)
+	}
+	redaction := p.redaction()
+
+	fixture := &GoldenFixture{
+		Method:       entry.Request.Method,
+		Template:     templateURL(entry.Request.URL),
+		Status:       entry.Response.Status,
+		MimeType:     normalizeMimeType(responseMimeType(entry.Response)),
+		RecordedFrom: redaction.text(entry.Request.URL),
+	}
+	if entry.Response.Content != nil && isPrintableText(entry.Response.Content.Text) {
+		fixture.Body = redaction.text(string(entry.Response.Content.Text))
+	}
+
+	return fixture, nil
+}
+
+// key identifies the endpoint of the fixture
+func (f *GoldenFixture) key() string {
+	return f.Method + " " + f.Template
+}
+
+// GoldenWrite describes the file storing a golden fixture
+type GoldenWrite struct {
+	Path  string `json:"path"`
+	Bytes int    `json:"bytes"`
+	// Replaces tells whether a previous fixture of the same endpoint is overwritten
+	Replaces bool `json:"replaces"`
+}
+
+// encodeGoldenFixture returns the path of the file storing the fixture in dir and its content
+func encodeGoldenFixture(dir string, fixture *GoldenFixture) (string, []byte, error) {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal golden fixture: %w", err)
+	}
+
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(fixture.key(), "_"), "_") + ".json"
+	return filepath.Join(dir, name), data, nil
+}
+
+// PreviewGoldenFixture describes the file SaveGoldenFixture would write, without touching disk
+func PreviewGoldenFixture(dir string, fixture *GoldenFixture) (*GoldenWrite, error) {
+	path, data, err := encodeGoldenFixture(dir, fixture)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(path)
+	return &GoldenWrite{Path: path, Bytes: len(data), Replaces: err == nil}, nil
+}
+
+// SaveGoldenFixture writes the fixture to dir, replacing the previous fixture of the same endpoint
+func SaveGoldenFixture(dir string, fixture *GoldenFixture) (string, error) {
+	path, data, err := encodeGoldenFixture(dir, fixture)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create golden directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write golden fixture: %w", err)
+	}
+
+	return path, nil
+}
+
+// LoadGoldenFixtures reads every fixture stored in dir, a missing directory holds no fixture
+func LoadGoldenFixtures(dir string) ([]GoldenFixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list golden fixtures: %w", err)
+	}
+
+	fixtures := make([]GoldenFixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read golden fixture: %w", err)
+		}
+		var fixture GoldenFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse golden fixture %s: %w", filepath.Base(path), err)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// CheckAgainstGolden compares every entry whose endpoint has a golden fixture against it.
+// Status and MIME type must match exactly. JSON bodies are compared by structure (field
+// names and types) since values such as IDs and timestamps legitimately change between
+// captures, other bodies must be identical once redacted as fixtures are.
+func (p *Parser) CheckAgainstGolden(harData *har.HAR, fixtures []GoldenFixture) *GoldenReport {
+	redaction := p.redaction()
+	report := &GoldenReport{
+		Fixtures: len(fixtures),
+		Drifts:   []GoldenDrift{},
+		Untested: []string{},
+	}
+
+	byKey := make(map[string]*GoldenFixture, len(fixtures))
+	for i := range fixtures {
+		byKey[fixtures[i].key()] = &fixtures[i]
+	}
+
+	tested := make(map[string]bool)
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		key := resourceKey(entry)
+		fixture, ok := byKey[key]
+		if !ok {
+			continue
+		}
+		tested[key] = true
+		report.Checked++
+
+		changes := p.goldenChanges(fixture, entry.Response, redaction)
+		if len(changes) == 0 {
+			report.Matching++
+			continue
+		}
+		report.Drifts = append(report.Drifts, GoldenDrift{
+			RequestID: formatRequestID(i),
+			Method:    fixture.Method,
+			Template:  fixture.Template,
+			Changes:   changes,
+		})
+	}
+
+	for _, fixture := range fixtures {
+		if !tested[fixture.key()] {
+			report.Untested = append(report.Untested, fixture.key())
+		}
+	}
+	sort.Strings(report.Untested)
+
+	return report
+}
+
+// goldenChanges describes how a response differs from its fixture
+func (p *Parser) goldenChanges(fixture *GoldenFixture, response *har.Response, redaction *redactor) []string {
+	var changes []string
+	if response.Status != fixture.Status {
+		changes = append(changes, p.sprintf("status changed from %d to %d", fixture.Status, response.Status))
+	}
+	mimeType := normalizeMimeType(responseMimeType(response))
+	if mimeType != fixture.MimeType {
+		changes = append(changes, p.sprintf("MIME type changed from %q to %q", fixture.MimeType, mimeType))
+	}
+
+	var body []byte
+	if response.Content != nil {
+		body = []byte(redaction.text(string(response.Content.Text)))
+	}
+
+	goldenShape, goldenErr := jsonShape([]byte(fixture.Body))
+	shape, err := jsonShape(body)
+	if goldenErr != nil || err != nil {
+		if !bytes.Equal(body, []byte(fixture.Body)) {
+			changes = append(changes, p.message("body changed"))
+		}
+		return changes
+	}
+
+	for _, path := range sortedKeys(goldenShape) {
+		if hasOpaqueAncestor(path, goldenShape, shape) {
+			continue
+		}
+		kind, ok := shape[path]
+		switch {
+		case !ok:
+			changes = append(changes, p.sprintf("field %s removed", path))
+		case !compatibleKinds(goldenShape[path], kind):
+			changes = append(changes, p.sprintf("field %s changed type from %s to %s", path, goldenShape[path], kind))
+		}
+	}
+	for _, path := range sortedKeys(shape) {
+		if _, ok := goldenShape[path]; !ok && !hasOpaqueAncestor(path, goldenShape, shape) {
+			changes = append(changes, p.sprintf("field %s added", path))
+		}
+	}
+
+	return changes
+}
+
+// compatibleKinds reports whether two JSON value kinds can describe the same field,
+// null and empty arrays telling nothing about the type of the field
+func compatibleKinds(a, b string) bool {
+	switch {
+	case a == b, a == kindNull, b == kindNull:
+		return true
+	default:
+		return strings.HasSuffix(a, kindArray) && strings.HasSuffix(b, kindArray)
+	}
+}
+
+// hasOpaqueAncestor reports whether a parent of path is null or an empty array in
+// either shape, in which case the structure below it cannot be compared
+func hasOpaqueAncestor(path string, shapes ...map[string]string) bool {
+	for i := 1; i < len(path); i++ {
+		if path[i] != '.' && path[i] != '[' {
+			continue
+		}
+		for _, shape := range shapes {
+			if kind := shape[path[:i]]; kind == kindNull || kind == kindEmptyArray {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonShape maps the JSONPath of every value in a JSON document to its type.
+// Array items share the [] path so that arrays of different lengths have the same shape.
+func jsonShape(body []byte) (map[string]string, error) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	shape := make(map[string]string)
+	var walk func(value interface{}, path string)
+	walk = func(value interface{}, path string) {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			shape[path] = kindObject
+			for key, item := range typed {
+				walk(item, path+"."+key)
+			}
+		case []interface{}:
+			// An empty array never hides the items seen in another one
+			switch {
+			case len(typed) > 0:
+				shape[path] = kindArray
+			case shape[path] != kindArray:
+				shape[path] = kindEmptyArray
+			}
+			for _, item := range typed {
+				walk(item, path+"[]")
+			}
+		case string:
+			shape[path] = "string"
+		case float64:
+			shape[path] = "number"
+		case bool:
+			shape[path] = "boolean"
+		case nil:
+			// null carries no type information, keep the type seen elsewhere if any
+			if _, ok := shape[path]; !ok {
+				shape[path] = kindNull
+			}
+		}
+	}
+	walk(document, "$")
+
+	return shape, nil
+}
@@ -0,0 +1,144 @@
+package har
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenFixturesRoundTrip(t *testing.T) {
+	parser := NewParser()
+	dir := filepath.Join(t.TempDir(), "golden")
+	archive := newTestHAR(newTestResponseEntry("https://api.example.com/users/42?expand=true", "application/json", `{"id": 42}`))
+
+	fixture, err := parser.NewGoldenFixture(archive, "request_0")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/users/{id}", fixture.Template)
+
+	path, err := SaveGoldenFixture(dir, fixture)
+	require.NoError(t, err)
+	assert.Equal(t, "GET_https_api.example.com_users_id.json", filepath.Base(path))
+
+	fixtures, err := LoadGoldenFixtures(dir)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 1)
+	assert.Equal(t, *fixture, fixtures[0])
+}
+
+func TestPreviewGoldenFixtureDoesNotWrite(t *testing.T) {
+	parser := NewParser()
+	dir := filepath.Join(t.TempDir(), "golden")
+	archive := newTestHAR(newTestResponseEntry("https://api.example.com/users/42", "application/json", `{"id": 42}`))
+	fixture, err := parser.NewGoldenFixture(archive, "request_0")
+	require.NoError(t, err)
+
+	preview, err := PreviewGoldenFixture(dir, fixture)
+	require.NoError(t, err)
+	assert.False(t, preview.Replaces)
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "previewing must not create the golden directory")
+
+	path, err := SaveGoldenFixture(dir, fixture)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, GoldenWrite{Path: path, Bytes: int(info.Size()), Replaces: false}, *preview)
+
+	preview, err = PreviewGoldenFixture(dir, fixture)
+	require.NoError(t, err)
+	assert.True(t, preview.Replaces)
+}
+
+func TestLoadGoldenFixturesMissingDirectory(t *testing.T) {
+	fixtures, err := LoadGoldenFixtures(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, fixtures)
+}
+
+func TestLoadGoldenFixturesInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644))
+
+	_, err := LoadGoldenFixtures(dir)
+	assert.ErrorContains(t, err, "broken.json")
+}
+
+func TestCheckAgainstGoldenReportsStructuralDrift(t *testing.T) {
+	parser := NewParser()
+	golden := newTestHAR(newTestResponseEntry("https://api.example.com/users/1", "application/json",
+		`{"id": 1, "name": "alice", "tags": ["a"], "manager": null, "age": 30}`))
+	fixture, err := parser.NewGoldenFixture(golden, "request_0")
+	require.NoError(t, err)
+
+	capture := newTestHAR(
+		newTestResponseEntry("https://api.example.com/users/2", "application/json",
+			`{"id": 2, "name": "bob", "tags": [], "manager": {"id": 1}, "age": 41}`),
+		newTestResponseEntry("https://api.example.com/users/3", "application/json",
+			`{"id": "3", "tags": ["b", "c"], "manager": null, "age": 12, "email": "carol@example.com"}`),
+		newTestResponseEntry("https://api.example.com/orders/1", "application/json", `{}`),
+	)
+
+	report := parser.CheckAgainstGolden(capture, []GoldenFixture{*fixture})
+
+	assert.Equal(t, 1, report.Fixtures)
+	assert.Equal(t, 2, report.Checked)
+	assert.Equal(t, 1, report.Matching)
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, "request_1", report.Drifts[0].RequestID)
+	assert.Equal(t, []string{
+		"field $.id changed type from number to string",
+		"field $.name removed",
+		"field $.email added",
+	}, report.Drifts[0].Changes)
+	assert.Empty(t, report.Untested)
+}
+
+func TestCheckAgainstGoldenReportsStatusAndBodyChanges(t *testing.T) {
+	parser := NewParser()
+	fixtures := []GoldenFixture{
+		{Method: "GET", Template: "https://example.com/robots.txt", Status: 200, MimeType: "text/plain", Body: "User-agent: *"},
+		{Method: "GET", Template: "https://example.com/health", Status: 200, MimeType: "text/plain", Body: "ok"},
+	}
+	entry := newTestResponseEntry("https://example.com/robots.txt", "text/html", "<html>")
+	entry.Response.Status = 404
+	capture := newTestHAR(entry)
+
+	report := parser.CheckAgainstGolden(capture, fixtures)
+
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, []string{
+		"status changed from 200 to 404",
+		`MIME type changed from "text/plain" to "text/html"`,
+		"body changed",
+	}, report.Drifts[0].Changes)
+	assert.Equal(t, []string{"GET https://example.com/health"}, report.Untested)
+}
+
+func TestNewGoldenFixtureRedactsBody(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(newTestResponseEntry("https://api.example.com/keys?key=sk_live_abc", "text/plain", "key: sk_live_abc"))
+
+	fixture, err := parser.NewGoldenFixture(archive, "request_0")
+
+	require.NoError(t, err)
+	assert.Equal(t, "key: "+redactedValue, fixture.Body)
+	assert.Equal(t, "https://api.example.com/keys?key="+redactedValue, fixture.RecordedFrom)
+}
+
+func TestCheckAgainstGoldenComparesRedactedBodies(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	golden := newTestHAR(newTestResponseEntry("https://api.example.com/keys", "text/plain", "key: sk_live_abc"))
+	fixture, err := parser.NewGoldenFixture(golden, "request_0")
+	require.NoError(t, err)
+	capture := newTestHAR(newTestResponseEntry("https://api.example.com/keys", "text/plain", "key: sk_live_def"))
+
+	report := parser.CheckAgainstGolden(capture, []GoldenFixture{*fixture})
+
+	assert.Equal(t, 1, report.Matching)
+	assert.Empty(t, report.Drifts)
+}
@@ -0,0 +1,103 @@
+package har
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// graphQLOperationPattern extracts the type and name of an operation from a GraphQL document
+var graphQLOperationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// graphQLRequest is the body of a GraphQL request over HTTP
+type graphQLRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQLOperations returns the operation names of a GraphQL request, several for batched
+// requests, and whether the request is a GraphQL request at all. Anonymous operations
+// are named after their type (query, mutation).
+func graphQLOperations(request *har.Request) ([]string, bool) {
+	if request == nil {
+		return nil, false
+	}
+
+	var operations []graphQLRequest
+	if request.PostData != nil && request.PostData.Text != "" {
+		text := []byte(request.PostData.Text)
+		var single graphQLRequest
+		if err := json.Unmarshal(text, &single); err == nil {
+			operations = append(operations, single)
+		} else if err := json.Unmarshal(text, &operations); err != nil {
+			operations = nil
+		}
+	} else if u, err := url.Parse(request.URL); err == nil {
+		query := u.Query()
+		operations = append(operations, graphQLRequest{Query: query.Get("query"), OperationName: query.Get("operationName")})
+	}
+
+	var names []string
+	for _, operation := range operations {
+		if operation.Query == "" && operation.OperationName == "" {
+			continue
+		}
+		names = append(names, operation.name())
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+	return names, true
+}
+
+// name returns the operation name, or its type for anonymous operations
+func (r graphQLRequest) name() string {
+	if r.OperationName != "" {
+		return r.OperationName
+	}
+	if match := graphQLOperationPattern.FindStringSubmatch(r.Query); match != nil {
+		return match[2]
+	}
+	if strings.HasPrefix(strings.TrimSpace(r.Query), "mutation") {
+		return "mutation"
+	}
+	return "query"
+}
+
+// graphQLErrors returns the messages of the errors reported by a GraphQL response, which
+// uses a 200 status even when the operation failed. Batched responses are supported.
+func graphQLErrors(response *har.Response) []string {
+	if response == nil || response.Content == nil || len(response.Content.Text) == 0 {
+		return nil
+	}
+
+	var responses []graphQLResponse
+	var single graphQLResponse
+	if err := json.Unmarshal(response.Content.Text, &single); err == nil {
+		responses = append(responses, single)
+	} else if err := json.Unmarshal(response.Content.Text, &responses); err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, r := range responses {
+		for _, e := range r.Errors {
+			message := e.Message
+			if message == "" {
+				message = "unknown error"
+			}
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,312 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/martian/har"
+)
+
+// writtenVersion is the HAR version of the documents written
+const writtenVersion = "1.2"
+
+// redactedComment marks the documents written with redaction applied
+const redactedComment = "Exported by har-mcp with redaction applied"
+
+// HARExport describes a HAR document written from a loaded archive
+type HARExport struct {
+	Path string `json:"path,omitempty"`
+	// Bytes is the size of the document
+	Bytes   int `json:"bytes"`
+	Entries int `json:"entries"`
+	Pages   int `json:"pages"`
+	// Redacted is false when the redaction policy is disabled and the document holds the
+	// captured values
+	Redacted bool `json:"redacted"`
+	// DryRun is set when the document was not written, Replaces when it overwrites a file
+	DryRun   bool `json:"dry_run,omitempty"`
+	Replaces bool `json:"replaces,omitempty"`
+}
+
+// HARExportOptions controls how ExportHAR writes a document to disk
+type HARExportOptions struct {
+	// DryRun describes the document without writing it
+	DryRun bool
+	// Overwrite allows replacing an existing file
+	Overwrite bool
+}
+
+// writtenHAR is a HAR 1.2 document, with the fields martian's model leaves out
+type writtenHAR struct {
+	Log writtenLog `json:"log"`
+}
+
+type writtenLog struct {
+	Version string         `json:"version"`
+	Creator *har.Creator   `json:"creator"`
+	Pages   []Page         `json:"pages,omitempty"`
+	Entries []writtenEntry `json:"entries"`
+	Comment string         `json:"comment,omitempty"`
+}
+
+type writtenEntry struct {
+	PageRef           string                     `json:"pageref,omitempty"`
+	StartedDateTime   time.Time                  `json:"startedDateTime"`
+	Time              float64                    `json:"time"`
+	Request           writtenRequest             `json:"request"`
+	Response          writtenResponse            `json:"response"`
+	Cache             struct{}                   `json:"cache"`
+	Timings           writtenTimings             `json:"timings"`
+	WebSocketMessages []FlexibleWebSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+type writtenRequest struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	HTTPVersion string            `json:"httpVersion"`
+	Cookies     []har.Cookie      `json:"cookies"`
+	Headers     []har.Header      `json:"headers"`
+	QueryString []har.QueryString `json:"queryString"`
+	PostData    *har.PostData     `json:"postData,omitempty"`
+	HeadersSize int64             `json:"headersSize"`
+	BodySize    int64             `json:"bodySize"`
+}
+
+type writtenResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []har.Cookie   `json:"cookies"`
+	Headers     []har.Header   `json:"headers"`
+	Content     writtenContent `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// writtenContent holds the body as text, base64 encoded when it is not valid UTF-8
+type writtenContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// writtenTimings are the phases of an entry, -1 marking the optional phases that do not apply
+type writtenTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// WriteHAR serializes the archive as a HAR 1.2 document with the redaction policy applied to
+// headers, cookies, query parameters, URLs, textual bodies and WebSocket frames, so captures
+// can be shared. The pages, full timings and WebSocket frames held by the parse report are
+// kept. Entries left out of a page scope are skipped, along with the pages without entries.
+func (p *Parser) WriteHAR(w io.Writer, harData *har.HAR, report *ParseReport) (*HARExport, error) {
+	data, export, err := p.encodeHAR(harData, report)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return export, nil
+}
+
+// ExportHAR writes the archive to path as WriteHAR serializes it. Existing files are only
+// replaced when allowed, atomically so readers never see a partial document.
+func (p *Parser) ExportHAR(harData *har.HAR, report *ParseReport, path string, options HARExportOptions) (*HARExport, error) {
+	data, export, err := p.encodeHAR(harData, report)
+	if err != nil {
+		return nil, err
+	}
+	export.Path = path
+	export.DryRun = options.DryRun
+
+	if _, err := os.Stat(path); err == nil {
+		if !options.Overwrite {
+			return nil, fmt.Errorf("%s already exists, allow overwriting to replace it", path)
+		}
+		export.Replaces = true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	if options.DryRun {
+		return export, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HAR file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to write HAR file: %w", err)
+	}
+
+	return export, nil
+}
+
+// encodeHAR returns the redacted HAR 1.2 document of the archive
+func (p *Parser) encodeHAR(harData *har.HAR, report *ParseReport) ([]byte, *HARExport, error) {
+	redaction := p.redaction()
+	timingIndex := report.TimingIndex()
+	pageIndex := report.PageIndex()
+	webSocketIndex := report.WebSocketIndex()
+
+	document := writtenHAR{Log: writtenLog{
+		Version: writtenVersion,
+		Creator: harData.Log.Creator,
+		Entries: []writtenEntry{},
+	}}
+	if !redaction.policy.Disabled {
+		document.Log.Comment = redactedComment
+	}
+
+	referenced := make(map[string]bool)
+	for _, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		written := writtenEntry{
+			PageRef:         pageIndex.PageRef(entry),
+			StartedDateTime: entry.StartedDateTime,
+			Time:            timingIndex.Time(entry),
+			Request:         writeRequest(redaction, entry.Request),
+			Response:        writeResponse(redaction, entry.Response),
+			Timings:         writeTimings(timingIndex.Timings(entry)),
+		}
+		for _, message := range webSocketIndex.Messages(entry) {
+			// Binary frames, opcode 2, are base64 encoded
+			if message.Opcode != 2 {
+				message.Data = FlexibleString(redaction.text(string(message.Data)))
+			}
+			written.WebSocketMessages = append(written.WebSocketMessages, message)
+		}
+		referenced[written.PageRef] = true
+		document.Log.Entries = append(document.Log.Entries, written)
+	}
+	for _, page := range pageIndex.Pages() {
+		if referenced[page.ID] {
+			document.Log.Pages = append(document.Log.Pages, page)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode HAR: %w", err)
+	}
+
+	return buf.Bytes(), &HARExport{
+		Bytes:    buf.Len(),
+		Entries:  len(document.Log.Entries),
+		Pages:    len(document.Log.Pages),
+		Redacted: !redaction.policy.Disabled,
+	}, nil
+}
+
+// writeRequest returns the redacted request, with the lists HAR requires present even when empty
+func writeRequest(redaction *redactor, request *har.Request) writtenRequest {
+	return writtenRequest{
+		Method:      request.Method,
+		URL:         redaction.text(request.URL),
+		HTTPVersion: request.HTTPVersion,
+		Cookies:     nonNil(redaction.cookies(request.Cookies)),
+		Headers:     nonNil(redaction.headerList(request.Headers)),
+		QueryString: nonNil(redaction.queryString(request.QueryString)),
+		PostData:    redaction.postData(request.PostData),
+		HeadersSize: request.HeadersSize,
+		BodySize:    request.BodySize,
+	}
+}
+
+// writeResponse returns the redacted response, entries without one getting the status 0
+// response browsers record for aborted requests
+func writeResponse(redaction *redactor, response *har.Response) writtenResponse {
+	if response == nil {
+		return writtenResponse{
+			Cookies:     []har.Cookie{},
+			Headers:     []har.Header{},
+			Content:     writtenContent{MimeType: "x-unknown"},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+
+	response = redaction.response(response)
+	written := writtenResponse{
+		Status:      response.Status,
+		StatusText:  response.StatusText,
+		HTTPVersion: response.HTTPVersion,
+		Cookies:     nonNil(response.Cookies),
+		Headers:     nonNil(response.Headers),
+		RedirectURL: response.RedirectURL,
+		HeadersSize: response.HeadersSize,
+		BodySize:    response.BodySize,
+	}
+	if content := response.Content; content != nil {
+		written.Content = writtenContent{Size: content.Size, MimeType: content.MimeType}
+		switch {
+		case len(content.Text) == 0:
+		case utf8.Valid(content.Text) && content.Encoding != "base64":
+			written.Content.Text = string(content.Text)
+		default:
+			written.Content.Text = base64.StdEncoding.EncodeToString(content.Text)
+			written.Content.Encoding = "base64"
+		}
+	}
+	return written
+}
+
+// writeTimings returns the phases of an entry, the required ones defaulting to 0
+func writeTimings(timings *Timings) writtenTimings {
+	orDefault := func(value *float64, fallback float64) float64 {
+		if value == nil {
+			return fallback
+		}
+		return *value
+	}
+	return writtenTimings{
+		Blocked: orDefault(timings.Blocked, -1),
+		DNS:     orDefault(timings.DNS, -1),
+		Connect: orDefault(timings.Connect, -1),
+		SSL:     orDefault(timings.SSL, -1),
+		Send:    orDefault(timings.Send, 0),
+		Wait:    orDefault(timings.Wait, 0),
+		Receive: orDefault(timings.Receive, 0),
+	}
+}
+
+// nonNil returns an empty slice instead of nil, so that lists HAR requires are written as []
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
@@ -0,0 +1,154 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rewrite writes the archive and parses the written document back
+func rewrite(t *testing.T, parser *Parser, harData *har.HAR, report *ParseReport) (*har.HAR, *ParseReport, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	export, err := parser.WriteHAR(&buf, harData, report)
+	require.NoError(t, err)
+	assert.Equal(t, buf.Len(), export.Bytes)
+
+	written, writtenReport, err := NewParser().ParseWithReport(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", writtenReport.Version)
+	assert.Empty(t, writtenReport.Warnings, "written documents follow the HAR specification")
+	return written, writtenReport, buf.String()
+}
+
+func TestWriteHARAppliesRedaction(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`, `eyJ[\w.-]+`}}))
+
+	written, _, document := rewrite(t, parser, newTestRedactionHAR(), nil)
+
+	assert.NotContains(t, document, "sk_live_1234")
+	assert.NotContains(t, document, testJWT)
+	assert.NotContains(t, document, "session=abc")
+	assert.Contains(t, document, redactedComment)
+	entry := written.Log.Entries[0]
+	assert.Equal(t, "https://api.example.com/login?api_key="+redactedValue, entry.Request.URL)
+	assert.Equal(t, []har.Header{
+		{Name: "Authorization", Value: redactedValue},
+		{Name: "X-Tenant-Secret", Value: "tenant-secret"},
+		{Name: "Cookie", Value: redactedValue},
+		{Name: "Accept", Value: "application/json"},
+	}, entry.Request.Headers)
+	assert.Equal(t, redactedValue, entry.Request.Cookies[0].Value)
+	assert.Equal(t, `{"token": "`+redactedValue+`"}`, string(entry.Response.Content.Text))
+}
+
+func TestWriteHARDisabledRedactionKeepsValues(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{Disabled: true}))
+
+	var buf bytes.Buffer
+	export, err := parser.WriteHAR(&buf, newTestRedactionHAR(), nil)
+
+	require.NoError(t, err)
+	assert.False(t, export.Redacted)
+	assert.Contains(t, buf.String(), testJWT)
+	assert.NotContains(t, buf.String(), redactedComment)
+}
+
+func TestWriteHARKeepsPagesTimingsAndWebSocketFrames(t *testing.T) {
+	parser := NewParser()
+
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+	written, writtenReport, _ := rewrite(t, parser, harData, report)
+	assert.Len(t, written.Log.Entries, 4)
+	assert.Equal(t, report.PageIndex().Pages(), writtenReport.PageIndex().Pages())
+	assert.Equal(t, "page_2", writtenReport.PageIndex().PageRef(written.Log.Entries[3]))
+	assert.Nil(t, written.Log.Entries[3].Response.Content.Text, "entries without response get an empty one")
+	assert.Equal(t, 0, written.Log.Entries[3].Response.Status)
+
+	harData, report, err = parser.ParseWithReport(strings.NewReader(testTimingsDocument))
+	require.NoError(t, err)
+	written, writtenReport, _ = rewrite(t, parser, harData, report)
+	assert.Equal(t, report.TimingIndex().Timings(harData.Log.Entries[0]), writtenReport.TimingIndex().Timings(written.Log.Entries[0]))
+	assert.Equal(t, report.TimingIndex().Timings(harData.Log.Entries[1]), writtenReport.TimingIndex().Timings(written.Log.Entries[1]))
+	assert.Equal(t, 12.75, writtenReport.TimingIndex().Time(written.Log.Entries[1]))
+
+	harData, report, err = parser.ParseWithReport(strings.NewReader(testWebSocketDocument))
+	require.NoError(t, err)
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`s3cr3t-\w+`}}))
+	written, writtenReport, document := rewrite(t, parser, harData, report)
+	messages := writtenReport.WebSocketIndex().Messages(written.Log.Entries[0])
+	require.Len(t, messages, 4)
+	assert.Equal(t, FlexibleString(`{"op":"auth","token":"`+redactedValue+`"}`), messages[0].Data)
+	assert.Equal(t, FlexibleString("AAECAw=="), messages[2].Data, "binary frames are kept base64 encoded")
+	assert.NotContains(t, document, "s3cr3t-token")
+}
+
+func TestWriteHARSkipsEntriesOutOfPageScope(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+	scoped, err := report.PageIndex().Scope(harData, "page_1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	export, err := parser.WriteHAR(&buf, scoped, report)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, export.Entries)
+	assert.Equal(t, 1, export.Pages)
+	var document struct {
+		Log struct {
+			Pages []Page `json:"pages"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	assert.Equal(t, "page_1", document.Log.Pages[0].ID)
+}
+
+func TestWriteHAREncodesBinaryBodies(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestResponseEntry("https://example.com/logo.png", "image/png", "\x89PNG\r\n\x1a\n\xff"))
+
+	written, _, document := rewrite(t, parser, archive, nil)
+
+	assert.Contains(t, document, `"encoding": "base64"`)
+	assert.Equal(t, []byte("\x89PNG\r\n\x1a\n\xff"), written.Log.Entries[0].Response.Content.Text)
+}
+
+func TestExportHAR(t *testing.T) {
+	parser := NewParser()
+	path := filepath.Join(t.TempDir(), "shared.har")
+
+	preview, err := parser.ExportHAR(newTestRedactionHAR(), nil, path, HARExportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.False(t, preview.Replaces)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "dry runs must not write")
+
+	export, err := parser.ExportHAR(newTestRedactionHAR(), nil, path, HARExportOptions{})
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(export.Bytes), info.Size())
+	assert.Equal(t, preview.Bytes, export.Bytes)
+
+	_, err = parser.ExportHAR(newTestRedactionHAR(), nil, path, HARExportOptions{})
+	assert.ErrorContains(t, err, "already exists")
+	export, err = parser.ExportHAR(newTestRedactionHAR(), nil, path, HARExportOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.True(t, export.Replaces)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are removed")
+}
@@ -0,0 +1,123 @@
+// Package hartest generates synthetic HAR files for benchmarks and tests.
+package hartest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Style selects which parsing path a generated HAR exercises
+type Style string
+
+const (
+	// Strict generates a HAR matching the standard model, with base64-encoded bodies
+	Strict Style = "strict"
+	// Flexible generates float times and stringified numbers, which need flexible parsing
+	Flexible Style = "flexible"
+	// Malformed generates trailing commas and NaN values, which need lenient parsing
+	Malformed Style = "malformed"
+)
+
+// Styles lists every style, in the order of the parsing paths they exercise
+var Styles = []Style{Strict, Flexible, Malformed}
+
+// ParseStyle returns the style with the given name
+func ParseStyle(name string) (Style, error) {
+	for _, style := range Styles {
+		if string(style) == name {
+			return style, nil
+		}
+	}
+	return "", fmt.Errorf("unknown style %q", name)
+}
+
+// start is the start time of generated captures
+var start = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+// Generate builds a HAR document with the given number of entries, mixing JSON API
+// calls, HTML documents and base64-encoded images as browser captures do
+func Generate(entries int, style Style) []byte {
+	var out bytes.Buffer
+	out.WriteString(`{"log": {"version": "1.2", "creator": {"name": "hartest", "version": "1.0"}, "entries": [`)
+	for i := 0; i < entries; i++ {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		writeEntry(&out, i, style)
+	}
+	if style == Malformed {
+		out.WriteString(",")
+	}
+	out.WriteString("]}}\n")
+	return out.Bytes()
+}
+
+// writeEntry writes the i-th entry of a generated capture
+func writeEntry(out *bytes.Buffer, i int, style Style) {
+	method, url, mimeType, body := exchange(i)
+	text, encoding := string(body), ""
+	if style == Strict || mimeType == "image/png" {
+		text, encoding = base64.StdEncoding.EncodeToString(body), `, "encoding": "base64"`
+	}
+	status := 200 + (i%7/6)*300
+
+	number := func(n int) string {
+		if style == Flexible {
+			return fmt.Sprintf(`"%d"`, n)
+		}
+		return fmt.Sprint(n)
+	}
+	duration := fmt.Sprint(20 + i%180)
+	if style != Strict {
+		duration += ".375"
+	}
+	trailing := ""
+	bodySize := number(len(body))
+	if style == Malformed {
+		trailing = ","
+		if i%10 == 0 {
+			bodySize = "NaN"
+		}
+	}
+
+	fmt.Fprintf(out, `
+{"startedDateTime": %q, "time": %s,
+ "request": {"method": %q, "url": %q, "httpVersion": "HTTP/2", "cookies": [],
+  "headers": [{"name": "User-Agent", "value": "Mozilla/5.0 (X11; Linux x86_64) hartest"}, {"name": "Accept", "value": "*/*"}, {"name": "Authorization", "value": "Bearer token-%d"}%s],
+  "queryString": [{"name": "page", "value": "%d"}], "headersSize": %s, "bodySize": 0},
+ "response": {"status": %s, "statusText": "", "httpVersion": "HTTP/2", "cookies": [],
+  "headers": [{"name": "Content-Type", "value": %q}, {"name": "Cache-Control", "value": "no-cache"}],
+  "content": {"size": %s, "mimeType": %q, "text": %q%s}, "redirectURL": "", "headersSize": -1, "bodySize": %s},
+ "cache": {}, "timings": {"blocked": 1, "dns": -1, "connect": -1, "send": 1, "wait": %s, "receive": 3%s}}`,
+		start.Add(time.Duration(i)*15*time.Millisecond).Format(time.RFC3339Nano), duration,
+		method, url, i, trailing,
+		i%50, number(350),
+		number(status),
+		mimeType,
+		number(len(body)), mimeType, text, encoding,
+		bodySize,
+		duration, trailing,
+	)
+}
+
+// exchange returns the request and response body of the i-th entry
+func exchange(i int) (method, url, mimeType string, body []byte) {
+	switch i % 10 {
+	case 0:
+		return "GET", fmt.Sprintf("https://www.example.com/products/%d", i), "text/html",
+			[]byte("<!DOCTYPE html><html><body>" + strings.Repeat("<p>Lorem ipsum dolor sit amet</p>", 40) + "</body></html>")
+	case 1:
+		return "GET", fmt.Sprintf("https://cdn.example.com/img/%d.png", i), "image/png",
+			append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{byte(i)}, 2048)...)
+	default:
+		var items []string
+		for j := 0; j < 20; j++ {
+			items = append(items, fmt.Sprintf(`{"id": %d, "sku": "SKU-%05d", "name": "Item %d", "price": %d.99, "tags": ["a", "b"]}`, i*100+j, i+j, j, j))
+		}
+		return "GET", fmt.Sprintf("https://api.example.com/v1/orders/%d/items", i), "application/json",
+			[]byte(`{"items": [` + strings.Join(items, ", ") + `], "total": 20}`)
+	}
+}
@@ -0,0 +1,255 @@
+package har
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Header conflict checks
+const (
+	// ConflictDuplicateHeader flags a header allowed once sent several times with different values
+	ConflictDuplicateHeader = "duplicate_header"
+	// ConflictCacheControl flags contradictory Cache-Control directives
+	ConflictCacheControl = "cache_control"
+	// ConflictCookieDomain flags a cookie set several times for different domains
+	ConflictCookieDomain = "cookie_domain"
+	// ConflictDuplicateCookie flags a cookie set several times for the same domain and path
+	ConflictDuplicateCookie = "duplicate_cookie"
+)
+
+// singletonHeaders lists the lower-cased names of the response headers holding a single value,
+// which browsers resolve differently when repeated
+var singletonHeaders = map[string]bool{
+	"access-control-allow-credentials": true,
+	"access-control-allow-origin":      true,
+	"content-disposition":              true,
+	"content-encoding":                 true,
+	"content-length":                   true,
+	"content-type":                     true,
+	"etag":                             true,
+	"expires":                          true,
+	"last-modified":                    true,
+	"location":                         true,
+	"referrer-policy":                  true,
+	"retry-after":                      true,
+	"strict-transport-security":        true,
+	"x-content-type-options":           true,
+	"x-frame-options":                  true,
+}
+
+// HeaderConflict describes conflicting headers of a response
+type HeaderConflict struct {
+	RequestID string `json:"request_id"`
+	URL       string `json:"url"`
+	Check     string `json:"check"`
+	Header    string `json:"header"`
+	// Values are the conflicting values, cookies being described by their name and attributes
+	Values  []string `json:"values"`
+	Message string   `json:"message"`
+}
+
+// HeaderConflictReport lists the responses with conflicting headers
+type HeaderConflictReport struct {
+	Checked   int              `json:"checked"`
+	ByCheck   map[string]int   `json:"by_check"`
+	Conflicts []HeaderConflict `json:"conflicts"`
+}
+
+// GetHeaderConflicts flags responses with conflicting headers, which browsers resolve silently
+// and inconsistently: single-valued headers such as Content-Type repeated with different values,
+// contradictory Cache-Control directives such as no-store with max-age, and cookies set several
+// times in a response, for different domains or overriding each other.
+func (p *Parser) GetHeaderConflicts(harData *har.HAR) *HeaderConflictReport {
+	redaction := p.redaction()
+	report := &HeaderConflictReport{ByCheck: make(map[string]int), Conflicts: []HeaderConflict{}}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil || entry.Response.Status == 0 {
+			continue
+		}
+		report.Checked++
+
+		var conflicts []HeaderConflict
+		conflicts = append(conflicts, duplicateHeaderConflicts(entry.Response.Headers, redaction)...)
+		conflicts = append(conflicts, cacheControlConflicts(entry.Response.Headers)...)
+		conflicts = append(conflicts, cookieConflicts(entry.Response.Headers)...)
+		for _, conflict := range conflicts {
+			conflict.RequestID = formatRequestID(i)
+			conflict.URL = redaction.text(entry.Request.URL)
+			report.ByCheck[conflict.Check]++
+			report.Conflicts = append(report.Conflicts, conflict)
+		}
+	}
+	return report
+}
+
+// duplicateHeaderConflicts reports the single-valued headers repeated with different values
+func duplicateHeaderConflicts(headers []har.Header, redaction *redactor) []HeaderConflict {
+	values := make(map[string][]string)
+	var names []string
+	for _, header := range headers {
+		name := strings.ToLower(header.Name)
+		if !singletonHeaders[name] {
+			continue
+		}
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = append(values[name], strings.TrimSpace(header.Value))
+	}
+
+	var conflicts []HeaderConflict
+	for _, name := range names {
+		distinct := distinctFold(values[name])
+		if len(distinct) < 2 {
+			continue
+		}
+		for i, value := range distinct {
+			distinct[i] = redaction.header(name, value)
+		}
+		conflicts = append(conflicts, HeaderConflict{
+			Check:   ConflictDuplicateHeader,
+			Header:  http.CanonicalHeaderKey(name),
+			Values:  distinct,
+			Message: fmt.Sprintf("%s is sent %d times with %d different values", http.CanonicalHeaderKey(name), len(values[name]), len(distinct)),
+		})
+	}
+	return conflicts
+}
+
+// cacheControlConflicts reports the contradictory directives of the Cache-Control headers
+func cacheControlConflicts(headers []har.Header) []HeaderConflict {
+	directives := make(map[string][]string)
+	var raw []string
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, "Cache-Control") {
+			continue
+		}
+		raw = append(raw, header.Value)
+		for _, directive := range strings.Split(header.Value, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			name = strings.ToLower(name)
+			directives[name] = append(directives[name], strings.Trim(value, `"`))
+		}
+	}
+
+	var reasons []string
+	has := func(name string) bool { _, ok := directives[name]; return ok }
+	if has("public") && has("private") {
+		reasons = append(reasons, "public and private")
+	}
+	if has("no-store") {
+		for _, name := range []string{"max-age", "s-maxage", "immutable", "public"} {
+			if has(name) && !(name == "max-age" && allEqual(directives[name], "0")) {
+				reasons = append(reasons, "no-store and "+name)
+			}
+		}
+	}
+	if has("no-cache") && has("immutable") {
+		reasons = append(reasons, "no-cache and immutable")
+	}
+	for _, name := range []string{"max-age", "s-maxage"} {
+		if len(distinctFold(directives[name])) > 1 {
+			reasons = append(reasons, "several "+name+" values "+strings.Join(distinctFold(directives[name]), ", "))
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	return []HeaderConflict{{
+		Check:   ConflictCacheControl,
+		Header:  "Cache-Control",
+		Values:  raw,
+		Message: "Cache-Control combines " + strings.Join(reasons, "; "),
+	}}
+}
+
+// cookieConflicts reports the cookies set several times by a response
+func cookieConflicts(headers []har.Header) []HeaderConflict {
+	cookies := make(map[string][]*http.Cookie)
+	var names []string
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, "Set-Cookie") {
+			continue
+		}
+		cookie, err := http.ParseSetCookie(header.Value)
+		if err != nil {
+			continue
+		}
+		if _, ok := cookies[cookie.Name]; !ok {
+			names = append(names, cookie.Name)
+		}
+		cookies[cookie.Name] = append(cookies[cookie.Name], cookie)
+	}
+
+	var conflicts []HeaderConflict
+	for _, name := range names {
+		set := cookies[name]
+		if len(set) < 2 {
+			continue
+		}
+		var values, domains, scopes []string
+		for _, cookie := range set {
+			domain := strings.TrimPrefix(strings.ToLower(cookie.Domain), ".")
+			values = append(values, describeCookie(cookie))
+			domains = append(domains, domain)
+			scopes = append(scopes, domain+"\x00"+cookie.Path)
+		}
+		conflict := HeaderConflict{Header: "Set-Cookie", Values: values}
+		if distinct := distinctFold(domains); len(distinct) > 1 {
+			conflict.Check = ConflictCookieDomain
+			conflict.Message = fmt.Sprintf("cookie %s is set for %d different domains, leaving the browser with several cookies of that name", name, len(distinct))
+		} else if len(distinctFold(scopes)) < len(scopes) {
+			conflict.Check = ConflictDuplicateCookie
+			conflict.Message = fmt.Sprintf("cookie %s is set %d times for the same domain and path, the last one overriding the others", name, len(set))
+		} else {
+			// Different paths store distinct cookies, which is legitimate
+			continue
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// describeCookie describes a cookie by its name and scoping attributes, leaving out its value
+func describeCookie(cookie *http.Cookie) string {
+	description := cookie.Name
+	if cookie.Domain != "" {
+		description += "; Domain=" + cookie.Domain
+	}
+	if cookie.Path != "" {
+		description += "; Path=" + cookie.Path
+	}
+	if cookie.MaxAge < 0 {
+		description += "; deleted"
+	}
+	return description
+}
+
+// distinctFold returns the distinct values ignoring case, in order of appearance
+func distinctFold(values []string) []string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, value := range values {
+		if key := strings.ToLower(value); !seen[key] {
+			seen[key] = true
+			distinct = append(distinct, value)
+		}
+	}
+	return distinct
+}
+
+// allEqual reports whether every value equals want
+func allEqual(values []string, want string) bool {
+	for _, value := range values {
+		if value != want {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHeaderConflictsDuplicateHeaders(t *testing.T) {
+	parser := NewParser()
+	entry := newTestResponseEntry("https://example.com/app.js", "application/javascript", "")
+	entry.Response.Headers = append(entry.Response.Headers,
+		har.Header{Name: "content-type", Value: "text/html"},
+		har.Header{Name: "Content-Length", Value: "12"},
+		har.Header{Name: "Content-Length", Value: "12"},
+		har.Header{Name: "Vary", Value: "Accept"},
+		har.Header{Name: "Vary", Value: "Origin"})
+
+	report := parser.GetHeaderConflicts(newTestHAR(entry))
+
+	assert.Equal(t, 1, report.Checked)
+	require.Len(t, report.Conflicts, 1)
+	conflict := report.Conflicts[0]
+	assert.Equal(t, "request_0", conflict.RequestID)
+	assert.Equal(t, ConflictDuplicateHeader, conflict.Check)
+	assert.Equal(t, "Content-Type", conflict.Header)
+	assert.Equal(t, []string{"application/javascript", "text/html"}, conflict.Values)
+	assert.Equal(t, map[string]int{ConflictDuplicateHeader: 1}, report.ByCheck)
+}
+
+func TestGetHeaderConflictsCacheControl(t *testing.T) {
+	parser := NewParser()
+	contradictory := newTestEntry("GET", "https://example.com/api/me")
+	contradictory.Response.Headers = []har.Header{
+		{Name: "Cache-Control", Value: "private, no-store"},
+		{Name: "Cache-Control", Value: "public, max-age=3600"},
+	}
+	// max-age=0 with no-store is a common belt and braces
+	consistent := newTestEntry("GET", "https://example.com/api/cart")
+	consistent.Response.Headers = []har.Header{{Name: "Cache-Control", Value: "no-store, max-age=0, must-revalidate"}}
+
+	report := parser.GetHeaderConflicts(newTestHAR(contradictory, consistent))
+
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, ConflictCacheControl, report.Conflicts[0].Check)
+	assert.Equal(t, "Cache-Control combines public and private; no-store and max-age; no-store and public", report.Conflicts[0].Message)
+	assert.Equal(t, []string{"private, no-store", "public, max-age=3600"}, report.Conflicts[0].Values)
+}
+
+func TestGetHeaderConflictsCookies(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("GET", "https://app.example.com/login")
+	entry.Response.Headers = []har.Header{
+		{Name: "Set-Cookie", Value: "session=abc; Domain=example.com; Path=/; Secure"},
+		{Name: "Set-Cookie", Value: "session=def; Path=/; Secure"},
+		{Name: "Set-Cookie", Value: "theme=dark; Path=/"},
+		{Name: "Set-Cookie", Value: "theme=light; Path=/"},
+		{Name: "Set-Cookie", Value: "lang=en; Path=/"},
+		{Name: "Set-Cookie", Value: "lang=fr; Path=/fr"},
+	}
+
+	report := parser.GetHeaderConflicts(newTestHAR(entry))
+
+	require.Len(t, report.Conflicts, 2)
+	assert.Equal(t, ConflictCookieDomain, report.Conflicts[0].Check)
+	assert.Equal(t, []string{"session; Domain=example.com; Path=/", "session; Path=/"}, report.Conflicts[0].Values)
+	assert.Equal(t, ConflictDuplicateCookie, report.Conflicts[1].Check)
+	assert.Contains(t, report.Conflicts[1].Message, "cookie theme is set 2 times")
+}
+
+func TestGetHeaderConflictsRedactsValues(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("GET", "https://example.com/")
+	entry.Response.Headers = []har.Header{
+		{Name: "Location", Value: "https://example.com/a?token=secret"},
+		{Name: "Location", Value: "https://example.com/b"},
+	}
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{"secret"}}))
+
+	report := parser.GetHeaderConflicts(newTestHAR(entry))
+
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, []string{"https://example.com/a?token=[REDACTED]", "https://example.com/b"}, report.Conflicts[0].Values)
+}
@@ -0,0 +1,93 @@
+package har
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// maxHeaderValueExamples caps the number of example request IDs reported per header value
+const maxHeaderValueExamples = 3
+
+// HeaderValueCount is a distinct header value with the number of times it was seen
+type HeaderValueCount struct {
+	Value      string   `json:"value"`
+	Count      int      `json:"count"`
+	RequestIDs []string `json:"example_request_ids"`
+}
+
+// HeaderValueFrequency tabulates the distinct values of a header across the HAR
+type HeaderValueFrequency struct {
+	Name    string             `json:"name"`
+	Side    string             `json:"side"`
+	Total   int                `json:"total"`
+	Missing int                `json:"missing"`
+	Values  []HeaderValueCount `json:"values"`
+}
+
+// GetHeaderValues tabulates the distinct values of the named header on either the
+// "request" or the "response" side of every entry, most frequent values first.
+// Values of authentication headers are counted separately but reported redacted.
+func (p *Parser) GetHeaderValues(harData *har.HAR, name, side string) (*HeaderValueFrequency, error) {
+	if side == "" {
+		side = "request"
+	}
+	if side != "request" && side != "response" {
+		return nil, fmt.Errorf("invalid side %q: must be request or response", side)
+	}
+
+	redaction := p.redaction()
+	result := &HeaderValueFrequency{Name: name, Side: side}
+	counts := make(map[string]*HeaderValueCount)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		var headers []har.Header
+		switch {
+		case side == "request" && entry.Request != nil:
+			headers = entry.Request.Headers
+		case side == "response" && entry.Response != nil:
+			headers = entry.Response.Headers
+		}
+
+		found := false
+		for _, header := range headers {
+			if !strings.EqualFold(header.Name, name) {
+				continue
+			}
+			found = true
+			result.Total++
+
+			count, ok := counts[header.Value]
+			if !ok {
+				count = &HeaderValueCount{Value: header.Value}
+				count.Value = redaction.header(name, header.Value)
+				counts[header.Value] = count
+				order = append(order, header.Value)
+			}
+			count.Count++
+			if len(count.RequestIDs) < maxHeaderValueExamples {
+				count.RequestIDs = append(count.RequestIDs, formatRequestID(i))
+			}
+		}
+		if !found {
+			result.Missing++
+		}
+	}
+
+	result.Values = make([]HeaderValueCount, 0, len(order))
+	for _, value := range order {
+		result.Values = append(result.Values, *counts[value])
+	}
+	// Stable sort keeps first-seen order among values with the same frequency
+	sort.SliceStable(result.Values, func(i, j int) bool {
+		return result.Values[i].Count > result.Values[j].Count
+	})
+
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHeaderValuesRequest(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/a", har.Header{Name: "User-Agent", Value: "curl"}),
+		newTestEntry("GET", "https://example.com/b", har.Header{Name: "user-agent", Value: "firefox"}),
+		newTestEntry("GET", "https://example.com/c", har.Header{Name: "User-Agent", Value: "firefox"}),
+		newTestEntry("GET", "https://example.com/d"),
+	)
+
+	frequency, err := parser.GetHeaderValues(archive, "User-Agent", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "request", frequency.Side)
+	assert.Equal(t, 3, frequency.Total)
+	assert.Equal(t, 1, frequency.Missing)
+	require.Len(t, frequency.Values, 2)
+	assert.Equal(t, HeaderValueCount{Value: "firefox", Count: 2, RequestIDs: []string{"request_1", "request_2"}}, frequency.Values[0])
+	assert.Equal(t, HeaderValueCount{Value: "curl", Count: 1, RequestIDs: []string{"request_0"}}, frequency.Values[1])
+}
+
+func TestGetHeaderValuesResponse(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("GET", "https://example.com/a")
+	entry.Response.Headers = []har.Header{{Name: "Content-Type", Value: "text/html"}}
+	archive := newTestHAR(entry)
+
+	frequency, err := parser.GetHeaderValues(archive, "content-type", "response")
+	require.NoError(t, err)
+
+	require.Len(t, frequency.Values, 1)
+	assert.Equal(t, "text/html", frequency.Values[0].Value)
+}
+
+func TestGetHeaderValuesRedactsAuthHeaders(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/a", har.Header{Name: "Authorization", Value: "Bearer a"}),
+		newTestEntry("GET", "https://example.com/b", har.Header{Name: "Authorization", Value: "Bearer b"}),
+	)
+
+	frequency, err := parser.GetHeaderValues(archive, "Authorization", "request")
+	require.NoError(t, err)
+
+	require.Len(t, frequency.Values, 2)
+	for _, value := range frequency.Values {
+		assert.Equal(t, "[REDACTED]", value.Value)
+	}
+}
+
+func TestGetHeaderValuesInvalidSide(t *testing.T) {
+	parser := NewParser()
+
+	frequency, err := parser.GetHeaderValues(newTestHAR(), "Accept", "both")
+
+	assert.Error(t, err)
+	assert.Nil(t, frequency)
+	assert.Contains(t, err.Error(), "invalid side")
+}
+
+func TestGetHeaderValuesIgnoresExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	archive, err := parser.Exclude(newTestHAR(
+		newTestEntry("GET", "https://example.com/a", har.Header{Name: "User-Agent", Value: "curl"}),
+		newTestEntry("GET", "https://example.com/b"),
+	), []string{"request_1"})
+	require.NoError(t, err)
+
+	frequency, err := parser.GetHeaderValues(archive, "User-Agent", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, frequency.Total)
+	assert.Equal(t, 0, frequency.Missing)
+}
@@ -0,0 +1,224 @@
+package har
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Head-of-line patterns inferred from the timings of HTTP/2 streams
+const (
+	// StallQueued is a stream queued before being sent while others were in flight, as when the
+	// concurrent stream limit of the server is reached
+	StallQueued = "queued"
+	// StallDelayedFirstByte is a stream whose response started long after its siblings' while
+	// another response was being received, as when frames wait behind those of a larger response
+	StallDelayedFirstByte = "delayed-first-byte"
+)
+
+const (
+	// http2StallMS is the delay from which a stream is considered stalled
+	http2StallMS = 50
+	// http2StallFactor is how many times the median wait of its connection a stream waits for its
+	// first byte to be considered delayed
+	http2StallFactor = 3
+	// maxHTTP2Stalls caps the stalls listed per connection
+	maxHTTP2Stalls = 10
+)
+
+// HTTP2Stall is a stream whose timings suggest head-of-line blocking
+type HTTP2Stall struct {
+	RequestID string  `json:"request_id"`
+	Pattern   string  `json:"pattern"`
+	DelayMS   float64 `json:"delay_ms"`
+	// InFlight counts the other streams of the connection in flight when the stream stalled
+	InFlight int `json:"in_flight"`
+}
+
+// HTTP2Connection describes the streams multiplexed over an HTTP/2 connection
+type HTTP2Connection struct {
+	// Connection is the connection ID recorded by the exporter or, when none is, the host the
+	// streams were grouped by, Inferred being set
+	Connection string   `json:"connection"`
+	Inferred   bool     `json:"inferred,omitempty"`
+	Hosts      []string `json:"hosts"`
+	Streams    int      `json:"streams"`
+	// StreamIDs are the stream identifiers recorded by the exporter, in request order
+	StreamIDs []int `json:"stream_ids,omitempty"`
+	// MaxConcurrent is the largest number of streams in flight at once
+	MaxConcurrent int `json:"max_concurrent"`
+	// Serialized is set when several streams never overlapped, multiplexing going unused
+	Serialized bool    `json:"serialized"`
+	DurationMS float64 `json:"duration_ms"`
+	// Stalls counts the streams whose timings suggest head-of-line blocking, the first of them
+	// being listed
+	Stalls         int          `json:"stalls"`
+	StalledStreams []HTTP2Stall `json:"stalled_streams"`
+	RequestIDs     []string     `json:"request_ids"`
+}
+
+// HTTP2StreamsReport describes how the HTTP/2 requests of a capture were multiplexed
+type HTTP2StreamsReport struct {
+	HTTP2Requests int `json:"http2_requests"`
+	OtherRequests int `json:"other_requests"`
+	// PseudoHeaders counts the HTTP/2 requests whose header lists keep the pseudo headers
+	PseudoHeaders int `json:"pseudo_headers"`
+	// Connections are sorted by number of streams, largest first
+	Connections []HTTP2Connection `json:"connections"`
+}
+
+// http2Stream is an HTTP/2 request placed on the timeline of its connection
+type http2Stream struct {
+	position   int
+	entry      *har.Entry
+	start, end time.Time
+	timings    *Timings
+}
+
+// ReportHTTP2Streams groups the HTTP/2 requests of the capture by connection, by the connection ID
+// recorded by the exporter or by host otherwise, and reports how they were multiplexed: the
+// streams of each connection, their stream IDs when recorded, the largest number in flight at
+// once and the connections never multiplexing. Head-of-line blocking is inferred from the
+// timings: streams queued while others were in flight, and streams waiting for their first byte
+// several times longer than their siblings while another response was received. Timings and
+// connection details are read from the indexes.
+func (p *Parser) ReportHTTP2Streams(harData *har.HAR, timings *TimingIndex, connections *ConnectionIndex) *HTTP2StreamsReport {
+	report := &HTTP2StreamsReport{Connections: []HTTP2Connection{}}
+	groups := make(map[string][]http2Stream)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		if !isHTTP2(entry) {
+			report.OtherRequests++
+			continue
+		}
+		report.HTTP2Requests++
+		if slices.ContainsFunc(entry.Request.Headers, func(header har.Header) bool { return strings.HasPrefix(header.Name, ":") }) {
+			report.PseudoHeaders++
+		}
+
+		key := "id " + connections.ConnectionID(entry)
+		if connections.ConnectionID(entry) == "" {
+			key = "host " + requestHost(entry)
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], http2Stream{position: i, entry: entry, start: entry.StartedDateTime, end: timings.End(entry), timings: timings.Timings(entry)})
+	}
+
+	for _, key := range order {
+		kind, name, _ := strings.Cut(key, " ")
+		report.Connections = append(report.Connections, newHTTP2Connection(name, kind == "host", groups[key], connections))
+	}
+	slices.SortStableFunc(report.Connections, func(a, b HTTP2Connection) int {
+		return cmp.Compare(b.Streams, a.Streams)
+	})
+	return report
+}
+
+// newHTTP2Connection describes the streams of a connection
+func newHTTP2Connection(name string, inferred bool, streams []http2Stream, connections *ConnectionIndex) HTTP2Connection {
+	slices.SortStableFunc(streams, func(a, b http2Stream) int { return a.start.Compare(b.start) })
+	connection := HTTP2Connection{Connection: name, Inferred: inferred, Streams: len(streams), StalledStreams: []HTTP2Stall{}}
+
+	waits := make([]float64, 0, len(streams))
+	first, last := streams[0].start, streams[0].end
+	for _, stream := range streams {
+		if host := requestHost(stream.entry); !slices.Contains(connection.Hosts, host) {
+			connection.Hosts = append(connection.Hosts, host)
+		}
+		if streamID, ok := connections.StreamID(stream.entry); ok {
+			connection.StreamIDs = append(connection.StreamIDs, streamID)
+		}
+		connection.RequestIDs = append(connection.RequestIDs, formatRequestID(stream.position))
+		if stream.timings.Wait != nil {
+			waits = append(waits, *stream.timings.Wait)
+		}
+		if stream.end.After(last) {
+			last = stream.end
+		}
+	}
+	connection.DurationMS = milliseconds(last.Sub(first))
+	medianWait := median(waits)
+
+	for i, stream := range streams {
+		inFlight := 0
+		for j, other := range streams {
+			if j != i && other.start.Before(stream.start.Add(time.Nanosecond)) && other.end.After(stream.start) {
+				inFlight++
+			}
+		}
+		connection.MaxConcurrent = max(connection.MaxConcurrent, inFlight+1)
+
+		stall := HTTP2Stall{RequestID: formatRequestID(stream.position), InFlight: inFlight}
+		if blocked := stream.timings.Blocked; blocked != nil && *blocked >= http2StallMS && inFlight > 0 {
+			stall.Pattern, stall.DelayMS = StallQueued, *blocked
+		} else if wait := stream.timings.Wait; wait != nil && *wait >= http2StallMS && *wait >= http2StallFactor*medianWait && receivingDuringWait(stream, streams, i) {
+			stall.Pattern, stall.DelayMS = StallDelayedFirstByte, *wait-medianWait
+		}
+		if stall.Pattern == "" {
+			continue
+		}
+		connection.Stalls++
+		if len(connection.StalledStreams) < maxHTTP2Stalls {
+			connection.StalledStreams = append(connection.StalledStreams, stall)
+		}
+	}
+	connection.Serialized = len(streams) > 1 && connection.MaxConcurrent == 1
+	return connection
+}
+
+// receivingDuringWait reports whether another stream of the connection received its response
+// while the stream at position i waited for its first byte
+func receivingDuringWait(stream http2Stream, streams []http2Stream, i int) bool {
+	waitEnd := stream.end.Add(-durationOf(phaseMS(stream.timings.Receive)))
+	waitStart := waitEnd.Add(-durationOf(phaseMS(stream.timings.Wait)))
+	for j, other := range streams {
+		receiveStart := other.end.Add(-durationOf(phaseMS(other.timings.Receive)))
+		if j != i && receiveStart.Before(waitEnd) && other.end.After(waitStart) && phaseMS(other.timings.Receive) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseMS returns the milliseconds of a phase, 0 when it does not apply
+func phaseMS(phase *float64) float64 {
+	if phase == nil {
+		return 0
+	}
+	return *phase
+}
+
+// isHTTP2 reports whether the entry was exchanged over HTTP/2, from its protocol version or the
+// pseudo headers of its request
+func isHTTP2(entry *har.Entry) bool {
+	versions := []string{entry.Request.HTTPVersion}
+	if entry.Response != nil {
+		versions = append(versions, entry.Response.HTTPVersion)
+	}
+	for _, version := range versions {
+		switch strings.ToLower(strings.TrimSpace(version)) {
+		case "h2", "h2c", "http/2", "http/2.0":
+			return true
+		}
+	}
+	return slices.ContainsFunc(entry.Request.Headers, func(header har.Header) bool { return header.Name == ":authority" })
+}
+
+// requestHost returns the host of the request URL, the URL itself when it cannot be parsed
+func requestHost(entry *har.Entry) string {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return entry.Request.URL
+	}
+	return u.Host
+}
@@ -0,0 +1,69 @@
+package har
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseTestStreams parses a document of HTTP/2 requests over connection 7, each started at the
+// given millisecond offset with the given blocked, wait and receive phases
+func parseTestStreams(t *testing.T, streams ...[4]int) *HTTP2StreamsReport {
+	t.Helper()
+	entries := make([]string, 0, len(streams))
+	for i, stream := range streams {
+		started := fmt.Sprintf("2023-01-01T00:00:00.%03dZ", stream[0])
+		entries = append(entries, fmt.Sprintf(`{"startedDateTime": %q, "time": %d, "connection": "7", "_streamId": %d,
+			"request": {"method": "GET", "url": "https://example.com/%d", "httpVersion": "h2", "headers": [{"name": ":authority", "value": "example.com"}], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			"response": {"status": 200, "statusText": "", "httpVersion": "h2", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/plain"}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+			"timings": {"blocked": %d, "send": 0, "wait": %d, "receive": %d}}`, started, stream[1]+stream[2]+stream[3], 2*i+1, i, stream[1], stream[2], stream[3]))
+	}
+	document := `{"log": {"version": "1.2", "creator": {"name": "test", "version": "1"}, "entries": [` + strings.Join(entries, ",") + `]}}`
+
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(document))
+	require.NoError(t, err)
+	return parser.ReportHTTP2Streams(harData, report.TimingIndex(), report.ConnectionIndex())
+}
+
+func TestReportHTTP2StreamsGroupsByConnection(t *testing.T) {
+	report := parseTestStreams(t, [4]int{0, 1, 20, 5}, [4]int{2, 1, 20, 5}, [4]int{4, 1, 20, 5})
+
+	assert.Equal(t, 3, report.HTTP2Requests)
+	assert.Equal(t, 3, report.PseudoHeaders)
+	require.Len(t, report.Connections, 1)
+	connection := report.Connections[0]
+	assert.Equal(t, "7", connection.Connection)
+	assert.False(t, connection.Inferred)
+	assert.Equal(t, []int{1, 3, 5}, connection.StreamIDs)
+	assert.Equal(t, 3, connection.MaxConcurrent)
+	assert.False(t, connection.Serialized)
+	assert.Zero(t, connection.Stalls)
+}
+
+func TestReportHTTP2StreamsFindsSerializedConnections(t *testing.T) {
+	report := parseTestStreams(t, [4]int{0, 0, 20, 5}, [4]int{100, 0, 20, 5})
+
+	require.Len(t, report.Connections, 1)
+	assert.Equal(t, 1, report.Connections[0].MaxConcurrent)
+	assert.True(t, report.Connections[0].Serialized)
+}
+
+func TestReportHTTP2StreamsInfersHeadOfLineBlocking(t *testing.T) {
+	report := parseTestStreams(t,
+		[4]int{0, 0, 10, 400},
+		[4]int{5, 0, 10, 5},
+		[4]int{10, 0, 300, 5},
+		[4]int{20, 120, 10, 5},
+	)
+
+	connection := report.Connections[0]
+	assert.Equal(t, 2, connection.Stalls)
+	assert.Equal(t, []HTTP2Stall{
+		{RequestID: "request_2", Pattern: StallDelayedFirstByte, DelayMS: 290, InFlight: 2},
+		{RequestID: "request_3", Pattern: StallQueued, DelayMS: 120, InFlight: 2},
+	}, connection.StalledStreams)
+}
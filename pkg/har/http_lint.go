@@ -0,0 +1,150 @@
+package har
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// HTTP semantics lint rules
+const (
+	LintBodyWithoutSemantics         = "body-without-semantics"
+	LintContentOnNoContent           = "content-on-no-content"
+	LintLengthWithTransferCoding     = "length-with-transfer-encoding"
+	LintContentLengthMismatch        = "content-length-mismatch"
+	LintRedirectWithoutLocation      = "redirect-without-location"
+	LintNotAllowedWithoutAllow       = "not-allowed-without-allow"
+	LintUnauthorizedWithoutChallenge = "unauthorized-without-challenge"
+)
+
+// LintRule describes an HTTP semantics lint rule and the RFC section it checks
+type LintRule struct {
+	ID          string `json:"id"`
+	Reference   string `json:"reference"`
+	Description string `json:"description"`
+}
+
+// LintRules lists the rules of the HTTP semantics lint
+var LintRules = []LintRule{
+	{LintBodyWithoutSemantics, "RFC 9110 section 9.3.1", "A GET, HEAD, DELETE or OPTIONS request carries a body, which has no defined semantics and may be dropped or rejected"},
+	{LintContentOnNoContent, "RFC 9110 sections 15.3.5 and 15.4.5", "A 1xx, 204 or 304 response, or a response to HEAD, carries content"},
+	{LintLengthWithTransferCoding, "RFC 9112 section 6.3", "A response has both Content-Length and Transfer-Encoding, a request smuggling vector"},
+	{LintContentLengthMismatch, "RFC 9112 section 6.3", "The Content-Length of a response differs from the size of the body received"},
+	{LintRedirectWithoutLocation, "RFC 9110 section 15.4", "A 301, 302, 303, 307 or 308 redirect has no Location header"},
+	{LintNotAllowedWithoutAllow, "RFC 9110 section 15.5.6", "A 405 response has no Allow header listing the supported methods"},
+	{LintUnauthorizedWithoutChallenge, "RFC 9110 section 15.5.2", "A 401 response has no WWW-Authenticate header"},
+}
+
+// LintViolation is a rule of the HTTP semantics lint broken by an exchange
+type LintViolation struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Status    int    `json:"status"`
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+}
+
+// HTTPLintReport lists the exchanges breaking HTTP semantics
+type HTTPLintReport struct {
+	Checked    int             `json:"checked"`
+	ByRule     map[string]int  `json:"by_rule"`
+	Violations []LintViolation `json:"violations"`
+}
+
+// LintHTTP checks the exchanges against HTTP semantics: bodies on GET requests, content on 204
+// and 304 responses, Content-Length inconsistent with Transfer-Encoding or with the body
+// received, redirects without Location, 405 without Allow and 401 without WWW-Authenticate.
+func (p *Parser) LintHTTP(harData *har.HAR) *HTTPLintReport {
+	redaction := p.redaction()
+	report := &HTTPLintReport{ByRule: make(map[string]int), Violations: []LintViolation{}}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		report.Checked++
+		for _, violation := range lintEntry(entry) {
+			violation.RequestID = formatRequestID(i)
+			violation.Method = entry.Request.Method
+			violation.URL = redaction.text(entry.Request.URL)
+			if entry.Response != nil {
+				violation.Status = entry.Response.Status
+			}
+			report.ByRule[violation.Rule]++
+			report.Violations = append(report.Violations, violation)
+		}
+	}
+	return report
+}
+
+// lintEntry returns the rules an exchange breaks with their messages, in the order of LintRules
+func lintEntry(entry *har.Entry) []LintViolation {
+	var results []LintViolation
+	add := func(rule, message string) {
+		results = append(results, LintViolation{Rule: rule, Message: message})
+	}
+	method := strings.ToUpper(entry.Request.Method)
+	switch method {
+	case "GET", "HEAD", "DELETE", "OPTIONS":
+		if size := requestBodySize(entry.Request); size > 0 {
+			add(LintBodyWithoutSemantics, fmt.Sprintf("%s request carries a %d bytes body", method, size))
+		}
+	}
+
+	response := entry.Response
+	// Failed requests received no response to check
+	if response == nil || response.Status == 0 {
+		return results
+	}
+	headers := response.Headers
+	// Browsers record the cached content on 304 responses, only the body received counts
+	contentSize := response.BodySize
+	if contentSize < 0 && response.Content != nil && response.Status != 304 {
+		contentSize = int64(len(response.Content.Text))
+	}
+	if response.Status < 200 || response.Status == 204 || response.Status == 304 || method == "HEAD" {
+		if contentSize > 0 {
+			subject := fmt.Sprintf("%d response", response.Status)
+			if method == "HEAD" {
+				subject = "response to HEAD"
+			}
+			add(LintContentOnNoContent, fmt.Sprintf("%s carries %d bytes of content", subject, contentSize))
+		}
+	}
+
+	contentLength := headerValue(headers, "Content-Length")
+	if contentLength != "" && headerValue(headers, "Transfer-Encoding") != "" {
+		add(LintLengthWithTransferCoding, fmt.Sprintf("response has both Content-Length %s and Transfer-Encoding %s", contentLength, headerValue(headers, "Transfer-Encoding")))
+	} else if length, err := strconv.ParseInt(strings.TrimSpace(contentLength), 10, 64); err == nil && response.BodySize > 0 && method != "HEAD" && length != response.BodySize {
+		add(LintContentLengthMismatch, fmt.Sprintf("Content-Length is %d but %d bytes of body were received", length, response.BodySize))
+	}
+
+	switch response.Status {
+	case 301, 302, 303, 307, 308:
+		if headerValue(headers, "Location") == "" {
+			add(LintRedirectWithoutLocation, fmt.Sprintf("%d redirect has no Location header", response.Status))
+		}
+	case 405:
+		if headerValue(headers, "Allow") == "" {
+			add(LintNotAllowedWithoutAllow, "405 response has no Allow header")
+		}
+	case 401:
+		if headerValue(headers, "WWW-Authenticate") == "" {
+			add(LintUnauthorizedWithoutChallenge, "401 response has no WWW-Authenticate header")
+		}
+	}
+	return results
+}
+
+// requestBodySize returns the size of the request body, from its recorded text or parameters
+func requestBodySize(request *har.Request) int64 {
+	if request.BodySize > 0 {
+		return request.BodySize
+	}
+	if request.PostData != nil {
+		return int64(len(curlBody(request.PostData)))
+	}
+	return 0
+}
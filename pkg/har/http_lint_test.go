@@ -0,0 +1,70 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintHTTPReportsViolations(t *testing.T) {
+	parser := NewParser()
+	getWithBody := newTestEntry("GET", "https://api.example.com/search")
+	getWithBody.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"q": "x"}`}
+	noContent := newTestEntry("DELETE", "https://api.example.com/items/1")
+	noContent.Response.Status = 204
+	noContent.Response.BodySize = 2
+	smuggling := newTestEntry("GET", "https://api.example.com/feed")
+	smuggling.Response.Headers = []har.Header{{Name: "Content-Length", Value: "10"}, {Name: "Transfer-Encoding", Value: "chunked"}}
+	mismatch := newTestEntry("GET", "https://api.example.com/me")
+	mismatch.Response.Headers = []har.Header{{Name: "Content-Length", Value: "10"}}
+	mismatch.Response.BodySize = 12
+	redirect := newTestEntry("GET", "https://example.com/old")
+	redirect.Response.Status = 301
+	notAllowed := newTestEntry("PUT", "https://api.example.com/users")
+	notAllowed.Response.Status = 405
+	unauthorized := newTestEntry("GET", "https://api.example.com/admin")
+	unauthorized.Response.Status = 401
+
+	report := parser.LintHTTP(newTestHAR(getWithBody, noContent, smuggling, mismatch, redirect, notAllowed, unauthorized))
+
+	assert.Equal(t, 7, report.Checked)
+	rules := make(map[string]string)
+	for _, violation := range report.Violations {
+		rules[violation.Rule] = violation.RequestID
+	}
+	assert.Equal(t, map[string]string{
+		LintBodyWithoutSemantics:         "request_0",
+		LintContentOnNoContent:           "request_1",
+		LintLengthWithTransferCoding:     "request_2",
+		LintContentLengthMismatch:        "request_3",
+		LintRedirectWithoutLocation:      "request_4",
+		LintNotAllowedWithoutAllow:       "request_5",
+		LintUnauthorizedWithoutChallenge: "request_6",
+	}, rules)
+	assert.Len(t, report.ByRule, len(LintRules))
+	assert.Equal(t, "GET request carries a 10 bytes body", report.Violations[0].Message)
+	assert.Equal(t, 204, report.Violations[1].Status)
+}
+
+func TestLintHTTPAcceptsValidExchanges(t *testing.T) {
+	parser := NewParser()
+	redirect := newTestEntry("GET", "https://example.com/old")
+	redirect.Response.Status = 302
+	redirect.Response.Headers = []har.Header{{Name: "Location", Value: "/new"}}
+	// Browsers record the cached content of 304 responses
+	notModified := newTestResponseEntry("https://example.com/app.js", "application/javascript", "console.log(1)")
+	notModified.Response.Status = 304
+	post := newTestEntry("POST", "https://example.com/api")
+	post.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{}`}
+	head := newTestEntry("HEAD", "https://example.com/file")
+	head.Response.Headers = []har.Header{{Name: "Content-Length", Value: "1000"}}
+	failed := newTestEntry("GET", "https://example.com/offline")
+	failed.Response.Status = 0
+
+	report := parser.LintHTTP(newTestHAR(redirect, notModified, post, head, failed))
+
+	require.NotNil(t, report.Violations)
+	assert.Empty(t, report.Violations)
+}
@@ -0,0 +1,168 @@
+package har
+
+import (
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// ParseWarning describes a deviation from the HAR specification tolerated while parsing
+type ParseWarning struct {
+	// RequestID is the affected entry, empty when the warning concerns the whole file
+	RequestID string `json:"request_id,omitempty"`
+	Message   string `json:"message"`
+}
+
+// Parse modes, from the most to the least faithful to the specification
+const (
+	// ParseModeStrict means the file matched the standard HAR model
+	ParseModeStrict = "strict"
+	// ParseModeFlexible means non-standard field types had to be coerced
+	ParseModeFlexible = "flexible"
+	// ParseModeRecovered means malformed JSON had to be repaired in lenient mode
+	ParseModeRecovered = "recovered"
+)
+
+// FormatHAR is the format of a single HAR JSON document
+const FormatHAR = "har"
+
+// ParseReport describes how a HAR file was parsed
+type ParseReport struct {
+	Format string `json:"format"`
+	// Containers are the compression containers the file was unwrapped from, outermost first
+	Containers []string `json:"containers,omitempty"`
+	// Version is the detected HAR version
+	Version string `json:"version"`
+	Mode    string `json:"mode"`
+	// Bytes is the size of the parsed data, once decompressed, and SHA256 its hash
+	Bytes           int    `json:"bytes"`
+	SHA256          string `json:"sha256"`
+	ParseDurationMS int64  `json:"parse_duration_ms"`
+	// DroppedEntries counts the entries left out because they could not be used
+	DroppedEntries int `json:"dropped_entries"`
+	// Pages counts the page loads of browser captures
+	Pages int `json:"pages"`
+	// WebSockets counts the entries holding WebSocket frames
+	WebSockets int            `json:"websockets"`
+	Warnings   []ParseWarning `json:"warnings"`
+
+	// pageIndex holds the pages and the page of each entry
+	pageIndex *PageIndex
+	// webSocketIndex holds the WebSocket frames of the entries
+	webSocketIndex *WebSocketIndex
+	// timingIndex holds the timings of the entries
+	timingIndex *TimingIndex
+	// lookupIndex holds the positions of the entries by URL, method, host and status
+	lookupIndex *LookupIndex
+	// connectionIndex holds the server IP address, certificate issuer, connection and stream of the entries
+	connectionIndex *ConnectionIndex
+	// customFieldIndex holds the custom fields Chrome DevTools adds to the entries
+	customFieldIndex *CustomFieldIndex
+}
+
+// TimingIndex returns the timings of the entries of the parsed file
+func (r *ParseReport) TimingIndex() *TimingIndex {
+	if r == nil || r.timingIndex == nil {
+		return &TimingIndex{}
+	}
+	return r.timingIndex
+}
+
+// ConnectionIndex returns the server IP addresses, certificate issuers, connection IDs and
+// HTTP/2 stream IDs of the entries of the parsed file
+func (r *ParseReport) ConnectionIndex() *ConnectionIndex {
+	if r == nil || r.connectionIndex == nil {
+		return &ConnectionIndex{}
+	}
+	return r.connectionIndex
+}
+
+// CustomFieldIndex returns the custom fields Chrome DevTools adds to the entries of the parsed file
+func (r *ParseReport) CustomFieldIndex() *CustomFieldIndex {
+	if r == nil || r.customFieldIndex == nil {
+		return &CustomFieldIndex{}
+	}
+	return r.customFieldIndex
+}
+
+// LookupIndex returns the positions of the entries of the parsed file by URL, method, host and status
+func (r *ParseReport) LookupIndex() *LookupIndex {
+	if r == nil || r.lookupIndex == nil {
+		return &LookupIndex{}
+	}
+	return r.lookupIndex
+}
+
+// WebSocketIndex returns the WebSocket frames of the entries of the parsed file
+func (r *ParseReport) WebSocketIndex() *WebSocketIndex {
+	if r == nil || r.webSocketIndex == nil {
+		return &WebSocketIndex{}
+	}
+	return r.webSocketIndex
+}
+
+// PageIndex returns the pages of the parsed file and the page of each entry
+func (r *ParseReport) PageIndex() *PageIndex {
+	if r == nil || r.pageIndex == nil {
+		return &PageIndex{}
+	}
+	return r.pageIndex
+}
+
+// warn records a warning about the entry with the given request ID, or the whole file when empty
+func (r *ParseReport) warn(requestID, message string) {
+	r.Warnings = append(r.Warnings, ParseWarning{RequestID: requestID, Message: message})
+}
+
+// HARInfo gives an overview of a loaded HAR file
+type HARInfo struct {
+	Version              string       `json:"version"`
+	Creator              *har.Creator `json:"creator,omitempty"`
+	Entries              int          `json:"entries"`
+	FirstStartedDateTime string       `json:"first_started_datetime,omitempty"`
+	LastStartedDateTime  string       `json:"last_started_datetime,omitempty"`
+	// Excluded counts the entries left out of the analysis with exclude_requests
+	Excluded           int            `json:"excluded"`
+	ExcludedRequestIDs []string       `json:"excluded_request_ids"`
+	Warnings           []ParseWarning `json:"warnings"`
+}
+
+// GetHARInfo summarizes the HAR file along with the report produced when parsing it, entries
+// without a request being reported as excluded from the analysis
+func (p *Parser) GetHARInfo(harData *har.HAR, report *ParseReport) *HARInfo {
+	info := &HARInfo{
+		Version:            harData.Log.Version,
+		Creator:            harData.Log.Creator,
+		Entries:            len(harData.Log.Entries),
+		ExcludedRequestIDs: []string{},
+		Warnings:           []ParseWarning{},
+	}
+	if report != nil {
+		info.Version = report.Version
+		info.Warnings = append(info.Warnings, report.Warnings...)
+	}
+
+	var first, last time.Time
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			info.Excluded++
+			info.ExcludedRequestIDs = append(info.ExcludedRequestIDs, formatRequestID(i))
+			continue
+		}
+		if entry.StartedDateTime.IsZero() {
+			continue
+		}
+		if first.IsZero() || entry.StartedDateTime.Before(first) {
+			first = entry.StartedDateTime
+		}
+		if entry.StartedDateTime.After(last) {
+			last = entry.StartedDateTime
+		}
+	}
+	if !first.IsZero() {
+		info.FirstStartedDateTime = p.formatTime(first, time.RFC3339Nano)
+		info.LastStartedDateTime = p.formatTime(last, time.RFC3339Nano)
+	}
+
+	return info
+}
@@ -0,0 +1,55 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHARInfoSummarizesCapture(t *testing.T) {
+	parser := NewParser()
+	first := newTestEntry("GET", "https://example.com/a")
+	first.StartedDateTime = time.Date(2023, 1, 1, 0, 0, 1, 0, time.UTC)
+	second := newTestEntry("GET", "https://example.com/b")
+	second.StartedDateTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	archive := newTestHAR(first, second)
+	report := &ParseReport{Version: "1.1", Warnings: []ParseWarning{{Message: "log.version is missing, assuming HAR 1.1"}}}
+
+	info := parser.GetHARInfo(archive, report)
+
+	assert.Equal(t, "1.1", info.Version)
+	assert.Equal(t, "test-creator", info.Creator.Name)
+	assert.Equal(t, 2, info.Entries)
+	assert.Equal(t, "2023-01-01T00:00:00Z", info.FirstStartedDateTime)
+	assert.Equal(t, "2023-01-01T00:00:01Z", info.LastStartedDateTime)
+	assert.Equal(t, report.Warnings, info.Warnings)
+}
+
+func TestGetHARInfoWithoutReport(t *testing.T) {
+	parser := NewParser()
+
+	info := parser.GetHARInfo(newTestHAR(), nil)
+
+	assert.Equal(t, "1.2", info.Version)
+	assert.Zero(t, info.Entries)
+	assert.Empty(t, info.FirstStartedDateTime)
+	assert.Empty(t, info.Warnings)
+}
+
+func TestGetHARInfoReportsExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	harData := newTestHAR(
+		newTestEntry("GET", "https://example.com/"),
+		newTestEntry("GET", "https://www.google-analytics.com/g/collect"),
+	)
+	excluded, err := parser.Exclude(harData, []string{"request_1"})
+	assert.NoError(t, err)
+
+	info := parser.GetHARInfo(excluded, nil)
+
+	assert.Equal(t, 2, info.Entries)
+	assert.Equal(t, 1, info.Excluded)
+	assert.Equal(t, []string{"request_1"}, info.ExcludedRequestIDs)
+	assert.Equal(t, []string{}, parser.GetHARInfo(harData, nil).ExcludedRequestIDs)
+}
@@ -0,0 +1,295 @@
+package har
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// ConnectionIndex holds the server IP address, the certificate issuer, the connection ID and the
+// HTTP/2 stream ID of the entries, which martian's model leaves out. It is built when parsing and
+// available from the parse report.
+type ConnectionIndex struct {
+	serverIPs   map[*har.Entry]string
+	issuers     map[*har.Entry]string
+	connections map[*har.Entry]string
+	streams     map[*har.Entry]int
+}
+
+// ServerIPAddress returns the IP address of the server the entry was sent to, empty when unknown
+func (idx *ConnectionIndex) ServerIPAddress(entry *har.Entry) string {
+	if idx == nil {
+		return ""
+	}
+	return idx.serverIPs[entry]
+}
+
+// CertificateIssuer returns the issuer of the certificate of the TLS connection of the entry,
+// recorded by some exporters in _securityDetails, empty when unknown
+func (idx *ConnectionIndex) CertificateIssuer(entry *har.Entry) string {
+	if idx == nil {
+		return ""
+	}
+	return idx.issuers[entry]
+}
+
+// ConnectionID returns the ID of the connection the entry was sent over, empty when unknown
+func (idx *ConnectionIndex) ConnectionID(entry *har.Entry) string {
+	if idx == nil {
+		return ""
+	}
+	return idx.connections[entry]
+}
+
+// StreamID returns the HTTP/2 stream ID of the entry and whether the exporter recorded it
+func (idx *ConnectionIndex) StreamID(entry *har.Entry) (int, bool) {
+	if idx == nil {
+		return 0, false
+	}
+	streamID, ok := idx.streams[entry]
+	return streamID, ok
+}
+
+// Signals of a capture recorded through a proxy
+const (
+	SignalInspectionProduct = "inspection-product"
+	SignalInspectionIssuer  = "inspection-certificate-issuer"
+	SignalUniformIssuer     = "uniform-certificate-issuer"
+	SignalUniformServerIP   = "uniform-server-ip"
+	SignalSharedVia         = "shared-via-header"
+	SignalProxyConnection   = "proxy-connection-header"
+)
+
+// Confidence levels of the interception report
+const (
+	ConfidenceNone   = "none"
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+// minInterceptedSites is the number of unrelated sites sharing a server IP address, certificate
+// issuer or Via header above which they are attributed to a proxy rather than to a CDN
+const minInterceptedSites = 3
+
+// inspectionProducts are lowercase names of TLS-inspecting proxies and debugging proxies, as
+// found in Via headers, vendor headers and the issuers of the certificates they forge
+var inspectionProducts = []string{
+	"zscaler", "bluecoat", "blue coat", "netskope", "forcepoint", "websense", "fortigate",
+	"fortinet", "palo alto", "paloalto", "sophos", "mcafee web gateway", "ironport",
+	"cisco umbrella", "symantec web", "checkpoint", "check point", "kaspersky", "eset ssl filter",
+	"avast", "bitdefender", "mitmproxy", "charles proxy", "fiddler", "portswigger",
+	"burp",
+}
+
+// InterceptionSignal is a piece of evidence that the capture went through a proxy
+type InterceptionSignal struct {
+	Signal string `json:"signal"`
+	// TLS tells whether the signal shows HTTPS traffic was decrypted, not merely forwarded
+	TLS     bool   `json:"tls"`
+	Message string `json:"message"`
+	// Occurrences counts the requests showing the signal, RequestIDs lists the first of them
+	Occurrences int      `json:"occurrences"`
+	RequestIDs  []string `json:"request_ids"`
+}
+
+// InterceptionReport tells whether a capture was recorded behind a proxy, possibly decrypting
+// TLS, in which case timings and TLS findings describe the proxy rather than the servers
+type InterceptionReport struct {
+	Proxied       bool                 `json:"proxied"`
+	TLSInspection bool                 `json:"tls_inspection"`
+	Confidence    string               `json:"confidence"`
+	Signals       []InterceptionSignal `json:"signals"`
+	// Caveats qualify the latency and TLS findings of the capture
+	Caveats []string `json:"caveats"`
+}
+
+// interceptionEvidence accumulates the requests showing a signal
+type interceptionEvidence struct {
+	signals map[string]*InterceptionSignal
+	order   []string
+}
+
+// add records a request showing the signal
+func (e *interceptionEvidence) add(signal string, tls bool, message, requestID string) {
+	key := signal + "|" + message
+	found, ok := e.signals[key]
+	if !ok {
+		found = &InterceptionSignal{Signal: signal, TLS: tls, Message: message}
+		e.signals[key] = found
+		e.order = append(e.order, key)
+	}
+	if slices.Contains(found.RequestIDs, requestID) {
+		return
+	}
+	found.Occurrences++
+	if len(found.RequestIDs) < maxSecurityEvidence {
+		found.RequestIDs = append(found.RequestIDs, requestID)
+	}
+}
+
+// sharedValue groups the requests of unrelated sites sharing a server IP, issuer or Via header
+type sharedValue struct {
+	sites      map[string]bool
+	requestIDs []string
+}
+
+// DetectInterception looks for signs that the capture was recorded through a proxy: inspection
+// products named in Via headers, vendor headers or certificate issuers, Proxy-Connection
+// request headers, and unrelated sites sharing one server IP address, one certificate issuer or
+// one Via header. TLS inspection is reported when HTTPS traffic shows such signs, since a proxy
+// merely tunneling TLS cannot alter it. Server IP addresses and certificate issuers are read
+// from the connection index.
+func (p *Parser) DetectInterception(harData *har.HAR, index *ConnectionIndex) *InterceptionReport {
+	evidence := &interceptionEvidence{signals: make(map[string]*InterceptionSignal)}
+	serverIPs := make(map[string]*sharedValue)
+	issuers := make(map[string]*sharedValue)
+	vias := make(map[string]*sharedValue)
+	httpsSites := make(map[string]bool)
+
+	share := func(values map[string]*sharedValue, value, site, requestID string) {
+		shared, ok := values[value]
+		if !ok {
+			shared = &sharedValue{sites: make(map[string]bool)}
+			values[value] = shared
+		}
+		shared.sites[site] = true
+		shared.requestIDs = append(shared.requestIDs, requestID)
+	}
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || isLoopbackHost(u.Hostname()) {
+			continue
+		}
+		requestID := formatRequestID(i)
+		site := RegistrableDomain(u.Hostname())
+		secure := u.Scheme == "https" || u.Scheme == "wss"
+		if secure {
+			httpsSites[site] = true
+		}
+
+		for _, header := range entry.Request.Headers {
+			if strings.EqualFold(header.Name, "Proxy-Connection") {
+				evidence.add(SignalProxyConnection, false, "requests carry a Proxy-Connection header, sent by clients configured with an explicit proxy", requestID)
+			}
+		}
+		if ip := index.ServerIPAddress(entry); ip != "" {
+			share(serverIPs, strings.Trim(ip, "[]"), site, requestID)
+		}
+		if issuer := index.CertificateIssuer(entry); issuer != "" {
+			if product := inspectionProduct(issuer); product != "" {
+				evidence.add(SignalInspectionIssuer, true, fmt.Sprintf("certificates are issued by %q, a %s certificate authority", issuer, product), requestID)
+			}
+			share(issuers, issuer, site, requestID)
+		}
+		if entry.Response == nil {
+			continue
+		}
+		for _, header := range entry.Response.Headers {
+			name := strings.ToLower(header.Name)
+			if name == "via" {
+				if product := inspectionProduct(header.Value); product != "" {
+					evidence.add(SignalInspectionProduct, secure, fmt.Sprintf("Via header names %s", product), requestID)
+				}
+				share(vias, header.Value, site, requestID)
+			} else if product := inspectionProduct(name); product != "" && strings.HasPrefix(name, "x-") {
+				evidence.add(SignalInspectionProduct, secure, fmt.Sprintf("%s header added by %s", header.Name, product), requestID)
+			}
+		}
+	}
+
+	for _, ip := range slices.Sorted(maps.Keys(serverIPs)) {
+		shared := serverIPs[ip]
+		if len(shared.sites) < minInterceptedSites {
+			continue
+		}
+		message := fmt.Sprintf("%d unrelated sites were all reached at %s", len(shared.sites), ip)
+		if parsed := net.ParseIP(ip); parsed != nil && (parsed.IsLoopback() || parsed.IsPrivate()) {
+			message = fmt.Sprintf("%d unrelated sites were all reached at the local address %s, as with debugging proxies", len(shared.sites), ip)
+		}
+		for _, requestID := range shared.requestIDs {
+			evidence.add(SignalUniformServerIP, false, message, requestID)
+		}
+	}
+	for _, issuer := range slices.Sorted(maps.Keys(issuers)) {
+		shared := issuers[issuer]
+		// Public certificate authorities issue for many sites, only one issuing for every site is suspicious
+		if len(shared.sites) < minInterceptedSites || len(shared.sites) < len(httpsSites) {
+			continue
+		}
+		for _, requestID := range shared.requestIDs {
+			evidence.add(SignalUniformIssuer, true, fmt.Sprintf("the certificates of all %d HTTPS sites are issued by %q", len(shared.sites), issuer), requestID)
+		}
+	}
+	for _, via := range slices.Sorted(maps.Keys(vias)) {
+		shared := vias[via]
+		if len(shared.sites) < minInterceptedSites {
+			continue
+		}
+		for _, requestID := range shared.requestIDs {
+			evidence.add(SignalSharedVia, false, fmt.Sprintf("%d unrelated sites answer with the same Via header %q, added on the client side", len(shared.sites), via), requestID)
+		}
+	}
+
+	report := &InterceptionReport{Confidence: ConfidenceNone, Signals: []InterceptionSignal{}, Caveats: []string{}}
+	for _, key := range evidence.order {
+		signal := *evidence.signals[key]
+		report.Signals = append(report.Signals, signal)
+		report.Proxied = true
+		report.TLSInspection = report.TLSInspection || signal.TLS
+	}
+	report.Confidence = interceptionConfidence(report.Signals)
+	if report.Proxied {
+		report.Caveats = append(report.Caveats, "Latency includes the proxy hop: DNS, connect and wait times may measure the proxy rather than the servers")
+	}
+	if report.TLSInspection {
+		report.Caveats = append(report.Caveats,
+			"TLS was terminated by the proxy: SSL timings, protocols, ciphers and certificates describe the proxy connection, not the servers",
+			"Security findings about transport and certificates may not apply to the servers, and the proxy may have altered headers")
+	}
+	return report
+}
+
+// inspectionProduct returns the inspection product named in the value, empty when none is
+func inspectionProduct(value string) string {
+	lower := strings.ToLower(value)
+	for _, product := range inspectionProducts {
+		if strings.Contains(lower, product) {
+			return product
+		}
+	}
+	return ""
+}
+
+// interceptionConfidence grades the signals: products and forged certificates are conclusive,
+// values shared by unrelated sites likely, proxy headers only show an explicit proxy
+func interceptionConfidence(signals []InterceptionSignal) string {
+	confidence := ConfidenceNone
+	for _, signal := range signals {
+		level := ConfidenceLow
+		switch signal.Signal {
+		case SignalInspectionProduct, SignalInspectionIssuer:
+			level = ConfidenceHigh
+		case SignalUniformServerIP, SignalUniformIssuer, SignalSharedVia:
+			level = ConfidenceMedium
+		}
+		if confidenceRank(level) > confidenceRank(confidence) {
+			confidence = level
+		}
+	}
+	return confidence
+}
+
+// confidenceRank orders the confidence levels, none first
+func confidenceRank(confidence string) int {
+	return slices.Index([]string{ConfidenceNone, ConfidenceLow, ConfidenceMedium, ConfidenceHigh}, confidence)
+}
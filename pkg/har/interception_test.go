@@ -0,0 +1,105 @@
+package har
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseTestConnections parses a document whose entries request the URLs from the server IP
+// addresses over connections whose certificates are issued by the issuers, empty values being left out
+func parseTestConnections(t *testing.T, connections ...[3]string) (*har.HAR, *ParseReport) {
+	t.Helper()
+	entries := make([]string, 0, len(connections))
+	for _, connection := range connections {
+		extensions := ""
+		if connection[1] != "" {
+			extensions += fmt.Sprintf(`, "serverIPAddress": %q`, connection[1])
+		}
+		if connection[2] != "" {
+			extensions += fmt.Sprintf(`, "_securityDetails": {"protocol": "TLS 1.3", "issuer": %q}`, connection[2])
+		}
+		entries = append(entries, fmt.Sprintf(`{"startedDateTime": "2023-01-01T00:00:00Z", "time": 10, "request": {"method": "GET", "url": %q, "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			"response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/html"}, "redirectURL": "", "headersSize": -1, "bodySize": 0}%s}`, connection[0], extensions))
+	}
+	document := `{"log": {"version": "1.2", "creator": {"name": "test", "version": "1"}, "entries": [` + strings.Join(entries, ",") + `]}}`
+
+	harData, report, err := NewParser().ParseWithReport(strings.NewReader(document))
+	require.NoError(t, err)
+	return harData, report
+}
+
+func TestParseKeepsConnectionDetails(t *testing.T) {
+	harData, report := parseTestConnections(t, [3]string{"https://example.com/", "93.184.216.34", "DigiCert TLS RSA SHA256 2020 CA1"})
+
+	index := report.ConnectionIndex()
+	assert.Equal(t, "93.184.216.34", index.ServerIPAddress(harData.Log.Entries[0]))
+	assert.Equal(t, "DigiCert TLS RSA SHA256 2020 CA1", index.CertificateIssuer(harData.Log.Entries[0]))
+}
+
+func TestDetectInterceptionFromInspectionIssuer(t *testing.T) {
+	harData, report := parseTestConnections(t,
+		[3]string{"https://example.com/", "", "Zscaler Intermediate Root CA"},
+		[3]string{"https://example.org/", "", "Zscaler Intermediate Root CA"},
+	)
+
+	interception := NewParser().DetectInterception(harData, report.ConnectionIndex())
+
+	assert.True(t, interception.TLSInspection)
+	assert.Equal(t, ConfidenceHigh, interception.Confidence)
+	require.Len(t, interception.Signals, 1)
+	assert.Equal(t, SignalInspectionIssuer, interception.Signals[0].Signal)
+	assert.Equal(t, []string{"request_0", "request_1"}, interception.Signals[0].RequestIDs)
+	assert.Len(t, interception.Caveats, 3)
+}
+
+func TestDetectInterceptionFromUniformServerIP(t *testing.T) {
+	harData, report := parseTestConnections(t,
+		[3]string{"https://example.com/", "127.0.0.1", ""},
+		[3]string{"https://cdn.example.net/app.js", "127.0.0.1", ""},
+		[3]string{"https://api.example.org/users", "127.0.0.1", ""},
+	)
+
+	interception := NewParser().DetectInterception(harData, report.ConnectionIndex())
+
+	assert.True(t, interception.Proxied)
+	assert.False(t, interception.TLSInspection)
+	assert.Equal(t, ConfidenceMedium, interception.Confidence)
+	require.Len(t, interception.Signals, 1)
+	assert.Equal(t, SignalUniformServerIP, interception.Signals[0].Signal)
+	assert.Contains(t, interception.Signals[0].Message, "local address 127.0.0.1")
+}
+
+func TestDetectInterceptionIgnoresCDNs(t *testing.T) {
+	harData, report := parseTestConnections(t,
+		[3]string{"https://example.com/", "151.101.1.1", "GlobalSign Atlas R3 DV TLS CA"},
+		[3]string{"https://example.com/app.js", "151.101.1.1", "GlobalSign Atlas R3 DV TLS CA"},
+		[3]string{"https://example.org/", "93.184.216.34", "DigiCert TLS RSA SHA256 2020 CA1"},
+	)
+
+	interception := NewParser().DetectInterception(harData, report.ConnectionIndex())
+
+	assert.False(t, interception.Proxied)
+	assert.Equal(t, ConfidenceNone, interception.Confidence)
+	assert.Empty(t, interception.Signals)
+	assert.Empty(t, interception.Caveats)
+}
+
+func TestDetectInterceptionFromHeaders(t *testing.T) {
+	parser := NewParser()
+	proxied := newTestEntry("GET", "http://example.com/", har.Header{Name: "Proxy-Connection", Value: "keep-alive"})
+	inspected := newTestEntry("GET", "https://example.org/")
+	inspected.Response.Headers = []har.Header{{Name: "Via", Value: "1.1 BlueCoat-ProxySG"}}
+
+	interception := parser.DetectInterception(newTestHAR(proxied, inspected), nil)
+
+	assert.True(t, interception.TLSInspection)
+	assert.Equal(t, ConfidenceHigh, interception.Confidence)
+	require.Len(t, interception.Signals, 2)
+	assert.Equal(t, SignalProxyConnection, interception.Signals[0].Signal)
+	assert.Equal(t, SignalInspectionProduct, interception.Signals[1].Signal)
+}
@@ -0,0 +1,535 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultQueryMatches is the number of matches returned by QueryResponseBody when no limit is given
+	DefaultQueryMatches = 50
+	// MaxQueryMatches caps the number of matches returned at once
+	MaxQueryMatches = 1000
+	// maxQueryValueSize is the serialized size above which a matched value is replaced by a marker
+	maxQueryValueSize = 64 * 1024
+)
+
+var (
+	// jsonIdentifierPattern matches the member names written in dot notation in JSONPaths
+	jsonIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$-]*$`)
+	// jsonPathOperandPattern matches the path to the member a filter tests, such as @.user.id
+	jsonPathOperandPattern = regexp.MustCompile(`^@(?:\.[A-Za-z0-9_$-]+)*`)
+)
+
+// JSONPathMatch is a value matched by a JSONPath expression, with its normalized path
+type JSONPathMatch struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// ResponseBodyQuery lists the values of a JSON response body matched by a JSONPath expression
+type ResponseBodyQuery struct {
+	RequestID  string `json:"request_id"`
+	Expression string `json:"expression"`
+	// Total counts every match, Matches lists the first of them
+	Total     int             `json:"total"`
+	Matches   []JSONPathMatch `json:"matches"`
+	Truncated bool            `json:"truncated"`
+}
+
+// jsonPathStep selects children of the current nodes, or of all their descendants when recursive
+type jsonPathStep struct {
+	recursive bool
+	names     []string
+	indices   []int
+	wildcard  bool
+	slice     *jsonPathSlice
+	filter    *jsonPathFilter
+}
+
+// jsonPathSlice selects the array items from start to end, exclusive, every step items
+type jsonPathSlice struct {
+	start, end, step int
+	hasStart, hasEnd bool
+}
+
+// jsonPathFilter keeps the children whose member at path compares to value, or exists when
+// operator is empty
+type jsonPathFilter struct {
+	path     []string
+	operator string
+	value    interface{}
+}
+
+// jsonPathNode is a value of the document reached by an expression
+type jsonPathNode struct {
+	path  string
+	value interface{}
+}
+
+// QueryResponseBody evaluates a JSONPath expression against the JSON response body of a request
+// and returns at most limit of the matched values (DefaultQueryMatches when not positive, capped
+// at MaxQueryMatches), so a single field can be read from a huge payload. The body is decoded and
+// redacted first. Supported syntax: $ for the root, .name and ['name'] for members, [n] for
+// items (negative from the end), [start:end:step] slices, [a,b] unions, * wildcards, .. for
+// recursive descent and [?(@.member op value)] filters with ==, !=, <, <=, > and >=, or
+// [?(@.member)] to test for a member.
+func (p *Parser) QueryResponseBody(harData *har.HAR, requestID, expression string, limit int) (*ResponseBodyQuery, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := parseJSONPath(expression)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultQueryMatches
+	}
+	limit = min(limit, MaxQueryMatches)
+
+	if entry.Response == nil || entry.Response.Content == nil || len(entry.Response.Content.Text) == 0 {
+		return nil, fmt.Errorf("request %s has no response body", requestID)
+	}
+	decoded, _ := decodedResponse(entry.Response)
+	response := p.redaction().response(decoded)
+	decoder := json.NewDecoder(bytes.NewReader(response.Content.Text))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("response body of request %s is not JSON: %w", requestID, err)
+	}
+
+	nodes := evaluateJSONPath(steps, document)
+	query := &ResponseBodyQuery{RequestID: requestID, Expression: expression, Total: len(nodes), Matches: []JSONPathMatch{}}
+	for _, node := range nodes {
+		if len(query.Matches) == limit {
+			query.Truncated = true
+			break
+		}
+		value := node.value
+		if data, err := json.Marshal(value); err == nil && len(data) > maxQueryValueSize {
+			value = fmt.Sprintf("[value omitted: %d bytes, query a narrower path]", len(data))
+		}
+		query.Matches = append(query.Matches, JSONPathMatch{Path: node.path, Value: value})
+	}
+	return query, nil
+}
+
+// parseJSONPath parses a JSONPath expression into the steps selecting its values
+func parseJSONPath(expression string) ([]jsonPathStep, error) {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: expressions start with $", expression)
+	}
+	var steps []jsonPathStep
+	rest := expression[1:]
+	for rest != "" {
+		step := jsonPathStep{}
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", expression, rest)
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := closingBracket(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed bracket", expression)
+			}
+			if err := parseBracketSelector(strings.TrimSpace(rest[1:end]), &step); err != nil {
+				return nil, fmt.Errorf("invalid JSONPath %q: %w", expression, err)
+			}
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty member name", expression)
+			}
+			if name == "*" {
+				step.wildcard = true
+			} else {
+				step.names = []string{name}
+			}
+			rest = rest[end:]
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// closingBracket returns the index of the bracket closing the one opening s, skipping quoted
+// strings and nested brackets, -1 when there is none
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseBracketSelector parses the content of a bracket: a wildcard, a filter, a slice, or a
+// union of member names and item indices
+func parseBracketSelector(content string, step *jsonPathStep) error {
+	switch {
+	case content == "*":
+		step.wildcard = true
+		return nil
+	case strings.HasPrefix(content, "?"):
+		filter, err := parseJSONPathFilter(strings.TrimSpace(content[1:]))
+		if err != nil {
+			return err
+		}
+		step.filter = filter
+		return nil
+	case !strings.ContainsAny(content, `'"`) && strings.Contains(content, ":"):
+		slice, err := parseJSONPathSlice(content)
+		if err != nil {
+			return err
+		}
+		step.slice = slice
+		return nil
+	}
+
+	for _, item := range splitUnion(content) {
+		item = strings.TrimSpace(item)
+		if name, ok := unquote(item); ok {
+			step.names = append(step.names, name)
+			continue
+		}
+		index, err := strconv.Atoi(item)
+		if err != nil {
+			return fmt.Errorf("invalid selector %q", item)
+		}
+		step.indices = append(step.indices, index)
+	}
+	return nil
+}
+
+// splitUnion splits the items of a union on the commas outside quoted strings
+func splitUnion(content string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',':
+			items = append(items, content[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, content[start:])
+}
+
+// unquote returns the content of a single or double quoted string
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", false
+	}
+	quote := string(s[0])
+	inner := s[1 : len(s)-1]
+	inner = strings.ReplaceAll(inner, `\`+quote, quote)
+	return strings.ReplaceAll(inner, `\\`, `\`), true
+}
+
+// parseJSONPathSlice parses a start:end:step slice, every part being optional
+func parseJSONPathSlice(content string) (*jsonPathSlice, error) {
+	parts := strings.Split(content, ":")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid slice %q", content)
+	}
+	slice := &jsonPathSlice{step: 1}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice %q", content)
+		}
+		switch i {
+		case 0:
+			slice.start, slice.hasStart = value, true
+		case 1:
+			slice.end, slice.hasEnd = value, true
+		case 2:
+			if value <= 0 {
+				return nil, fmt.Errorf("invalid slice %q: steps must be positive", content)
+			}
+			slice.step = value
+		}
+	}
+	return slice, nil
+}
+
+// parseJSONPathFilter parses a (@.member op value) filter expression
+func parseJSONPathFilter(content string) (*jsonPathFilter, error) {
+	if !strings.HasPrefix(content, "(") || !strings.HasSuffix(content, ")") {
+		return nil, fmt.Errorf("invalid filter %q: expected ?(...)", content)
+	}
+	content = strings.TrimSpace(content[1 : len(content)-1])
+	if !strings.HasPrefix(content, "@") {
+		return nil, fmt.Errorf("invalid filter %q: filters test the current item @", content)
+	}
+
+	operand := jsonPathOperandPattern.FindString(content)
+	filter := &jsonPathFilter{}
+	for _, name := range strings.Split(strings.TrimPrefix(operand, "@"), ".") {
+		if name != "" {
+			filter.path = append(filter.path, name)
+		}
+	}
+	comparison := strings.TrimSpace(content[len(operand):])
+	if comparison == "" {
+		return filter, nil
+	}
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(comparison, operator) {
+			value, err := parseJSONPathLiteral(strings.TrimSpace(comparison[len(operator):]))
+			if err != nil {
+				return nil, err
+			}
+			filter.operator, filter.value = operator, value
+			return filter, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid filter %q: unknown operator", content)
+}
+
+// parseJSONPathLiteral parses a quoted string, a number, true, false or null
+func parseJSONPathLiteral(literal string) (interface{}, error) {
+	if value, ok := unquote(literal); ok {
+		return value, nil
+	}
+	switch literal {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter value %q", literal)
+	}
+	return number, nil
+}
+
+// evaluateJSONPath returns the nodes of the document the steps select, in document order
+func evaluateJSONPath(steps []jsonPathStep, document interface{}) []jsonPathNode {
+	nodes := []jsonPathNode{{path: "$", value: document}}
+	for _, step := range steps {
+		var selected []jsonPathNode
+		for _, node := range nodes {
+			if step.recursive {
+				for _, descendant := range descendants(node) {
+					selected = append(selected, step.selectChildren(descendant)...)
+				}
+			} else {
+				selected = append(selected, step.selectChildren(node)...)
+			}
+		}
+		nodes = selected
+	}
+	return nodes
+}
+
+// descendants returns the node followed by all the values it holds, depth first
+func descendants(node jsonPathNode) []jsonPathNode {
+	result := []jsonPathNode{node}
+	for _, child := range children(node) {
+		result = append(result, descendants(child)...)
+	}
+	return result
+}
+
+// children returns the members of an object, by name, or the items of an array
+func children(node jsonPathNode) []jsonPathNode {
+	var result []jsonPathNode
+	switch typed := node.value.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			result = append(result, jsonPathNode{path: memberPath(node.path, name), value: typed[name]})
+		}
+	case []interface{}:
+		for i, item := range typed {
+			result = append(result, jsonPathNode{path: fmt.Sprintf("%s[%d]", node.path, i), value: item})
+		}
+	}
+	return result
+}
+
+// selectChildren returns the children of a node the step selects
+func (s jsonPathStep) selectChildren(node jsonPathNode) []jsonPathNode {
+	switch {
+	case s.wildcard:
+		return children(node)
+	case s.filter != nil:
+		var result []jsonPathNode
+		for _, child := range children(node) {
+			if s.filter.matches(child.value) {
+				result = append(result, child)
+			}
+		}
+		return result
+	}
+
+	var result []jsonPathNode
+	switch typed := node.value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.names {
+			if value, ok := typed[name]; ok {
+				result = append(result, jsonPathNode{path: memberPath(node.path, name), value: value})
+			}
+		}
+	case []interface{}:
+		item := func(i int) {
+			result = append(result, jsonPathNode{path: fmt.Sprintf("%s[%d]", node.path, i), value: typed[i]})
+		}
+		for _, index := range s.indices {
+			if index < 0 {
+				index += len(typed)
+			}
+			if index >= 0 && index < len(typed) {
+				item(index)
+			}
+		}
+		if s.slice != nil {
+			start, end := 0, len(typed)
+			if s.slice.hasStart {
+				start = sliceBound(s.slice.start, len(typed))
+			}
+			if s.slice.hasEnd {
+				end = sliceBound(s.slice.end, len(typed))
+			}
+			for i := start; i < end; i += s.slice.step {
+				item(i)
+			}
+		}
+	}
+	return result
+}
+
+// sliceBound resolves a slice bound, negative bounds counting from the end
+func sliceBound(bound, length int) int {
+	if bound < 0 {
+		bound += length
+	}
+	return max(0, min(bound, length))
+}
+
+// memberPath appends a member to a normalized path, in bracket notation when not an identifier
+func memberPath(path, name string) string {
+	if jsonIdentifierPattern.MatchString(name) {
+		return path + "." + name
+	}
+	return path + "['" + strings.ReplaceAll(strings.ReplaceAll(name, `\`, `\\`), "'", `\'`) + "']"
+}
+
+// matches reports whether a value passes the filter
+func (f *jsonPathFilter) matches(value interface{}) bool {
+	for _, name := range f.path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok = object[name]; !ok {
+			return false
+		}
+	}
+	if f.operator == "" {
+		return true
+	}
+
+	if number, ok := value.(json.Number); ok {
+		actual, err := number.Float64()
+		expected, isNumber := f.value.(float64)
+		if err != nil || !isNumber {
+			return f.operator == "!="
+		}
+		return compareOrdered(actual, expected, f.operator)
+	}
+	if actual, ok := value.(string); ok {
+		expected, isString := f.value.(string)
+		if !isString {
+			return f.operator == "!="
+		}
+		return compareOrdered(actual, expected, f.operator)
+	}
+	switch f.operator {
+	case "==":
+		return value == f.value
+	case "!=":
+		return value != f.value
+	}
+	return false
+}
+
+// compareOrdered applies a comparison operator to two numbers or strings
+func compareOrdered[T float64 | string](actual, expected T, operator string) bool {
+	switch operator {
+	case "==":
+		return actual == expected
+	case "!=":
+		return actual != expected
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	}
+	return false
+}
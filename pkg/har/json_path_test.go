@@ -0,0 +1,140 @@
+package har
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSONPathBody = `{
+	"data": {
+		"users": [
+			{"id": 1, "name": "alice", "roles": ["admin"], "address": {"city": "Paris"}},
+			{"id": 2, "name": "bob", "active": false},
+			{"id": 3, "name": "carol", "address": {"city": "Berlin"}}
+		],
+		"next-page": "/users?page=2"
+	},
+	"meta": {"total": 12345678901234567890}
+}`
+
+// assertQueryMatches checks the paths and values the expression matches in testJSONPathBody
+func assertQueryMatches(t *testing.T, expression string, paths []string, values string) {
+	t.Helper()
+	archive := newTestHAR(newTestResponseEntry("https://api.example.com/users", "application/json", testJSONPathBody))
+
+	query, err := NewParser().QueryResponseBody(archive, "request_0", expression, 0)
+	require.NoError(t, err)
+
+	var matchedPaths []string
+	matchedValues := []interface{}{}
+	for _, match := range query.Matches {
+		matchedPaths = append(matchedPaths, match.Path)
+		matchedValues = append(matchedValues, match.Value)
+	}
+	assert.Equal(t, paths, matchedPaths)
+	data, err := json.Marshal(matchedValues)
+	require.NoError(t, err)
+	assert.Equal(t, values, string(data))
+	assert.Equal(t, len(paths), query.Total)
+}
+
+func TestQueryResponseBodyChildMember(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[0].name", []string{"$.data.users[0].name"}, `["alice"]`)
+}
+
+func TestQueryResponseBodyBracketMember(t *testing.T) {
+	assertQueryMatches(t, "$['data']['next-page']", []string{"$.data.next-page"}, `["/users?page=2"]`)
+}
+
+func TestQueryResponseBodyNegativeIndex(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[-1].id", []string{"$.data.users[2].id"}, `[3]`)
+}
+
+func TestQueryResponseBodyWildcard(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[*].id", []string{"$.data.users[0].id", "$.data.users[1].id", "$.data.users[2].id"}, `[1,2,3]`)
+}
+
+func TestQueryResponseBodySlice(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[0:2].name", []string{"$.data.users[0].name", "$.data.users[1].name"}, `["alice","bob"]`)
+}
+
+func TestQueryResponseBodyIndexUnion(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[0,2].name", []string{"$.data.users[0].name", "$.data.users[2].name"}, `["alice","carol"]`)
+}
+
+func TestQueryResponseBodyRecursiveDescent(t *testing.T) {
+	assertQueryMatches(t, "$..city", []string{"$.data.users[0].address.city", "$.data.users[2].address.city"}, `["Paris","Berlin"]`)
+}
+
+func TestQueryResponseBodyNumericFilter(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[?(@.id >= 2)].name", []string{"$.data.users[1].name", "$.data.users[2].name"}, `["bob","carol"]`)
+}
+
+func TestQueryResponseBodyStringFilter(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[?(@.name == 'bob')].id", []string{"$.data.users[1].id"}, `[2]`)
+}
+
+func TestQueryResponseBodyExistenceFilter(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[?(@.address.city)].id", []string{"$.data.users[0].id", "$.data.users[2].id"}, `[1,3]`)
+}
+
+func TestQueryResponseBodyBooleanFilter(t *testing.T) {
+	assertQueryMatches(t, "$.data.users[?(@.active == false)].name", []string{"$.data.users[1].name"}, `["bob"]`)
+}
+
+func TestQueryResponseBodyKeepsLargeNumbers(t *testing.T) {
+	assertQueryMatches(t, "$.meta.total", []string{"$.meta.total"}, `[12345678901234567890]`)
+}
+
+func TestQueryResponseBodyMissingMember(t *testing.T) {
+	assertQueryMatches(t, "$.data.missing", nil, `[]`)
+}
+
+func TestQueryResponseBodyLimitsMatches(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestResponseEntry("https://api.example.com/users", "application/json", testJSONPathBody))
+
+	query, err := parser.QueryResponseBody(archive, "request_0", "$..*", 2)
+	require.NoError(t, err)
+
+	assert.Len(t, query.Matches, 2)
+	assert.Greater(t, query.Total, 2)
+	assert.True(t, query.Truncated)
+	assert.Equal(t, "$.data", query.Matches[0].Path)
+}
+
+func TestQueryResponseBodyRedactsValues(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(newTestResponseEntry("https://api.example.com/keys", "application/json", `{"key": "sk_live_abc123"}`))
+
+	query, err := parser.QueryResponseBody(archive, "request_0", "$.key", 0)
+	require.NoError(t, err)
+
+	require.Len(t, query.Matches, 1)
+	assert.Equal(t, redactedValue, query.Matches[0].Value)
+}
+
+func TestQueryResponseBodyRejectsInvalidQueries(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestResponseEntry("https://api.example.com/users", "application/json", testJSONPathBody),
+		newTestResponseEntry("https://example.com/", "text/html", "<html></html>"),
+	)
+
+	for expression, message := range map[string]string{
+		"data.users":                "expressions start with $",
+		"$.data[0":                  "unclosed bracket",
+		"$.data[abc]":               `invalid selector "abc"`,
+		"$.data.users[?(@.id ~ 2)]": "unknown operator",
+	} {
+		_, err := parser.QueryResponseBody(archive, "request_0", expression, 0)
+		assert.ErrorContains(t, err, message, expression)
+	}
+
+	_, err := parser.QueryResponseBody(archive, "request_1", "$", 0)
+	assert.ErrorContains(t, err, "is not JSON")
+}
@@ -0,0 +1,83 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/martian/har"
+)
+
+// jsonRPCRequest is a JSON-RPC call, JSON-RPC 1.0 calls have no jsonrpc member
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonRPCResponse is a JSON-RPC reply
+type jsonRPCResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// jsonRPCMethods returns the methods called by a JSON-RPC request, several for batches,
+// and whether the request is a JSON-RPC request at all
+func jsonRPCMethods(request *har.Request) ([]string, bool) {
+	if request == nil || request.PostData == nil || request.PostData.Text == "" {
+		return nil, false
+	}
+
+	text := []byte(request.PostData.Text)
+	var calls []jsonRPCRequest
+	var single jsonRPCRequest
+	if err := json.Unmarshal(text, &single); err == nil {
+		calls = append(calls, single)
+	} else if err := json.Unmarshal(text, &calls); err != nil {
+		return nil, false
+	}
+
+	var methods []string
+	for _, call := range calls {
+		if !call.isJSONRPC() {
+			return nil, false
+		}
+		methods = append(methods, call.Method)
+	}
+	return methods, len(methods) > 0
+}
+
+// isJSONRPC reports whether the object is a JSON-RPC 2.0 call, or a JSON-RPC 1.0 one
+// (method, params and id members)
+func (r jsonRPCRequest) isJSONRPC() bool {
+	if r.Method == "" {
+		return false
+	}
+	return r.JSONRPC == "2.0" || (r.JSONRPC == "" && r.Params != nil && r.ID != nil)
+}
+
+// jsonRPCErrors returns the errors reported by a JSON-RPC response, as code and message.
+// Batched responses are supported.
+func jsonRPCErrors(response *har.Response) []string {
+	if response == nil || response.Content == nil || len(response.Content.Text) == 0 {
+		return nil
+	}
+
+	var replies []jsonRPCResponse
+	var single jsonRPCResponse
+	if err := json.Unmarshal(response.Content.Text, &single); err == nil {
+		replies = append(replies, single)
+	} else if err := json.Unmarshal(response.Content.Text, &replies); err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, reply := range replies {
+		if reply.Error != nil {
+			messages = append(messages, fmt.Sprintf("%d: %s", reply.Error.Code, reply.Error.Message))
+		}
+	}
+	return messages
+}
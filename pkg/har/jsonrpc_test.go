@@ -0,0 +1,53 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestJSONRPCEntry builds a JSON-RPC call answered with a 200 status
+func newTestJSONRPCEntry(requestBody, responseBody string) *har.Entry {
+	entry := newTestResponseEntry("https://rpc.example.com/", "application/json", responseBody)
+	entry.Request.Method = "POST"
+	entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: requestBody}
+	return entry
+}
+
+func TestJSONRPCMethods(t *testing.T) {
+	methods, ok := jsonRPCMethods(newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 1}`, `{}`).Request)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"eth_call"}, methods)
+
+	methods, ok = jsonRPCMethods(newTestJSONRPCEntry(`[{"jsonrpc": "2.0", "method": "eth_blockNumber", "id": 1}, {"jsonrpc": "2.0", "method": "eth_getBalance", "params": ["0x1"], "id": 2}]`, `[]`).Request)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"eth_blockNumber", "eth_getBalance"}, methods)
+
+	// JSON-RPC 1.0 calls have no version member
+	methods, ok = jsonRPCMethods(newTestJSONRPCEntry(`{"method": "echo", "params": ["hi"], "id": 1}`, `{}`).Request)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"echo"}, methods)
+
+	_, ok = jsonRPCMethods(newTestJSONRPCEntry(`{"method": "echo"}`, `{}`).Request)
+	assert.False(t, ok)
+	_, ok = jsonRPCMethods(newTestGraphQLEntry(`{"query": "{ me { id } }"}`, `{}`).Request)
+	assert.False(t, ok)
+}
+
+func TestGetErrorSummaryCountsJSONRPCErrorsAsFailures(t *testing.T) {
+	parser := NewParser()
+	failed := newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 1}`,
+		`{"jsonrpc": "2.0", "error": {"code": -32000, "message": "execution reverted"}, "id": 1}`)
+	succeeded := newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 2}`,
+		`{"jsonrpc": "2.0", "result": "0x", "id": 2}`)
+
+	summary := parser.GetErrorSummary(newTestHAR(failed, succeeded), nil)
+
+	assert.Equal(t, 1, summary.Failed)
+	require.Len(t, summary.Groups, 1)
+	assert.Equal(t, FailureJSONRPC, summary.Groups[0].Kind)
+	assert.Equal(t, "eth_call", summary.Groups[0].Operation)
+	assert.Equal(t, []string{"-32000: execution reverted"}, summary.Groups[0].Messages)
+}
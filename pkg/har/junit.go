@@ -0,0 +1,157 @@
+package har
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// JUnit suites rendered by ExportJUnit
+const (
+	JUnitSuiteGolden   = "golden"
+	JUnitSuiteSecurity = "security"
+	JUnitSuiteOAuth    = "oauth"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report, as read by CI test reporting
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the test cases of a kind of check
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a check, passed unless it holds a failure or is skipped
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+// JUnitFailure describes why a check failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+// JUnitSkipped describes why a check did not run
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// ExportJUnit renders the checks run against the HAR file as JUnit test cases: one per golden
+// fixture when fixtures are given, one per security scan rule, and one per OAuth audit check
+// when the capture holds an OAuth flow. Failures list the requests providing evidence.
+func (p *Parser) ExportJUnit(harData *har.HAR, fixtures []GoldenFixture) *JUnitTestSuites {
+	var suites []JUnitTestSuite
+	if len(fixtures) > 0 {
+		suites = append(suites, p.goldenSuite(fixtures, p.CheckAgainstGolden(harData, fixtures)))
+	}
+	suites = append(suites, securitySuite(p.ScanSecurity(harData)))
+	if audit := p.AuditOAuth(harData); len(audit.AuthorizeRequests) > 0 || len(audit.TokenRequests) > 0 {
+		suites = append(suites, oauthSuite(audit))
+	}
+
+	report := &JUnitTestSuites{Name: "har-mcp", Suites: suites}
+	for i := range report.Suites {
+		suite := &report.Suites[i]
+		suite.Tests = len(suite.TestCases)
+		for _, testCase := range suite.TestCases {
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+	return report
+}
+
+// goldenSuite has a test case per fixture, failing when a response drifted from it and skipped
+// when no entry exercised it
+func (p *Parser) goldenSuite(fixtures []GoldenFixture, report *GoldenReport) JUnitTestSuite {
+	suite := JUnitTestSuite{Name: JUnitSuiteGolden}
+	for _, fixture := range fixtures {
+		testCase := JUnitTestCase{Name: fixture.key(), ClassName: JUnitSuiteGolden}
+		var details []string
+		for _, drift := range report.Drifts {
+			if drift.Method == fixture.Method && drift.Template == fixture.Template {
+				details = append(details, fmt.Sprintf("%s: %s", drift.RequestID, strings.Join(drift.Changes, "; ")))
+			}
+		}
+		switch {
+		case len(details) > 0:
+			testCase.Failure = &JUnitFailure{
+				Message: p.sprintf("responses drifting from the golden fixture: %d", len(details)),
+				Type:    "drift",
+				Details: strings.Join(details, "\n"),
+			}
+		case slices.Contains(report.Untested, fixture.key()):
+			testCase.Skipped = &JUnitSkipped{Message: p.message("no request of the capture exercised the endpoint")}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
+
+// securitySuite has a test case per security rule, failing with its findings
+func securitySuite(report *SecurityReport) JUnitTestSuite {
+	suite := JUnitTestSuite{Name: JUnitSuiteSecurity}
+	for _, rule := range SecurityRules {
+		testCase := JUnitTestCase{Name: rule.ID, ClassName: JUnitSuiteSecurity}
+		var messages, details []string
+		for _, finding := range report.Findings {
+			if finding.RuleID == rule.ID {
+				messages = append(messages, finding.Message)
+				details = append(details, fmt.Sprintf("%s (%s)", finding.Message, strings.Join(finding.RequestIDs, ", ")))
+			}
+		}
+		if len(messages) > 0 {
+			testCase.Failure = &JUnitFailure{Message: strings.Join(messages, "; "), Type: rule.Severity, Details: strings.Join(details, "\n")}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
+
+// oauthSuite has a test case per OAuth audit check, failing with its finding
+func oauthSuite(audit *OAuthAudit) JUnitTestSuite {
+	checks := make([]string, 0, len(oauthChecks))
+	for check := range oauthChecks {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+
+	suite := JUnitTestSuite{Name: JUnitSuiteOAuth}
+	for _, check := range checks {
+		testCase := JUnitTestCase{Name: check, ClassName: JUnitSuiteOAuth}
+		for _, finding := range audit.Findings {
+			if finding.Check == check {
+				testCase.Failure = &JUnitFailure{Message: finding.Message, Type: finding.Severity, Details: strings.Join(finding.RequestIDs, "\n")}
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
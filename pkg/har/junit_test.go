@@ -0,0 +1,64 @@
+package har
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportJUnit(t *testing.T) {
+	parser := NewParser()
+	fixtures := []GoldenFixture{
+		{Method: "GET", Template: "https://example.com/robots.txt", Status: 200, MimeType: "text/plain", Body: "User-agent: *"},
+		{Method: "GET", Template: "https://example.com/health", Status: 200, MimeType: "text/plain", Body: "ok"},
+	}
+	robots := newTestResponseEntry("https://example.com/robots.txt", "text/plain", "User-agent: *")
+	robots.Response.Status = 404
+	capture := newTestHAR(robots, newTestEntry("GET", "http://example.com/login"))
+
+	report := parser.ExportJUnit(capture, fixtures)
+
+	require.Len(t, report.Suites, 2)
+	golden := report.Suites[0]
+	assert.Equal(t, JUnitSuiteGolden, golden.Name)
+	assert.Equal(t, 2, golden.Tests)
+	assert.Equal(t, 1, golden.Failures)
+	assert.Equal(t, 1, golden.Skipped)
+	require.NotNil(t, golden.TestCases[0].Failure)
+	assert.Equal(t, "GET https://example.com/robots.txt", golden.TestCases[0].Name)
+	assert.Contains(t, golden.TestCases[0].Failure.Details, "request_0: ")
+	assert.NotNil(t, golden.TestCases[1].Skipped)
+
+	security := report.Suites[1]
+	assert.Equal(t, len(SecurityRules), security.Tests)
+	assert.Equal(t, 1, security.Failures)
+	assert.Equal(t, len(SecurityRules)+2, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+
+	data, err := xml.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<testsuites name="har-mcp" tests="12" failures="2" skipped="1">`)
+	assert.Contains(t, string(data), `<testcase name="insecure-transport" classname="security"><failure message="requests to example.com are sent over plain HTTP" type="medium">`)
+}
+
+func TestExportJUnitAuditsOAuthFlows(t *testing.T) {
+	parser := NewParser()
+	capture := newTestHAR(
+		newTestEntry("GET", "https://auth.example.com/authorize?response_type=code&client_id=app&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback&state=xyz"),
+	)
+
+	report := parser.ExportJUnit(capture, nil)
+
+	require.Len(t, report.Suites, 2)
+	oauth := report.Suites[1]
+	assert.Equal(t, JUnitSuiteOAuth, oauth.Name)
+	assert.Equal(t, len(oauthChecks), oauth.Tests)
+	assert.Equal(t, 1, oauth.Failures)
+	for _, testCase := range oauth.TestCases {
+		if testCase.Name == OAuthCheckPKCEMissing {
+			assert.Equal(t, &JUnitFailure{Message: oauthChecks[OAuthCheckPKCEMissing].message, Type: SeverityHigh, Details: "request_0"}, testCase.Failure)
+		}
+	}
+}
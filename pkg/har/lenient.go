@@ -0,0 +1,125 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/martian/har"
+)
+
+// nonFiniteLiterals are the JavaScript number literals some exporters write, which JSON forbids
+var nonFiniteLiterals = [][]byte{[]byte("-Infinity"), []byte("Infinity"), []byte("NaN")}
+
+// SetLenient enables recovering malformed JSON that strict decoding rejects: trailing
+// commas, NaN and Infinity values, and several JSON documents concatenated in one file
+// (as some proxies append logs), in which case the first valid HAR log is used.
+// Every recovery is reported as a warning. Duplicate keys are tolerated in every mode, the last value wins.
+func (p *Parser) SetLenient(lenient bool) {
+	p.lenient = lenient
+}
+
+// decodeLeniently repairs the JSON and decodes the first valid HAR log it holds
+func (p *Parser) decodeLeniently(data []byte, report *ParseReport) (*har.HAR, []*float64, error) {
+	repaired, trailingCommas, nonFinite := repairJSON(data)
+
+	var warnings []ParseWarning
+	if trailingCommas > 0 {
+		warnings = append(warnings, ParseWarning{Message: fmt.Sprintf("removed %d trailing commas", trailingCommas)})
+	}
+	if nonFinite > 0 {
+		warnings = append(warnings, ParseWarning{Message: fmt.Sprintf("replaced %d NaN or Infinity values with null", nonFinite)})
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(repaired))
+	for index := 1; ; index++ {
+		var document json.RawMessage
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to recover HAR file: %w", err)
+		}
+
+		documentReport := &ParseReport{}
+		harData, transferSizes, err := p.decodeDocument(document, documentReport)
+		if err != nil {
+			continue
+		}
+
+		if index > 1 || decoder.More() {
+			warnings = append(warnings, ParseWarning{Message: fmt.Sprintf("file holds several JSON documents, using the HAR log of document %d", index)})
+		}
+		report.Mode = ParseModeRecovered
+		report.Warnings = append(report.Warnings, warnings...)
+		report.Warnings = append(report.Warnings, documentReport.Warnings...)
+		report.Pages = documentReport.Pages
+		report.pageIndex = documentReport.pageIndex
+		report.WebSockets = documentReport.WebSockets
+		report.webSocketIndex = documentReport.webSocketIndex
+		report.timingIndex = documentReport.timingIndex
+		report.connectionIndex = documentReport.connectionIndex
+		report.customFieldIndex = documentReport.customFieldIndex
+		return harData, transferSizes, nil
+	}
+
+	return nil, nil, fmt.Errorf("failed to recover HAR file: no valid HAR log found")
+}
+
+// repairJSON removes trailing commas and replaces NaN and Infinity values with null,
+// leaving string contents untouched. It returns the number of repairs of each kind.
+func repairJSON(data []byte) ([]byte, int, int) {
+	repaired := make([]byte, 0, len(data))
+	trailingCommas, nonFinite := 0, 0
+	inString, escaped := false, false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			repaired = append(repaired, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case ',':
+			next := bytes.TrimLeft(data[i+1:], " \t\r\n")
+			if len(next) > 0 && (next[0] == '}' || next[0] == ']') {
+				trailingCommas++
+				continue
+			}
+		default:
+			if literal := nonFiniteLiteralAt(data[i:]); literal != nil {
+				repaired = append(repaired, "null"...)
+				i += len(literal) - 1
+				nonFinite++
+				continue
+			}
+		}
+		repaired = append(repaired, c)
+	}
+
+	return repaired, trailingCommas, nonFinite
+}
+
+// nonFiniteLiteralAt returns the NaN or Infinity literal data starts with, if any
+func nonFiniteLiteralAt(data []byte) []byte {
+	for _, literal := range nonFiniteLiterals {
+		if bytes.HasPrefix(data, literal) {
+			return literal
+		}
+	}
+	return nil
+}
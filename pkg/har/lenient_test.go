@@ -0,0 +1,58 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLenientRepairsTrailingCommasAndNaN(t *testing.T) {
+	harData := strings.Replace(createTestHAR(), `"bodySize": 1024`, `"bodySize": NaN,`, 1)
+	harData = strings.Replace(harData, `"wait": 50,`, `"wait": 50, "dns": -Infinity,`, 1)
+	harData = strings.Replace(harData, `"value": "Test"`, `"value": "NaN, ]"`, 1)
+	harData = strings.Replace(harData, `"value": "Bearer token123"}`, `"value": "Bearer token123"},`, 1)
+
+	parser := NewParser()
+	_, err := parser.Parse(strings.NewReader(harData))
+	require.Error(t, err)
+
+	parser.SetLenient(true)
+	archive, report, err := parser.ParseWithReport(strings.NewReader(harData))
+	require.NoError(t, err)
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, "NaN, ]", entry.Request.Headers[0].Value)
+	assert.Equal(t, int64(0), entry.Response.BodySize)
+	assert.Equal(t, []ParseWarning{
+		{Message: "removed 2 trailing commas"},
+		{Message: "replaced 2 NaN or Infinity values with null"},
+	}, report.Warnings)
+}
+
+func TestParseLenientSelectsFirstValidLog(t *testing.T) {
+	harData := `{"status": "partial"}` + "\n" + createTestHAR() + "\n" + createEmptyHAR()
+
+	parser := NewParser()
+	_, err := parser.Parse(strings.NewReader(harData))
+	require.Error(t, err)
+
+	parser.SetLenient(true)
+	archive, report, err := parser.ParseWithReport(strings.NewReader(harData))
+	require.NoError(t, err)
+
+	assert.Len(t, archive.Log.Entries, 1)
+	assert.Equal(t, "recovered", report.Mode)
+	assert.Equal(t, []ParseWarning{
+		{Message: "file holds several JSON documents, using the HAR log of document 2"},
+	}, report.Warnings)
+}
+
+func TestParseLenientFailsWithoutHARLog(t *testing.T) {
+	parser := NewParser()
+	parser.SetLenient(true)
+
+	_, err := parser.Parse(strings.NewReader(`{"a": 1,} {"b": 2}`))
+	assert.ErrorContains(t, err, "no valid HAR log found")
+}
@@ -0,0 +1,229 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultBundleMaxBytes is the default size budget of an LLM bundle
+	DefaultBundleMaxBytes = 32000
+	// BytesPerToken approximates the number of bytes of text per model token
+	BytesPerToken = 4
+	// bundleTruncationMarker is appended to truncated bodies
+	bundleTruncationMarker = "\n… [truncated %d bytes]"
+	// bundleOmissionNote reserves room for the note listing entries that did not fit
+	bundleOmissionNote = "\n… %d more entries omitted to fit the budget\n"
+)
+
+// noisyHeaders are left out of bundles as they rarely matter when reasoning about an exchange
+var noisyHeaders = map[string]bool{
+	"accept-encoding":           true,
+	"accept-language":           true,
+	"connection":                true,
+	"dnt":                       true,
+	"pragma":                    true,
+	"priority":                  true,
+	"upgrade-insecure-requests": true,
+	"keep-alive":                true,
+}
+
+var (
+	// jsonSecretPattern matches JSON string fields whose name suggests a secret
+	jsonSecretPattern = regexp.MustCompile(`(?i)("(?:password|passwd|secret|client_secret|token|access_token|refresh_token|id_token|api_key|apikey)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// formSecretPattern matches form-encoded fields whose name suggests a secret
+	formSecretPattern = regexp.MustCompile(`(?i)(^|&)((?:password|passwd|secret|client_secret|token|access_token|refresh_token|id_token|api_key|apikey)=)[^&]*`)
+)
+
+// bundleEntry is an entry rendered for a bundle, with bodies kept apart so they can be truncated
+type bundleEntry struct {
+	summary         string
+	requestBody     string
+	responseBody    string
+	responseSummary string
+}
+
+// ExportLLMBundle packages the given entries (or every entry when requestIDs is
+// empty) into a single compact text document that fits in maxBytes: noisy headers
+// are dropped, secrets are redacted and bodies are truncated so that each gets a
+// fair share of the budget. Entries that do not fit at all are omitted with a note. Durations
+// are read from the timing index.
+func (p *Parser) ExportLLMBundle(harData *har.HAR, index *TimingIndex, requestIDs []string, maxBytes int) (string, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultBundleMaxBytes
+	}
+	if len(requestIDs) == 0 {
+		for i, entry := range harData.Log.Entries {
+			// Entries excluded from the analysis have no request
+			if entry == nil || entry.Request == nil {
+				continue
+			}
+			requestIDs = append(requestIDs, formatRequestID(i))
+		}
+	}
+
+	redaction := p.redaction()
+	entries := make([]bundleEntry, 0, len(requestIDs))
+	for _, requestID := range requestIDs {
+		entry, err := p.getEntry(harData, requestID)
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, p.newBundleEntry(requestID, entry, index.Time(entry), redaction))
+	}
+
+	preamble := p.sprintf("# HAR excerpt: %d entries\n", len(entries))
+	used := len(preamble) + len(p.sprintf(bundleOmissionNote, len(entries)))
+	included := 0
+	for _, entry := range entries {
+		size := len(entry.summary) + len(entry.responseSummary)
+		if used+size > maxBytes {
+			break
+		}
+		used += size
+		included++
+	}
+	entries = entries[:included]
+
+	allotments := allotBodies(entries, maxBytes-used)
+
+	var out strings.Builder
+	out.WriteString(preamble)
+	for i, entry := range entries {
+		out.WriteString(entry.summary)
+		if entry.requestBody != "" {
+			out.WriteString(truncateBody(entry.requestBody, allotments[2*i]))
+			out.WriteString("\n")
+		}
+		out.WriteString(entry.responseSummary)
+		if entry.responseBody != "" {
+			out.WriteString(truncateBody(entry.responseBody, allotments[2*i+1]))
+			out.WriteString("\n")
+		}
+	}
+	if omitted := len(requestIDs) - included; omitted > 0 {
+		fmt.Fprintf(&out, p.message(bundleOmissionNote), omitted)
+	}
+
+	return out.String(), nil
+}
+
+// newBundleEntry renders the headers of an entry and prepares its bodies
+func (p *Parser) newBundleEntry(requestID string, entry *har.Entry, elapsedMS float64, redaction *redactor) bundleEntry {
+	var rendered bundleEntry
+	var summary strings.Builder
+
+	request := entry.Request
+	if request == nil {
+		request = &har.Request{}
+	}
+	fmt.Fprintf(&summary, "\n## %s %s %s (%s ms)\n", requestID, request.Method, redaction.text(request.URL), formatMilliseconds(elapsedMS))
+	writeBundleHeaders(&summary, p.message("Request headers"), request.Headers, redaction)
+	if request.PostData != nil && request.PostData.Text != "" {
+		fmt.Fprintf(&summary, p.message("Request body (%s, %d bytes):\n"), request.PostData.MimeType, len(request.PostData.Text))
+		rendered.requestBody = compactBody([]byte(request.PostData.Text), redaction)
+	}
+	rendered.summary = summary.String()
+
+	var responseSummary strings.Builder
+	if entry.Response != nil {
+		fmt.Fprintf(&responseSummary, p.message("Response: %d %s\n"), entry.Response.Status, entry.Response.StatusText)
+		writeBundleHeaders(&responseSummary, p.message("Response headers"), entry.Response.Headers, redaction)
+		if content := entry.Response.Content; content != nil && len(content.Text) > 0 {
+			if isPrintableText(content.Text) {
+				fmt.Fprintf(&responseSummary, p.message("Response body (%s, %d bytes):\n"), content.MimeType, len(content.Text))
+				rendered.responseBody = compactBody(content.Text, redaction)
+			} else {
+				fmt.Fprintf(&responseSummary, p.message("Response body (%s, %d bytes): binary content omitted\n"), content.MimeType, len(content.Text))
+			}
+		}
+	} else {
+		responseSummary.WriteString(p.message("Response: none\n"))
+	}
+	rendered.responseSummary = responseSummary.String()
+
+	return rendered
+}
+
+// writeBundleHeaders writes the relevant headers on a single line, redacting credentials
+func writeBundleHeaders(out *strings.Builder, title string, headers []har.Header, redaction *redactor) {
+	var parts []string
+	for _, header := range headers {
+		name := strings.ToLower(header.Name)
+		if noisyHeaders[name] || strings.HasPrefix(name, "sec-") || strings.HasPrefix(name, ":") {
+			continue
+		}
+		parts = append(parts, header.Name+": "+redaction.header(header.Name, header.Value))
+	}
+	if len(parts) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s: %s\n", title, strings.Join(parts, "; "))
+}
+
+// compactBody strips insignificant JSON whitespace and redacts secret-looking fields
+func compactBody(body []byte, redaction *redactor) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		body = compacted.Bytes()
+	}
+	text := jsonSecretPattern.ReplaceAllString(string(body), `$1"`+redactedValue+`"`)
+	return redaction.text(formSecretPattern.ReplaceAllString(text, "$1$2"+redactedValue))
+}
+
+// allotBodies splits the budget between bodies: small bodies are kept whole and
+// what they leave is shared evenly among the larger ones.
+// Allotments are indexed 2*i for the request body and 2*i+1 for the response body of entry i.
+func allotBodies(entries []bundleEntry, budget int) []int {
+	allotments := make([]int, 2*len(entries))
+	var slots []int
+	for i, entry := range entries {
+		// Each body also costs a trailing newline
+		if entry.requestBody != "" {
+			budget--
+			slots = append(slots, 2*i)
+		}
+		if entry.responseBody != "" {
+			budget--
+			slots = append(slots, 2*i+1)
+		}
+	}
+	size := func(slot int) int {
+		if slot%2 == 0 {
+			return len(entries[slot/2].requestBody)
+		}
+		return len(entries[slot/2].responseBody)
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return size(slots[i]) < size(slots[j]) })
+
+	for i, slot := range slots {
+		if budget <= 0 {
+			break
+		}
+		share := budget / (len(slots) - i)
+		allotments[slot] = min(size(slot), share)
+		budget -= allotments[slot]
+	}
+
+	return allotments
+}
+
+// truncateBody cuts the body to fit in limit bytes, marker included, without splitting a character
+func truncateBody(body string, limit int) string {
+	if len(body) <= limit {
+		return body
+	}
+	marker := fmt.Sprintf(bundleTruncationMarker, len(body))
+	keep := limit - len(marker)
+	if keep <= 0 {
+		return ""
+	}
+	keep = runeEnd([]byte(body), keep)
+	return body[:keep] + fmt.Sprintf(bundleTruncationMarker, len(body)-keep)
+}
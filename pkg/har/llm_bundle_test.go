@@ -0,0 +1,84 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportLLMBundleSummarizesAndRedacts(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("POST", "https://example.com/login",
+		har.Header{Name: "Authorization", Value: "Bearer secret-token"},
+		har.Header{Name: "Accept-Encoding", Value: "gzip"},
+		har.Header{Name: "Sec-Fetch-Mode", Value: "cors"},
+		har.Header{Name: "Content-Type", Value: "application/json"},
+	)
+	entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: "{\n  \"user\": \"alice\",\n  \"password\": \"hunter2\"\n}"}
+	entry.Response.Content = &har.Content{MimeType: "application/json", Text: []byte(`{"access_token": "abc.def", "expires_in": 3600}`)}
+
+	bundle, err := parser.ExportLLMBundle(newTestHAR(entry), nil, nil, 0)
+	require.NoError(t, err)
+
+	assert.Contains(t, bundle, "## request_0 POST https://example.com/login")
+	assert.Contains(t, bundle, "Request headers: Authorization: [REDACTED]; Content-Type: application/json")
+	assert.NotContains(t, bundle, "Accept-Encoding")
+	assert.NotContains(t, bundle, "Sec-Fetch-Mode")
+	assert.Contains(t, bundle, `{"user":"alice","password":"[REDACTED]"}`)
+	assert.Contains(t, bundle, `{"access_token":"[REDACTED]","expires_in":3600}`)
+	assert.NotContains(t, bundle, "hunter2")
+	assert.NotContains(t, bundle, "secret-token")
+}
+
+func TestExportLLMBundleRespectsBudget(t *testing.T) {
+	parser := NewParser()
+	small := newTestEntry("GET", "https://example.com/small")
+	small.Response.Content = &har.Content{MimeType: "text/plain", Text: []byte("tiny")}
+	large := newTestEntry("GET", "https://example.com/large")
+	large.Response.Content = &har.Content{MimeType: "text/plain", Text: []byte(strings.Repeat("x", 10000))}
+
+	bundle, err := parser.ExportLLMBundle(newTestHAR(small, large), nil, nil, 1000)
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, len(bundle), 1000)
+	assert.Contains(t, bundle, "tiny\n")
+	assert.Contains(t, bundle, "[truncated")
+}
+
+func TestExportLLMBundleOmitsEntriesThatDoNotFit(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/"+strings.Repeat("a", 200)),
+		newTestEntry("GET", "https://example.com/"+strings.Repeat("b", 200)),
+	)
+
+	bundle, err := parser.ExportLLMBundle(archive, nil, []string{"request_0", "request_1"}, 400)
+	require.NoError(t, err)
+
+	assert.Contains(t, bundle, "request_0")
+	assert.NotContains(t, bundle, "request_1")
+	assert.Contains(t, bundle, "1 more entries omitted")
+}
+
+func TestExportLLMBundleInvalidID(t *testing.T) {
+	parser := NewParser()
+
+	_, err := parser.ExportLLMBundle(newTestHAR(), nil, []string{"request_1"}, 0)
+
+	assert.Error(t, err)
+}
+
+func TestExportLLMBundleSkipsExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	harData, err := parser.Exclude(newTestHAR(newTestEntry("GET", "https://example.com/"), newTestEntry("GET", "https://tracker.net/pixel")), []string{"request_1"})
+	require.NoError(t, err)
+
+	bundle, err := parser.ExportLLMBundle(harData, nil, nil, 0)
+
+	require.NoError(t, err)
+	assert.Contains(t, bundle, "# HAR excerpt: 1 entries")
+	assert.NotContains(t, bundle, "tracker.net")
+}
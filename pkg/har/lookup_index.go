@@ -0,0 +1,125 @@
+package har
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// LookupIndex holds the positions of the entries by URL and method, by method, by host and by
+// status, so that the tools selecting entries on these criteria do not scan the whole file. It
+// is built when parsing and available from the parse report. Positions are in capture order.
+type LookupIndex struct {
+	entries int
+	// urlMethods are the URL and method combinations in the order they were first requested
+	urlMethods  []urlMethod
+	byURLMethod map[urlMethod][]int
+	// byMethod is keyed by upper-case method, byHost by lower-case host name
+	byMethod map[string][]int
+	byHost   map[string][]int
+	// byStatus holds the status of the entries, 0 for the entries without a response
+	byStatus map[int][]int
+}
+
+// urlMethod is a URL and method combination
+type urlMethod struct {
+	url    string
+	method string
+}
+
+// NewLookupIndex indexes the entries of a HAR file
+func NewLookupIndex(harData *har.HAR) *LookupIndex {
+	idx := &LookupIndex{
+		entries:     len(harData.Log.Entries),
+		byURLMethod: make(map[urlMethod][]int),
+		byMethod:    make(map[string][]int),
+		byHost:      make(map[string][]int),
+		byStatus:    make(map[int][]int),
+	}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		key := urlMethod{url: entry.Request.URL, method: entry.Request.Method}
+		if _, ok := idx.byURLMethod[key]; !ok {
+			idx.urlMethods = append(idx.urlMethods, key)
+		}
+		idx.byURLMethod[key] = append(idx.byURLMethod[key], i)
+		method := strings.ToUpper(entry.Request.Method)
+		idx.byMethod[method] = append(idx.byMethod[method], i)
+		if u, err := url.Parse(entry.Request.URL); err == nil {
+			host := indexedHost(u.Hostname())
+			idx.byHost[host] = append(idx.byHost[host], i)
+		}
+		status := 0
+		if entry.Response != nil {
+			status = entry.Response.Status
+		}
+		idx.byStatus[status] = append(idx.byStatus[status], i)
+	}
+	return idx
+}
+
+// indexedHost normalizes a host name the way MatchHost compares them
+func indexedHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// covers reports whether the index was built for the HAR file, or a copy of it scoped to a
+// page, falling back to scanning otherwise
+func (idx *LookupIndex) covers(harData *har.HAR) bool {
+	return idx != nil && idx.entries == len(harData.Log.Entries)
+}
+
+// candidates returns the positions of the entries that may match the filter, in capture order,
+// narrowing on the most selective of its method, exact host and status criteria. It returns
+// false when no criterion is indexed, or when the index does not cover the file, every entry
+// being a candidate then.
+func (idx *LookupIndex) candidates(harData *har.HAR, filter *compiledFilter) ([]int, bool) {
+	if !idx.covers(harData) {
+		return nil, false
+	}
+
+	var selections [][]int
+	if filter.Method != "" {
+		selections = append(selections, idx.byMethod[strings.ToUpper(filter.Method)])
+	}
+	if filter.Host != "" && !strings.HasPrefix(filter.Host, SiteHostPrefix) && !strings.ContainsAny(filter.Host, `*?[\`) {
+		selections = append(selections, idx.byHost[indexedHost(filter.Host)])
+	}
+	if filter.Status != "" {
+		var positions []int
+		for status, statusPositions := range idx.byStatus {
+			if filter.matchStatus(status) {
+				positions = append(positions, statusPositions...)
+			}
+		}
+		slices.Sort(positions)
+		selections = append(selections, positions)
+	}
+	if len(selections) == 0 {
+		return nil, false
+	}
+
+	narrowest := selections[0]
+	for _, selection := range selections[1:] {
+		if len(selection) < len(narrowest) {
+			narrowest = selection
+		}
+	}
+	return narrowest, true
+}
+
+// positions returns the positions of the entries to check against the filter, in capture order
+func (f *compiledFilter) positions(harData *har.HAR, lookup *LookupIndex) []int {
+	if candidates, ok := lookup.candidates(harData, f); ok {
+		return candidates
+	}
+	positions := make([]int, len(harData.Log.Entries))
+	for i := range positions {
+		positions[i] = i
+	}
+	return positions
+}
@@ -0,0 +1,84 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupIndexMatchesScanning(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestFilterEntry("https://API.example.com/v2/orders", 404, "application/json", 10),
+		newTestFilterEntry("https://cdn.example.com/app.js", 200, "application/javascript", 10),
+		newTestFilterEntry("https://api.example.com./v2/orders", 503, "application/json", 10),
+		newTestFilterEntry("https://eu.api.example.com/v2/orders", 500, "application/json", 10),
+		newTestFilterEntry("https://api.example.com/v2/orders", 404, "application/json", 10),
+	)
+	archive.Log.Entries[4].Request.Method = "POST"
+	index := NewLookupIndex(archive)
+
+	for _, filter := range []EntryFilter{
+		{Host: "api.example.com"},
+		{Host: "api.example.com", Status: "4xx"},
+		{Host: "*.example.com", Status: "5xx"},
+		{Method: "post"},
+		{Method: "GET", Status: "200, 500-599", PathPrefix: "/v2/"},
+		{Status: "404"},
+		{Host: "site:example.com", MimeType: "json"},
+	} {
+		scanned, err := parser.FilterEntries(archive, nil, nil, nil, filter)
+		require.NoError(t, err)
+		indexed, err := parser.FilterEntries(archive, nil, index, nil, filter)
+		require.NoError(t, err)
+		assert.Equal(t, scanned, indexed, "%+v", filter)
+	}
+
+	matches, err := parser.FilterEntries(archive, nil, index, nil, EntryFilter{Host: "API.example.com", Status: "4xx"})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "request_0", matches[0].RequestID)
+	assert.Equal(t, "request_4", matches[1].RequestID)
+
+	assert.Equal(t, []string{"request_0"}, parser.GetRequestIDsForURLMethod(archive, index, "https://API.example.com/v2/orders", "GET"), "URLs are matched exactly")
+	assert.Equal(t, []string{"request_4"}, parser.GetRequestIDsForURLMethod(archive, index, "https://api.example.com/v2/orders", "POST"))
+	assert.Empty(t, parser.GetRequestIDsForURLMethod(archive, index, "https://api.example.com/v2/orders", "GET"))
+}
+
+func TestGetURLsAndMethodsKeepsCaptureOrder(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/b"),
+		newTestEntry("GET", "https://example.com/a"),
+		newTestEntry("POST", "https://example.com/b"),
+		newTestEntry("GET", "https://example.com/b"),
+	)
+
+	urlMethods := parser.GetURLsAndMethods(archive, NewLookupIndex(archive))
+
+	assert.Equal(t, []URLMethodEntry{
+		{URL: "https://example.com/b", Method: "GET", RequestIDs: []string{"request_0", "request_3"}},
+		{URL: "https://example.com/a", Method: "GET", RequestIDs: []string{"request_1"}},
+		{URL: "https://example.com/b", Method: "POST", RequestIDs: []string{"request_2"}},
+	}, urlMethods)
+	assert.Equal(t, urlMethods, parser.GetURLsAndMethods(archive, nil), "an index is built when none is given")
+}
+
+func TestLookupIndexCoversPageScopedFiles(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+
+	scoped, err := report.PageIndex().Scope(harData, "page_2")
+	require.NoError(t, err)
+	matches, err := parser.FilterEntries(scoped, nil, report.LookupIndex(), nil, EntryFilter{Method: "GET"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "request_3", matches[0].RequestID)
+
+	urlMethods := parser.GetURLsAndMethods(scoped, report.LookupIndex())
+	require.Len(t, urlMethods, 1)
+	assert.Equal(t, []string{"request_3"}, urlMethods[0].RequestIDs)
+}
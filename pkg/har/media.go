@@ -0,0 +1,600 @@
+package har
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Streaming protocols of the media manifests
+const (
+	MediaProtocolHLS  = "hls"
+	MediaProtocolDASH = "dash"
+)
+
+// Kinds of media streams
+const (
+	MediaKindVideo = "video"
+	MediaKindAudio = "audio"
+)
+
+const (
+	// lowBufferMS is the buffered media below which the arrival of a segment is a stall risk
+	lowBufferMS = 2000
+	// maxStallRisks caps the stall risks listed per stream
+	maxStallRisks = 20
+)
+
+var (
+	// hlsAttributePattern matches the attributes of an HLS tag, quoted or not
+	hlsAttributePattern = regexp.MustCompile(`([A-Z0-9-]+)=("[^"]*"|[^,]*)`)
+	// lastNumberPattern matches the last run of digits of a file name, the number of a segment
+	lastNumberPattern = regexp.MustCompile(`\d+(\D*)$`)
+	// dashIdentifierPattern matches the identifiers of DASH segment templates, such as $Number%05d$
+	dashIdentifierPattern = regexp.MustCompile(`\$(RepresentationID|Bandwidth|Number|Time)(%0\d+d)?\$`)
+)
+
+// mediaManifestTypes maps the MIME types and URL extensions of media manifests to their protocol
+var mediaManifestTypes = map[string]string{
+	"application/vnd.apple.mpegurl": MediaProtocolHLS,
+	"application/x-mpegurl":         MediaProtocolHLS,
+	"audio/mpegurl":                 MediaProtocolHLS,
+	"audio/x-mpegurl":               MediaProtocolHLS,
+	"application/dash+xml":          MediaProtocolDASH,
+	".m3u8":                         MediaProtocolHLS,
+	".mpd":                          MediaProtocolDASH,
+}
+
+// mediaSegmentExtensions maps the URL extensions of media segments to the kind of media they hold
+var mediaSegmentExtensions = map[string]string{
+	".ts":   MediaKindVideo,
+	".m4s":  MediaKindVideo,
+	".m4v":  MediaKindVideo,
+	".mp4":  MediaKindVideo,
+	".cmfv": MediaKindVideo,
+	".webm": MediaKindVideo,
+	".aac":  MediaKindAudio,
+	".m4a":  MediaKindAudio,
+	".cmfa": MediaKindAudio,
+	".mp3":  MediaKindAudio,
+}
+
+// MediaManifest is an HLS playlist or a DASH manifest
+type MediaManifest struct {
+	RequestID string `json:"request_id"`
+	URL       string `json:"url"`
+	Protocol  string `json:"protocol"`
+	// Renditions counts the variants a master playlist or a DASH manifest declares
+	Renditions int `json:"renditions,omitempty"`
+	// Segments counts the segments a media playlist lists
+	Segments int `json:"segments,omitempty"`
+}
+
+// MediaVariant is a rung of the bitrate ladder of a stream
+type MediaVariant struct {
+	// ID is the rendition declared by a manifest, or the URL of its segments with their number
+	// replaced by # when no manifest was captured
+	ID string `json:"id"`
+	// BandwidthBPS is the bandwidth the manifest declares
+	BandwidthBPS int64  `json:"bandwidth_bps,omitempty"`
+	Resolution   string `json:"resolution,omitempty"`
+	Segments     int    `json:"segments"`
+	Bytes        int64  `json:"bytes"`
+	// MeasuredKbps is the median bitrate of the segments, from their sizes and durations
+	MeasuredKbps   float64 `json:"measured_kbps,omitempty"`
+	FirstRequestID string  `json:"first_request_id"`
+}
+
+// BitrateSwitch is a change of variant between consecutive segments of a stream
+type BitrateSwitch struct {
+	RequestID string `json:"request_id"`
+	StartedAt string `json:"started_at"`
+	// OffsetMS is the time from the first segment of the stream
+	OffsetMS float64 `json:"offset_ms"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	// Direction is up or down the ladder
+	Direction string `json:"direction"`
+}
+
+// StallRisk is a segment arriving when the player had little or no media left to play
+type StallRisk struct {
+	RequestID         string `json:"request_id"`
+	PreviousRequestID string `json:"previous_request_id"`
+	// GapMS is the time between the end of the previous segment download and the start of this one
+	GapMS float64 `json:"gap_ms"`
+	// BufferMS is the media left to play when the segment arrived, negative when playback had
+	// already stalled for that long
+	BufferMS float64 `json:"buffer_ms"`
+}
+
+// MediaStream is the video or audio stream of a streaming session
+type MediaStream struct {
+	Kind     string `json:"kind"`
+	Segments int    `json:"segments"`
+	Bytes    int64  `json:"bytes"`
+	// SegmentDurationMS is the median duration of the segments, estimated from the interval
+	// between their downloads when no manifest declares it
+	SegmentDurationMS float64 `json:"segment_duration_ms"`
+	DurationEstimated bool    `json:"duration_estimated,omitempty"`
+	// Ladder lists the variants the stream played, lowest bitrate first
+	Ladder       []MediaVariant  `json:"ladder"`
+	Switches     []BitrateSwitch `json:"switches"`
+	Upswitches   int             `json:"upswitches"`
+	Downswitches int             `json:"downswitches"`
+	// StallRisks lists the segments arriving with the lowest buffer first
+	StallRisks       []StallRisk `json:"stall_risks"`
+	EstimatedStalls  int         `json:"estimated_stalls"`
+	EstimatedStallMS float64     `json:"estimated_stall_ms"`
+}
+
+// MediaReport describes the adaptive streaming sessions of a capture
+type MediaReport struct {
+	// Detected is set when the capture holds media manifests or segments
+	Detected  bool            `json:"detected"`
+	Manifests []MediaManifest `json:"manifests"`
+	Streams   []MediaStream   `json:"streams"`
+}
+
+// mediaRendition is a variant declared by a manifest
+type mediaRendition struct {
+	id         string
+	kind       string
+	bandwidth  int64
+	resolution string
+	// durationMS is the duration of the segments, zero when not declared
+	durationMS float64
+	// pattern matches the URLs of the segments of DASH representations
+	pattern *regexp.Regexp
+}
+
+// mediaSegment is a segment request
+type mediaSegment struct {
+	position   int
+	entry      *har.Entry
+	kind       string
+	variant    string
+	rendition  *mediaRendition
+	durationMS float64
+	bytes      int64
+	end        time.Time
+}
+
+// mediaManifests holds the renditions and segments declared by the manifests of a capture
+type mediaManifests struct {
+	manifests []MediaManifest
+	// playlists maps the URLs of HLS media playlists to their rendition
+	playlists map[string]*mediaRendition
+	// segments maps the URLs of the segments listed by HLS media playlists to their rendition and duration
+	segments map[string]mediaSegment
+	// representations are the DASH representations, matched on the URLs of their segments
+	representations []*mediaRendition
+	// initializations are the URLs and patterns of the initialization segments, which hold no media
+	initializations        map[string]bool
+	initializationPatterns []*regexp.Regexp
+}
+
+// ReportMedia analyzes the HLS and DASH streaming sessions of a capture. Manifests are detected
+// from their MIME type or extension, and parsed for the renditions they declare and the segments
+// they list. Segments are the requests listed by a playlist, matching a DASH segment template or
+// with a media segment extension or MIME type; without manifest, their variant is their URL with
+// the segment number replaced. Per stream, the bitrate ladder is reconstructed from the declared
+// bandwidths or the sizes and durations of the segments, and the variant switches are listed in
+// download order. Playback is simulated from the end of the first segment, each segment adding
+// its duration to the buffer, to tell which segments arrived with little or no media left to
+// play. Timings are read from the timing index.
+func (p *Parser) ReportMedia(harData *har.HAR, index *TimingIndex) *MediaReport {
+	report := &MediaReport{Manifests: []MediaManifest{}, Streams: []MediaStream{}}
+	redaction := p.redaction()
+	manifests := parseMediaManifests(harData, redaction)
+	report.Manifests = manifests.manifests
+
+	streams := make(map[string][]mediaSegment)
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		segment, ok := manifests.segment(entry)
+		if !ok {
+			continue
+		}
+		segment.position = i
+		segment.entry = entry
+		segment.bytes = responseBodySize(entry.Response)
+		segment.end = index.End(entry)
+		streams[segment.kind] = append(streams[segment.kind], segment)
+	}
+
+	for _, kind := range []string{MediaKindVideo, MediaKindAudio} {
+		if segments := streams[kind]; len(segments) > 0 {
+			report.Streams = append(report.Streams, p.mediaStream(kind, segments, redaction))
+		}
+	}
+	report.Detected = len(report.Manifests) > 0 || len(report.Streams) > 0
+	return report
+}
+
+// mediaStream reconstructs the ladder, switches and stall risks of the segments of a stream
+func (p *Parser) mediaStream(kind string, segments []mediaSegment, redaction *redactor) MediaStream {
+	stream := MediaStream{Kind: kind, Ladder: []MediaVariant{}, Switches: []BitrateSwitch{}, StallRisks: []StallRisk{}}
+	slices.SortStableFunc(segments, func(a, b mediaSegment) int { return a.entry.StartedDateTime.Compare(b.entry.StartedDateTime) })
+
+	// Players download about one segment per segment duration once their buffer is full
+	var declared, intervals []float64
+	for i, segment := range segments {
+		if segment.durationMS > 0 {
+			declared = append(declared, segment.durationMS)
+		}
+		if i > 0 {
+			intervals = append(intervals, milliseconds(segment.entry.StartedDateTime.Sub(segments[i-1].entry.StartedDateTime)))
+		}
+	}
+	stream.SegmentDurationMS = median(declared)
+	if len(declared) == 0 {
+		stream.SegmentDurationMS = median(intervals)
+		stream.DurationEstimated = true
+	}
+
+	variants := make(map[string]*MediaVariant)
+	bitrates := make(map[string][]float64)
+	for _, segment := range segments {
+		stream.Segments++
+		stream.Bytes += segment.bytes
+		variant, ok := variants[segment.variant]
+		if !ok {
+			variant = &MediaVariant{ID: redaction.text(segment.variant), FirstRequestID: formatRequestID(segment.position)}
+			if segment.rendition != nil {
+				variant.BandwidthBPS = segment.rendition.bandwidth
+				variant.Resolution = segment.rendition.resolution
+			}
+			variants[segment.variant] = variant
+		}
+		variant.Segments++
+		variant.Bytes += segment.bytes
+		if duration := cmp.Or(segment.durationMS, stream.SegmentDurationMS); duration > 0 && segment.bytes > 0 {
+			bitrates[segment.variant] = append(bitrates[segment.variant], float64(segment.bytes)*8/duration)
+		}
+	}
+	ranks := make(map[string]int, len(variants))
+	for id, variant := range variants {
+		variant.MeasuredKbps = median(bitrates[id])
+		stream.Ladder = append(stream.Ladder, *variant)
+	}
+	slices.SortStableFunc(stream.Ladder, func(a, b MediaVariant) int {
+		return cmp.Or(cmp.Compare(a.BandwidthBPS, b.BandwidthBPS), cmp.Compare(a.MeasuredKbps, b.MeasuredKbps), strings.Compare(a.ID, b.ID))
+	})
+	for rank, variant := range stream.Ladder {
+		ranks[variant.ID] = rank
+	}
+
+	first := segments[0].entry.StartedDateTime
+	for i := 1; i < len(segments); i++ {
+		previous, segment := segments[i-1], segments[i]
+		if previous.variant == segment.variant {
+			continue
+		}
+		change := BitrateSwitch{
+			RequestID: formatRequestID(segment.position),
+			StartedAt: p.formatTime(segment.entry.StartedDateTime, time.RFC3339Nano),
+			OffsetMS:  milliseconds(segment.entry.StartedDateTime.Sub(first)),
+			From:      redaction.text(previous.variant),
+			To:        redaction.text(segment.variant),
+			Direction: "up",
+		}
+		if ranks[change.To] < ranks[change.From] {
+			change.Direction = "down"
+			stream.Downswitches++
+		} else {
+			stream.Upswitches++
+		}
+		stream.Switches = append(stream.Switches, change)
+	}
+
+	// Playback starts when the first segment is downloaded and consumes the buffer in real time
+	arrivals := slices.Clone(segments)
+	slices.SortStableFunc(arrivals, func(a, b mediaSegment) int { return a.end.Compare(b.end) })
+	buffer := cmp.Or(arrivals[0].durationMS, stream.SegmentDurationMS)
+	for i := 1; i < len(arrivals); i++ {
+		previous, segment := arrivals[i-1], arrivals[i]
+		buffer -= milliseconds(segment.end.Sub(previous.end))
+		if buffer < lowBufferMS {
+			stream.StallRisks = append(stream.StallRisks, StallRisk{
+				RequestID:         formatRequestID(segment.position),
+				PreviousRequestID: formatRequestID(previous.position),
+				GapMS:             max(milliseconds(segment.entry.StartedDateTime.Sub(previous.end)), 0),
+				BufferMS:          buffer,
+			})
+		}
+		if buffer < 0 {
+			stream.EstimatedStalls++
+			stream.EstimatedStallMS -= buffer
+			buffer = 0
+		}
+		buffer += cmp.Or(segment.durationMS, stream.SegmentDurationMS)
+	}
+	slices.SortStableFunc(stream.StallRisks, func(a, b StallRisk) int { return cmp.Compare(a.BufferMS, b.BufferMS) })
+	if len(stream.StallRisks) > maxStallRisks {
+		stream.StallRisks = stream.StallRisks[:maxStallRisks]
+	}
+	return stream
+}
+
+// segment describes the entry when it downloaded a media segment
+func (m *mediaManifests) segment(entry *har.Entry) (mediaSegment, bool) {
+	key := withoutFragment(entry.Request.URL)
+	if m.initializations[key] {
+		return mediaSegment{}, false
+	}
+	u, err := url.Parse(key)
+	if err != nil {
+		return mediaSegment{}, false
+	}
+	for _, pattern := range m.initializationPatterns {
+		if pattern.MatchString(u.Path) {
+			return mediaSegment{}, false
+		}
+	}
+
+	if segment, ok := m.segments[key]; ok {
+		return segment, true
+	}
+	for _, representation := range m.representations {
+		if representation.pattern != nil && representation.pattern.MatchString(u.Path) {
+			return mediaSegment{kind: representation.kind, variant: representation.id, rendition: representation, durationMS: representation.durationMS}, true
+		}
+	}
+
+	mimeType := normalizeMimeType(responseMimeType(entry.Response))
+	kind := mediaSegmentExtensions[strings.ToLower(path.Ext(u.Path))]
+	switch {
+	case mediaManifestTypes[mimeType] != "" || mediaManifestTypes[strings.ToLower(path.Ext(u.Path))] != "":
+		return mediaSegment{}, false
+	case strings.HasPrefix(mimeType, "audio/"):
+		kind = MediaKindAudio
+	case strings.HasPrefix(mimeType, "video/"):
+		kind = MediaKindVideo
+	}
+	if kind == "" {
+		return mediaSegment{}, false
+	}
+	// Without manifest, the segments of a variant share their URL but for their number
+	variant := u.Scheme + "://" + u.Host + path.Join(path.Dir(u.Path), lastNumberPattern.ReplaceAllString(path.Base(u.Path), "#$1"))
+	return mediaSegment{kind: kind, variant: variant}, true
+}
+
+// parseMediaManifests parses the HLS playlists and DASH manifests of the capture, master
+// playlists first so that media playlists know their rendition
+func parseMediaManifests(harData *har.HAR, redaction *redactor) *mediaManifests {
+	manifests := &mediaManifests{
+		manifests:       []MediaManifest{},
+		playlists:       make(map[string]*mediaRendition),
+		segments:        make(map[string]mediaSegment),
+		initializations: make(map[string]bool),
+	}
+	type manifest struct {
+		position int
+		url      *url.URL
+		protocol string
+		body     string
+	}
+	var found []manifest
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		protocol := cmp.Or(mediaManifestTypes[normalizeMimeType(responseMimeType(entry.Response))], mediaManifestTypes[strings.ToLower(path.Ext(u.Path))])
+		if protocol == "" {
+			continue
+		}
+		var body string
+		if entry.Response.Content != nil {
+			decoded, _ := decodedResponse(entry.Response)
+			body = string(decoded.Content.Text)
+		}
+		found = append(found, manifest{position: i, url: u, protocol: protocol, body: body})
+	}
+
+	summaries := make([]MediaManifest, len(found))
+	for i, m := range found {
+		summaries[i] = MediaManifest{RequestID: formatRequestID(m.position), URL: redaction.text(m.url.String()), Protocol: m.protocol}
+		switch {
+		case m.protocol == MediaProtocolDASH:
+			summaries[i].Renditions = manifests.addDASH(m.url, m.body)
+		case strings.Contains(m.body, "#EXT-X-STREAM-INF") || strings.Contains(m.body, "#EXT-X-MEDIA:"):
+			summaries[i].Renditions = manifests.addHLSMaster(m.url, m.body)
+		}
+	}
+	for i, m := range found {
+		if m.protocol == MediaProtocolHLS && strings.Contains(m.body, "#EXTINF") {
+			summaries[i].Segments = manifests.addHLSMedia(m.url, m.body)
+		}
+	}
+	manifests.manifests = summaries
+	return manifests
+}
+
+// addHLSMaster registers the variant streams and alternative audio renditions of an HLS master
+// playlist, returning their number
+func (m *mediaManifests) addHLSMaster(base *url.URL, body string) int {
+	renditions := 0
+	var variant *mediaRendition
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attributes := hlsAttributes(line)
+			bandwidth, _ := strconv.ParseInt(attributes["BANDWIDTH"], 10, 64)
+			variant = &mediaRendition{kind: MediaKindVideo, bandwidth: bandwidth, resolution: attributes["RESOLUTION"]}
+			// Audio-only variants declare a single audio codec
+			if codecs := attributes["CODECS"]; attributes["RESOLUTION"] == "" && strings.HasPrefix(codecs, "mp4a") && !strings.Contains(codecs, ",") {
+				variant.kind = MediaKindAudio
+			}
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			attributes := hlsAttributes(line)
+			if attributes["TYPE"] == "AUDIO" && attributes["URI"] != "" {
+				if target, err := base.Parse(attributes["URI"]); err == nil {
+					m.playlists[target.String()] = &mediaRendition{id: target.String(), kind: MediaKindAudio}
+					renditions++
+				}
+			}
+		case line != "" && !strings.HasPrefix(line, "#") && variant != nil:
+			if target, err := base.Parse(line); err == nil {
+				variant.id = target.String()
+				m.playlists[target.String()] = variant
+				renditions++
+			}
+			variant = nil
+		}
+	}
+	return renditions
+}
+
+// addHLSMedia registers the segments of an HLS media playlist with their duration, returning their number
+func (m *mediaManifests) addHLSMedia(base *url.URL, body string) int {
+	rendition := m.playlists[base.String()]
+	if rendition == nil {
+		rendition = &mediaRendition{id: base.String(), kind: MediaKindVideo}
+		m.playlists[base.String()] = rendition
+	}
+	segments := 0
+	var duration float64
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			seconds, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			duration = seconds * 1000
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			if target, err := base.Parse(hlsAttributes(line)["URI"]); err == nil {
+				m.initializations[withoutFragment(target.String())] = true
+			}
+		case line != "" && !strings.HasPrefix(line, "#"):
+			if target, err := base.Parse(line); err == nil {
+				m.segments[withoutFragment(target.String())] = mediaSegment{kind: rendition.kind, variant: rendition.id, rendition: rendition, durationMS: duration}
+				segments++
+			}
+			duration = 0
+		}
+	}
+	return segments
+}
+
+// hlsAttributes returns the attributes of an HLS tag, unquoted
+func hlsAttributes(line string) map[string]string {
+	_, list, _ := strings.Cut(line, ":")
+	attributes := make(map[string]string)
+	for _, match := range hlsAttributePattern.FindAllStringSubmatch(list, -1) {
+		attributes[match[1]] = strings.Trim(match[2], `"`)
+	}
+	return attributes
+}
+
+// dashManifest is the part of a DASH manifest describing the representations and their segments
+type dashManifest struct {
+	Periods []struct {
+		AdaptationSets []struct {
+			MimeType        string               `xml:"mimeType,attr"`
+			ContentType     string               `xml:"contentType,attr"`
+			SegmentTemplate *dashSegmentTemplate `xml:"SegmentTemplate"`
+			Representations []dashRepresentation `xml:"Representation"`
+		} `xml:"AdaptationSet"`
+	} `xml:"Period"`
+}
+
+// dashRepresentation is a variant of a DASH adaptation set
+type dashRepresentation struct {
+	ID              string               `xml:"id,attr"`
+	Bandwidth       int64                `xml:"bandwidth,attr"`
+	Width           int                  `xml:"width,attr"`
+	Height          int                  `xml:"height,attr"`
+	MimeType        string               `xml:"mimeType,attr"`
+	BaseURL         string               `xml:"BaseURL"`
+	SegmentTemplate *dashSegmentTemplate `xml:"SegmentTemplate"`
+}
+
+// dashSegmentTemplate builds the URLs of the segments of DASH representations
+type dashSegmentTemplate struct {
+	Media          string `xml:"media,attr"`
+	Initialization string `xml:"initialization,attr"`
+	Duration       int64  `xml:"duration,attr"`
+	Timescale      int64  `xml:"timescale,attr"`
+}
+
+// addDASH registers the representations of a DASH manifest, returning their number
+func (m *mediaManifests) addDASH(base *url.URL, body string) int {
+	var manifest dashManifest
+	if err := xml.Unmarshal([]byte(body), &manifest); err != nil {
+		return 0
+	}
+	renditions := 0
+	for _, period := range manifest.Periods {
+		for _, set := range period.AdaptationSets {
+			for _, representation := range set.Representations {
+				kind := MediaKindVideo
+				if strings.HasPrefix(cmp.Or(representation.MimeType, set.MimeType, set.ContentType), "audio") {
+					kind = MediaKindAudio
+				}
+				rendition := &mediaRendition{id: representation.ID, kind: kind, bandwidth: representation.Bandwidth}
+				if representation.Width > 0 && representation.Height > 0 {
+					rendition.resolution = fmt.Sprintf("%dx%d", representation.Width, representation.Height)
+				}
+				template := cmp.Or(representation.SegmentTemplate, set.SegmentTemplate)
+				switch {
+				case template != nil && template.Media != "":
+					rendition.pattern = dashTemplatePattern(template.Media, representation)
+					if template.Duration > 0 {
+						rendition.durationMS = float64(template.Duration) * 1000 / float64(cmp.Or(template.Timescale, 1))
+					}
+					if template.Initialization != "" {
+						m.initializationPatterns = append(m.initializationPatterns, dashTemplatePattern(template.Initialization, representation))
+					}
+				case representation.BaseURL != "":
+					if target, err := base.Parse(strings.TrimSpace(representation.BaseURL)); err == nil {
+						rendition.pattern = regexp.MustCompile(regexp.QuoteMeta(target.Path) + "$")
+					}
+				}
+				m.representations = append(m.representations, rendition)
+				renditions++
+			}
+		}
+	}
+	return renditions
+}
+
+// dashTemplatePattern returns the pattern matching the paths of the segments a DASH template
+// builds for the representation
+func dashTemplatePattern(template string, representation dashRepresentation) *regexp.Regexp {
+	var pattern strings.Builder
+	last := 0
+	for _, match := range dashIdentifierPattern.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:match[0]]))
+		switch template[match[2]:match[3]] {
+		case "RepresentationID":
+			pattern.WriteString(regexp.QuoteMeta(representation.ID))
+		case "Bandwidth":
+			pattern.WriteString(strconv.FormatInt(representation.Bandwidth, 10))
+		default:
+			pattern.WriteString(`\d+`)
+		}
+		last = match[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	return regexp.MustCompile(`(^|/)` + pattern.String() + `$`)
+}
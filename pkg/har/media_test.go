@@ -0,0 +1,125 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMediaEntry returns a response of the given MIME type, body and size, downloaded from the
+// given offset in milliseconds for the given time
+func newTestMediaEntry(url, mimeType, body string, offset, elapsed int, size int64) *har.Entry {
+	entry := newTestEntry("GET", url)
+	entry.StartedDateTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(offset) * time.Millisecond)
+	entry.Time = int64(elapsed)
+	entry.Response.Content.MimeType = mimeType
+	entry.Response.Content.Text = []byte(body)
+	entry.Response.BodySize = size
+	return entry
+}
+
+// newTestHLSHAR returns an HLS session switching from 360p to 720p and back, the last segment
+// arriving after the buffer ran out
+func newTestHLSHAR() *har.HAR {
+	const playlist = "application/vnd.apple.mpegurl"
+	media := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.0,\nseg0.ts\n#EXTINF:4.0,\nseg1.ts\n#EXTINF:4.0,\nseg2.ts\n#EXT-X-ENDLIST\n"
+	return newTestHAR(
+		newTestMediaEntry("https://cdn.example.com/live/master.m3u8", playlist, "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS=\"avc1.4d401e,mp4a.40.2\"\n360p/index.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=2500000,RESOLUTION=1280x720\n720p/index.m3u8\n", 0, 50, 200),
+		newTestMediaEntry("https://cdn.example.com/live/360p/index.m3u8", playlist, media, 60, 20, 100),
+		newTestMediaEntry("https://cdn.example.com/live/720p/index.m3u8", playlist, media, 60, 20, 100),
+		newTestMediaEntry("https://cdn.example.com/live/360p/seg0.ts", "video/mp2t", "", 100, 400, 100000),
+		newTestMediaEntry("https://cdn.example.com/live/720p/seg1.ts", "video/mp2t", "", 1000, 500, 1250000),
+		newTestMediaEntry("https://cdn.example.com/live/360p/seg2.ts", "video/mp2t", "", 9000, 500, 100000),
+	)
+}
+
+func TestReportMediaHLS(t *testing.T) {
+	report := NewParser().ReportMedia(newTestHLSHAR(), nil)
+
+	assert.True(t, report.Detected)
+	require.Len(t, report.Manifests, 3)
+	assert.Equal(t, MediaManifest{RequestID: "request_0", URL: "https://cdn.example.com/live/master.m3u8", Protocol: MediaProtocolHLS, Renditions: 2}, report.Manifests[0])
+	assert.Equal(t, 3, report.Manifests[1].Segments)
+
+	require.Len(t, report.Streams, 1)
+	stream := report.Streams[0]
+	assert.Equal(t, MediaKindVideo, stream.Kind)
+	assert.Equal(t, 3, stream.Segments)
+	assert.Equal(t, 4000.0, stream.SegmentDurationMS)
+	assert.False(t, stream.DurationEstimated)
+	assert.Equal(t, []MediaVariant{
+		{ID: "https://cdn.example.com/live/360p/index.m3u8", BandwidthBPS: 800000, Resolution: "640x360", Segments: 2, Bytes: 200000, MeasuredKbps: 200, FirstRequestID: "request_3"},
+		{ID: "https://cdn.example.com/live/720p/index.m3u8", BandwidthBPS: 2500000, Resolution: "1280x720", Segments: 1, Bytes: 1250000, MeasuredKbps: 2500, FirstRequestID: "request_4"},
+	}, stream.Ladder)
+
+	require.Len(t, stream.Switches, 2)
+	assert.Equal(t, "up", stream.Switches[0].Direction)
+	assert.Equal(t, 900.0, stream.Switches[0].OffsetMS)
+	assert.Equal(t, "down", stream.Switches[1].Direction)
+	assert.Equal(t, "request_5", stream.Switches[1].RequestID)
+	assert.Equal(t, 1, stream.Upswitches)
+	assert.Equal(t, 1, stream.Downswitches)
+
+	// 4s of media buffered at 0.5s, 3s left at 1.5s then 8s, run out 1s before 9.5s
+	assert.Equal(t, []StallRisk{{RequestID: "request_5", PreviousRequestID: "request_4", GapMS: 7500, BufferMS: -1000}}, stream.StallRisks)
+	assert.Equal(t, 1, stream.EstimatedStalls)
+	assert.Equal(t, 1000.0, stream.EstimatedStallMS)
+}
+
+func TestReportMediaDASH(t *testing.T) {
+	manifest := `<?xml version="1.0"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static">
+  <Period>
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate media="$RepresentationID$/chunk-$Number%05d$.m4s" initialization="$RepresentationID$/init.mp4" duration="2000" timescale="1000"/>
+      <Representation id="v1" bandwidth="1000000" width="1280" height="720"/>
+      <Representation id="v2" bandwidth="3000000" width="1920" height="1080"/>
+    </AdaptationSet>
+    <AdaptationSet mimeType="audio/mp4">
+      <SegmentTemplate media="$RepresentationID$/chunk-$Number%05d$.m4s" initialization="$RepresentationID$/init.mp4" duration="2000" timescale="1000"/>
+      <Representation id="a1" bandwidth="128000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+	harData := newTestHAR(
+		newTestMediaEntry("https://cdn.example.com/vod/manifest.mpd", "application/dash+xml", manifest, 0, 50, 1000),
+		newTestMediaEntry("https://cdn.example.com/vod/v1/init.mp4", "video/mp4", "", 100, 50, 800),
+		newTestMediaEntry("https://cdn.example.com/vod/a1/init.mp4", "audio/mp4", "", 100, 50, 600),
+		newTestMediaEntry("https://cdn.example.com/vod/v1/chunk-00001.m4s", "video/iso.segment", "", 200, 300, 250000),
+		newTestMediaEntry("https://cdn.example.com/vod/a1/chunk-00001.m4s", "audio/iso.segment", "", 200, 100, 32000),
+	)
+
+	report := NewParser().ReportMedia(harData, nil)
+	require.Len(t, report.Manifests, 1)
+	assert.Equal(t, 3, report.Manifests[0].Renditions)
+	require.Len(t, report.Streams, 2)
+	assert.Equal(t, []string{MediaKindVideo, MediaKindAudio}, []string{report.Streams[0].Kind, report.Streams[1].Kind})
+	assert.Equal(t, []MediaVariant{{ID: "v1", BandwidthBPS: 1000000, Resolution: "1280x720", Segments: 1, Bytes: 250000, MeasuredKbps: 1000, FirstRequestID: "request_3"}}, report.Streams[0].Ladder)
+	assert.Equal(t, "a1", report.Streams[1].Ladder[0].ID)
+	assert.Equal(t, 2000.0, report.Streams[1].SegmentDurationMS)
+}
+
+func TestReportMediaWithoutManifest(t *testing.T) {
+	harData := newTestHAR(
+		newTestMediaEntry("https://cdn.example.com/video/480p/seg_00011.ts?token=abc", "video/mp2t", "", 0, 500, 300000),
+		newTestMediaEntry("https://cdn.example.com/video/480p/seg_00012.ts?token=abc", "video/mp2t", "", 6000, 500, 300000),
+		newTestMediaEntry("https://cdn.example.com/video/1080p/seg_00013.ts?token=abc", "video/mp2t", "", 12000, 500, 1500000),
+	)
+
+	report := NewParser().ReportMedia(harData, nil)
+	require.Len(t, report.Streams, 1)
+	stream := report.Streams[0]
+	assert.True(t, stream.DurationEstimated)
+	assert.Equal(t, 6000.0, stream.SegmentDurationMS)
+	require.Len(t, stream.Ladder, 2)
+	assert.Equal(t, "https://cdn.example.com/video/480p/seg_#.ts", stream.Ladder[0].ID)
+	assert.Equal(t, 400.0, stream.Ladder[0].MeasuredKbps)
+	assert.Equal(t, []string{"up"}, []string{stream.Switches[0].Direction})
+	// Downloading one segment per segment duration leaves nothing buffered ahead
+	require.Len(t, stream.StallRisks, 2)
+	assert.Equal(t, 0.0, stream.StallRisks[0].BufferMS)
+	assert.Equal(t, 0, stream.EstimatedStalls)
+}
@@ -0,0 +1,25 @@
+package har
+
+import "fmt"
+
+// SetMessages translates the text of the reports: finding and OAuth audit messages, golden drifts,
+// JUnit failures and LLM bundle headings. translate returns the translation of an English format
+// string, or the string itself when it has none. A nil function keeps the reports in English.
+// Details quoted from the capture or from the lower-level analyzers, such as lint rule
+// descriptions, header conflicts and parse warnings, stay in English.
+func (p *Parser) SetMessages(translate func(string) string) {
+	p.translate = translate
+}
+
+// message returns the translation of a report text
+func (p *Parser) message(text string) string {
+	if p.translate == nil {
+		return text
+	}
+	return p.translate(text)
+}
+
+// sprintf formats a report text in the configured language
+func (p *Parser) sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(p.message(format), args...)
+}
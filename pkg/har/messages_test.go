@@ -0,0 +1,77 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTranslatingParser returns a parser translating the report texts with the given catalog
+func newTranslatingParser(translations map[string]string) *Parser {
+	parser := NewParser()
+	parser.SetMessages(func(text string) string {
+		if translated, ok := translations[text]; ok {
+			return translated
+		}
+		return text
+	})
+	return parser
+}
+
+func TestMessagesStayInEnglishByDefault(t *testing.T) {
+	parser := NewParser()
+
+	assert.Equal(t, "field id added", parser.sprintf("field %s added", "id"))
+}
+
+func TestSetMessagesTranslatesSecurityFindings(t *testing.T) {
+	parser := newTranslatingParser(map[string]string{"requests to %s are sent over plain HTTP": "les requêtes vers %s sont envoyées en HTTP non chiffré"})
+
+	report := parser.ScanSecurity(newTestHAR(newTestEntry("GET", "http://example.com/login")))
+
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, "les requêtes vers example.com sont envoyées en HTTP non chiffré", report.Findings[0].Message)
+}
+
+func TestSetMessagesTranslatesOAuthFindings(t *testing.T) {
+	english := oauthChecks[OAuthCheckPKCEMissing].message
+	parser := newTranslatingParser(map[string]string{english: "requêtes de code d'autorisation sans code_challenge PKCE"})
+
+	audit := parser.AuditOAuth(newTestHAR(
+		newTestEntry("GET", "https://auth.example.com/authorize?response_type=code&client_id=app&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback&state=xyz"),
+	))
+
+	require.Len(t, audit.Findings, 1)
+	assert.Equal(t, "requêtes de code d'autorisation sans code_challenge PKCE", audit.Findings[0].Message)
+}
+
+func TestSetMessagesTranslatesJUnitFailures(t *testing.T) {
+	parser := newTranslatingParser(map[string]string{
+		"responses drifting from the golden fixture: %d": "réponses s'écartant de la référence : %d",
+		"status changed from %d to %d":                   "statut passé de %d à %d",
+	})
+	robots := newTestResponseEntry("https://example.com/robots.txt", "text/plain", "User-agent: *")
+	robots.Response.Status = 404
+	fixtures := []GoldenFixture{{Method: "GET", Template: "https://example.com/robots.txt", Status: 200, MimeType: "text/plain", Body: "User-agent: *"}}
+
+	report := parser.ExportJUnit(newTestHAR(robots), fixtures)
+
+	failure := report.Suites[0].TestCases[0].Failure
+	require.NotNil(t, failure)
+	assert.Equal(t, "réponses s'écartant de la référence : 1", failure.Message)
+	assert.Equal(t, "request_0: statut passé de 200 à 404", failure.Details)
+}
+
+func TestSetMessagesTranslatesLLMBundleHeadings(t *testing.T) {
+	parser := newTranslatingParser(map[string]string{
+		"# HAR excerpt: %d entries\n": "# Extrait HAR : %d entrées\n",
+		"Response: %d %s\n":           "Réponse : %d %s\n",
+	})
+
+	bundle, err := parser.ExportLLMBundle(newTestHAR(newTestEntry("GET", "https://example.com/")), nil, nil, 0)
+
+	require.NoError(t, err)
+	assert.Contains(t, bundle, "# Extrait HAR : 1 entrées\n")
+	assert.Contains(t, bundle, "Réponse : 200 OK\n")
+}
@@ -0,0 +1,286 @@
+package har
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/martian/har"
+)
+
+// Content families group the MIME types browsers treat interchangeably
+const (
+	familyJavaScript = "javascript"
+	familyJSON       = "json"
+	familyHTML       = "html"
+	familyXML        = "xml"
+	familyCSS        = "css"
+	familyText       = "text"
+	familyPNG        = "png"
+	familyJPEG       = "jpeg"
+	familyGIF        = "gif"
+	familyWebP       = "webp"
+	familySVG        = "svg"
+	familyPDF        = "pdf"
+	familyWASM       = "wasm"
+	familyWOFF       = "woff"
+	familyWOFF2      = "woff2"
+	familyZIP        = "zip"
+	familyGZIP       = "gzip"
+	familyMP4        = "mp4"
+	familyWebM       = "webm"
+	familyICO        = "ico"
+	familyProtobuf   = "protobuf"
+)
+
+// extensionFamilies maps URL file extensions to the content family they imply
+var extensionFamilies = map[string]string{
+	".js":    familyJavaScript,
+	".mjs":   familyJavaScript,
+	".json":  familyJSON,
+	".html":  familyHTML,
+	".htm":   familyHTML,
+	".xml":   familyXML,
+	".css":   familyCSS,
+	".txt":   familyText,
+	".png":   familyPNG,
+	".jpg":   familyJPEG,
+	".jpeg":  familyJPEG,
+	".gif":   familyGIF,
+	".webp":  familyWebP,
+	".svg":   familySVG,
+	".pdf":   familyPDF,
+	".wasm":  familyWASM,
+	".woff":  familyWOFF,
+	".woff2": familyWOFF2,
+	".zip":   familyZIP,
+	".gz":    familyGZIP,
+	".mp4":   familyMP4,
+	".webm":  familyWebM,
+	".ico":   familyICO,
+}
+
+// mimeTypeFamilies maps MIME types to their content family, including legacy aliases
+var mimeTypeFamilies = map[string]string{
+	"application/javascript":   familyJavaScript,
+	"application/x-javascript": familyJavaScript,
+	"application/ecmascript":   familyJavaScript,
+	"text/javascript":          familyJavaScript,
+	"text/ecmascript":          familyJavaScript,
+	"application/json":         familyJSON,
+	"text/json":                familyJSON,
+	"text/html":                familyHTML,
+	"application/xhtml+xml":    familyHTML,
+	"application/xml":          familyXML,
+	"text/xml":                 familyXML,
+	"text/css":                 familyCSS,
+	"text/plain":               familyText,
+	"image/png":                familyPNG,
+	"image/jpeg":               familyJPEG,
+	"image/jpg":                familyJPEG,
+	"image/gif":                familyGIF,
+	"image/webp":               familyWebP,
+	"image/svg+xml":            familySVG,
+	"application/pdf":          familyPDF,
+	"application/wasm":         familyWASM,
+	"font/woff":                familyWOFF,
+	"application/font-woff":    familyWOFF,
+	"font/woff2":               familyWOFF2,
+	"application/zip":          familyZIP,
+	"application/gzip":         familyGZIP,
+	"application/x-gzip":       familyGZIP,
+	"video/mp4":                familyMP4,
+	"video/webm":               familyWebM,
+	"audio/webm":               familyWebM,
+	"image/x-icon":             familyICO,
+	"image/vnd.microsoft.icon": familyICO,
+	"application/x-protobuf":   familyProtobuf,
+	"application/protobuf":     familyProtobuf,
+	"application/grpc":         familyProtobuf,
+	"application/grpc-web":     familyProtobuf,
+}
+
+// genericMimeTypes are MIME types that say nothing about the actual content
+var genericMimeTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/binary":       true,
+	"application/x-binary":     true,
+	"application/unknown":      true,
+}
+
+// normalizeMimeType strips parameters such as charset and lower-cases the MIME type
+func normalizeMimeType(mimeType string) string {
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// mimeTypeFamily returns the content family of a MIME type, or "" when unknown
+func mimeTypeFamily(mimeType string) string {
+	mimeType = normalizeMimeType(mimeType)
+	if family, ok := mimeTypeFamilies[mimeType]; ok {
+		return family
+	}
+	// Structured syntax suffixes (RFC 6839) such as application/problem+json
+	switch {
+	case strings.HasSuffix(mimeType, "+json"):
+		return familyJSON
+	case strings.HasSuffix(mimeType, "+xml"):
+		return familyXML
+	}
+	return ""
+}
+
+// extensionFamily returns the content family implied by the URL path extension, or "" when unknown
+func extensionFamily(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return extensionFamilies[strings.ToLower(path.Ext(u.Path))]
+}
+
+// sniffFamily guesses the content family from the body itself, or returns "" when unsure
+func sniffFamily(body []byte) string {
+	switch {
+	case bytes.HasPrefix(body, []byte("\x89PNG\r\n\x1a\n")):
+		return familyPNG
+	case bytes.HasPrefix(body, []byte("\xff\xd8\xff")):
+		return familyJPEG
+	case bytes.HasPrefix(body, []byte("GIF87a")), bytes.HasPrefix(body, []byte("GIF89a")):
+		return familyGIF
+	case len(body) >= 12 && bytes.HasPrefix(body, []byte("RIFF")) && string(body[8:12]) == "WEBP":
+		return familyWebP
+	case bytes.HasPrefix(body, []byte("%PDF-")):
+		return familyPDF
+	case bytes.HasPrefix(body, []byte("\x00asm")):
+		return familyWASM
+	case bytes.HasPrefix(body, []byte("wOFF")):
+		return familyWOFF
+	case bytes.HasPrefix(body, []byte("wOF2")):
+		return familyWOFF2
+	case bytes.HasPrefix(body, []byte("PK\x03\x04")):
+		return familyZIP
+	case bytes.HasPrefix(body, []byte("\x1f\x8b")):
+		return familyGZIP
+	case len(body) >= 12 && string(body[4:8]) == "ftyp":
+		return familyMP4
+	case bytes.HasPrefix(body, []byte("\x1a\x45\xdf\xa3")):
+		return familyWebM
+	case bytes.HasPrefix(body, []byte("\x00\x00\x01\x00")):
+		return familyICO
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	lower := bytes.ToLower(trimmed[:min(len(trimmed), 512)])
+	switch {
+	case len(trimmed) == 0:
+		return ""
+	case (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed):
+		return familyJSON
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")):
+		return familyHTML
+	case bytes.HasPrefix(lower, []byte("<svg")):
+		return familySVG
+	case bytes.HasPrefix(lower, []byte("<?xml")):
+		return familyXML
+	}
+	return ""
+}
+
+// ClassifyContent identifies binary content from its magic bytes when the declared
+// MIME type is generic (e.g. application/octet-stream), so such responses stop
+// being opaque. It returns "" when the MIME type is specific or the content is unknown.
+func ClassifyContent(mimeType string, body []byte) string {
+	if !genericMimeTypes[normalizeMimeType(mimeType)] {
+		return ""
+	}
+	if family := sniffFamily(body); family != "" {
+		return family
+	}
+	// Protobuf has no magic bytes, only try it once every other signature failed
+	if looksLikeProtobuf(body) {
+		return familyProtobuf
+	}
+	return ""
+}
+
+// looksLikeProtobuf reports whether the whole body decodes as protobuf wire format.
+// Text is excluded since short ASCII strings can accidentally be valid wire format.
+func looksLikeProtobuf(body []byte) bool {
+	if len(body) == 0 || isPrintableText(body) {
+		return false
+	}
+
+	for len(body) > 0 {
+		tag, n := binary.Uvarint(body)
+		if n <= 0 || tag>>3 == 0 {
+			return false
+		}
+		body = body[n:]
+
+		switch tag & 0x7 {
+		case 0: // varint
+			if _, n = binary.Uvarint(body); n <= 0 {
+				return false
+			}
+			body = body[n:]
+		case 1: // 64-bit
+			if len(body) < 8 {
+				return false
+			}
+			body = body[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(body)
+			if n <= 0 || length > uint64(len(body)-n) {
+				return false
+			}
+			body = body[n+int(length):]
+		case 5: // 32-bit
+			if len(body) < 4 {
+				return false
+			}
+			body = body[4:]
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPrintableText reports whether the body is valid UTF-8 without control characters other than whitespace
+func isPrintableText(body []byte) bool {
+	if !utf8.Valid(body) {
+		return false
+	}
+	for _, r := range string(body) {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// responseMimeType returns the response MIME type, preferring the Content-Type header
+func responseMimeType(response *har.Response) string {
+	if response == nil {
+		return ""
+	}
+	for _, header := range response.Headers {
+		if strings.EqualFold(header.Name, "Content-Type") {
+			return header.Value
+		}
+	}
+	if response.Content != nil {
+		return response.Content.MimeType
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package har
+
+import (
+	"fmt"
+
+	"github.com/google/martian/har"
+)
+
+// MimeMismatch describes a response whose declared MIME type disagrees with its URL or content
+type MimeMismatch struct {
+	RequestID        string   `json:"request_id"`
+	URL              string   `json:"url"`
+	DeclaredMimeType string   `json:"declared_mime_type"`
+	ExtensionType    string   `json:"extension_type,omitempty"`
+	SniffedType      string   `json:"sniffed_type,omitempty"`
+	Reasons          []string `json:"reasons"`
+}
+
+// MimeMismatchReport lists the responses with a MIME type mismatch
+type MimeMismatchReport struct {
+	Checked    int            `json:"checked"`
+	Mismatches []MimeMismatch `json:"mismatches"`
+}
+
+// GetMimeMismatches flags responses whose Content-Type disagrees with the URL
+// extension or with the sniffed body content, e.g. JavaScript served as text/html.
+func (p *Parser) GetMimeMismatches(harData *har.HAR) *MimeMismatchReport {
+	redaction := p.redaction()
+	report := &MimeMismatchReport{Mismatches: []MimeMismatch{}}
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		// Redirects and empty responses carry no content to compare against
+		if entry.Response.Status < 200 || entry.Response.Status >= 300 || entry.Response.Status == 204 {
+			continue
+		}
+		report.Checked++
+
+		declared := responseMimeType(entry.Response)
+		declaredFamily := mimeTypeFamily(declared)
+		mismatch := MimeMismatch{
+			RequestID:        formatRequestID(i),
+			URL:              redaction.text(entry.Request.URL),
+			DeclaredMimeType: declared,
+			ExtensionType:    extensionFamily(entry.Request.URL),
+		}
+		if entry.Response.Content != nil {
+			mismatch.SniffedType = sniffFamily(entry.Response.Content.Text)
+		}
+
+		if mismatch.ExtensionType != "" && !compatibleFamilies(declaredFamily, mismatch.ExtensionType) {
+			mismatch.Reasons = append(mismatch.Reasons, fmt.Sprintf("URL extension suggests %s but response is declared as %q", mismatch.ExtensionType, declared))
+		}
+		if mismatch.SniffedType != "" && !compatibleFamilies(declaredFamily, mismatch.SniffedType) {
+			mismatch.Reasons = append(mismatch.Reasons, fmt.Sprintf("body looks like %s but response is declared as %q", mismatch.SniffedType, declared))
+		}
+
+		if len(mismatch.Reasons) > 0 {
+			report.Mismatches = append(report.Mismatches, mismatch)
+		}
+	}
+
+	return report
+}
+
+// compatibleFamilies reports whether a declared content family is acceptable for the expected one
+func compatibleFamilies(declared, expected string) bool {
+	if declared == expected {
+		return true
+	}
+	// SVG and XHTML documents are XML and are legitimately sniffed as such
+	xmlBased := func(family string) bool { return family == familySVG || family == familyHTML }
+	return (expected == familyXML && xmlBased(declared)) || (declared == familyXML && xmlBased(expected))
+}
@@ -0,0 +1,77 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestResponseEntry builds a GET entry whose response has the given content type and body.
+func newTestResponseEntry(url, contentType, body string) *har.Entry {
+	entry := newTestEntry("GET", url)
+	entry.Response.Headers = []har.Header{{Name: "Content-Type", Value: contentType}}
+	entry.Response.Content = &har.Content{MimeType: contentType, Text: []byte(body), Size: int64(len(body))}
+	return entry
+}
+
+func TestGetMimeMismatchesExtension(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestResponseEntry("https://example.com/app.js", "text/html; charset=utf-8", "console.log(1)"),
+		newTestResponseEntry("https://example.com/lib.js", "application/javascript", "console.log(2)"),
+	)
+
+	report := parser.GetMimeMismatches(archive)
+
+	assert.Equal(t, 2, report.Checked)
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, "request_0", report.Mismatches[0].RequestID)
+	assert.Equal(t, "javascript", report.Mismatches[0].ExtensionType)
+	assert.Contains(t, report.Mismatches[0].Reasons[0], "URL extension suggests javascript")
+}
+
+func TestGetMimeMismatchesSniffedContent(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestResponseEntry("https://example.com/api/users", "text/plain", `{"users": []}`),
+		newTestResponseEntry("https://example.com/api/problem", "application/problem+json", `{"title": "oops"}`),
+	)
+
+	report := parser.GetMimeMismatches(archive)
+
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, "request_0", report.Mismatches[0].RequestID)
+	assert.Equal(t, "json", report.Mismatches[0].SniffedType)
+}
+
+func TestGetMimeMismatchesSkipsRedirects(t *testing.T) {
+	parser := NewParser()
+	entry := newTestResponseEntry("https://example.com/app.js", "text/html", "<html></html>")
+	entry.Response.Status = 302
+	archive := newTestHAR(entry)
+
+	report := parser.GetMimeMismatches(archive)
+
+	assert.Equal(t, 0, report.Checked)
+	assert.Empty(t, report.Mismatches)
+}
+
+func TestSniffFamily(t *testing.T) {
+	assert.Equal(t, "png", sniffFamily([]byte("\x89PNG\r\n\x1a\nrest")))
+	assert.Equal(t, "html", sniffFamily([]byte("  <!DOCTYPE html><html></html>")))
+	assert.Equal(t, "json", sniffFamily([]byte(`[1, 2]`)))
+	assert.Equal(t, "", sniffFamily([]byte("plain words")))
+}
+
+func TestGetMimeMismatchesRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(newTestResponseEntry("https://example.com/app.js?key=sk_live_abc", "text/html", "console.log(1)"))
+
+	report := parser.GetMimeMismatches(archive)
+
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, "https://example.com/app.js?key=[REDACTED]", report.Mismatches[0].URL)
+}
@@ -0,0 +1,29 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyContentMagicBytes(t *testing.T) {
+	assert.Equal(t, "png", ClassifyContent("application/octet-stream", []byte("\x89PNG\r\n\x1a\nrest")))
+	assert.Equal(t, "pdf", ClassifyContent("", []byte("%PDF-1.7")))
+	assert.Equal(t, "wasm", ClassifyContent("binary/octet-stream", []byte("\x00asm\x01\x00\x00\x00")))
+	assert.Equal(t, "zip", ClassifyContent("application/octet-stream", []byte("PK\x03\x04rest")))
+}
+
+func TestClassifyContentProtobuf(t *testing.T) {
+	// field 1 varint 150, field 2 string "hi"
+	body := []byte{0x08, 0x96, 0x01, 0x12, 0x02, 'h', 'i'}
+
+	assert.Equal(t, "protobuf", ClassifyContent("application/octet-stream", body))
+}
+
+func TestClassifyContentIgnoresSpecificMimeTypes(t *testing.T) {
+	assert.Equal(t, "", ClassifyContent("image/png", []byte("\x89PNG\r\n\x1a\nrest")))
+}
+
+func TestClassifyContentUnknown(t *testing.T) {
+	assert.Equal(t, "", ClassifyContent("application/octet-stream", []byte("just some text")))
+}
@@ -0,0 +1,100 @@
+package har
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/google/martian/har"
+)
+
+// FormatNDJSON is the format of entry logs holding one HAR entry per line, as written by proxy
+// exporters and custom loggers instead of a full document
+const FormatNDJSON = "ndjson"
+
+// formatDetectionBytes is the size of the beginning of a file read to detect its format
+const formatDetectionBytes = 4096
+
+// entryKeys are the members of a HAR entry, an entry log starting with one of them rather than log
+var entryKeys = []string{
+	"startedDateTime", "time", "request", "response", "cache", "timings", "pageref",
+	"serverIPAddress", "connection",
+}
+
+// ParseNDJSON parses an entry log holding one HAR entry per line (NDJSON) and assembles the entries
+// into an archive, so that it is analyzed with the same tools as HAR documents. Compressed logs are
+// decompressed. Blank lines are skipped; in lenient mode malformed lines are repaired or left out
+// with a warning instead of failing. ParseWithReport detects entry logs on its own, ParseNDJSON
+// spares the detection for files known to be entry logs.
+func (p *Parser) ParseNDJSON(r io.Reader) (*har.HAR, *ParseReport, error) {
+	return p.parse(r, FormatNDJSON, 0)
+}
+
+// detectFormat tells entry logs from HAR documents by the first member of the first JSON object
+// of the file, HAR documents being the default
+func detectFormat(prefix []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(prefix))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return FormatHAR
+	}
+	token, err := decoder.Token()
+	if key, ok := token.(string); err == nil && ok && slices.Contains(entryKeys, key) {
+		return FormatNDJSON
+	}
+	return FormatHAR
+}
+
+// decodeNDJSON decodes an entry log line by line, returning the _transferSize of the responses
+// aligned with the entries. Only the current line is held in memory besides the decoded entries.
+func (p *Parser) decodeNDJSON(r io.Reader, report *ParseReport) (*har.HAR, []*float64, error) {
+	d := newDocumentDecoder(nil, report)
+	// Entry logs carry no log block, the entries are those of HAR 1.2 exporters
+	log := &har.Log{Version: harVersion12, Creator: &har.Creator{Name: unknownCreator, Version: unknownCreator}}
+	reader := bufio.NewReader(r)
+	repairs, ignored := 0, 0
+
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("failed to read HAR data: %w", err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 {
+			if p.lenient {
+				repaired, trailingCommas, nonFinite := repairJSON(raw)
+				raw = repaired
+				repairs += trailingCommas + nonFinite
+			}
+			entry, decodeErr := d.decodeEntry(raw, len(log.Entries))
+			switch {
+			case decodeErr == nil:
+				log.Entries = append(log.Entries, entry)
+				d.collect(entry, raw, len(log.Entries)-1)
+			case p.lenient:
+				ignored++
+				report.warn("", fmt.Sprintf("ignored line %d of the entry log: %v", line, decodeErr))
+			default:
+				return nil, nil, fmt.Errorf("failed to parse entry log: line %d: %w", line, decodeErr)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(log.Entries) == 0 && ignored > 0 {
+		return nil, nil, fmt.Errorf("failed to recover entry log: no valid entry found")
+	}
+
+	d.index()
+	if repairs > 0 {
+		report.warn("", fmt.Sprintf("repaired %d malformed values in the entry log", repairs))
+	}
+	if repairs > 0 || ignored > 0 {
+		report.Mode = ParseModeRecovered
+	}
+	return &har.HAR{Log: log}, d.transferSizes, nil
+}
@@ -0,0 +1,62 @@
+package har
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEntryLine returns an entry log line requesting the URL
+func testEntryLine(url string) string {
+	return fmt.Sprintf(`{"startedDateTime": "2023-01-01T00:00:00Z", "time": 12, "request": {"method": "GET", "url": %q, "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0}, "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/html"}, "redirectURL": "", "headersSize": -1, "bodySize": 0}, "timings": {"send": 1, "wait": 10, "receive": 1}, "serverIPAddress": "93.184.216.34"}`, url)
+}
+
+func TestParseDetectsEntryLogs(t *testing.T) {
+	log := testEntryLine("https://example.com/") + "\n\n" + testEntryLine("https://example.com/app.js") + "\n"
+
+	harData, report, err := NewParser().ParseWithReport(strings.NewReader(log))
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatNDJSON, report.Format)
+	assert.Equal(t, ParseModeStrict, report.Mode)
+	assert.Equal(t, "1.2", report.Version)
+	assert.Empty(t, report.Warnings)
+	require.Len(t, harData.Log.Entries, 2)
+	assert.Equal(t, "https://example.com/app.js", harData.Log.Entries[1].Request.URL)
+	assert.Equal(t, 12.0, report.TimingIndex().Time(harData.Log.Entries[0]))
+	assert.Equal(t, "93.184.216.34", report.ConnectionIndex().ServerIPAddress(harData.Log.Entries[1]))
+}
+
+func TestParseKeepsHARDocumentFormat(t *testing.T) {
+	_, report, err := NewParser().ParseWithReport(strings.NewReader(createTestHAR()))
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatHAR, report.Format)
+}
+
+func TestParseNDJSONFailsOnMalformedLine(t *testing.T) {
+	log := testEntryLine("https://example.com/") + "\n" + `{"request": ` + "\n"
+
+	_, _, err := NewParser().ParseNDJSON(strings.NewReader(log))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestParseNDJSONLenientSkipsMalformedLines(t *testing.T) {
+	log := strings.Replace(testEntryLine("https://example.com/"), `"time": 12`, `"time": NaN`, 1) + "\n" +
+		`{"request": ` + "\n" +
+		testEntryLine("https://example.com/app.js")
+
+	parser := NewParser()
+	parser.SetLenient(true)
+	harData, report, err := parser.ParseNDJSON(strings.NewReader(log))
+	require.NoError(t, err)
+
+	assert.Len(t, harData.Log.Entries, 2)
+	assert.Equal(t, ParseModeRecovered, report.Mode)
+	require.Len(t, report.Warnings, 2)
+	assert.Contains(t, report.Warnings[0].Message, "ignored line 2")
+	assert.Equal(t, "repaired 1 malformed values in the entry log", report.Warnings[1].Message)
+}
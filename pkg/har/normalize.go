@@ -0,0 +1,40 @@
+package har
+
+import (
+	"fmt"
+
+	"github.com/google/martian/har"
+)
+
+// unknownCreator names the creator of exports that omit it
+const unknownCreator = "unknown"
+
+// normalize fills in what minimal or non-standard exports omit, recording each deviation in the report
+func normalize(harData *har.HAR, transferSizes []*float64, report *ParseReport) {
+	normalizeVersion(harData, transferSizes, report)
+	normalizeCreator(harData, report)
+	dropUnusableEntries(harData, report)
+}
+
+// dropUnusableEntries leaves out null entries and entries without a request, which no tool can analyze
+func dropUnusableEntries(harData *har.HAR, report *ParseReport) {
+	entries := harData.Log.Entries[:0]
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			report.DroppedEntries++
+			report.warn("", fmt.Sprintf("dropped entry %d of the file: no request", i))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	harData.Log.Entries = entries
+}
+
+// normalizeCreator defaults a missing or malformed creator block instead of leaving it nil
+func normalizeCreator(harData *har.HAR, report *ParseReport) {
+	if harData.Log.Creator != nil && harData.Log.Creator.Name != "" {
+		return
+	}
+	harData.Log.Creator = &har.Creator{Name: unknownCreator, Version: unknownCreator}
+	report.warn("", "log.creator is missing or malformed, defaulted to unknown")
+}
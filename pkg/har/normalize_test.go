@@ -0,0 +1,82 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createMinimalHAR creates a HAR with a single entry, the creator and browser fields are inserted verbatim.
+func createMinimalHAR(creatorAndBrowser string) string {
+	return `{
+		"log": {
+			"version": "1.2",
+			` + creatorAndBrowser + `
+			"entries": [
+				{
+					"startedDateTime": "2023-01-01T00:00:00.000Z",
+					"time": 10.5,
+					"request": {"method": "GET", "url": "https://example.com", "httpVersion": "HTTP/1.1", "headers": []},
+					"response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "content": {"size": 0, "mimeType": "text/plain"}}
+				}
+			]
+		}
+	}`
+}
+
+func TestParseWithReportDefaultsMissingCreator(t *testing.T) {
+	parser := NewParser()
+
+	archive, report, err := parser.ParseWithReport(strings.NewReader(createMinimalHAR("")))
+	require.NoError(t, err)
+
+	require.NotNil(t, archive.Log.Creator)
+	assert.Equal(t, "unknown", archive.Log.Creator.Name)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "log.creator is missing")
+}
+
+func TestParseWithReportAcceptsCreatorName(t *testing.T) {
+	parser := NewParser()
+
+	archive, report, err := parser.ParseWithReport(strings.NewReader(createMinimalHAR(`"creator": "mitmproxy", "browser": "Firefox",`)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "mitmproxy", archive.Log.Creator.Name)
+	assert.Len(t, archive.Log.Entries, 1)
+	assert.Empty(t, report.Warnings)
+}
+
+func TestParseWithReportDefaultsMalformedCreator(t *testing.T) {
+	parser := NewParser()
+
+	archive, report, err := parser.ParseWithReport(strings.NewReader(createMinimalHAR(`"creator": 42, "browser": null,`)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "unknown", archive.Log.Creator.Name)
+	require.Len(t, report.Warnings, 1)
+}
+
+func TestParseRejectsMissingLog(t *testing.T) {
+	parser := NewParser()
+
+	_, err := parser.Parse(strings.NewReader(`{"entries": []}`))
+	assert.ErrorContains(t, err, "missing log")
+}
+
+func TestParseWithReportDropsEntriesWithoutRequest(t *testing.T) {
+	harData := strings.Replace(createMultipleEntriesHAR(), `"entries": [`, `"entries": [null, {"startedDateTime": "2023-01-01T00:00:00.000Z", "time": 1},`, 1)
+
+	parser := NewParser()
+	archive, report, err := parser.ParseWithReport(strings.NewReader(harData))
+	require.NoError(t, err)
+
+	assert.Len(t, archive.Log.Entries, 3)
+	assert.Equal(t, 2, report.DroppedEntries)
+	assert.Equal(t, []ParseWarning{
+		{Message: "dropped entry 0 of the file: no request"},
+		{Message: "dropped entry 1 of the file: no request"},
+	}, report.Warnings)
+}
@@ -0,0 +1,265 @@
+package har
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// OAuth audit checks, each reported at most once with every request providing evidence
+const (
+	OAuthCheckPKCEMissing         = "pkce_missing"
+	OAuthCheckPKCEPlain           = "pkce_plain"
+	OAuthCheckCodeVerifierMissing = "code_verifier_missing"
+	OAuthCheckStateMissing        = "state_missing"
+	OAuthCheckStateMismatch       = "state_mismatch"
+	OAuthCheckImplicitFlow        = "implicit_flow"
+	OAuthCheckTokenInURL          = "token_in_url"
+	OAuthCheckInsecureRedirectURI = "insecure_redirect_uri"
+	OAuthCheckRedirectURIMismatch = "redirect_uri_mismatch"
+)
+
+// Severities of audit findings
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// oauthChecks describes each check with its severity
+var oauthChecks = map[string]struct {
+	severity string
+	message  string
+}{
+	OAuthCheckPKCEMissing:         {SeverityHigh, "authorization code requests without a PKCE code_challenge"},
+	OAuthCheckPKCEPlain:           {SeverityMedium, "PKCE with the plain code_challenge_method instead of S256"},
+	OAuthCheckCodeVerifierMissing: {SeverityHigh, "authorization code exchanged without the code_verifier of the PKCE challenge"},
+	OAuthCheckStateMissing:        {SeverityMedium, "authorization requests without a state parameter protecting against CSRF"},
+	OAuthCheckStateMismatch:       {SeverityHigh, "redirects back to the client with a state differing from the one sent"},
+	OAuthCheckImplicitFlow:        {SeverityMedium, "implicit flow returning tokens from the authorization endpoint, use the code flow with PKCE"},
+	OAuthCheckTokenInURL:          {SeverityHigh, "tokens sent in URLs, where they leak to logs, history and referrers"},
+	OAuthCheckInsecureRedirectURI: {SeverityHigh, "redirect URIs neither HTTPS nor loopback"},
+	OAuthCheckRedirectURIMismatch: {SeverityMedium, "token requests whose redirect_uri differs from the authorization request"},
+}
+
+// tokenParameters are the parameters carrying tokens
+var tokenParameters = []string{"access_token", "id_token", "refresh_token"}
+
+// OAuthFinding is a failed OAuth check with the requests providing evidence
+type OAuthFinding struct {
+	Check      string   `json:"check"`
+	Severity   string   `json:"severity"`
+	Message    string   `json:"message"`
+	RequestIDs []string `json:"request_ids"`
+}
+
+// OAuthAudit lists the OAuth and OpenID Connect exchanges of a capture and the checks they fail
+type OAuthAudit struct {
+	AuthorizeRequests []string       `json:"authorize_requests"`
+	TokenRequests     []string       `json:"token_requests"`
+	Findings          []OAuthFinding `json:"findings"`
+}
+
+// oauthAuthorization is an authorization request awaiting its callback and token exchange
+type oauthAuthorization struct {
+	requestID     string
+	redirectURI   string
+	state         string
+	codeChallenge bool
+}
+
+// AuditOAuth checks the captured OAuth and OpenID Connect flows against basic best practices:
+// PKCE present with S256 and its verifier sent, state present and echoed back, no implicit
+// flow, no tokens in URLs, HTTPS redirect URIs matching between the authorization and token
+// requests. Findings are sorted by severity and never include the token values.
+func (p *Parser) AuditOAuth(harData *har.HAR) *OAuthAudit {
+	audit := &OAuthAudit{AuthorizeRequests: []string{}, TokenRequests: []string{}, Findings: []OAuthFinding{}}
+	findings := make(map[string]*OAuthFinding)
+	report := func(check, requestID string) {
+		finding, ok := findings[check]
+		if !ok {
+			finding = &OAuthFinding{Check: check, Severity: oauthChecks[check].severity, Message: p.message(oauthChecks[check].message)}
+			findings[check] = finding
+		}
+		if !slices.Contains(finding.RequestIDs, requestID) {
+			finding.RequestIDs = append(finding.RequestIDs, requestID)
+		}
+	}
+	// authorizations are the pending authorization requests by client ID
+	authorizations := make(map[string]*oauthAuthorization)
+	var pending []*oauthAuthorization
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		requestID := formatRequestID(i)
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		query := u.Query()
+
+		if urlCarriesToken(u) {
+			report(OAuthCheckTokenInURL, requestID)
+		}
+		if entry.Response != nil {
+			for _, header := range entry.Response.Headers {
+				if location, err := url.Parse(header.Value); err == nil && strings.EqualFold(header.Name, "Location") && urlCarriesToken(location) {
+					report(OAuthCheckTokenInURL, requestID)
+				}
+			}
+		}
+
+		switch {
+		case query.Get("response_type") != "" && query.Get("client_id") != "":
+			audit.AuthorizeRequests = append(audit.AuthorizeRequests, requestID)
+			authorization := auditAuthorization(query, requestID, report)
+			authorizations[query.Get("client_id")] = authorization
+			pending = append(pending, authorization)
+
+		case isTokenRequest(entry.Request):
+			audit.TokenRequests = append(audit.TokenRequests, requestID)
+			form := requestForm(entry.Request)
+			if form.Get("grant_type") != "authorization_code" {
+				continue
+			}
+			authorization := authorizations[form.Get("client_id")]
+			if authorization == nil && len(pending) > 0 {
+				// Confidential clients authenticate with basic auth rather than a client_id parameter
+				authorization = pending[len(pending)-1]
+			}
+			if authorization == nil {
+				continue
+			}
+			if authorization.codeChallenge && form.Get("code_verifier") == "" {
+				report(OAuthCheckCodeVerifierMissing, requestID)
+			}
+			if redirectURI := form.Get("redirect_uri"); authorization.redirectURI != "" && redirectURI != authorization.redirectURI {
+				report(OAuthCheckRedirectURIMismatch, authorization.requestID)
+				report(OAuthCheckRedirectURIMismatch, requestID)
+			}
+
+		case query.Get("code") != "" || query.Get("error") != "":
+			// A redirect back to the client, matched to the authorization by its redirect URI
+			for j := len(pending) - 1; j >= 0; j-- {
+				authorization := pending[j]
+				if authorization.redirectURI == "" || !strings.HasPrefix(entry.Request.URL, authorization.redirectURI) {
+					continue
+				}
+				if authorization.state != "" && query.Get("state") != authorization.state {
+					report(OAuthCheckStateMismatch, authorization.requestID)
+					report(OAuthCheckStateMismatch, requestID)
+				}
+				break
+			}
+		}
+	}
+
+	for _, finding := range findings {
+		audit.Findings = append(audit.Findings, *finding)
+	}
+	sort.Slice(audit.Findings, func(i, j int) bool {
+		a, b := audit.Findings[i], audit.Findings[j]
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		return a.Check < b.Check
+	})
+	return audit
+}
+
+// auditAuthorization checks an authorization request
+func auditAuthorization(query url.Values, requestID string, report func(check, requestID string)) *oauthAuthorization {
+	authorization := &oauthAuthorization{
+		requestID:     requestID,
+		redirectURI:   query.Get("redirect_uri"),
+		state:         query.Get("state"),
+		codeChallenge: query.Get("code_challenge") != "",
+	}
+
+	responseTypes := strings.Fields(query.Get("response_type"))
+	if slices.Contains(responseTypes, "token") || (slices.Contains(responseTypes, "id_token") && !slices.Contains(responseTypes, "code")) {
+		report(OAuthCheckImplicitFlow, requestID)
+	}
+	if slices.Contains(responseTypes, "code") {
+		if !authorization.codeChallenge {
+			report(OAuthCheckPKCEMissing, requestID)
+		} else if method := query.Get("code_challenge_method"); method == "" || strings.EqualFold(method, "plain") {
+			report(OAuthCheckPKCEPlain, requestID)
+		}
+	}
+	if authorization.state == "" {
+		report(OAuthCheckStateMissing, requestID)
+	}
+	if authorization.redirectURI != "" && !secureRedirectURI(authorization.redirectURI) {
+		report(OAuthCheckInsecureRedirectURI, requestID)
+	}
+	return authorization
+}
+
+// isTokenRequest reports whether the request is a token endpoint request
+func isTokenRequest(request *har.Request) bool {
+	return strings.EqualFold(request.Method, "POST") && requestForm(request).Get("grant_type") != ""
+}
+
+// requestForm returns the form parameters of a request body
+func requestForm(request *har.Request) url.Values {
+	form := url.Values{}
+	if request.PostData == nil {
+		return form
+	}
+	for _, param := range request.PostData.Params {
+		form.Add(param.Name, param.Value)
+	}
+	if len(form) == 0 && strings.Contains(request.PostData.MimeType, "application/x-www-form-urlencoded") {
+		if parsed, err := url.ParseQuery(request.PostData.Text); err == nil {
+			form = parsed
+		}
+	}
+	return form
+}
+
+// urlCarriesToken reports whether the query string or the fragment of a URL carries a token
+func urlCarriesToken(u *url.URL) bool {
+	fragment, _ := url.ParseQuery(u.Fragment)
+	query := u.Query()
+	for _, name := range tokenParameters {
+		if query.Get(name) != "" || fragment.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// secureRedirectURI reports whether a redirect URI uses HTTPS, a loopback address or a
+// private-use scheme of a native app
+func secureRedirectURI(redirectURI string) bool {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		return isLoopbackHost(u.Hostname())
+	default:
+		return u.Scheme != ""
+	}
+}
+
+// severityRank orders severities from the most to the least severe
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityHigh:
+		return 0
+	case SeverityMedium:
+		return 1
+	default:
+		return 2
+	}
+}
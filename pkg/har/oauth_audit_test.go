@@ -0,0 +1,70 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTokenRequest builds a form encoded token endpoint request
+func newTestTokenRequest(form string) *har.Entry {
+	entry := newTestEntry("POST", "https://auth.example.com/oauth/token")
+	entry.Request.PostData = &har.PostData{MimeType: "application/x-www-form-urlencoded", Text: form}
+	return entry
+}
+
+func TestAuditOAuthAcceptsCodeFlowWithPKCE(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://auth.example.com/authorize?response_type=code&client_id=app&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback&state=xyz&code_challenge=abc&code_challenge_method=S256"),
+		newTestEntry("GET", "https://app.example.com/callback?code=secret&state=xyz"),
+		newTestTokenRequest("grant_type=authorization_code&client_id=app&code=secret&code_verifier=verifier&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback"),
+	)
+
+	audit := parser.AuditOAuth(archive)
+
+	assert.Equal(t, []string{"request_0"}, audit.AuthorizeRequests)
+	assert.Equal(t, []string{"request_2"}, audit.TokenRequests)
+	assert.Empty(t, audit.Findings)
+}
+
+func TestAuditOAuthReportsFindingsWithEvidence(t *testing.T) {
+	parser := NewParser()
+	callback := newTestEntry("GET", "http://app.example.com/callback?code=secret&state=forged")
+	implicit := newTestEntry("GET", "https://auth.example.com/authorize?response_type=token&client_id=spa&redirect_uri=https%3A%2F%2Fspa.example.com%2F&state=1")
+	implicit.Response.Headers = []har.Header{{Name: "Location", Value: "https://spa.example.com/#access_token=eyJ&token_type=bearer"}}
+	archive := newTestHAR(
+		newTestEntry("GET", "https://auth.example.com/authorize?response_type=code&client_id=app&redirect_uri=http%3A%2F%2Fapp.example.com%2Fcallback&state=xyz&code_challenge=abc&code_challenge_method=plain"),
+		callback,
+		newTestTokenRequest("grant_type=authorization_code&client_id=app&code=secret&redirect_uri=https%3A%2F%2Fapp.example.com%2Fother"),
+		newTestEntry("GET", "https://auth.example.com/authorize?response_type=code&client_id=legacy&redirect_uri=http%3A%2F%2F127.0.0.1%3A8080%2F"),
+		implicit,
+		newTestEntry("GET", "https://api.example.com/me?access_token=eyJ"),
+	)
+
+	audit := parser.AuditOAuth(archive)
+
+	checks := make(map[string][]string)
+	for _, finding := range audit.Findings {
+		checks[finding.Check] = finding.RequestIDs
+	}
+	assert.Equal(t, map[string][]string{
+		OAuthCheckCodeVerifierMissing: {"request_2"},
+		OAuthCheckInsecureRedirectURI: {"request_0"},
+		OAuthCheckStateMismatch:       {"request_0", "request_1"},
+		OAuthCheckTokenInURL:          {"request_4", "request_5"},
+		OAuthCheckPKCEMissing:         {"request_3"},
+		OAuthCheckPKCEPlain:           {"request_0"},
+		OAuthCheckStateMissing:        {"request_3"},
+		OAuthCheckImplicitFlow:        {"request_4"},
+		OAuthCheckRedirectURIMismatch: {"request_0", "request_2"},
+	}, checks)
+	require.NotEmpty(t, audit.Findings)
+	assert.Equal(t, SeverityHigh, audit.Findings[0].Severity)
+	assert.Equal(t, SeverityMedium, audit.Findings[len(audit.Findings)-1].Severity)
+	for _, finding := range audit.Findings {
+		assert.NotContains(t, finding.Message, "eyJ")
+	}
+}
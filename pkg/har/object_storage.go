@@ -0,0 +1,215 @@
+package har
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schemes of the object storage URIs HAR files can be loaded from
+const (
+	schemeS3    = "s3"
+	schemeGCS   = "gs"
+	schemeAzure = "az"
+)
+
+const (
+	// emptyPayloadHash is the SHA-256 of the empty body of the requests signed for S3
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// azureStorageVersion is the Blob Storage API version sent along bearer tokens, which require one
+	azureStorageVersion = "2021-08-06"
+)
+
+// awsCredentials sign the requests to S3
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+}
+
+// isObjectStorageURI reports whether the URL is an s3://, gs:// or az:// object storage URI
+func isObjectStorageURI(u *url.URL) bool {
+	return u.Scheme == schemeS3 || u.Scheme == schemeGCS || u.Scheme == schemeAzure
+}
+
+// openObject fetches a HAR file stored in S3, Google Cloud Storage or Azure Blob Storage and
+// returns its body. The URI is translated to the HTTPS URL of the object, fetched with the
+// credentials the provider's tools read from the environment, anonymously when there are none so
+// that public objects load:
+//   - s3://bucket/key requests are signed with AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+//     AWS_SESSION_TOKEN, for the AWS_REGION or AWS_DEFAULT_REGION region, us-east-1 by default.
+//     AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL select S3 compatible stores such as MinIO.
+//   - gs://bucket/object requests carry the bearer token of the options or GOOGLE_OAUTH_ACCESS_TOKEN,
+//     as printed by gcloud auth print-access-token. STORAGE_EMULATOR_HOST selects an emulator.
+//   - az://account/container/blob requests carry the AZURE_STORAGE_SAS_TOKEN shared access
+//     signature, or else the bearer token of the options.
+func openObject(ctx context.Context, u *url.URL, options FetchOptions, limits FetchLimits) (*limitedBody, error) {
+	objectURL, err := objectURL(u)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == schemeAzure {
+		if sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"); sas != "" {
+			objectURL += "?" + sas
+		}
+	}
+	request, err := newFetchRequest(ctx, objectURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case schemeS3:
+		if credentials := awsCredentialsFromEnv(); credentials.accessKeyID != "" {
+			credentials.sign(request, time.Now())
+		}
+	case schemeGCS:
+		token := options.BearerToken
+		if token == "" {
+			token = os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		}
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+	case schemeAzure:
+		if options.BearerToken != "" && request.URL.RawQuery == "" {
+			request.Header.Set("Authorization", "Bearer "+options.BearerToken)
+			request.Header.Set("X-Ms-Version", azureStorageVersion)
+		}
+	}
+	return send(request, options, limits)
+}
+
+// objectURL returns the HTTPS URL of the object an object storage URI refers to
+func objectURL(u *url.URL) (string, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return "", fmt.Errorf("invalid object storage URI %q: expected %s://<bucket>/<key>", u.Redacted(), u.Scheme)
+	}
+
+	switch u.Scheme {
+	case schemeS3:
+		endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+		if endpoint == "" {
+			endpoint = os.Getenv("AWS_ENDPOINT_URL")
+		}
+		switch {
+		case endpoint != "":
+			return strings.TrimSuffix(endpoint, "/") + "/" + u.Host + "/" + escapeObjectPath(key), nil
+		case strings.Contains(u.Host, "."):
+			// Bucket names with dots do not match the wildcard certificate of virtual-hosted URLs
+			return fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", awsRegion(), u.Host, escapeObjectPath(key)), nil
+		default:
+			return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.Host, awsRegion(), escapeObjectPath(key)), nil
+		}
+	case schemeGCS:
+		endpoint := "https://storage.googleapis.com"
+		if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+			endpoint = strings.TrimSuffix(emulator, "/")
+			if !strings.Contains(endpoint, "://") {
+				endpoint = "http://" + endpoint
+			}
+		}
+		return endpoint + "/" + u.Host + "/" + escapeObjectPath(key), nil
+	case schemeAzure:
+		container, blob, ok := strings.Cut(key, "/")
+		if !ok || container == "" || blob == "" {
+			return "", fmt.Errorf("invalid object storage URI %q: expected az://<account>/<container>/<blob>", u.Redacted())
+		}
+		return fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", u.Host, container, escapeObjectPath(blob)), nil
+	}
+	return "", fmt.Errorf("unsupported object storage scheme %q", u.Scheme)
+}
+
+// awsCredentialsFromEnv returns the AWS credentials and region of the environment
+func awsCredentialsFromEnv() awsCredentials {
+	return awsCredentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		region:          awsRegion(),
+	}
+}
+
+// awsRegion returns the AWS region of the environment, us-east-1 by default
+func awsRegion() string {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region
+		}
+	}
+	return "us-east-1"
+}
+
+// sign signs the bodyless S3 request with AWS Signature Version 4, covering its host and headers
+func (c awsCredentials) sign(request *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if c.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	values := map[string]string{"host": request.URL.Host}
+	for name, value := range request.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(value, ","))
+	}
+	names := slices.Sorted(maps.Keys(values))
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		escapeObjectPath(request.URL.Path),
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + c.secretAccessKey)
+	for _, part := range []string{date, c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data)) //nolint:errcheck
+	return mac.Sum(nil)
+}
+
+// escapeObjectPath percent-encodes every byte of the path but the unreserved characters and
+// slashes, as the canonical requests of AWS signatures expect and every store accepts
+func escapeObjectPath(path string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
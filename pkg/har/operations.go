@@ -0,0 +1,111 @@
+package har
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// RPC protocols whose calls are grouped by logical operation rather than by URL
+const (
+	ProtocolGraphQL = "graphql"
+	ProtocolJSONRPC = "jsonrpc"
+)
+
+// requestOperations returns the protocol and the logical operations of a request sent to a
+// single RPC endpoint: GraphQL operation names or JSON-RPC methods, several for batches
+func requestOperations(request *har.Request) (string, []string) {
+	if methods, ok := jsonRPCMethods(request); ok {
+		return ProtocolJSONRPC, methods
+	}
+	if operations, ok := graphQLOperations(request); ok {
+		return ProtocolGraphQL, operations
+	}
+	return "", nil
+}
+
+// operationErrors returns the errors an RPC response reports for the given protocol
+func operationErrors(protocol string, response *har.Response) []string {
+	switch protocol {
+	case ProtocolGraphQL:
+		return graphQLErrors(response)
+	case ProtocolJSONRPC:
+		return jsonRPCErrors(response)
+	default:
+		return nil
+	}
+}
+
+// OperationStats aggregates the calls to one logical operation of an RPC endpoint
+type OperationStats struct {
+	Protocol  string `json:"protocol"`
+	Method    string `json:"method"`
+	Endpoint  string `json:"endpoint"`
+	Operation string `json:"operation"`
+	// Calls counts the operation calls, a batched request counting once per call
+	Calls int `json:"calls"`
+	// Failures counts the calls whose request failed or whose response reported errors
+	Failures int `json:"failures"`
+	// AverageMS is the average duration of the requests carrying the operation
+	AverageMS  float64  `json:"average_ms"`
+	MaxMS      float64  `json:"max_ms"`
+	RequestIDs []string `json:"request_ids"`
+}
+
+// GetOperationStats groups the GraphQL and JSON-RPC requests by logical operation rather than by
+// URL, since such apps send every call to a single endpoint. Operations are sorted by decreasing
+// number of calls. Durations are read from the timing index.
+func (p *Parser) GetOperationStats(harData *har.HAR, index *TimingIndex) []OperationStats {
+	stats := make(map[string]*OperationStats)
+	totals := make(map[string]float64)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		protocol, operations := requestOperations(entry.Request)
+		if len(operations) == 0 {
+			continue
+		}
+		kind, _ := classifyFailure(entry)
+		requestID := formatRequestID(i)
+
+		for _, operation := range operations {
+			key := strings.Join([]string{protocol, resourceKey(entry), operation}, " ")
+			stat, ok := stats[key]
+			if !ok {
+				stat = &OperationStats{
+					Protocol:   protocol,
+					Method:     entry.Request.Method,
+					Endpoint:   templateURL(entry.Request.URL),
+					Operation:  operation,
+					RequestIDs: []string{},
+				}
+				stats[key] = stat
+				order = append(order, key)
+			}
+			stat.Calls++
+			if kind != "" {
+				stat.Failures++
+			}
+			totals[key] += index.Time(entry)
+			stat.MaxMS = max(stat.MaxMS, index.Time(entry))
+			if len(stat.RequestIDs) == 0 || stat.RequestIDs[len(stat.RequestIDs)-1] != requestID {
+				stat.RequestIDs = append(stat.RequestIDs, requestID)
+			}
+		}
+	}
+
+	result := make([]OperationStats, 0, len(order))
+	for _, key := range order {
+		stat := stats[key]
+		stat.AverageMS = totals[key] / float64(stat.Calls)
+		result = append(result, *stat)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Calls > result[j].Calls
+	})
+	return result
+}
@@ -0,0 +1,55 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOperationStats(t *testing.T) {
+	parser := NewParser()
+	call := newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 1}`, `{"jsonrpc": "2.0", "result": "0x", "id": 1}`)
+	call.Time = 10
+	failed := newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 2}`,
+		`{"jsonrpc": "2.0", "error": {"code": -32000, "message": "execution reverted"}, "id": 2}`)
+	failed.Time = 30
+	batch := newTestJSONRPCEntry(`[{"jsonrpc": "2.0", "method": "eth_blockNumber", "id": 3}, {"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 4}]`, `[]`)
+	batch.Time = 20
+	query := newTestGraphQLEntry(`{"operationName": "Me", "query": "query Me { me { id } }"}`, `{"data": {}}`)
+	rest := newTestResponseEntry("https://api.example.com/users", "application/json", `{}`)
+
+	stats := parser.GetOperationStats(newTestHAR(call, failed, batch, query, rest), nil)
+
+	require.Len(t, stats, 3)
+	assert.Equal(t, OperationStats{
+		Protocol:   ProtocolJSONRPC,
+		Method:     "POST",
+		Endpoint:   "https://rpc.example.com/",
+		Operation:  "eth_call",
+		Calls:      3,
+		Failures:   1,
+		AverageMS:  20,
+		MaxMS:      30,
+		RequestIDs: []string{"request_0", "request_1", "request_2"},
+	}, stats[0])
+	assert.Equal(t, "eth_blockNumber", stats[1].Operation)
+	assert.Equal(t, ProtocolGraphQL, stats[2].Protocol)
+	assert.Equal(t, "Me", stats[2].Operation)
+}
+
+func TestGetURLsAndMethodsListsOperations(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_call", "params": [], "id": 1}`, `{}`),
+		newTestJSONRPCEntry(`{"jsonrpc": "2.0", "method": "eth_chainId", "id": 2}`, `{}`),
+	)
+
+	urlMethods := parser.GetURLsAndMethods(archive, nil)
+
+	require.Len(t, urlMethods, 1)
+	assert.Equal(t, map[string][]string{
+		"eth_call":    {"request_0"},
+		"eth_chainId": {"request_1"},
+	}, urlMethods[0].Operations)
+}
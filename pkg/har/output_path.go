@@ -0,0 +1,39 @@
+package har
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveOutputPath returns the absolute path of a file written inside dir, relative paths
+// being relative to dir. Paths leaving dir, with ".." or through a symbolic link, are rejected
+// so a misbehaving agent cannot overwrite arbitrary files
+func ResolveOutputPath(dir, path string) (string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	// the parent directory is resolved rather than the file, which may not exist yet
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the directory of %s: %w", path, err)
+	}
+	resolved := filepath.Join(parent, filepath.Base(path))
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the output directory %s", path, root)
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,72 @@
+package har
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOutputDir returns an output directory with an exports subdirectory, its symbolic links resolved
+func newOutputDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "exports"), 0o755))
+	return dir
+}
+
+// assertOutsideOutputDir checks path is rejected by ResolveOutputPath
+func assertOutsideOutputDir(t *testing.T, dir, path string) {
+	t.Helper()
+	_, err := ResolveOutputPath(dir, path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside of the output directory")
+}
+
+func TestResolveOutputPathRelativeToDirectory(t *testing.T) {
+	dir := newOutputDir(t)
+
+	path, err := ResolveOutputPath(dir, "exports/failing.har")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "exports", "failing.har"), path)
+}
+
+func TestResolveOutputPathAcceptsAbsolutePathsInside(t *testing.T) {
+	dir := newOutputDir(t)
+
+	path, err := ResolveOutputPath(dir, filepath.Join(dir, "exports", "..", "capture.har"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "capture.har"), path)
+}
+
+func TestResolveOutputPathRejectsTraversal(t *testing.T) {
+	dir := newOutputDir(t)
+
+	assertOutsideOutputDir(t, filepath.Join(dir, "exports"), "../capture.har")
+	assertOutsideOutputDir(t, filepath.Join(dir, "exports"), "..")
+	assertOutsideOutputDir(t, dir, ".")
+}
+
+func TestResolveOutputPathRejectsAbsolutePathsOutside(t *testing.T) {
+	dir := newOutputDir(t)
+
+	assertOutsideOutputDir(t, filepath.Join(dir, "exports"), filepath.Join(dir, "capture.har"))
+}
+
+func TestResolveOutputPathRejectsSymbolicLinksLeavingDirectory(t *testing.T) {
+	dir := newOutputDir(t)
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "exports", "link")))
+
+	assertOutsideOutputDir(t, filepath.Join(dir, "exports"), "link/capture.har")
+}
+
+func TestResolveOutputPathRequiresExistingDirectory(t *testing.T) {
+	dir := newOutputDir(t)
+
+	_, err := ResolveOutputPath(dir, "missing/capture.har")
+	assert.Error(t, err)
+}
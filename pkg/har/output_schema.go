@@ -0,0 +1,186 @@
+package har
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OutputSchemaVersion is the version of the JSON output of the tools. It is bumped whenever a
+// field is removed, renamed or changes type. Adding fields does not bump it, consumers must
+// ignore the fields they do not know.
+const OutputSchemaVersion = 1
+
+// jsonSchemaDialect is the JSON Schema draft the output schemas follow
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// JSONSchema describes the JSON encoding of a value with a JSON Schema derived from its type.
+// Named structs are described once under $defs and referenced, so recursive types are
+// supported. Fields tagged omitempty are optional, nil slices, maps and pointers of the other
+// fields encode as null. Objects do not forbid additional properties, as later versions may
+// add some.
+func JSONSchema(value interface{}) map[string]interface{} {
+	generator := &schemaGenerator{defs: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+	schema := map[string]interface{}{"$schema": jsonSchemaDialect}
+	if value != nil {
+		for key, property := range generator.schema(reflect.TypeOf(value)) {
+			schema[key] = property
+		}
+	}
+	if len(generator.defs) > 0 {
+		schema["$defs"] = generator.defs
+	}
+	return schema
+}
+
+// schemaGenerator collects the definitions of the named structs met while describing a type
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+}
+
+// schema describes the JSON encoding of a type
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + g.define(t)}
+	}
+	// Interfaces hold any value
+	return map[string]interface{}{}
+}
+
+// define describes a named struct under $defs, returning its definition name
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	// Register the name first so that recursive fields reference the definition being built
+	g.names[t] = name
+	g.defs[name] = map[string]interface{}{}
+	g.defs[name] = g.object(t)
+	return name
+}
+
+// object describes a struct as an object, promoting the fields of embedded structs as
+// encoding/json does
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	g.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the properties of the fields of a struct, the fields of outer structs taking
+// precedence over the promoted ones
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldType)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schema(fieldType)
+		omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+		switch fieldType.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			if !omitEmpty {
+				property = nullable(property)
+			}
+		}
+		properties[name] = property
+		if !omitEmpty {
+			*required = append(*required, name)
+		}
+	}
+
+	for _, fieldType := range embedded {
+		promoted := make(map[string]interface{})
+		var promotedRequired []string
+		g.addFields(fieldType, promoted, &promotedRequired)
+		for _, name := range promotedRequired {
+			if _, ok := properties[name]; !ok {
+				*required = append(*required, name)
+			}
+		}
+		for name, property := range promoted {
+			if _, ok := properties[name]; !ok {
+				properties[name] = property
+			}
+		}
+	}
+}
+
+// nullable allows null in place of the value described
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if len(schema) == 0 {
+		return schema
+	}
+	if kind, ok := schema["type"].(string); ok {
+		schema["type"] = []string{kind, "null"}
+		return schema
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
@@ -0,0 +1,182 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaTestNode struct {
+	Name     string            `json:"name"`
+	Children []*schemaTestNode `json:"children,omitempty"`
+}
+
+type schemaTestBase struct {
+	ID    string `json:"id"`
+	Shade string `json:"shade"`
+}
+
+type schemaTestValue struct {
+	schemaTestBase
+	Shade    int               `json:"shade,omitempty"`
+	Count    int               `json:"count"`
+	Ratio    float64           `json:"ratio,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Seen     time.Time         `json:"seen"`
+	Raw      []byte            `json:"raw,omitempty"`
+	Root     *schemaTestNode   `json:"root"`
+	Any      interface{}       `json:"any,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema(&schemaTestValue{})
+
+	assert.Equal(t, jsonSchemaDialect, schema["$schema"])
+	assert.Equal(t, "#/$defs/schemaTestValue", schema["$ref"])
+	defs := schema["$defs"].(map[string]interface{})
+	value := defs["schemaTestValue"].(map[string]interface{})
+	properties := value["properties"].(map[string]interface{})
+
+	assert.ElementsMatch(t, []string{"id", "shade", "count", "ratio", "labels", "seen", "raw", "root", "any"}, slices.Collect(maps.Keys(properties)))
+	assert.ElementsMatch(t, []string{"count", "labels", "seen", "root", "id"}, value["required"], "omitempty fields and shadowed promoted fields are optional")
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["shade"], "outer fields shadow the promoted ones")
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["id"])
+	assert.Equal(t, map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": map[string]interface{}{"type": "string"}}, properties["labels"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, properties["seen"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "contentEncoding": "base64"}, properties["raw"])
+	assert.Equal(t, map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"$ref": "#/$defs/schemaTestNode"}, map[string]interface{}{"type": "null"}}}, properties["root"])
+	assert.Equal(t, map[string]interface{}{}, properties["any"])
+
+	node := defs["schemaTestNode"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/schemaTestNode"}}, node["properties"].(map[string]interface{})["children"], "recursive types reference their definition")
+}
+
+// newTestSchemaArchive returns a capture whose tool outputs exercise optional and nested fields
+func newTestSchemaArchive() *har.HAR {
+	return newTestHAR(
+		newTestResponseEntry("https://example.com/api", "application/json", `{"error":"boom","trace":"at com.example.Api.run(Api.java:12)"}`),
+		newTestEntry("POST", "http://example.com/login?user=alice", har.Header{Name: "Cookie", Value: "session=abc"}),
+	)
+}
+
+// assertMatchesSchema checks that the JSON encoding of a tool output is valid against its schema
+func assertMatchesSchema(t *testing.T, output interface{}) {
+	t.Helper()
+	data, err := json.Marshal(output)
+	require.NoError(t, err)
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	schema := JSONSchema(output)
+	assert.NoError(t, validateSchema(schema["$defs"].(map[string]interface{}), schema, decoded, "$"))
+}
+
+func TestJSONSchemaDescribesDebugLeaks(t *testing.T) {
+	assertMatchesSchema(t, NewParser().GetDebugLeaks(newTestSchemaArchive()))
+}
+
+func TestJSONSchemaDescribesAllFindings(t *testing.T) {
+	assertMatchesSchema(t, NewParser().GetAllFindings(newTestSchemaArchive()))
+}
+
+func TestJSONSchemaDescribesRequestDetails(t *testing.T) {
+	details, err := NewParser().GetRequestDetails(newTestSchemaArchive(), nil, nil, "request_0")
+	require.NoError(t, err)
+
+	assertMatchesSchema(t, details)
+}
+
+func TestJSONSchemaDescribesStats(t *testing.T) {
+	assertMatchesSchema(t, NewParser().GetHARStats(newTestSchemaArchive(), nil, 3))
+}
+
+func TestJSONSchemaDescribesURLs(t *testing.T) {
+	assertMatchesSchema(t, NewParser().GetURLsAndMethods(newTestSchemaArchive(), nil))
+}
+
+// validateSchema checks a decoded JSON value against the subset of JSON Schema JSONSchema produces
+func validateSchema(defs, schema map[string]interface{}, value interface{}, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		return validateSchema(defs, defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{}), value, at)
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		for _, alternative := range anyOf {
+			if validateSchema(defs, alternative.(map[string]interface{}), value, at) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s matches no alternative", at)
+	}
+
+	var kinds []string
+	switch kind := schema["type"].(type) {
+	case nil:
+		return nil
+	case string:
+		kinds = []string{kind}
+	case []string:
+		kinds = kind
+	}
+	switch value := value.(type) {
+	case nil:
+		if !slices.Contains(kinds, "null") {
+			return fmt.Errorf("%s is null, expected %v", at, kinds)
+		}
+	case bool:
+		if !slices.Contains(kinds, "boolean") {
+			return fmt.Errorf("%s is a boolean, expected %v", at, kinds)
+		}
+	case float64:
+		if !slices.Contains(kinds, "number") && !(slices.Contains(kinds, "integer") && value == float64(int64(value))) {
+			return fmt.Errorf("%s is a number, expected %v", at, kinds)
+		}
+	case string:
+		if !slices.Contains(kinds, "string") {
+			return fmt.Errorf("%s is a string, expected %v", at, kinds)
+		}
+	case []interface{}:
+		if !slices.Contains(kinds, "array") {
+			return fmt.Errorf("%s is an array, expected %v", at, kinds)
+		}
+		for i, item := range value {
+			if err := validateSchema(defs, schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if !slices.Contains(kinds, "object") {
+			return fmt.Errorf("%s is an object, expected %v", at, kinds)
+		}
+		if required, ok := schema["required"].([]string); ok {
+			for _, name := range required {
+				if _, ok := value[name]; !ok {
+					return fmt.Errorf("%s misses the required property %s", at, name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range value {
+			propertySchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				propertySchema, ok = schema["additionalProperties"].(map[string]interface{})
+			}
+			if !ok {
+				return fmt.Errorf("%s has the undescribed property %s", at, name)
+			}
+			if err := validateSchema(defs, propertySchema, property, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package har
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// PageLoadSummary describes one page load: a navigation and the requests it triggered
+type PageLoadSummary struct {
+	RequestID  string  `json:"request_id"`
+	URL        string  `json:"url"`
+	Requests   int     `json:"requests"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// ResourceTiming compares one resource between two page loads.
+// Offsets are relative to the start of the navigation of each page load.
+type ResourceTiming struct {
+	Method          string  `json:"method"`
+	Template        string  `json:"template"`
+	RequestIDA      string  `json:"request_id_a"`
+	RequestIDB      string  `json:"request_id_b"`
+	StartOffsetAMS  float64 `json:"start_offset_a_ms"`
+	StartOffsetBMS  float64 `json:"start_offset_b_ms"`
+	StartDeltaMS    float64 `json:"start_delta_ms"`
+	DurationAMS     float64 `json:"duration_a_ms"`
+	DurationBMS     float64 `json:"duration_b_ms"`
+	DurationDeltaMS float64 `json:"duration_delta_ms"`
+}
+
+// UnmatchedResource is a resource loaded by only one of the compared page loads
+type UnmatchedResource struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	Template  string `json:"template"`
+}
+
+// PageLoadComparison aligns the resources of two page loads
+type PageLoadComparison struct {
+	PageA           PageLoadSummary `json:"page_a"`
+	PageB           PageLoadSummary `json:"page_b"`
+	DurationDeltaMS float64         `json:"duration_delta_ms"`
+	// Resources are sorted by how much later they finished in B than in A
+	Resources []ResourceTiming    `json:"resources"`
+	OnlyInA   []UnmatchedResource `json:"only_in_a"`
+	OnlyInB   []UnmatchedResource `json:"only_in_b"`
+}
+
+// pageLoadEntry is an entry of a page load with its request ID
+type pageLoadEntry struct {
+	requestID string
+	entry     *har.Entry
+}
+
+// ComparePageLoads aligns the requests of two page loads by resource (same method
+// and URL template) and reports start-offset and duration deltas for each, to
+// explain why one navigation was slower than the other.
+//
+// A page load is identified by the request ID of its navigation (the HTML
+// document) and spans every request started until the next HTML navigation. Durations are
+// read from the timing index.
+func (p *Parser) ComparePageLoads(harData *har.HAR, index *TimingIndex, pageA, pageB string) (*PageLoadComparison, error) {
+	entriesA, err := p.getPageLoad(harData, pageA)
+	if err != nil {
+		return nil, err
+	}
+	entriesB, err := p.getPageLoad(harData, pageB)
+	if err != nil {
+		return nil, err
+	}
+
+	redaction := p.redaction()
+	comparison := &PageLoadComparison{
+		PageA:     summarizePageLoad(entriesA, index, redaction),
+		PageB:     summarizePageLoad(entriesB, index, redaction),
+		Resources: []ResourceTiming{},
+		OnlyInA:   []UnmatchedResource{},
+		OnlyInB:   []UnmatchedResource{},
+	}
+	comparison.DurationDeltaMS = comparison.PageB.DurationMS - comparison.PageA.DurationMS
+
+	startA := entriesA[0].entry.StartedDateTime
+	startB := entriesB[0].entry.StartedDateTime
+
+	// Repeated loads of the same resource are matched in order of occurrence
+	pendingB := make(map[string][]pageLoadEntry)
+	for _, loaded := range entriesB {
+		key := resourceKey(loaded.entry)
+		pendingB[key] = append(pendingB[key], loaded)
+	}
+
+	for _, loadedA := range entriesA {
+		key := resourceKey(loadedA.entry)
+		if len(pendingB[key]) == 0 {
+			comparison.OnlyInA = append(comparison.OnlyInA, newUnmatchedResource(loadedA, redaction))
+			continue
+		}
+		loadedB := pendingB[key][0]
+		pendingB[key] = pendingB[key][1:]
+
+		offsetA := milliseconds(loadedA.entry.StartedDateTime.Sub(startA))
+		offsetB := milliseconds(loadedB.entry.StartedDateTime.Sub(startB))
+		comparison.Resources = append(comparison.Resources, ResourceTiming{
+			Method:          loadedA.entry.Request.Method,
+			Template:        redaction.text(templateURL(loadedA.entry.Request.URL)),
+			RequestIDA:      loadedA.requestID,
+			RequestIDB:      loadedB.requestID,
+			StartOffsetAMS:  offsetA,
+			StartOffsetBMS:  offsetB,
+			StartDeltaMS:    offsetB - offsetA,
+			DurationAMS:     index.Time(loadedA.entry),
+			DurationBMS:     index.Time(loadedB.entry),
+			DurationDeltaMS: index.Time(loadedB.entry) - index.Time(loadedA.entry),
+		})
+	}
+
+	for _, loadedB := range entriesB {
+		for _, pending := range pendingB[resourceKey(loadedB.entry)] {
+			if pending.requestID == loadedB.requestID {
+				comparison.OnlyInB = append(comparison.OnlyInB, newUnmatchedResource(loadedB, redaction))
+			}
+		}
+	}
+
+	sort.SliceStable(comparison.Resources, func(i, j int) bool {
+		resourceI, resourceJ := comparison.Resources[i], comparison.Resources[j]
+		return resourceI.StartDeltaMS+resourceI.DurationDeltaMS > resourceJ.StartDeltaMS+resourceJ.DurationDeltaMS
+	})
+
+	return comparison, nil
+}
+
+// getPageLoad returns the navigation entry and the entries started after it until the next navigation
+func (p *Parser) getPageLoad(harData *har.HAR, requestID string) ([]pageLoadEntry, error) {
+	navigation, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if navigation.Request == nil {
+		return nil, fmt.Errorf("request %s has no request data", requestID)
+	}
+
+	var next time.Time
+	for _, entry := range harData.Log.Entries {
+		if entry == navigation || !isNavigation(entry) || !entry.StartedDateTime.After(navigation.StartedDateTime) {
+			continue
+		}
+		if next.IsZero() || entry.StartedDateTime.Before(next) {
+			next = entry.StartedDateTime
+		}
+	}
+
+	loaded := []pageLoadEntry{{requestID: requestID, entry: navigation}}
+	for i, entry := range harData.Log.Entries {
+		if entry == navigation || entry.Request == nil {
+			continue
+		}
+		if entry.StartedDateTime.Before(navigation.StartedDateTime) {
+			continue
+		}
+		if !next.IsZero() && !entry.StartedDateTime.Before(next) {
+			continue
+		}
+		loaded = append(loaded, pageLoadEntry{requestID: formatRequestID(i), entry: entry})
+	}
+
+	sort.SliceStable(loaded, func(i, j int) bool {
+		return loaded[i].entry.StartedDateTime.Before(loaded[j].entry.StartedDateTime)
+	})
+
+	return loaded, nil
+}
+
+// isNavigation reports whether the entry looks like a top-level HTML document load
+func isNavigation(entry *har.Entry) bool {
+	return entry.Request != nil && entry.Request.Method == "GET" && mimeTypeFamily(responseMimeType(entry.Response)) == familyHTML
+}
+
+// summarizePageLoad computes the size and total duration of a page load
+func summarizePageLoad(entries []pageLoadEntry, index *TimingIndex, redaction *redactor) PageLoadSummary {
+	navigation := entries[0]
+	start := navigation.entry.StartedDateTime
+	var end time.Time
+	for _, loaded := range entries {
+		finished := index.End(loaded.entry)
+		if finished.After(end) {
+			end = finished
+		}
+	}
+
+	return PageLoadSummary{
+		RequestID:  navigation.requestID,
+		URL:        redaction.text(navigation.entry.Request.URL),
+		Requests:   len(entries),
+		DurationMS: milliseconds(end.Sub(start)),
+	}
+}
+
+// resourceKey identifies the same resource across page loads
+func resourceKey(entry *har.Entry) string {
+	return entry.Request.Method + " " + templateURL(entry.Request.URL)
+}
+
+// newUnmatchedResource describes a resource present in a single page load
+func newUnmatchedResource(loaded pageLoadEntry, redaction *redactor) UnmatchedResource {
+	return UnmatchedResource{
+		RequestID: loaded.requestID,
+		Method:    loaded.entry.Request.Method,
+		Template:  redaction.text(templateURL(loaded.entry.Request.URL)),
+	}
+}
@@ -0,0 +1,91 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPageEntry builds a GET entry started at the given offset, with an HTML response for documents.
+func newTestPageEntry(url string, offset time.Duration, durationMS int64, document bool) *har.Entry {
+	entry := newTestEntry("GET", url)
+	entry.StartedDateTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(offset)
+	entry.Time = durationMS
+	if document {
+		entry.Response.Content.MimeType = "text/html"
+	}
+	return entry
+}
+
+// createTwoPageLoadsHAR creates a HAR where the second load of the same page is slower.
+func createTwoPageLoadsHAR() *har.HAR {
+	return newTestHAR(
+		newTestPageEntry("https://example.com/products/1", 0, 100, true),
+		newTestPageEntry("https://example.com/app.js", 110*time.Millisecond, 50, false),
+		newTestPageEntry("https://example.com/api/products/1", 120*time.Millisecond, 80, false),
+		newTestPageEntry("https://example.com/products/2", 10*time.Second, 100, true),
+		newTestPageEntry("https://example.com/app.js", 10*time.Second+110*time.Millisecond, 50, false),
+		newTestPageEntry("https://example.com/api/products/2", 10*time.Second+300*time.Millisecond, 400, false),
+		newTestPageEntry("https://example.com/ads.js", 10*time.Second+310*time.Millisecond, 20, false),
+	)
+}
+
+func TestComparePageLoadsAlignsResources(t *testing.T) {
+	parser := NewParser()
+
+	comparison, err := parser.ComparePageLoads(createTwoPageLoadsHAR(), nil, "request_0", "request_3")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, comparison.PageA.Requests)
+	assert.Equal(t, 4, comparison.PageB.Requests)
+	assert.Equal(t, float64(200), comparison.PageA.DurationMS)
+	assert.Equal(t, float64(700), comparison.PageB.DurationMS)
+	assert.Equal(t, float64(500), comparison.DurationDeltaMS)
+
+	require.Len(t, comparison.Resources, 3)
+	slowest := comparison.Resources[0]
+	assert.Equal(t, "https://example.com/api/products/{id}", slowest.Template)
+	assert.Equal(t, float64(180), slowest.StartDeltaMS)
+	assert.Equal(t, float64(320), slowest.DurationDeltaMS)
+}
+
+func TestComparePageLoadsUnmatchedResources(t *testing.T) {
+	parser := NewParser()
+
+	comparison, err := parser.ComparePageLoads(createTwoPageLoadsHAR(), nil, "request_0", "request_3")
+	require.NoError(t, err)
+
+	assert.Empty(t, comparison.OnlyInA)
+	require.Len(t, comparison.OnlyInB, 1)
+	assert.Equal(t, "request_6", comparison.OnlyInB[0].RequestID)
+}
+
+func TestComparePageLoadsInvalidID(t *testing.T) {
+	parser := NewParser()
+
+	comparison, err := parser.ComparePageLoads(createTwoPageLoadsHAR(), nil, "request_0", "bogus")
+
+	assert.Error(t, err)
+	assert.Nil(t, comparison)
+}
+
+func TestComparePageLoadsRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(
+		newTestPageEntry("https://example.com/?key=sk_live_abc", 0, 100, true),
+		newTestPageEntry("https://example.com/?key=sk_live_abc", 10*time.Second, 100, true),
+		newTestPageEntry("https://example.com/share/sk_live_abc", 10*time.Second+10*time.Millisecond, 50, false),
+	)
+
+	comparison, err := parser.ComparePageLoads(archive, nil, "request_0", "request_1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/?key=[REDACTED]", comparison.PageA.URL)
+	assert.Equal(t, "https://example.com/?key=[REDACTED]", comparison.PageB.URL)
+	require.Len(t, comparison.OnlyInB, 1)
+	assert.Equal(t, "https://example.com/share/[REDACTED]", comparison.OnlyInB[0].Template)
+}
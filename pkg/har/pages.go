@@ -0,0 +1,162 @@
+package har
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// pageTimingUnavailable is the value HAR files use for timings that do not apply
+const pageTimingUnavailable = -1
+
+// Page is a page load of a browser capture, entries refer to it by ID through their pageref
+type Page struct {
+	ID              string       `json:"id"`
+	Title           string       `json:"title"`
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	PageTimings     *PageTimings `json:"pageTimings,omitempty"`
+	Comment         string       `json:"comment,omitempty"`
+}
+
+// PageTimings are the milliseconds from the start of the page load to its events, nil when
+// not available
+type PageTimings struct {
+	OnContentLoad *float64 `json:"onContentLoad,omitempty"`
+	OnLoad        *float64 `json:"onLoad,omitempty"`
+}
+
+// PageIndex holds the pages of a HAR file and the page of each entry, which martian's model
+// leaves out. It is built when parsing and available from the parse report.
+type PageIndex struct {
+	pages []Page
+	// refs are the pageref of the entries holding one
+	refs map[*har.Entry]string
+}
+
+// PageSummary describes a page load and its entries
+type PageSummary struct {
+	ID              string   `json:"id"`
+	Title           string   `json:"title"`
+	StartedDateTime string   `json:"started_datetime"`
+	OnContentLoadMS *float64 `json:"on_content_load_ms,omitempty"`
+	OnLoadMS        *float64 `json:"on_load_ms,omitempty"`
+	Entries         int      `json:"entries"`
+	FirstRequestID  string   `json:"first_request_id,omitempty"`
+	LastRequestID   string   `json:"last_request_id,omitempty"`
+	// Bytes is the total size of the response bodies
+	Bytes int64 `json:"bytes"`
+	// Errors counts the responses with a 4xx or 5xx status
+	Errors int `json:"errors"`
+}
+
+// PageList lists the pages of a HAR file
+type PageList struct {
+	Pages []PageSummary `json:"pages"`
+	// UnassignedEntries counts the entries without a pageref, or referring to an unknown page
+	UnassignedEntries int `json:"unassigned_entries"`
+}
+
+// Pages returns the pages of the HAR file, in file order
+func (idx *PageIndex) Pages() []Page {
+	if idx == nil {
+		return nil
+	}
+	return idx.pages
+}
+
+// PageRef returns the ID of the page the entry belongs to, empty when it has none
+func (idx *PageIndex) PageRef(entry *har.Entry) string {
+	if idx == nil {
+		return ""
+	}
+	return idx.refs[entry]
+}
+
+// Scope returns a copy of the HAR file where the entries outside the page are replaced by
+// entries without a request, which tools skip, so that request IDs still refer to the whole file
+func (idx *PageIndex) Scope(harData *har.HAR, pageID string) (*har.HAR, error) {
+	if !idx.hasPage(pageID) {
+		return nil, fmt.Errorf("page %q not found", pageID)
+	}
+
+	log := *harData.Log
+	log.Entries = make([]*har.Entry, len(harData.Log.Entries))
+	for i, entry := range harData.Log.Entries {
+		if idx.PageRef(entry) == pageID {
+			log.Entries[i] = entry
+		} else {
+			log.Entries[i] = &har.Entry{}
+		}
+	}
+	return &har.HAR{Log: &log}, nil
+}
+
+// hasPage reports whether the HAR file declares the page
+func (idx *PageIndex) hasPage(pageID string) bool {
+	for _, page := range idx.Pages() {
+		if page.ID == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPages summarizes the page loads of a browser capture with the entries referring to them
+func (p *Parser) ListPages(harData *har.HAR, index *PageIndex) *PageList {
+	redaction := p.redaction()
+	list := &PageList{Pages: []PageSummary{}}
+	positions := make(map[string]int)
+	for _, page := range index.Pages() {
+		summary := PageSummary{
+			ID:              page.ID,
+			Title:           redaction.text(page.Title),
+			StartedDateTime: p.formatTime(page.StartedDateTime, time.RFC3339Nano),
+		}
+		if page.PageTimings != nil {
+			summary.OnContentLoadMS = page.PageTimings.OnContentLoad
+			summary.OnLoadMS = page.PageTimings.OnLoad
+		}
+		positions[page.ID] = len(list.Pages)
+		list.Pages = append(list.Pages, summary)
+	}
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		position, ok := positions[index.PageRef(entry)]
+		if !ok {
+			list.UnassignedEntries++
+			continue
+		}
+		summary := &list.Pages[position]
+		summary.Entries++
+		if summary.FirstRequestID == "" {
+			summary.FirstRequestID = formatRequestID(i)
+		}
+		summary.LastRequestID = formatRequestID(i)
+		if entry.Response != nil {
+			if entry.Response.BodySize > 0 {
+				summary.Bytes += entry.Response.BodySize
+			}
+			if entry.Response.Status >= 400 {
+				summary.Errors++
+			}
+		}
+	}
+
+	return list
+}
+
+// normalizePageTimings clears the timings marked as unavailable
+func normalizePageTimings(timings *PageTimings) {
+	if timings == nil {
+		return
+	}
+	for _, timing := range []**float64{&timings.OnContentLoad, &timings.OnLoad} {
+		if *timing != nil && **timing <= pageTimingUnavailable {
+			*timing = nil
+		}
+	}
+}
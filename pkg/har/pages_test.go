@@ -0,0 +1,155 @@
+package har
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPagesDocument is a browser capture of two page loads, with an entry outside any page
+const testPagesDocument = `{
+	"log": {
+		"version": "1.2",
+		"creator": {"name": "browser", "version": "1"},
+		"pages": [
+			{"id": "page_1", "title": "Home", "startedDateTime": "2023-01-01T00:00:00Z", "pageTimings": {"onContentLoad": 120.5, "onLoad": 300}},
+			{"id": "page_2", "title": "Checkout", "startedDateTime": "2023-01-01T00:00:10Z", "pageTimings": {"onContentLoad": -1, "onLoad": 250}},
+			{"title": "No ID"}
+		],
+		"entries": [
+			{"pageref": "page_1", "startedDateTime": "2023-01-01T00:00:00Z", "time": 12, "request": {"method": "GET", "url": "https://example.com/", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 5, "mimeType": "text/html", "text": "<html"}, "redirectURL": "", "headersSize": -1, "bodySize": 5}},
+			{"pageref": "page_1", "startedDateTime": "2023-01-01T00:00:01Z", "time": 3.5, "request": {"method": "GET", "url": "https://example.com/app.js", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "response": {"status": 404, "statusText": "Not Found", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/plain"}, "redirectURL": "", "headersSize": -1, "bodySize": 0}},
+			{"startedDateTime": "2023-01-01T00:00:05Z", "time": 1, "request": {"method": "POST", "url": "https://example.com/beacon", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0}},
+			{"pageref": "page_2", "startedDateTime": "2023-01-01T00:00:10Z", "time": 20, "request": {"method": "GET", "url": "https://example.com/checkout", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0}}
+		]
+	}
+}`
+
+func TestParseKeepsPagesAndPageRefs(t *testing.T) {
+	parser := NewParser()
+
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Pages)
+	assert.Contains(t, report.Warnings, ParseWarning{Message: "ignored log.pages[2]: missing id"})
+	index := report.PageIndex()
+	pages := index.Pages()
+	require.Len(t, pages, 2)
+	assert.Equal(t, "Home", pages[0].Title)
+	assert.Nil(t, pages[1].PageTimings.OnContentLoad)
+	assert.Equal(t, "page_1", index.PageRef(harData.Log.Entries[1]))
+	assert.Equal(t, "", index.PageRef(harData.Log.Entries[2]))
+	assert.Equal(t, "page_2", index.PageRef(harData.Log.Entries[3]))
+}
+
+func TestParseLenientlyKeepsPages(t *testing.T) {
+	parser := NewParser()
+	parser.SetLenient(true)
+	document := strings.Replace(testPagesDocument, `"pages": [`, `"pages": [{"id": "page_0", "pageTimings": {"onLoad": 1,}},`, 1)
+
+	harData, report, err := parser.ParseWithReport(strings.NewReader(document))
+
+	require.NoError(t, err)
+	assert.Equal(t, ParseModeRecovered, report.Mode)
+	assert.Equal(t, 3, report.Pages)
+	assert.Equal(t, "page_2", report.PageIndex().PageRef(harData.Log.Entries[3]))
+}
+
+func TestListPages(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+
+	list := parser.ListPages(harData, report.PageIndex())
+
+	onContentLoad, onLoad := 120.5, 300.0
+	assert.Equal(t, &PageList{
+		Pages: []PageSummary{
+			{
+				ID:              "page_1",
+				Title:           "Home",
+				StartedDateTime: "2023-01-01T00:00:00Z",
+				OnContentLoadMS: &onContentLoad,
+				OnLoadMS:        &onLoad,
+				Entries:         2,
+				FirstRequestID:  "request_0",
+				LastRequestID:   "request_1",
+				Bytes:           5,
+				Errors:          1,
+			},
+			{
+				ID:              "page_2",
+				Title:           "Checkout",
+				StartedDateTime: "2023-01-01T00:00:10Z",
+				OnLoadMS:        list.Pages[1].OnLoadMS,
+				Entries:         1,
+				FirstRequestID:  "request_3",
+				LastRequestID:   "request_3",
+			},
+		},
+		UnassignedEntries: 1,
+	}, list)
+	assert.Equal(t, 250.0, *list.Pages[1].OnLoadMS)
+}
+
+func TestPageIndexScopeKeepsRequestIDs(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+
+	scoped, err := report.PageIndex().Scope(harData, "page_2")
+	require.NoError(t, err)
+	matches, err := parser.FilterEntries(scoped, nil, nil, nil, EntryFilter{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "request_3", matches[0].RequestID)
+	assert.Len(t, harData.Log.Entries, 4)
+	assert.NotNil(t, harData.Log.Entries[0].Request)
+
+	_, err = report.PageIndex().Scope(harData, "page_9")
+	assert.EqualError(t, err, `page "page_9" not found`)
+}
+
+func TestFlexibleHARToStandardHARWithPages(t *testing.T) {
+	var flexible FlexibleHAR
+	require.NoError(t, json.Unmarshal([]byte(strings.Replace(testPagesDocument, `{"title": "No ID"}`, `{"id": "page_3"}`, 1)), &flexible))
+
+	harData, index := flexible.ToStandardHARWithPages()
+
+	assert.Len(t, index.Pages(), 3)
+	assert.Nil(t, index.Pages()[1].PageTimings.OnContentLoad)
+	assert.Equal(t, "page_1", index.PageRef(harData.Log.Entries[0]))
+	assert.Equal(t, "page_2", index.PageRef(harData.Log.Entries[3]))
+}
+
+func TestListPagesIgnoresExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+	harData, err = parser.Exclude(harData, []string{"request_1", "request_2"})
+	require.NoError(t, err)
+
+	list := parser.ListPages(harData, report.PageIndex())
+
+	assert.Equal(t, 0, list.UnassignedEntries)
+	assert.Equal(t, 1, list.Pages[0].Entries)
+	assert.Equal(t, 0, list.Pages[0].Errors)
+}
+
+func TestListPagesRedactsTitles(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`Check\w+`}}))
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+
+	list := parser.ListPages(harData, report.PageIndex())
+
+	assert.Equal(t, "Home", list.Pages[0].Title)
+	assert.Equal(t, "[REDACTED]", list.Pages[1].Title)
+}
@@ -2,32 +2,65 @@
 package har
 
 import (
+	"bufio"
 	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/martian/har"
 )
 
 // Parser handles HAR file parsing from various sources
-type Parser struct{}
+type Parser struct {
+	// location renders timestamps in a fixed time zone, nil keeps the offsets of the capture
+	location *time.Location
+	// lenient recovers malformed JSON instead of failing
+	lenient bool
+	// scoring ranks the composite findings
+	scoring ScoringPolicy
+	// redactor applies the redaction policy, nil applies the default one
+	redactor    *redactor
+	redactionMu sync.RWMutex
+	// fetch authenticates the requests fetching HAR files from HTTP URLs
+	fetch FetchOptions
+	// fetchLimits bound the fetches of HAR files from HTTP URLs and object storage
+	fetchLimits FetchLimits
+	// translate translates the text of the reports, nil keeps them in English
+	translate func(string) string
+}
 
 // NewParser creates a new HAR parser
 func NewParser() *Parser {
 	return &Parser{}
 }
 
+// SetTimeZone renders every timestamp in the given time zone, so captures from testers
+// in different regions read consistently. A nil location keeps the offsets of the capture.
+func (p *Parser) SetTimeZone(location *time.Location) {
+	p.location = location
+}
+
+// formatTime formats t in the configured time zone
+func (p *Parser) formatTime(t time.Time, layout string) string {
+	if p.location != nil {
+		t = t.In(p.location)
+	}
+	return t.Format(layout)
+}
+
 // ParseFromFile parses a HAR file from disk
 func (p *Parser) ParseFromFile(path string) (*har.HAR, error) {
-	file, err := os.Open(path)
+	file, err := openFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open HAR file: %w", err)
+		return nil, err
 	}
 	defer file.Close() //nolint:errcheck
 
@@ -36,44 +69,173 @@ func (p *Parser) ParseFromFile(path string) (*har.HAR, error) {
 
 // ParseFromURL parses a HAR file from an HTTP URL
 func (p *Parser) ParseFromURL(harURL string) (*har.HAR, error) {
-	resp, err := http.Get(harURL)
+	return p.ParseFromURLContext(context.Background(), harURL)
+}
+
+// ParseFromURLContext parses a HAR file from an HTTP URL like ParseFromURL, abandoning the fetch
+// when the context is done
+func (p *Parser) ParseFromURLContext(ctx context.Context, harURL string) (*har.HAR, error) {
+	u, err := url.Parse(harURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch HAR from URL: %w", err)
 	}
-	defer resp.Body.Close() //nolint:errcheck
+	body, err := p.openRemote(ctx, u, p.fetch)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close() //nolint:errcheck
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch HAR: HTTP %d", resp.StatusCode)
+	harData, _, err := p.parseRemote(body)
+	return harData, err
+}
+
+// openRemote fetches a HAR file from an HTTP URL or an object storage URI within the fetch
+// limits and returns its body, the timeout covering the whole read of the body
+func (p *Parser) openRemote(ctx context.Context, u *url.URL, options FetchOptions) (io.ReadCloser, error) {
+	limits := p.fetchLimits.withDefaults()
+	ctx, cancel := limits.context(ctx)
+	var body *limitedBody
+	var err error
+	if isObjectStorageURI(u) {
+		body, err = openObject(ctx, u, options, limits)
+	} else {
+		body, err = openURL(ctx, u.String(), options, limits)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
 	}
+	body.cancel = cancel
+	return body, nil
+}
 
-	return p.Parse(resp.Body)
+// openFile opens a HAR file on disk
+func openFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HAR file: %w", err)
+	}
+	return file, nil
 }
 
 // Parse parses a HAR file from the given reader
 func (p *Parser) Parse(r io.Reader) (*har.HAR, error) {
-	// Read all data so we can try multiple parsing approaches
-	data, err := io.ReadAll(r)
+	harData, _, err := p.ParseWithReport(r)
+	return harData, err
+}
+
+// ParseWithReport parses a HAR file from the given reader and reports how it was parsed:
+// detected format and version, parse mode, dropped entries and the deviations from the
+// specification that were tolerated. Gzip, zstd and zip compressed files are decompressed.
+// Entries are decoded as they are read so that memory use stays close to the size of the
+// parsed entries, except in lenient mode where the whole file is buffered to be repaired.
+// Entry logs holding one HAR entry per line are detected and assembled into an archive.
+func (p *Parser) ParseWithReport(r io.Reader) (*har.HAR, *ParseReport, error) {
+	return p.parse(r, "", 0)
+}
+
+// parse parses a file of the given format, detected from its content when empty. A positive
+// maxBytes caps the size of the data once decompressed, failing with a FetchLimitError beyond.
+func (p *Parser) parse(r io.Reader, format string, maxBytes int64) (*har.HAR, *ParseReport, error) {
+	start := time.Now()
+	reader, containers, err := decompress(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read HAR data: %w", err)
+		return nil, nil, err
 	}
+	if maxBytes > 0 {
+		reader = &limitedBody{ctx: context.Background(), body: reader, maxBytes: maxBytes}
+	}
+	defer reader.Close() //nolint:errcheck
 
-	// First try standard parsing
-	var harData har.HAR
-	decoder := json.NewDecoder(bytes.NewReader(data))
-	if err := decoder.Decode(&harData); err == nil {
-		// Standard parsing succeeded
-		return &harData, nil
+	counter := &countingReader{reader: reader, hash: sha256.New()}
+	report := &ParseReport{
+		Containers: containers,
+		Warnings:   []ParseWarning{},
 	}
 
-	// If standard parsing failed, try flexible parsing
-	var flexibleHAR FlexibleHAR
-	decoder = json.NewDecoder(bytes.NewReader(data))
-	if err := decoder.Decode(&flexibleHAR); err != nil {
-		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	var harData *har.HAR
+	var transferSizes []*float64
+	if p.lenient {
+		// Read all data so malformed JSON can be repaired
+		data, err := io.ReadAll(counter)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read HAR data: %w", err)
+		}
+		if format == "" {
+			format = detectFormat(data)
+		}
+		report.Format = format
+		if format == FormatNDJSON {
+			harData, transferSizes, err = p.decodeNDJSON(bytes.NewReader(data), report)
+		} else {
+			harData, transferSizes, err = p.decode(data, report)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		buffered := bufio.NewReaderSize(counter, formatDetectionBytes)
+		if format == "" {
+			// A short read leaves the whole file in the buffer, so read errors surface when decoding
+			prefix, _ := buffered.Peek(formatDetectionBytes)
+			format = detectFormat(prefix)
+		}
+		report.Format = format
+		if format == FormatNDJSON {
+			harData, transferSizes, err = p.decodeNDJSON(buffered, report)
+		} else {
+			harData, transferSizes, err = p.decodeStream(buffered, report)
+		}
+		if err != nil {
+			if counter.err != nil {
+				return nil, nil, fmt.Errorf("failed to read HAR data: %w", counter.err)
+			}
+			return nil, nil, err
+		}
 	}
+	report.Bytes = counter.count
+	report.SHA256 = hex.EncodeToString(counter.hash.Sum(nil))
+	normalize(harData, transferSizes, report)
+	report.lookupIndex = NewLookupIndex(harData)
+	report.ParseDurationMS = time.Since(start).Milliseconds()
+
+	return harData, report, nil
+}
 
-	// Convert flexible HAR to standard HAR
-	return flexibleHAR.ToStandardHAR(), nil
+// countingReader counts and hashes the bytes read and remembers the read error, to tell it apart
+// from decoding errors
+type countingReader struct {
+	reader io.Reader
+	count  int
+	hash   hash.Hash
+	err    error
+}
+
+// Read implements io.Reader
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.reader.Read(b)
+	c.count += n
+	c.hash.Write(b[:n])
+	if err != nil && err != io.EOF {
+		c.err = err
+	}
+	return n, err
+}
+
+// decode decodes the HAR document, falling back, in lenient mode, to recovering malformed JSON.
+// It returns the _transferSize of the responses aligned with the entries.
+func (p *Parser) decode(data []byte, report *ParseReport) (*har.HAR, []*float64, error) {
+	harData, transferSizes, err := p.decodeDocument(data, report)
+	if err == nil || !p.lenient {
+		return harData, transferSizes, err
+	}
+
+	return p.decodeLeniently(data, report)
+}
+
+// decodeDocument decodes a single HAR document, trying standard then flexible parsing
+func (p *Parser) decodeDocument(data []byte, report *ParseReport) (*har.HAR, []*float64, error) {
+	return p.decodeStream(bytes.NewReader(data), report)
 }
 
 // URLMethodEntry represents a URL and method combination with associated request IDs
@@ -81,52 +243,68 @@ type URLMethodEntry struct {
 	URL        string   `json:"url"`
 	Method     string   `json:"method"`
 	RequestIDs []string `json:"request_ids"`
+	// Operations are the request IDs by GraphQL operation or JSON-RPC method, for RPC endpoints
+	Operations map[string][]string `json:"operations,omitempty"`
 }
 
-// GetURLsAndMethods returns all unique URL and method combinations from the HAR
-func (p *Parser) GetURLsAndMethods(harData *har.HAR) []URLMethodEntry {
-	// Map to store unique URL+Method combinations and their request IDs
-	urlMethodMap := make(map[string]*URLMethodEntry)
-
-	for i, entry := range harData.Log.Entries {
-		if entry.Request == nil {
-			continue
-		}
-
-		key := fmt.Sprintf("%s|%s", entry.Request.URL, entry.Request.Method)
-		requestID := fmt.Sprintf("request_%d", i)
-
-		if existing, ok := urlMethodMap[key]; ok {
-			existing.RequestIDs = append(existing.RequestIDs, requestID)
-		} else {
-			urlMethodMap[key] = &URLMethodEntry{
-				URL:        entry.Request.URL,
-				Method:     entry.Request.Method,
-				RequestIDs: []string{requestID},
-			}
-		}
+// GetURLsAndMethods returns all unique URL and method combinations from the HAR, in the order
+// they were first requested. The lookup index of the file saves building one on every call.
+func (p *Parser) GetURLsAndMethods(harData *har.HAR, index *LookupIndex) []URLMethodEntry {
+	if !index.covers(harData) {
+		index = NewLookupIndex(harData)
 	}
 
-	// Convert map to slice
 	var result []URLMethodEntry
-	for _, entry := range urlMethodMap {
-		result = append(result, *entry)
+	for _, key := range index.urlMethods {
+		urlMethod := URLMethodEntry{URL: key.url, Method: key.method}
+		for _, i := range index.byURLMethod[key] {
+			entry := harData.Log.Entries[i]
+			// Entries outside the page of a scoped file have no request
+			if entry == nil || entry.Request == nil {
+				continue
+			}
+			requestID := formatRequestID(i)
+			urlMethod.RequestIDs = append(urlMethod.RequestIDs, requestID)
+
+			// Calls to a single RPC endpoint are told apart by their operations
+			if _, operations := requestOperations(entry.Request); len(operations) > 0 {
+				if urlMethod.Operations == nil {
+					urlMethod.Operations = make(map[string][]string)
+				}
+				for _, operation := range operations {
+					urlMethod.Operations[operation] = append(urlMethod.Operations[operation], requestID)
+				}
+			}
+		}
+		if len(urlMethod.RequestIDs) > 0 {
+			result = append(result, urlMethod)
+		}
 	}
 
 	return result
 }
 
-// GetRequestIDsForURLMethod returns all request IDs for a specific URL and method
-func (p *Parser) GetRequestIDsForURLMethod(harData *har.HAR, targetURL, method string) []string {
+// GetRequestIDsForURLMethod returns all request IDs for a specific URL and method, looked up in
+// the index of the file when it covers it
+func (p *Parser) GetRequestIDsForURLMethod(harData *har.HAR, index *LookupIndex, targetURL, method string) []string {
 	var requestIDs []string
 
+	if index.covers(harData) {
+		for _, i := range index.byURLMethod[urlMethod{url: targetURL, method: method}] {
+			if entry := harData.Log.Entries[i]; entry != nil && entry.Request != nil {
+				requestIDs = append(requestIDs, formatRequestID(i))
+			}
+		}
+		return requestIDs
+	}
+
 	for i, entry := range harData.Log.Entries {
 		if entry.Request == nil {
 			continue
 		}
 
 		if entry.Request.URL == targetURL && entry.Request.Method == method {
-			requestID := fmt.Sprintf("request_%d", i)
+			requestID := formatRequestID(i)
 			requestIDs = append(requestIDs, requestID)
 		}
 	}
@@ -140,12 +318,19 @@ type RequestDetails struct {
 	StartedDateTime string        `json:"started_datetime"`
 	Time            float64       `json:"time"`
 	Request         *RequestInfo  `json:"request"`
-	Response        *har.Response `json:"response"`
+	Response        *ResponseInfo `json:"response"`
 	Cache           *har.Cache    `json:"cache,omitempty"`
-	Timings         *har.Timings  `json:"timings,omitempty"`
+	Timings         *Timings      `json:"timings,omitempty"`
 	ServerIPAddress string        `json:"serverIPAddress,omitempty"`
 	Connection      string        `json:"connection,omitempty"`
 	Comment         string        `json:"comment,omitempty"`
+	// CustomFields are the resource type, priority, cache and initiator Chrome DevTools records
+	CustomFields *CustomFields `json:"custom_fields,omitempty"`
+
+	// SniffedContentType identifies binary responses declared with a generic MIME type
+	SniffedContentType string `json:"sniffed_content_type,omitempty"`
+	// OperationErrors are the errors a GraphQL or JSON-RPC response reports, often with a 200 status
+	OperationErrors []string `json:"operation_errors,omitempty"`
 }
 
 // RequestInfo is like har.Request but with redacted auth headers
@@ -161,41 +346,111 @@ type RequestInfo struct {
 	BodySize    int64             `json:"bodySize"`
 }
 
-// GetRequestDetails returns the full details of a request by ID with auth headers redacted
-func (p *Parser) GetRequestDetails(harData *har.HAR, requestID string) (*RequestDetails, error) {
+// ResponseInfo is like har.Response but with redacted auth headers and its content decoded
+type ResponseInfo struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []har.Cookie `json:"cookies"`
+	Headers     []har.Header `json:"headers"`
+	Content     *ContentInfo `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int64        `json:"headersSize"`
+	BodySize    int64        `json:"bodySize"`
+}
+
+// ContentInfo is like har.Content but with its text decoded to UTF-8 by DecodeBody.
+// Bodies that remain binary are base64 encoded.
+type ContentInfo struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	// DecodedContentEncodings are the content codings removed from the recorded body
+	DecodedContentEncodings []string `json:"decodedContentEncodings,omitempty"`
+	// DecodedCharset is the character set the recorded body was converted from
+	DecodedCharset string `json:"decodedCharset,omitempty"`
+	// DecodeError tells why the recorded body could not be fully decoded
+	DecodeError string `json:"decodeError,omitempty"`
+}
+
+// formatRequestID builds the request ID of the entry at the given index
+func formatRequestID(index int) string {
+	return fmt.Sprintf("request_%d", index)
+}
+
+// getEntryIndex resolves a request ID to the index of its entry in the HAR
+func (p *Parser) getEntryIndex(harData *har.HAR, requestID string) (int, error) {
 	// Extract index from request ID
 	var index int
 	if _, err := fmt.Sscanf(requestID, "request_%d", &index); err != nil {
-		return nil, fmt.Errorf("invalid request ID format: %s", requestID)
+		return 0, fmt.Errorf("invalid request ID format: %s", requestID)
 	}
 
 	if index < 0 || index >= len(harData.Log.Entries) {
-		return nil, fmt.Errorf("request ID out of range: %s", requestID)
+		return 0, fmt.Errorf("request ID out of range: %s", requestID)
 	}
 
+	return index, nil
+}
+
+// getEntry resolves a request ID to its entry in the HAR
+func (p *Parser) getEntry(harData *har.HAR, requestID string) (*har.Entry, error) {
+	index, err := p.getEntryIndex(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
 	entry := harData.Log.Entries[index]
+	if entry == nil || entry.Request == nil {
+		return nil, fmt.Errorf("request ID excluded from the analysis: %s", requestID)
+	}
+	return entry, nil
+}
+
+// GetRequestDetails returns the full details of a request by ID with auth headers redacted,
+// its time and timings read from the timing index and the custom fields Chrome DevTools
+// records, initiator stack included, from the custom field index
+func (p *Parser) GetRequestDetails(harData *har.HAR, index *TimingIndex, fields *CustomFieldIndex, requestID string) (*RequestDetails, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create request info with redacted headers
+	// Create request info with redacted headers, cookies and values
+	redaction := p.redaction()
 	requestInfo := &RequestInfo{
 		Method:      entry.Request.Method,
-		URL:         entry.Request.URL,
+		URL:         redaction.text(entry.Request.URL),
 		HTTPVersion: entry.Request.HTTPVersion,
-		Cookies:     entry.Request.Cookies,
-		Headers:     p.redactAuthHeaders(entry.Request.Headers),
-		QueryString: entry.Request.QueryString,
-		PostData:    entry.Request.PostData,
+		Cookies:     redaction.cookies(entry.Request.Cookies),
+		Headers:     redaction.headerList(entry.Request.Headers),
+		QueryString: redaction.queryString(entry.Request.QueryString),
+		PostData:    redaction.postData(entry.Request.PostData),
 		HeadersSize: entry.Request.HeadersSize,
 		BodySize:    entry.Request.BodySize,
 	}
 
 	details := &RequestDetails{
 		RequestID:       requestID,
-		StartedDateTime: entry.StartedDateTime.Format(time.RFC3339),
-		Time:            float64(entry.Time),
+		StartedDateTime: p.formatTime(entry.StartedDateTime, time.RFC3339),
+		Time:            index.Time(entry),
 		Request:         requestInfo,
-		Response:        entry.Response,
 		Cache:           entry.Cache,
-		Timings:         entry.Timings,
+		CustomFields:    fields.Fields(entry).redacted(redaction),
+	}
+	if timings := index.Timings(entry); *timings != (Timings{}) {
+		details.Timings = timings
+	}
+
+	if entry.Response != nil {
+		response, decoded := decodedResponse(entry.Response)
+		details.Response = newResponseInfo(redaction.response(response), decoded)
+		if response.Content != nil {
+			details.SniffedContentType = ClassifyContent(response.Content.MimeType, response.Content.Text)
+		}
+	}
+	if protocol, _ := requestOperations(entry.Request); protocol != "" {
+		details.OperationErrors = operationErrors(protocol, entry.Response)
 	}
 
 	return details, nil
@@ -203,37 +458,49 @@ func (p *Parser) GetRequestDetails(harData *har.HAR, requestID string) (*Request
 
 // redactAuthHeaders redacts sensitive authentication headers
 func (p *Parser) redactAuthHeaders(headers []har.Header) []har.Header {
-	authHeaders := map[string]bool{
-		"authorization":       true,
-		"x-api-key":           true,
-		"x-auth-token":        true,
-		"cookie":              true,
-		"set-cookie":          true,
-		"proxy-authorization": true,
-	}
-
-	redactedHeaders := make([]har.Header, len(headers))
-	for i, header := range headers {
-		redactedHeaders[i] = har.Header{
-			Name:  header.Name,
-			Value: header.Value,
-		}
+	return p.redaction().headerList(headers)
+}
 
-		if authHeaders[strings.ToLower(header.Name)] {
-			redactedHeaders[i].Value = "[REDACTED]"
-		}
-	}
+// ParseSource parses a HAR file from either a file path, an HTTP URL or an s3://, gs:// or az://
+// object storage URI
+func (p *Parser) ParseSource(source string) (*har.HAR, error) {
+	return p.ParseSourceContext(context.Background(), source)
+}
 
-	return redactedHeaders
+// ParseSourceContext parses a HAR file like ParseSource, abandoning the fetch of remote files
+// when the context is done
+func (p *Parser) ParseSourceContext(ctx context.Context, source string) (*har.HAR, error) {
+	harData, _, err := p.ParseSourceWithOptions(ctx, source, FetchOptions{})
+	return harData, err
 }
 
-// ParseSource parses a HAR file from either a file path or URL
-func (p *Parser) ParseSource(source string) (*har.HAR, error) {
+// ParseSourceWithReport parses a HAR file from either a file path or URL and reports
+// the detected version and the deviations from the specification that were tolerated
+func (p *Parser) ParseSourceWithReport(source string) (*har.HAR, *ParseReport, error) {
+	return p.ParseSourceWithOptions(context.Background(), source, FetchOptions{})
+}
+
+// ParseSourceWithOptions parses a HAR file from either a file path or URL like
+// ParseSourceWithReport, URLs being fetched with the given options over those of the parser.
+// Remote files are fetched within the fetch limits of the parser, the size limit applying to
+// the file both as fetched and once decompressed, and the fetch is abandoned when the context is done.
+func (p *Parser) ParseSourceWithOptions(ctx context.Context, source string, options FetchOptions) (*har.HAR, *ParseReport, error) {
 	// Check if it's a URL
-	if u, err := url.Parse(source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
-		return p.ParseFromURL(source)
+	if u, parseErr := url.Parse(source); parseErr == nil && (u.Scheme == "http" || u.Scheme == "https" || isObjectStorageURI(u)) {
+		body, err := p.openRemote(ctx, u, p.fetch.merge(options))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer body.Close() //nolint:errcheck
+		return p.parseRemote(body)
 	}
 
 	// Otherwise treat as file path
-	return p.ParseFromFile(source)
+	body, err := openFile(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close() //nolint:errcheck
+
+	return p.ParseWithReport(body)
 }
@@ -1,8 +1,11 @@
 package har
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/martian/har"
 	"github.com/stretchr/testify/assert"
@@ -193,6 +196,34 @@ func parseTestHAR(t *testing.T, harData string) *har.HAR {
 	return archive
 }
 
+// newTestHAR builds an in-memory HAR holding the given entries.
+func newTestHAR(entries ...*har.Entry) *har.HAR {
+	return &har.HAR{
+		Log: &har.Log{
+			Version: "1.2",
+			Creator: &har.Creator{Name: "test-creator", Version: "1.0"},
+			Entries: entries,
+		},
+	}
+}
+
+// newTestEntry builds an entry for the given request with an empty 200 response.
+func newTestEntry(method, url string, headers ...har.Header) *har.Entry {
+	return &har.Entry{
+		Request: &har.Request{
+			Method:      method,
+			URL:         url,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     headers,
+		},
+		Response: &har.Response{
+			Status:     200,
+			StatusText: "OK",
+			Content:    &har.Content{},
+		},
+	}
+}
+
 // Tests
 
 func TestParseValidHAR(t *testing.T) {
@@ -211,6 +242,27 @@ func TestParseValidHAR(t *testing.T) {
 	assert.Equal(t, int64(100), entry.Time)
 }
 
+func TestParseWithReportDescribesParse(t *testing.T) {
+	parser := NewParser()
+	harData := createTestHAR()
+
+	_, report, err := parser.ParseWithReport(strings.NewReader(harData))
+	require.NoError(t, err)
+
+	assert.Equal(t, "har", report.Format)
+	assert.Equal(t, "1.2", report.Version)
+	assert.Equal(t, "strict", report.Mode)
+	assert.Equal(t, len(harData), report.Bytes)
+	sum := sha256.Sum256([]byte(harData))
+	assert.Equal(t, hex.EncodeToString(sum[:]), report.SHA256)
+	assert.Zero(t, report.DroppedEntries)
+	assert.Empty(t, report.Warnings)
+
+	_, report, err = parser.ParseWithReport(strings.NewReader(strings.Replace(harData, `"time": 100`, `"time": 100.5`, 1)))
+	require.NoError(t, err)
+	assert.Equal(t, "flexible", report.Mode)
+}
+
 func TestParseEmptyEntries(t *testing.T) {
 	harData := createEmptyHAR()
 	archive := parseTestHAR(t, harData)
@@ -236,7 +288,7 @@ func TestGetURLsAndMethods(t *testing.T) {
 	parser := NewParser()
 	archive := parseTestHAR(t, harData)
 
-	urlMethods := parser.GetURLsAndMethods(archive)
+	urlMethods := parser.GetURLsAndMethods(archive, nil)
 
 	assert.Len(t, urlMethods, 2) // GET and POST for /api/users
 
@@ -261,18 +313,18 @@ func TestGetRequestIDsForURLMethod(t *testing.T) {
 	archive := parseTestHAR(t, harData)
 
 	// Test GET requests
-	getIDs := parser.GetRequestIDsForURLMethod(archive, "https://example.com/api/users", "GET")
+	getIDs := parser.GetRequestIDsForURLMethod(archive, nil, "https://example.com/api/users", "GET")
 	assert.Len(t, getIDs, 2)
 	assert.Contains(t, getIDs, "request_0")
 	assert.Contains(t, getIDs, "request_2")
 
 	// Test POST request
-	postIDs := parser.GetRequestIDsForURLMethod(archive, "https://example.com/api/users", "POST")
+	postIDs := parser.GetRequestIDsForURLMethod(archive, nil, "https://example.com/api/users", "POST")
 	assert.Len(t, postIDs, 1)
 	assert.Contains(t, postIDs, "request_1")
 
 	// Test non-existent combination
-	deleteIDs := parser.GetRequestIDsForURLMethod(archive, "https://example.com/api/users", "DELETE")
+	deleteIDs := parser.GetRequestIDsForURLMethod(archive, nil, "https://example.com/api/users", "DELETE")
 	assert.Empty(t, deleteIDs)
 }
 
@@ -281,7 +333,7 @@ func TestGetRequestDetails(t *testing.T) {
 	parser := NewParser()
 	archive := parseTestHAR(t, harData)
 
-	details, err := parser.GetRequestDetails(archive, "request_0")
+	details, err := parser.GetRequestDetails(archive, nil, nil, "request_0")
 
 	require.NoError(t, err)
 	require.NotNil(t, details)
@@ -323,18 +375,30 @@ func TestGetRequestDetailsInvalidID(t *testing.T) {
 	archive := parseTestHAR(t, harData)
 
 	// Test invalid format
-	details, err := parser.GetRequestDetails(archive, "invalid_id")
+	details, err := parser.GetRequestDetails(archive, nil, nil, "invalid_id")
 	assert.Error(t, err)
 	assert.Nil(t, details)
 	assert.Contains(t, err.Error(), "invalid request ID format")
 
 	// Test out of range
-	details, err = parser.GetRequestDetails(archive, "request_999")
+	details, err = parser.GetRequestDetails(archive, nil, nil, "request_999")
 	assert.Error(t, err)
 	assert.Nil(t, details)
 	assert.Contains(t, err.Error(), "request ID out of range")
 }
 
+func TestGetRequestDetailsSniffsGenericContent(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("GET", "https://example.com/download")
+	entry.Response.Content = &har.Content{MimeType: "application/octet-stream", Text: []byte("%PDF-1.7 ...")}
+	archive := newTestHAR(entry)
+
+	details, err := parser.GetRequestDetails(archive, nil, nil, "request_0")
+
+	require.NoError(t, err)
+	assert.Equal(t, "pdf", details.SniffedContentType)
+}
+
 func TestRedactAuthHeaders(t *testing.T) {
 	parser := NewParser()
 
@@ -428,6 +492,108 @@ func TestParseFlexibleTime(t *testing.T) {
 	assert.Equal(t, int64(71), entry.Timings.Receive) // Rounded down from 71.206
 }
 
+func TestParseStringifiedNumbers(t *testing.T) {
+	// HAR with numeric fields written as strings
+	harData := `{
+		"log": {
+			"version": "1.2",
+			"creator": {
+				"name": "test-creator",
+				"version": "1.0"
+			},
+			"entries": [
+				{
+					"startedDateTime": "2023-01-01T00:00:00.000Z",
+					"time": "42.5",
+					"request": {
+						"method": "POST",
+						"url": "https://example.com",
+						"httpVersion": "HTTP/1.1",
+						"cookies": [],
+						"headers": [],
+						"queryString": [],
+						"headersSize": "150",
+						"bodySize": " 12 "
+					},
+					"response": {
+						"status": "201",
+						"statusText": "Created",
+						"httpVersion": "HTTP/1.1",
+						"cookies": [],
+						"headers": [],
+						"content": {
+							"size": "1024",
+							"mimeType": "text/html"
+						},
+						"redirectURL": "",
+						"headersSize": "-1",
+						"bodySize": "1234"
+					},
+					"connection": 52341
+				}
+			]
+		}
+	}`
+
+	parser := NewParser()
+	archive, err := parser.Parse(strings.NewReader(harData))
+	require.NoError(t, err)
+	require.Len(t, archive.Log.Entries, 1)
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, int64(42), entry.Time)
+	assert.Equal(t, int64(150), entry.Request.HeadersSize)
+	assert.Equal(t, int64(12), entry.Request.BodySize)
+	assert.Equal(t, 201, entry.Response.Status)
+	assert.Equal(t, int64(-1), entry.Response.HeadersSize)
+	assert.Equal(t, int64(1234), entry.Response.BodySize)
+	assert.Equal(t, int64(1024), entry.Response.Content.Size)
+}
+
+func TestParseRejectsNonNumericStrings(t *testing.T) {
+	harData := strings.Replace(createTestHAR(), `"status": 200`, `"status": "OK"`, 1)
+
+	parser := NewParser()
+	_, err := parser.Parse(strings.NewReader(harData))
+	assert.ErrorContains(t, err, `invalid numeric value "OK"`)
+}
+
+func TestParseCoercesQueryStringAndParams(t *testing.T) {
+	harData := strings.Replace(createTestHAR(), `"queryString": [],`, `"queryString": [
+							{"name": "page", "value": 2},
+							{"name": "debug", "value": true},
+							{"name": "q", "value": "shoes"}
+						],
+						"postData": {
+							"mimeType": "application/x-www-form-urlencoded",
+							"text": "user=alice&remember",
+							"params": [{"name": "user", "value": "alice"}, "remember", "count=3", {"name": "age", "value": null}]
+						},`, 1)
+
+	parser := NewParser()
+	archive, report, err := parser.ParseWithReport(strings.NewReader(harData))
+	require.NoError(t, err)
+
+	request := archive.Log.Entries[0].Request
+	assert.Equal(t, []har.QueryString{
+		{Name: "page", Value: "2"},
+		{Name: "debug", Value: "true"},
+		{Name: "q", Value: "shoes"},
+	}, request.QueryString)
+	require.NotNil(t, request.PostData)
+	assert.Equal(t, []har.Param{
+		{Name: "user", Value: "alice"},
+		{Name: "remember"},
+		{Name: "count", Value: "3"},
+		{Name: "age"},
+	}, request.PostData.Params)
+
+	assert.Equal(t, []ParseWarning{
+		{RequestID: "request_0", Message: "coerced 2 non-string queryString values to strings"},
+		{RequestID: "request_0", Message: "coerced 2 non-standard postData.params to name/value strings"},
+	}, report.Warnings)
+}
+
 func TestParseTextContent(t *testing.T) {
 	// HAR with plain text content (not base64)
 	harData := `{
@@ -650,7 +816,7 @@ func TestParseComplexHAR(t *testing.T) {
 	assert.Equal(t, int64(34), entry.Timings.Receive) // Rounded down from 34.0
 
 	// Check auth header is redacted when getting details
-	details, err := parser.GetRequestDetails(archive, "request_0")
+	details, err := parser.GetRequestDetails(archive, nil, nil, "request_0")
 	require.NoError(t, err)
 
 	var authHeader *har.Header
@@ -663,3 +829,19 @@ func TestParseComplexHAR(t *testing.T) {
 	require.NotNil(t, authHeader)
 	assert.Equal(t, "[REDACTED]", authHeader.Value)
 }
+
+func TestGetRequestDetailsRendersConfiguredTimeZone(t *testing.T) {
+	entry := newTestEntry("GET", "https://example.com")
+	entry.StartedDateTime = time.Date(2023, 1, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	archive := newTestHAR(entry)
+
+	parser := NewParser()
+	details, err := parser.GetRequestDetails(archive, nil, nil, "request_0")
+	require.NoError(t, err)
+	assert.Equal(t, "2023-01-01T09:30:00+01:00", details.StartedDateTime)
+
+	parser.SetTimeZone(time.UTC)
+	details, err = parser.GetRequestDetails(archive, nil, nil, "request_0")
+	require.NoError(t, err)
+	assert.Equal(t, "2023-01-01T08:30:00Z", details.StartedDateTime)
+}
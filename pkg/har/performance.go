@@ -0,0 +1,185 @@
+package har
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Orders of the rows of the performance report
+const (
+	PerformanceByRequests = "requests"
+	PerformanceByP90      = "p90"
+	PerformanceByErrors   = "errors"
+	PerformanceByBytes    = "bytes"
+)
+
+// DefaultPerformancePaths is the number of path rows returned when no limit is given
+const DefaultPerformancePaths = 50
+
+// PerformanceRow aggregates the requests of a host, or of a templated path of a host
+type PerformanceRow struct {
+	Host string `json:"host"`
+	// Path is the templated path, such as /users/{id}, empty for host rows
+	Path     string `json:"path,omitempty"`
+	Requests int    `json:"requests"`
+	// Errors counts the requests without response or answered with a 4xx or 5xx status
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50MS     float64 `json:"p50_ms"`
+	P90MS     float64 `json:"p90_ms"`
+	P99MS     float64 `json:"p99_ms"`
+	// Bytes sums the known header and body sizes of the requests and responses
+	Bytes int64 `json:"bytes"`
+}
+
+// PerformanceReport aggregates the latency, errors and transferred bytes of a capture per host
+// and per templated path
+type PerformanceReport struct {
+	Requests int              `json:"requests"`
+	OrderBy  string           `json:"order_by"`
+	Hosts    []PerformanceRow `json:"hosts"`
+	Paths    []PerformanceRow `json:"paths"`
+	// Truncated is set when only the first path rows are returned
+	Truncated bool `json:"truncated,omitempty"`
+	// Beacons summarizes the beacons left out of the rows, when they are
+	Beacons *BeaconSummary `json:"beacons,omitempty"`
+}
+
+// performanceGroup accumulates the requests of a row
+type performanceGroup struct {
+	row       PerformanceRow
+	durations []float64
+}
+
+// add records a request of the group
+func (g *performanceGroup) add(entry *har.Entry, duration float64, bytes int64) {
+	g.row.Requests++
+	g.durations = append(g.durations, duration)
+	g.row.Bytes += bytes
+	if status := responseStatus(entry); status == 0 || status >= 400 {
+		g.row.Errors++
+	}
+}
+
+// finish computes the percentiles and error rate of the group
+func (g *performanceGroup) finish() PerformanceRow {
+	slices.Sort(g.durations)
+	g.row.P50MS = percentile(g.durations, 50)
+	g.row.P90MS = percentile(g.durations, 90)
+	g.row.P99MS = percentile(g.durations, 99)
+	g.row.ErrorRate = math.Round(float64(g.row.Errors)/float64(g.row.Requests)*1000) / 1000
+	return g.row
+}
+
+// GetPerformanceReport aggregates the requests per host and per templated path (/users/42 and
+// /users/7 both being /users/{id}): request counts, errors and error rate, p50, p90 and p99
+// latencies and transferred bytes, so performance triage starts from a compact table. Rows are
+// ordered by requests, p90, errors or bytes, largest first, and at most limit path rows are
+// returned, DefaultPerformancePaths when limit is not positive. Durations are read from the
+// timing index. The entries of the beacon index are left out and summarized apart, so that
+// beacons cut short at unload do not inflate error rates; every entry is counted when it is nil.
+func (p *Parser) GetPerformanceReport(harData *har.HAR, index *TimingIndex, beacons *BeaconIndex, orderBy string, limit int) (*PerformanceReport, error) {
+	if orderBy == "" {
+		orderBy = PerformanceByRequests
+	}
+	if !slices.Contains([]string{PerformanceByRequests, PerformanceByP90, PerformanceByErrors, PerformanceByBytes}, orderBy) {
+		return nil, fmt.Errorf("invalid order %q, expected %s, %s, %s or %s", orderBy, PerformanceByRequests, PerformanceByP90, PerformanceByErrors, PerformanceByBytes)
+	}
+	if limit <= 0 {
+		limit = DefaultPerformancePaths
+	}
+
+	hosts := make(map[string]*performanceGroup)
+	paths := make(map[string]*performanceGroup)
+	var hostOrder, pathOrder []string
+	report := &PerformanceReport{OrderBy: orderBy}
+	redaction := p.redaction()
+	if beacons != nil {
+		report.Beacons = newBeaconSummary()
+	}
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		if kind := beacons.Kind(entry); kind != "" {
+			report.Beacons.add(kind, entry, formatRequestID(i))
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		report.Requests++
+		duration := index.Time(entry)
+		bytes := max(entry.Request.HeadersSize, 0) + max(entry.Request.BodySize, 0)
+		if entry.Response != nil {
+			bytes += max(entry.Response.HeadersSize, 0) + responseBodySize(entry.Response)
+		}
+
+		host, ok := hosts[u.Host]
+		if !ok {
+			host = &performanceGroup{row: PerformanceRow{Host: u.Host}}
+			hosts[u.Host] = host
+			hostOrder = append(hostOrder, u.Host)
+		}
+		host.add(entry, duration, bytes)
+
+		path := redaction.text(strings.TrimPrefix(templateURL(entry.Request.URL), u.Scheme+"://"+u.Host))
+		if path == "" {
+			path = "/"
+		}
+		key := u.Host + " " + path
+		group, ok := paths[key]
+		if !ok {
+			group = &performanceGroup{row: PerformanceRow{Host: u.Host, Path: path}}
+			paths[key] = group
+			pathOrder = append(pathOrder, key)
+		}
+		group.add(entry, duration, bytes)
+	}
+
+	report.Hosts = performanceRows(hosts, hostOrder, orderBy)
+	report.Paths = performanceRows(paths, pathOrder, orderBy)
+	if len(report.Paths) > limit {
+		report.Paths = report.Paths[:limit]
+		report.Truncated = true
+	}
+	return report, nil
+}
+
+// performanceRows finishes the groups and sorts them by the order, largest first, ties keeping
+// the order the groups were first requested in
+func performanceRows(groups map[string]*performanceGroup, order []string, orderBy string) []PerformanceRow {
+	rows := make([]PerformanceRow, 0, len(order))
+	for _, key := range order {
+		rows = append(rows, groups[key].finish())
+	}
+	slices.SortStableFunc(rows, func(a, b PerformanceRow) int {
+		switch orderBy {
+		case PerformanceByP90:
+			return cmp.Compare(b.P90MS, a.P90MS)
+		case PerformanceByErrors:
+			return cmp.Compare(b.Errors, a.Errors)
+		case PerformanceByBytes:
+			return cmp.Compare(b.Bytes, a.Bytes)
+		}
+		return cmp.Compare(b.Requests, a.Requests)
+	})
+	return rows
+}
+
+// percentile returns the nearest-rank percentile of the sorted values, 0 when there are none
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,73 @@
+package har
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPerformanceEntry builds a request to the URL lasting ms milliseconds answered with the status
+func newTestPerformanceEntry(url string, ms int64, status int) *har.Entry {
+	entry := newTestEntry("GET", url)
+	entry.Time = ms
+	entry.Response.Status = status
+	entry.Response.BodySize = 100
+	return entry
+}
+
+// newTestPerformanceHAR builds a capture of user lookups and a failing search on an API host and
+// an asset on a CDN
+func newTestPerformanceHAR() *har.HAR {
+	var entries []*har.Entry
+	for i := int64(1); i <= 10; i++ {
+		entries = append(entries, newTestPerformanceEntry(fmt.Sprintf("https://api.example.com/users/%d", i), i*10, 200))
+	}
+	entries = append(entries,
+		newTestPerformanceEntry("https://api.example.com/search?q=a", 900, 500),
+		newTestPerformanceEntry("https://cdn.example.com/app.js", 20, 200),
+	)
+	return newTestHAR(entries...)
+}
+
+func TestGetPerformanceReportAggregatesHostsAndPaths(t *testing.T) {
+	report, err := NewParser().GetPerformanceReport(newTestPerformanceHAR(), nil, nil, "", 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 12, report.Requests)
+	require.Len(t, report.Hosts, 2)
+	assert.Equal(t, PerformanceRow{Host: "api.example.com", Requests: 11, Errors: 1, ErrorRate: 0.091, P50MS: 60, P90MS: 100, P99MS: 900, Bytes: 1100}, report.Hosts[0])
+	require.Len(t, report.Paths, 3)
+	assert.Equal(t, "/users/{id}", report.Paths[0].Path)
+	assert.Equal(t, 10, report.Paths[0].Requests)
+	assert.Equal(t, 50.0, report.Paths[0].P50MS)
+	assert.Equal(t, 90.0, report.Paths[0].P90MS)
+}
+
+func TestGetPerformanceReportOrdersAndLimitsPaths(t *testing.T) {
+	report, err := NewParser().GetPerformanceReport(newTestPerformanceHAR(), nil, nil, PerformanceByP90, 1)
+	require.NoError(t, err)
+
+	require.Len(t, report.Paths, 1)
+	assert.True(t, report.Truncated)
+	assert.Equal(t, "/search", report.Paths[0].Path)
+	assert.Equal(t, 1.0, report.Paths[0].ErrorRate)
+}
+
+func TestGetPerformanceReportRejectsUnknownOrder(t *testing.T) {
+	_, err := NewParser().GetPerformanceReport(newTestPerformanceHAR(), nil, nil, "latency", 0)
+	assert.ErrorContains(t, err, `invalid order "latency"`)
+}
+
+func TestGetPerformanceReportRedactsPaths(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+
+	report, err := parser.GetPerformanceReport(newTestHAR(newTestPerformanceEntry("https://api.example.com/share/sk_live_abc", 10, 200)), nil, nil, "", 0)
+	require.NoError(t, err)
+
+	require.Len(t, report.Paths, 1)
+	assert.Equal(t, "/share/[REDACTED]", report.Paths[0].Path)
+}
@@ -0,0 +1,265 @@
+package har
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Query DSLs recognized in query parameters
+const (
+	// QueryDSLOData marks OData system query options such as $filter or $expand
+	QueryDSLOData = "odata"
+	// QueryDSLJSONAPI marks JSON:API families such as filter[status] or fields[articles]
+	QueryDSLJSONAPI = "jsonapi"
+)
+
+// maxParameterValues caps the most frequent values listed per parameter
+const maxParameterValues = 5
+
+var (
+	// jsonAPIParameterPattern matches bracketed parameter families, e.g. filter[author][name]
+	jsonAPIParameterPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_-]*)((?:\[[^\]]*\])+)$`)
+	odataStringPattern      = regexp.MustCompile(`'(?:[^']|'')*'`)
+	odataIdentifierPattern  = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_./]*(\s*\()?`)
+	odataLambdaPattern      = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*:`)
+	odataKeywords           = map[string]bool{
+		"eq": true, "ne": true, "gt": true, "ge": true, "lt": true, "le": true,
+		"and": true, "or": true, "not": true, "has": true, "in": true,
+		"add": true, "sub": true, "mul": true, "div": true, "divby": true, "mod": true,
+		"true": true, "false": true, "null": true, "asc": true, "desc": true,
+	}
+)
+
+// ParameterValueCount is a distinct parameter value with the number of times it was seen
+type ParameterValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ParameterStats describes how a query parameter, or a DSL parameter family, is used on an endpoint
+type ParameterStats struct {
+	Name string `json:"name"`
+	DSL  string `json:"dsl,omitempty"`
+	// Count is the number of requests carrying the parameter
+	Count          int                   `json:"count"`
+	DistinctValues int                   `json:"distinct_values"`
+	TopValues      []ParameterValueCount `json:"top_values"`
+	// Fields are the fields referenced by DSL expressions, e.g. the properties a $filter compares
+	Fields []string `json:"fields,omitempty"`
+}
+
+// EndpointParameters gathers the query parameters used on one endpoint
+type EndpointParameters struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	Requests int    `json:"requests"`
+	// UniqueURLs counts the distinct URLs, query string included, grouped in the endpoint
+	UniqueURLs int              `json:"unique_urls"`
+	Parameters []ParameterStats `json:"parameters"`
+}
+
+// parameterUsage accumulates the usage of a parameter while scanning the HAR
+type parameterUsage struct {
+	stats  *ParameterStats
+	values map[string]int
+	fields map[string]bool
+	// lastEntry avoids counting a request twice when it repeats the parameter
+	lastEntry int
+}
+
+// GetQueryParameterStats groups the requests carrying query parameters by endpoint template and
+// tabulates their parameters, so that query-heavy APIs read as a few logical operations rather
+// than thousands of unique URLs. OData system query options and JSON:API parameter families are
+// recognized and the fields their expressions reference are listed. Values are redacted.
+func (p *Parser) GetQueryParameterStats(harData *har.HAR) []EndpointParameters {
+	redaction := p.redaction()
+	endpoints := make(map[string]*EndpointParameters)
+	usages := make(map[string]map[string]*parameterUsage)
+	urls := make(map[string]map[string]bool)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.RawQuery == "" {
+			continue
+		}
+
+		key := resourceKey(entry)
+		endpoint, ok := endpoints[key]
+		if !ok {
+			endpoint = &EndpointParameters{Method: entry.Request.Method, Endpoint: templateURL(entry.Request.URL)}
+			endpoints[key] = endpoint
+			usages[key] = make(map[string]*parameterUsage)
+			urls[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+		endpoint.Requests++
+		urls[key][entry.Request.URL] = true
+
+		for rawName, values := range u.Query() {
+			name, dsl, field := classifyParameter(rawName)
+			usage, ok := usages[key][name]
+			if !ok {
+				usage = &parameterUsage{
+					stats:     &ParameterStats{Name: name, DSL: dsl},
+					values:    make(map[string]int),
+					fields:    make(map[string]bool),
+					lastEntry: -1,
+				}
+				usages[key][name] = usage
+			}
+			if usage.lastEntry != i {
+				usage.stats.Count++
+				usage.lastEntry = i
+			}
+			if field != "" {
+				usage.fields[field] = true
+			}
+			for _, value := range values {
+				usage.values[redaction.text(value)]++
+				for _, field := range odataFields(name, value) {
+					usage.fields[field] = true
+				}
+			}
+		}
+	}
+
+	result := make([]EndpointParameters, 0, len(order))
+	for _, key := range order {
+		endpoint := endpoints[key]
+		endpoint.UniqueURLs = len(urls[key])
+		endpoint.Parameters = make([]ParameterStats, 0, len(usages[key]))
+		for _, usage := range usages[key] {
+			endpoint.Parameters = append(endpoint.Parameters, usage.summary())
+		}
+		sort.Slice(endpoint.Parameters, func(i, j int) bool {
+			if endpoint.Parameters[i].Count != endpoint.Parameters[j].Count {
+				return endpoint.Parameters[i].Count > endpoint.Parameters[j].Count
+			}
+			return endpoint.Parameters[i].Name < endpoint.Parameters[j].Name
+		})
+		result = append(result, *endpoint)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Requests > result[j].Requests
+	})
+	return result
+}
+
+// summary returns the parameter statistics with the most frequent values and the sorted fields
+func (u *parameterUsage) summary() ParameterStats {
+	stats := *u.stats
+	stats.DistinctValues = len(u.values)
+	stats.TopValues = make([]ParameterValueCount, 0, len(u.values))
+	for value, count := range u.values {
+		stats.TopValues = append(stats.TopValues, ParameterValueCount{Value: value, Count: count})
+	}
+	sort.Slice(stats.TopValues, func(i, j int) bool {
+		if stats.TopValues[i].Count != stats.TopValues[j].Count {
+			return stats.TopValues[i].Count > stats.TopValues[j].Count
+		}
+		return stats.TopValues[i].Value < stats.TopValues[j].Value
+	})
+	if len(stats.TopValues) > maxParameterValues {
+		stats.TopValues = stats.TopValues[:maxParameterValues]
+	}
+	for field := range u.fields {
+		stats.Fields = append(stats.Fields, field)
+	}
+	sort.Strings(stats.Fields)
+	return stats
+}
+
+// classifyParameter returns the name a query parameter is grouped under, its DSL if any, and the
+// field a JSON:API family addresses, filter[author][name] being grouped under filter with field author.name
+func classifyParameter(name string) (string, string, string) {
+	if strings.HasPrefix(name, "$") {
+		return name, QueryDSLOData, ""
+	}
+	if match := jsonAPIParameterPattern.FindStringSubmatch(name); match != nil {
+		field := strings.Trim(match[2], "[]")
+		return match[1], QueryDSLJSONAPI, strings.ReplaceAll(field, "][", ".")
+	}
+	return name, "", ""
+}
+
+// odataFields returns the fields referenced by the value of an OData system query option
+func odataFields(name, value string) []string {
+	switch name {
+	case "$filter":
+		return odataFilterFields(value)
+	case "$select", "$orderby", "$expand":
+		var fields []string
+		for _, item := range splitTopLevel(value) {
+			// Drop the nested options of $expand and the direction of $orderby
+			if index := strings.IndexByte(item, '('); index >= 0 {
+				item = item[:index]
+			}
+			if words := strings.Fields(item); len(words) > 0 {
+				fields = append(fields, words[0])
+			}
+		}
+		return fields
+	default:
+		return nil
+	}
+}
+
+// odataFilterFields returns the properties a $filter expression references, ignoring
+// literals, operators, function names and lambda variables
+func odataFilterFields(expression string) []string {
+	expression = odataStringPattern.ReplaceAllString(expression, "''")
+	lambdas := make(map[string]bool)
+	for _, match := range odataLambdaPattern.FindAllStringSubmatch(expression, -1) {
+		lambdas[match[1]] = true
+	}
+
+	var fields []string
+	for _, match := range odataIdentifierPattern.FindAllStringSubmatch(expression, -1) {
+		identifier := strings.TrimRight(match[0], " (")
+		if match[1] != "" {
+			// Functions are skipped, but the collection of a lambda, as in Tags/any(...), is kept
+			collection, _, found := strings.Cut(identifier, "/any")
+			if !found {
+				collection, _, found = strings.Cut(identifier, "/all")
+			}
+			if !found {
+				continue
+			}
+			identifier = collection
+		}
+		root, _, _ := strings.Cut(identifier, "/")
+		if odataKeywords[identifier] || lambdas[root] {
+			continue
+		}
+		fields = append(fields, identifier)
+	}
+	return fields
+}
+
+// splitTopLevel splits a comma separated list, ignoring the commas nested in parentheses
+func splitTopLevel(value string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(value[start:]))
+}
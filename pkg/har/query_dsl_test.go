@@ -0,0 +1,66 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQueryParameterStatsGroupsODataQueries(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/odata/Products?$filter=Price%20gt%2010%20and%20Category/Name%20eq%20'Toys'&$top=5"),
+		newTestEntry("GET", "https://example.com/odata/Products?$filter=contains(Name,'ball')%20or%20Price%20lt%205&$top=5"),
+		newTestEntry("GET", "https://example.com/odata/Products?$filter=Tags/any(t:%20t%20eq%20'new')&$expand=Supplier($select=Name),Category&$orderby=Price%20desc"),
+		newTestEntry("GET", "https://example.com/odata/Products"),
+	)
+
+	stats := parser.GetQueryParameterStats(archive)
+
+	require.Len(t, stats, 1)
+	assert.Equal(t, "https://example.com/odata/Products", stats[0].Endpoint)
+	assert.Equal(t, 3, stats[0].Requests)
+	assert.Equal(t, 3, stats[0].UniqueURLs)
+	require.Len(t, stats[0].Parameters, 4)
+	assert.Equal(t, ParameterStats{
+		Name:           "$filter",
+		DSL:            QueryDSLOData,
+		Count:          3,
+		DistinctValues: 3,
+		TopValues: []ParameterValueCount{
+			{Value: "Price gt 10 and Category/Name eq 'Toys'", Count: 1},
+			{Value: "Tags/any(t: t eq 'new')", Count: 1},
+			{Value: "contains(Name,'ball') or Price lt 5", Count: 1},
+		},
+		Fields: []string{"Category/Name", "Name", "Price", "Tags"},
+	}, stats[0].Parameters[0])
+	assert.Equal(t, ParameterStats{
+		Name:           "$top",
+		DSL:            QueryDSLOData,
+		Count:          2,
+		DistinctValues: 1,
+		TopValues:      []ParameterValueCount{{Value: "5", Count: 2}},
+	}, stats[0].Parameters[1])
+	assert.Equal(t, []string{"Category", "Supplier"}, stats[0].Parameters[2].Fields)
+	assert.Equal(t, []string{"Price"}, stats[0].Parameters[3].Fields)
+}
+
+func TestGetQueryParameterStatsGroupsJSONAPIFamilies(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/articles?filter[status]=published&page[size]=10"),
+		newTestEntry("GET", "https://example.com/articles?filter[author][name]=ada&filter[status]=draft&page[size]=10"),
+	)
+
+	stats := parser.GetQueryParameterStats(archive)
+
+	require.Len(t, stats, 1)
+	require.Len(t, stats[0].Parameters, 2)
+	assert.Equal(t, "filter", stats[0].Parameters[0].Name)
+	assert.Equal(t, QueryDSLJSONAPI, stats[0].Parameters[0].DSL)
+	assert.Equal(t, 2, stats[0].Parameters[0].Count)
+	assert.Equal(t, []string{"author.name", "status"}, stats[0].Parameters[0].Fields)
+	assert.Equal(t, "page", stats[0].Parameters[1].Name)
+	assert.Equal(t, []string{"size"}, stats[0].Parameters[1].Fields)
+}
@@ -0,0 +1,105 @@
+package har
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// DefaultRawBodySize is the number of bytes of each body rendered by GetRawHTTP when no size is given
+const DefaultRawBodySize = 16 * 1024
+
+// GetRawHTTP renders an entry as the raw HTTP/1.1 request and response exchanged: start line,
+// headers and body, the response following the request after a blank line. HTTP/2 pseudo headers
+// are left out, the Host header being restored from the URL when missing. Bodies are the decoded
+// content recorded in the HAR, cut after maxBodySize bytes (DefaultRawBodySize when not positive),
+// and binary bodies are replaced by a marker. Sensitive values are redacted.
+func (p *Parser) GetRawHTTP(harData *har.HAR, requestID string, maxBodySize int) (string, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return "", err
+	}
+	if entry.Request == nil {
+		return "", fmt.Errorf("request %s has no request data", requestID)
+	}
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultRawBodySize
+	}
+
+	redaction := p.redaction()
+	var raw strings.Builder
+	writeRawRequest(&raw, entry.Request, redaction, maxBodySize)
+	if response := redaction.response(entry.Response); response != nil && response.Status > 0 {
+		raw.WriteString("\n")
+		writeRawResponse(&raw, response, maxBodySize)
+	}
+	return raw.String(), nil
+}
+
+// writeRawRequest writes the request line, headers and body of a request
+func writeRawRequest(raw *strings.Builder, request *har.Request, redaction *redactor, maxBodySize int) {
+	target, host := request.URL, ""
+	if u, err := url.Parse(request.URL); err == nil {
+		target, host = u.RequestURI(), u.Host
+	}
+	fmt.Fprintf(raw, "%s %s HTTP/1.1\n", strings.ToUpper(request.Method), redaction.text(target))
+	headers := redaction.headerList(request.Headers)
+	if headerValue(headers, "Host") == "" {
+		if authority := headerValue(headers, ":authority"); authority != "" {
+			host = authority
+		}
+		if host != "" {
+			fmt.Fprintf(raw, "Host: %s\n", host)
+		}
+	}
+	writeRawHeaders(raw, headers)
+	raw.WriteString("\n")
+	if postData := redaction.postData(request.PostData); postData != nil {
+		writeRawBody(raw, []byte(curlBody(postData)), postData.MimeType, maxBodySize)
+	}
+}
+
+// writeRawResponse writes the status line, headers and body of a redacted response
+func writeRawResponse(raw *strings.Builder, response *har.Response, maxBodySize int) {
+	reason := response.StatusText
+	if reason == "" {
+		reason = http.StatusText(response.Status)
+	}
+	fmt.Fprintf(raw, "%s\n", strings.TrimSpace(fmt.Sprintf("HTTP/1.1 %d %s", response.Status, reason)))
+	writeRawHeaders(raw, response.Headers)
+	raw.WriteString("\n")
+	if response.Content != nil {
+		writeRawBody(raw, response.Content.Text, responseMimeType(response), maxBodySize)
+	}
+}
+
+// writeRawHeaders writes the headers, leaving out HTTP/2 pseudo headers
+func writeRawHeaders(raw *strings.Builder, headers []har.Header) {
+	for _, header := range headers {
+		if strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		fmt.Fprintf(raw, "%s: %s\n", header.Name, header.Value)
+	}
+}
+
+// writeRawBody writes a body followed by a line break, at most maxBodySize bytes of it
+func writeRawBody(raw *strings.Builder, body []byte, mimeType string, maxBodySize int) {
+	if len(body) == 0 {
+		return
+	}
+	if !isPrintableText(body) {
+		fmt.Fprintf(raw, "[binary content omitted: %d bytes of %s]\n", len(body), mimeType)
+		return
+	}
+	if len(body) > maxBodySize {
+		end := runeEnd(body, maxBodySize)
+		fmt.Fprintf(raw, "%s\n[truncated: %d of %d bytes shown]\n", body[:end], end, len(body))
+		return
+	}
+	raw.Write(body)
+	raw.WriteString("\n")
+}
@@ -0,0 +1,62 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRawHTTP(t *testing.T) {
+	parser := NewParser()
+	entry := newTestResponseEntry("https://api.example.com/v1/orders?page=2", "application/json", `{"id": 1}`)
+	entry.Request.Method = "post"
+	entry.Request.Headers = []har.Header{
+		{Name: ":authority", Value: "api.example.com"},
+		{Name: "Authorization", Value: "Bearer secret"},
+		{Name: "Content-Type", Value: "application/json"},
+	}
+	entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"sku": "A1"}`}
+	entry.Response.Status = 201
+	entry.Response.StatusText = ""
+
+	raw, err := parser.GetRawHTTP(newTestHAR(entry), "request_0", 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, `POST /v1/orders?page=2 HTTP/1.1
+Host: api.example.com
+Authorization: [REDACTED]
+Content-Type: application/json
+
+{"sku": "A1"}
+
+HTTP/1.1 201 Created
+Content-Type: application/json
+
+{"id": 1}
+`, raw)
+}
+
+func TestGetRawHTTPBodies(t *testing.T) {
+	parser := NewParser()
+	form := newTestResponseEntry("https://example.com/logo.png", "image/png", "\x89PNG\r\n\x1a\n\x00\x00")
+	form.Request.Method = "POST"
+	form.Request.Headers = []har.Header{{Name: "Host", Value: "example.com"}}
+	form.Request.PostData = &har.PostData{MimeType: "application/x-www-form-urlencoded", Params: []har.Param{{Name: "q", Value: "a b"}}}
+	long := newTestResponseEntry("https://example.com/notes", "text/plain", "héllo world")
+
+	raw, err := parser.GetRawHTTP(newTestHAR(form, long), "request_0", 0)
+
+	require.NoError(t, err)
+	assert.Contains(t, raw, "POST /logo.png HTTP/1.1\nHost: example.com\n\nq=a+b\n")
+	assert.Contains(t, raw, "HTTP/1.1 200 OK\nContent-Type: image/png\n\n[binary content omitted: 10 bytes of image/png]\n")
+
+	raw, err = parser.GetRawHTTP(newTestHAR(form, long), "request_1", 2)
+
+	require.NoError(t, err)
+	assert.Contains(t, raw, "\n\nh\n[truncated: 1 of 12 bytes shown]\n")
+
+	_, err = parser.GetRawHTTP(newTestHAR(form), "request_3", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,232 @@
+package har
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// redactedValue replaces the value of sensitive headers
+const redactedValue = "[REDACTED]"
+
+// authHeaders lists the lower-cased names of headers carrying credentials
+var authHeaders = map[string]bool{
+	"authorization":       true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"proxy-authorization": true,
+}
+
+// RedactionPolicy configures what is redacted before HAR contents are exposed.
+// Credential headers (Authorization, Cookie, X-API-Key...) are always redacted unless
+// Disabled is set.
+type RedactionPolicy struct {
+	// Headers are additional header names to redact, matched ignoring case
+	Headers []string `json:"headers,omitempty"`
+	// ValuePatterns are regular expressions whose matches are redacted in header values,
+	// query parameters and bodies, e.g. JWTs or API keys
+	ValuePatterns []string `json:"value_patterns,omitempty"`
+	// AllowedCookies are the names of the cookies whose values are shown, every other cookie
+	// value is redacted
+	AllowedCookies []string `json:"allowed_cookies,omitempty"`
+	// Disabled turns redaction off entirely
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// redactor applies a compiled redaction policy
+type redactor struct {
+	policy         RedactionPolicy
+	headers        map[string]bool
+	patterns       []*regexp.Regexp
+	allowedCookies map[string]bool
+}
+
+// defaultRedactor redacts the credential headers and every cookie value
+var defaultRedactor = &redactor{headers: authHeaders}
+
+// newRedactor compiles a redaction policy
+func newRedactor(policy RedactionPolicy) (*redactor, error) {
+	r := &redactor{
+		policy:         policy,
+		headers:        make(map[string]bool, len(authHeaders)+len(policy.Headers)),
+		allowedCookies: make(map[string]bool, len(policy.AllowedCookies)),
+	}
+	for name := range authHeaders {
+		r.headers[name] = true
+	}
+	for _, name := range policy.Headers {
+		r.headers[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	for _, pattern := range policy.ValuePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, name := range policy.AllowedCookies {
+		r.allowedCookies[name] = true
+	}
+	return r, nil
+}
+
+// SetRedactionPolicy replaces the redaction policy, it can be called while other calls are served
+func (p *Parser) SetRedactionPolicy(policy RedactionPolicy) error {
+	r, err := newRedactor(policy)
+	if err != nil {
+		return err
+	}
+
+	p.redactionMu.Lock()
+	defer p.redactionMu.Unlock()
+	p.redactor = r
+	return nil
+}
+
+// RedactionPolicy returns the redaction policy in effect
+func (p *Parser) RedactionPolicy() RedactionPolicy {
+	return p.redaction().policy
+}
+
+// redaction returns the redactor in effect
+func (p *Parser) redaction() *redactor {
+	p.redactionMu.RLock()
+	defer p.redactionMu.RUnlock()
+	if p.redactor == nil {
+		return defaultRedactor
+	}
+	return p.redactor
+}
+
+// sensitiveHeader reports whether the value of the header must be redacted
+func (r *redactor) sensitiveHeader(name string) bool {
+	return !r.policy.Disabled && r.headers[strings.ToLower(name)]
+}
+
+// sensitiveCookie reports whether the value of the cookie must be redacted
+func (r *redactor) sensitiveCookie(name string) bool {
+	return !r.policy.Disabled && !r.allowedCookies[name]
+}
+
+// header returns the value of a header as it can be exposed. Cookie headers keep the
+// values of allowed cookies when the policy allows some.
+func (r *redactor) header(name, value string) string {
+	if !r.sensitiveHeader(name) {
+		return r.text(value)
+	}
+	if len(r.allowedCookies) > 0 {
+		switch strings.ToLower(name) {
+		case "cookie":
+			return r.cookieHeader(value, "; ")
+		case "set-cookie":
+			// Only the cookie value is sensitive, not its attributes
+			cookie, attributes, found := strings.Cut(value, ";")
+			if found {
+				return r.cookieHeader(cookie, "") + ";" + attributes
+			}
+			return r.cookieHeader(cookie, "")
+		}
+	}
+	return redactedValue
+}
+
+// cookieHeader redacts the values of the cookies of a Cookie header that are not allowed
+func (r *redactor) cookieHeader(value, separator string) string {
+	pairs := strings.Split(value, ";")
+	for i, pair := range pairs {
+		name, _, found := strings.Cut(strings.TrimSpace(pair), "=")
+		pairs[i] = strings.TrimSpace(pair)
+		if found && r.sensitiveCookie(name) {
+			pairs[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(pairs, separator)
+}
+
+// text redacts the matches of the value patterns
+func (r *redactor) text(value string) string {
+	if r.policy.Disabled {
+		return value
+	}
+	for _, pattern := range r.patterns {
+		value = pattern.ReplaceAllString(value, redactedValue)
+	}
+	return value
+}
+
+// headerList returns a copy of the headers with sensitive values redacted
+func (r *redactor) headerList(headers []har.Header) []har.Header {
+	if headers == nil {
+		return nil
+	}
+	redacted := make([]har.Header, len(headers))
+	for i, header := range headers {
+		redacted[i] = har.Header{Name: header.Name, Value: r.header(header.Name, header.Value)}
+	}
+	return redacted
+}
+
+// cookies returns a copy of the cookies with the values of cookies that are not allowed redacted
+func (r *redactor) cookies(cookies []har.Cookie) []har.Cookie {
+	if cookies == nil {
+		return nil
+	}
+	redacted := make([]har.Cookie, len(cookies))
+	copy(redacted, cookies)
+	for i := range redacted {
+		if r.sensitiveCookie(redacted[i].Name) {
+			redacted[i].Value = redactedValue
+		}
+	}
+	return redacted
+}
+
+// queryString returns a copy of the query parameters with the value patterns redacted
+func (r *redactor) queryString(params []har.QueryString) []har.QueryString {
+	if params == nil {
+		return nil
+	}
+	redacted := make([]har.QueryString, len(params))
+	for i, param := range params {
+		redacted[i] = har.QueryString{Name: param.Name, Value: r.text(param.Value)}
+	}
+	return redacted
+}
+
+// postData returns a copy of the request body with the value patterns redacted
+func (r *redactor) postData(postData *har.PostData) *har.PostData {
+	if postData == nil {
+		return nil
+	}
+	redacted := *postData
+	redacted.Text = r.text(postData.Text)
+	if postData.Params != nil {
+		redacted.Params = make([]har.Param, len(postData.Params))
+		for i, param := range postData.Params {
+			redacted.Params[i] = param
+			redacted.Params[i].Value = r.text(param.Value)
+		}
+	}
+	return &redacted
+}
+
+// response returns a copy of the response with sensitive headers and cookies redacted,
+// and the value patterns redacted in textual bodies
+func (r *redactor) response(response *har.Response) *har.Response {
+	if response == nil {
+		return nil
+	}
+	redacted := *response
+	redacted.Headers = r.headerList(response.Headers)
+	redacted.Cookies = r.cookies(response.Cookies)
+	if response.Content != nil && isPrintableText(response.Content.Text) {
+		content := *response.Content
+		content.Text = []byte(r.text(string(response.Content.Text)))
+		redacted.Content = &content
+	}
+	return &redacted
+}
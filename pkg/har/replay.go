@@ -0,0 +1,221 @@
+package har
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// maxReplayBodySize caps the number of bytes of a live response body read by ReplayRequest
+const maxReplayBodySize = 10 * 1024 * 1024
+
+// replaySkippedHeaders are the request headers not replayed: the HTTP client sets them from
+// the request it sends
+var replaySkippedHeaders = map[string]bool{
+	"host":              true,
+	"connection":        true,
+	"content-length":    true,
+	"transfer-encoding": true,
+	"keep-alive":        true,
+	"upgrade":           true,
+	"te":                true,
+	"trailer":           true,
+	"proxy-connection":  true,
+	// Left to the client so that compressed responses are decoded
+	"accept-encoding": true,
+}
+
+// ReplayOptions tunes how ReplayRequest sends a captured request
+type ReplayOptions struct {
+	// BaseURL replaces the scheme and host of the captured URL, its path prefixing the captured path
+	BaseURL string
+	// Headers set or replace request headers, an empty value removing the header
+	Headers map[string]string
+	// MaxBodySize caps the bytes of each body returned, DefaultRawBodySize when not positive
+	MaxBodySize int
+}
+
+// ReplayResponse is a response, recorded or live, with its body cut after the maximum body size
+type ReplayResponse struct {
+	Status     int          `json:"status"`
+	StatusText string       `json:"status_text,omitempty"`
+	Headers    []har.Header `json:"headers"`
+	MimeType   string       `json:"mime_type"`
+	BodySize   int          `json:"body_size"`
+	Body       string       `json:"body,omitempty"`
+	Truncated  bool         `json:"truncated,omitempty"`
+}
+
+// ReplayResult compares the live response to a replayed request with the recorded one
+type ReplayResult struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	// URL is the URL the request was sent to, after the base URL override
+	URL        string          `json:"url"`
+	DurationMS float64         `json:"duration_ms"`
+	Recorded   *ReplayResponse `json:"recorded,omitempty"`
+	Live       ReplayResponse  `json:"live"`
+	// StatusChanged and BodyChanged report the differences with the recorded response, bodies
+	// being compared byte for byte
+	StatusChanged bool `json:"status_changed"`
+	BodyChanged   bool `json:"body_changed"`
+}
+
+// BuildReplayRequest rebuilds the HTTP request of an entry, with its unredacted headers and body,
+// so it can be sent again. The base URL and header overrides of the options are applied.
+// Connection-level headers and HTTP/2 pseudo headers are left out.
+func (p *Parser) BuildReplayRequest(ctx context.Context, harData *har.HAR, requestID string, options ReplayOptions) (*http.Request, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Request == nil {
+		return nil, fmt.Errorf("request %s has no request data", requestID)
+	}
+
+	target, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL for request %s: %w", requestID, err)
+	}
+	if options.BaseURL != "" {
+		base, err := url.Parse(options.BaseURL)
+		if err != nil || base.Scheme == "" || base.Host == "" {
+			return nil, fmt.Errorf("invalid base URL: %s", options.BaseURL)
+		}
+		target.Scheme = base.Scheme
+		target.Host = base.Host
+		target.Path = strings.TrimSuffix(base.Path, "/") + target.Path
+		target.RawPath = ""
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("request %s cannot be replayed over %s", requestID, target.Scheme)
+	}
+
+	var body io.Reader
+	if text := curlBody(entry.Request.PostData); text != "" {
+		body = strings.NewReader(text)
+	}
+	request, err := http.NewRequestWithContext(ctx, strings.ToUpper(entry.Request.Method), target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request %s: %w", requestID, err)
+	}
+	for _, header := range entry.Request.Headers {
+		if strings.HasPrefix(header.Name, ":") || replaySkippedHeaders[strings.ToLower(header.Name)] {
+			continue
+		}
+		request.Header.Add(header.Name, header.Value)
+	}
+	if request.Header.Get("Content-Type") == "" && entry.Request.PostData != nil && entry.Request.PostData.MimeType != "" {
+		request.Header.Set("Content-Type", entry.Request.PostData.MimeType)
+	}
+	for name, value := range options.Headers {
+		if strings.EqualFold(name, "Host") {
+			request.Host = value
+			continue
+		}
+		if value == "" {
+			request.Header.Del(name)
+			continue
+		}
+		request.Header.Set(name, value)
+	}
+	return request, nil
+}
+
+// ReplayRequest sends the request of an entry again with the client, see BuildReplayRequest,
+// and returns the live response next to the recorded one. Both are redacted by the redaction
+// policy and binary bodies are replaced by a marker. Redirects are returned as is when the
+// client does not follow them.
+func (p *Parser) ReplayRequest(ctx context.Context, client *http.Client, harData *har.HAR, requestID string, options ReplayOptions) (*ReplayResult, error) {
+	request, err := p.BuildReplayRequest(ctx, harData, requestID, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultRawBodySize
+	}
+
+	started := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay request %s: %w", requestID, err)
+	}
+	defer response.Body.Close()
+	liveBody, err := io.ReadAll(io.LimitReader(response.Body, maxReplayBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response to request %s: %w", requestID, err)
+	}
+	duration := time.Since(started)
+
+	redaction := p.redaction()
+	result := &ReplayResult{
+		RequestID:  requestID,
+		Method:     request.Method,
+		URL:        redaction.text(request.URL.String()),
+		DurationMS: float64(duration.Microseconds()) / 1000,
+	}
+	var liveHeaders []har.Header
+	for _, name := range slices.Sorted(maps.Keys(response.Header)) {
+		for _, value := range response.Header[name] {
+			liveHeaders = append(liveHeaders, har.Header{Name: name, Value: value})
+		}
+	}
+	live := &har.Response{
+		Status:     response.StatusCode,
+		StatusText: strings.TrimSpace(strings.TrimPrefix(response.Status, fmt.Sprint(response.StatusCode))),
+		Headers:    liveHeaders,
+		Content:    &har.Content{Text: liveBody, MimeType: response.Header.Get("Content-Type")},
+	}
+	result.Live = replayResponse(redaction.response(live), options.MaxBodySize)
+
+	entry, _ := p.getEntry(harData, requestID)
+	if recorded := entry.Response; recorded != nil && recorded.Status > 0 {
+		summary := replayResponse(redaction.response(recorded), options.MaxBodySize)
+		result.Recorded = &summary
+		result.StatusChanged = recorded.Status != response.StatusCode
+		var recordedBody []byte
+		if recorded.Content != nil {
+			recordedBody = recorded.Content.Text
+		}
+		result.BodyChanged = !bytes.Equal(recordedBody, liveBody)
+	}
+	return result, nil
+}
+
+// replayResponse summarizes a redacted response, cutting its body after maxBodySize bytes
+func replayResponse(response *har.Response, maxBodySize int) ReplayResponse {
+	summary := ReplayResponse{
+		Status:     response.Status,
+		StatusText: response.StatusText,
+		Headers:    response.Headers,
+		MimeType:   responseMimeType(response),
+	}
+	if summary.Headers == nil {
+		summary.Headers = []har.Header{}
+	}
+	if response.Content == nil {
+		return summary
+	}
+	body := response.Content.Text
+	summary.BodySize = len(body)
+	switch {
+	case len(body) == 0:
+	case !isPrintableText(body):
+		summary.Body = fmt.Sprintf("[binary content omitted: %d bytes of %s]", len(body), summary.MimeType)
+	case len(body) > maxBodySize:
+		summary.Body = string(body[:runeEnd(body, maxBodySize)])
+		summary.Truncated = true
+	default:
+		summary.Body = string(body)
+	}
+	return summary
+}
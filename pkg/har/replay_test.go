@@ -0,0 +1,108 @@
+package har
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReplayRequestAppliesOverrides(t *testing.T) {
+	parser := NewParser()
+	entry := newTestEntry("post", "https://api.example.com/v1/carts?id=42",
+		har.Header{Name: ":authority", Value: "api.example.com"},
+		har.Header{Name: "Host", Value: "api.example.com"},
+		har.Header{Name: "Authorization", Value: "Bearer secret"},
+		har.Header{Name: "X-Trace", Value: "abc"},
+		har.Header{Name: "Accept-Encoding", Value: "gzip"},
+	)
+	entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"item":1}`}
+	archive := newTestHAR(entry)
+
+	request, err := parser.BuildReplayRequest(context.Background(), archive, "request_0", ReplayOptions{
+		BaseURL: "http://localhost:8080/staging/",
+		Headers: map[string]string{"X-Trace": "", "X-Debug": "1", "Host": "api.example.com"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "POST", request.Method)
+	assert.Equal(t, "http://localhost:8080/staging/v1/carts?id=42", request.URL.String())
+	assert.Equal(t, "api.example.com", request.Host)
+	assert.Equal(t, "Bearer secret", request.Header.Get("Authorization"), "replayed requests keep their credentials")
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	assert.Equal(t, "1", request.Header.Get("X-Debug"))
+	assert.Empty(t, request.Header.Get("X-Trace"))
+	assert.Empty(t, request.Header.Get("Accept-Encoding"))
+	assert.Empty(t, request.Header.Get(":authority"))
+	body, err := io.ReadAll(request.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"item":1}`, string(body))
+}
+
+func TestBuildReplayRequestRejectsInvalidTargets(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestEntry("GET", "wss://example.com/socket"))
+
+	_, err := parser.BuildReplayRequest(context.Background(), archive, "request_0", ReplayOptions{})
+	assert.ErrorContains(t, err, "cannot be replayed over wss")
+
+	_, err = parser.BuildReplayRequest(context.Background(), archive, "request_0", ReplayOptions{BaseURL: "localhost"})
+	assert.ErrorContains(t, err, "invalid base URL")
+
+	_, err = parser.BuildReplayRequest(context.Background(), archive, "request_1", ReplayOptions{})
+	assert.Error(t, err)
+}
+
+func TestReplayRequestComparesResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/carts", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=live-secret")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"cart not found"}`))
+	}))
+	defer server.Close()
+
+	parser := NewParser()
+	entry := newTestResponseEntry("https://api.example.com/v1/carts", "application/json", `{"id":42}`)
+	archive := newTestHAR(entry)
+
+	result, err := parser.ReplayRequest(context.Background(), server.Client(), archive, "request_0", ReplayOptions{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, server.URL+"/v1/carts", result.URL)
+	assert.Equal(t, 404, result.Live.Status)
+	assert.Equal(t, "Not Found", result.Live.StatusText)
+	assert.Equal(t, "application/json", result.Live.MimeType)
+	assert.Equal(t, `{"error":"cart not found"}`, result.Live.Body)
+	assert.Contains(t, result.Live.Headers, har.Header{Name: "Set-Cookie", Value: redactedValue})
+	require.NotNil(t, result.Recorded)
+	assert.Equal(t, 200, result.Recorded.Status)
+	assert.Equal(t, `{"id":42}`, result.Recorded.Body)
+	assert.True(t, result.StatusChanged)
+	assert.True(t, result.BodyChanged)
+}
+
+func TestReplayRequestTruncatesBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	parser := NewParser()
+	archive := newTestHAR(newTestResponseEntry("https://example.com/digits", "text/plain", "0123456789"))
+
+	result, err := parser.ReplayRequest(context.Background(), server.Client(), archive, "request_0", ReplayOptions{BaseURL: server.URL, MaxBodySize: 4})
+	require.NoError(t, err)
+
+	assert.Equal(t, "0123", result.Live.Body)
+	assert.Equal(t, 10, result.Live.BodySize)
+	assert.True(t, result.Live.Truncated)
+	assert.False(t, result.StatusChanged)
+	assert.False(t, result.BodyChanged)
+}
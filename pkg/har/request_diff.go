@@ -0,0 +1,203 @@
+package har
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// NameValue is a single name/value pair such as a header or a query parameter
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ValueChange describes a value that differs between two requests
+type ValueChange struct {
+	Name string `json:"name,omitempty"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+// NameValueDiff lists the pairs that were added, removed or changed between two requests
+type NameValueDiff struct {
+	OnlyInA []NameValue   `json:"only_in_a,omitempty"`
+	OnlyInB []NameValue   `json:"only_in_b,omitempty"`
+	Changed []ValueChange `json:"changed,omitempty"`
+}
+
+// BodyDiff compares the bodies of two requests
+type BodyDiff struct {
+	Equal     bool   `json:"equal"`
+	MimeTypeA string `json:"mime_type_a,omitempty"`
+	MimeTypeB string `json:"mime_type_b,omitempty"`
+	SizeA     int    `json:"size_a"`
+	SizeB     int    `json:"size_b"`
+	// Bodies are only included when they differ to keep the output small
+	A string `json:"a,omitempty"`
+	B string `json:"b,omitempty"`
+}
+
+// RequestDiff is the difference between the request side of two entries
+type RequestDiff struct {
+	RequestIDA  string        `json:"request_id_a"`
+	RequestIDB  string        `json:"request_id_b"`
+	Method      *ValueChange  `json:"method,omitempty"`
+	URL         *ValueChange  `json:"url,omitempty"`
+	HTTPVersion *ValueChange  `json:"http_version,omitempty"`
+	Headers     NameValueDiff `json:"headers"`
+	QueryString NameValueDiff `json:"query_string"`
+	Body        BodyDiff      `json:"body"`
+}
+
+// DiffRequests compares the request side (headers, query parameters and body) of two entries.
+// Values are compared as captured but reported redacted.
+func (p *Parser) DiffRequests(harData *har.HAR, requestIDA, requestIDB string) (*RequestDiff, error) {
+	entryA, err := p.getEntry(harData, requestIDA)
+	if err != nil {
+		return nil, err
+	}
+	entryB, err := p.getEntry(harData, requestIDB)
+	if err != nil {
+		return nil, err
+	}
+
+	reqA := entryA.Request
+	if reqA == nil {
+		reqA = &har.Request{}
+	}
+	reqB := entryB.Request
+	if reqB == nil {
+		reqB = &har.Request{}
+	}
+
+	headersA := make([]NameValue, len(reqA.Headers))
+	for i, h := range reqA.Headers {
+		headersA[i] = NameValue{Name: h.Name, Value: h.Value}
+	}
+	headersB := make([]NameValue, len(reqB.Headers))
+	for i, h := range reqB.Headers {
+		headersB[i] = NameValue{Name: h.Name, Value: h.Value}
+	}
+
+	queryA := make([]NameValue, len(reqA.QueryString))
+	for i, q := range reqA.QueryString {
+		queryA[i] = NameValue{Name: q.Name, Value: q.Value}
+	}
+	queryB := make([]NameValue, len(reqB.QueryString))
+	for i, q := range reqB.QueryString {
+		queryB[i] = NameValue{Name: q.Name, Value: q.Value}
+	}
+
+	redaction := p.redaction()
+	return &RequestDiff{
+		RequestIDA:  requestIDA,
+		RequestIDB:  requestIDB,
+		Method:      diffValue(reqA.Method, reqB.Method),
+		URL:         diffValue(redaction.text(reqA.URL), redaction.text(reqB.URL)),
+		HTTPVersion: diffValue(reqA.HTTPVersion, reqB.HTTPVersion),
+		// HTTP header names are case insensitive, query parameter names are not
+		Headers:     diffNameValues(headersA, headersB, true, redaction),
+		QueryString: diffNameValues(queryA, queryB, false, redaction),
+		Body:        diffBodies(reqA.PostData, reqB.PostData, redaction),
+	}, nil
+}
+
+// diffValue returns nil when both values are equal
+func diffValue(a, b string) *ValueChange {
+	if a == b {
+		return nil
+	}
+	return &ValueChange{A: a, B: b}
+}
+
+// diffNameValues compares two lists of pairs, grouping repeated names.
+// When headers is true, names are matched case-insensitively and sensitive headers are redacted.
+func diffNameValues(a, b []NameValue, headers bool, redaction *redactor) NameValueDiff {
+	groupedA, namesA := groupNameValues(a, headers)
+	groupedB, namesB := groupNameValues(b, headers)
+
+	display := func(name, value string) string {
+		if headers {
+			return redaction.header(name, value)
+		}
+		return redaction.text(value)
+	}
+
+	var diff NameValueDiff
+	for _, key := range sortedKeys(groupedA) {
+		valueA := groupedA[key]
+		valueB, ok := groupedB[key]
+		switch {
+		case !ok:
+			diff.OnlyInA = append(diff.OnlyInA, NameValue{Name: namesA[key], Value: display(key, valueA)})
+		case valueA != valueB:
+			diff.Changed = append(diff.Changed, ValueChange{
+				Name: namesA[key],
+				A:    display(key, valueA),
+				B:    display(key, valueB),
+			})
+		}
+	}
+	for _, key := range sortedKeys(groupedB) {
+		if _, ok := groupedA[key]; !ok {
+			diff.OnlyInB = append(diff.OnlyInB, NameValue{Name: namesB[key], Value: display(key, groupedB[key])})
+		}
+	}
+
+	return diff
+}
+
+// groupNameValues joins the values of repeated names and remembers the original spelling of each name
+func groupNameValues(pairs []NameValue, caseInsensitive bool) (map[string]string, map[string]string) {
+	grouped := make(map[string]string)
+	names := make(map[string]string)
+	for _, pair := range pairs {
+		key := pair.Name
+		if caseInsensitive {
+			key = strings.ToLower(key)
+		}
+		if existing, ok := grouped[key]; ok {
+			grouped[key] = existing + ", " + pair.Value
+			continue
+		}
+		grouped[key] = pair.Value
+		names[key] = pair.Name
+	}
+	return grouped, names
+}
+
+// sortedKeys returns the keys of the map in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffBodies compares two request bodies
+func diffBodies(a, b *har.PostData, redaction *redactor) BodyDiff {
+	var textA, textB string
+	diff := BodyDiff{}
+	if a != nil {
+		textA = a.Text
+		diff.MimeTypeA = a.MimeType
+	}
+	if b != nil {
+		textB = b.Text
+		diff.MimeTypeB = b.MimeType
+	}
+
+	diff.SizeA = len(textA)
+	diff.SizeB = len(textB)
+	diff.Equal = textA == textB && diff.MimeTypeA == diff.MimeTypeB
+	if !diff.Equal {
+		diff.A = redaction.text(textA)
+		diff.B = redaction.text(textB)
+	}
+
+	return diff
+}
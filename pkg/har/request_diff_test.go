@@ -0,0 +1,78 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRequestsHeaders(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/api",
+			har.Header{Name: "Accept", Value: "application/json"},
+			har.Header{Name: "X-Trace", Value: "1"},
+		),
+		newTestEntry("GET", "https://example.com/api",
+			har.Header{Name: "accept", Value: "text/html"},
+			har.Header{Name: "X-Retry", Value: "true"},
+		),
+	)
+
+	diff, err := parser.DiffRequests(archive, "request_0", "request_1")
+	require.NoError(t, err)
+
+	assert.Nil(t, diff.Method)
+	assert.Nil(t, diff.URL)
+	assert.Equal(t, []ValueChange{{Name: "Accept", A: "application/json", B: "text/html"}}, diff.Headers.Changed)
+	assert.Equal(t, []NameValue{{Name: "X-Trace", Value: "1"}}, diff.Headers.OnlyInA)
+	assert.Equal(t, []NameValue{{Name: "X-Retry", Value: "true"}}, diff.Headers.OnlyInB)
+}
+
+func TestDiffRequestsRedactsAuthHeaders(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://example.com/api", har.Header{Name: "Authorization", Value: "Bearer old"}),
+		newTestEntry("GET", "https://example.com/api", har.Header{Name: "Authorization", Value: "Bearer new"}),
+	)
+
+	diff, err := parser.DiffRequests(archive, "request_0", "request_1")
+	require.NoError(t, err)
+
+	require.Len(t, diff.Headers.Changed, 1)
+	assert.Equal(t, "Authorization", diff.Headers.Changed[0].Name)
+	assert.Equal(t, "[REDACTED]", diff.Headers.Changed[0].A)
+	assert.Equal(t, "[REDACTED]", diff.Headers.Changed[0].B)
+}
+
+func TestDiffRequestsQueryAndBody(t *testing.T) {
+	parser := NewParser()
+	entryA := newTestEntry("POST", "https://example.com/api?page=1")
+	entryA.Request.QueryString = []har.QueryString{{Name: "page", Value: "1"}}
+	entryA.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"a":1}`}
+	entryB := newTestEntry("POST", "https://example.com/api?page=2")
+	entryB.Request.QueryString = []har.QueryString{{Name: "page", Value: "2"}}
+	entryB.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"a":2}`}
+
+	diff, err := parser.DiffRequests(newTestHAR(entryA, entryB), "request_0", "request_1")
+	require.NoError(t, err)
+
+	require.NotNil(t, diff.URL)
+	assert.Equal(t, []ValueChange{{Name: "page", A: "1", B: "2"}}, diff.QueryString.Changed)
+	assert.False(t, diff.Body.Equal)
+	assert.Equal(t, `{"a":1}`, diff.Body.A)
+	assert.Equal(t, `{"a":2}`, diff.Body.B)
+}
+
+func TestDiffRequestsInvalidID(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(newTestEntry("GET", "https://example.com"))
+
+	diff, err := parser.DiffRequests(archive, "request_0", "request_5")
+
+	assert.Error(t, err)
+	assert.Nil(t, diff)
+	assert.Contains(t, err.Error(), "request ID out of range")
+}
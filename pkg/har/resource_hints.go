@@ -0,0 +1,267 @@
+package har
+
+import (
+	"cmp"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// Resource hints checked by the effectiveness report
+const (
+	HintPreconnect  = "preconnect"
+	HintDNSPrefetch = "dns-prefetch"
+)
+
+// Verdicts of the resource hints
+const (
+	// HintEffective is a hint whose host was first requested with its connection, or its DNS
+	// lookup for dns-prefetch, already set up
+	HintEffective = "effective"
+	// HintIneffective is a hint whose host was first requested paying the setup anyway, the hint
+	// coming too late or, for preconnect, its crossorigin mode not matching the request
+	HintIneffective = "ineffective"
+	// HintRedundant is a hint for a host already requested when the hint was received
+	HintRedundant = "redundant"
+	// HintUnused is a hint for a host never requested afterwards
+	HintUnused = "unused"
+)
+
+const (
+	// hintReadyMS is the setup time below which a connection or lookup is considered ready
+	hintReadyMS = 1
+	// missedHintSetupMS is the setup time from which an unhinted host would have benefited from a hint
+	missedHintSetupMS = 50
+	// maxMissedHints caps the hosts listed as missing a hint
+	maxMissedHints = 10
+)
+
+var (
+	// linkTagPattern matches the link tags of an HTML document
+	linkTagPattern = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	// tagAttributePattern matches the rel and href attributes of a tag, quoted or not
+	tagAttributePattern = regexp.MustCompile(`(?is)\b(rel|href)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// ResourceHint is a preconnect or dns-prefetch hint and whether it saved time
+type ResourceHint struct {
+	Rel  string `json:"rel"`
+	Host string `json:"host"`
+	Href string `json:"href"`
+	// Source tells whether the hint was found in the HTML of the document or in a Link header
+	Source            string `json:"source"`
+	DocumentRequestID string `json:"document_request_id"`
+	Verdict           string `json:"verdict"`
+	// FirstRequestID is the first request to the host after the hint, LeadMS the time from the
+	// hint to that request
+	FirstRequestID string  `json:"first_request_id,omitempty"`
+	LeadMS         float64 `json:"lead_ms,omitempty"`
+	// SetupMS is the DNS lookup and connection time the first request paid, the DNS lookup only
+	// for dns-prefetch
+	SetupMS float64 `json:"setup_ms"`
+	// SavedMS estimates the time the hint saved, from the setup paid by unhinted hosts
+	SavedMS float64 `json:"saved_ms"`
+}
+
+// MissedHint is a host requested without hint whose first request paid a long setup
+type MissedHint struct {
+	Host      string  `json:"host"`
+	RequestID string  `json:"request_id"`
+	SetupMS   float64 `json:"setup_ms"`
+}
+
+// ResourceHintsReport tells whether the preconnect and dns-prefetch hints of the captured pages
+// saved connection setup time
+type ResourceHintsReport struct {
+	Hints       []ResourceHint `json:"hints"`
+	Effective   int            `json:"effective"`
+	Ineffective int            `json:"ineffective"`
+	Redundant   int            `json:"redundant"`
+	Unused      int            `json:"unused"`
+	// BaselineSetupMS and BaselineDNSMS are the median setup and DNS times of the first requests
+	// to the hosts without hint, from which the savings are estimated
+	BaselineSetupMS  float64 `json:"baseline_setup_ms"`
+	BaselineDNSMS    float64 `json:"baseline_dns_ms"`
+	EstimatedSavedMS float64 `json:"estimated_saved_ms"`
+	// Missed lists the hosts without hint whose first request paid the longest setups
+	Missed []MissedHint `json:"missed"`
+}
+
+// hostVisit is a request to a host, in capture order
+type hostVisit struct {
+	position int
+	start    time.Time
+	dnsMS    float64
+	setupMS  float64
+}
+
+// ReportResourceHints correlates the preconnect and dns-prefetch hints found in the link tags of
+// the HTML documents and in Link response headers with the timings of the first request to each
+// hinted host once the hint was received. A hint is effective when that request found its
+// connection, or its DNS lookup for dns-prefetch, already set up, and ineffective when it paid the
+// setup anyway. Savings are estimated from the median setup paid by the first requests to the
+// hosts without hint, the hosts whose first request paid the longest setups being listed as
+// missed opportunities. Timings are read from the timing index.
+func (p *Parser) ReportResourceHints(harData *har.HAR, index *TimingIndex) *ResourceHintsReport {
+	report := &ResourceHintsReport{Hints: []ResourceHint{}, Missed: []MissedHint{}}
+	redaction := p.redaction()
+	visits := make(map[string][]hostVisit)
+	var hosts []string
+	seen := make(map[string]bool)
+	// received holds the time each hint was received, aligned with the hints
+	var received []time.Time
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		host := requestHost(entry)
+		if _, ok := visits[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		timings := index.Timings(entry)
+		dns := max(phaseMS(timings.DNS), 0)
+		visits[host] = append(visits[host], hostVisit{position: i, start: entry.StartedDateTime, dnsMS: dns, setupMS: dns + max(phaseMS(timings.Connect), 0)})
+
+		// Hints apply once the response headers are received
+		headersReceived := index.End(entry).Add(-durationOf(phaseMS(timings.Receive)))
+		for _, hint := range documentHints(entry) {
+			key := hint.Rel + " " + hint.Host
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			hint.DocumentRequestID = formatRequestID(i)
+			hint.Href = redaction.text(hint.Href)
+			report.Hints = append(report.Hints, hint)
+			received = append(received, headersReceived)
+		}
+	}
+
+	// Baselines are the setups paid by the first requests to the hosts without hint
+	hinted := make(map[string]bool)
+	for _, hint := range report.Hints {
+		hinted[hint.Host] = true
+	}
+	var setups, lookups []float64
+	for _, host := range hosts[min(1, len(hosts)):] {
+		if first := visits[host][0]; !hinted[host] && first.setupMS > 0 {
+			setups = append(setups, first.setupMS)
+			lookups = append(lookups, first.dnsMS)
+		}
+	}
+	report.BaselineSetupMS = median(setups)
+	report.BaselineDNSMS = median(lookups)
+
+	for i := range report.Hints {
+		hint := &report.Hints[i]
+		hint.Verdict = HintUnused
+		for _, visit := range visits[hint.Host] {
+			if visit.start.Before(received[i]) {
+				hint.Verdict = HintRedundant
+				hint.FirstRequestID = formatRequestID(visit.position)
+				break
+			}
+			setup, baseline := visit.setupMS, report.BaselineSetupMS
+			if hint.Rel == HintDNSPrefetch {
+				setup, baseline = visit.dnsMS, report.BaselineDNSMS
+			}
+			hint.FirstRequestID = formatRequestID(visit.position)
+			hint.LeadMS = milliseconds(visit.start.Sub(received[i]))
+			hint.SetupMS = setup
+			hint.Verdict = HintIneffective
+			if setup < hintReadyMS {
+				hint.Verdict = HintEffective
+				hint.SavedMS = max(baseline-setup, 0)
+				report.EstimatedSavedMS += hint.SavedMS
+			}
+			break
+		}
+		switch hint.Verdict {
+		case HintEffective:
+			report.Effective++
+		case HintIneffective:
+			report.Ineffective++
+		case HintRedundant:
+			report.Redundant++
+		case HintUnused:
+			report.Unused++
+		}
+	}
+
+	// The host of the first document cannot be hinted
+	for _, host := range hosts[min(1, len(hosts)):] {
+		if first := visits[host][0]; !hinted[host] && first.setupMS >= missedHintSetupMS {
+			report.Missed = append(report.Missed, MissedHint{Host: host, RequestID: formatRequestID(first.position), SetupMS: first.setupMS})
+		}
+	}
+	slices.SortStableFunc(report.Missed, func(a, b MissedHint) int { return cmp.Compare(b.SetupMS, a.SetupMS) })
+	if len(report.Missed) > maxMissedHints {
+		report.Missed = report.Missed[:maxMissedHints]
+	}
+	return report
+}
+
+// documentHints returns the preconnect and dns-prefetch hints of the Link headers of the response
+// and, for HTML documents, of their link tags, resolved against the URL of the request
+func documentHints(entry *har.Entry) []ResourceHint {
+	if entry.Response == nil {
+		return nil
+	}
+	base, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return nil
+	}
+
+	var hints []ResourceHint
+	add := func(rel, href, source string) {
+		target, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || target.Host == "" {
+			return
+		}
+		for _, value := range strings.Fields(strings.ToLower(rel)) {
+			if value == HintPreconnect || value == HintDNSPrefetch {
+				hints = append(hints, ResourceHint{Rel: value, Host: target.Host, Href: target.String(), Source: source})
+			}
+		}
+	}
+
+	for _, header := range entry.Response.Headers {
+		if !strings.EqualFold(header.Name, "Link") {
+			continue
+		}
+		for _, link := range strings.Split(header.Value, ",") {
+			target, parameters, _ := strings.Cut(link, ";")
+			for _, parameter := range strings.Split(parameters, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+				if strings.EqualFold(name, "rel") {
+					add(strings.Trim(value, `"`), strings.Trim(strings.TrimSpace(target), "<>"), "header")
+				}
+			}
+		}
+	}
+
+	if !strings.Contains(strings.ToLower(responseMimeType(entry.Response)), "html") || entry.Response.Content == nil {
+		return hints
+	}
+	decoded, _ := decodedResponse(entry.Response)
+	for _, tag := range linkTagPattern.FindAllString(string(decoded.Content.Text), -1) {
+		var rel, href string
+		for _, attribute := range tagAttributePattern.FindAllStringSubmatch(tag, -1) {
+			value := attribute[2] + attribute[3] + attribute[4]
+			if strings.EqualFold(attribute[1], "rel") {
+				rel = value
+			} else {
+				href = value
+			}
+		}
+		if rel != "" && href != "" {
+			add(rel, href, "html")
+		}
+	}
+	return hints
+}
@@ -0,0 +1,59 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createResourceHintsHAR returns a page hinting four hosts in its HTML and Link header, followed
+// by the first requests to three of them and to an unhinted host
+func createResourceHintsHAR() string {
+	entry := func(started, url, timings, headers, content string) string {
+		return `{"startedDateTime": "2023-01-01T00:00:00.` + started + `Z", "time": 100, "timings": {` + timings + `},
+		 "request": {"method": "GET", "url": "` + url + `", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+		 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [` + headers + `], "cookies": [], "content": ` + content + `, "redirectURL": "", "headersSize": -1, "bodySize": 0}}`
+	}
+	empty := `{"size": 0, "mimeType": "text/plain"}`
+	html := `{"size": 0, "mimeType": "text/html", "text": "<html><head><link rel=\"preconnect\" href=\"https://cdn.example.com\" crossorigin><link rel='dns-prefetch' href='//fonts.example.net'><link href=https://unused.example.org rel=preconnect></head></html>"}`
+	return `{"log": {"version": "1.2", "creator": {"name": "test", "version": "1.0"}, "entries": [` + strings.Join([]string{
+		entry("000", "https://example.com/", `"dns": 20, "connect": 30, "send": 0, "wait": 40, "receive": 10`, `{"name": "Link", "value": "<https://api.example.com>; rel=preconnect"}`, html),
+		entry("200", "https://cdn.example.com/app.js", `"dns": -1, "connect": -1, "send": 0, "wait": 90, "receive": 10`, "", empty),
+		entry("300", "https://fonts.example.net/font.woff2", `"dns": 0, "connect": 40, "send": 0, "wait": 50, "receive": 10`, "", empty),
+		entry("400", "https://api.example.com/user", `"dns": 30, "connect": 50, "send": 0, "wait": 10, "receive": 10`, "", empty),
+		entry("500", "https://slow.example.io/pixel", `"dns": 40, "connect": 80, "send": 0, "wait": 0, "receive": 0`, "", empty),
+	}, ",") + `]}}`
+}
+
+func TestReportResourceHints(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(createResourceHintsHAR()))
+	require.NoError(t, err)
+
+	hints := parser.ReportResourceHints(harData, report.TimingIndex())
+	require.Len(t, hints.Hints, 4)
+	verdicts := make(map[string]string)
+	for _, hint := range hints.Hints {
+		verdicts[hint.Rel+" "+hint.Host] = hint.Verdict
+	}
+	assert.Equal(t, map[string]string{
+		"preconnect api.example.com":     HintIneffective,
+		"preconnect cdn.example.com":     HintEffective,
+		"dns-prefetch fonts.example.net": HintEffective,
+		"preconnect unused.example.org":  HintUnused,
+	}, verdicts)
+	assert.Equal(t, "header", hints.Hints[0].Source)
+	assert.Equal(t, "https://fonts.example.net", hints.Hints[2].Href)
+
+	assert.Equal(t, 2, hints.Effective)
+	assert.Equal(t, 1, hints.Ineffective)
+	assert.Equal(t, 1, hints.Unused)
+	assert.Equal(t, 120.0, hints.BaselineSetupMS)
+	assert.Equal(t, 40.0, hints.BaselineDNSMS)
+	assert.Equal(t, 160.0, hints.EstimatedSavedMS)
+	assert.Equal(t, 80.0, hints.Hints[0].SetupMS)
+	assert.Equal(t, 310.0, hints.Hints[0].LeadMS)
+	assert.Equal(t, []MissedHint{{Host: "slow.example.io", RequestID: "request_4", SetupMS: 120}}, hints.Missed)
+}
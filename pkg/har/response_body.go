@@ -0,0 +1,95 @@
+package har
+
+import (
+	"fmt"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultBodyPageSize is the number of bytes of a response body returned when no length is given
+	DefaultBodyPageSize = 16 * 1024
+	// MaxBodyPageSize caps the number of bytes of a response body returned at once
+	MaxBodyPageSize = 256 * 1024
+)
+
+// ResponseBody is a page of a response body.
+// Offsets are byte offsets into the full, decoded body.
+type ResponseBody struct {
+	RequestID string `json:"request_id"`
+	MimeType  string `json:"mime_type"`
+	TotalSize int    `json:"total_size"`
+	Offset    int    `json:"offset"`
+	Length    int    `json:"length"`
+	// NextOffset is where the next page starts, absent on the last page
+	NextOffset int `json:"next_offset,omitempty"`
+	// Base64Decoded is set when the HAR stored the body base64 encoded
+	Base64Decoded bool `json:"base64_decoded,omitempty"`
+	// DecodedContentEncodings are the content codings removed from the recorded body
+	DecodedContentEncodings []string `json:"decoded_content_encodings,omitempty"`
+	// DecodedCharset is the character set the recorded body was converted from
+	DecodedCharset string `json:"decoded_charset,omitempty"`
+	// Binary bodies are not returned, Text only holds a marker
+	Binary    bool   `json:"binary,omitempty"`
+	Truncated bool   `json:"truncated"`
+	Text      string `json:"text"`
+}
+
+// GetResponseBody returns at most length bytes of the response body of a request starting at
+// offset, so that large bodies can be read page by page. Non-positive lengths fall back to
+// DefaultBodyPageSize and lengths above MaxBodyPageSize are capped. Pages never cut a UTF-8
+// sequence in half and binary bodies are replaced by a marker.
+func (p *Parser) GetResponseBody(harData *har.HAR, requestID string, offset, length int) (*ResponseBody, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset: %d", offset)
+	}
+	if length <= 0 {
+		length = DefaultBodyPageSize
+	}
+	length = min(length, MaxBodyPageSize)
+
+	if entry.Response == nil || entry.Response.Content == nil {
+		return &ResponseBody{RequestID: requestID, MimeType: responseMimeType(entry.Response)}, nil
+	}
+	// Redact the whole decoded body so patterns are not split across pages
+	decodedResponse, decoded := decodedResponse(entry.Response)
+	response := p.redaction().response(decodedResponse)
+	body := &ResponseBody{
+		RequestID:               requestID,
+		MimeType:                responseMimeType(response),
+		DecodedContentEncodings: decoded.ContentEncodings,
+		DecodedCharset:          decoded.Charset,
+	}
+	content := response.Content.Text
+	body.TotalSize = len(content)
+	body.Base64Decoded = response.Content.Encoding == "base64"
+
+	if !isPrintableText(content) {
+		body.Binary = true
+		body.Truncated = len(content) > 0
+		body.Text = fmt.Sprintf("[binary content omitted: %d bytes of %s]", len(content), body.MimeType)
+		return body, nil
+	}
+	if offset > len(content) {
+		return nil, fmt.Errorf("offset %d is beyond the end of the %d bytes body", offset, len(content))
+	}
+
+	start := runeStart(content, offset)
+	end := runeEnd(content, min(len(content), start+length))
+	if end <= start && start < len(content) {
+		// The page is shorter than a single rune, return the whole rune
+		end = runeStart(content, start+1)
+	}
+	body.Offset = start
+	body.Length = end - start
+	body.Text = string(content[start:end])
+	if end < len(content) {
+		body.NextOffset = end
+		body.Truncated = true
+	}
+	return body, nil
+}
@@ -0,0 +1,195 @@
+package har
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// defaultRetryWindow is the maximum delay between a failure and the next identical request
+	defaultRetryWindow = time.Second
+	// defaultRetryMinAttempts is the minimum number of identical requests forming a storm
+	defaultRetryMinAttempts = 3
+	// backoffGrowthFactor is the minimum growth between successive gaps to call it exponential backoff
+	backoffGrowthFactor = 1.5
+)
+
+// RetryAttempt is one request of a retry burst
+type RetryAttempt struct {
+	RequestID string `json:"request_id"`
+	// OffsetMS is the start time relative to the first attempt of the burst
+	OffsetMS float64 `json:"offset_ms"`
+	Status   int     `json:"status"`
+	TimeMS   float64 `json:"time_ms"`
+}
+
+// RetryBurst is a sequence of identical requests fired in quick succession after failures
+type RetryBurst struct {
+	StartedDateTime string  `json:"started_datetime"`
+	Attempts        int     `json:"attempts"`
+	DurationMS      float64 `json:"duration_ms"`
+	// GapsMS are the delays between the end of an attempt and the start of the next one
+	GapsMS          []float64      `json:"gaps_ms"`
+	BackoffDetected bool           `json:"backoff_detected"`
+	Recovered       bool           `json:"recovered"`
+	Timeline        []RetryAttempt `json:"timeline"`
+}
+
+// RetryStormEndpoint groups the retry bursts of one endpoint
+type RetryStormEndpoint struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Bursts []RetryBurst `json:"bursts"`
+}
+
+// RetryStormReport lists the endpoints that were retried in bursts after errors
+type RetryStormReport struct {
+	WindowMS    int64                `json:"window_ms"`
+	MinAttempts int                  `json:"min_attempts"`
+	Endpoints   []RetryStormEndpoint `json:"endpoints"`
+}
+
+// retryCandidate is an entry with its request ID
+type retryCandidate struct {
+	requestID string
+	entry     *har.Entry
+}
+
+// GetRetryStorms detects bursts of identical requests (same method, URL and body) fired
+// within window of a 5xx or failed (status 0) response, durations being read from the timing
+// index. A zero window or minAttempts falls back to the defaults.
+func (p *Parser) GetRetryStorms(harData *har.HAR, index *TimingIndex, window time.Duration, minAttempts int) *RetryStormReport {
+	if window <= 0 {
+		window = defaultRetryWindow
+	}
+	if minAttempts <= 0 {
+		minAttempts = defaultRetryMinAttempts
+	}
+
+	report := &RetryStormReport{
+		WindowMS:    window.Milliseconds(),
+		MinAttempts: minAttempts,
+		Endpoints:   []RetryStormEndpoint{},
+	}
+
+	redaction := p.redaction()
+	groups := make(map[string][]retryCandidate)
+	var order []string
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		key := entry.Request.Method + " " + entry.Request.URL
+		if entry.Request.PostData != nil {
+			key += "\n" + entry.Request.PostData.Text
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], retryCandidate{requestID: formatRequestID(i), entry: entry})
+	}
+
+	for _, key := range order {
+		candidates := groups[key]
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].entry.StartedDateTime.Before(candidates[j].entry.StartedDateTime)
+		})
+
+		bursts := p.findRetryBursts(candidates, index, window, minAttempts)
+		if len(bursts) == 0 {
+			continue
+		}
+		report.Endpoints = append(report.Endpoints, RetryStormEndpoint{
+			Method: candidates[0].entry.Request.Method,
+			URL:    redaction.text(candidates[0].entry.Request.URL),
+			Bursts: bursts,
+		})
+	}
+
+	return report
+}
+
+// findRetryBursts scans the time-ordered attempts of one endpoint for retry bursts
+func (p *Parser) findRetryBursts(candidates []retryCandidate, index *TimingIndex, window time.Duration, minAttempts int) []RetryBurst {
+	var bursts []RetryBurst
+	var current []retryCandidate
+
+	flush := func() {
+		if len(current) >= minAttempts {
+			bursts = append(bursts, p.newRetryBurst(current, index))
+		}
+		current = nil
+	}
+
+	for _, candidate := range candidates {
+		if len(current) > 0 {
+			previous := current[len(current)-1].entry
+			if !isFailedResponse(previous) || retryGap(previous, candidate.entry, index) > window {
+				flush()
+			}
+		}
+		// A burst can only start with a failure
+		if len(current) == 0 && !isFailedResponse(candidate.entry) {
+			continue
+		}
+		current = append(current, candidate)
+	}
+	flush()
+
+	return bursts
+}
+
+// newRetryBurst summarizes a sequence of attempts
+func (p *Parser) newRetryBurst(attempts []retryCandidate, index *TimingIndex) RetryBurst {
+	first := attempts[0].entry
+	last := attempts[len(attempts)-1].entry
+	burst := RetryBurst{
+		StartedDateTime: p.formatTime(first.StartedDateTime, time.RFC3339Nano),
+		Attempts:        len(attempts),
+		DurationMS:      milliseconds(index.End(last).Sub(first.StartedDateTime)),
+		Recovered:       !isFailedResponse(last),
+		GapsMS:          []float64{},
+	}
+
+	for i, attempt := range attempts {
+		status := 0
+		if attempt.entry.Response != nil {
+			status = attempt.entry.Response.Status
+		}
+		burst.Timeline = append(burst.Timeline, RetryAttempt{
+			RequestID: attempt.requestID,
+			OffsetMS:  milliseconds(attempt.entry.StartedDateTime.Sub(first.StartedDateTime)),
+			Status:    status,
+			TimeMS:    index.Time(attempt.entry),
+		})
+		if i > 0 {
+			burst.GapsMS = append(burst.GapsMS, milliseconds(retryGap(attempts[i-1].entry, attempt.entry, index)))
+		}
+	}
+
+	burst.BackoffDetected = len(burst.GapsMS) > 1
+	for i := 1; i < len(burst.GapsMS); i++ {
+		if burst.GapsMS[i] < burst.GapsMS[i-1]*backoffGrowthFactor {
+			burst.BackoffDetected = false
+			break
+		}
+	}
+
+	return burst
+}
+
+// retryGap is the delay between the end of the previous attempt and the start of the next one
+func retryGap(previous, next *har.Entry, index *TimingIndex) time.Duration {
+	gap := next.StartedDateTime.Sub(index.End(previous))
+	if gap < 0 {
+		return 0
+	}
+	return gap
+}
+
+// isFailedResponse reports whether the entry ended with a server error or without a response
+func isFailedResponse(entry *har.Entry) bool {
+	return entry.Response == nil || entry.Response.Status == 0 || entry.Response.Status >= 500
+}
@@ -0,0 +1,115 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAttempt builds a GET entry started at the given offset with the given status and duration.
+func newTestAttempt(url string, offset time.Duration, status int, durationMS int64) *har.Entry {
+	entry := newTestEntry("GET", url)
+	entry.StartedDateTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(offset)
+	entry.Time = durationMS
+	entry.Response.Status = status
+	return entry
+}
+
+func TestGetRetryStormsDetectsBurst(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api", 0, 503, 10),
+		newTestAttempt("https://example.com/api", 15*time.Millisecond, 503, 10),
+		newTestAttempt("https://example.com/other", 20*time.Millisecond, 200, 10),
+		newTestAttempt("https://example.com/api", 30*time.Millisecond, 200, 10),
+	)
+
+	report := parser.GetRetryStorms(archive, nil, 0, 0)
+
+	require.Len(t, report.Endpoints, 1)
+	assert.Equal(t, "https://example.com/api", report.Endpoints[0].URL)
+	require.Len(t, report.Endpoints[0].Bursts, 1)
+	burst := report.Endpoints[0].Bursts[0]
+	assert.Equal(t, 3, burst.Attempts)
+	assert.Equal(t, []float64{5, 5}, burst.GapsMS)
+	assert.False(t, burst.BackoffDetected)
+	assert.True(t, burst.Recovered)
+	assert.Equal(t, "request_3", burst.Timeline[2].RequestID)
+	assert.Equal(t, float64(30), burst.Timeline[2].OffsetMS)
+}
+
+func TestGetRetryStormsDetectsBackoff(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api", 0, 500, 0),
+		newTestAttempt("https://example.com/api", 100*time.Millisecond, 500, 0),
+		newTestAttempt("https://example.com/api", 300*time.Millisecond, 500, 0),
+		newTestAttempt("https://example.com/api", 700*time.Millisecond, 500, 0),
+	)
+
+	report := parser.GetRetryStorms(archive, nil, time.Second, 3)
+
+	require.Len(t, report.Endpoints, 1)
+	burst := report.Endpoints[0].Bursts[0]
+	assert.True(t, burst.BackoffDetected)
+	assert.False(t, burst.Recovered)
+}
+
+func TestGetRetryStormsIgnoresSlowRetries(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api", 0, 502, 10),
+		newTestAttempt("https://example.com/api", 5*time.Second, 502, 10),
+		newTestAttempt("https://example.com/api", 10*time.Second, 502, 10),
+	)
+
+	report := parser.GetRetryStorms(archive, nil, time.Second, 3)
+
+	assert.Empty(t, report.Endpoints)
+}
+
+func TestGetRetryStormsIgnoresSuccessfulPolling(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/poll", 0, 200, 10),
+		newTestAttempt("https://example.com/poll", 20*time.Millisecond, 200, 10),
+		newTestAttempt("https://example.com/poll", 40*time.Millisecond, 200, 10),
+	)
+
+	report := parser.GetRetryStorms(archive, nil, time.Second, 3)
+
+	assert.Empty(t, report.Endpoints)
+}
+
+func TestGetRetryStormsRendersConfiguredTimeZone(t *testing.T) {
+	parser := NewParser()
+	parser.SetTimeZone(time.FixedZone("UTC-5", -5*3600))
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api", 0, 503, 10),
+		newTestAttempt("https://example.com/api", 15*time.Millisecond, 503, 10),
+		newTestAttempt("https://example.com/api", 30*time.Millisecond, 503, 10),
+	)
+
+	report := parser.GetRetryStorms(archive, nil, 0, 0)
+
+	require.Len(t, report.Endpoints, 1)
+	assert.Equal(t, "2022-12-31T19:00:00-05:00", report.Endpoints[0].Bursts[0].StartedDateTime)
+}
+
+func TestGetRetryStormsRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api?key=sk_live_abc", 0, 503, 10),
+		newTestAttempt("https://example.com/api?key=sk_live_abc", 15*time.Millisecond, 503, 10),
+		newTestAttempt("https://example.com/api?key=sk_live_abc", 30*time.Millisecond, 200, 10),
+	)
+
+	report := parser.GetRetryStorms(archive, nil, 0, 0)
+
+	require.Len(t, report.Endpoints, 1)
+	assert.Equal(t, "https://example.com/api?key=[REDACTED]", report.Endpoints[0].URL)
+}
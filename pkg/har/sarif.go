@@ -0,0 +1,141 @@
+package har
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifToolURI is the information URI of the SARIF tool driver
+	sarifToolURI = "https://github.com/tjamet/har-mcp"
+)
+
+// SARIFLog is a SARIF 2.1.0 log, the format code-scanning dashboards ingest
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a single run of the security scan
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the tool and its rules
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool component producing the results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is a rule the results refer to
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+}
+
+// SARIFConfiguration is the default configuration of a rule
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFMessage is a plain text message
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is a finding
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []SARIFLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+// SARIFLocation points at the HAR file and the requests of a finding
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// SARIFPhysicalLocation is the HAR file
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+// SARIFArtifactLocation is the URI of the HAR file
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFLogicalLocation is a request of the HAR file
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// ExportSARIF runs the security scan and converts its findings to a SARIF log, locating them
+// in the HAR file at artifactURI and, logically, in the requests providing evidence. Levels
+// follow the severities of the scoring policy.
+func (p *Parser) ExportSARIF(harData *har.HAR, artifactURI string) *SARIFLog {
+	report := p.ScanSecurity(harData)
+
+	driver := SARIFDriver{Name: "har-mcp", InformationURI: sarifToolURI, Rules: make([]SARIFRule, 0, len(SecurityRules))}
+	for _, rule := range SecurityRules {
+		driver.Rules = append(driver.Rules, SARIFRule{
+			ID:                   rule.ID,
+			ShortDescription:     SARIFMessage{Text: rule.Description},
+			DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(p.scoring.severity(rule.ID, rule.Severity))},
+		})
+	}
+
+	run := SARIFRun{Tool: SARIFTool{Driver: driver}, Results: make([]SARIFResult, 0, len(report.Findings))}
+	for _, finding := range report.Findings {
+		location := SARIFLocation{PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: artifactURI}}}
+		for _, requestID := range finding.RequestIDs {
+			location.LogicalLocations = append(location.LogicalLocations, SARIFLogicalLocation{
+				Name:               requestID,
+				FullyQualifiedName: requestID + "/" + finding.Location,
+				Kind:               "member",
+			})
+		}
+		// Fingerprints let dashboards track a finding across captures, whatever its request IDs
+		fingerprint := sha256.Sum256([]byte(strings.Join([]string{finding.RuleID, finding.Location, finding.Message}, "|")))
+		run.Results = append(run.Results, SARIFResult{
+			RuleID:              finding.RuleID,
+			Level:               sarifLevel(p.scoring.severity(finding.RuleID, finding.Severity)),
+			Message:             SARIFMessage{Text: finding.Message},
+			Locations:           []SARIFLocation{location},
+			PartialFingerprints: map[string]string{"harFinding/v1": hex.EncodeToString(fingerprint[:16])},
+		})
+	}
+
+	return &SARIFLog{Schema: sarifSchema, Version: sarifVersion, Runs: []SARIFRun{run}}
+}
+
+// sarifLevel maps a severity to a SARIF level
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,80 @@
+package har
+
+import (
+	"fmt"
+	"slices"
+)
+
+// severityScores are the base scores of the severities, weights scaling them
+var severityScores = map[string]float64{
+	SeverityHigh:   9,
+	SeverityMedium: 3,
+	SeverityLow:    1,
+}
+
+// findingCategories lists the categories of the composite findings
+var findingCategories = []string{FindingErrors, FindingSecurity, FindingPerformance, FindingCaching, FindingContent}
+
+// ScoringPolicy adjusts the composite findings to the priorities of a team. Findings are ranked
+// by score, the base score of their severity (9 for high, 3 for medium, 1 for low) multiplied by
+// their weight, so that with a weight of 3 medium security findings rank along high ones.
+type ScoringPolicy struct {
+	// Severities override the severity of checks, keyed by check such as cookie-missing-secure
+	// or slow_request
+	Severities map[string]string `json:"severities,omitempty"`
+	// CategoryWeights weigh the findings of a category, 1 by default, 0 hiding them
+	CategoryWeights map[string]float64 `json:"category_weights,omitempty"`
+	// CheckWeights weigh the findings of a check, replacing the weight of their category
+	CheckWeights map[string]float64 `json:"check_weights,omitempty"`
+}
+
+// validate rejects unknown severities and categories, and negative weights
+func (s ScoringPolicy) validate() error {
+	for check, severity := range s.Severities {
+		if _, ok := severityScores[severity]; !ok {
+			return fmt.Errorf("invalid severity %q for check %s, expected high, medium or low", severity, check)
+		}
+	}
+	for category, weight := range s.CategoryWeights {
+		if !slices.Contains(findingCategories, category) {
+			return fmt.Errorf("unknown finding category %q, expected one of %v", category, findingCategories)
+		}
+		if weight < 0 {
+			return fmt.Errorf("invalid weight %v for category %s, weights cannot be negative", weight, category)
+		}
+	}
+	for check, weight := range s.CheckWeights {
+		if weight < 0 {
+			return fmt.Errorf("invalid weight %v for check %s, weights cannot be negative", weight, check)
+		}
+	}
+	return nil
+}
+
+// severity returns the severity of a check, as overridden by the policy
+func (s ScoringPolicy) severity(check, severity string) string {
+	if override, ok := s.Severities[check]; ok {
+		return override
+	}
+	return severity
+}
+
+// weight returns the weight of the findings of a check in a category
+func (s ScoringPolicy) weight(category, check string) float64 {
+	if weight, ok := s.CheckWeights[check]; ok {
+		return weight
+	}
+	if weight, ok := s.CategoryWeights[category]; ok {
+		return weight
+	}
+	return 1
+}
+
+// SetScoringPolicy replaces the scoring policy of the composite findings
+func (p *Parser) SetScoringPolicy(policy ScoringPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	p.scoring = policy
+	return nil
+}
@@ -0,0 +1,56 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAllFindingsAppliesScoringPolicy(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetScoringPolicy(ScoringPolicy{
+		Severities:      map[string]string{"slow_request": SeverityMedium},
+		CategoryWeights: map[string]float64{FindingSecurity: 4, FindingErrors: 0},
+		CheckWeights:    map[string]float64{"retry_storm": 0.5},
+	}))
+	archive := newTestHAR(
+		newTestAttempt("https://example.com/api", 0, 503, 10),
+		newTestAttempt("https://example.com/api", 15*time.Millisecond, 503, 10),
+		newTestAttempt("https://example.com/api", 30*time.Millisecond, 503, 10),
+		newTestAttempt("http://example.com/report", time.Second, 200, 4500),
+	)
+
+	result := parser.GetAllFindings(archive)
+
+	var ranking []string
+	for _, finding := range result.Findings {
+		ranking = append(ranking, finding.Check)
+	}
+	assert.Equal(t, []string{RuleInsecureTransport, "retry_storm", "slow_request"}, ranking, "error findings are hidden")
+	assert.Equal(t, 12.0, result.Findings[0].Score)
+	assert.Equal(t, 4.5, result.Findings[1].Score)
+	assert.Equal(t, SeverityMedium, result.Findings[2].Severity)
+	assert.Equal(t, 3.0, result.Findings[2].Score)
+	assert.Equal(t, map[string]int{SeverityHigh: 1, SeverityMedium: 2}, result.BySeverity)
+	assert.NotContains(t, result.ByCategory, FindingErrors)
+}
+
+func TestExportSARIFAppliesSeverityOverrides(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetScoringPolicy(ScoringPolicy{Severities: map[string]string{RuleInsecureTransport: SeverityHigh}}))
+
+	log := parser.ExportSARIF(newTestHAR(newTestEntry("GET", "http://example.com/")), "capture.har")
+
+	require.Len(t, log.Runs[0].Results, 1)
+	assert.Equal(t, "error", log.Runs[0].Results[0].Level)
+}
+
+func TestSetScoringPolicyRejectsInvalidPolicies(t *testing.T) {
+	parser := NewParser()
+
+	assert.ErrorContains(t, parser.SetScoringPolicy(ScoringPolicy{Severities: map[string]string{"slow_request": "critical"}}), `invalid severity "critical"`)
+	assert.ErrorContains(t, parser.SetScoringPolicy(ScoringPolicy{CategoryWeights: map[string]float64{"privacy": 2}}), `unknown finding category "privacy"`)
+	assert.ErrorContains(t, parser.SetScoringPolicy(ScoringPolicy{CheckWeights: map[string]float64{"slow_request": -1}}), "cannot be negative")
+}
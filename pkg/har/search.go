@@ -0,0 +1,220 @@
+package har
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Fields searched by SearchEntries
+const (
+	SearchFieldURL             = "url"
+	SearchFieldRequestHeaders  = "request_headers"
+	SearchFieldResponseHeaders = "response_headers"
+	SearchFieldQueryString     = "query_string"
+	SearchFieldRequestBody     = "request_body"
+	SearchFieldResponseBody    = "response_body"
+)
+
+const (
+	// defaultSearchMaxResults caps the number of matches returned
+	defaultSearchMaxResults = 100
+	// defaultSearchWindow is the number of bytes of context shown on each side of a match
+	defaultSearchWindow = 40
+)
+
+// searchFields lists every searchable field, in the order they are searched
+var searchFields = []string{
+	SearchFieldURL,
+	SearchFieldRequestHeaders,
+	SearchFieldResponseHeaders,
+	SearchFieldQueryString,
+	SearchFieldRequestBody,
+	SearchFieldResponseBody,
+}
+
+// SearchQuery describes a search across entries
+type SearchQuery struct {
+	Query string `json:"query"`
+	// Fields restricts the search to some fields, every field is searched when empty
+	Fields []string `json:"fields,omitempty"`
+	// Regex interprets Query as a regular expression instead of a plain substring
+	Regex         bool `json:"regex,omitempty"`
+	CaseSensitive bool `json:"case_sensitive,omitempty"`
+	MaxResults    int  `json:"max_results,omitempty"`
+	// Window is the number of bytes of context shown on each side of a match, and MaxExcerpts
+	// caps the excerpts returned per field value
+	Window      int `json:"window,omitempty"`
+	MaxExcerpts int `json:"max_excerpts,omitempty"`
+}
+
+// SearchMatch is an occurrence of the query in an entry
+type SearchMatch struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Field     string `json:"field"`
+	// Name is the header or query parameter name when the match is in one
+	Name string `json:"name,omitempty"`
+	// Excerpts show the occurrences with some context, their offsets being byte offsets into the
+	// field value
+	Excerpts []Excerpt `json:"excerpts"`
+}
+
+// SearchResult lists the matches of a search
+type SearchResult struct {
+	Matches []SearchMatch `json:"matches"`
+	// Truncated is set when more matches than MaxResults were found
+	Truncated bool `json:"truncated"`
+}
+
+// searchTarget is a searchable value of an entry
+type searchTarget struct {
+	field string
+	name  string
+	value string
+}
+
+// SearchEntries finds the entries containing the query in their URL, headers, query string or
+// bodies, reporting each field value matching with excerpts around its occurrences, so matches
+// in huge bodies stay consumable. Binary response bodies are skipped and excerpts are redacted
+// according to the redaction policy.
+func (p *Parser) SearchEntries(harData *har.HAR, query SearchQuery) (*SearchResult, error) {
+	match, err := compileSearch(query)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := selectSearchFields(query.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	maxResults := query.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultSearchMaxResults
+	}
+	window := query.Window
+	if window <= 0 {
+		window = defaultSearchWindow
+	}
+
+	redaction := p.redaction()
+	result := &SearchResult{Matches: []SearchMatch{}}
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		for _, target := range searchTargets(entry, fields) {
+			excerpts := excerptsAround([]byte(target.value), func(from int) (int, int, bool) {
+				return match(target.value, from)
+			}, window, query.MaxExcerpts)
+			if len(excerpts) == 0 {
+				continue
+			}
+			if len(result.Matches) == maxResults {
+				result.Truncated = true
+				return result, nil
+			}
+			sensitive := (target.field == SearchFieldRequestHeaders || target.field == SearchFieldResponseHeaders) && redaction.sensitiveHeader(target.name)
+			for j := range excerpts {
+				excerpts[j].Text = redaction.text(excerpts[j].Text)
+				if sensitive {
+					excerpts[j].Text = redactedValue
+				}
+			}
+			result.Matches = append(result.Matches, SearchMatch{
+				RequestID: formatRequestID(i),
+				Method:    entry.Request.Method,
+				URL:       redaction.text(entry.Request.URL),
+				Field:     target.field,
+				Name:      target.name,
+				Excerpts:  excerpts,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// compileSearch returns a function locating the first occurrence of the query in a value from an offset
+func compileSearch(query SearchQuery) (func(string, int) (int, int, bool), error) {
+	if query.Query == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
+
+	pattern := query.Query
+	if !query.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !query.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	return func(value string, from int) (int, int, bool) {
+		loc := re.FindStringIndex(value[from:])
+		if loc == nil {
+			return 0, 0, false
+		}
+		return from + loc[0], from + loc[1], true
+	}, nil
+}
+
+// selectSearchFields validates the requested fields, defaulting to every field
+func selectSearchFields(requested []string) (map[string]bool, error) {
+	selected := make(map[string]bool)
+	if len(requested) == 0 {
+		for _, field := range searchFields {
+			selected[field] = true
+		}
+		return selected, nil
+	}
+
+	for _, field := range requested {
+		known := false
+		for _, candidate := range searchFields {
+			known = known || candidate == field
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown search field %q, expected one of %s", field, strings.Join(searchFields, ", "))
+		}
+		selected[field] = true
+	}
+	return selected, nil
+}
+
+// searchTargets lists the values of the selected fields of an entry
+func searchTargets(entry *har.Entry, fields map[string]bool) []searchTarget {
+	var targets []searchTarget
+	if fields[SearchFieldURL] {
+		targets = append(targets, searchTarget{field: SearchFieldURL, value: entry.Request.URL})
+	}
+	if fields[SearchFieldRequestHeaders] {
+		for _, header := range entry.Request.Headers {
+			targets = append(targets, searchTarget{field: SearchFieldRequestHeaders, name: header.Name, value: header.Value})
+		}
+	}
+	if fields[SearchFieldResponseHeaders] && entry.Response != nil {
+		for _, header := range entry.Response.Headers {
+			targets = append(targets, searchTarget{field: SearchFieldResponseHeaders, name: header.Name, value: header.Value})
+		}
+	}
+	if fields[SearchFieldQueryString] {
+		for _, param := range entry.Request.QueryString {
+			targets = append(targets, searchTarget{field: SearchFieldQueryString, name: param.Name, value: param.Value})
+		}
+	}
+	if fields[SearchFieldRequestBody] && entry.Request.PostData != nil {
+		targets = append(targets, searchTarget{field: SearchFieldRequestBody, value: entry.Request.PostData.Text})
+	}
+	if fields[SearchFieldResponseBody] && entry.Response != nil && entry.Response.Content != nil && isPrintableText(entry.Response.Content.Text) {
+		targets = append(targets, searchTarget{field: SearchFieldResponseBody, value: string(entry.Response.Content.Text)})
+	}
+	return targets
+}
@@ -0,0 +1,143 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSearchUUID = "3f2b8c1e-9a4d-4e7b-b5f6-0c1d2e3f4a5b"
+
+// newTestSearchHAR builds a capture where a UUID flows through every searchable field
+func newTestSearchHAR() *har.HAR {
+	created := newTestResponseEntry("https://api.example.com/orders", "application/json",
+		`{"order": {"id": "`+strings.ToUpper(testSearchUUID)+`", "note": "`+strings.Repeat("x", 100)+`"}}`)
+	created.Request.Method = "POST"
+	created.Request.PostData = &har.PostData{MimeType: "application/json", Text: `{"cart": "c-1"}`}
+
+	fetched := newTestEntry("GET", "https://api.example.com/orders/"+testSearchUUID+"?expand=items",
+		har.Header{Name: "Authorization", Value: "Bearer " + testSearchUUID},
+		har.Header{Name: "X-Order", Value: testSearchUUID})
+	fetched.Request.QueryString = []har.QueryString{{Name: "ref", Value: testSearchUUID}}
+
+	image := newTestResponseEntry("https://cdn.example.com/"+"logo.png", "image/png", "\x89PNG\x00"+testSearchUUID)
+
+	return newTestHAR(created, fetched, image)
+}
+
+func TestSearchEntriesFindsValuesAcrossFields(t *testing.T) {
+	parser := NewParser()
+
+	result, err := parser.SearchEntries(newTestSearchHAR(), SearchQuery{Query: testSearchUUID})
+	require.NoError(t, err)
+
+	require.Len(t, result.Matches, 5)
+	assert.False(t, result.Truncated)
+	assert.Equal(t, SearchMatch{
+		RequestID: "request_0",
+		Method:    "POST",
+		URL:       "https://api.example.com/orders",
+		Field:     SearchFieldResponseBody,
+		Excerpts: []Excerpt{{
+			MatchOffset: 18,
+			Start:       0,
+			End:         94,
+			Text:        `{"order": {"id": "` + strings.ToUpper(testSearchUUID) + `", "note": "` + strings.Repeat("x", 28),
+		}},
+	}, result.Matches[0])
+
+	fields := []string{}
+	for _, match := range result.Matches[1:] {
+		assert.Equal(t, "request_1", match.RequestID)
+		fields = append(fields, match.Field+" "+match.Name)
+	}
+	assert.Equal(t, []string{"url ", "request_headers Authorization", "request_headers X-Order", "query_string ref"}, fields)
+	assert.Equal(t, redactedValue, result.Matches[2].Excerpts[0].Text)
+	assert.Equal(t, testSearchUUID, result.Matches[3].Excerpts[0].Text)
+}
+
+func TestSearchEntriesScopesFieldsAndCase(t *testing.T) {
+	parser := NewParser()
+
+	result, err := parser.SearchEntries(newTestSearchHAR(), SearchQuery{
+		Query:         testSearchUUID,
+		Fields:        []string{SearchFieldResponseBody, SearchFieldURL},
+		CaseSensitive: true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, SearchFieldURL, result.Matches[0].Field)
+	assert.Equal(t, "https://api.example.com/orders/"+testSearchUUID+"?expand=items", result.Matches[0].Excerpts[0].Text)
+}
+
+func TestSearchEntriesMatchesRegexAndTruncates(t *testing.T) {
+	parser := NewParser()
+
+	result, err := parser.SearchEntries(newTestSearchHAR(), SearchQuery{
+		Query:      `[0-9a-f]{8}-[0-9a-f]{4}-`,
+		Regex:      true,
+		MaxResults: 1,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, "request_0", result.Matches[0].RequestID)
+	assert.True(t, result.Truncated)
+}
+
+func TestSearchEntriesRejectsInvalidQueries(t *testing.T) {
+	parser := NewParser()
+	archive := newTestSearchHAR()
+
+	_, err := parser.SearchEntries(archive, SearchQuery{})
+	assert.Error(t, err)
+
+	_, err = parser.SearchEntries(archive, SearchQuery{Query: "(", Regex: true})
+	assert.ErrorContains(t, err, "invalid regular expression")
+
+	_, err = parser.SearchEntries(archive, SearchQuery{Query: "x", Fields: []string{"cookies"}})
+	assert.ErrorContains(t, err, "unknown search field")
+}
+
+func TestSearchEntriesExcerptsEveryOccurrenceWithinTheWindow(t *testing.T) {
+	parser := NewParser()
+	body := "id=" + testSearchUUID + strings.Repeat(" ", 200) + "ref=" + testSearchUUID
+	entry := newTestResponseEntry("https://api.example.com/orders", "text/plain", body)
+
+	result, err := parser.SearchEntries(newTestHAR(entry), SearchQuery{Query: testSearchUUID, Window: 4, Fields: []string{SearchFieldResponseBody}})
+	require.NoError(t, err)
+
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, []Excerpt{
+		{MatchOffset: 3, Start: 0, End: 43, Text: "id=" + testSearchUUID + "    "},
+		{MatchOffset: 243, Start: 239, End: 279, Text: "ref=" + testSearchUUID},
+	}, result.Matches[0].Excerpts)
+}
+
+func TestSearchEntriesCapsExcerpts(t *testing.T) {
+	parser := NewParser()
+	entry := newTestResponseEntry("https://api.example.com/orders", "text/plain", strings.Repeat(testSearchUUID+strings.Repeat(" ", 100), 3))
+
+	result, err := parser.SearchEntries(newTestHAR(entry), SearchQuery{Query: testSearchUUID, MaxExcerpts: 2, Fields: []string{SearchFieldResponseBody}})
+	require.NoError(t, err)
+
+	excerpts := result.Matches[0].Excerpts
+	require.Len(t, excerpts, 2)
+	assert.True(t, excerpts[1].Truncated)
+}
+
+func TestSearchEntriesRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	entry := newTestResponseEntry("https://api.example.com/orders?key=sk_live_abc", "text/plain", testSearchUUID)
+
+	result, err := parser.SearchEntries(newTestHAR(entry), SearchQuery{Query: testSearchUUID})
+	require.NoError(t, err)
+
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, "https://api.example.com/orders?key=[REDACTED]", result.Matches[0].URL)
+}
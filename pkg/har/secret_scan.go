@@ -0,0 +1,152 @@
+package har
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Secret kinds detected by ScanSecrets on top of the credential formats of the security scan
+const (
+	SecretJWT            = "JWT"
+	SecretURLCredentials = "basic auth credentials in URL"
+	SecretQueryAPIKey    = "API key in query string"
+)
+
+var (
+	// embeddedJWTPattern finds JWTs within a text, their header and payload being JSON objects
+	embeddedJWTPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*`)
+	// urlCredentialsPattern finds URLs carrying a user and a password, the password being captured
+	urlCredentialsPattern = regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^/\s:@"']+:([^/\s@"']+)@`)
+	// queryKeyPattern matches the names of query parameters carrying API keys or tokens
+	queryKeyPattern = regexp.MustCompile(`(?i)^(api[_-]?key|apikey|key|api[_-]?token|access[_-]?token|auth[_-]?token|token|client[_-]?secret|secret|password|passwd)$`)
+)
+
+// SecretFinding locates a secret found in the capture. The secret itself is never exposed,
+// only a fingerprint telling secrets apart; JWTs share the fingerprints of token_expiry_report.
+type SecretFinding struct {
+	Kind string `json:"kind"`
+	// Location is where the secret shows, e.g. request.url, request.query.api_key,
+	// request.headers.X-Debug or response.body
+	Location    string `json:"location"`
+	Fingerprint string `json:"fingerprint"`
+	// Occurrences counts the requests carrying the secret at this location, RequestIDs lists the
+	// first of them
+	Occurrences int      `json:"occurrences"`
+	RequestIDs  []string `json:"request_ids"`
+}
+
+// SecretScanReport lists the secrets found across a capture, in the order they were first sent
+type SecretScanReport struct {
+	Entries  int             `json:"entries"`
+	Findings []SecretFinding `json:"findings"`
+}
+
+// secretScan accumulates the secrets found, merging those sent again at the same location
+type secretScan struct {
+	findings map[string]*SecretFinding
+	order    []string
+}
+
+// add records a secret found at the location of the request
+func (s *secretScan) add(kind, location, fingerprint, requestID string) {
+	key := kind + "|" + location + "|" + fingerprint
+	finding, ok := s.findings[key]
+	if !ok {
+		finding = &SecretFinding{Kind: kind, Location: location, Fingerprint: fingerprint}
+		s.findings[key] = finding
+		s.order = append(s.order, key)
+	}
+	if slices.Contains(finding.RequestIDs, requestID) {
+		return
+	}
+	finding.Occurrences++
+	if len(finding.RequestIDs) < maxSecurityEvidence {
+		finding.RequestIDs = append(finding.RequestIDs, requestID)
+	}
+}
+
+// check records every secret found in the text
+func (s *secretScan) check(location, text, requestID string) {
+	for _, secret := range secretPatterns {
+		for _, value := range secret.pattern.FindAllString(text, -1) {
+			s.add(secret.kind, location, secretFingerprint(value), requestID)
+		}
+	}
+	for _, value := range embeddedJWTPattern.FindAllString(text, -1) {
+		s.add(SecretJWT, location, jwtFingerprint(value), requestID)
+	}
+	for _, match := range urlCredentialsPattern.FindAllStringSubmatch(text, -1) {
+		s.add(SecretURLCredentials, location, secretFingerprint(match[1]), requestID)
+	}
+}
+
+// ScanSecrets looks for secrets across the URLs, headers and bodies of the capture: cloud and
+// API keys, GitHub and Slack tokens, private keys, JWTs, credentials embedded in URLs and API
+// keys passed as query parameters. Headers meant to carry credentials (Authorization, Cookie,
+// Set-Cookie, ...) are left out as the redaction policy covers them. Secret values are never
+// reported, findings carry a fingerprint instead.
+func (p *Parser) ScanSecrets(harData *har.HAR) *SecretScanReport {
+	scan := &secretScan{findings: make(map[string]*SecretFinding)}
+	report := &SecretScanReport{}
+
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		report.Entries++
+		requestID := formatRequestID(i)
+
+		scan.check("request.url", entry.Request.URL, requestID)
+		if u, err := url.Parse(entry.Request.URL); err == nil {
+			query := u.Query()
+			for _, name := range slices.Sorted(maps.Keys(query)) {
+				if !queryKeyPattern.MatchString(name) {
+					continue
+				}
+				for _, value := range query[name] {
+					if value != "" {
+						scan.add(SecretQueryAPIKey, "request.query."+name, secretFingerprint(value), requestID)
+					}
+				}
+			}
+		}
+		for _, header := range entry.Request.Headers {
+			if !slices.Contains(credentialHeaders, strings.ToLower(header.Name)) {
+				scan.check("request.headers."+header.Name, header.Value, requestID)
+			}
+		}
+		if entry.Request.PostData != nil {
+			scan.check("request.body", entry.Request.PostData.Text, requestID)
+		}
+		if entry.Response == nil {
+			continue
+		}
+		for _, header := range entry.Response.Headers {
+			if !strings.EqualFold(header.Name, "Set-Cookie") {
+				scan.check("response.headers."+header.Name, header.Value, requestID)
+			}
+		}
+		if entry.Response.Content != nil && isPrintableText(entry.Response.Content.Text) {
+			scan.check("response.body", string(entry.Response.Content.Text), requestID)
+		}
+	}
+
+	report.Findings = make([]SecretFinding, 0, len(scan.order))
+	for _, key := range scan.order {
+		report.Findings = append(report.Findings, *scan.findings[key])
+	}
+	return report
+}
+
+// secretFingerprint identifies a secret without exposing it
+func secretFingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "secret_" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,283 @@
+package har
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// maxSecurityEvidence caps the request IDs listed per security finding
+const maxSecurityEvidence = 10
+
+// Security scan rules
+const (
+	RuleSecretExposed         = "secret-exposed"
+	RuleCookieMissingSecure   = "cookie-missing-secure"
+	RuleCookieMissingHTTPOnly = "cookie-missing-httponly"
+	RuleCookieMissingSameSite = "cookie-missing-samesite"
+	RuleInsecureTransport     = "insecure-transport"
+	RuleMixedContent          = "mixed-content"
+	RuleCredentialsOverHTTP   = "credentials-over-http"
+	RuleCORSWildcardCreds     = "cors-wildcard-credentials"
+	RuleCORSNullOrigin        = "cors-null-origin"
+	RuleCORSReflectedOrigin   = "cors-reflected-origin"
+)
+
+// SecurityRule describes a security scan rule
+type SecurityRule struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// SecurityRules lists the rules of the security scan
+var SecurityRules = []SecurityRule{
+	{RuleSecretExposed, SeverityHigh, "A credential such as a cloud or API secret key travels in a URL, header or body"},
+	{RuleCookieMissingSecure, SeverityMedium, "A cookie set over HTTPS lacks the Secure attribute and may be sent in clear text"},
+	{RuleCookieMissingHTTPOnly, SeverityLow, "A cookie lacks the HttpOnly attribute and is readable by scripts"},
+	{RuleCookieMissingSameSite, SeverityLow, "A cookie lacks a SameSite attribute restricting cross-site requests"},
+	{RuleInsecureTransport, SeverityMedium, "Requests are sent over plain HTTP to a remote host"},
+	{RuleMixedContent, SeverityMedium, "An HTTPS page loads a resource over plain HTTP"},
+	{RuleCredentialsOverHTTP, SeverityHigh, "Credentials are sent over plain HTTP"},
+	{RuleCORSWildcardCreds, SeverityHigh, "CORS allows any origin along with credentials"},
+	{RuleCORSNullOrigin, SeverityMedium, "CORS allows the null origin, which sandboxed documents can forge"},
+	{RuleCORSReflectedOrigin, SeverityMedium, "CORS echoes a cross-site origin with credentials, check it is allow-listed rather than reflected"},
+}
+
+// secretPatterns identify well-known credential formats
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (RSA |EC |DSA |OPENSSH |ENCRYPTED )?PRIVATE KEY-----`)},
+	{"Stripe secret key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{16,}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+}
+
+// credentialHeaders are request headers expected to carry credentials, scanned for transport but not for secrets
+var credentialHeaders = []string{"authorization", "proxy-authorization", "cookie", "x-api-key"}
+
+// SecurityFinding is a security issue with the requests providing evidence
+type SecurityFinding struct {
+	RuleID   string `json:"rule_id"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Location is where the issue shows, e.g. response.headers.Set-Cookie or request.body
+	Location   string   `json:"location"`
+	RequestIDs []string `json:"request_ids"`
+}
+
+// SecurityReport lists the security findings of a capture, the most severe first
+type SecurityReport struct {
+	Findings []SecurityFinding `json:"findings"`
+}
+
+// securityScan accumulates findings, merging those about the same subject
+type securityScan struct {
+	findings map[string]*SecurityFinding
+	order    []string
+	// sprintf formats the messages in the language of the parser
+	sprintf func(format string, args ...interface{}) string
+}
+
+// add records evidence for a finding about the given subject
+func (s *securityScan) add(rule, subject, location, message, requestID string) {
+	key := rule + "|" + subject + "|" + location
+	finding, ok := s.findings[key]
+	if !ok {
+		finding = &SecurityFinding{RuleID: rule, Severity: securityRuleSeverity(rule), Message: message, Location: location}
+		s.findings[key] = finding
+		s.order = append(s.order, key)
+	}
+	if len(finding.RequestIDs) < maxSecurityEvidence && !slices.Contains(finding.RequestIDs, requestID) {
+		finding.RequestIDs = append(finding.RequestIDs, requestID)
+	}
+}
+
+// ScanSecurity looks for security issues in the capture: exposed secrets, cookies lacking
+// the Secure, HttpOnly or SameSite attributes, plain HTTP requests and CORS misconfigurations.
+// Findings about the same subject, such as a cookie or a host, are merged and list every
+// request providing evidence. Secret values are never reported.
+func (p *Parser) ScanSecurity(harData *har.HAR) *SecurityReport {
+	scan := &securityScan{findings: make(map[string]*SecurityFinding), sprintf: p.sprintf}
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		requestID := formatRequestID(i)
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+
+		scanSecrets(scan, entry, requestID)
+		scanTransport(scan, entry, u, requestID)
+		if entry.Response != nil {
+			scanCookies(scan, entry.Response, u, requestID)
+			scanCORS(scan, entry, u, requestID)
+		}
+	}
+
+	report := &SecurityReport{Findings: make([]SecurityFinding, 0, len(scan.order))}
+	for _, key := range scan.order {
+		report.Findings = append(report.Findings, *scan.findings[key])
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank(report.Findings[i].Severity) < severityRank(report.Findings[j].Severity)
+	})
+	return report
+}
+
+// scanSecrets looks for well-known credential formats in the URL, headers and bodies
+func scanSecrets(scan *securityScan, entry *har.Entry, requestID string) {
+	check := func(location, text string) {
+		for _, secret := range secretPatterns {
+			if secret.pattern.MatchString(text) {
+				scan.add(RuleSecretExposed, secret.kind, location, scan.sprintf("%s found in %s", secret.kind, location), requestID)
+			}
+		}
+	}
+
+	check("request.url", entry.Request.URL)
+	for _, header := range entry.Request.Headers {
+		if !slices.Contains(credentialHeaders, strings.ToLower(header.Name)) {
+			check("request.headers."+header.Name, header.Value)
+		}
+	}
+	if entry.Request.PostData != nil {
+		check("request.body", entry.Request.PostData.Text)
+	}
+	if entry.Response != nil && entry.Response.Content != nil && isPrintableText(entry.Response.Content.Text) {
+		check("response.body", string(entry.Response.Content.Text))
+	}
+}
+
+// scanTransport reports requests sent over plain HTTP, with credentials or from HTTPS pages
+func scanTransport(scan *securityScan, entry *har.Entry, u *url.URL, requestID string) {
+	if u.Scheme != "http" || isLoopbackHost(u.Hostname()) {
+		return
+	}
+	scan.add(RuleInsecureTransport, u.Host, "request.url", scan.sprintf("requests to %s are sent over plain HTTP", u.Host), requestID)
+
+	if referer := requestReferer(entry.Request); referer != nil && referer.Scheme == "https" {
+		scan.add(RuleMixedContent, u.Host, "request.url", scan.sprintf("HTTPS pages of %s load resources from %s over plain HTTP", referer.Host, u.Host), requestID)
+	}
+	for _, header := range entry.Request.Headers {
+		if slices.Contains(credentialHeaders, strings.ToLower(header.Name)) {
+			scan.add(RuleCredentialsOverHTTP, u.Host, "request.headers."+header.Name, scan.sprintf("%s header sent to %s over plain HTTP", header.Name, u.Host), requestID)
+		}
+	}
+	for name := range requestForm(entry.Request) {
+		if strings.Contains(strings.ToLower(name), "password") {
+			scan.add(RuleCredentialsOverHTTP, u.Host, "request.body", scan.sprintf("%s field posted to %s over plain HTTP", name, u.Host), requestID)
+		}
+	}
+}
+
+// scanCookies checks the attributes of the cookies set by a response
+func scanCookies(scan *securityScan, response *har.Response, u *url.URL, requestID string) {
+	for _, header := range response.Headers {
+		if !strings.EqualFold(header.Name, "Set-Cookie") {
+			continue
+		}
+		cookie, err := http.ParseSetCookie(header.Value)
+		if err != nil {
+			continue
+		}
+		// Deletions expire the cookie, their attributes don't matter
+		if cookie.MaxAge < 0 || cookie.Value == "" {
+			continue
+		}
+		subject := cookie.Name + "@" + u.Hostname()
+		for _, rule := range cookieIssues(cookie, u) {
+			message := scan.sprintf(cookieIssueMessages[rule], cookie.Name, u.Hostname())
+			scan.add(rule, subject, "response.headers.Set-Cookie", message, requestID)
+		}
+	}
+}
+
+// cookieIssueMessages report the attribute a cookie lacks for each cookie rule
+var cookieIssueMessages = map[string]string{
+	RuleCookieMissingSecure:   "cookie %s set by %s lacks the Secure attribute",
+	RuleCookieMissingHTTPOnly: "cookie %s set by %s lacks the HttpOnly attribute",
+	RuleCookieMissingSameSite: "cookie %s set by %s lacks a SameSite attribute",
+}
+
+// cookieIssues returns the rules broken by a cookie set by a response to the URL: Secure is
+// only expected on cookies set over HTTPS
+func cookieIssues(cookie *http.Cookie, u *url.URL) []string {
+	var issues []string
+	if u.Scheme == "https" && !cookie.Secure {
+		issues = append(issues, RuleCookieMissingSecure)
+	}
+	if !cookie.HttpOnly {
+		issues = append(issues, RuleCookieMissingHTTPOnly)
+	}
+	// Set-Cookie headers without SameSite attribute parse to the zero mode, not the default one
+	if cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode {
+		issues = append(issues, RuleCookieMissingSameSite)
+	}
+	return issues
+}
+
+// scanCORS checks the CORS headers of a response against the request origin
+func scanCORS(scan *securityScan, entry *har.Entry, u *url.URL, requestID string) {
+	allowOrigin := headerValue(entry.Response.Headers, "Access-Control-Allow-Origin")
+	if allowOrigin == "" {
+		return
+	}
+	credentials := strings.EqualFold(headerValue(entry.Response.Headers, "Access-Control-Allow-Credentials"), "true")
+	endpoint := templateURL(entry.Request.URL)
+	location := "response.headers.Access-Control-Allow-Origin"
+
+	switch {
+	case allowOrigin == "*" && credentials:
+		scan.add(RuleCORSWildcardCreds, endpoint, location, scan.sprintf("%s allows any origin with credentials", endpoint), requestID)
+	case allowOrigin == "null":
+		scan.add(RuleCORSNullOrigin, endpoint, location, scan.sprintf("%s allows the null origin", endpoint), requestID)
+	case credentials && allowOrigin == headerValue(entry.Request.Headers, "Origin"):
+		origin, err := url.Parse(allowOrigin)
+		if err == nil && RegistrableDomain(origin.Hostname()) != RegistrableDomain(u.Hostname()) {
+			scan.add(RuleCORSReflectedOrigin, endpoint, location, scan.sprintf("%s allows %s with credentials", endpoint, allowOrigin), requestID)
+		}
+	}
+}
+
+// headerValue returns the value of the first header with the given name
+func headerValue(headers []har.Header, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// isLoopbackHost reports whether a host is the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// securityRuleSeverity returns the severity of a rule
+func securityRuleSeverity(rule string) string {
+	for _, candidate := range SecurityRules {
+		if candidate.ID == rule {
+			return candidate.Severity
+		}
+	}
+	return SeverityLow
+}
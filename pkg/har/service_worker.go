@@ -0,0 +1,177 @@
+package har
+
+import (
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Roles of the requests in the service worker report
+const (
+	// ServiceWorkerServed is a response the service worker built without a network fetch, from
+	// Cache Storage or its own code
+	ServiceWorkerServed = "served"
+	// ServiceWorkerPassThrough is a response the service worker fetched from the network or the
+	// HTTP cache on behalf of the page
+	ServiceWorkerPassThrough = "pass-through"
+	// ServiceWorkerBackground is a request the service worker sent on its own, as background sync
+	// or periodic updates do, outside of any page load
+	ServiceWorkerBackground = "background"
+	// ServiceWorkerScript is a fetch of the service worker script, at registration or update
+	ServiceWorkerScript = "script"
+)
+
+// maxServiceWorkerRequests caps the requests listed in the service worker report
+const maxServiceWorkerRequests = 50
+
+// ServiceWorkerRequest is a request involving a service worker
+type ServiceWorkerRequest struct {
+	RequestID string `json:"request_id"`
+	Role      string `json:"role"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	// Source is where the service worker got the response from, as recorded by Chrome
+	Source string `json:"source,omitempty"`
+	// NetworkRequestID is the fetch the service worker sent for a pass-through response, when recorded
+	NetworkRequestID string  `json:"network_request_id,omitempty"`
+	DurationMS       float64 `json:"duration_ms"`
+}
+
+// ServiceWorkerReport tells which requests a service worker answered and how, which aggregate
+// statistics otherwise count as plain network requests
+type ServiceWorkerReport struct {
+	// Detected is set when the capture shows any service worker activity
+	Detected bool `json:"detected"`
+	// Intercepted counts the responses the page got through a service worker
+	Intercepted int `json:"intercepted"`
+	Served      int `json:"served"`
+	PassThrough int `json:"pass_through"`
+	// NetworkFetches counts the fetches the service worker sent for pass-through responses, which
+	// duplicate them in the capture
+	NetworkFetches int `json:"network_fetches"`
+	Background     int `json:"background"`
+	Scripts        int `json:"scripts"`
+	// Sources counts the intercepted responses by response source
+	Sources map[string]int `json:"sources"`
+	// Requests lists the first requests involving the service worker, in capture order
+	Requests  []ServiceWorkerRequest `json:"requests"`
+	Truncated bool                   `json:"truncated,omitempty"`
+}
+
+// ReportServiceWorker classifies the requests involving a service worker, from the service worker
+// fields recorded by Chrome DevTools and some other exporters. Intercepted responses are served
+// when the worker answered from Cache Storage or its own code, and pass-through when it fetched
+// them from the network or the HTTP cache, which the capture shows as a duplicate fetch of the
+// same URL started while the intercepted request was pending. When the capture has pages,
+// requests attributed to none of them that are neither such fetches nor intercepted are reported
+// as background traffic of the worker. Custom fields, page references and timings are read from
+// the indexes.
+func (p *Parser) ReportServiceWorker(harData *har.HAR, fields *CustomFieldIndex, pages *PageIndex, timings *TimingIndex) *ServiceWorkerReport {
+	report := &ServiceWorkerReport{Sources: make(map[string]int), Requests: []ServiceWorkerRequest{}}
+	entries := harData.Log.Entries
+	roles := make([]*ServiceWorkerRequest, len(entries))
+	networkFetches := make(map[int]bool)
+	redaction := p.redaction()
+
+	for i, entry := range entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		if strings.EqualFold(headerValue(entry.Request.Headers, "Service-Worker"), "script") {
+			roles[i] = newServiceWorkerRequest(i, entry, ServiceWorkerScript, timings, redaction)
+			continue
+		}
+		custom := fields.Fields(entry)
+		if custom == nil || !custom.FromServiceWorker {
+			continue
+		}
+		request := newServiceWorkerRequest(i, entry, ServiceWorkerServed, timings, redaction)
+		request.Source = custom.ServiceWorkerSource
+		if fetch := networkFetch(entries, i, fields, timings, networkFetches); fetch >= 0 {
+			networkFetches[fetch] = true
+			request.NetworkRequestID = formatRequestID(fetch)
+			request.Role = ServiceWorkerPassThrough
+		} else if request.Source == "network" || request.Source == "http-cache" {
+			request.Role = ServiceWorkerPassThrough
+		}
+		roles[i] = request
+	}
+
+	detected := len(networkFetches) > 0
+	for _, request := range roles {
+		detected = detected || request != nil
+	}
+	if detected && len(pages.Pages()) > 0 {
+		for i, entry := range entries {
+			if entry != nil && entry.Request != nil && roles[i] == nil && !networkFetches[i] && pages.PageRef(entry) == "" {
+				roles[i] = newServiceWorkerRequest(i, entry, ServiceWorkerBackground, timings, redaction)
+			}
+		}
+	}
+
+	report.Detected = detected
+	report.NetworkFetches = len(networkFetches)
+	for _, request := range roles {
+		if request == nil {
+			continue
+		}
+		switch request.Role {
+		case ServiceWorkerServed:
+			report.Served++
+		case ServiceWorkerPassThrough:
+			report.PassThrough++
+		case ServiceWorkerBackground:
+			report.Background++
+		case ServiceWorkerScript:
+			report.Scripts++
+		}
+		if request.Role == ServiceWorkerServed || request.Role == ServiceWorkerPassThrough {
+			report.Intercepted++
+			source := request.Source
+			if source == "" {
+				source = "unknown"
+			}
+			report.Sources[source]++
+		}
+		if len(report.Requests) < maxServiceWorkerRequests {
+			report.Requests = append(report.Requests, *request)
+		} else {
+			report.Truncated = true
+		}
+	}
+	return report
+}
+
+// newServiceWorkerRequest describes the entry at the given position in the given role, its URL redacted
+func newServiceWorkerRequest(i int, entry *har.Entry, role string, timings *TimingIndex, redaction *redactor) *ServiceWorkerRequest {
+	return &ServiceWorkerRequest{
+		RequestID:  formatRequestID(i),
+		Role:       role,
+		Method:     entry.Request.Method,
+		URL:        redaction.text(entry.Request.URL),
+		DurationMS: timings.Time(entry),
+	}
+}
+
+// networkFetch returns the position of the fetch the service worker sent for the intercepted
+// entry at position i: the first entry not intercepted and not already matched, requesting the
+// same method and URL and started while the intercepted entry was pending, -1 when there is none
+func networkFetch(entries []*har.Entry, i int, fields *CustomFieldIndex, timings *TimingIndex, matched map[int]bool) int {
+	intercepted := entries[i]
+	end := timings.End(intercepted)
+	for j, entry := range entries {
+		if j == i || matched[j] || entry == nil || entry.Request == nil {
+			continue
+		}
+		if custom := fields.Fields(entry); custom != nil && custom.FromServiceWorker {
+			continue
+		}
+		if entry.Request.Method != intercepted.Request.Method || entry.Request.URL != intercepted.Request.URL {
+			continue
+		}
+		if !entry.StartedDateTime.Before(intercepted.StartedDateTime) && !entry.StartedDateTime.After(end) {
+			return j
+		}
+	}
+	return -1
+}
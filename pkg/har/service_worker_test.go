@@ -0,0 +1,76 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createServiceWorkerHAR returns a Chrome capture of a page controlled by a service worker: the
+// worker script, a response served from Cache Storage, a pass-through fetch recorded twice and a
+// background sync request attributed to no page
+func createServiceWorkerHAR() string {
+	entry := func(started, url, extra, responseExtra, headers string) string {
+		return `{"startedDateTime": "` + started + `", "time": 100` + extra + `,
+		 "request": {"method": "GET", "url": "` + url + `", "httpVersion": "HTTP/1.1", "headers": [` + headers + `], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+		 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/plain"}, "redirectURL": "", "headersSize": -1, "bodySize": 0` + responseExtra + `}}`
+	}
+	return `{"log": {"version": "1.2", "creator": {"name": "WebInspector", "version": "537.36"},
+		"pages": [{"startedDateTime": "2023-01-01T00:00:00Z", "id": "page_1", "title": "app", "pageTimings": {}}],
+		"entries": [` + strings.Join([]string{
+		entry("2023-01-01T00:00:00Z", "https://example.com/sw.js", `, "pageref": "page_1"`, "", `{"name": "Service-Worker", "value": "script"}`),
+		entry("2023-01-01T00:00:01Z", "https://example.com/app.css", `, "pageref": "page_1"`, `, "_fetchedViaServiceWorker": true, "_serviceWorkerResponseSource": "cache-storage"`, ""),
+		entry("2023-01-01T00:00:02Z", "https://example.com/api/feed", `, "pageref": "page_1"`, `, "_fetchedViaServiceWorker": true, "_serviceWorkerResponseSource": "network"`, ""),
+		entry("2023-01-01T00:00:02.010Z", "https://example.com/api/feed", "", "", ""),
+		entry("2023-01-01T00:00:05Z", "https://example.com/api/sync?token=s3cr3t", "", "", ""),
+	}, ",") + `]}}`
+}
+
+func TestParseKeepsServiceWorkerFields(t *testing.T) {
+	harData, report, err := NewParser().ParseWithReport(strings.NewReader(createServiceWorkerHAR()))
+	require.NoError(t, err)
+
+	fields := report.CustomFieldIndex().Fields(harData.Log.Entries[1])
+	require.NotNil(t, fields)
+	assert.True(t, fields.FromServiceWorker)
+	assert.Equal(t, "cache-storage", fields.ServiceWorkerSource)
+	assert.Nil(t, report.CustomFieldIndex().Fields(harData.Log.Entries[3]))
+}
+
+func TestReportServiceWorker(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`s3cr3t`}}))
+	harData, report, err := parser.ParseWithReport(strings.NewReader(createServiceWorkerHAR()))
+	require.NoError(t, err)
+
+	sw := parser.ReportServiceWorker(harData, report.CustomFieldIndex(), report.PageIndex(), report.TimingIndex())
+	assert.True(t, sw.Detected)
+	assert.Equal(t, 2, sw.Intercepted)
+	assert.Equal(t, 1, sw.Served)
+	assert.Equal(t, 1, sw.PassThrough)
+	assert.Equal(t, 1, sw.NetworkFetches)
+	assert.Equal(t, 1, sw.Background)
+	assert.Equal(t, 1, sw.Scripts)
+	assert.Equal(t, map[string]int{"cache-storage": 1, "network": 1}, sw.Sources)
+
+	require.Len(t, sw.Requests, 4)
+	assert.Equal(t, ServiceWorkerScript, sw.Requests[0].Role)
+	assert.Equal(t, ServiceWorkerServed, sw.Requests[1].Role)
+	assert.Equal(t, ServiceWorkerPassThrough, sw.Requests[2].Role)
+	assert.Equal(t, "request_3", sw.Requests[2].NetworkRequestID)
+	assert.Equal(t, ServiceWorkerBackground, sw.Requests[3].Role)
+	assert.Equal(t, "request_4", sw.Requests[3].RequestID)
+	assert.NotContains(t, sw.Requests[3].URL, "s3cr3t")
+}
+
+func TestReportServiceWorkerWithoutActivity(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(createChromeHAR()))
+	require.NoError(t, err)
+
+	sw := parser.ReportServiceWorker(harData, report.CustomFieldIndex(), report.PageIndex(), report.TimingIndex())
+	assert.False(t, sw.Detected)
+	assert.Empty(t, sw.Requests)
+}
@@ -0,0 +1,216 @@
+package har
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// Soft error signals, the error-shaped parts of a successful response
+const (
+	// SignalErrorField is a top-level error or errors member holding a value
+	SignalErrorField = "error_field"
+	// SignalSuccessFalse is a top-level success or ok member set to false, or a status member
+	// set to error or failed
+	SignalSuccessFalse = "success_false"
+	// SignalErrorCode is a top-level code, status or statusCode member holding a 4xx or 5xx code
+	SignalErrorCode = "error_code"
+	// SignalStackTrace is a stack trace of a Java, .NET, Python, Node.js, PHP, Ruby or Go program
+	SignalStackTrace = "stack_trace"
+)
+
+// maxSoftErrorMessage caps the length of the error messages reported
+const maxSoftErrorMessage = 200
+
+// stackTracePatterns match the stack traces of the common server runtimes
+var stackTracePatterns = []struct {
+	runtime string
+	pattern *regexp.Regexp
+}{
+	// at com.example.Service.call(Service.java:42)
+	{"Java", regexp.MustCompile(`\bat [\w$.]+\([\w$]+\.(?:java|kt|scala):\d+\)`)},
+	// at Example.Service.Call() in C:\src\Service.cs:line 42
+	{".NET", regexp.MustCompile(`\bat [\w.<>]+\(.*\) in .+:line \d+`)},
+	{"Python", regexp.MustCompile(`Traceback \(most recent call last\)`)},
+	// at handler (/app/src/index.js:10:5)
+	{"Node.js", regexp.MustCompile(`\bat (?:[\w$.<>]+ )?\(?(?:/|[A-Za-z]:\\|file://|node:)[^\s()]+\.(?:js|mjs|cjs|ts):\d+:\d+\)?`)},
+	{"PHP", regexp.MustCompile(`Fatal error: .+ in \S+\.php(?::\d+| on line \d+)|Stack trace:\s+#0 `)},
+	// app/models/user.rb:42:in `save'
+	{"Ruby", regexp.MustCompile(`\.rb:\d+:in [` + "`" + `']`)},
+	{"Go", regexp.MustCompile(`goroutine \d+ \[running\]`)},
+}
+
+// SoftError is a successful response whose body reports an error
+type SoftError struct {
+	RequestID string   `json:"request_id"`
+	Method    string   `json:"method"`
+	URL       string   `json:"url"`
+	Status    int      `json:"status"`
+	MimeType  string   `json:"mime_type"`
+	Signals   []string `json:"signals"`
+	// Message is the error message found in the body, if any
+	Message string `json:"message,omitempty"`
+}
+
+// SoftErrorReport lists the successful responses reporting errors in their body
+type SoftErrorReport struct {
+	// Checked counts the 2xx responses with a body
+	Checked    int            `json:"checked"`
+	BySignal   map[string]int `json:"by_signal"`
+	SoftErrors []SoftError    `json:"soft_errors"`
+}
+
+// GetSoftErrors flags 2xx responses whose body is shaped like an error: JSON objects with an
+// error member, success set to false or an error code, and stack traces in any textual body.
+// Such endpoints evade the status-based error summary. GraphQL and JSON-RPC responses, whose
+// errors the error summary reads, are left out.
+func (p *Parser) GetSoftErrors(harData *har.HAR) *SoftErrorReport {
+	redaction := p.redaction()
+	report := &SoftErrorReport{BySignal: make(map[string]int), SoftErrors: []SoftError{}}
+	for i, entry := range harData.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		response := entry.Response
+		if response.Status < 200 || response.Status >= 300 || response.Content == nil || len(response.Content.Text) == 0 {
+			continue
+		}
+		if protocol, _ := requestOperations(entry.Request); protocol != "" {
+			continue
+		}
+		if !isPrintableText(response.Content.Text) {
+			continue
+		}
+		report.Checked++
+
+		signals, message := softErrorSignals(response)
+		if len(signals) == 0 {
+			continue
+		}
+		for _, signal := range signals {
+			report.BySignal[signal]++
+		}
+		report.SoftErrors = append(report.SoftErrors, SoftError{
+			RequestID: formatRequestID(i),
+			Method:    entry.Request.Method,
+			URL:       redaction.text(entry.Request.URL),
+			Status:    response.Status,
+			MimeType:  responseMimeType(response),
+			Signals:   signals,
+			Message:   truncateBody(redaction.text(message), maxSoftErrorMessage),
+		})
+	}
+	return report
+}
+
+// softErrorSignals returns the error signals of a response body, with the error message found
+func softErrorSignals(response *har.Response) ([]string, string) {
+	var signals []string
+	var message string
+	body := response.Content.Text
+
+	var document map[string]interface{}
+	if mimeTypeFamily(responseMimeType(response)) == familyJSON || json.Valid(body) {
+		if err := json.Unmarshal(body, &document); err != nil {
+			document = nil
+		}
+	}
+	if document != nil {
+		for _, name := range []string{"error", "errors", "Error", "Errors"} {
+			if value, ok := document[name]; ok && holdsValue(value) {
+				signals = append(signals, SignalErrorField)
+				message = errorMessage(value)
+				break
+			}
+		}
+		if isFalse(document["success"]) || isFalse(document["ok"]) || isFailureStatus(document["status"]) {
+			signals = append(signals, SignalSuccessFalse)
+		}
+		for _, name := range []string{"code", "status", "statusCode", "status_code"} {
+			if code, ok := document[name].(float64); ok && code >= 400 && code < 600 && code == float64(int(code)) {
+				signals = append(signals, SignalErrorCode)
+				break
+			}
+		}
+		if message == "" && len(signals) > 0 {
+			for _, name := range []string{"message", "msg", "error_description", "detail", "reason"} {
+				if text, ok := document[name].(string); ok && text != "" {
+					message = text
+					break
+				}
+			}
+		}
+	}
+
+	for _, stackTrace := range stackTracePatterns {
+		if location := stackTrace.pattern.FindIndex(body); location != nil {
+			signals = append(signals, SignalStackTrace)
+			if message == "" {
+				trace := body[location[0]:]
+				message = strings.TrimSpace(string(trace[:runeEnd(trace, min(len(trace), maxSoftErrorMessage))]))
+			}
+			break
+		}
+	}
+	return signals, message
+}
+
+// holdsValue reports whether a JSON value is set: not null, false, empty or zero
+func holdsValue(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case string:
+		return typed != ""
+	case float64:
+		return typed != 0
+	case []interface{}:
+		return len(typed) > 0
+	case map[string]interface{}:
+		return len(typed) > 0
+	}
+	return true
+}
+
+// errorMessage returns the message of an error member: a string, or the message of an object
+// or of the first item of an array
+func errorMessage(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case []interface{}:
+		if len(typed) > 0 {
+			return errorMessage(typed[0])
+		}
+	case map[string]interface{}:
+		for _, name := range []string{"message", "msg", "description", "detail", "error_description", "error"} {
+			if text, ok := typed[name].(string); ok && text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// isFalse reports whether a JSON value is the false boolean
+func isFalse(value interface{}) bool {
+	flag, ok := value.(bool)
+	return ok && !flag
+}
+
+// isFailureStatus reports whether a JSON value is a status string reporting a failure
+func isFailureStatus(value interface{}) bool {
+	status, ok := value.(string)
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(status) {
+	case "error", "fail", "failed", "failure":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSoftErrorsFlagsErrorPayloads(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestResponseEntry("https://api.example.com/orders", "application/json", `{"error": {"code": "E42", "message": "Order not found"}}`),
+		newTestResponseEntry("https://api.example.com/pay", "application/json", `{"success": false, "message": "Card declined", "code": 402}`),
+		newTestResponseEntry("https://api.example.com/report", "text/html", "<pre>java.lang.NullPointerException\n\tat com.example.Report.render(Report.java:42)</pre>"),
+		newTestResponseEntry("https://api.example.com/users", "application/json", `{"error": null, "errors": [], "success": true, "users": []}`),
+		newTestResponseEntry("https://api.example.com/health", "application/json", `{"status": "ok", "code": 200}`),
+		newTestGraphQLEntry(`{"query": "{ me { id } }"}`, `{"errors": [{"message": "Unauthenticated"}]}`),
+	)
+
+	report := parser.GetSoftErrors(archive)
+
+	assert.Equal(t, 5, report.Checked)
+	require.Len(t, report.SoftErrors, 3)
+	assert.Equal(t, "request_0", report.SoftErrors[0].RequestID)
+	assert.Equal(t, []string{SignalErrorField}, report.SoftErrors[0].Signals)
+	assert.Equal(t, "Order not found", report.SoftErrors[0].Message)
+
+	assert.Equal(t, []string{SignalSuccessFalse, SignalErrorCode}, report.SoftErrors[1].Signals)
+	assert.Equal(t, "Card declined", report.SoftErrors[1].Message)
+
+	assert.Equal(t, []string{SignalStackTrace}, report.SoftErrors[2].Signals)
+	assert.Equal(t, "at com.example.Report.render(Report.java:42)</pre>", report.SoftErrors[2].Message)
+	assert.Equal(t, map[string]int{SignalErrorField: 1, SignalSuccessFalse: 1, SignalErrorCode: 1, SignalStackTrace: 1}, report.BySignal)
+}
+
+func TestGetSoftErrorsDetectsStackTraces(t *testing.T) {
+	for _, body := range []string{
+		"Traceback (most recent call last):\n  File \"app.py\", line 3",
+		"TypeError: x is undefined\n    at handler (/app/src/index.js:10:5)",
+		"   at Example.Service.Call() in C:\\src\\Service.cs:line 42",
+		"PHP Fatal error: Uncaught Exception in /var/www/index.php:3\nStack trace:\n#0 {main}",
+		"panic: boom\n\ngoroutine 1 [running]:\nmain.main()",
+		"app/models/user.rb:42:in `save'",
+	} {
+		signals, message := softErrorSignals(newTestResponseEntry("https://example.com/", "text/plain", body).Response)
+		assert.Equal(t, []string{SignalStackTrace}, signals, body)
+		assert.NotEmpty(t, message)
+	}
+
+	signals, _ := softErrorSignals(newTestResponseEntry("https://example.com/", "text/plain", "Meet us at Paris (France.java:1 is not a trace)").Response)
+	assert.Empty(t, signals)
+}
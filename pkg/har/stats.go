@@ -0,0 +1,153 @@
+package har
+
+import (
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// DefaultSlowestRequests is the number of slowest requests reported when none is given
+const DefaultSlowestRequests = 5
+
+// HostCount is a host with the number of requests sent to it
+type HostCount struct {
+	Host     string `json:"host"`
+	Requests int    `json:"requests"`
+}
+
+// SlowRequest is one of the slowest requests of the capture
+type SlowRequest struct {
+	RequestID  string  `json:"request_id"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Status     int     `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// HARStats gives aggregate statistics over a HAR, a cheap overview before drilling into requests
+type HARStats struct {
+	Entries     int         `json:"entries"`
+	UniqueHosts int         `json:"unique_hosts"`
+	Hosts       []HostCount `json:"hosts"`
+	// Sites groups the hosts by registrable domain, such as example.co.uk for api.eu.example.co.uk
+	Sites   []HostCount    `json:"sites"`
+	Methods map[string]int `json:"methods"`
+	// Statuses counts the response statuses, 0 being requests without a response
+	Statuses map[int]int `json:"statuses"`
+	// RequestBytes and ResponseBytes sum the known header and body sizes
+	RequestBytes         int64         `json:"request_bytes"`
+	ResponseBytes        int64         `json:"response_bytes"`
+	TotalBytes           int64         `json:"total_bytes"`
+	Slowest              []SlowRequest `json:"slowest"`
+	FirstStartedDateTime string        `json:"first_started_datetime,omitempty"`
+	LastFinishedDateTime string        `json:"last_finished_datetime,omitempty"`
+	// TimeSpanMS is the time between the start of the first request and the end of the last one
+	TimeSpanMS float64 `json:"time_span_ms"`
+}
+
+// GetHARStats computes aggregate statistics over the HAR: hosts, methods, statuses, bytes
+// transferred, time span and the slowest requests, at most slowest of them. Non-positive
+// counts fall back to DefaultSlowestRequests.
+func (p *Parser) GetHARStats(harData *har.HAR, index *TimingIndex, slowest int) *HARStats {
+	if slowest <= 0 {
+		slowest = DefaultSlowestRequests
+	}
+
+	redaction := p.redaction()
+	stats := &HARStats{
+		Hosts:    []HostCount{},
+		Sites:    []HostCount{},
+		Methods:  make(map[string]int),
+		Statuses: make(map[int]int),
+		Slowest:  []SlowRequest{},
+	}
+	hosts := make(map[string]int)
+	sites := make(map[string]int)
+	var first, last time.Time
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		stats.Entries++
+		stats.Methods[entry.Request.Method]++
+		if u, err := url.Parse(entry.Request.URL); err == nil && u.Host != "" {
+			hosts[u.Host]++
+			sites[RegistrableDomain(u.Hostname())]++
+		}
+		stats.RequestBytes += max(entry.Request.HeadersSize, 0) + max(entry.Request.BodySize, 0)
+
+		status := 0
+		if entry.Response != nil {
+			status = entry.Response.Status
+			stats.ResponseBytes += max(entry.Response.HeadersSize, 0) + responseBodySize(entry.Response)
+		}
+		stats.Statuses[status]++
+
+		stats.Slowest = append(stats.Slowest, SlowRequest{
+			RequestID:  formatRequestID(i),
+			Method:     entry.Request.Method,
+			URL:        redaction.text(entry.Request.URL),
+			Status:     status,
+			DurationMS: index.Time(entry),
+		})
+
+		if entry.StartedDateTime.IsZero() {
+			continue
+		}
+		finished := index.End(entry)
+		if first.IsZero() || entry.StartedDateTime.Before(first) {
+			first = entry.StartedDateTime
+		}
+		if finished.After(last) {
+			last = finished
+		}
+	}
+
+	stats.TotalBytes = stats.RequestBytes + stats.ResponseBytes
+	stats.UniqueHosts = len(hosts)
+	stats.Hosts = sortedHostCounts(hosts)
+	stats.Sites = sortedHostCounts(sites)
+	sort.SliceStable(stats.Slowest, func(i, j int) bool {
+		return stats.Slowest[i].DurationMS > stats.Slowest[j].DurationMS
+	})
+	if len(stats.Slowest) > slowest {
+		stats.Slowest = stats.Slowest[:slowest]
+	}
+	if !first.IsZero() {
+		stats.FirstStartedDateTime = p.formatTime(first, time.RFC3339Nano)
+		stats.LastFinishedDateTime = p.formatTime(last, time.RFC3339Nano)
+		stats.TimeSpanMS = milliseconds(last.Sub(first))
+	}
+
+	return stats
+}
+
+// responseBodySize returns the size of the response body received, falling back to the
+// content size when the body size is unknown
+func responseBodySize(response *har.Response) int64 {
+	if response.BodySize >= 0 {
+		return response.BodySize
+	}
+	if response.Content != nil {
+		return max(response.Content.Size, 0)
+	}
+	return 0
+}
+
+// sortedHostCounts lists the request counts by host, most requested first
+func sortedHostCounts(hosts map[string]int) []HostCount {
+	counts := make([]HostCount, 0, len(hosts))
+	for host, requests := range hosts {
+		counts = append(counts, HostCount{Host: host, Requests: requests})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Requests != counts[j].Requests {
+			return counts[i].Requests > counts[j].Requests
+		}
+		return counts[i].Host < counts[j].Host
+	})
+	return counts
+}
@@ -0,0 +1,42 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHARStats(t *testing.T) {
+	parser := NewParser()
+	page := newTestAttempt("https://example.com/", 0, 200, 120)
+	page.Response.HeadersSize = 100
+	page.Response.BodySize = 1000
+	script := newTestAttempt("https://cdn.example.com/app.js", 50*time.Millisecond, 200, 300)
+	script.Response.BodySize = -1
+	script.Response.Content = nil
+	failed := newTestAttempt("https://example.com/api", 400*time.Millisecond, 503, 40)
+	failed.Request.Method = "POST"
+	failed.Request.HeadersSize = 50
+	failed.Request.BodySize = 20
+	aborted := newTestAttempt("https://example.com/api", 600*time.Millisecond, 0, 5)
+
+	stats := parser.GetHARStats(newTestHAR(page, script, failed, aborted), nil, 2)
+
+	assert.Equal(t, 4, stats.Entries)
+	assert.Equal(t, 2, stats.UniqueHosts)
+	assert.Equal(t, []HostCount{{Host: "example.com", Requests: 3}, {Host: "cdn.example.com", Requests: 1}}, stats.Hosts)
+	assert.Equal(t, []HostCount{{Host: "example.com", Requests: 4}}, stats.Sites)
+	assert.Equal(t, map[string]int{"GET": 3, "POST": 1}, stats.Methods)
+	assert.Equal(t, map[int]int{200: 2, 503: 1, 0: 1}, stats.Statuses)
+	assert.Equal(t, int64(70), stats.RequestBytes)
+	assert.Equal(t, int64(1100), stats.ResponseBytes)
+	assert.Equal(t, int64(1170), stats.TotalBytes)
+	require.Len(t, stats.Slowest, 2)
+	assert.Equal(t, SlowRequest{RequestID: "request_1", Method: "GET", URL: "https://cdn.example.com/app.js", Status: 200, DurationMS: 300}, stats.Slowest[0])
+	assert.Equal(t, "request_0", stats.Slowest[1].RequestID)
+	assert.Equal(t, "2023-01-01T00:00:00Z", stats.FirstStartedDateTime)
+	assert.Equal(t, "2023-01-01T00:00:00.605Z", stats.LastFinishedDateTime)
+	assert.Equal(t, float64(605), stats.TimeSpanMS)
+}
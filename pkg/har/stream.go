@@ -0,0 +1,399 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/martian/har"
+)
+
+// documentDecoder decodes a HAR document from a stream, one entry at a time, so that only
+// the decoded entries are kept in memory rather than the raw file and a full intermediate model.
+// Each part of the log is decoded with the standard model first and the flexible one when it fails.
+type documentDecoder struct {
+	decoder *json.Decoder
+	report  *ParseReport
+	// flexible is set when a part of the log needed the flexible model
+	flexible bool
+	// transferSizes are the _transferSize of the responses, aligned with the entries
+	transferSizes []*float64
+	// pages are the page loads of the log, and pageRefs the page of each entry
+	pages    []Page
+	pageRefs map[*har.Entry]string
+	// webSockets are the WebSocket frames of the entries
+	webSockets map[*har.Entry][]FlexibleWebSocketMessage
+	// timings are the timings of the entries, with the phases martian's model leaves out
+	timings map[*har.Entry]*Timings
+	// times are the times of the entries with fractional milliseconds
+	times map[*har.Entry]float64
+	// serverIPs and issuers are the server IP addresses and certificate issuers of the entries,
+	// connections and streams their connection IDs and HTTP/2 stream IDs
+	serverIPs   map[*har.Entry]string
+	issuers     map[*har.Entry]string
+	connections map[*har.Entry]string
+	streams     map[*har.Entry]int
+	// customFields are the custom fields Chrome DevTools adds to the entries
+	customFields map[*har.Entry]*CustomFields
+}
+
+// entryExtensions holds the fields of an entry beyond martian's model
+type entryExtensions struct {
+	PageRef string `json:"pageref"`
+	// WebSocketMessages are decoded apart so that malformed frames do not lose the other fields
+	WebSocketMessages json.RawMessage `json:"_webSocketMessages"`
+	Response          *struct {
+		TransferSize *float64 `json:"_transferSize"`
+		// FetchedViaServiceWorker and ServiceWorkerResponseSource are recorded by Chrome DevTools
+		FetchedViaServiceWorker     bool   `json:"_fetchedViaServiceWorker"`
+		ServiceWorkerResponseSource string `json:"_serviceWorkerResponseSource"`
+	} `json:"response"`
+	ServerIPAddress string `json:"serverIPAddress"`
+	// Connection identifies the TCP or QUIC connection, as a number or a port for some exporters
+	Connection FlexibleString `json:"connection"`
+	// StreamID is the HTTP/2 stream identifier some exporters record
+	StreamID FlexibleString `json:"_streamId"`
+	// SecurityDetails describes the TLS connection, as recorded by Puppeteer-based exporters
+	SecurityDetails *struct {
+		Issuer string `json:"issuer"`
+	} `json:"_securityDetails"`
+	// Initiator is decoded apart so that a malformed stack does not lose the other fields
+	Initiator    json.RawMessage `json:"_initiator"`
+	Priority     string          `json:"_priority"`
+	ResourceType string          `json:"_resourceType"`
+	// FromCache is disk or memory for Chrome, a boolean for some other exporters
+	FromCache any `json:"_fromCache"`
+	// FromServiceWorker is set by exporters recording service worker responses on the entry
+	FromServiceWorker bool `json:"_fromServiceWorker"`
+}
+
+// decodeStream decodes a single HAR document from r, returning the _transferSize of the responses
+// aligned with the entries. Data following the document is ignored.
+func (p *Parser) decodeStream(r io.Reader, report *ParseReport) (*har.HAR, []*float64, error) {
+	d := newDocumentDecoder(json.NewDecoder(r), report)
+	harData, err := d.decodeDocument()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+	if harData.Log == nil {
+		return nil, nil, fmt.Errorf("failed to parse HAR file: missing log")
+	}
+
+	d.index()
+	return harData, d.transferSizes, nil
+}
+
+// newDocumentDecoder creates a decoder reporting to report
+func newDocumentDecoder(decoder *json.Decoder, report *ParseReport) *documentDecoder {
+	return &documentDecoder{decoder: decoder, report: report, pageRefs: make(map[*har.Entry]string), webSockets: make(map[*har.Entry][]FlexibleWebSocketMessage), timings: make(map[*har.Entry]*Timings), times: make(map[*har.Entry]float64), serverIPs: make(map[*har.Entry]string), issuers: make(map[*har.Entry]string), connections: make(map[*har.Entry]string), streams: make(map[*har.Entry]int), customFields: make(map[*har.Entry]*CustomFields)}
+}
+
+// index records the parse mode and the indexes built while decoding in the report
+func (d *documentDecoder) index() {
+	d.report.Mode = ParseModeStrict
+	if d.flexible {
+		d.report.Mode = ParseModeFlexible
+	}
+	d.report.Pages = len(d.pages)
+	d.report.pageIndex = &PageIndex{pages: d.pages, refs: d.pageRefs}
+	d.report.WebSockets = len(d.webSockets)
+	d.report.webSocketIndex = &WebSocketIndex{messages: d.webSockets}
+	d.report.timingIndex = &TimingIndex{timings: d.timings, times: d.times}
+	d.report.connectionIndex = &ConnectionIndex{serverIPs: d.serverIPs, issuers: d.issuers, connections: d.connections, streams: d.streams}
+	d.report.customFieldIndex = &CustomFieldIndex{fields: d.customFields}
+}
+
+// decodeDocument decodes the top-level object, skipping the members other than log
+func (d *documentDecoder) decodeDocument() (*har.HAR, error) {
+	if err := d.expectDelim('{'); err != nil {
+		return nil, err
+	}
+
+	harData := &har.HAR{}
+	for d.decoder.More() {
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if key != "log" {
+			if err := d.skip(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if harData.Log, err = d.decodeLog(); err != nil {
+			return nil, err
+		}
+	}
+
+	return harData, d.expectDelim('}')
+}
+
+// decodeLog decodes the log object, nil when it is null
+func (d *documentDecoder) decodeLog() (*har.Log, error) {
+	token, err := d.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("log: expected an object, found %v", token)
+	}
+
+	log := &har.Log{}
+	for d.decoder.More() {
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "version":
+			if err := d.decoder.Decode(&log.Version); err != nil {
+				return nil, fmt.Errorf("log.version: %w", err)
+			}
+		case "creator":
+			if log.Creator, err = d.decodeCreator(); err != nil {
+				return nil, err
+			}
+		case "entries":
+			if log.Entries, err = d.decodeEntries(); err != nil {
+				return nil, err
+			}
+		case "pages":
+			if err := d.decodePages(); err != nil {
+				return nil, err
+			}
+		default:
+			if err := d.skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return log, d.expectDelim('}')
+}
+
+// decodeCreator decodes the creator block, falling back to the flexible model for minimal exports
+func (d *documentDecoder) decodeCreator() (*har.Creator, error) {
+	var raw json.RawMessage
+	if err := d.decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("log.creator: %w", err)
+	}
+
+	var creator *har.Creator
+	if err := json.Unmarshal(raw, &creator); err == nil {
+		return creator, nil
+	}
+	d.flexible = true
+	var flexible *FlexibleCreator
+	if err := json.Unmarshal(raw, &flexible); err != nil {
+		return nil, fmt.Errorf("log.creator: %w", err)
+	}
+	return flexible.ToStandardCreator(), nil
+}
+
+// decodeEntries decodes the entries array one entry at a time
+func (d *documentDecoder) decodeEntries() ([]*har.Entry, error) {
+	token, err := d.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("log.entries: expected an array, found %v", token)
+	}
+
+	var entries []*har.Entry
+	for d.decoder.More() {
+		// Only the raw bytes of the current entry are held while it is decoded
+		var raw json.RawMessage
+		if err := d.decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("log.entries[%d]: %w", len(entries), err)
+		}
+		entry, err := d.decodeEntry(raw, len(entries))
+		if err != nil {
+			return nil, fmt.Errorf("log.entries[%d]: %w", len(entries), err)
+		}
+		entries = append(entries, entry)
+		d.collect(entry, raw, len(entries)-1)
+	}
+
+	return entries, d.expectDelim(']')
+}
+
+// collect records the fields of the entry at the given position beyond martian's model
+func (d *documentDecoder) collect(entry *har.Entry, raw json.RawMessage, index int) {
+	extensions := decodeExtensions(entry, raw)
+	d.transferSizes = append(d.transferSizes, extensions.transferSize())
+	if extensions.PageRef != "" {
+		d.pageRefs[entry] = extensions.PageRef
+	}
+	if len(extensions.WebSocketMessages) > 0 {
+		d.decodeWebSocketMessages(entry, extensions.WebSocketMessages, index)
+	}
+	if extensions.ServerIPAddress != "" {
+		d.serverIPs[entry] = extensions.ServerIPAddress
+	}
+	if extensions.SecurityDetails != nil && extensions.SecurityDetails.Issuer != "" {
+		d.issuers[entry] = extensions.SecurityDetails.Issuer
+	}
+	if extensions.Connection != "" {
+		d.connections[entry] = string(extensions.Connection)
+	}
+	if streamID, err := strconv.Atoi(string(extensions.StreamID)); err == nil {
+		d.streams[entry] = streamID
+	}
+	if entry != nil {
+		if timings := decodeTimings(raw); timings != nil {
+			d.timings[entry] = timings
+		}
+		if fields := d.decodeCustomFields(extensions, index); fields != nil {
+			d.customFields[entry] = fields
+		}
+	}
+}
+
+// decodeWebSocketMessages decodes the WebSocket frames of an entry, leaving them out with a
+// warning when malformed
+func (d *documentDecoder) decodeWebSocketMessages(entry *har.Entry, raw json.RawMessage, index int) {
+	var messages []FlexibleWebSocketMessage
+	if err := json.Unmarshal(raw, &messages); err != nil {
+		d.report.warn(formatRequestID(index), fmt.Sprintf("ignored _webSocketMessages: %v", err))
+		return
+	}
+	if len(messages) > 0 {
+		d.webSockets[entry] = messages
+	}
+}
+
+// decodePages decodes the pages array. Pages are optional, so malformed pages are left out
+// with a warning rather than failing the parse.
+func (d *documentDecoder) decodePages() error {
+	var raws []json.RawMessage
+	if err := d.decoder.Decode(&raws); err != nil {
+		return fmt.Errorf("log.pages: %w", err)
+	}
+	for i, raw := range raws {
+		var page Page
+		if err := json.Unmarshal(raw, &page); err != nil {
+			d.report.warn("", fmt.Sprintf("ignored log.pages[%d]: %v", i, err))
+			continue
+		}
+		if page.ID == "" {
+			d.report.warn("", fmt.Sprintf("ignored log.pages[%d]: missing id", i))
+			continue
+		}
+		normalizePageTimings(page.PageTimings)
+		d.pages = append(d.pages, page)
+	}
+	return nil
+}
+
+// decodeEntry decodes an entry with the standard model, or the flexible one when it fails.
+// Null entries are kept as nil so that normalization reports them.
+func (d *documentDecoder) decodeEntry(raw json.RawMessage, index int) (*har.Entry, error) {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, nil
+	}
+
+	var entry har.Entry
+	if err := json.Unmarshal(raw, &entry); err == nil {
+		return &entry, nil
+	}
+
+	var flexible FlexibleEntry
+	if err := json.Unmarshal(raw, &flexible); err != nil {
+		return nil, err
+	}
+	d.flexible = true
+	d.report.Warnings = append(d.report.Warnings, flexible.Warnings(formatRequestID(index))...)
+	standard := flexible.ToStandardEntry()
+	if elapsed := float64(flexible.Time); elapsed != float64(standard.Time) {
+		d.times[standard] = elapsed
+	}
+	return standard, nil
+}
+
+// decodeExtensions decodes the fields of the entry beyond martian's model, only when it holds a
+// pageref, WebSocket frames, connection details or Chrome DevTools custom fields or its body size
+// is unknown so that the entry is not decoded twice otherwise
+func decodeExtensions(entry *har.Entry, raw json.RawMessage) *entryExtensions {
+	extensions := &entryExtensions{}
+	if entry == nil {
+		return extensions
+	}
+	unknownSize := entry.Response != nil && entry.Response.BodySize < 0
+	if !unknownSize && !containsAny(raw, extensionKeys) {
+		return extensions
+	}
+	if err := json.Unmarshal(raw, extensions); err != nil {
+		return &entryExtensions{}
+	}
+	if !unknownSize && extensions.Response != nil {
+		extensions.Response.TransferSize = nil
+	}
+	return extensions
+}
+
+// extensionKeys are the keys of the fields of an entry beyond martian's model
+var extensionKeys = [][]byte{
+	[]byte(`"pageref"`), []byte(`"_webSocketMessages"`), []byte(`"serverIPAddress"`), []byte(`"_securityDetails"`),
+	[]byte(`"connection"`), []byte(`"_streamId"`),
+	[]byte(`"_initiator"`), []byte(`"_priority"`), []byte(`"_resourceType"`), []byte(`"_fromCache"`),
+	[]byte(`"_fromServiceWorker"`), []byte(`"_fetchedViaServiceWorker"`), []byte(`"_serviceWorkerResponseSource"`),
+}
+
+// containsAny reports whether data contains any of the keys
+func containsAny(data []byte, keys [][]byte) bool {
+	for _, key := range keys {
+		if bytes.Contains(data, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferSize returns the _transferSize of the response, nil when unknown
+func (e *entryExtensions) transferSize() *float64 {
+	if e.Response == nil {
+		return nil
+	}
+	return e.Response.TransferSize
+}
+
+// key reads an object key
+func (d *documentDecoder) key() (string, error) {
+	token, err := d.decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected an object key, found %v", token)
+	}
+	return key, nil
+}
+
+// skip discards the next value
+func (d *documentDecoder) skip() error {
+	var discarded json.RawMessage
+	return d.decoder.Decode(&discarded)
+}
+
+// expectDelim reads the given delimiter
+func (d *documentDecoder) expectDelim(delim json.Delim) error {
+	token, err := d.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, found %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package har
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader returns its data then fails
+type failingReader struct {
+	reader io.Reader
+}
+
+// Read implements io.Reader
+func (f *failingReader) Read(b []byte) (int, error) {
+	n, err := f.reader.Read(b)
+	if errors.Is(err, io.EOF) {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestParseStreamsEntriesMixingStandardAndFlexibleFields(t *testing.T) {
+	document := `{
+		"comment": {"ignored": [1, 2, 3]},
+		"log": {
+			"version": "1.2",
+			"creator": "exporter",
+			"pages": [{"id": "page_1", "title": "Home"}],
+			"entries": [
+				{"startedDateTime": "2023-01-01T00:00:00Z", "time": 12, "request": {"method": "GET", "url": "https://example.com/a", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0}},
+				null,
+				{"startedDateTime": "2023-01-01T00:00:01Z", "time": 3.5, "request": {"method": "GET", "url": "https://example.com/b?page=2", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [{"name": "page", "value": 2}], "cookies": [], "headersSize": -1, "bodySize": 0},
+				 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 2, "mimeType": "text/plain", "text": "ok"}, "redirectURL": "", "headersSize": 100, "bodySize": -1, "_transferSize": 102}}
+			]
+		}
+	}`
+
+	harData, report, err := NewParser().ParseWithReport(strings.NewReader(document))
+	require.NoError(t, err)
+
+	require.Len(t, harData.Log.Entries, 2)
+	assert.Equal(t, ParseModeFlexible, report.Mode)
+	assert.Equal(t, "exporter", harData.Log.Creator.Name)
+	assert.Equal(t, len(document), report.Bytes)
+	assert.Equal(t, 1, report.DroppedEntries)
+	assert.Equal(t, int64(12), harData.Log.Entries[0].Time)
+	assert.Equal(t, "2", harData.Log.Entries[1].Request.QueryString[0].Value)
+	assert.Equal(t, []byte("ok"), harData.Log.Entries[1].Response.Content.Text)
+	assert.Equal(t, int64(2), harData.Log.Entries[1].Response.BodySize)
+	assert.Contains(t, report.Warnings, ParseWarning{RequestID: "request_2", Message: "coerced 1 non-string queryString values to strings"})
+}
+
+func TestParseReportsTruncatedEntries(t *testing.T) {
+	_, err := NewParser().Parse(strings.NewReader(`{"log": {"version": "1.2", "entries": [{"request": {"method": "GET", "url": "https://exa`))
+
+	assert.ErrorContains(t, err, "failed to parse HAR file: log.entries[0]")
+}
+
+func TestParseReportsReadErrors(t *testing.T) {
+	_, err := NewParser().Parse(&failingReader{reader: strings.NewReader(createMultipleEntriesHAR()[:100])})
+
+	assert.ErrorContains(t, err, "failed to read HAR data: connection reset")
+}
@@ -0,0 +1,66 @@
+package har
+
+import (
+	"github.com/google/martian/har"
+)
+
+// Subset returns a copy of the HAR file keeping only the entries with the given request IDs,
+// the others being replaced by entries without a request, which tools and WriteHAR skip, so
+// that request IDs still refer to the whole file and the parse report indices still apply
+func (p *Parser) Subset(harData *har.HAR, requestIDs []string) (*har.HAR, error) {
+	selected := make(map[int]bool, len(requestIDs))
+	for _, requestID := range requestIDs {
+		index, err := p.getEntryIndex(harData, requestID)
+		if err != nil {
+			return nil, err
+		}
+		selected[index] = true
+	}
+
+	log := *harData.Log
+	log.Entries = make([]*har.Entry, len(harData.Log.Entries))
+	for i, entry := range harData.Log.Entries {
+		if selected[i] {
+			log.Entries[i] = entry
+		} else {
+			log.Entries[i] = &har.Entry{}
+		}
+	}
+	return &har.HAR{Log: &log}, nil
+}
+
+// AnalyzedEntries counts the entries of the HAR file that are not excluded from the analysis
+func AnalyzedEntries(harData *har.HAR) int {
+	count := 0
+	for _, entry := range harData.Log.Entries {
+		if entry != nil && entry.Request != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Exclude returns a copy of the HAR file where the entries with the given request IDs are
+// replaced by entries without a request, which tools and WriteHAR skip, so that they are left out
+// of every analysis while request IDs still refer to the whole file
+func (p *Parser) Exclude(harData *har.HAR, requestIDs []string) (*har.HAR, error) {
+	excluded := make(map[int]bool, len(requestIDs))
+	for _, requestID := range requestIDs {
+		index, err := p.getEntryIndex(harData, requestID)
+		if err != nil {
+			return nil, err
+		}
+		excluded[index] = true
+	}
+
+	log := *harData.Log
+	log.Entries = make([]*har.Entry, len(harData.Log.Entries))
+	for i, entry := range harData.Log.Entries {
+		if excluded[i] {
+			log.Entries[i] = &har.Entry{}
+		} else {
+			log.Entries[i] = entry
+		}
+	}
+	return &har.HAR{Log: &log}, nil
+}
@@ -0,0 +1,73 @@
+package har
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsetKeepsSelectedEntries(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testPagesDocument))
+	require.NoError(t, err)
+
+	subset, err := parser.Subset(harData, []string{"request_1", "request_2", "request_1"})
+	require.NoError(t, err)
+	require.Len(t, subset.Log.Entries, len(harData.Log.Entries), "request IDs keep referring to the whole file")
+	assert.Nil(t, subset.Log.Entries[0].Request)
+	assert.Same(t, harData.Log.Entries[1], subset.Log.Entries[1])
+	assert.Len(t, harData.Log.Entries, 4, "the archive is left untouched")
+	assert.NotNil(t, harData.Log.Entries[0].Request)
+
+	var buf bytes.Buffer
+	export, err := parser.WriteHAR(&buf, subset, report)
+	require.NoError(t, err)
+	assert.Equal(t, 2, export.Entries)
+	assert.Equal(t, 1, export.Pages, "only the pages of the selected entries are kept")
+	written, err := NewParser().Parse(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, harData.Log.Creator, written.Log.Creator)
+	assert.Equal(t, "https://example.com/app.js", written.Log.Entries[0].Request.URL)
+	assert.Equal(t, "https://example.com/beacon", written.Log.Entries[1].Request.URL)
+}
+
+func TestSubsetRejectsUnknownRequestIDs(t *testing.T) {
+	parser := NewParser()
+	harData := newTestHAR(newTestEntry("GET", "https://example.com/"))
+
+	_, err := parser.Subset(harData, []string{"request_3"})
+
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestExcludeLeavesEntriesOut(t *testing.T) {
+	parser := NewParser()
+	harData := newTestHAR(
+		newTestEntry("GET", "https://example.com/"),
+		newTestEntry("GET", "https://www.google-analytics.com/g/collect"),
+		newTestEntry("GET", "https://example.com/api"),
+	)
+
+	excluded, err := parser.Exclude(harData, []string{"request_1"})
+	require.NoError(t, err)
+	require.Len(t, excluded.Log.Entries, 3, "request IDs keep referring to the whole file")
+	assert.Nil(t, excluded.Log.Entries[1].Request)
+	assert.Same(t, harData.Log.Entries[2], excluded.Log.Entries[2])
+	assert.NotNil(t, harData.Log.Entries[1].Request, "the archive is left untouched")
+
+	_, err = parser.GetRequestDetails(excluded, nil, nil, "request_1")
+	assert.ErrorContains(t, err, "excluded from the analysis")
+	_, err = parser.Exclude(harData, []string{"request_3"})
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestAnalyzedEntriesLeavesOutExcludedEntries(t *testing.T) {
+	parser := NewParser()
+	harData, err := parser.Exclude(newTestHAR(newTestEntry("GET", "https://example.com/"), newTestEntry("GET", "https://example.com/a")), []string{"request_0"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, AnalyzedEntries(harData))
+}
@@ -0,0 +1,250 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultTimingEntries is the number of entries returned when no limit is given
+	DefaultTimingEntries = 50
+	// MaxTimingEntries caps the number of entries returned at once
+	MaxTimingEntries = 1000
+)
+
+// Timings are the milliseconds an entry spent in each phase, nil when the phase does not apply,
+// such as dns and connect on a reused connection. SSL is part of Connect, as in HAR files.
+type Timings struct {
+	Blocked *float64 `json:"blocked"`
+	DNS     *float64 `json:"dns"`
+	Connect *float64 `json:"connect"`
+	SSL     *float64 `json:"ssl"`
+	Send    *float64 `json:"send"`
+	Wait    *float64 `json:"wait"`
+	Receive *float64 `json:"receive"`
+}
+
+// TimingIndex holds the timings of the entries, of which martian's model only keeps the send,
+// wait and receive milliseconds, and the times of the entries with fractional milliseconds,
+// which martian's model truncates. It is built when parsing and available from the parse report.
+type TimingIndex struct {
+	timings map[*har.Entry]*Timings
+	times   map[*har.Entry]float64
+}
+
+// EntryTimings is the timing breakdown of an entry
+type EntryTimings struct {
+	RequestID       string `json:"request_id"`
+	Method          string `json:"method"`
+	URL             string `json:"url"`
+	Status          int    `json:"status"`
+	StartedDateTime string `json:"started_date_time"`
+	// TimeMS is the time recorded on the entry, TotalMS the sum of its phases
+	TimeMS  float64  `json:"time_ms"`
+	TotalMS float64  `json:"total_ms"`
+	Timings *Timings `json:"timings"`
+}
+
+// TimingReport is the timing breakdown of a set of entries, slowest first
+type TimingReport struct {
+	Matching int `json:"matching"`
+	// Phases sums each phase over the matching entries, so the dominant one stands out
+	Phases  Timings `json:"phases"`
+	TotalMS float64 `json:"total_ms"`
+	// Truncated is set when only the slowest entries are returned
+	Truncated bool           `json:"truncated,omitempty"`
+	Entries   []EntryTimings `json:"entries"`
+	// Caveats qualify the timings of captures recorded behind a proxy, see DetectInterception
+	Caveats []string `json:"caveats,omitempty"`
+}
+
+// Timings returns the timings of the entry, only the send, wait and receive milliseconds of
+// martian's model when the index does not hold the entry
+func (idx *TimingIndex) Timings(entry *har.Entry) *Timings {
+	if idx != nil {
+		if timings, ok := idx.timings[entry]; ok {
+			return timings
+		}
+	}
+	if entry == nil || entry.Timings == nil {
+		return &Timings{}
+	}
+	return &Timings{
+		Send:    phase(float64(entry.Timings.Send)),
+		Wait:    phase(float64(entry.Timings.Wait)),
+		Receive: phase(float64(entry.Timings.Receive)),
+	}
+}
+
+// Time returns the total elapsed milliseconds of the entry, with the fractional milliseconds
+// martian's model truncates when the index holds them
+func (idx *TimingIndex) Time(entry *har.Entry) float64 {
+	if idx != nil {
+		if elapsed, ok := idx.times[entry]; ok {
+			return elapsed
+		}
+	}
+	return float64(entry.Time)
+}
+
+// Duration returns the total elapsed time of the entry
+func (idx *TimingIndex) Duration(entry *har.Entry) time.Duration {
+	return durationOf(idx.Time(entry))
+}
+
+// End returns the time the entry finished, its start time plus its elapsed time
+func (idx *TimingIndex) End(entry *har.Entry) time.Time {
+	return entry.StartedDateTime.Add(idx.Duration(entry))
+}
+
+// milliseconds converts a duration to milliseconds, keeping the fractional part
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// formatMilliseconds renders milliseconds without trailing zeros, such as 12 or 12.75
+func formatMilliseconds(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', -1, 64)
+}
+
+// durationOf converts milliseconds to a duration
+func durationOf(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// Total returns the milliseconds spent in every phase, SSL being counted in Connect
+func (t *Timings) Total() float64 {
+	var total float64
+	for _, value := range []*float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if value != nil {
+			total += *value
+		}
+	}
+	return total
+}
+
+// add sums the phases of other into t
+func (t *Timings) add(other *Timings) {
+	addPhase(&t.Blocked, other.Blocked)
+	addPhase(&t.DNS, other.DNS)
+	addPhase(&t.Connect, other.Connect)
+	addPhase(&t.SSL, other.SSL)
+	addPhase(&t.Send, other.Send)
+	addPhase(&t.Wait, other.Wait)
+	addPhase(&t.Receive, other.Receive)
+}
+
+// addPhase adds value to the phase sum, which stays nil as long as no value is added
+func addPhase(sum **float64, value *float64) {
+	if value == nil {
+		return
+	}
+	if *sum == nil {
+		*sum = new(float64)
+	}
+	**sum += *value
+}
+
+// phase returns the milliseconds of a phase, nil for the -1 marking phases that do not apply
+func phase(value float64) *float64 {
+	if value < 0 {
+		return nil
+	}
+	return &value
+}
+
+// optionalPhase returns the milliseconds of an optional phase, nil when missing or not applicable
+func optionalPhase(value *FlexibleFloat) *float64 {
+	if value == nil {
+		return nil
+	}
+	return phase(float64(*value))
+}
+
+// decodeTimings decodes the timings of an entry from its raw bytes. Browsers write timings
+// last, so only the members from the last timings key on are decoded, avoiding decoding the
+// whole entry twice; the whole entry is decoded when that key is not a member of the entry.
+func decodeTimings(raw json.RawMessage) *Timings {
+	start := bytes.LastIndex(raw, []byte(`"timings"`))
+	if start < 0 {
+		return nil
+	}
+	var members struct {
+		Timings *FlexibleTimings `json:"timings"`
+	}
+	if err := json.Unmarshal(append([]byte{'{'}, raw[start:]...), &members); err != nil {
+		if err := json.Unmarshal(raw, &members); err != nil {
+			return nil
+		}
+	}
+	return members.Timings.ToTimings()
+}
+
+// GetTimings returns the timing breakdown of the entry with the given request ID, or of the
+// entries matching the filter when requestID is empty, slowest first. The lookup index narrows
+// the entries checked against the filter.
+func (p *Parser) GetTimings(harData *har.HAR, index *TimingIndex, lookup *LookupIndex, fields *CustomFieldIndex, requestID string, filter EntryFilter, limit int) (*TimingReport, error) {
+	compiled, err := filter.compile()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultTimingEntries
+	}
+	limit = min(limit, MaxTimingEntries)
+
+	var positions []int
+	if requestID != "" {
+		i, err := p.getEntryIndex(harData, requestID)
+		if err != nil {
+			return nil, err
+		}
+		positions = []int{i}
+	} else {
+		positions = compiled.positions(harData, lookup)
+	}
+
+	redaction := p.redaction()
+	report := &TimingReport{Entries: []EntryTimings{}}
+	for _, i := range positions {
+		entry := harData.Log.Entries[i]
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		if requestID == "" && !compiled.matches(entry, index, fields) {
+			continue
+		}
+
+		timings := index.Timings(entry)
+		timing := EntryTimings{
+			RequestID:       formatRequestID(i),
+			Method:          entry.Request.Method,
+			URL:             redaction.text(entry.Request.URL),
+			StartedDateTime: p.formatTime(entry.StartedDateTime, time.RFC3339Nano),
+			TimeMS:          index.Time(entry),
+			TotalMS:         timings.Total(),
+			Timings:         timings,
+		}
+		if entry.Response != nil {
+			timing.Status = entry.Response.Status
+		}
+		report.Matching++
+		report.Phases.add(timings)
+		report.TotalMS += timing.TotalMS
+		report.Entries = append(report.Entries, timing)
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		return report.Entries[i].TotalMS > report.Entries[j].TotalMS
+	})
+	if len(report.Entries) > limit {
+		report.Entries = report.Entries[:limit]
+		report.Truncated = true
+	}
+	return report, nil
+}
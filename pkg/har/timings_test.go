@@ -0,0 +1,159 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTimingsDocument holds a connection setup, a reused connection with float timings and an
+// entry whose initiator has timings of its own after the entry timings
+const testTimingsDocument = `{
+	"log": {
+		"version": "1.2",
+		"creator": {"name": "browser", "version": "1"},
+		"entries": [
+			{"startedDateTime": "2023-01-01T00:00:00Z", "time": 180, "request": {"method": "GET", "url": "https://example.com/", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "text/html"}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+			 "cache": {}, "timings": {"blocked": 5, "dns": 20, "connect": 60, "ssl": 40, "send": 1, "wait": 90, "receive": 4}},
+			{"startedDateTime": "2023-01-01T00:00:01Z", "time": 12.75, "request": {"method": "GET", "url": "https://example.com/app.js", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "application/javascript"}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+			 "cache": {}, "timings": {"blocked": 0.5, "dns": -1, "connect": -1, "ssl": -1, "send": 0.25, "wait": 10.5, "receive": 1.5}},
+			{"startedDateTime": "2023-01-01T00:00:02Z", "time": 30, "request": {"method": "POST", "url": "https://example.com/api", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "response": {"status": 500, "statusText": "Error", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "application/json"}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+			 "cache": {}, "timings": {"blocked": 2, "send": 1, "wait": 25, "receive": 2}, "_initiator": {"type": "script", "timings": {"wait": 999}}}
+		]
+	}
+}`
+
+func TestParseKeepsEveryTimingPhase(t *testing.T) {
+	parser := NewParser()
+
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testTimingsDocument))
+
+	require.NoError(t, err)
+	index := report.TimingIndex()
+	connection := index.Timings(harData.Log.Entries[0])
+	require.NotNil(t, connection.DNS)
+	assert.Equal(t, 20.0, *connection.DNS)
+	assert.Equal(t, 40.0, *connection.SSL)
+	assert.Equal(t, 180.0, connection.Total())
+
+	reused := index.Timings(harData.Log.Entries[1])
+	assert.Nil(t, reused.DNS)
+	assert.Nil(t, reused.Connect)
+	require.NotNil(t, reused.Wait)
+	assert.Equal(t, 10.5, *reused.Wait)
+	assert.Equal(t, 12.75, reused.Total())
+	assert.Equal(t, 12.75, index.Time(harData.Log.Entries[1]))
+	assert.Equal(t, int64(12), harData.Log.Entries[1].Time)
+
+	initiated := index.Timings(harData.Log.Entries[2])
+	require.NotNil(t, initiated.Wait)
+	assert.Equal(t, 25.0, *initiated.Wait)
+	assert.Nil(t, initiated.SSL)
+}
+
+func TestTimingIndexFallsBackToMartianTimings(t *testing.T) {
+	entry := newTestEntry("GET", "https://example.com/")
+	entry.Timings = &har.Timings{Send: 1, Wait: 20, Receive: 3}
+
+	timings := (&TimingIndex{}).Timings(entry)
+
+	assert.Nil(t, timings.Blocked)
+	require.NotNil(t, timings.Wait)
+	assert.Equal(t, 20.0, *timings.Wait)
+	assert.Equal(t, 24.0, timings.Total())
+}
+
+func TestGetTimingsSortsSlowestFirst(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testTimingsDocument))
+	require.NoError(t, err)
+
+	timings, err := parser.GetTimings(harData, report.TimingIndex(), report.LookupIndex(), nil, "", EntryFilter{}, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, timings.Matching)
+	require.Len(t, timings.Entries, 3)
+	assert.Equal(t, "request_0", timings.Entries[0].RequestID)
+	assert.Equal(t, "request_2", timings.Entries[1].RequestID)
+	assert.Equal(t, "request_1", timings.Entries[2].RequestID)
+	assert.Equal(t, 222.75, timings.TotalMS)
+	require.NotNil(t, timings.Phases.DNS)
+	assert.Equal(t, 20.0, *timings.Phases.DNS)
+	assert.Equal(t, 125.5, *timings.Phases.Wait)
+	assert.False(t, timings.Truncated)
+}
+
+func TestGetTimingsFiltersAndLimits(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testTimingsDocument))
+	require.NoError(t, err)
+
+	timings, err := parser.GetTimings(harData, report.TimingIndex(), report.LookupIndex(), nil, "", EntryFilter{Method: "GET"}, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, timings.Matching)
+	assert.True(t, timings.Truncated)
+	require.Len(t, timings.Entries, 1)
+	assert.Equal(t, "request_0", timings.Entries[0].RequestID)
+}
+
+func TestGetTimingsOfARequest(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testTimingsDocument))
+	require.NoError(t, err)
+
+	timings, err := parser.GetTimings(harData, report.TimingIndex(), report.LookupIndex(), nil, "request_2", EntryFilter{}, 0)
+
+	require.NoError(t, err)
+	require.Len(t, timings.Entries, 1)
+	assert.Equal(t, 500, timings.Entries[0].Status)
+	assert.Equal(t, 30.0, timings.Entries[0].TimeMS)
+	assert.Equal(t, 30.0, timings.Entries[0].TotalMS)
+
+	_, err = parser.GetTimings(harData, report.TimingIndex(), report.LookupIndex(), nil, "request_9", EntryFilter{}, 0)
+	assert.Error(t, err)
+}
+
+func TestAnalyzersKeepFractionalMilliseconds(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testTimingsDocument))
+	require.NoError(t, err)
+	index := report.TimingIndex()
+
+	details, err := parser.GetRequestDetails(harData, index, nil, "request_1")
+	require.NoError(t, err)
+	assert.Equal(t, 12.75, details.Time)
+	require.NotNil(t, details.Timings)
+	assert.Equal(t, 0.25, *details.Timings.Send)
+	assert.Nil(t, details.Timings.DNS)
+
+	matches, err := parser.FilterEntries(harData, index, nil, nil, EntryFilter{MinDurationMS: 12.5})
+	require.NoError(t, err)
+	require.Len(t, matches, 3)
+	assert.Equal(t, 12.75, matches[1].DurationMS)
+	matches, err = parser.FilterEntries(harData, index, nil, nil, EntryFilter{MinDurationMS: 12.8})
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+
+	stats := parser.GetHARStats(harData, index, 3)
+	assert.Equal(t, 2030.0, stats.TimeSpanMS)
+	assert.Equal(t, 12.75, stats.Slowest[2].DurationMS)
+}
+
+func TestGetTimingsRedactsURLs(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`sk_live_\w+`}}))
+	archive := newTestHAR(newTestEntry("GET", "https://example.com/api?key=sk_live_abc"))
+
+	timings, err := parser.GetTimings(archive, nil, nil, nil, "", EntryFilter{}, 0)
+	require.NoError(t, err)
+
+	require.Len(t, timings.Entries, 1)
+	assert.Equal(t, "https://example.com/api?key=[REDACTED]", timings.Entries[0].URL)
+}
@@ -0,0 +1,227 @@
+package har
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+// maxTokenExamples caps the request IDs listed per token
+const maxTokenExamples = 10
+
+// jwtClaims are the registered JWT claims the expiry report relies on
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	IssuedAt  *float64 `json:"iat"`
+	ExpiresAt *float64 `json:"exp"`
+}
+
+// TokenUsage describes how one JWT was used across the capture. The token itself is never
+// exposed, only a fingerprint telling tokens apart.
+type TokenUsage struct {
+	Fingerprint string `json:"fingerprint"`
+	// Location is where the token was sent, e.g. header:Authorization or cookie:session
+	Location  string `json:"location"`
+	Subject   string `json:"subject,omitempty"`
+	Issuer    string `json:"issuer,omitempty"`
+	IssuedAt  string `json:"issued_at,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	FirstUsed string `json:"first_used"`
+	LastUsed  string `json:"last_used"`
+	Requests  int    `json:"requests"`
+	// ExpiredRequests counts the requests sent at or after the expiry time
+	ExpiredRequests int `json:"expired_requests"`
+	Unauthorized    int `json:"unauthorized"`
+	// ExpiredRequestIDs are examples of requests sent with the token expired
+	ExpiredRequestIDs []string `json:"expired_request_ids,omitempty"`
+	// UnauthorizedRequestIDs are examples of requests rejected with a 401
+	UnauthorizedRequestIDs []string `json:"unauthorized_request_ids,omitempty"`
+	expiresAt              time.Time
+}
+
+// TokenExpiryReport correlates the expiry of the JWTs sent by the client with the 401 responses
+type TokenExpiryReport struct {
+	Tokens       []TokenUsage `json:"tokens"`
+	Unauthorized int          `json:"unauthorized"`
+	// UnauthorizedExpired counts the 401 responses to requests carrying an expired token
+	UnauthorizedExpired int `json:"unauthorized_expired"`
+	// UnauthorizedValid counts the 401 responses to requests carrying only unexpired tokens
+	UnauthorizedValid int `json:"unauthorized_valid"`
+	// UnauthorizedWithoutToken counts the 401 responses to requests carrying no JWT
+	UnauthorizedWithoutToken int      `json:"unauthorized_without_token"`
+	Findings                 []string `json:"findings"`
+}
+
+// GetTokenExpiryReport finds the JWTs sent in request headers, cookies and query parameters,
+// decodes their exp claims and correlates them with the 401 responses, so refresh-logic bugs
+// show up directly: tokens still sent after expiring, or rejected while still valid.
+func (p *Parser) GetTokenExpiryReport(harData *har.HAR) *TokenExpiryReport {
+	report := &TokenExpiryReport{Tokens: []TokenUsage{}, Findings: []string{}}
+	tokens := make(map[string]*TokenUsage)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		requestID := formatRequestID(i)
+		unauthorized := entry.Response != nil && entry.Response.Status == 401
+		if unauthorized {
+			report.Unauthorized++
+		}
+
+		found := requestJWTs(entry.Request)
+		expired := false
+		for _, token := range found {
+			usage, ok := tokens[token.fingerprint]
+			if !ok {
+				usage = p.newTokenUsage(token)
+				usage.FirstUsed = p.formatTime(entry.StartedDateTime, time.RFC3339Nano)
+				tokens[token.fingerprint] = usage
+				order = append(order, token.fingerprint)
+			}
+			usage.Requests++
+			usage.LastUsed = p.formatTime(entry.StartedDateTime, time.RFC3339Nano)
+
+			if !usage.expiresAt.IsZero() && !entry.StartedDateTime.Before(usage.expiresAt) {
+				expired = true
+				usage.ExpiredRequests++
+				if len(usage.ExpiredRequestIDs) < maxTokenExamples {
+					usage.ExpiredRequestIDs = append(usage.ExpiredRequestIDs, requestID)
+				}
+			}
+			if unauthorized {
+				usage.Unauthorized++
+				if len(usage.UnauthorizedRequestIDs) < maxTokenExamples {
+					usage.UnauthorizedRequestIDs = append(usage.UnauthorizedRequestIDs, requestID)
+				}
+			}
+		}
+
+		switch {
+		case !unauthorized:
+		case len(found) == 0:
+			report.UnauthorizedWithoutToken++
+		case expired:
+			report.UnauthorizedExpired++
+		default:
+			report.UnauthorizedValid++
+		}
+	}
+
+	for _, fingerprint := range order {
+		usage := tokens[fingerprint]
+		report.Tokens = append(report.Tokens, *usage)
+		if usage.ExpiredRequests > 0 {
+			report.Findings = append(report.Findings, fmt.Sprintf(
+				"token %s (%s) was sent %d times after expiring at %s, the client did not refresh it in time",
+				usage.Fingerprint, usage.Location, usage.ExpiredRequests, usage.ExpiresAt))
+		}
+	}
+	if report.UnauthorizedValid > 0 {
+		report.Findings = append(report.Findings, fmt.Sprintf(
+			"%d requests were rejected with a 401 while carrying unexpired tokens: revoked token, wrong audience or clock skew",
+			report.UnauthorizedValid))
+	}
+	if report.UnauthorizedWithoutToken > 0 {
+		report.Findings = append(report.Findings, fmt.Sprintf(
+			"%d requests were rejected with a 401 without carrying any JWT", report.UnauthorizedWithoutToken))
+	}
+
+	return report
+}
+
+// newTokenUsage starts tracking a token with its decoded claims
+func (p *Parser) newTokenUsage(token foundJWT) *TokenUsage {
+	usage := &TokenUsage{
+		Fingerprint: token.fingerprint,
+		Location:    token.location,
+		Subject:     token.claims.Subject,
+		Issuer:      token.claims.Issuer,
+	}
+	if token.claims.IssuedAt != nil {
+		usage.IssuedAt = p.formatTime(unixTime(*token.claims.IssuedAt), time.RFC3339)
+	}
+	if token.claims.ExpiresAt != nil {
+		usage.expiresAt = unixTime(*token.claims.ExpiresAt)
+		usage.ExpiresAt = p.formatTime(usage.expiresAt, time.RFC3339)
+	}
+	return usage
+}
+
+// foundJWT is a JWT sent by a request
+type foundJWT struct {
+	fingerprint string
+	location    string
+	claims      jwtClaims
+}
+
+// requestJWTs returns the JWTs a request sends in its headers, cookies and query string
+func requestJWTs(request *har.Request) []foundJWT {
+	var found []foundJWT
+	seen := make(map[string]bool)
+	add := func(location, value string) {
+		value = strings.TrimSpace(value)
+		if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			value = strings.TrimSpace(token)
+		}
+		claims, ok := decodeJWTClaims(value)
+		if !ok {
+			return
+		}
+		fingerprint := jwtFingerprint(value)
+		if seen[fingerprint] {
+			return
+		}
+		seen[fingerprint] = true
+		found = append(found, foundJWT{fingerprint: fingerprint, location: location, claims: claims})
+	}
+
+	for _, header := range request.Headers {
+		// Cookies are read from the parsed cookies rather than the Cookie header
+		if !strings.EqualFold(header.Name, "Cookie") {
+			add("header:"+header.Name, header.Value)
+		}
+	}
+	for _, cookie := range request.Cookies {
+		add("cookie:"+cookie.Name, cookie.Value)
+	}
+	for _, param := range request.QueryString {
+		add("query:"+param.Name, param.Value)
+	}
+	return found
+}
+
+// decodeJWTClaims decodes the claims of a JWT
+func decodeJWTClaims(token string) (jwtClaims, bool) {
+	var claims jwtClaims
+	if !jwtPattern.MatchString(token) {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.Split(token, ".")[1], "="))
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+// jwtFingerprint identifies a token without exposing it
+func jwtFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "jwt_" + hex.EncodeToString(sum[:6])
+}
+
+// unixTime converts a NumericDate claim, seconds since the epoch, to a time
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+}
@@ -0,0 +1,82 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestJWT builds an unsigned JWT expiring at the given time
+func newTestJWT(subject string, expiresAt time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":%q,"iss":"auth.example.com","exp":%d}`, subject, expiresAt.Unix())))
+	return header + "." + claims + ".c2lnbmF0dXJl"
+}
+
+// newTestAuthorizedAttempt builds a request carrying a bearer token
+func newTestAuthorizedAttempt(offset time.Duration, status int, token string) *har.Entry {
+	entry := newTestAttempt("https://api.example.com/me", offset, status, 10)
+	entry.Request.Headers = []har.Header{{Name: "Authorization", Value: "Bearer " + token}}
+	return entry
+}
+
+func TestGetTokenExpiryReportFindsTokensUsedAfterExpiry(t *testing.T) {
+	parser := NewParser()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	stale := newTestJWT("user-1", start.Add(time.Minute))
+	fresh := newTestJWT("user-1", start.Add(time.Hour))
+	rejected := newTestJWT("user-2", start.Add(time.Hour))
+
+	withCookie := newTestAttempt("https://api.example.com/cart", 3*time.Minute, 200, 10)
+	withCookie.Request.Cookies = []har.Cookie{{Name: "session", Value: fresh}}
+	anonymous := newTestAttempt("https://api.example.com/admin", 4*time.Minute, 401, 10)
+
+	report := parser.GetTokenExpiryReport(newTestHAR(
+		newTestAuthorizedAttempt(0, 200, stale),
+		newTestAuthorizedAttempt(time.Minute, 401, stale),
+		newTestAuthorizedAttempt(2*time.Minute, 401, stale),
+		newTestAuthorizedAttempt(2*time.Minute+time.Second, 200, fresh),
+		withCookie,
+		anonymous,
+		newTestAuthorizedAttempt(5*time.Minute, 401, rejected),
+	))
+
+	require.Len(t, report.Tokens, 3)
+	usage := report.Tokens[0]
+	assert.Equal(t, jwtFingerprint(stale), usage.Fingerprint)
+	assert.NotContains(t, usage.Fingerprint, stale)
+	assert.Equal(t, "header:Authorization", usage.Location)
+	assert.Equal(t, "user-1", usage.Subject)
+	assert.Equal(t, "auth.example.com", usage.Issuer)
+	assert.Equal(t, "2023-01-01T00:01:00Z", usage.ExpiresAt)
+	assert.Equal(t, "2023-01-01T00:00:00Z", usage.FirstUsed)
+	assert.Equal(t, "2023-01-01T00:02:00Z", usage.LastUsed)
+	assert.Equal(t, 3, usage.Requests)
+	assert.Equal(t, 2, usage.ExpiredRequests)
+	assert.Equal(t, []string{"request_1", "request_2"}, usage.ExpiredRequestIDs)
+	assert.Equal(t, []string{"request_1", "request_2"}, usage.UnauthorizedRequestIDs)
+
+	assert.Equal(t, 2, report.Tokens[1].Requests)
+	assert.Zero(t, report.Tokens[1].ExpiredRequests)
+
+	assert.Equal(t, 4, report.Unauthorized)
+	assert.Equal(t, 2, report.UnauthorizedExpired)
+	assert.Equal(t, 1, report.UnauthorizedValid)
+	assert.Equal(t, 1, report.UnauthorizedWithoutToken)
+	assert.Equal(t, []string{
+		fmt.Sprintf("token %s (header:Authorization) was sent 2 times after expiring at 2023-01-01T00:01:00Z, the client did not refresh it in time", jwtFingerprint(stale)),
+		"1 requests were rejected with a 401 while carrying unexpired tokens: revoked token, wrong audience or clock skew",
+		"1 requests were rejected with a 401 without carrying any JWT",
+	}, report.Findings)
+}
+
+func TestRequestJWTsIgnoresOpaqueTokens(t *testing.T) {
+	entry := newTestEntry("GET", "https://api.example.com/me", har.Header{Name: "Authorization", Value: "Bearer opaque-token"})
+
+	assert.Empty(t, requestJWTs(entry.Request))
+}
@@ -0,0 +1,110 @@
+package har
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// pathPlaceholder replaces variable path segments in URL templates
+const pathPlaceholder = "{id}"
+
+var (
+	numericSegmentPattern = regexp.MustCompile(`^[0-9]+$`)
+	hexSegmentPattern     = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	// tokenSegmentPattern matches long opaque tokens mixing letters and digits, e.g. content hashes
+	tokenSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{24,}$`)
+	digitPattern        = regexp.MustCompile(`[0-9]`)
+	// odataKeySegmentPattern matches OData entity keys and function parameters, e.g. Products(42)
+	odataKeySegmentPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.]*)\(.+\)$`)
+)
+
+// templateURL returns the URL without its query string and with variable path
+// segments (numeric IDs, UUIDs, hashes) replaced by {id}, so that /users/123
+// and /users/456 share the same template. OData keys are replaced the same way,
+// /Products(42) becoming /Products({id}).
+func templateURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if IsVariableSegment(segment) {
+			segments[i] = pathPlaceholder
+		} else if match := odataKeySegmentPattern.FindStringSubmatch(segment); match != nil {
+			segments[i] = match[1] + "(" + pathPlaceholder + ")"
+		}
+	}
+
+	return u.Scheme + "://" + u.Host + strings.Join(segments, "/")
+}
+
+// IsVariableSegment reports whether a path segment looks like an identifier (numeric ID, UUID,
+// hash) rather than a route
+func IsVariableSegment(segment string) bool {
+	return numericSegmentPattern.MatchString(segment) ||
+		uuidPattern.MatchString(segment) ||
+		hexSegmentPattern.MatchString(segment) ||
+		(tokenSegmentPattern.MatchString(segment) && digitPattern.MatchString(segment))
+}
+
+// maxPatternExamples caps the number of example URLs reported per URL pattern
+const maxPatternExamples = 3
+
+// URLPatternEntry groups the requests of a method to the URLs sharing a templated path
+type URLPatternEntry struct {
+	Pattern  string `json:"pattern"`
+	Method   string `json:"method"`
+	Requests int    `json:"requests"`
+	// DistinctURLs counts the concrete URLs, query strings included, matching the pattern
+	DistinctURLs int      `json:"distinct_urls"`
+	ExampleURLs  []string `json:"example_urls"`
+	RequestIDs   []string `json:"request_ids"`
+}
+
+// GetURLPatterns groups the requests by method and URL pattern, the URL without query string
+// and with its variable path segments replaced by {id}, most requested patterns first, so REST
+// APIs list /users/{id} once rather than every user requested.
+func (p *Parser) GetURLPatterns(harData *har.HAR) []URLPatternEntry {
+	redaction := p.redaction()
+	patterns := make(map[string]*URLPatternEntry)
+	urls := make(map[string]map[string]bool)
+	var order []string
+
+	for i, entry := range harData.Log.Entries {
+		if entry.Request == nil {
+			continue
+		}
+		key := resourceKey(entry)
+		pattern, ok := patterns[key]
+		if !ok {
+			pattern = &URLPatternEntry{Pattern: redaction.text(templateURL(entry.Request.URL)), Method: entry.Request.Method, ExampleURLs: []string{}}
+			patterns[key] = pattern
+			urls[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+		pattern.Requests++
+		pattern.RequestIDs = append(pattern.RequestIDs, formatRequestID(i))
+		if !urls[key][entry.Request.URL] {
+			urls[key][entry.Request.URL] = true
+			pattern.DistinctURLs++
+			if len(pattern.ExampleURLs) < maxPatternExamples {
+				pattern.ExampleURLs = append(pattern.ExampleURLs, redaction.text(entry.Request.URL))
+			}
+		}
+	}
+
+	result := make([]URLPatternEntry, 0, len(order))
+	for _, key := range order {
+		result = append(result, *patterns[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Requests > result[j].Requests
+	})
+	return result
+}
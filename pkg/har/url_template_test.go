@@ -0,0 +1,51 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateURLNumericID(t *testing.T) {
+	assert.Equal(t, "https://example.com/users/{id}/orders", templateURL("https://example.com/users/123/orders?page=2"))
+}
+
+func TestTemplateURLUUIDAndHash(t *testing.T) {
+	assert.Equal(t, "https://example.com/items/{id}", templateURL("https://example.com/items/6fa459ea-ee8a-3ca4-894e-db77e160355e"))
+	assert.Equal(t, "https://cdn.example.com/assets/{id}/app.js", templateURL("https://cdn.example.com/assets/3f2a9c1b7d4e5f60/app.js"))
+}
+
+func TestTemplateURLKeepsRouteNames(t *testing.T) {
+	assert.Equal(t, "https://example.com/api/v2/users", templateURL("https://example.com/api/v2/users"))
+}
+
+func TestTemplateURLODataKeys(t *testing.T) {
+	assert.Equal(t, "https://example.com/odata/Products({id})/Supplier", templateURL("https://example.com/odata/Products(42)/Supplier?$expand=Address"))
+	assert.Equal(t, "https://example.com/odata/Customers({id})", templateURL("https://example.com/odata/Customers('ALFKI')"))
+	assert.Equal(t, "https://example.com/odata/OrderDetails({id})", templateURL("https://example.com/odata/OrderDetails(OrderID=1,ProductID=2)"))
+}
+
+func TestGetURLPatternsGroupsByTemplate(t *testing.T) {
+	parser := NewParser()
+	archive := newTestHAR(
+		newTestEntry("GET", "https://api.example.com/users/1"),
+		newTestEntry("GET", "https://api.example.com/users/2?expand=orders"),
+		newTestEntry("GET", "https://api.example.com/users/3"),
+		newTestEntry("GET", "https://api.example.com/users/4"),
+		newTestEntry("DELETE", "https://api.example.com/users/1"),
+		newTestEntry("GET", "https://api.example.com/users/1"),
+	)
+
+	patterns := parser.GetURLPatterns(archive)
+
+	require.Len(t, patterns, 2)
+	assert.Equal(t, "https://api.example.com/users/{id}", patterns[0].Pattern)
+	assert.Equal(t, "GET", patterns[0].Method)
+	assert.Equal(t, 5, patterns[0].Requests)
+	assert.Equal(t, 4, patterns[0].DistinctURLs)
+	assert.Equal(t, []string{"https://api.example.com/users/1", "https://api.example.com/users/2?expand=orders", "https://api.example.com/users/3"}, patterns[0].ExampleURLs)
+	assert.Equal(t, []string{"request_0", "request_1", "request_2", "request_3", "request_5"}, patterns[0].RequestIDs)
+	assert.Equal(t, "DELETE", patterns[1].Method)
+	assert.Equal(t, 1, patterns[1].Requests)
+}
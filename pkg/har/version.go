@@ -0,0 +1,63 @@
+package har
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+// HAR format versions the parser knows about.
+// HAR 1.1 lacks serverIPAddress, connection, timings.ssl, content.encoding and comments,
+// which are simply left empty. HAR 1.3 drafts standardize fields browsers used to
+// export with an underscore prefix, such as the transfer size of responses.
+const (
+	harVersion11 = "1.1"
+	harVersion12 = "1.2"
+	harVersion13 = "1.3"
+)
+
+// normalizeVersion records the HAR version, defaulting to 1.1 as the specification
+// mandates when it is missing, and fills in fields later versions provide differently
+func normalizeVersion(harData *har.HAR, transferSizes []*float64, report *ParseReport) {
+	version := strings.TrimSpace(harData.Log.Version)
+	switch version {
+	case "":
+		version = harVersion11
+		report.warn("", "log.version is missing, assuming HAR 1.1")
+	case harVersion11, harVersion12, harVersion13:
+	default:
+		report.warn("", fmt.Sprintf("unknown HAR version %q, parsed as HAR 1.2", version))
+	}
+	harData.Log.Version = version
+	report.Version = version
+
+	applyTransferSizes(harData, transferSizes, report)
+}
+
+// applyTransferSizes derives the unknown (-1) response body size from _transferSize,
+// which counts the headers and body bytes received on the wire
+func applyTransferSizes(harData *har.HAR, transferSizes []*float64, report *ParseReport) {
+	// The transfer sizes are only meaningful when they align with the parsed entries
+	if len(transferSizes) != len(harData.Log.Entries) {
+		return
+	}
+
+	derived := 0
+	for i, entry := range harData.Log.Entries {
+		transferSize := transferSizes[i]
+		if entry == nil || entry.Response == nil || entry.Response.BodySize >= 0 || transferSize == nil {
+			continue
+		}
+		bodySize := int64(*transferSize) - max(entry.Response.HeadersSize, 0)
+		if bodySize < 0 {
+			continue
+		}
+		entry.Response.BodySize = bodySize
+		derived++
+	}
+
+	if derived > 0 {
+		report.warn("", fmt.Sprintf("response.bodySize derived from _transferSize for %d entries", derived))
+	}
+}
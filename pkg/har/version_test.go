@@ -0,0 +1,84 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createVersionedHAR creates a HAR with a single entry, the version field and the response
+// extra fields are inserted verbatim.
+func createVersionedHAR(versionField, responseExtra string) string {
+	return `{
+		"log": {
+			` + versionField + `
+			"creator": {"name": "test-creator", "version": "1.0"},
+			"entries": [
+				{
+					"startedDateTime": "2023-01-01T00:00:00.000Z",
+					"time": 100,
+					"request": {"method": "GET", "url": "https://example.com", "httpVersion": "HTTP/1.1", "headers": [], "headersSize": -1, "bodySize": 0},
+					"response": {
+						` + responseExtra + `
+						"status": 200,
+						"statusText": "OK",
+						"httpVersion": "HTTP/1.1",
+						"headers": [],
+						"content": {"size": 1000, "mimeType": "text/html"},
+						"redirectURL": "",
+						"headersSize": 150,
+						"bodySize": -1
+					}
+				}
+			]
+		}
+	}`
+}
+
+func TestParseWithReportDefaultsMissingVersion(t *testing.T) {
+	parser := NewParser()
+
+	archive, report, err := parser.ParseWithReport(strings.NewReader(createVersionedHAR("", "")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.1", archive.Log.Version)
+	assert.Equal(t, "1.1", report.Version)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "log.version is missing")
+}
+
+func TestParseWithReportFlagsUnknownVersion(t *testing.T) {
+	parser := NewParser()
+
+	_, report, err := parser.ParseWithReport(strings.NewReader(createVersionedHAR(`"version": "2.0",`, "")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2.0", report.Version)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, `unknown HAR version "2.0"`)
+}
+
+func TestParseWithReportDerivesBodySizeFromTransferSize(t *testing.T) {
+	parser := NewParser()
+
+	archive, report, err := parser.ParseWithReport(strings.NewReader(createVersionedHAR(`"version": "1.3",`, `"_transferSize": 1150,`)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.3", report.Version)
+	assert.Equal(t, int64(1000), archive.Log.Entries[0].Response.BodySize)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "_transferSize for 1 entries")
+}
+
+func TestParseWithReportKeepsKnownBodySize(t *testing.T) {
+	parser := NewParser()
+	data := strings.Replace(createVersionedHAR(`"version": "1.2",`, `"_transferSize": 1150,`), `"bodySize": -1`, `"bodySize": 42`, 1)
+
+	archive, report, err := parser.ParseWithReport(strings.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(42), archive.Log.Entries[0].Response.BodySize)
+	assert.Empty(t, report.Warnings)
+}
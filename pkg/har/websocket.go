@@ -0,0 +1,183 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/martian/har"
+)
+
+const (
+	// DefaultWebSocketMessages is the number of messages returned when no limit is given
+	DefaultWebSocketMessages = 100
+	// MaxWebSocketMessages caps the number of messages returned at once
+	MaxWebSocketMessages = 1000
+	// maxWebSocketPayload caps the bytes of each returned payload
+	maxWebSocketPayload = 4 * 1024
+)
+
+// WebSocket message directions
+const (
+	WebSocketSend    = "send"
+	WebSocketReceive = "receive"
+)
+
+// webSocketOpcodes names the frame opcodes of RFC 6455
+var webSocketOpcodes = map[int]string{
+	0:  "continuation",
+	1:  "text",
+	2:  "binary",
+	8:  "close",
+	9:  "ping",
+	10: "pong",
+}
+
+// WebSocketIndex holds the WebSocket frames of the entries, which martian's model leaves out.
+// It is built when parsing and available from the parse report.
+type WebSocketIndex struct {
+	messages map[*har.Entry][]FlexibleWebSocketMessage
+}
+
+// WebSocketMessage is a frame of a WebSocket connection
+type WebSocketMessage struct {
+	Index     int    `json:"index"`
+	Direction string `json:"direction"`
+	Opcode    int    `json:"opcode"`
+	Kind      string `json:"kind"`
+	Time      string `json:"time"`
+	// Size is the payload size in bytes, once base64 decoded for binary frames
+	Size int `json:"size"`
+	// Binary payloads are not returned, Payload only holds a marker
+	Binary    bool   `json:"binary,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Payload   string `json:"payload"`
+}
+
+// WebSocketMessages is a page of the frames of a WebSocket connection
+type WebSocketMessages struct {
+	RequestID string `json:"request_id"`
+	URL       string `json:"url"`
+	Total     int    `json:"total"`
+	Sent      int    `json:"sent"`
+	Received  int    `json:"received"`
+	// Matching counts the messages in the requested direction
+	Matching int `json:"matching"`
+	Offset   int `json:"offset"`
+	// NextOffset is where the next page starts, absent on the last page
+	NextOffset int                `json:"next_offset,omitempty"`
+	Messages   []WebSocketMessage `json:"messages"`
+}
+
+// Messages returns the WebSocket frames recorded on the entry
+func (idx *WebSocketIndex) Messages(entry *har.Entry) []FlexibleWebSocketMessage {
+	if idx == nil {
+		return nil
+	}
+	return idx.messages[entry]
+}
+
+// GetWebSocketMessages returns the frames of a WebSocket request, at most limit of them starting
+// at offset among the frames in the given direction, or in both when empty. Non-positive limits
+// fall back to DefaultWebSocketMessages and limits above MaxWebSocketMessages are capped.
+// Text payloads are redacted and truncated, binary payloads are replaced by a marker.
+func (p *Parser) GetWebSocketMessages(harData *har.HAR, index *WebSocketIndex, requestID, direction string, offset, limit int) (*WebSocketMessages, error) {
+	entry, err := p.getEntry(harData, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if direction != "" && direction != WebSocketSend && direction != WebSocketReceive {
+		return nil, fmt.Errorf("invalid direction %q, expected %s or %s", direction, WebSocketSend, WebSocketReceive)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset: %d", offset)
+	}
+	if limit <= 0 {
+		limit = DefaultWebSocketMessages
+	}
+	limit = min(limit, MaxWebSocketMessages)
+
+	frames := index.Messages(entry)
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("%s has no WebSocket messages", requestID)
+	}
+
+	redaction := p.redaction()
+	result := &WebSocketMessages{
+		RequestID: requestID,
+		URL:       redaction.text(entry.Request.URL),
+		Total:     len(frames),
+		Offset:    offset,
+		Messages:  []WebSocketMessage{},
+	}
+	for i, frame := range frames {
+		switch frame.Type {
+		case WebSocketSend:
+			result.Sent++
+		case WebSocketReceive:
+			result.Received++
+		}
+		if direction != "" && frame.Type != direction {
+			continue
+		}
+		result.Matching++
+		if result.Matching <= offset {
+			continue
+		}
+		if len(result.Messages) == limit {
+			result.NextOffset = offset + limit
+			continue
+		}
+		result.Messages = append(result.Messages, p.newWebSocketMessage(i, frame, redaction))
+	}
+
+	return result, nil
+}
+
+// newWebSocketMessage describes a frame, redacting its payload
+func (p *Parser) newWebSocketMessage(index int, frame FlexibleWebSocketMessage, redaction *redactor) WebSocketMessage {
+	opcode := int(frame.Opcode)
+	message := WebSocketMessage{
+		Index:     index,
+		Direction: frame.Type,
+		Opcode:    opcode,
+		Kind:      webSocketOpcodes[opcode],
+		Time:      p.formatTime(webSocketTime(float64(frame.Time)), time.RFC3339Nano),
+	}
+	if message.Kind == "" {
+		message.Kind = "unknown"
+	}
+
+	data := []byte(frame.Data)
+	if opcode == 2 {
+		// Chrome stores binary frames base64 encoded
+		if decoded, err := base64.StdEncoding.DecodeString(string(frame.Data)); err == nil {
+			data = decoded
+		}
+	}
+	message.Size = len(data)
+	if opcode == 2 || !isPrintableText(data) {
+		message.Binary = true
+		message.Payload = fmt.Sprintf("[binary frame omitted: %d bytes]", len(data))
+		return message
+	}
+
+	payload := redaction.text(string(data))
+	if len(payload) > maxWebSocketPayload {
+		payload = payload[:runeEnd([]byte(payload), maxWebSocketPayload)]
+		message.Truncated = true
+	}
+	message.Payload = payload
+	return message
+}
+
+// webSocketTime converts a frame timestamp, in seconds since the epoch as Chrome records it or
+// in milliseconds as some other exporters do
+func webSocketTime(timestamp float64) time.Time {
+	if timestamp > 1e11 {
+		timestamp /= 1000
+	}
+	seconds, fraction := math.Modf(timestamp)
+	return time.Unix(int64(seconds), int64(fraction*1e9)).UTC()
+}
@@ -0,0 +1,99 @@
+package har
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWebSocketDocument is a Chrome capture of a WebSocket connection
+const testWebSocketDocument = `{
+	"log": {
+		"version": "1.2",
+		"creator": {"name": "WebInspector", "version": "537.36"},
+		"entries": [
+			{"startedDateTime": "2023-01-01T00:00:00Z", "time": 12, "_resourceType": "websocket",
+			 "request": {"method": "GET", "url": "wss://example.com/socket", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "response": {"status": 101, "statusText": "Switching Protocols", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": "x-unknown"}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+			 "_webSocketMessages": [
+				{"type": "send", "time": 1672531200.5, "opcode": 1, "data": "{\"op\":\"auth\",\"token\":\"s3cr3t-token\"}"},
+				{"type": "receive", "time": 1672531200.75, "opcode": 1, "data": "{\"op\":\"ready\"}"},
+				{"type": "receive", "time": "1672531201", "opcode": 2, "data": "AAECAw=="},
+				{"type": "send", "time": 1672531202, "opcode": 9, "data": ""}
+			 ]},
+			{"startedDateTime": "2023-01-01T00:00:01Z", "time": 3, "request": {"method": "GET", "url": "wss://example.com/broken", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+			 "_webSocketMessages": [{"type": "send", "time": "soon"}]}
+		]
+	}
+}`
+
+func TestParseKeepsWebSocketMessages(t *testing.T) {
+	parser := NewParser()
+
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testWebSocketDocument))
+
+	require.NoError(t, err)
+	assert.Equal(t, ParseModeStrict, report.Mode)
+	assert.Equal(t, 1, report.WebSockets)
+	assert.Len(t, report.WebSocketIndex().Messages(harData.Log.Entries[0]), 4)
+	assert.Empty(t, report.WebSocketIndex().Messages(harData.Log.Entries[1]))
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, "request_1", report.Warnings[0].RequestID)
+	assert.Contains(t, report.Warnings[0].Message, "ignored _webSocketMessages")
+}
+
+func TestGetWebSocketMessages(t *testing.T) {
+	parser := NewParser()
+	require.NoError(t, parser.SetRedactionPolicy(RedactionPolicy{ValuePatterns: []string{`s3cr3t-[a-z]+`}}))
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testWebSocketDocument))
+	require.NoError(t, err)
+
+	messages, err := parser.GetWebSocketMessages(harData, report.WebSocketIndex(), "request_0", "", 0, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, "wss://example.com/socket", messages.URL)
+	assert.Equal(t, 4, messages.Total)
+	assert.Equal(t, 2, messages.Sent)
+	assert.Equal(t, 2, messages.Received)
+	assert.Equal(t, 2, messages.NextOffset)
+	require.Len(t, messages.Messages, 2)
+	assert.Equal(t, WebSocketMessage{
+		Index:     0,
+		Direction: WebSocketSend,
+		Opcode:    1,
+		Kind:      "text",
+		Time:      "2023-01-01T00:00:00.5Z",
+		Size:      36,
+		Payload:   `{"op":"auth","token":"[REDACTED]"}`,
+	}, messages.Messages[0])
+
+	received, err := parser.GetWebSocketMessages(harData, report.WebSocketIndex(), "request_0", WebSocketReceive, 1, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, received.Matching)
+	assert.Zero(t, received.NextOffset)
+	assert.Equal(t, []WebSocketMessage{{
+		Index:     2,
+		Direction: WebSocketReceive,
+		Opcode:    2,
+		Kind:      "binary",
+		Time:      "2023-01-01T00:00:01Z",
+		Size:      4,
+		Binary:    true,
+		Payload:   "[binary frame omitted: 4 bytes]",
+	}}, received.Messages)
+}
+
+func TestGetWebSocketMessagesErrors(t *testing.T) {
+	parser := NewParser()
+	harData, report, err := parser.ParseWithReport(strings.NewReader(testWebSocketDocument))
+	require.NoError(t, err)
+
+	_, err = parser.GetWebSocketMessages(harData, report.WebSocketIndex(), "request_1", "", 0, 0)
+	assert.EqualError(t, err, "request_1 has no WebSocket messages")
+
+	_, err = parser.GetWebSocketMessages(harData, report.WebSocketIndex(), "request_0", "both", 0, 0)
+	assert.EqualError(t, err, `invalid direction "both", expected send or receive`)
+}
@@ -0,0 +1,493 @@
+// Package openapi generates OpenAPI documents describing the APIs observed in HAR files.
+package openapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/martian/har"
+	harParser "github.com/tjamet/har-mcp/pkg/har"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.0.3"
+
+// DefaultTitle is the title of generated documents when none is given
+const DefaultTitle = "API observed in HAR capture"
+
+// Options configures the generated document
+type Options struct {
+	// Title of the document, DefaultTitle when empty
+	Title string
+	// Host restricts the document to the hosts matching a host name, a glob such as
+	// *.example.com or a registrable domain such as site:example.co.uk, see har.MatchHost.
+	// All API hosts are described when empty.
+	Host string
+}
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    Info                 `json:"info"`
+	Servers []Server             `json:"servers,omitempty"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info describes the document
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the paths are relative to
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds the operations of a templated path. Servers is only set when the document
+// describes several hosts.
+type PathItem struct {
+	Servers []Server   `json:"servers,omitempty"`
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation is a method called on a path
+type Operation struct {
+	Summary     string               `json:"summary"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// samples counts the requests the operation was inferred from
+	samples int
+	// queryCounts counts the requests carrying each query parameter
+	queryCounts map[string]int
+	// bodies counts the requests carrying a body
+	bodies int
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the observed request bodies
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes the observed responses with a status code
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema inferred for a content type, absent for non-JSON bodies
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Generate describes the API requests of the HAR file in an OpenAPI document. Requests are
+// grouped by host and templated path, numeric, UUID and hash segments becoming path parameters,
+// and schemas are inferred from the JSON bodies. Only requests exchanging JSON, or changing
+// state without a response body, are described so that pages and assets are left out. Schemas
+// only hold property names and types, never observed values.
+func Generate(harData *har.HAR, opts Options) *Document {
+	title := opts.Title
+	if title == "" {
+		title = DefaultTitle
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: "0.1.0"},
+		Paths:   make(map[string]*PathItem),
+	}
+	if harData == nil || harData.Log == nil {
+		return doc
+	}
+
+	described := 0
+	pathServers := make(map[string][]Server)
+	for _, entry := range harData.Log.Entries {
+		if entry.Request == nil || !isAPIEntry(entry) {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || u.Host == "" || (opts.Host != "" && !matchHost(opts.Host, u)) {
+			continue
+		}
+
+		server := Server{URL: u.Scheme + "://" + u.Host}
+		if !slices.Contains(doc.Servers, server) {
+			doc.Servers = append(doc.Servers, server)
+		}
+		template, pathValues := templatePath(u.EscapedPath())
+		if !slices.Contains(pathServers[template], server) {
+			pathServers[template] = append(pathServers[template], server)
+		}
+
+		item := doc.Paths[template]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[template] = item
+		}
+		operation := item.operation(entry.Request.Method)
+		if operation == nil {
+			continue
+		}
+		if operation.Responses == nil {
+			operation.Summary = strings.ToUpper(entry.Request.Method) + " " + template
+			operation.OperationID = operationID(entry.Request.Method, template)
+			operation.Responses = make(map[string]*Response)
+			operation.queryCounts = make(map[string]int)
+		}
+		operation.addSample(entry, u, pathValues)
+		described++
+	}
+
+	for template, item := range doc.Paths {
+		if len(doc.Servers) > 1 {
+			item.Servers = pathServers[template]
+		}
+		for _, operation := range item.operations() {
+			operation.finish()
+		}
+	}
+	doc.Info.Description = fmt.Sprintf("Generated from %d requests captured in a HAR file", described)
+	return doc
+}
+
+// operation returns the operation of the path for an HTTP method, creating it on first use.
+// It returns nil for methods OpenAPI does not describe.
+func (item *PathItem) operation(method string) *Operation {
+	var slot **Operation
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		slot = &item.Get
+	case http.MethodPut:
+		slot = &item.Put
+	case http.MethodPost:
+		slot = &item.Post
+	case http.MethodDelete:
+		slot = &item.Delete
+	case http.MethodOptions:
+		slot = &item.Options
+	case http.MethodHead:
+		slot = &item.Head
+	case http.MethodPatch:
+		slot = &item.Patch
+	case http.MethodTrace:
+		slot = &item.Trace
+	default:
+		return nil
+	}
+	if *slot == nil {
+		*slot = &Operation{}
+	}
+	return *slot
+}
+
+// operations returns the operations defined on the path
+func (item *PathItem) operations() []*Operation {
+	var operations []*Operation
+	for _, operation := range []*Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch, item.Trace} {
+		if operation != nil {
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+// addSample merges an entry into the operation
+func (o *Operation) addSample(entry *har.Entry, u *url.URL, pathValues map[string]string) {
+	o.samples++
+
+	for name, value := range pathValues {
+		o.mergeParameter(name, "path", scalarSchema(value))
+	}
+	for name, values := range u.Query() {
+		o.queryCounts[name]++
+		for _, value := range values {
+			o.mergeParameter(name, "query", scalarSchema(value))
+		}
+	}
+
+	if postData := entry.Request.PostData; postData != nil && (postData.Text != "" || len(postData.Params) > 0) {
+		o.bodies++
+		if o.RequestBody == nil {
+			o.RequestBody = &RequestBody{Content: make(map[string]*MediaType)}
+		}
+		mediaType := baseMediaType(postData.MimeType)
+		content := o.RequestBody.Content[mediaType]
+		if content == nil {
+			content = &MediaType{}
+			o.RequestBody.Content[mediaType] = content
+		}
+		content.Schema = MergeSchemas(content.Schema, requestBodySchema(mediaType, postData))
+	}
+
+	response := entry.Response
+	if response == nil || response.Status == 0 {
+		return
+	}
+	status := strconv.Itoa(response.Status)
+	described := o.Responses[status]
+	if described == nil {
+		described = &Response{Description: http.StatusText(response.Status)}
+		if described.Description == "" {
+			described.Description = "Status " + status
+		}
+		o.Responses[status] = described
+	}
+	body := responseBody(response)
+	if body == nil {
+		return
+	}
+	if described.Content == nil {
+		described.Content = make(map[string]*MediaType)
+	}
+	mediaType := baseMediaType(response.Content.MimeType)
+	content := described.Content[mediaType]
+	if content == nil {
+		content = &MediaType{}
+		described.Content[mediaType] = content
+	}
+	if isJSON(mediaType) {
+		content.Schema = MergeSchemas(content.Schema, InferJSONSchema(body))
+	}
+}
+
+// mergeParameter merges the schema of an observed parameter value
+func (o *Operation) mergeParameter(name, in string, schema *Schema) {
+	i := slices.IndexFunc(o.Parameters, func(parameter *Parameter) bool { return parameter.Name == name && parameter.In == in })
+	if i < 0 {
+		o.Parameters = append(o.Parameters, &Parameter{Name: name, In: in, Required: in == "path", Schema: schema})
+		return
+	}
+	o.Parameters[i].Schema = MergeSchemas(o.Parameters[i].Schema, schema)
+}
+
+// finish settles what depends on every sample: required query parameters, required bodies and
+// the ordering of parameters
+func (o *Operation) finish() {
+	for _, parameter := range o.Parameters {
+		if parameter.In == "query" {
+			parameter.Required = o.queryCounts[parameter.Name] == o.samples
+		}
+		parameter.Schema.complete()
+	}
+	slices.SortFunc(o.Parameters, func(a, b *Parameter) int {
+		if a.In != b.In {
+			// path parameters come first
+			return strings.Compare(a.In, b.In)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if o.RequestBody != nil {
+		for _, content := range o.RequestBody.Content {
+			content.Schema.complete()
+		}
+		o.RequestBody.Required = o.bodies == o.samples
+	}
+	for _, response := range o.Responses {
+		for _, content := range response.Content {
+			content.Schema.complete()
+		}
+	}
+	if len(o.Responses) == 0 {
+		o.Responses["default"] = &Response{Description: "No response captured"}
+	}
+}
+
+// matchHost reports whether the URL host matches the host pattern, with its port or without
+func matchHost(pattern string, u *url.URL) bool {
+	return strings.EqualFold(u.Host, pattern) || harParser.MatchHost(pattern, u.Hostname())
+}
+
+// isAPIEntry reports whether an entry is an API call rather than a page or an asset: it
+// exchanges JSON or changes state without a response body
+func isAPIEntry(entry *har.Entry) bool {
+	if entry.Request.PostData != nil && isJSON(entry.Request.PostData.MimeType) {
+		return true
+	}
+	if entry.Response == nil || entry.Response.Content == nil {
+		return false
+	}
+	if isJSON(entry.Response.Content.MimeType) {
+		return true
+	}
+	switch strings.ToUpper(entry.Request.Method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return entry.Response.Content.Size <= 0 && len(entry.Response.Content.Text) == 0
+	}
+	return false
+}
+
+// isJSON reports whether a media type holds JSON, including vendor types such as
+// application/vnd.api+json
+func isJSON(mediaType string) bool {
+	mediaType = baseMediaType(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "/json")
+}
+
+// baseMediaType returns a media type without its parameters
+func baseMediaType(value string) string {
+	if mediaType, _, err := mime.ParseMediaType(value); err == nil {
+		return mediaType
+	}
+	if value == "" {
+		return "application/octet-stream"
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(value, ";")[0]))
+}
+
+// responseBody returns the decoded response body, nil when it was not captured
+func responseBody(response *har.Response) []byte {
+	if response.Content == nil || len(response.Content.Text) == 0 {
+		return nil
+	}
+	if response.Content.Encoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(string(response.Content.Text)); err == nil {
+			return decoded
+		}
+	}
+	return response.Content.Text
+}
+
+// requestBodySchema infers the schema of a request body: JSON documents, or objects of string
+// fields for forms
+func requestBodySchema(mediaType string, postData *har.PostData) *Schema {
+	switch {
+	case isJSON(mediaType):
+		return InferJSONSchema([]byte(postData.Text))
+	case mediaType == "application/x-www-form-urlencoded", mediaType == "multipart/form-data":
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addField := func(name string, field *Schema) {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Required = append(schema.Required, name)
+			}
+			schema.Properties[name] = field
+		}
+		for _, param := range postData.Params {
+			if param.Filename != "" {
+				addField(param.Name, &Schema{Type: "string", Format: "binary"})
+			} else {
+				addField(param.Name, &Schema{Type: "string"})
+			}
+		}
+		if len(postData.Params) == 0 {
+			values, _ := url.ParseQuery(postData.Text)
+			for name := range values {
+				addField(name, &Schema{Type: "string"})
+			}
+		}
+		slices.Sort(schema.Required)
+		return schema
+	}
+	return nil
+}
+
+// scalarSchema infers the schema of a query or path parameter value
+func scalarSchema(value string) *Schema {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &Schema{Type: "integer"}
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return &Schema{Type: "number"}
+	}
+	if value == "true" || value == "false" {
+		return &Schema{Type: "boolean"}
+	}
+	return &Schema{Type: "string", Format: stringFormat(value)}
+}
+
+// templatePath replaces the variable segments of a path by parameters named after the segment
+// preceding them, /users/42/orders/7 becoming /users/{userId}/orders/{orderId}. It returns the
+// template and the values of its parameters.
+func templatePath(escapedPath string) (string, map[string]string) {
+	if escapedPath == "" {
+		escapedPath = "/"
+	}
+	segments := strings.Split(escapedPath, "/")
+	values := make(map[string]string)
+	for i, segment := range segments {
+		if segment == "" || !harParser.IsVariableSegment(segment) {
+			continue
+		}
+		name := "id"
+		if i > 0 && segments[i-1] != "" && !strings.HasPrefix(segments[i-1], "{") {
+			name = identifier(singular(segments[i-1])) + "Id"
+		}
+		unique := name
+		for n := 2; values[unique] != ""; n++ {
+			unique = name + strconv.Itoa(n)
+		}
+		values[unique] = segment
+		segments[i] = "{" + unique + "}"
+	}
+	return strings.Join(segments, "/"), values
+}
+
+// singular strips the plural of a path segment, orders becoming order and categories category
+func singular(segment string) string {
+	switch {
+	case strings.HasSuffix(segment, "ies") && len(segment) > 3:
+		return strings.TrimSuffix(segment, "ies") + "y"
+	case strings.HasSuffix(segment, "s") && !strings.HasSuffix(segment, "ss") && !strings.HasSuffix(segment, "us") && len(segment) > 1:
+		return strings.TrimSuffix(segment, "s")
+	}
+	return segment
+}
+
+// identifier turns a path segment into a lower camel case identifier, order-items becoming
+// orderItems
+func identifier(segment string) string {
+	words := strings.FieldsFunc(segment, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	var b strings.Builder
+	for i, word := range words {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word[:1]) + word[1:])
+		} else {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "resource"
+	}
+	return b.String()
+}
+
+// operationID derives an operation ID from the method and the templated path, GET
+// /users/{userId} becoming getUsersByUserId
+func operationID(method, template string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(template, "/") {
+		if strings.HasPrefix(segment, "{") {
+			segment = "by-" + strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
@@ -0,0 +1,116 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/martian/har"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAPIEntry creates an entry exchanging JSON bodies, reqBody being empty for requests
+// without a body
+func newTestAPIEntry(method, rawURL, reqBody string, status int, respBody string) *har.Entry {
+	entry := &har.Entry{
+		Request: &har.Request{Method: method, URL: rawURL},
+		Response: &har.Response{
+			Status:  status,
+			Content: &har.Content{MimeType: "application/json; charset=utf-8", Text: []byte(respBody), Size: int64(len(respBody))},
+		},
+	}
+	if reqBody != "" {
+		entry.Request.PostData = &har.PostData{MimeType: "application/json", Text: reqBody}
+	}
+	return entry
+}
+
+func newTestHAR(entries ...*har.Entry) *har.HAR {
+	return &har.HAR{Log: &har.Log{Version: "1.2", Entries: entries}}
+}
+
+func TestGenerateGroupsByTemplatedPath(t *testing.T) {
+	harData := newTestHAR(
+		newTestAPIEntry("GET", "https://api.example.com/users/42?expand=orders&page=1", "", 200, `{"id": 42, "name": "Ada"}`),
+		newTestAPIEntry("GET", "https://api.example.com/users/43?expand=orders", "", 200, `{"id": 43, "name": "Grace", "email": "grace@example.com"}`),
+		newTestAPIEntry("GET", "https://api.example.com/users/44", "", 404, `{"error": "not found"}`),
+		newTestAPIEntry("POST", "https://api.example.com/users/42/orders", `{"sku": "A-1", "quantity": 2}`, 201, `{"id": "0b7c2a3e-1d4f-4a6b-9c8d-7e6f5a4b3c2d"}`),
+		newTestAPIEntry("GET", "https://api.example.com/users/42/orders/0b7c2a3e-1d4f-4a6b-9c8d-7e6f5a4b3c2d", "", 200, `{"sku": "A-1"}`),
+	)
+
+	doc := Generate(harData, Options{})
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, DefaultTitle, doc.Info.Title)
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}}, doc.Servers)
+	assert.Len(t, doc.Paths, 3)
+
+	getUser := doc.Paths["/users/{userId}"].Get
+	require.NotNil(t, getUser)
+	assert.Equal(t, "getUsersByUserId", getUser.OperationID)
+	assert.Equal(t, []*Parameter{
+		{Name: "userId", In: "path", Required: true, Schema: &Schema{Type: "integer"}},
+		{Name: "expand", In: "query", Required: false, Schema: &Schema{Type: "string"}},
+		{Name: "page", In: "query", Required: false, Schema: &Schema{Type: "integer"}},
+	}, getUser.Parameters)
+	ok := getUser.Responses["200"]
+	assert.Equal(t, "OK", ok.Description)
+	user := ok.Content["application/json"].Schema
+	assert.Equal(t, []string{"id", "name"}, user.Required)
+	assert.Contains(t, user.Properties, "email")
+	assert.Contains(t, getUser.Responses, "404")
+
+	createOrder := doc.Paths["/users/{userId}/orders"].Post
+	require.NotNil(t, createOrder)
+	require.NotNil(t, createOrder.RequestBody)
+	assert.True(t, createOrder.RequestBody.Required)
+	assert.Equal(t, []string{"quantity", "sku"}, createOrder.RequestBody.Content["application/json"].Schema.Required)
+
+	getOrder := doc.Paths["/users/{userId}/orders/{orderId}"].Get
+	require.NotNil(t, getOrder)
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, getOrder.Parameters[0].Schema)
+}
+
+func TestGenerateSkipsPagesAndAssetsAndFiltersHosts(t *testing.T) {
+	page := newTestAPIEntry("GET", "https://www.example.com/", "", 200, "<html></html>")
+	page.Response.Content.MimeType = "text/html"
+	logout := newTestAPIEntry("POST", "https://www.example.com/logout", "", 204, "")
+	logout.Response.Content.MimeType = ""
+	harData := newTestHAR(
+		page,
+		logout,
+		newTestAPIEntry("GET", "https://api.example.com/status", "", 200, `{"up": true}`),
+		newTestAPIEntry("GET", "https://other.example.com/status", "", 200, `{"up": true, "version": "1.2"}`),
+	)
+
+	doc := Generate(harData, Options{Title: "Example"})
+
+	assert.Equal(t, "Example", doc.Info.Title)
+	assert.Len(t, doc.Servers, 3)
+	assert.NotContains(t, doc.Paths, "/")
+	require.Contains(t, doc.Paths, "/logout")
+	assert.Equal(t, &Response{Description: "No Content"}, doc.Paths["/logout"].Post.Responses["204"])
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}, {URL: "https://other.example.com"}}, doc.Paths["/status"].Servers)
+
+	single := Generate(harData, Options{Host: "api.example.com"})
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}}, single.Servers)
+	require.Len(t, single.Paths, 1)
+	assert.Empty(t, single.Paths["/status"].Servers)
+
+	site := Generate(harData, Options{Host: "site:example.com"})
+	assert.Len(t, site.Servers, 3)
+	globbed := Generate(harData, Options{Host: "*.example.com"})
+	assert.Len(t, globbed.Servers, 3)
+}
+
+func TestGenerateNeverIncludesValues(t *testing.T) {
+	harData := newTestHAR(newTestAPIEntry("POST", "https://api.example.com/login?token=s3cr3t", `{"password": "hunter2"}`, 200, `{"access_token": "eyJhbGciOi"}`))
+
+	data, err := json.Marshal(Generate(harData, Options{}))
+
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.NotContains(t, string(data), "hunter2")
+	assert.NotContains(t, string(data), "eyJhbGciOi")
+	assert.Contains(t, string(data), `"access_token"`)
+}
@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Schema is the subset of the OpenAPI 3.0 schema object inferred from observed values
+type Schema struct {
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	AnyOf      []*Schema          `json:"anyOf,omitempty"`
+}
+
+// InferJSONSchema infers the schema of a JSON document, returning nil when it is not valid JSON
+func InferJSONSchema(data []byte) *Schema {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	return InferSchema(value)
+}
+
+// InferSchema infers the schema of a value decoded from JSON with numbers kept as json.Number.
+// Every property of an object is required, merging schemas relaxes this to the properties
+// present in every sample.
+func InferSchema(value interface{}) *Schema {
+	switch v := value.(type) {
+	case nil:
+		return &Schema{Nullable: true}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &Schema{Type: "integer"}
+		}
+		return &Schema{Type: "number"}
+	case float64:
+		if v == float64(int64(v)) {
+			return &Schema{Type: "integer"}
+		}
+		return &Schema{Type: "number"}
+	case string:
+		return &Schema{Type: "string", Format: stringFormat(v)}
+	case []interface{}:
+		schema := &Schema{Type: "array"}
+		for _, item := range v {
+			schema.Items = MergeSchemas(schema.Items, InferSchema(item))
+		}
+		return schema
+	case map[string]interface{}:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(v))}
+		for name, property := range v {
+			schema.Properties[name] = InferSchema(property)
+			schema.Required = append(schema.Required, name)
+		}
+		slices.Sort(schema.Required)
+		return schema
+	}
+	return &Schema{}
+}
+
+// stringFormat returns the OpenAPI format of a string value, if any
+func stringFormat(value string) string {
+	if uuidPattern.MatchString(value) {
+		return "uuid"
+	}
+	if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse(time.DateOnly, value); err == nil {
+		return "date"
+	}
+	return ""
+}
+
+// MergeSchemas returns a schema accepting the values of both schemas: objects keep the union of
+// their properties but only require the common ones, integers widen to numbers and unrelated
+// types become alternatives. Either schema may be nil.
+func MergeSchemas(a, b *Schema) *Schema {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.Type == "" && a.AnyOf == nil:
+		return withNullable(b, a.Nullable)
+	case b.Type == "" && b.AnyOf == nil:
+		return withNullable(a, b.Nullable)
+	}
+
+	nullable := a.Nullable || b.Nullable
+	alternatives := append(alternativesOf(a), alternativesOf(b)...)
+	var merged []*Schema
+	for _, alternative := range alternatives {
+		i := slices.IndexFunc(merged, func(schema *Schema) bool { return compatibleTypes(schema.Type, alternative.Type) })
+		if i < 0 {
+			merged = append(merged, withNullable(alternative, false))
+			continue
+		}
+		merged[i] = mergeSameType(merged[i], alternative)
+	}
+
+	if len(merged) == 1 {
+		return withNullable(merged[0], nullable)
+	}
+	return &Schema{AnyOf: merged, Nullable: nullable}
+}
+
+// alternativesOf returns the schemas a schema accepts one of
+func alternativesOf(schema *Schema) []*Schema {
+	if schema.AnyOf != nil {
+		return schema.AnyOf
+	}
+	return []*Schema{schema}
+}
+
+// compatibleTypes reports whether values of two types can share a schema
+func compatibleTypes(a, b string) bool {
+	numeric := func(t string) bool { return t == "integer" || t == "number" }
+	return a == b || (numeric(a) && numeric(b))
+}
+
+// mergeSameType merges two schemas of compatible types
+func mergeSameType(a, b *Schema) *Schema {
+	merged := &Schema{Type: a.Type, Nullable: a.Nullable || b.Nullable}
+	switch a.Type {
+	case "integer", "number":
+		if a.Type != b.Type {
+			merged.Type = "number"
+		}
+	case "string":
+		if a.Format == b.Format {
+			merged.Format = a.Format
+		}
+	case "array":
+		merged.Items = MergeSchemas(a.Items, b.Items)
+	case "object":
+		merged.Properties = make(map[string]*Schema, len(a.Properties))
+		for name, property := range a.Properties {
+			merged.Properties[name] = property
+		}
+		for name, property := range b.Properties {
+			merged.Properties[name] = MergeSchemas(merged.Properties[name], property)
+		}
+		for _, name := range a.Required {
+			if slices.Contains(b.Required, name) {
+				merged.Required = append(merged.Required, name)
+			}
+		}
+	}
+	return merged
+}
+
+// withNullable returns a copy of the schema, nullable if either it or nullable is
+func withNullable(schema *Schema, nullable bool) *Schema {
+	copied := *schema
+	copied.Nullable = copied.Nullable || nullable
+	return &copied
+}
+
+// complete fills in what OpenAPI requires but samples may not provide, such as the items of
+// arrays only observed empty
+func (s *Schema) complete() {
+	if s == nil {
+		return
+	}
+	if s.Type == "array" && s.Items == nil {
+		s.Items = &Schema{}
+	}
+	s.Items.complete()
+	for _, property := range s.Properties {
+		property.complete()
+	}
+	for _, alternative := range s.AnyOf {
+		alternative.complete()
+	}
+}
@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferJSONSchema(t *testing.T) {
+	schema := InferJSONSchema([]byte(`{"id": 42, "price": 9.5, "name": "Ada", "id_ref": "0b7c2a3e-1d4f-4a6b-9c8d-7e6f5a4b3c2d", "created": "2023-01-01T10:00:00Z", "tags": [], "manager": null}`))
+
+	require.NotNil(t, schema)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"created", "id", "id_ref", "manager", "name", "price", "tags"}, schema.Required)
+	assert.Equal(t, &Schema{Type: "integer"}, schema.Properties["id"])
+	assert.Equal(t, &Schema{Type: "number"}, schema.Properties["price"])
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, schema.Properties["id_ref"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created"])
+	assert.Equal(t, &Schema{Nullable: true}, schema.Properties["manager"])
+	assert.Equal(t, &Schema{Type: "array"}, schema.Properties["tags"])
+
+	assert.Nil(t, InferJSONSchema([]byte(`{"truncated":`)))
+}
+
+func TestMergeSchemas(t *testing.T) {
+	first := InferJSONSchema([]byte(`{"id": 1, "name": "Ada", "manager": null, "tags": []}`))
+	second := InferJSONSchema([]byte(`{"id": 2.5, "email": "ada@example.com", "manager": {"id": 3}, "tags": ["admin"]}`))
+
+	merged := MergeSchemas(first, second)
+
+	assert.Equal(t, []string{"id", "manager", "tags"}, merged.Required)
+	assert.Len(t, merged.Properties, 5)
+	assert.Equal(t, &Schema{Type: "number"}, merged.Properties["id"])
+	assert.Equal(t, &Schema{Type: "object", Nullable: true, Properties: map[string]*Schema{"id": {Type: "integer"}}, Required: []string{"id"}}, merged.Properties["manager"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, merged.Properties["tags"])
+
+	alternatives := MergeSchemas(&Schema{Type: "string"}, &Schema{Type: "integer"})
+	assert.Equal(t, &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "integer"}}}, alternatives)
+}